@@ -0,0 +1,331 @@
+/*
+ * Copyright 2019 The original author or authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/env"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/avro"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/content"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/liiklus"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/metrics"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/partition"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/transform"
+	"github.com/projectriff/kafka-provisioner/pkg/httpserver"
+	"github.com/projectriff/kafka-provisioner/pkg/k8s"
+	"github.com/projectriff/kafka-provisioner/pkg/logging"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/authz"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func main() {
+	level := slog.LevelInfo
+	if value := os.Getenv("LOG_LEVEL"); value != "" {
+		var err error
+		if level, err = logging.ParseLevel(value); err != nil {
+			log.Fatal(fmt.Errorf("environment variable LOG_LEVEL is invalid: %v", err))
+		}
+	}
+	logger := logging.NewLogging(os.Stderr, level).Logger()
+	slog.SetDefault(logger)
+
+	brokers := env.List("BROKER")
+	if len(brokers) == 0 {
+		log.Fatal("Environment variable BROKER should contain the comma separated host and port of Kafka brokers")
+	}
+
+	redeliveryTimeout, err := env.Duration("REDELIVERY_TIMEOUT", 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	drainTimeout, err := env.Duration("DRAIN_TIMEOUT", gateway.DefaultDrainTimeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	commitStrategy, err := commitStrategy()
+	if err != nil {
+		log.Fatal(err)
+	}
+	autoCommitInterval, err := env.Duration("AUTO_COMMIT_INTERVAL", time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	idempotent, err := env.Bool("PRODUCER_IDEMPOTENCE", false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	transactional, err := env.Bool("TRANSACTIONS", false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	maxPayloadBytes, err := env.Int("MAX_PAYLOAD_BYTES")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compression, streamCompression, err := compression()
+	if err != nil {
+		log.Fatal(err)
+	}
+	streamPartitioners, err := env.ConfigEntries("STREAM_PARTITIONERS")
+	if err != nil {
+		log.Fatal(err)
+	}
+	partitioners := make(map[string]string)
+	for topic, value := range streamPartitioners {
+		partitioners[topic] = *value
+	}
+	partitioner, err := partition.NewRegistry().Constructor(partitioners)
+	if err != nil {
+		log.Fatalf("Environment variable STREAM_PARTITIONERS is invalid: %v", err)
+	}
+
+	tuning, err := client.TuningFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// checked first, so that features the cluster lacks are reported as such rather than as protocol errors
+	apiVersions, err := client.ProbeAPIVersions(brokers, tuning)
+	if err != nil {
+		log.Fatalf("Error connecting to Kafka brokers %v: %v", brokers, err)
+	}
+	if err := apiVersions.Require(requiredFeatures(idempotent, transactional, compression, streamCompression)...); err != nil {
+		log.Fatal(err)
+	}
+	if !apiVersions.Supports(client.FeatureGroupDeletion) {
+		logger.Warn("Consumer groups can't be deleted", "error", &client.UnsupportedFeatureError{Feature: client.FeatureGroupDeletion})
+	}
+
+	server, err := gateway.NewServer(brokers, gateway.ProducerOptions{
+		Idempotent:        idempotent,
+		Transactional:     transactional,
+		MaxPayloadBytes:   maxPayloadBytes,
+		Compression:       compression,
+		StreamCompression: streamCompression,
+		Partitioner:       partitioner,
+		Tuning:            tuning,
+	}, logger)
+	if err != nil {
+		log.Fatalf("Error connecting to Kafka brokers %v: %v", brokers, err)
+	}
+	defer server.Close()
+	if path := os.Getenv("TRANSFORMS_CONFIG"); path != "" {
+		if server.Transforms, err = transform.LoadHooks(context.Background(), path); err != nil {
+			log.Fatal(err)
+		}
+		defer server.Transforms.Close(context.Background())
+	}
+	if path := os.Getenv("MESSAGE_TYPES_CONFIG"); path != "" {
+		if server.Messages, err = content.LoadMessages(path); err != nil {
+			log.Fatal(err)
+		}
+	}
+	server.Streams = &gateway.StreamMetadata{Consumer: server.Consumer, RetryInterval: 30 * time.Second, Logger: logger}
+	go server.Streams.Run(context.Background())
+	if registryURL := os.Getenv("SCHEMA_REGISTRY_URL"); registryURL != "" {
+		server.Avro = avro.NewSerializer(&avro.Registry{URL: registryURL, Client: &http.Client{Timeout: 10 * time.Second}})
+		// streams may choose the subject naming strategy of their schema when provisioned
+		server.Avro.Subjects = server.Streams.SchemaSubject
+	}
+	server.Metrics = metrics.NewMetrics()
+	server.APIVersions = apiVersions
+	if server.Authorization, err = authorization(); err != nil {
+		log.Fatal(err)
+	}
+	server.RedeliveryTimeout = redeliveryTimeout
+	server.DrainTimeout = drainTimeout
+	server.CommitStrategy = commitStrategy
+	server.AutoCommitInterval = autoCommitInterval
+
+	listener, err := net.Listen("tcp", ":6565")
+	if err != nil {
+		log.Fatal(err)
+	}
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	if (certFile == "") != (keyFile == "") {
+		log.Fatal("Environment variables TLS_CERT_FILE and TLS_KEY_FILE should both point to the certificate and key the gateway serves, or both be unset")
+	}
+	options := []grpc.ServerOption{grpc.UnaryInterceptor(server.UnaryInterceptor), grpc.StreamInterceptor(server.StreamInterceptor)}
+	if certFile != "" {
+		serverCredentials, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+		if err != nil {
+			log.Fatalf("Error loading the TLS certificate and key: %v", err)
+		}
+		options = append(options, grpc.Creds(serverCredentials))
+	}
+	if maxPayloadBytes > 0 {
+		// let requests through for the gateway to reject them with guidance
+		options = append(options, grpc.MaxRecvMsgSize(client.MaxMessageBytes(maxPayloadBytes)))
+	}
+	grpcServer := grpc.NewServer(options...)
+	liiklus.RegisterLiiklusServiceServer(grpcServer, server)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", server.Metrics.Handler())
+	mux.Handle("/", server)
+	httpServer, err := httpserver.New(":8080", mux)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go func() {
+		logger.Info("Serving the HTTP API", "address", httpServer.Addr, "tls", certFile != "")
+		var err error
+		if certFile != "" {
+			err = httpServer.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// on scale down and rolling updates, drain the partitions of the subscriptions so that the replicas
+	// taking them over don't deliver again the records acknowledged meanwhile
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		<-signals
+		logger.Info("Shutting down")
+		ctx, cancel := context.WithTimeout(context.Background(), drainTimeout+5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			logger.Warn("Subscriptions did not end in time", "error", err)
+		}
+		_ = httpServer.Shutdown(ctx)
+		grpcServer.GracefulStop()
+	}()
+	logger.Info("Serving the liiklus API", "address", listener.Addr().String(), "tls", certFile != "")
+	if err := grpcServer.Serve(listener); err != nil {
+		logger.Error("Error serving the liiklus API", "error", err)
+	}
+}
+
+// requiredFeatures returns the features of Kafka the configuration of the gateway relies on
+func requiredFeatures(idempotent, transactional bool, compression gateway.Compression, streamCompression map[string]gateway.Compression) []client.Feature {
+	var features []client.Feature
+	if idempotent {
+		features = append(features, client.FeatureIdempotence)
+	}
+	if transactional {
+		features = append(features, client.FeatureTransactions)
+	}
+	zstd := compression.Codec == sarama.CompressionZSTD
+	for _, c := range streamCompression {
+		zstd = zstd || c.Codec == sarama.CompressionZSTD
+	}
+	if zstd {
+		features = append(features, client.FeatureZstd)
+	}
+	return features
+}
+
+// commitStrategy reads the commit strategy of subscriptions not choosing one
+func commitStrategy() (liiklus.SubscribeRequest_CommitStrategy, error) {
+	switch value := os.Getenv("COMMIT_STRATEGY"); value {
+	case "", "auto":
+		return liiklus.SubscribeRequest_AUTO, nil
+	case "on-ack":
+		return liiklus.SubscribeRequest_ON_ACK, nil
+	case "manual":
+		return liiklus.SubscribeRequest_MANUAL, nil
+	default:
+		return 0, fmt.Errorf("environment variable COMMIT_STRATEGY should be one of auto, on-ack or manual, got %q", value)
+	}
+}
+
+// authorization reads how callers are authorized, nil when they are not
+func authorization() (*gateway.Authorization, error) {
+	cacheTTL, err := env.Duration("AUTHORIZATION_CACHE_TTL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	tenantIsolation, err := env.Bool("TENANT_ISOLATION", true)
+	if err != nil {
+		return nil, err
+	}
+	crossNamespaceAccess := make(map[string][]string)
+	for _, entry := range env.List("CROSS_NAMESPACE_ACCESS") {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("environment variable CROSS_NAMESPACE_ACCESS should be a comma separated list of <caller-namespace>=<stream-namespace>, got %q", entry)
+		}
+		crossNamespaceAccess[parts[0]] = append(crossNamespaceAccess[parts[0]], parts[1])
+	}
+
+	authorization := &gateway.Authorization{CacheTTL: cacheTTL, TenantIsolation: tenantIsolation, CrossNamespaceAccess: crossNamespaceAccess}
+	switch mode := os.Getenv("AUTHORIZATION_MODE"); mode {
+	case "", "none":
+		return nil, nil
+	case "kubernetes":
+		kubernetesClient, err := k8s.NewInClusterClient()
+		if err != nil {
+			return nil, fmt.Errorf("Kubernetes authorization requires running in a cluster: %v", err)
+		}
+		authorization.Authorizer = authz.NewKubernetesAuthorizer(kubernetesClient)
+	case "static":
+		path := os.Getenv("STATIC_TOKENS_FILE")
+		if path == "" {
+			return nil, fmt.Errorf("static authorization requires environment variable STATIC_TOKENS_FILE")
+		}
+		tokens, err := authz.LoadStaticTokens(path)
+		if err != nil {
+			return nil, err
+		}
+		authorization.Authorizer = authz.NewStaticAuthorizer(tokens)
+	default:
+		return nil, fmt.Errorf("environment variable AUTHORIZATION_MODE should be one of none, kubernetes or static, got %q", mode)
+	}
+	return authorization, nil
+}
+
+// compression reads the compression of all topics, and the topics compressed differently
+func compression() (gateway.Compression, map[string]gateway.Compression, error) {
+	compression := gateway.DefaultCompression
+	if value := os.Getenv("COMPRESSION"); value != "" {
+		var err error
+		if compression, err = gateway.ParseCompression(value); err != nil {
+			return compression, nil, fmt.Errorf("environment variable COMPRESSION is invalid: %v", err)
+		}
+	}
+	entries, err := env.ConfigEntries("STREAM_COMPRESSION")
+	if err != nil {
+		return compression, nil, err
+	}
+	streamCompression := make(map[string]gateway.Compression)
+	for topic, value := range entries {
+		if streamCompression[topic], err = gateway.ParseCompression(*value); err != nil {
+			return compression, nil, fmt.Errorf("environment variable STREAM_COMPRESSION is invalid for topic %s: %v", topic, err)
+		}
+	}
+	return compression, streamCompression, nil
+}
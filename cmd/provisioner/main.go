@@ -17,50 +17,1297 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/env"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/avro"
+	"github.com/projectriff/kafka-provisioner/pkg/httpserver"
+	"github.com/projectriff/kafka-provisioner/pkg/k8s"
+	"github.com/projectriff/kafka-provisioner/pkg/logging"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/absence"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/authz"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/breaker"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/clusterlink"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/controller"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/election"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/events"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/failover"
 	"github.com/projectriff/kafka-provisioner/pkg/provisioner/handler"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/health"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/history"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/jetstream"
 	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/limiter"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/memory"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/metrics"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/migration"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/policy"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/pubsub"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/pulsar"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/quota"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 func main() {
-	gateway := os.Getenv("GATEWAY")
-	if gateway == "" {
-		log.Fatal("Environment variable GATEWAY should contain the host and port of a liiklus gRPC endpoint")
+	logs, err := newLogging()
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger := logs.Logger()
+	slog.SetDefault(logger)
+
+	switch backend := os.Getenv("BACKEND"); backend {
+	case "", "kafka":
+	case "pulsar":
+		log.Fatal(servePulsar(logs, logger))
+	case "jetstream":
+		log.Fatal(serveJetStream(logs, logger))
+	case "memory":
+		log.Fatal(serveMemory(logs, logger))
+	case "pubsub":
+		log.Fatal(servePubSub(logs, logger))
+	default:
+		log.Fatalf("Environment variable BACKEND should be one of kafka, pulsar, jetstream, pubsub or memory, got %q", backend)
+	}
+
+	gateways := env.List("GATEWAY")
+	if len(gateways) == 0 {
+		log.Fatal("Environment variable GATEWAY should contain the host and port of a liiklus gRPC endpoint, or a comma separated list of them")
+	}
+	gatewayTLS, err := env.Bool("GATEWAY_TLS", false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	gatewayHTTP := os.Getenv("GATEWAY_HTTP")
+	if gatewayHTTP != "" && !strings.HasPrefix(gatewayHTTP, "http://") && !strings.HasPrefix(gatewayHTTP, "https://") {
+		log.Fatal("Environment variable GATEWAY_HTTP should be the http:// or https:// base URL of the HTTP API of the gateway")
 	}
 	broker := os.Getenv("BROKER")
 	if broker == "" {
 		log.Fatal("Environment variable BROKER should contain the host and port of a Kafka broker")
 	}
 
-	sarama.Logger = log.New(os.Stdout, "[Sarama] ", log.LstdFlags)
+	replication, err := replicationPolicy()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	maxTopics, err := env.Int("NAMESPACE_MAX_TOPICS")
+	if err != nil {
+		log.Fatal(err)
+	}
+	maxPartitions, err := env.Int("NAMESPACE_MAX_PARTITIONS")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	budget, err := partitionBudget()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rules, err := validationRules()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	maxPayloadBytes, err := env.Int("MAX_PAYLOAD_BYTES")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	retryAfter, err := env.Duration("RETRY_AFTER", 5*time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	repartitionRetention, err := env.Duration("REPARTITION_RETENTION", handler.DefaultRepartitionRetention)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	kafkaBreaker, err := circuitBreaker(logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tuning, err := client.TuningFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	brokerDefaults, err := topicDefaults()
+	if err != nil {
+		log.Fatal(err)
+	}
+	requiredFeatures := []client.Feature{client.FeatureTopicConfigs}
+	if brokerDefaults {
+		requiredFeatures = append(requiredFeatures, client.FeatureBrokerDefaults)
+	}
+	// the provisioner starts while Kafka is unavailable, checking the versions it supports when it can
+	if apiVersions, err := client.ProbeAPIVersions([]string{broker}, tuning); err != nil {
+		logger.Warn("Error checking the versions of the APIs of the Kafka cluster", "broker", broker, "error", err)
+	} else if err := apiVersions.Require(requiredFeatures...); err != nil {
+		log.Fatal(err)
+	}
+	requestTimeout, err := requestTimeout(tuning.AdminTimeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	adminConcurrency, err := env.Int("KAFKA_ADMIN_CONCURRENCY")
+	if err != nil {
+		log.Fatal(err)
+	}
+	adminLimiter := limiter.New(adminConcurrency)
+	adminMutationRate, err := env.Int("KAFKA_ADMIN_MUTATION_RATE")
+	if err != nil {
+		log.Fatal(err)
+	}
+	adminLimiter.Mutations = limiter.NewThrottle(adminMutationRate)
+	absentTopicsTTL, err := env.Duration("ABSENT_TOPICS_TTL", 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	absentTopics := absence.New(absentTopicsTTL)
+
+	metricsRefreshInterval, err := env.Duration("METRICS_REFRESH_INTERVAL", time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	provisionerMetrics := metrics.NewMetrics()
+	refresher := &metrics.NamespaceUsageRefresher{
+		Metrics:  provisionerMetrics,
+		Interval: metricsRefreshInterval,
+		ListTopics: func() (map[string]client.TopicSpec, error) {
+			kafkaClient, err := client.NewKafkaClient(broker, tuning)
+			if err != nil {
+				return nil, err
+			}
+			defer kafkaClient.Close()
+			return adminLimiter.WrapKafkaClient(kafkaClient).ListTopics()
+		},
+		Logger: logger,
+	}
+	go refresher.Run(context.Background())
+	prober, brokerHealthInterval, err := brokerProber(broker, tuning, provisionerMetrics, logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go prober.Run(context.Background(), brokerHealthInterval)
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		exporter, err := otlpExporter(endpoint, provisionerMetrics, logger)
+		if err != nil {
+			log.Fatal(err)
+		}
+		go exporter.Run(context.Background())
+	}
+
+	template := handler.TopicCreationRequestHandler{
+		Gateway:              gateways[0],
+		GatewayTLS:           gatewayTLS,
+		GatewayHTTP:          gatewayHTTP,
+		Logger:               logger,
+		Replication:          replication,
+		Quota:                quota.Limits{MaxTopics: maxTopics, MaxPartitions: maxPartitions},
+		PartitionBudget:      budget,
+		Rules:                rules,
+		MaxPayloadBytes:      maxPayloadBytes,
+		BrokerDefaults:       brokerDefaults,
+		RetryAfter:           retryAfter,
+		Backoff:              kafkaBackoff(kafkaBreaker, adminLimiter),
+		RepartitionRetention: repartitionRetention,
+	}
+	if strings.Contains(gateways[0], "{{") {
+		if len(gateways) > 1 {
+			log.Fatal("Environment variable GATEWAY should either be the template of the address of the gateway of each namespace, or a list of endpoints")
+		}
+		if template.GatewayTemplate, err = handler.ParseGatewayTemplate(gateways[0]); err != nil {
+			log.Fatalf("Environment variable GATEWAY is not a valid template: %v", err)
+		}
+	}
+	if len(gateways) > 1 {
+		selector, interval, err := gatewaySelector(gateways, provisionerMetrics, logger)
+		if err != nil {
+			log.Fatal(err)
+		}
+		template.GatewaySelector = selector
+		go selector.Run(context.Background(), interval)
+	}
+	if policyURL := os.Getenv("POLICY_URL"); policyURL != "" {
+		template.Policy = policy.NewOPAEvaluator(policyURL, &http.Client{Timeout: 10 * time.Second})
+	}
+	if template.Subjects, template.HardDeleteSubjects, err = subjectCleanup(); err != nil {
+		log.Fatal(err)
+	}
+	if template.Archive, err = archivePolicy(); err != nil {
+		log.Fatal(err)
+	}
+	if template.Operations, err = operationHistory(); err != nil {
+		log.Fatal(err)
+	}
+	if template.Journal, err = env.Bool("PROVISIONING_JOURNAL", false); err != nil {
+		log.Fatal(err)
+	}
+	if template.ReadOnly, err = env.Bool("READ_ONLY", false); err != nil {
+		log.Fatal(err)
+	}
+	if template.ETags, template.RequireIfMatch, err = conditionalUpdates(); err != nil {
+		log.Fatal(err)
+	}
+	if template.Secondary, err = secondaryRegion(tuning); err != nil {
+		log.Fatal(err)
+	}
+	if template.Mirrors, err = clusterLink(); err != nil {
+		log.Fatal(err)
+	}
+	if template.Backends, err = namedBackends(retryAfter, logger); err != nil {
+		log.Fatal(err)
+	}
+	// singletons are the loops that run on the elected replica only when several replicas run
+	var singletons []func(ctx context.Context)
+	// standbys leave archived topics and migrations to the provisioner of the cluster theirs is replicated from
+	if template.Archive.Enabled() && !template.ReadOnly {
+		singletons = append(singletons, func(ctx context.Context) {
+			deleteArchived(ctx, broker, tuning, kafkaBreaker, adminLimiter, template)
+		})
+	}
+	migrator := newMigrator(broker, tuning, maxPayloadBytes, logger)
+	template.Migrator = migrator
+	publisher, err := eventPublisher(broker, tuning, logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if publisher != nil {
+		template.Events = publisher
+		go publisher.Run(context.Background())
+	}
+	if !template.ReadOnly {
+		go syncMigrations(context.Background(), broker, tuning, kafkaBreaker, migrator, logger)
+	}
+	reconcileInterval, repairDrift, err := reconcileMode()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if repairDrift && template.ReadOnly {
+		log.Fatal("Environment variable RECONCILE_MODE=repair can't be set with READ_ONLY")
+	}
+	if template.Journal {
+		singletons = append(singletons, func(ctx context.Context) {
+			recoverJournal(ctx, broker, tuning, kafkaBreaker, adminLimiter, absentTopics, template, repairDrift, provisionerMetrics)
+		})
+	}
+	if reconcileInterval > 0 {
+		singletons = append(singletons, func(ctx context.Context) {
+			reconcile(ctx, broker, tuning, kafkaBreaker, adminLimiter, template, reconcileInterval, repairDrift, provisionerMetrics)
+		})
+	}
+	if template.Authorizer, err = authorizer(); err != nil {
+		log.Fatal(err)
+	}
+	controllerInterval, provisionProcessors, err := streamControllerMode()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if principal := os.Getenv("TRANSACTIONAL_ID_PRINCIPAL"); principal != "" {
+		if !provisionProcessors {
+			log.Fatal("Environment variable TRANSACTIONAL_ID_PRINCIPAL requires PROCESSOR_CONTROLLER to be set")
+		}
+		if template.TransactionalIDPrincipal, err = handler.ParseTransactionalIDPrincipal(principal); err != nil {
+			log.Fatalf("Environment variable TRANSACTIONAL_ID_PRINCIPAL is not a valid template: %v", err)
+		}
+	}
+	if controllerInterval > 0 && template.ReadOnly {
+		log.Fatal("Environment variable STREAM_CONTROLLER can't be set with READ_ONLY")
+	}
+	if controllerInterval > 0 {
+		kubernetesClient, err := k8s.NewInClusterClient()
+		if err != nil {
+			log.Fatalf("The stream controller requires running in a cluster: %v", err)
+		}
+		streamController := &controller.Controller{
+			Client: kubernetesClient,
+			Deprovisioner: controller.DeprovisionerFunc(func(ctx context.Context, namespace, stream string) error {
+				return withRequestHandler(broker, tuning, kafkaBreaker, adminLimiter, absentTopics, template, func(requestHandler *handler.TopicCreationRequestHandler) error {
+					return requestHandler.DeprovisionStream(ctx, namespace, stream)
+				})
+			}),
+			Addresser: controller.AddresserFunc(func(ctx context.Context) (map[string]controller.Address, error) {
+				addresses := map[string]controller.Address{}
+				err := withRequestHandler(broker, tuning, kafkaBreaker, adminLimiter, absentTopics, template, func(requestHandler *handler.TopicCreationRequestHandler) error {
+					streamAddresses, err := requestHandler.StreamAddresses()
+					for name, address := range streamAddresses {
+						addresses[name] = controller.Address{Topic: address.Topic, Gateway: address.Gateway, Partitions: address.Partitions}
+					}
+					return err
+				})
+				return addresses, err
+			}),
+			Provider: os.Getenv("STREAM_PROVIDER"),
+			Metrics:  provisionerMetrics,
+			Logger:   logger,
+		}
+		if provisionProcessors {
+			streamController.Provisioner = controller.ProvisionerFunc(func(ctx context.Context, namespace string, streams []string) error {
+				return withRequestHandler(broker, tuning, kafkaBreaker, adminLimiter, absentTopics, template, func(requestHandler *handler.TopicCreationRequestHandler) error {
+					return requestHandler.ProvisionStreams(namespace, streams)
+				})
+			})
+			if template.TransactionalIDPrincipal != nil {
+				streamController.Reserver = controller.ReserverFunc(func(ctx context.Context, namespace, processor string) (string, error) {
+					var prefix string
+					err := withRequestHandler(broker, tuning, kafkaBreaker, adminLimiter, absentTopics, template, func(requestHandler *handler.TopicCreationRequestHandler) error {
+						var err error
+						prefix, err = requestHandler.ReserveTransactionalIDs(namespace, processor)
+						return err
+					})
+					return prefix, err
+				})
+			}
+		}
+		singletons = append(singletons, func(ctx context.Context) {
+			streamController.Run(ctx, controllerInterval)
+		})
+	}
+	if err := runSingletons(singletons, logger); err != nil {
+		log.Fatal(err)
+	}
 
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPut {
-			w.WriteHeader(http.StatusMethodNotAllowed)
+	http.Handle("/metrics", provisionerMetrics.Handler())
+	http.Handle("/log-level", template.AuthorizeClusterWide(logs.Handler()))
+	http.Handle(health.ReadyPath, prober.Handler())
+	// capabilities are known without connecting to Kafka
+	http.Handle(handler.CapabilitiesPath, template.GetHandlerFunc())
+	http.Handle(handler.OperationsPath, template.GetHandlerFunc())
+	var provision http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut && r.Method != http.MethodGet && r.Method != http.MethodDelete && r.Method != http.MethodPost {
+			handler.WriteError(w, &handler.StatusError{Status: http.StatusMethodNotAllowed, Message: fmt.Sprintf("Method %s is not allowed", r.Method)})
+			return
+		}
+		if _, ok := template.Backends[handler.ChosenBackend(r)]; ok {
+			// streams of other backends are provisioned without connecting to Kafka
+			template.GetHandlerFunc()(w, r)
 			return
 		}
-		handleProvisionRequest(broker, gateway, w, r)
+		handleProvisionRequest(broker, tuning, kafkaBreaker, adminLimiter, absentTopics, template, w, r)
+	})
+	if requestTimeout > 0 {
+		provision = http.TimeoutHandler(provision, requestTimeout, handler.ErrorBody(handler.CodeTimeout, fmt.Sprintf("Provisioning took longer than %v", requestTimeout)))
+	}
+	provision = template.Operations.Instrument(provision, func(err error) {
+		logger.Error("Error persisting provisioning operation", "error", err)
 	})
-	_ = http.ListenAndServe(":8080", nil)
+	http.Handle("/", provisionerMetrics.InstrumentProvisioning(provision))
+	httpServer, err := httpserver.New(":8080", provisionerMetrics.InstrumentRoutes(http.DefaultServeMux, route))
+	if err != nil {
+		log.Fatal(err)
+	}
+	_ = httpServer.ListenAndServe()
 }
 
-func handleProvisionRequest(broker, gateway string, writer http.ResponseWriter, request *http.Request) {
-	kafkaClient, err := client.NewKafkaClient(broker)
+// servePulsar serves the provisioning of streams on Pulsar, the APIs managing Kafka topics being left out
+func servePulsar(logs *logging.Logging, logger *slog.Logger) error {
+	retryAfter, err := env.Duration("RETRY_AFTER", 5*time.Second)
+	if err != nil {
+		return err
+	}
+	backend, err := pulsarBackend(retryAfter, logger)
 	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
-		_, _ = fmt.Fprintf(os.Stderr, "Error connecting to Kafka broker %q: %v\n", broker, err)
-		_, _ = fmt.Fprintf(writer, "Error connecting to Kafka broker %q: %v\n", broker, err)
+		return err
+	}
+	return serveBackend(&handler.TopicCreationRequestHandler{Backend: backend, Logger: logger, RetryAfter: retryAfter}, logs)
+}
+
+// serveJetStream serves the provisioning of streams on NATS JetStream, the APIs managing Kafka topics being left out
+func serveJetStream(logs *logging.Logging, logger *slog.Logger) error {
+	retryAfter, err := env.Duration("RETRY_AFTER", 5*time.Second)
+	if err != nil {
+		return err
+	}
+	backend, err := jetStreamBackend(retryAfter, logger)
+	if err != nil {
+		return err
+	}
+	return serveBackend(&handler.TopicCreationRequestHandler{Backend: backend, Logger: logger, RetryAfter: retryAfter}, logs)
+}
+
+// serveMemory serves the provisioning of streams held in memory, and their records on port 8081
+func serveMemory(logs *logging.Logging, logger *slog.Logger) error {
+	maxRecords, err := env.Int("MEMORY_MAX_RECORDS")
+	if err != nil {
+		return err
+	}
+	gateway := os.Getenv("MEMORY_GATEWAY")
+	if gateway == "" {
+		gateway = "localhost:8081"
+	}
+	backend := memory.NewBackend(maxRecords, logger)
+	dataServer, err := httpserver.New(":8081", backend)
+	if err != nil {
+		return err
+	}
+	go func() {
+		log.Fatal(dataServer.ListenAndServe())
+	}()
+	return serveBackend(&handler.TopicCreationRequestHandler{
+		Backend:     backend,
+		Gateway:     gateway,
+		GatewayHTTP: "http://" + gateway,
+		Logger:      logger,
+	}, logs)
+}
+
+// servePubSub serves the provisioning of streams on Google Cloud Pub/Sub, the APIs managing Kafka topics being left
+// out
+func servePubSub(logs *logging.Logging, logger *slog.Logger) error {
+	retryAfter, err := env.Duration("RETRY_AFTER", 5*time.Second)
+	if err != nil {
+		return err
+	}
+	backend, err := pubSubBackend(retryAfter, logger)
+	if err != nil {
+		return err
+	}
+	return serveBackend(&handler.TopicCreationRequestHandler{Backend: backend, Logger: logger, RetryAfter: retryAfter}, logs)
+}
+
+// namedBackends returns the backends of BACKENDS, which requests may choose rather than Kafka, each allowed to the
+// namespaces of <NAME>_NAMESPACES
+func namedBackends(retryAfter time.Duration, logger *slog.Logger) (map[string]handler.NamedBackend, error) {
+	names := env.List("BACKENDS")
+	if len(names) == 0 {
+		return nil, nil
+	}
+	backends := map[string]handler.NamedBackend{}
+	for _, name := range names {
+		var backend handler.Backend
+		var err error
+		switch name {
+		case "pulsar":
+			backend, err = pulsarBackend(retryAfter, logger)
+		case "jetstream":
+			backend, err = jetStreamBackend(retryAfter, logger)
+		case "pubsub":
+			backend, err = pubSubBackend(retryAfter, logger)
+		default:
+			return nil, fmt.Errorf("environment variable BACKENDS should list some of pulsar, jetstream or pubsub, got %q", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		namespacesVariable := strings.ToUpper(name) + "_NAMESPACES"
+		namespaces := env.List(namespacesVariable)
+		if len(namespaces) == 0 {
+			return nil, fmt.Errorf("environment variable %s should list the namespaces allowed to choose the %s backend, or be *", namespacesVariable, name)
+		}
+		backends[name] = handler.NamedBackend{Backend: backend, Namespaces: namespaces}
+	}
+	return backends, nil
+}
+
+// pulsarBackend configures the Pulsar backend from the PULSAR_ variables
+func pulsarBackend(retryAfter time.Duration, logger *slog.Logger) (*pulsar.Backend, error) {
+	adminURL, serviceURL := os.Getenv("PULSAR_ADMIN_URL"), os.Getenv("PULSAR_SERVICE_URL")
+	if adminURL == "" || serviceURL == "" {
+		return nil, fmt.Errorf("environment variables PULSAR_ADMIN_URL and PULSAR_SERVICE_URL should be set for the pulsar backend")
+	}
+	tenant := os.Getenv("PULSAR_TENANT")
+	if tenant == "" {
+		tenant = "riff"
+	}
+	clusters := env.List("PULSAR_CLUSTERS")
+	if len(clusters) == 0 {
+		clusters = []string{"standalone"}
+	}
+	partitions, err := env.Int("PULSAR_PARTITIONS")
+	if err != nil {
+		return nil, err
+	}
+	return &pulsar.Backend{
+		AdminURL:   adminURL,
+		ServiceURL: serviceURL,
+		Token:      os.Getenv("PULSAR_TOKEN"),
+		Tenant:     tenant,
+		Clusters:   clusters,
+		Partitions: partitions,
+		RetryAfter: retryAfter,
+		Client:     &http.Client{Timeout: 30 * time.Second},
+		Logger:     logger,
+	}, nil
+}
+
+// jetStreamBackend configures the JetStream backend from the JETSTREAM_ variables
+func jetStreamBackend(retryAfter time.Duration, logger *slog.Logger) (*jetstream.Backend, error) {
+	natsURL := os.Getenv("JETSTREAM_URL")
+	if natsURL == "" {
+		return nil, fmt.Errorf("environment variable JETSTREAM_URL should be set for the jetstream backend")
+	}
+	replicas, err := env.Int("JETSTREAM_REPLICAS")
+	if err != nil {
+		return nil, err
+	}
+	maxAge, err := env.Duration("JETSTREAM_MAX_AGE", 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	maxBytes, err := env.Int("JETSTREAM_MAX_BYTES")
+	if err != nil {
+		return nil, err
+	}
+	subjectPrefix, ok := os.LookupEnv("JETSTREAM_SUBJECT_PREFIX")
+	if !ok {
+		subjectPrefix = "riff"
+	}
+	return &jetstream.Backend{
+		URL:           natsURL,
+		Gateway:       os.Getenv("JETSTREAM_GATEWAY"),
+		Token:         os.Getenv("JETSTREAM_TOKEN"),
+		SubjectPrefix: subjectPrefix,
+		Replicas:      replicas,
+		MaxAge:        maxAge,
+		MaxBytes:      int64(maxBytes),
+		RetryAfter:    retryAfter,
+		Logger:        logger,
+	}, nil
+}
+
+// pubSubBackend authenticates requests with the application default credentials, unless PUBSUB_EMULATOR_HOST points
+// to an emulator
+func pubSubBackend(retryAfter time.Duration, logger *slog.Logger) (*pubsub.Backend, error) {
+	retention, err := env.Duration("PUBSUB_RETENTION", 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	ackDeadline, err := env.Duration("PUBSUB_ACK_DEADLINE", 0)
+	if err != nil {
+		return nil, err
+	}
+	topicPrefix, ok := os.LookupEnv("PUBSUB_TOPIC_PREFIX")
+	if !ok {
+		topicPrefix = "riff_"
+	}
+	backend := &pubsub.Backend{
+		Project:     os.Getenv("PUBSUB_PROJECT"),
+		Gateway:     "pubsub.googleapis.com:443",
+		TopicPrefix: topicPrefix,
+		Retention:   retention,
+		AckDeadline: ackDeadline,
+		RetryAfter:  retryAfter,
+		Logger:      logger,
+	}
+	if emulator := os.Getenv("PUBSUB_EMULATOR_HOST"); emulator != "" {
+		backend.Endpoint, backend.Gateway = "http://"+emulator, emulator
+		backend.Client = &http.Client{Timeout: 30 * time.Second}
+	} else {
+		credentials, err := google.FindDefaultCredentials(context.Background(), "https://www.googleapis.com/auth/pubsub")
+		if err != nil {
+			return nil, fmt.Errorf("error finding the application default credentials: %v", err)
+		}
+		if backend.Project == "" {
+			backend.Project = credentials.ProjectID
+		}
+		backend.Client = oauth2.NewClient(context.Background(), credentials.TokenSource)
+		backend.Client.Timeout = 30 * time.Second
+	}
+	if backend.Project == "" {
+		return nil, fmt.Errorf("environment variable PUBSUB_PROJECT should be set when the credentials don't tell the project")
+	}
+	return backend, nil
+}
+
+// serveBackend serves the provisioning of streams on a backend other than Kafka
+func serveBackend(requestHandler *handler.TopicCreationRequestHandler, logs *logging.Logging) error {
+	var err error
+	if requestHandler.Authorizer, err = authorizer(); err != nil {
+		return err
+	}
+	if requestHandler.ReadOnly, err = env.Bool("READ_ONLY", false); err != nil {
+		return err
+	}
+	provisionerMetrics := metrics.NewMetrics()
+	http.Handle("/metrics", provisionerMetrics.Handler())
+	http.Handle("/log-level", requestHandler.AuthorizeClusterWide(logs.Handler()))
+	http.Handle("/", provisionerMetrics.InstrumentProvisioning(requestHandler.GetHandlerFunc()))
+	httpServer, err := httpserver.New(":8080", provisionerMetrics.InstrumentRoutes(http.DefaultServeMux, route))
+	if err != nil {
+		return err
+	}
+	return httpServer.ListenAndServe()
+}
+
+// route returns the route of a request the HTTP metrics are labeled with, the pattern it was registered with but for
+// the API, whose routes are told apart by the handler
+func route(request *http.Request) string {
+	if _, pattern := http.DefaultServeMux.Handler(request); pattern != "/" && pattern != "" {
+		return pattern
+	}
+	return handler.Route(request.URL.Path)
+}
+
+// authorizer returns the authorizer of AUTHORIZATION_MODE, nil when requests aren't authorized
+func authorizer() (authz.Authorizer, error) {
+	switch mode := os.Getenv("AUTHORIZATION_MODE"); mode {
+	case "", "none":
+		return nil, nil
+	case "kubernetes":
+		kubernetesClient, err := k8s.NewInClusterClient()
+		if err != nil {
+			return nil, fmt.Errorf("kubernetes authorization requires running in a cluster: %v", err)
+		}
+		return authz.NewKubernetesAuthorizer(kubernetesClient), nil
+	default:
+		return nil, fmt.Errorf("environment variable AUTHORIZATION_MODE should be one of none or kubernetes, got %q", mode)
+	}
+}
+
+// kafkaBackoff tells how long until Kafka is expected to take requests again: until the circuit breaker lets a probe
+// through while it is open, or until the admin mutations already throttled are let through
+func kafkaBackoff(kafkaBreaker *breaker.Breaker, adminLimiter *limiter.Limiter) func() time.Duration {
+	return func() time.Duration {
+		return max(kafkaBreaker.RetryAfter(), adminLimiter.Mutations.Backlog())
+	}
+}
+
+func handleProvisionRequest(broker string, tuning client.Tuning, kafkaBreaker *breaker.Breaker, adminLimiter *limiter.Limiter, absentTopics *absence.Cache, template handler.TopicCreationRequestHandler, writer http.ResponseWriter, request *http.Request) {
+	if err := kafkaBreaker.Allow(); err != nil {
+		retryAfter := err.(*breaker.OpenError).RetryAfter
+		handler.WriteError(writer, &handler.StatusError{Status: http.StatusServiceUnavailable, RetryAfter: retryAfter, Message: fmt.Sprintf("Error connecting to Kafka broker %q: %v", broker, err)})
+		return
+	}
+	kafkaClient, err := client.NewKafkaClient(broker, tuning)
+	kafkaBreaker.Record(err)
+	if err != nil {
+		statusError := &handler.StatusError{Status: http.StatusInternalServerError, Message: fmt.Sprintf("Error connecting to Kafka broker %q: %v", broker, err)}
+		if client.Classify(err) == client.Retryable {
+			// the breaker may just have opened
+			statusError.Status, statusError.RetryAfter = http.StatusServiceUnavailable, max(template.RetryAfter, template.Backoff())
+		}
+		template.Logger.Error("Error connecting to Kafka broker", "broker", broker, "error", err)
+		handler.WriteError(writer, statusError)
 		return
 	}
 	defer func() {
 		if err := kafkaClient.Close(); err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "Error disconnecting from Kafka broker %q: %v\n", broker, err)
+			template.Logger.Error("Error disconnecting from Kafka broker", "broker", broker, "error", err)
 		}
 	}()
-	requestHandler := &handler.TopicCreationRequestHandler{KafkaClient: kafkaClient, Gateway: gateway, Writer: os.Stderr}
+	requestHandler := template
+	requestHandler.KafkaClient = absentTopics.WrapKafkaClient(kafkaBreaker.WrapKafkaClient(adminLimiter.WrapKafkaClient(kafkaClient)))
 	requestHandler.GetHandlerFunc()(writer, request)
 }
+
+// requestTimeout reads how long provisioning requests may take overall, unbounded when zero, leaving room for admin
+// operations to complete
+func requestTimeout(adminTimeout time.Duration) (time.Duration, error) {
+	if adminTimeout == 0 {
+		adminTimeout = sarama.NewConfig().Admin.Timeout
+	}
+	requestTimeout, err := env.Duration("REQUEST_TIMEOUT", 0)
+	if err != nil {
+		return 0, err
+	}
+	if requestTimeout < 0 || (requestTimeout > 0 && requestTimeout <= adminTimeout) {
+		return 0, fmt.Errorf("environment variable REQUEST_TIMEOUT should be longer than the Kafka admin timeout (%v), got %v", adminTimeout, requestTimeout)
+	}
+	return requestTimeout, nil
+}
+
+// archivePolicy reads how long the topics of deleted streams are kept, and how long their records are retained
+func archivePolicy() (handler.ArchivePolicy, error) {
+	gracePeriod, err := env.Duration("ARCHIVE_GRACE_PERIOD", 0)
+	if err != nil {
+		return handler.ArchivePolicy{}, err
+	}
+	retention, err := env.Duration("ARCHIVE_RETENTION", time.Hour)
+	if err != nil {
+		return handler.ArchivePolicy{}, err
+	}
+	if gracePeriod < 0 || retention <= 0 {
+		return handler.ArchivePolicy{}, fmt.Errorf("environment variables ARCHIVE_GRACE_PERIOD and ARCHIVE_RETENTION should be positive durations")
+	}
+	return handler.ArchivePolicy{GracePeriod: gracePeriod, Retention: retention}, nil
+}
+
+// conditionalUpdates reads whether the ETags of streams are returned and checked, and whether If-Match is required
+// to change their metadata: none, optional or required, none by default
+func conditionalUpdates() (etags, requireIfMatch bool, err error) {
+	switch mode := os.Getenv("CONDITIONAL_UPDATES"); mode {
+	case "", "none":
+		return false, false, nil
+	case "optional":
+		return true, false, nil
+	case "required":
+		return true, true, nil
+	default:
+		return false, false, fmt.Errorf("environment variable CONDITIONAL_UPDATES should be none, optional or required, got %q", mode)
+	}
+}
+
+// operationHistory reads how many recent provisioning operations are kept, 100 by default, and the file they are
+// persisted in, if any
+func operationHistory() (*history.History, error) {
+	size, err := env.Int("OPERATIONS_HISTORY_SIZE")
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("environment variable OPERATIONS_HISTORY_SIZE should be a positive number of operations")
+	}
+	if size == 0 {
+		size = 100
+	}
+	if path := os.Getenv("OPERATIONS_HISTORY_FILE"); path != "" {
+		return history.Open(size, path)
+	}
+	return history.New(size), nil
+}
+
+// deleteArchived deletes the topics of archived streams once their grace period is over, checking every minute
+// until ctx is done
+func deleteArchived(ctx context.Context, broker string, tuning client.Tuning, kafkaBreaker *breaker.Breaker, adminLimiter *limiter.Limiter, template handler.TopicCreationRequestHandler) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if kafkaBreaker.Allow() != nil {
+			continue
+		}
+		kafkaClient, err := client.NewKafkaClient(broker, tuning)
+		kafkaBreaker.Record(err)
+		if err != nil {
+			template.Logger.Error("Error connecting to Kafka broker to delete archived topics", "broker", broker, "error", err)
+			continue
+		}
+		requestHandler := template
+		requestHandler.KafkaClient = kafkaBreaker.WrapKafkaClient(adminLimiter.WrapKafkaClient(kafkaClient))
+		if err := requestHandler.DeleteArchived(ctx); err != nil {
+			template.Logger.Error("Error deleting archived topics", "error", err)
+		}
+		_ = kafkaClient.Close()
+	}
+}
+
+// reconcileMode reads how often the topics of streams are checked for drift from the spec they were provisioned with,
+// never when zero, and whether the drift is reverted or only reported
+func reconcileMode() (time.Duration, bool, error) {
+	interval, err := env.Duration("RECONCILE_INTERVAL", 0)
+	if err != nil {
+		return 0, false, err
+	}
+	if interval < 0 {
+		return 0, false, fmt.Errorf("environment variable RECONCILE_INTERVAL should be a positive duration, got %v", interval)
+	}
+	switch mode := os.Getenv("RECONCILE_MODE"); mode {
+	case "", "report":
+		return interval, false, nil
+	case "repair":
+		return interval, true, nil
+	default:
+		return 0, false, fmt.Errorf("environment variable RECONCILE_MODE should be one of report or repair, got %q", mode)
+	}
+}
+
+// reconcile checks the topics of streams for drift from the spec they were provisioned with at each interval until
+// ctx is done, reverting their config when repairing
+func reconcile(ctx context.Context, broker string, tuning client.Tuning, kafkaBreaker *breaker.Breaker, adminLimiter *limiter.Limiter, template handler.TopicCreationRequestHandler, interval time.Duration, repair bool, provisionerMetrics *metrics.Metrics) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if kafkaBreaker.Allow() != nil {
+			continue
+		}
+		kafkaClient, err := client.NewKafkaClient(broker, tuning)
+		kafkaBreaker.Record(err)
+		if err != nil {
+			template.Logger.Error("Error connecting to Kafka broker to reconcile topics", "broker", broker, "error", err)
+			continue
+		}
+		requestHandler := template
+		requestHandler.KafkaClient = kafkaBreaker.WrapKafkaClient(adminLimiter.WrapKafkaClient(kafkaClient))
+		if drifted, err := requestHandler.Reconcile(repair); err != nil {
+			template.Logger.Error("Error reconciling topics", "error", err)
+		} else {
+			provisionerMetrics.SetDriftedTopics(drifted)
+		}
+		_ = kafkaClient.Close()
+	}
+}
+
+// recoverJournal replays the provisioning journal once the replica is elected, retrying every minute until Kafka is
+// reachable or ctx is done, reporting the topics that disappeared while the provisioner was down, then reconciles the
+// topics owned against the spec they were provisioned with right away rather than at the next interval
+func recoverJournal(ctx context.Context, broker string, tuning client.Tuning, kafkaBreaker *breaker.Breaker, adminLimiter *limiter.Limiter, absentTopics *absence.Cache, template handler.TopicCreationRequestHandler, repair bool, provisionerMetrics *metrics.Metrics) {
+	for {
+		err := withRequestHandler(broker, tuning, kafkaBreaker, adminLimiter, absentTopics, template, func(requestHandler *handler.TopicCreationRequestHandler) error {
+			recovery, err := requestHandler.RecoverJournal()
+			if err != nil {
+				return err
+			}
+			provisionerMetrics.SetJournalRecovery(recovery.Owned, len(recovery.Disappeared))
+			drifted, err := requestHandler.Reconcile(repair)
+			if err != nil {
+				template.Logger.Error("Error reconciling topics after recovering the journal", "error", err)
+				return nil
+			}
+			provisionerMetrics.SetDriftedTopics(drifted)
+			return nil
+		})
+		if err == nil {
+			return
+		}
+		template.Logger.Error("Error recovering the provisioning journal", "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Minute):
+		}
+	}
+}
+
+// streamControllerMode reads how often the stream controller checks the streams of the cluster, the controller
+// being off unless STREAM_CONTROLLER is set, and whether it provisions the streams of processors
+func streamControllerMode() (time.Duration, bool, error) {
+	enabled, err := env.Bool("STREAM_CONTROLLER", false)
+	if err != nil {
+		return 0, false, err
+	}
+	provisionProcessors, err := env.Bool("PROCESSOR_CONTROLLER", false)
+	if err != nil {
+		return 0, false, err
+	}
+	if !enabled {
+		if provisionProcessors {
+			return 0, false, fmt.Errorf("environment variable PROCESSOR_CONTROLLER requires STREAM_CONTROLLER to be set")
+		}
+		return 0, false, nil
+	}
+	interval, err := env.Duration("STREAM_CONTROLLER_INTERVAL", 30*time.Second)
+	if err != nil {
+		return 0, false, err
+	}
+	if interval <= 0 {
+		return 0, false, fmt.Errorf("environment variable STREAM_CONTROLLER_INTERVAL should be a positive duration, got %v", interval)
+	}
+	return interval, provisionProcessors, nil
+}
+
+// runSingletons runs the loops that should only run on one replica, on the replica elected by holding a lease
+// when LEADER_ELECTION is set, or right away otherwise
+func runSingletons(singletons []func(ctx context.Context), logger *slog.Logger) error {
+	lead := func(ctx context.Context) {
+		var wg sync.WaitGroup
+		for _, singleton := range singletons {
+			wg.Add(1)
+			go func(singleton func(ctx context.Context)) {
+				defer wg.Done()
+				singleton(ctx)
+			}(singleton)
+		}
+		wg.Wait()
+	}
+	enabled, err := env.Bool("LEADER_ELECTION", false)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		go lead(context.Background())
+		return nil
+	}
+	kubernetesClient, err := k8s.NewInClusterClient()
+	if err != nil {
+		return fmt.Errorf("leader election requires running in a cluster: %v", err)
+	}
+	namespace := os.Getenv("LEADER_ELECTION_NAMESPACE")
+	if namespace == "" {
+		if namespace, err = k8s.InClusterNamespace(); err != nil {
+			return fmt.Errorf("error reading the namespace of the pod, set LEADER_ELECTION_NAMESPACE: %v", err)
+		}
+	}
+	lease := os.Getenv("LEADER_ELECTION_LEASE")
+	if lease == "" {
+		lease = "kafka-provisioner"
+	}
+	identity := os.Getenv("POD_NAME")
+	if identity == "" {
+		if identity, err = os.Hostname(); err != nil {
+			return err
+		}
+	}
+	go election.New(kubernetesClient, namespace, lease, identity, logger).Run(context.Background(), lead)
+	return nil
+}
+
+// withRequestHandler calls f with a copy of the template handler connected to Kafka, on behalf of the stream
+// controller
+func withRequestHandler(broker string, tuning client.Tuning, kafkaBreaker *breaker.Breaker, adminLimiter *limiter.Limiter, absentTopics *absence.Cache, template handler.TopicCreationRequestHandler, f func(*handler.TopicCreationRequestHandler) error) error {
+	if err := kafkaBreaker.Allow(); err != nil {
+		return err
+	}
+	kafkaClient, err := client.NewKafkaClient(broker, tuning)
+	kafkaBreaker.Record(err)
+	if err != nil {
+		return fmt.Errorf("error connecting to Kafka broker %q: %v", broker, err)
+	}
+	defer func() {
+		_ = kafkaClient.Close()
+	}()
+	requestHandler := template
+	requestHandler.KafkaClient = absentTopics.WrapKafkaClient(kafkaBreaker.WrapKafkaClient(adminLimiter.WrapKafkaClient(kafkaClient)))
+	return f(&requestHandler)
+}
+
+// newMigrator creates the migrator copying the records of renamed streams, producing records as large as those
+// the gateway accepts
+func newMigrator(broker string, tuning client.Tuning, maxPayloadBytes int, logger *slog.Logger) *migration.Migrator {
+	config := sarama.NewConfig()
+	config.Version = sarama.V0_11_0_0
+	config.ClientID = "kafka-provisioner"
+	tuning.Apply(config)
+	return &migration.Migrator{
+		NewConsumerGroup: func(groupID string) (sarama.ConsumerGroup, error) {
+			groupConfig := *config
+			groupConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+			// records of aborted transactions were never part of the stream
+			groupConfig.Consumer.IsolationLevel = sarama.ReadCommitted
+			return sarama.NewConsumerGroup([]string{broker}, groupID, &groupConfig)
+		},
+		NewProducer: func() (sarama.SyncProducer, error) {
+			producerConfig := *config
+			producerConfig.Producer.Return.Successes = true
+			producerConfig.Producer.RequiredAcks = sarama.WaitForAll
+			producerConfig.Producer.Partitioner = sarama.NewManualPartitioner
+			if maxPayloadBytes > 0 {
+				producerConfig.Producer.MaxMessageBytes = client.MaxMessageBytes(maxPayloadBytes)
+			}
+			return sarama.NewSyncProducer([]string{broker}, &producerConfig)
+		},
+		RetryInterval: 5 * time.Second,
+		Logger:        logger,
+	}
+}
+
+// syncMigrations copies the records of the streams being migrated, as recorded in their metadata, checking when
+// starting and every minute after until ctx is done, so that copies resume after restarts and stop once the
+// streams migrated are deleted
+func syncMigrations(ctx context.Context, broker string, tuning client.Tuning, kafkaBreaker *breaker.Breaker, migrator *migration.Migrator, logger *slog.Logger) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		if kafkaBreaker.Allow() == nil {
+			kafkaClient, err := client.NewKafkaClient(broker, tuning)
+			kafkaBreaker.Record(err)
+			if err != nil {
+				logger.Error("Error connecting to Kafka broker to sync stream migrations", "broker", broker, "error", err)
+			} else {
+				recorded, err := kafkaBreaker.WrapKafkaClient(kafkaClient).ListMetadata()
+				if err != nil {
+					logger.Error("Error reading stream migrations", "error", err)
+				} else {
+					migrator.Sync(handler.Migrations(recorded))
+				}
+				_ = kafkaClient.Close()
+			}
+		}
+		select {
+		case <-ctx.Done():
+			migrator.Stop()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// eventPublisher creates the publisher of the events of streams to the URL of EVENT_SINK, or the Kafka topic of
+// EVENT_TOPIC, returning nil when neither is set
+func eventPublisher(broker string, tuning client.Tuning, logger *slog.Logger) (*events.Publisher, error) {
+	sinkURL, topic := os.Getenv("EVENT_SINK"), os.Getenv("EVENT_TOPIC")
+	var sink events.Sink
+	switch {
+	case sinkURL != "" && topic != "":
+		return nil, fmt.Errorf("environment variables EVENT_SINK and EVENT_TOPIC are mutually exclusive")
+	case sinkURL != "":
+		sink = &events.HTTPSink{URL: sinkURL, Client: &http.Client{Timeout: 10 * time.Second}}
+	case topic != "":
+		if err := validation.ValidateTopicName(topic); err != nil {
+			return nil, fmt.Errorf("invalid EVENT_TOPIC: %v", err)
+		}
+		config := sarama.NewConfig()
+		config.Version = sarama.V0_11_0_0
+		config.ClientID = "kafka-provisioner"
+		config.Producer.Return.Successes = true
+		config.Producer.RequiredAcks = sarama.WaitForAll
+		tuning.Apply(config)
+		sink = &events.KafkaSink{Topic: topic, NewProducer: func() (sarama.SyncProducer, error) {
+			return sarama.NewSyncProducer([]string{broker}, config)
+		}}
+	default:
+		return nil, nil
+	}
+	source := os.Getenv("EVENT_SOURCE")
+	if source == "" {
+		source = "/kafka-provisioner"
+	}
+	size, err := env.Int("EVENT_QUEUE_SIZE")
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("environment variable EVENT_QUEUE_SIZE should be positive, got %d", size)
+	}
+	if size == 0 {
+		size = 1000
+	}
+	return events.NewPublisher(sink, source, size, logger), nil
+}
+
+// subjectCleanup reads how the schema subjects of deleted streams are cleaned up, returning a nil deleter when
+// they are left alone
+func subjectCleanup() (handler.SubjectDeleter, bool, error) {
+	registryURL := os.Getenv("SCHEMA_REGISTRY_URL")
+	switch cleanup := os.Getenv("SCHEMA_SUBJECT_CLEANUP"); cleanup {
+	case "", "none":
+		return nil, false, nil
+	case "soft", "hard":
+		if registryURL == "" {
+			return nil, false, fmt.Errorf("environment variable SCHEMA_SUBJECT_CLEANUP requires SCHEMA_REGISTRY_URL to be set")
+		}
+		return &avro.Registry{URL: registryURL, Client: &http.Client{Timeout: 10 * time.Second}}, cleanup == "hard", nil
+	default:
+		return nil, false, fmt.Errorf("environment variable SCHEMA_SUBJECT_CLEANUP should be one of none, soft or hard, got %q", cleanup)
+	}
+}
+
+// circuitBreaker reads the settings of the breaker failing requests fast during Kafka outages
+func circuitBreaker(logger *slog.Logger) (*breaker.Breaker, error) {
+	threshold, err := env.Int("CIRCUIT_BREAKER_THRESHOLD")
+	if err != nil {
+		return nil, err
+	}
+	cooldown, err := env.Duration("CIRCUIT_BREAKER_COOLDOWN", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return breaker.New(threshold, cooldown, logger), nil
+}
+
+// gatewaySelector reads how often the endpoints of the gateway are health-checked, and how long checks may take
+func gatewaySelector(gateways []string, provisionerMetrics *metrics.Metrics, logger *slog.Logger) (*failover.Selector, time.Duration, error) {
+	interval, err := env.Duration("GATEWAY_HEALTH_INTERVAL", 10*time.Second)
+	if err != nil {
+		return nil, 0, err
+	}
+	timeout, err := env.Duration("GATEWAY_HEALTH_TIMEOUT", 2*time.Second)
+	if err != nil {
+		return nil, 0, err
+	}
+	if interval <= 0 || timeout <= 0 {
+		return nil, 0, fmt.Errorf("environment variables GATEWAY_HEALTH_INTERVAL and GATEWAY_HEALTH_TIMEOUT should be positive durations")
+	}
+	selector := failover.New(gateways, timeout, logger)
+	selector.Metrics = provisionerMetrics
+	return selector, interval, nil
+}
+
+// brokerProber reads how often the brokers are probed in the background for the readiness of the provisioner and the
+// broker_up metric
+func brokerProber(broker string, tuning client.Tuning, provisionerMetrics *metrics.Metrics, logger *slog.Logger) (*health.Prober, time.Duration, error) {
+	interval, err := env.Duration("BROKER_HEALTH_INTERVAL", 10*time.Second)
+	if err != nil {
+		return nil, 0, err
+	}
+	if interval <= 0 {
+		return nil, 0, fmt.Errorf("environment variable BROKER_HEALTH_INTERVAL should be a positive duration, got %v", interval)
+	}
+	// probes bypass the circuit breaker, so that the brokers recovering show while it is open
+	prober := health.New(func() error {
+		kafkaClient, err := client.NewKafkaClient(broker, tuning)
+		if err != nil {
+			return err
+		}
+		defer kafkaClient.Close()
+		_, err = kafkaClient.Brokers()
+		return err
+	}, logger)
+	prober.Metrics = provisionerMetrics
+	return prober, interval, nil
+}
+
+// newLogging reads the initial log settings, which can later be changed at runtime
+func newLogging() (*logging.Logging, error) {
+	level := slog.LevelInfo
+	if value := os.Getenv("LOG_LEVEL"); value != "" {
+		var err error
+		if level, err = logging.ParseLevel(value); err != nil {
+			return nil, fmt.Errorf("environment variable LOG_LEVEL is invalid: %v", err)
+		}
+	}
+	saramaLogging := true
+	if value := os.Getenv("SARAMA_LOGGING"); value != "" {
+		var err error
+		if saramaLogging, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("environment variable SARAMA_LOGGING should be a boolean: %v", err)
+		}
+	}
+	logs := logging.NewLogging(os.Stderr, level)
+	logs.SetSaramaLogging(saramaLogging)
+	return logs, nil
+}
+
+// otlpExporter reads the settings of the OTLP push of metrics, following the OpenTelemetry SDK conventions
+func otlpExporter(endpoint string, provisionerMetrics *metrics.Metrics, logger *slog.Logger) (*metrics.OTLPExporter, error) {
+	intervalMillis, err := env.Int("OTEL_METRIC_EXPORT_INTERVAL")
+	if err != nil {
+		return nil, err
+	}
+	interval := time.Duration(intervalMillis) * time.Millisecond
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = "kafka-provisioner"
+	}
+	return &metrics.OTLPExporter{
+		Endpoint:    endpoint,
+		Interval:    interval,
+		ServiceName: serviceName,
+		Gatherer:    provisionerMetrics.Registry,
+		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
+		Logger:      logger,
+	}, nil
+}
+
+// topicDefaults tells whether TOPIC_DEFAULTS lets the broker choose the partitions and replication factor of the topics
+// of streams, rather than the provisioner creating them with a single partition and replica
+func topicDefaults() (bool, error) {
+	switch mode := os.Getenv("TOPIC_DEFAULTS"); mode {
+	case "", "provisioner":
+		return false, nil
+	case "broker":
+		return true, nil
+	default:
+		return false, fmt.Errorf("environment variable TOPIC_DEFAULTS should be provisioner or broker, got %q", mode)
+	}
+}
+
+// validationRules reads the restrictions on topic specs, shared with the admission webhook
+func validationRules() (validation.Rules, error) {
+	maxPartitions, err := env.Int("MAX_PARTITIONS")
+	if err != nil {
+		return validation.Rules{}, err
+	}
+	return validation.Rules{
+		MaxPartitions:  int32(maxPartitions),
+		AllowedConfigs: env.List("ALLOWED_TOPIC_CONFIGS"),
+	}, nil
+}
+
+// partitionBudget reads the optional cluster-wide partition budget
+func partitionBudget() (quota.ClusterBudget, error) {
+	maxPartitions, err := env.Int("CLUSTER_MAX_PARTITIONS")
+	if err != nil {
+		return quota.ClusterBudget{}, err
+	}
+	budget := quota.ClusterBudget{MaxPartitions: maxPartitions, WarnRatio: 0.9}
+	if value := os.Getenv("CLUSTER_PARTITION_WARN_RATIO"); value != "" {
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return quota.ClusterBudget{}, fmt.Errorf("environment variable CLUSTER_PARTITION_WARN_RATIO should be a number between 0 and 1, got %q", value)
+		}
+		budget.WarnRatio = ratio
+	}
+	switch mode := os.Getenv("CLUSTER_PARTITION_BUDGET_MODE"); mode {
+	case "", "enforce":
+	case "warn":
+		budget.WarnOnly = true
+	default:
+		return quota.ClusterBudget{}, fmt.Errorf("environment variable CLUSTER_PARTITION_BUDGET_MODE should be one of enforce or warn, got %q", mode)
+	}
+	return budget, nil
+}
+
+// secondaryRegion reads the passive region streams are provisioned in as well, nil when SECONDARY_BROKER is unset
+func secondaryRegion(tuning client.Tuning) (*handler.SecondaryRegion, error) {
+	broker := os.Getenv("SECONDARY_BROKER")
+	if broker == "" {
+		return nil, nil
+	}
+	gateway := os.Getenv("SECONDARY_GATEWAY")
+	if gateway == "" {
+		return nil, fmt.Errorf("environment variable SECONDARY_GATEWAY should be set with SECONDARY_BROKER")
+	}
+	region := &handler.SecondaryRegion{
+		Name:        os.Getenv("SECONDARY_REGION"),
+		PrimaryName: os.Getenv("REGION"),
+		Gateway:     gateway,
+		Connect: func() (client.KafkaClient, error) {
+			return client.NewKafkaClient(broker, tuning)
+		},
+	}
+	if region.Name == "" {
+		region.Name = "secondary"
+	}
+	if region.PrimaryName == "" {
+		region.PrimaryName = "primary"
+	}
+	if region.Name == region.PrimaryName {
+		return nil, fmt.Errorf("environment variables REGION and SECONDARY_REGION should name different regions, got %q", region.Name)
+	}
+	return region, nil
+}
+
+// clusterLink reads the Confluent cluster link streams may be mirrored with, nil when CLUSTER_LINK_NAME is unset
+func clusterLink() (handler.MirrorManager, error) {
+	link := os.Getenv("CLUSTER_LINK_NAME")
+	if link == "" {
+		return nil, nil
+	}
+	restURL, clusterID := os.Getenv("CLUSTER_LINK_REST_URL"), os.Getenv("CLUSTER_LINK_CLUSTER_ID")
+	if restURL == "" || clusterID == "" {
+		return nil, fmt.Errorf("environment variables CLUSTER_LINK_REST_URL and CLUSTER_LINK_CLUSTER_ID should be set with CLUSTER_LINK_NAME")
+	}
+	return &clusterlink.Client{
+		URL:       restURL,
+		ClusterID: clusterID,
+		Link:      link,
+		APIKey:    os.Getenv("CLUSTER_LINK_API_KEY"),
+		APISecret: os.Getenv("CLUSTER_LINK_API_SECRET"),
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// replicationPolicy reads the optional MirrorMaker 2 settings, returning nil when replication is not configured
+func replicationPolicy() (*handler.ReplicationPolicy, error) {
+	alias := os.Getenv("REPLICATION_SOURCE_ALIAS")
+	if alias == "" {
+		return nil, nil
+	}
+	configEntries, err := env.ConfigEntries("REPLICATION_TOPIC_CONFIG")
+	if err != nil {
+		return nil, err
+	}
+	return &handler.ReplicationPolicy{
+		SourceClusterAlias: alias,
+		Separator:          os.Getenv("REPLICATION_SEPARATOR"),
+		ConfigEntries:      configEntries,
+	}, nil
+}
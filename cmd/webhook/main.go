@@ -0,0 +1,63 @@
+/*
+ * Copyright 2019 The original author or authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"github.com/projectriff/kafka-provisioner/pkg/env"
+	"github.com/projectriff/kafka-provisioner/pkg/httpserver"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+	"github.com/projectriff/kafka-provisioner/pkg/webhook"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+)
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	certFile := os.Getenv("TLS_CERT_FILE")
+	keyFile := os.Getenv("TLS_KEY_FILE")
+	if certFile == "" || keyFile == "" {
+		log.Fatal("Environment variables TLS_CERT_FILE and TLS_KEY_FILE should point to the certificate and key the webhook serves")
+	}
+	maxPartitions, err := env.Int("MAX_PARTITIONS")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	validator := &webhook.StreamValidator{
+		Rules: validation.Rules{
+			MaxPartitions:  int32(maxPartitions),
+			AllowedConfigs: env.List("ALLOWED_TOPIC_CONFIGS"),
+		},
+		Logger: logger,
+	}
+	httpServer, err := httpserver.New(":8443", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	http.HandleFunc("/validate-streams", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		validator.GetHandlerFunc()(w, r)
+	})
+	log.Fatal(httpServer.ListenAndServeTLS(certFile, keyFile))
+}
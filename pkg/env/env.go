@@ -0,0 +1,78 @@
+// Package env reads the optional settings shared by the binaries of this repository from environment variables
+package env
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Int reads an optional integer environment variable, returning 0 when unset
+func Int(name string) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("environment variable %s should be an integer: %v", name, err)
+	}
+	return i, nil
+}
+
+// Duration reads an optional duration environment variable (e.g. 30s), returning defaultValue when unset
+func Duration(name string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("environment variable %s should be a duration: %v", name, err)
+	}
+	return d, nil
+}
+
+// Bool reads an optional boolean environment variable, returning defaultValue when unset
+func Bool(name string, defaultValue bool) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("environment variable %s should be a boolean: %v", name, err)
+	}
+	return b, nil
+}
+
+// List reads an optional comma separated list, returning nil when unset
+func List(name string) []string {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ConfigEntries reads optional topic configs of the form key1=value1,key2=value2
+func ConfigEntries(name string) (map[string]*string, error) {
+	configEntries := make(map[string]*string)
+	for _, entry := range List(name) {
+		keyValue := strings.SplitN(entry, "=", 2)
+		if len(keyValue) != 2 || keyValue[0] == "" {
+			return nil, fmt.Errorf("environment variable %s is invalid: config entry %q should be of the form key=value", name, entry)
+		}
+		configValue := keyValue[1]
+		configEntries[keyValue[0]] = &configValue
+	}
+	return configEntries, nil
+}
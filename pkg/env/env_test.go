@@ -0,0 +1,78 @@
+package env_test
+
+import (
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/env"
+)
+
+var _ = Describe("Environment variables", func() {
+
+	const name = "KAFKA_PROVISIONER_TEST_VARIABLE"
+
+	AfterEach(func() {
+		Expect(os.Unsetenv(name)).To(Succeed())
+	})
+
+	It("defaults integers to 0", func() {
+		Expect(env.Int(name)).To(Equal(0))
+	})
+
+	It("rejects invalid integers", func() {
+		Expect(os.Setenv(name, "ten")).To(Succeed())
+
+		_, err := env.Int(name)
+
+		Expect(err).To(MatchError(ContainSubstring(name + " should be an integer")))
+	})
+
+	It("parses durations", func() {
+		Expect(env.Duration(name, time.Minute)).To(Equal(time.Minute))
+
+		Expect(os.Setenv(name, "30s")).To(Succeed())
+		Expect(env.Duration(name, time.Minute)).To(Equal(30 * time.Second))
+
+		Expect(os.Setenv(name, "30")).To(Succeed())
+		_, err := env.Duration(name, time.Minute)
+		Expect(err).To(MatchError(ContainSubstring(name + " should be a duration")))
+	})
+
+	It("parses booleans", func() {
+		Expect(env.Bool(name, true)).To(BeTrue())
+
+		Expect(os.Setenv(name, "false")).To(Succeed())
+		Expect(env.Bool(name, true)).To(BeFalse())
+
+		Expect(os.Setenv(name, "no")).To(Succeed())
+		_, err := env.Bool(name, true)
+		Expect(err).To(MatchError(ContainSubstring(name + " should be a boolean")))
+	})
+
+	It("splits and trims lists", func() {
+		Expect(os.Setenv(name, "a, b,,c")).To(Succeed())
+
+		Expect(env.List(name)).To(Equal([]string{"a", "b", "c"}))
+	})
+
+	It("parses topic configs", func() {
+		Expect(os.Setenv(name, "retention.ms=1000,cleanup.policy=compact")).To(Succeed())
+
+		configEntries, err := env.ConfigEntries(name)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(configEntries).To(HaveLen(2))
+		Expect(*configEntries["retention.ms"]).To(Equal("1000"))
+		Expect(*configEntries["cleanup.policy"]).To(Equal("compact"))
+	})
+
+	It("rejects malformed topic configs", func() {
+		Expect(os.Setenv(name, "retention.ms")).To(Succeed())
+
+		_, err := env.ConfigEntries(name)
+
+		Expect(err).To(MatchError(ContainSubstring(`config entry "retention.ms" should be of the form key=value`)))
+	})
+})
@@ -0,0 +1,270 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/liiklus"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/authz"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// PublishVerb is the verb callers need to be granted on the streams of a namespace to publish to them
+	PublishVerb = "publish"
+	// SubscribeVerb is the verb callers need to be granted on the streams of a namespace to consume them
+	SubscribeVerb = "subscribe"
+	// ManageVerb is the verb callers need to be granted on the streams of a namespace to delete or rewind the
+	// consumer groups of their subscribers
+	ManageVerb = "manage"
+)
+
+// Authorization requires callers to present a bearer token allowed to publish to or subscribe to the streams of
+// the namespaces of the topics they call the gateway on
+type Authorization struct {
+	Authorizer authz.Authorizer
+	// CacheTTL is how long decisions are reused for, rather than reviewing the token of each call. Decisions
+	// are not cached when zero.
+	CacheTTL time.Duration
+	// TenantIsolation restricts callers whose identity belongs to a namespace to the streams of that namespace,
+	// whatever they are otherwise allowed
+	TenantIsolation bool
+	// CrossNamespaceAccess maps namespaces to the other namespaces their callers may access the streams of
+	// despite tenant isolation, AllNamespaces standing for any namespace
+	CrossNamespaceAccess map[string][]string
+
+	m         sync.Mutex
+	decisions map[decisionKey]cachedDecision
+}
+
+type decisionKey struct {
+	token, namespace, verb string
+}
+
+type cachedDecision struct {
+	decision authz.Decision
+	expiry   time.Time
+}
+
+// maxCachedDecisions bounds the cache, expired decisions being evicted when reached
+const maxCachedDecisions = 10000
+
+func (a *Authorization) authorize(token string, namespace string, verb string) (authz.Decision, error) {
+	key := decisionKey{token: token, namespace: namespace, verb: verb}
+	now := time.Now()
+	a.m.Lock()
+	cached, ok := a.decisions[key]
+	a.m.Unlock()
+	if ok && now.Before(cached.expiry) {
+		return cached.decision, nil
+	}
+	decision, err := a.Authorizer.Authorize(token, namespace, verb)
+	if err != nil || a.CacheTTL <= 0 {
+		return decision, err
+	}
+	a.m.Lock()
+	defer a.m.Unlock()
+	if a.decisions == nil {
+		a.decisions = make(map[decisionKey]cachedDecision)
+	}
+	if len(a.decisions) >= maxCachedDecisions {
+		for k, d := range a.decisions {
+			if !now.Before(d.expiry) {
+				delete(a.decisions, k)
+			}
+		}
+		if len(a.decisions) >= maxCachedDecisions {
+			a.decisions = make(map[decisionKey]cachedDecision)
+		}
+	}
+	a.decisions[key] = cachedDecision{decision: decision, expiry: now.Add(a.CacheTTL)}
+	return decision, nil
+}
+
+// access is the verb a call needs on the stream of a topic
+type access struct {
+	verb, topic string
+}
+
+// check returns the gRPC status of a call with a token needing accesses
+func (a *Authorization) check(token string, accesses []access) error {
+	if token == "" {
+		return status.Error(codes.Unauthenticated, "calls should carry a bearer token in their authorization metadata")
+	}
+	for _, access := range accesses {
+		namespace := topicNamespace(access.topic)
+		if namespace == "" {
+			return status.Errorf(codes.PermissionDenied, "topic %q is not the topic of a stream", access.topic)
+		}
+		decision, err := a.authorize(token, namespace, access.verb)
+		if err != nil {
+			return status.Errorf(codes.Unavailable, "error authorizing call on namespace %q: %v", namespace, err)
+		}
+		if !decision.Authenticated {
+			return status.Errorf(codes.Unauthenticated, "invalid bearer token: %s", decision.Reason)
+		}
+		if !decision.Allowed {
+			return status.Errorf(codes.PermissionDenied, "forbidden: %s", decision.Reason)
+		}
+		if !a.tenantAllows(decision.Namespace, namespace) {
+			return status.Errorf(codes.PermissionDenied, "forbidden: callers of namespace %q may not access the streams of namespace %q", decision.Namespace, namespace)
+		}
+	}
+	return nil
+}
+
+// tenantAllows tells whether tenant isolation lets callers of a namespace access the streams of a namespace,
+// callers without namespace not being subject to it
+func (a *Authorization) tenantAllows(callerNamespace string, namespace string) bool {
+	if !a.TenantIsolation || callerNamespace == "" || callerNamespace == namespace {
+		return true
+	}
+	for _, allowed := range a.CrossNamespaceAccess[callerNamespace] {
+		if allowed == namespace || allowed == authz.AllNamespaces {
+			return true
+		}
+	}
+	return false
+}
+
+// topicNamespace returns the namespace of the stream a topic backs, unescaped, empty for topics that don't back a
+// stream. Namespaces that aren't kubernetes namespace names are authorized as they are: kubernetes denies access to
+// namespaces that can't exist, and static tokens only allow the namespaces they list, so escaping them is enough.
+func topicNamespace(topic string) string {
+	namespace, _, _ := validation.ParseTopicName(topic)
+	return namespace
+}
+
+// UnaryInterceptor authorizes the unary calls of the liiklus API when Authorization is set
+func (s *Server) UnaryInterceptor(ctx context.Context, request interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authorizeCall(ctx, request); err != nil {
+		return nil, err
+	}
+	return handler(ctx, request)
+}
+
+// StreamInterceptor authorizes the streaming calls of the liiklus API when Authorization is set, once their
+// request is received
+func (s *Server) StreamInterceptor(server interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if s.Authorization == nil && s.Streams == nil {
+		return handler(server, stream)
+	}
+	return handler(server, &authorizedStream{ServerStream: stream, server: s})
+}
+
+type authorizedStream struct {
+	grpc.ServerStream
+	server     *Server
+	authorized bool
+}
+
+func (a *authorizedStream) RecvMsg(m interface{}) error {
+	if err := a.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if !a.authorized {
+		if err := a.server.authorizeCall(a.Context(), m); err != nil {
+			return err
+		}
+		a.authorized = true
+	}
+	return nil
+}
+
+func (s *Server) authorizeCall(ctx context.Context, request interface{}) error {
+	if s.Streams != nil {
+		for _, access := range s.accesses(request) {
+			if err := s.Streams.checkArchived(access.topic); err != nil {
+				return err
+			}
+		}
+	}
+	if s.Authorization == nil {
+		return nil
+	}
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, value := range md.Get("authorization") {
+			if strings.HasPrefix(value, "Bearer ") {
+				token = strings.TrimPrefix(value, "Bearer ")
+			}
+		}
+	}
+	return s.Authorization.check(token, s.accesses(request))
+}
+
+// accesses returns the verbs a request needs on the streams of the topics it is about
+func (s *Server) accesses(request interface{}) []access {
+	switch request := request.(type) {
+	case *liiklus.PublishRequest:
+		return []access{{verb: PublishVerb, topic: request.Topic}}
+	case *liiklus.PublishBatchRequest:
+		var accesses []access
+		for _, record := range request.Records {
+			accesses = append(accesses, access{verb: PublishVerb, topic: record.Topic})
+		}
+		return accesses
+	case *liiklus.TransactRequest:
+		var accesses []access
+		for _, record := range request.Records {
+			accesses = append(accesses, access{verb: PublishVerb, topic: record.Topic})
+		}
+		if request.Ack != nil {
+			accesses = append(accesses, access{verb: SubscribeVerb, topic: request.Ack.Topic})
+		}
+		return accesses
+	case *liiklus.ReceiveRequest:
+		// the session id of the assignment was handed to a subscriber authorized on its topic, but may leak
+		if request.Assignment == nil {
+			return nil
+		}
+		s.m.Lock()
+		defer s.m.Unlock()
+		if a, ok := s.assignments[request.Assignment.SessionId]; ok {
+			return []access{{verb: SubscribeVerb, topic: a.claim.Topic()}}
+		}
+		return nil
+	case interface{ GetTopic() string }:
+		return []access{{verb: SubscribeVerb, topic: request.GetTopic()}}
+	}
+	return nil
+}
+
+// authorizeHTTP checks the bearer token of an HTTP request on the streams of a namespace, writing an error
+// response and returning false when the caller is not allowed
+func (s *Server) authorizeHTTP(writer http.ResponseWriter, request *http.Request, topic string, verb string) bool {
+	if err := s.Streams.checkArchived(topic); err != nil {
+		writeStatus(writer, err)
+		return false
+	}
+	if s.Authorization == nil {
+		return true
+	}
+	token := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+	if token == request.Header.Get("Authorization") {
+		token = ""
+	}
+	err := s.Authorization.check(token, []access{{verb: verb, topic: topic}})
+	if err == nil {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unauthenticated:
+		writer.Header().Set("WWW-Authenticate", "Bearer")
+		writer.WriteHeader(http.StatusUnauthorized)
+	case codes.PermissionDenied:
+		writer.WriteHeader(http.StatusForbidden)
+	default:
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = fmt.Fprintln(writer, status.Convert(err).Message())
+	return false
+}
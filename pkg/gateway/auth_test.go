@@ -0,0 +1,266 @@
+package gateway_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/liiklus"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/authz"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/authz/authzfakes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var _ = Describe("Gateway authorization", func() {
+
+	var (
+		server     *gateway.Server
+		producer   *mocks.SyncProducer
+		authorizer *authzfakes.FakeAuthorizer
+		grpcServer *grpc.Server
+		connection *grpc.ClientConn
+		client     liiklus.LiiklusServiceClient
+		ctx        context.Context
+		cancel     context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		producer = mocks.NewSyncProducer(GinkgoT(), nil)
+		authorizer = &authzfakes.FakeAuthorizer{}
+		authorizer.AuthorizeReturns(authz.Decision{Authenticated: true, Allowed: true}, nil)
+		group := &fakeConsumerGroup{
+			claims:  []*fakeClaim{{topic: "ns_stream", partition: 0, messages: make(chan *sarama.ConsumerMessage, 10)}},
+			session: &fakeSession{},
+		}
+		server = &gateway.Server{
+			Producer: producer,
+			NewConsumerGroup: func(string, gateway.GroupOptions) (sarama.ConsumerGroup, error) {
+				return group, nil
+			},
+			Logger:        logger,
+			Authorization: &gateway.Authorization{Authorizer: authorizer, CacheTTL: time.Minute},
+		}
+
+		listener := bufconn.Listen(1024 * 1024)
+		grpcServer = grpc.NewServer(grpc.UnaryInterceptor(server.UnaryInterceptor), grpc.StreamInterceptor(server.StreamInterceptor))
+		liiklus.RegisterLiiklusServiceServer(grpcServer, server)
+		go func() {
+			_ = grpcServer.Serve(listener)
+		}()
+		var err error
+		connection, err = grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return listener.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).NotTo(HaveOccurred())
+		client = liiklus.NewLiiklusServiceClient(connection)
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	})
+
+	AfterEach(func() {
+		cancel()
+		_ = connection.Close()
+		grpcServer.Stop()
+		Expect(producer.Close()).To(Succeed())
+	})
+
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+
+	It("lets callers allowed to publish to the stream publish", func() {
+		producer.ExpectSendMessageAndSucceed()
+
+		_, err := client.Publish(withToken("caller-token"), &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte("hello")})
+
+		Expect(err).NotTo(HaveOccurred())
+		token, namespace, verb := authorizer.AuthorizeArgsForCall(0)
+		Expect([]string{token, namespace, verb}).To(Equal([]string{"caller-token", "ns", "publish"}))
+	})
+
+	It("authorizes the streams of escaped namespaces on their namespace", func() {
+		authorizer.AuthorizeReturns(authz.Decision{Authenticated: true}, nil)
+
+		_, err := client.Publish(withToken("caller-token"), &liiklus.PublishRequest{Topic: "a-5fb-_c", Value: []byte("hello")})
+
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+		_, namespace, _ := authorizer.AuthorizeArgsForCall(0)
+		Expect(namespace).To(Equal("a_b"))
+	})
+
+	It("rejects calls on topics that don't back a stream", func() {
+		_, err := client.Publish(withToken("caller-token"), &liiklus.PublishRequest{Topic: "__consumer_offsets", Value: []byte("hello")})
+
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+		Expect(authorizer.AuthorizeCallCount()).To(BeZero())
+	})
+
+	It("rejects calls without token", func() {
+		_, err := client.Publish(ctx, &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte("hello")})
+
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+		Expect(authorizer.AuthorizeCallCount()).To(BeZero())
+	})
+
+	It("rejects invalid tokens", func() {
+		authorizer.AuthorizeReturns(authz.Decision{Reason: "unknown token"}, nil)
+
+		_, err := client.Publish(withToken("guess"), &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte("hello")})
+
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+	})
+
+	It("rejects callers not allowed on the stream", func() {
+		authorizer.AuthorizeReturns(authz.Decision{Authenticated: true, Reason: "not in this namespace"}, nil)
+
+		_, err := client.Publish(withToken("caller-token"), &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte("hello")})
+
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+		Expect(status.Convert(err).Message()).To(ContainSubstring("not in this namespace"))
+	})
+
+	It("reuses decisions", func() {
+		producer.ExpectSendMessageAndSucceed()
+		producer.ExpectSendMessageAndSucceed()
+
+		_, err := client.Publish(withToken("caller-token"), &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte("hello")})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.Publish(withToken("caller-token"), &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte("world")})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(authorizer.AuthorizeCallCount()).To(Equal(1))
+	})
+
+	It("requires subscribers to be allowed to subscribe to the stream", func() {
+		authorizer.AuthorizeReturns(authz.Decision{Authenticated: true}, nil)
+
+		subscription, err := client.Subscribe(withToken("caller-token"), &liiklus.SubscribeRequest{Topic: "ns_stream", Group: "my-function"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = subscription.Recv()
+
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+		_, _, verb := authorizer.AuthorizeArgsForCall(0)
+		Expect(verb).To(Equal("subscribe"))
+	})
+
+	It("requires transactions to be allowed on both the published and acknowledged streams", func() {
+		_, err := client.Transact(withToken("caller-token"), &liiklus.TransactRequest{
+			Records: []*liiklus.PublishRequest{{Topic: "other-ns_results"}},
+			Ack:     &liiklus.AckRequest{Topic: "ns_stream", Group: "my-function"},
+		})
+
+		Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+		_, namespace, verb := authorizer.AuthorizeArgsForCall(0)
+		Expect([]string{namespace, verb}).To(Equal([]string{"other-ns", "publish"}))
+		_, namespace, verb = authorizer.AuthorizeArgsForCall(1)
+		Expect([]string{namespace, verb}).To(Equal([]string{"ns", "subscribe"}))
+	})
+
+	Describe("isolating tenants", func() {
+
+		BeforeEach(func() {
+			server.Authorization.TenantIsolation = true
+			server.Authorization.CrossNamespaceAccess = map[string][]string{"ingest": {"ns"}}
+		})
+
+		publish := func() error {
+			_, err := client.Publish(withToken("caller-token"), &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte("hello")})
+			return err
+		}
+
+		It("lets callers access the streams of their namespace", func() {
+			authorizer.AuthorizeReturns(authz.Decision{Authenticated: true, Allowed: true, Namespace: "ns"}, nil)
+			producer.ExpectSendMessageAndSucceed()
+
+			Expect(publish()).To(Succeed())
+		})
+
+		It("rejects callers of other namespaces, even when allowed", func() {
+			authorizer.AuthorizeReturns(authz.Decision{Authenticated: true, Allowed: true, Namespace: "other-ns"}, nil)
+
+			err := publish()
+			Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+			Expect(status.Convert(err).Message()).To(ContainSubstring(`callers of namespace "other-ns" may not access the streams of namespace "ns"`))
+		})
+
+		It("lets callers of namespaces explicitly allowed access the streams of other namespaces", func() {
+			authorizer.AuthorizeReturns(authz.Decision{Authenticated: true, Allowed: true, Namespace: "ingest"}, nil)
+			producer.ExpectSendMessageAndSucceed()
+
+			Expect(publish()).To(Succeed())
+		})
+
+		It("leaves callers without namespace alone", func() {
+			authorizer.AuthorizeReturns(authz.Decision{Authenticated: true, Allowed: true}, nil)
+			producer.ExpectSendMessageAndSucceed()
+
+			Expect(publish()).To(Succeed())
+		})
+	})
+
+	Describe("over HTTP", func() {
+
+		It("requires a bearer token", func() {
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/ns/stream", strings.NewReader("hello")))
+
+			Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+			Expect(recorder.Header().Get("WWW-Authenticate")).To(Equal("Bearer"))
+		})
+
+		It("rejects callers not allowed on the stream", func() {
+			authorizer.AuthorizeReturns(authz.Decision{Authenticated: true}, nil)
+			request := httptest.NewRequest(http.MethodPost, "/ns/stream", strings.NewReader("hello"))
+			request.Header.Set("Authorization", "Bearer caller-token")
+
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		})
+
+		It("requires the manage verb to delete or rewind consumer groups", func() {
+			authorizer.AuthorizeStub = func(_, _, verb string) (authz.Decision, error) {
+				return authz.Decision{Authenticated: true, Allowed: verb == gateway.SubscribeVerb}, nil
+			}
+
+			for _, request := range []*http.Request{
+				httptest.NewRequest(http.MethodDelete, "/ns/stream/groups/other-function", nil),
+				httptest.NewRequest(http.MethodPost, "/ns/stream/groups/other-function/rewind?to=earliest", nil),
+			} {
+				request.Header.Set("Authorization", "Bearer subscriber-token")
+				recorder := httptest.NewRecorder()
+				server.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusForbidden), request.Method+" "+request.URL.Path)
+				_, _, verb := authorizer.AuthorizeArgsForCall(authorizer.AuthorizeCallCount() - 1)
+				Expect(verb).To(Equal(gateway.ManageVerb))
+			}
+		})
+
+		It("only requires the subscribe verb to describe consumer groups", func() {
+			authorizer.AuthorizeReturns(authz.Decision{Authenticated: true}, nil)
+			request := httptest.NewRequest(http.MethodGet, "/ns/stream/groups", nil)
+			request.Header.Set("Authorization", "Bearer subscriber-token")
+
+			server.ServeHTTP(httptest.NewRecorder(), request)
+
+			_, _, verb := authorizer.AuthorizeArgsForCall(0)
+			Expect(verb).To(Equal(gateway.SubscribeVerb))
+		})
+	})
+})
@@ -0,0 +1,112 @@
+package avro
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Registry looks up schemas in a Confluent Schema Registry
+type Registry struct {
+	// URL is the base URL of the registry, e.g. http://schema-registry:8081
+	URL    string
+	Client *http.Client
+}
+
+// Schema is a version of the schema of a subject
+type Schema struct {
+	ID     int    `json:"id"`
+	Schema string `json:"schema"`
+	// Type is empty for Avro schemas, the default type of the registry
+	Type string `json:"schemaType,omitempty"`
+}
+
+// error codes the registry reports for unknown subjects, versions and schemas, and subjects already deleted
+const (
+	errSubjectNotFound    = 40401
+	errVersionNotFound    = 40402
+	errSchemaNotFound     = 40403
+	errSubjectSoftDeleted = 40404
+)
+
+// Latest returns the latest version of the schema of a subject, or nil when the subject has no schema
+func (r *Registry) Latest(ctx context.Context, subject string) (*Schema, error) {
+	var schema Schema
+	found, err := r.get(ctx, fmt.Sprintf("/subjects/%s/versions/latest", url.PathEscape(subject)), &schema)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// ByID returns the schema registered with an id
+func (r *Registry) ByID(ctx context.Context, id int) (*Schema, error) {
+	schema := Schema{ID: id}
+	found, err := r.get(ctx, fmt.Sprintf("/schemas/ids/%d", id), &schema)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("schema %d is not registered", id)
+	}
+	return &schema, nil
+}
+
+// DeleteSubject deletes the versions of a subject, softly so that they can still be restored unless permanent.
+// Subjects are soft deleted before being permanently deleted, as the registry requires, and unknown subjects
+// are left alone.
+func (r *Registry) DeleteSubject(ctx context.Context, subject string, permanent bool) error {
+	path := "/subjects/" + url.PathEscape(subject)
+	var versions []int
+	if _, err := r.do(ctx, http.MethodDelete, path, &versions); err != nil {
+		return err
+	}
+	if !permanent {
+		return nil
+	}
+	_, err := r.do(ctx, http.MethodDelete, path+"?permanent=true", &versions)
+	return err
+}
+
+// get decodes the response to a registry request, returning false when what it looks up is not found
+func (r *Registry) get(ctx context.Context, path string, v interface{}) (bool, error) {
+	return r.do(ctx, http.MethodGet, path, v)
+}
+
+// do decodes the response to a registry request, returning false when what it acts on is not found
+func (r *Registry) do(ctx context.Context, method string, path string, v interface{}) (bool, error) {
+	request, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(r.URL, "/")+path, nil)
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return false, fmt.Errorf("error reaching the schema registry: %v", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		var registryError struct {
+			ErrorCode int    `json:"error_code"`
+			Message   string `json:"message"`
+		}
+		_ = json.NewDecoder(response.Body).Decode(&registryError)
+		switch registryError.ErrorCode {
+		case errSubjectNotFound, errVersionNotFound, errSchemaNotFound, errSubjectSoftDeleted:
+			return false, nil
+		}
+		return false, fmt.Errorf("schema registry responded %d: %s", response.StatusCode, registryError.Message)
+	}
+	if err := json.NewDecoder(response.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("error decoding the schema registry response: %v", err)
+	}
+	return true, nil
+}
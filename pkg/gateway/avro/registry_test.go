@@ -0,0 +1,69 @@
+package avro_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/avro"
+)
+
+var _ = Describe("Registry", func() {
+
+	var (
+		server   *httptest.Server
+		m        sync.Mutex
+		requests []string
+		registry *avro.Registry
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.Lock()
+			requests = append(requests, r.Method+" "+r.URL.RequestURI())
+			m.Unlock()
+			switch r.URL.Path {
+			case "/subjects/ns_users-value":
+				_, _ = fmt.Fprint(w, `[1, 2]`)
+			case "/subjects/ns_broken-value":
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = fmt.Fprint(w, `{"error_code": 50001, "message": "Error in the backend data store"}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = fmt.Fprint(w, `{"error_code": 40401, "message": "Subject not found."}`)
+			}
+		}))
+		registry = &avro.Registry{URL: server.URL}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("soft deletes subjects", func() {
+		Expect(registry.DeleteSubject(context.Background(), "ns_users-value", false)).To(Succeed())
+
+		Expect(requests).To(Equal([]string{"DELETE /subjects/ns_users-value"}))
+	})
+
+	It("soft deletes subjects before deleting them permanently", func() {
+		Expect(registry.DeleteSubject(context.Background(), "ns_users-value", true)).To(Succeed())
+
+		Expect(requests).To(Equal([]string{"DELETE /subjects/ns_users-value", "DELETE /subjects/ns_users-value?permanent=true"}))
+	})
+
+	It("leaves unknown subjects alone", func() {
+		Expect(registry.DeleteSubject(context.Background(), "ns_unknown-value", true)).To(Succeed())
+	})
+
+	It("reports registry errors", func() {
+		err := registry.DeleteSubject(context.Background(), "ns_broken-value", false)
+
+		Expect(err).To(MatchError("schema registry responded 500: Error in the backend data store"))
+	})
+})
@@ -0,0 +1,168 @@
+// Package avro serializes the values of streams having an Avro schema in a Confluent Schema Registry, so that
+// Kafka-native consumers can read the records published through the gateway
+package avro
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// magicByte starts the values of the registry wire format, followed by the schema id as a 4 bytes big endian
+// integer and the Avro binary encoding of the value
+const magicByte = 0
+
+// InvalidPayloadError reports a value that doesn't conform to the schema of its stream
+type InvalidPayloadError struct {
+	Topic    string
+	SchemaID int
+	Err      error
+}
+
+func (e *InvalidPayloadError) Error() string {
+	return fmt.Sprintf("value does not conform to the Avro schema %d of topic %s: %v", e.SchemaID, e.Topic, e.Err)
+}
+
+// Serializer converts the JSON values of streams having an Avro schema to the registry wire format as they are
+// published, and back as they are delivered. The schema of a stream is the latest one of its subject, streams
+// without schema being left alone.
+type Serializer struct {
+	Registry *Registry
+	// Subjects returns the subject of the schema of a topic, following the naming strategy chosen for its stream,
+	// the <topic>-value subject when nil
+	Subjects func(topic string) string
+	// RefreshInterval is how long the latest schema of a subject, or its absence, is cached
+	RefreshInterval time.Duration
+
+	m      sync.Mutex
+	codecs map[int]*goavro.Codec
+	latest map[string]latestSchema
+}
+
+type latestSchema struct {
+	id      int
+	codec   *goavro.Codec
+	expires time.Time
+}
+
+// NewSerializer looks up schemas in a registry, caching the latest one of each subject for a minute
+func NewSerializer(registry *Registry) *Serializer {
+	return &Serializer{Registry: registry, RefreshInterval: time.Minute}
+}
+
+// Serialize encodes a JSON value published to a topic with the schema of the topic, returning an
+// *InvalidPayloadError when it doesn't conform to it
+func (s *Serializer) Serialize(ctx context.Context, topic string, value []byte) ([]byte, error) {
+	if s == nil || value == nil {
+		return value, nil
+	}
+	latest, err := s.schema(ctx, topic)
+	if err != nil || latest.codec == nil {
+		return value, err
+	}
+	native, rest, err := latest.codec.NativeFromTextual(value)
+	if err == nil && len(bytes.TrimSpace(rest)) > 0 {
+		err = fmt.Errorf("unexpected content after the value: %q", rest)
+	}
+	if err != nil {
+		return nil, &InvalidPayloadError{Topic: topic, SchemaID: latest.id, Err: err}
+	}
+	serialized := make([]byte, 5, 5+len(value))
+	serialized[0] = magicByte
+	binary.BigEndian.PutUint32(serialized[1:], uint32(latest.id))
+	if serialized, err = latest.codec.BinaryFromNative(serialized, native); err != nil {
+		return nil, &InvalidPayloadError{Topic: topic, SchemaID: latest.id, Err: err}
+	}
+	return serialized, nil
+}
+
+// Deserialize decodes a value of a topic in the registry wire format to JSON, with the schema it was written
+// with. Values of topics without schema, or not in the wire format, are returned as is.
+func (s *Serializer) Deserialize(ctx context.Context, topic string, value []byte) ([]byte, error) {
+	if s == nil || len(value) < 5 || value[0] != magicByte {
+		return value, nil
+	}
+	latest, err := s.schema(ctx, topic)
+	if err != nil || latest.codec == nil {
+		return value, err
+	}
+	id := int(binary.BigEndian.Uint32(value[1:5]))
+	codec, err := s.codec(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	native, _, err := codec.NativeFromBinary(value[5:])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding a value of topic %s with the Avro schema %d: %v", topic, id, err)
+	}
+	return codec.TextualFromNative(nil, native)
+}
+
+// schema returns the latest schema of a topic, whose codec is nil when the topic has no Avro schema
+func (s *Serializer) schema(ctx context.Context, topic string) (latestSchema, error) {
+	subject := topic + "-value"
+	if s.Subjects != nil {
+		subject = s.Subjects(topic)
+	}
+	now := time.Now()
+	s.m.Lock()
+	latest, ok := s.latest[subject]
+	s.m.Unlock()
+	if ok && now.Before(latest.expires) {
+		return latest, nil
+	}
+
+	latest = latestSchema{expires: now.Add(s.RefreshInterval)}
+	schema, err := s.Registry.Latest(ctx, subject)
+	if err != nil {
+		return latest, err
+	}
+	if schema != nil && (schema.Type == "" || schema.Type == "AVRO") {
+		latest.id = schema.ID
+		if latest.codec, err = s.codecOf(schema); err != nil {
+			return latest, err
+		}
+	}
+	s.m.Lock()
+	if s.latest == nil {
+		s.latest = make(map[string]latestSchema)
+	}
+	s.latest[subject] = latest
+	s.m.Unlock()
+	return latest, nil
+}
+
+// codec returns the codec of a schema id, schemas never changing once registered
+func (s *Serializer) codec(ctx context.Context, id int) (*goavro.Codec, error) {
+	s.m.Lock()
+	codec, ok := s.codecs[id]
+	s.m.Unlock()
+	if ok {
+		return codec, nil
+	}
+	schema, err := s.Registry.ByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.codecOf(schema)
+}
+
+func (s *Serializer) codecOf(schema *Schema) (*goavro.Codec, error) {
+	// values are exchanged with clients as plain JSON, rather than the JSON encoding of Avro
+	codec, err := goavro.NewCodecForStandardJSONFull(schema.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Avro schema %d: %v", schema.ID, err)
+	}
+	s.m.Lock()
+	if s.codecs == nil {
+		s.codecs = make(map[int]*goavro.Codec)
+	}
+	s.codecs[schema.ID] = codec
+	s.m.Unlock()
+	return codec, nil
+}
@@ -0,0 +1,132 @@
+package avro_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/avro"
+)
+
+const userSchema = `{"type": "record", "name": "User", "fields": [{"name": "name", "type": "string"}, {"name": "age", "type": "int"}]}`
+
+var _ = Describe("Serializer", func() {
+
+	var (
+		registry   *httptest.Server
+		lookups    int32
+		serializer *avro.Serializer
+		ctx        context.Context
+	)
+
+	BeforeEach(func() {
+		atomic.StoreInt32(&lookups, 0)
+		registry = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&lookups, 1)
+			switch r.URL.Path {
+			case "/subjects/ns_users-value/versions/latest", "/subjects/com.example.User/versions/latest":
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"subject": "ns_users-value", "version": 1, "id": 7, "schema": userSchema})
+			case "/schemas/ids/7":
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"schema": userSchema})
+			case "/subjects/ns_broken-value/versions/latest":
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = fmt.Fprint(w, `{"error_code": 50001, "message": "Error in the backend data store"}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = fmt.Fprint(w, `{"error_code": 40401, "message": "Subject not found."}`)
+			}
+		}))
+		serializer = avro.NewSerializer(&avro.Registry{URL: registry.URL})
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		registry.Close()
+	})
+
+	It("serializes values in the registry wire format", func() {
+		value, err := serializer.Serialize(ctx, "ns_users", []byte(`{"name": "Ada", "age": 36}`))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal([]byte{0, 0, 0, 0, 7, 6, 'A', 'd', 'a', 72}))
+	})
+
+	It("deserializes values in the registry wire format to JSON", func() {
+		value, err := serializer.Deserialize(ctx, "ns_users", []byte{0, 0, 0, 0, 7, 6, 'A', 'd', 'a', 72})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(MatchJSON(`{"name": "Ada", "age": 36}`))
+	})
+
+	It("rejects values not conforming to the schema", func() {
+		_, err := serializer.Serialize(ctx, "ns_users", []byte(`{"name": "Ada"}`))
+
+		Expect(err).To(BeAssignableToTypeOf(&avro.InvalidPayloadError{}))
+		Expect(err.Error()).To(HavePrefix("value does not conform to the Avro schema 7 of topic ns_users"))
+	})
+
+	It("rejects values that are not JSON", func() {
+		_, err := serializer.Serialize(ctx, "ns_users", []byte(`Ada`))
+
+		Expect(err).To(BeAssignableToTypeOf(&avro.InvalidPayloadError{}))
+	})
+
+	It("leaves the values of topics without schema alone", func() {
+		value, err := serializer.Serialize(ctx, "ns_other", []byte("hello"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal([]byte("hello")))
+
+		value, err = serializer.Deserialize(ctx, "ns_other", []byte{0, 0, 0, 0, 7, 6, 'A', 'd', 'a', 72})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal([]byte{0, 0, 0, 0, 7, 6, 'A', 'd', 'a', 72}))
+	})
+
+	It("leaves values not in the wire format alone", func() {
+		value, err := serializer.Deserialize(ctx, "ns_users", []byte(`{"name": "Ada", "age": 36}`))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(MatchJSON(`{"name": "Ada", "age": 36}`))
+	})
+
+	It("looks up the subject the topic is named after", func() {
+		serializer.Subjects = func(topic string) string {
+			return "com.example.User"
+		}
+
+		value, err := serializer.Serialize(ctx, "ns_members", []byte(`{"name": "Ada", "age": 36}`))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal([]byte{0, 0, 0, 0, 7, 6, 'A', 'd', 'a', 72}))
+	})
+
+	It("caches the latest schema of subjects", func() {
+		for i := 0; i < 3; i++ {
+			_, err := serializer.Serialize(ctx, "ns_users", []byte(`{"name": "Ada", "age": 36}`))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = serializer.Serialize(ctx, "ns_other", []byte("hello"))
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(atomic.LoadInt32(&lookups)).To(Equal(int32(2)))
+	})
+
+	It("reports registry errors", func() {
+		_, err := serializer.Serialize(ctx, "ns_broken", []byte("hello"))
+
+		Expect(err).To(MatchError("schema registry responded 500: Error in the backend data store"))
+	})
+
+	It("does nothing when nil", func() {
+		var serializer *avro.Serializer
+
+		value, err := serializer.Serialize(ctx, "ns_users", []byte("hello"))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal([]byte("hello")))
+	})
+})
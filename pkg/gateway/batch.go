@@ -0,0 +1,145 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/liiklus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// MaxBatchRecords is the number of records a batch may hold at most
+	MaxBatchRecords = 1000
+	// maxBatchBytes bounds the body of the batches published over HTTP
+	maxBatchBytes = 32 << 20
+)
+
+// PublishBatch produces records in as few requests to Kafka as their compression allows. Records are validated,
+// transformed and published independently, the reply telling the result of each record in the order of the
+// request, so that sources buffering events only retry those that failed.
+func (s *Server) PublishBatch(ctx context.Context, request *liiklus.PublishBatchRequest) (*liiklus.PublishBatchReply, error) {
+	if len(request.Records) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "records are required")
+	}
+	if len(request.Records) > MaxBatchRecords {
+		return nil, status.Errorf(codes.InvalidArgument, "batch of %d records exceeds the limit of %d records", len(request.Records), MaxBatchRecords)
+	}
+	results := make([]*liiklus.PublishBatchResult, len(request.Records))
+	serialized := make([]*liiklus.PublishRequest, len(request.Records))
+	// records compressed alike are sent together by the producer of their topics
+	batches := make(map[sarama.SyncProducer][]*sarama.ProducerMessage)
+	for i, record := range request.Records {
+		var err error
+		if serialized[i], err = s.prepare(ctx, record); err != nil {
+			results[i] = failedResult(err)
+			continue
+		}
+		if serialized[i] == nil {
+			results[i] = &liiklus.PublishBatchResult{Record: &liiklus.PublishReply{Topic: record.Topic}}
+			continue
+		}
+		message := producerMessage(serialized[i])
+		message.Metadata = i
+		producer := s.producer(record.Topic)
+		batches[producer] = append(batches[producer], message)
+	}
+
+	for producer, messages := range batches {
+		failures := make(map[int]error)
+		if err := producer.SendMessages(messages); err != nil {
+			s.Logger.Error("Error publishing batch", "records", len(messages), "error", err)
+			if producerErrors, ok := err.(sarama.ProducerErrors); ok {
+				for _, producerError := range producerErrors {
+					failures[producerError.Msg.Metadata.(int)] = producerError.Err
+				}
+			} else {
+				for _, message := range messages {
+					failures[message.Metadata.(int)] = err
+				}
+			}
+		}
+		for _, message := range messages {
+			i := message.Metadata.(int)
+			if err, failed := failures[i]; failed {
+				results[i] = failedResult(kafkaStatus(err))
+				continue
+			}
+			s.Metrics.Published(message.Topic, len(serialized[i].Key)+len(serialized[i].Value))
+			results[i] = &liiklus.PublishBatchResult{Record: &liiklus.PublishReply{
+				Topic:     message.Topic,
+				Partition: uint32(message.Partition),
+				Offset:    uint64(message.Offset),
+			}}
+		}
+	}
+	return &liiklus.PublishBatchReply{Results: results}, nil
+}
+
+func failedResult(err error) *liiklus.PublishBatchResult {
+	st := status.Convert(err)
+	return &liiklus.PublishBatchResult{Code: uint32(st.Code()), Message: st.Message()}
+}
+
+// batchRecord is a record of a batch published over HTTP, whose value is JSON
+type batchRecord struct {
+	Key     string            `json:"key,omitempty"`
+	Value   json.RawMessage   `json:"value"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// batchResult is either the result of a record published or the status of its failure
+type batchResult struct {
+	*publishResult
+	Status int    `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// publishBatchHTTP publishes the JSON array of records of POST /<namespace>/<stream-name>/batch requests,
+// returning the result of each record
+func (s *Server) publishBatchHTTP(writer http.ResponseWriter, request *http.Request, topic string) {
+	var records []batchRecord
+	if err := json.NewDecoder(http.MaxBytesReader(writer, request.Body, maxBatchBytes)).Decode(&records); err != nil {
+		if _, ok := err.(*http.MaxBytesError); ok {
+			writer.WriteHeader(http.StatusRequestEntityTooLarge)
+			_, _ = fmt.Fprintf(writer, "batch exceeds the limit of %d bytes: split it into smaller batches\n", maxBatchBytes)
+			return
+		}
+		writer.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(writer, "the batch should be a JSON array of records: %v\n", err)
+		return
+	}
+	batch := &liiklus.PublishBatchRequest{Records: make([]*liiklus.PublishRequest, len(records))}
+	for i, record := range records {
+		published := &liiklus.PublishRequest{Topic: topic, Value: record.Value, ContentType: "application/json"}
+		if record.Key != "" {
+			published.Key = []byte(record.Key)
+		}
+		for key, value := range record.Headers {
+			if published.Headers == nil {
+				published.Headers = make(map[string][]byte)
+			}
+			published.Headers[key] = []byte(value)
+		}
+		batch.Records[i] = published
+	}
+
+	reply, err := s.PublishBatch(request.Context(), batch)
+	if err != nil {
+		writeStatus(writer, err)
+		return
+	}
+	results := make([]batchResult, len(reply.Results))
+	for i, result := range reply.Results {
+		if codes.Code(result.Code) != codes.OK {
+			results[i] = batchResult{Status: httpStatus(codes.Code(result.Code)), Error: result.Message}
+			continue
+		}
+		results[i] = batchResult{publishResult: &publishResult{Topic: result.Record.Topic, Partition: result.Record.Partition, Offset: result.Record.Offset}}
+	}
+	writeJSON(writer, results)
+}
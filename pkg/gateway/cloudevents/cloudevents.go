@@ -0,0 +1,250 @@
+// Package cloudevents maps CloudEvents (https://cloudevents.io) between their HTTP and Kafka protocol bindings, in
+// both binary mode, where attributes are headers and data is the body of the message, and structured mode, where
+// the message is a JSON envelope of the whole event
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	// ContentType is the content type of events in structured mode
+	ContentType = "application/cloudevents+json"
+
+	// KafkaPrefix prefixes the names of the Kafka headers of attributes in binary mode
+	KafkaPrefix = "ce_"
+	// HTTPPrefix prefixes the names of the HTTP headers of attributes in binary mode
+	HTTPPrefix = "ce-"
+
+	specVersion     = "specversion"
+	dataContentType = "datacontenttype"
+	// partitionKey is the extension mapped to the key of Kafka records
+	partitionKey = "partitionkey"
+)
+
+var requiredAttributes = []string{specVersion, "id", "source", "type"}
+
+// Event is a CloudEvent, its attributes, extensions included, being represented as strings
+type Event struct {
+	Attributes map[string]string
+	Data       []byte
+}
+
+// ContentType returns the content type of the data of the event
+func (e *Event) ContentType() string {
+	return e.Attributes[dataContentType]
+}
+
+// Key returns the key of the Kafka record of the event, its partitionkey extension
+func (e *Event) Key() []byte {
+	if key, ok := e.Attributes[partitionKey]; ok {
+		return []byte(key)
+	}
+	return nil
+}
+
+// Validate checks the event has the attributes required by the 1.0 specification
+func (e *Event) Validate() error {
+	for _, name := range requiredAttributes {
+		if e.Attributes[name] == "" {
+			return fmt.Errorf("the %s attribute of the CloudEvent is required", name)
+		}
+	}
+	if version := e.Attributes[specVersion]; version != "1.0" {
+		return fmt.Errorf("CloudEvents specification version %q is not supported", version)
+	}
+	return nil
+}
+
+// IsStructured tells whether a content type is the one of events in structured mode
+func IsStructured(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == ContentType
+}
+
+// FromHTTP reads the event of an HTTP request or response in either mode, returning nil when it carries none
+func FromHTTP(header http.Header, body []byte) (*Event, error) {
+	if IsStructured(header.Get("Content-Type")) {
+		return ParseStructured(body)
+	}
+	if header.Get(HTTPPrefix+specVersion) == "" {
+		return nil, nil
+	}
+	event := &Event{Attributes: make(map[string]string), Data: body}
+	for name, values := range header {
+		if name := strings.ToLower(name); strings.HasPrefix(name, HTTPPrefix) && len(values) > 0 {
+			event.Attributes[strings.TrimPrefix(name, HTTPPrefix)] = values[0]
+		}
+	}
+	if contentType := header.Get("Content-Type"); contentType != "" {
+		event.Attributes[dataContentType] = contentType
+	}
+	return event, event.Validate()
+}
+
+// WriteHTTP writes the event to an HTTP response, in structured or binary mode
+func (e *Event) WriteHTTP(writer http.ResponseWriter, structured bool) error {
+	if structured {
+		writer.Header().Set("Content-Type", ContentType)
+		_, err := writer.Write(e.Structured())
+		return err
+	}
+	for name, value := range e.Attributes {
+		if name == dataContentType {
+			writer.Header().Set("Content-Type", value)
+			continue
+		}
+		writer.Header().Set(HTTPPrefix+name, value)
+	}
+	_, err := writer.Write(e.Data)
+	return err
+}
+
+// FromKafka reads the event of a Kafka record in either mode, returning nil when it carries none
+func FromKafka(headers map[string][]byte, contentType string, value []byte) (*Event, error) {
+	if IsStructured(contentType) {
+		return ParseStructured(value)
+	}
+	if _, ok := headers[KafkaPrefix+specVersion]; !ok {
+		return nil, nil
+	}
+	event := &Event{Attributes: make(map[string]string), Data: value}
+	for name, value := range headers {
+		if strings.HasPrefix(name, KafkaPrefix) {
+			event.Attributes[strings.TrimPrefix(name, KafkaPrefix)] = string(value)
+		}
+	}
+	if contentType != "" {
+		event.Attributes[dataContentType] = contentType
+	}
+	return event, event.Validate()
+}
+
+// KafkaHeaders returns the headers of the attributes of the event in binary mode, but its content type recorded
+// in the content-type header
+func (e *Event) KafkaHeaders() map[string][]byte {
+	headers := make(map[string][]byte)
+	for name, value := range e.Attributes {
+		if name != dataContentType {
+			headers[KafkaPrefix+name] = []byte(value)
+		}
+	}
+	return headers
+}
+
+// ParseStructured reads an event in structured mode
+func ParseStructured(envelope []byte) (*Event, error) {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(envelope, &fields); err != nil {
+		return nil, fmt.Errorf("invalid CloudEvent: %v", err)
+	}
+	event := &Event{Attributes: make(map[string]string)}
+	for name, value := range fields {
+		switch name {
+		case "data":
+			if isJSON(envelopeContentType(fields)) {
+				event.Data = []byte(value)
+				continue
+			}
+			var data string
+			if err := json.Unmarshal(value, &data); err != nil {
+				return nil, fmt.Errorf("invalid CloudEvent: data should be a string for content type %q", envelopeContentType(fields))
+			}
+			event.Data = []byte(data)
+		case "data_base64":
+			var data string
+			if err := json.Unmarshal(value, &data); err != nil {
+				return nil, fmt.Errorf("invalid CloudEvent: data_base64 should be a string")
+			}
+			decoded, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CloudEvent: data_base64 is not base64 encoded: %v", err)
+			}
+			event.Data = decoded
+		default:
+			var attribute interface{}
+			if err := json.Unmarshal(value, &attribute); err != nil {
+				return nil, fmt.Errorf("invalid CloudEvent: %v", err)
+			}
+			switch attribute := attribute.(type) {
+			case string:
+				event.Attributes[name] = attribute
+			case nil:
+			default:
+				// numbers and booleans of extensions
+				event.Attributes[name] = string(bytes.TrimSpace(value))
+			}
+		}
+	}
+	return event, event.Validate()
+}
+
+// envelopeContentType returns the content type of the data of an envelope being parsed
+func envelopeContentType(fields map[string]json.RawMessage) string {
+	var contentType string
+	_ = json.Unmarshal(fields[dataContentType], &contentType)
+	return contentType
+}
+
+// Structured returns the event in structured mode
+func (e *Event) Structured() []byte {
+	names := make([]string, 0, len(e.Attributes))
+	for name := range e.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		writeField(&buffer, name, e.Attributes[name])
+	}
+	if e.Data != nil {
+		if len(names) > 0 {
+			buffer.WriteByte(',')
+		}
+		switch {
+		case isJSON(e.ContentType()) && json.Valid(e.Data):
+			buffer.WriteString(`"data":`)
+			buffer.Write(e.Data)
+		case isText(e.ContentType()):
+			writeField(&buffer, "data", string(e.Data))
+		default:
+			writeField(&buffer, "data_base64", base64.StdEncoding.EncodeToString(e.Data))
+		}
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes()
+}
+
+func writeField(buffer *bytes.Buffer, name string, value string) {
+	encodedName, _ := json.Marshal(name)
+	encodedValue, _ := json.Marshal(value)
+	buffer.Write(encodedName)
+	buffer.WriteByte(':')
+	buffer.Write(encodedValue)
+}
+
+// isJSON tells whether data of a content type is embedded as JSON in envelopes, as is the case when unset
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || mediaType == "text/json")
+}
+
+func isText(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && strings.HasPrefix(mediaType, "text/")
+}
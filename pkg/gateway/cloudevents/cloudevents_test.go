@@ -0,0 +1,132 @@
+package cloudevents_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/cloudevents"
+)
+
+var _ = Describe("CloudEvents", func() {
+
+	attributes := map[string]string{"specversion": "1.0", "id": "1234", "source": "/orders", "type": "order.created"}
+
+	Describe("structured mode", func() {
+
+		It("parses events with JSON data", func() {
+			event, err := cloudevents.ParseStructured([]byte(`{
+				"specversion": "1.0", "id": "1234", "source": "/orders", "type": "order.created",
+				"datacontenttype": "application/json", "priority": 3, "data": {"total": 42}
+			}`))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event.Attributes).To(HaveKeyWithValue("priority", "3"))
+			Expect(event.ContentType()).To(Equal("application/json"))
+			Expect(event.Data).To(MatchJSON(`{"total": 42}`))
+		})
+
+		It("parses events with binary data", func() {
+			event, err := cloudevents.ParseStructured([]byte(`{
+				"specversion": "1.0", "id": "1234", "source": "/orders", "type": "order.created",
+				"datacontenttype": "application/octet-stream", "data_base64": "AQID"
+			}`))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event.Data).To(Equal([]byte{1, 2, 3}))
+		})
+
+		It("rejects events missing required attributes", func() {
+			_, err := cloudevents.ParseStructured([]byte(`{"specversion": "1.0", "id": "1234", "source": "/orders"}`))
+
+			Expect(err).To(MatchError("the type attribute of the CloudEvent is required"))
+		})
+
+		It("rejects unsupported specification versions", func() {
+			_, err := cloudevents.ParseStructured([]byte(`{"specversion": "0.3", "id": "1234", "source": "/orders", "type": "order.created"}`))
+
+			Expect(err).To(MatchError(`CloudEvents specification version "0.3" is not supported`))
+		})
+
+		It("writes events", func() {
+			event := &cloudevents.Event{Attributes: map[string]string{}, Data: []byte{1, 2, 3}}
+			for name, value := range attributes {
+				event.Attributes[name] = value
+			}
+			event.Attributes["datacontenttype"] = "application/octet-stream"
+
+			Expect(event.Structured()).To(MatchJSON(`{
+				"specversion": "1.0", "id": "1234", "source": "/orders", "type": "order.created",
+				"datacontenttype": "application/octet-stream", "data_base64": "AQID"
+			}`))
+		})
+	})
+
+	Describe("binary mode", func() {
+
+		It("reads events from HTTP headers", func() {
+			header := http.Header{}
+			header.Set("Content-Type", "text/plain")
+			for name, value := range attributes {
+				header.Set("Ce-"+name, value)
+			}
+
+			event, err := cloudevents.FromHTTP(header, []byte("hello"))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event.ContentType()).To(Equal("text/plain"))
+			Expect(event.Data).To(Equal([]byte("hello")))
+			Expect(event.KafkaHeaders()).To(Equal(map[string][]byte{
+				"ce_specversion": []byte("1.0"),
+				"ce_id":          []byte("1234"),
+				"ce_source":      []byte("/orders"),
+				"ce_type":        []byte("order.created"),
+			}))
+		})
+
+		It("ignores HTTP messages without event", func() {
+			header := http.Header{}
+			header.Set("Content-Type", "text/plain")
+
+			event, err := cloudevents.FromHTTP(header, []byte("hello"))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event).To(BeNil())
+		})
+
+		It("reads events from Kafka headers", func() {
+			event, err := cloudevents.FromKafka(map[string][]byte{
+				"ce_specversion": []byte("1.0"),
+				"ce_id":          []byte("1234"),
+				"ce_source":      []byte("/orders"),
+				"ce_type":        []byte("order.created"),
+				"traceparent":    []byte("00-abc"),
+			}, "text/plain", []byte("hello"))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event.Attributes).To(Equal(map[string]string{
+				"specversion":     "1.0",
+				"id":              "1234",
+				"source":          "/orders",
+				"type":            "order.created",
+				"datacontenttype": "text/plain",
+			}))
+		})
+
+		It("writes events to HTTP headers", func() {
+			event := &cloudevents.Event{Attributes: map[string]string{"datacontenttype": "text/plain"}, Data: []byte("hello")}
+			for name, value := range attributes {
+				event.Attributes[name] = value
+			}
+			recorder := httptest.NewRecorder()
+
+			Expect(event.WriteHTTP(recorder, false)).To(Succeed())
+
+			Expect(recorder.Header().Get("Content-Type")).To(Equal("text/plain"))
+			Expect(recorder.Header().Get("Ce-Specversion")).To(Equal("1.0"))
+			Expect(recorder.Header().Get("Ce-Source")).To(Equal("/orders"))
+			Expect(recorder.Body.String()).To(Equal("hello"))
+		})
+	})
+})
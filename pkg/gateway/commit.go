@@ -0,0 +1,51 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/liiklus"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// GroupOptions configures how a consumer group consumes
+type GroupOptions struct {
+	// InitialOffset is where to start on partitions without committed offsets, sarama.OffsetOldest or
+	// sarama.OffsetNewest
+	InitialOffset int64
+	// AutoCommitInterval is the interval between commits of the acknowledged offsets, which are only
+	// committed on demand when zero
+	AutoCommitInterval time.Duration
+}
+
+// Commit commits the offsets acknowledged on the partitions of a topic assigned to a group, for subscriptions
+// with the MANUAL commit strategy
+func (s *Server) Commit(_ context.Context, request *liiklus.CommitRequest) (*emptypb.Empty, error) {
+	a, err := s.findGroupAssignment(request.Topic, request.Group, request.GroupVersion)
+	if err != nil {
+		return nil, err
+	}
+	// all the partitions of a subscription share the same session
+	a.session.Commit()
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) commitStrategy(requested liiklus.SubscribeRequest_CommitStrategy) liiklus.SubscribeRequest_CommitStrategy {
+	if requested != liiklus.SubscribeRequest_DEFAULT {
+		return requested
+	}
+	if s.CommitStrategy != liiklus.SubscribeRequest_DEFAULT {
+		return s.CommitStrategy
+	}
+	return liiklus.SubscribeRequest_AUTO
+}
+
+func (s *Server) autoCommitInterval(requestedMs uint32) time.Duration {
+	if requestedMs > 0 {
+		return time.Duration(requestedMs) * time.Millisecond
+	}
+	if s.AutoCommitInterval > 0 {
+		return s.AutoCommitInterval
+	}
+	return time.Second
+}
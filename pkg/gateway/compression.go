@@ -0,0 +1,54 @@
+package gateway
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+// Compression configures how a producer compresses the records it publishes
+type Compression struct {
+	Codec sarama.CompressionCodec
+	// Level depends on the codec, sarama.CompressionLevelDefault leaving it to the codec
+	Level int
+}
+
+// DefaultCompression publishes records uncompressed
+var DefaultCompression = Compression{Codec: sarama.CompressionNone, Level: sarama.CompressionLevelDefault}
+
+// ParseCompression reads a codec, one of none, gzip, snappy, lz4 or zstd, optionally followed by a level,
+// e.g. zstd:3
+func ParseCompression(value string) (Compression, error) {
+	compression := DefaultCompression
+	codec, level, hasLevel := strings.Cut(value, ":")
+	if err := compression.Codec.UnmarshalText([]byte(codec)); err != nil {
+		return compression, fmt.Errorf("compression codec %q should be one of none, gzip, snappy, lz4 or zstd", codec)
+	}
+	if hasLevel {
+		var err error
+		if compression.Level, err = strconv.Atoi(level); err != nil {
+			return compression, fmt.Errorf("compression level %q should be an integer", level)
+		}
+	}
+	if err := compression.validate(); err != nil {
+		return compression, err
+	}
+	return compression, nil
+}
+
+// configure sets the compression of a producer, the zstd codec requiring Kafka 2.1
+func (c Compression) configure(config *sarama.Config) {
+	config.Producer.Compression = c.Codec
+	config.Producer.CompressionLevel = c.Level
+	if c.Codec == sarama.CompressionZSTD && !config.Version.IsAtLeast(sarama.V2_1_0_0) {
+		config.Version = sarama.V2_1_0_0
+	}
+}
+
+func (c Compression) validate() error {
+	config := sarama.NewConfig()
+	c.configure(config)
+	return config.Validate()
+}
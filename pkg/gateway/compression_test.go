@@ -0,0 +1,43 @@
+package gateway_test
+
+import (
+	"github.com/Shopify/sarama"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway"
+)
+
+var _ = Describe("Compression", func() {
+
+	It("parses a codec", func() {
+		compression, err := gateway.ParseCompression("lz4")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(compression).To(Equal(gateway.Compression{Codec: sarama.CompressionLZ4, Level: sarama.CompressionLevelDefault}))
+	})
+
+	It("parses a codec and a level", func() {
+		compression, err := gateway.ParseCompression("zstd:3")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(compression).To(Equal(gateway.Compression{Codec: sarama.CompressionZSTD, Level: 3}))
+	})
+
+	It("rejects unknown codecs", func() {
+		_, err := gateway.ParseCompression("brotli")
+
+		Expect(err).To(MatchError(`compression codec "brotli" should be one of none, gzip, snappy, lz4 or zstd`))
+	})
+
+	It("rejects levels that are not integers", func() {
+		_, err := gateway.ParseCompression("gzip:best")
+
+		Expect(err).To(MatchError(`compression level "best" should be an integer`))
+	})
+
+	It("rejects levels the codec does not support", func() {
+		_, err := gateway.ParseCompression("gzip:42")
+
+		Expect(err).To(MatchError(ContainSubstring("gzip compression does not work with level 42")))
+	})
+})
@@ -0,0 +1,106 @@
+// Package content records the content types of the values of streams and negotiates the content type values
+// are delivered in, converting them between JSON and protobuf for the streams whose message type is known
+package content
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Header is the Kafka record header recording the content type of a value
+	Header = "content-type"
+
+	JSON     = "application/json"
+	Protobuf = "application/x-protobuf"
+)
+
+// MediaType returns the lower cased media type of a content type, without its parameters
+func MediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType
+}
+
+// Negotiate returns the offered content type preferred by an Accept header, offers being in order of preference
+// for equal qualities. Anything is acceptable when the header is empty.
+func Negotiate(accept string, offered ...string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		if len(offered) == 0 {
+			return "", false
+		}
+		return offered[0], true
+	}
+	ranges := parseAccept(accept)
+	best, bestQuality := "", 0.0
+	for _, offer := range offered {
+		if quality := ranges.quality(MediaType(offer)); quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+	return best, bestQuality > 0
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+type mediaRanges []mediaRange
+
+func parseAccept(accept string) mediaRanges {
+	var ranges mediaRanges
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+	}
+	// the most specific range matching a media type applies
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return specificity(ranges[i].mediaType) > specificity(ranges[j].mediaType)
+	})
+	return ranges
+}
+
+func (r mediaRanges) quality(mediaType string) float64 {
+	for _, mediaRange := range r {
+		if matches(mediaRange.mediaType, mediaType) {
+			return mediaRange.quality
+		}
+	}
+	return 0
+}
+
+func specificity(mediaRange string) int {
+	switch {
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func matches(mediaRange, mediaType string) bool {
+	switch {
+	case mediaRange == "*/*":
+		return true
+	case strings.HasSuffix(mediaRange, "/*"):
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*"))
+	default:
+		return mediaRange == mediaType
+	}
+}
@@ -0,0 +1,31 @@
+package content_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/content"
+)
+
+var _ = Describe("Content negotiation", func() {
+
+	DescribeTable("choosing the content type",
+		func(accept string, offered []string, expected string, acceptable bool) {
+			contentType, ok := content.Negotiate(accept, offered...)
+
+			Expect(ok).To(Equal(acceptable))
+			Expect(contentType).To(Equal(expected))
+		},
+		Entry("anything without Accept header", "", []string{content.JSON, content.Protobuf}, content.JSON, true),
+		Entry("the accepted type", "application/x-protobuf", []string{content.JSON, content.Protobuf}, content.Protobuf, true),
+		Entry("the type of highest quality", "application/json;q=0.5, application/x-protobuf", []string{content.JSON, content.Protobuf}, content.Protobuf, true),
+		Entry("the first offer for equal qualities", "application/*", []string{content.JSON, content.Protobuf}, content.JSON, true),
+		Entry("the most specific range", "application/*;q=0.1, application/json;q=0", []string{content.JSON, content.Protobuf}, content.Protobuf, true),
+		Entry("offers with parameters", "application/json", []string{"application/json; charset=utf-8"}, "application/json; charset=utf-8", true),
+		Entry("nothing acceptable", "text/plain", []string{content.JSON, content.Protobuf}, "", false),
+	)
+
+	It("extracts media types", func() {
+		Expect(content.MediaType("Application/JSON; charset=utf-8")).To(Equal("application/json"))
+	})
+})
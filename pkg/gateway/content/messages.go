@@ -0,0 +1,157 @@
+package content
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// InvalidPayloadError reports a value that doesn't conform to the message type of its stream
+type InvalidPayloadError struct {
+	Topic       string
+	MessageType protoreflect.FullName
+	Err         error
+}
+
+func (e *InvalidPayloadError) Error() string {
+	return fmt.Sprintf("value is not a valid %s message of topic %s: %v", e.MessageType, e.Topic, e.Err)
+}
+
+// Messages are the protobuf message types of streams, keyed by topic
+type Messages struct {
+	types map[string]protoreflect.MessageType
+}
+
+// NewMessages creates messages without any type
+func NewMessages() *Messages {
+	return &Messages{types: make(map[string]protoreflect.MessageType)}
+}
+
+// Register sets the message type of the values of a topic
+func (m *Messages) Register(topic string, messageType protoreflect.MessageType) {
+	m.types[topic] = messageType
+}
+
+// Validate checks that a JSON or protobuf value published to a topic having a message type is a valid message,
+// returning an *InvalidPayloadError otherwise. Values of other content types are not checked.
+func (m *Messages) Validate(topic string, contentType string, value []byte) error {
+	if m == nil || m.types[topic] == nil {
+		return nil
+	}
+	_, err := m.unmarshal(topic, MediaType(contentType), value)
+	return err
+}
+
+// Offers returns the content types a value of a topic can be delivered in, its own first
+func (m *Messages) Offers(topic string, contentType string) []string {
+	offers := []string{contentType}
+	if m == nil || m.types[topic] == nil {
+		return offers
+	}
+	switch MediaType(contentType) {
+	case JSON:
+		return append(offers, Protobuf)
+	case Protobuf:
+		return append(offers, JSON)
+	}
+	return offers
+}
+
+// Convert converts a value of a topic to one of the content types offered for it
+func (m *Messages) Convert(topic string, value []byte, from string, to string) ([]byte, error) {
+	from, to = MediaType(from), MediaType(to)
+	if from == to {
+		return value, nil
+	}
+	message, err := m.unmarshal(topic, from, value)
+	if err != nil || message == nil {
+		return nil, fmt.Errorf("can't convert values of topic %s from %s to %s: %v", topic, from, to, err)
+	}
+	switch to {
+	case JSON:
+		return protojson.Marshal(message)
+	case Protobuf:
+		return proto.Marshal(message)
+	}
+	return nil, fmt.Errorf("can't convert values of topic %s from %s to %s", topic, from, to)
+}
+
+func (m *Messages) unmarshal(topic string, mediaType string, value []byte) (proto.Message, error) {
+	messageType := m.types[topic]
+	if messageType == nil {
+		return nil, fmt.Errorf("topic %s has no message type", topic)
+	}
+	message := messageType.New().Interface()
+	var err error
+	switch mediaType {
+	case JSON:
+		err = protojson.Unmarshal(value, message)
+	case Protobuf:
+		err = proto.Unmarshal(value, message)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &InvalidPayloadError{Topic: topic, MessageType: messageType.Descriptor().FullName(), Err: err}
+	}
+	return message, nil
+}
+
+// Config names the message types of streams
+type Config struct {
+	// DescriptorSet is the path of a serialized FileDescriptorSet defining the message types, relative to the
+	// config file, as written by protoc --include_imports --descriptor_set_out
+	DescriptorSet string `json:"descriptorSet"`
+	// Streams maps topics to the full names of their message types
+	Streams map[string]string `json:"streams"`
+}
+
+// LoadMessages reads a JSON config file and the descriptor set it names
+func LoadMessages(path string) (*Messages, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := Config{}
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("invalid message types config %q: %v", path, err)
+	}
+	descriptorSetPath := config.DescriptorSet
+	if !filepath.IsAbs(descriptorSetPath) {
+		descriptorSetPath = filepath.Join(filepath.Dir(path), descriptorSetPath)
+	}
+	content, err = ioutil.ReadFile(descriptorSetPath)
+	if err != nil {
+		return nil, err
+	}
+	descriptorSet := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(content, descriptorSet); err != nil {
+		return nil, fmt.Errorf("invalid descriptor set %q: %v", descriptorSetPath, err)
+	}
+	files, err := protodesc.NewFiles(descriptorSet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set %q: %v", descriptorSetPath, err)
+	}
+
+	messages := NewMessages()
+	for topic, name := range config.Streams {
+		descriptor, err := files.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("stream %q refers to unknown message type %q", topic, name)
+		}
+		messageDescriptor, ok := descriptor.(protoreflect.MessageDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("stream %q refers to %q, which is not a message type", topic, name)
+		}
+		messages.Register(topic, dynamicpb.NewMessageType(messageDescriptor))
+	}
+	return messages, nil
+}
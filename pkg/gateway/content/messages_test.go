@@ -0,0 +1,113 @@
+package content_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/content"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// greetings defines the test.Greeting message type, with a single greeting string field
+var greetings = &descriptorpb.FileDescriptorSet{
+	File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("greeting.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Greeting"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("greeting"),
+				JsonName: proto.String("greeting"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			}},
+		}},
+	}},
+}
+
+// hello is the protobuf encoding of a test.Greeting saying hello
+var hello = []byte{10, 5, 'h', 'e', 'l', 'l', 'o'}
+
+var _ = Describe("Messages", func() {
+
+	var (
+		dir      string
+		messages *content.Messages
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "messages")
+		Expect(err).NotTo(HaveOccurred())
+		descriptorSet, err := proto.Marshal(greetings)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "greetings.pb"), descriptorSet, 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(`{
+			"descriptorSet": "greetings.pb",
+			"streams": {"ns_greetings": "test.Greeting"}
+		}`), 0644)).To(Succeed())
+
+		messages, err = content.LoadMessages(filepath.Join(dir, "config.json"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("validates the values of streams having a message type", func() {
+		Expect(messages.Validate("ns_greetings", content.Protobuf, hello)).To(Succeed())
+		Expect(messages.Validate("ns_greetings", content.JSON, []byte(`{"greeting": "hello"}`))).To(Succeed())
+
+		err := messages.Validate("ns_greetings", "application/json; charset=utf-8", []byte(`{"salutation": "hello"}`))
+		Expect(err).To(BeAssignableToTypeOf(&content.InvalidPayloadError{}))
+		Expect(err.Error()).To(HavePrefix("value is not a valid test.Greeting message of topic ns_greetings"))
+		Expect(messages.Validate("ns_greetings", content.Protobuf, []byte{10, 5})).To(BeAssignableToTypeOf(&content.InvalidPayloadError{}))
+	})
+
+	It("doesn't validate other values", func() {
+		Expect(messages.Validate("ns_other", content.Protobuf, []byte{10, 5})).To(Succeed())
+		Expect(messages.Validate("ns_greetings", "text/plain", []byte("hello"))).To(Succeed())
+	})
+
+	It("offers conversions between JSON and protobuf", func() {
+		Expect(messages.Offers("ns_greetings", content.JSON)).To(Equal([]string{content.JSON, content.Protobuf}))
+		Expect(messages.Offers("ns_greetings", content.Protobuf)).To(Equal([]string{content.Protobuf, content.JSON}))
+		Expect(messages.Offers("ns_greetings", "text/plain")).To(Equal([]string{"text/plain"}))
+		Expect(messages.Offers("ns_other", content.JSON)).To(Equal([]string{content.JSON}))
+	})
+
+	It("converts values between JSON and protobuf", func() {
+		value, err := messages.Convert("ns_greetings", hello, content.Protobuf, content.JSON)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(MatchJSON(`{"greeting": "hello"}`))
+
+		value, err = messages.Convert("ns_greetings", []byte(`{"greeting": "hello"}`), content.JSON, content.Protobuf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal(hello))
+	})
+
+	It("rejects configs referring to unknown message types", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(`{
+			"descriptorSet": "greetings.pb",
+			"streams": {"ns_greetings": "test.Salutation"}
+		}`), 0644)).To(Succeed())
+
+		_, err := content.LoadMessages(filepath.Join(dir, "config.json"))
+
+		Expect(err).To(MatchError(`stream "ns_greetings" refers to unknown message type "test.Salutation"`))
+	})
+
+	It("does nothing when nil", func() {
+		var messages *content.Messages
+
+		Expect(messages.Validate("ns_greetings", content.Protobuf, []byte{10, 5})).To(Succeed())
+		Expect(messages.Offers("ns_greetings", content.JSON)).To(Equal([]string{content.JSON}))
+	})
+})
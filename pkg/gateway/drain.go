@@ -0,0 +1,156 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultDrainTimeout is how long revoked partitions wait for their records to be acknowledged, well below
+// the time Kafka gives members to rejoin their group on rebalances
+const DefaultDrainTimeout = 10 * time.Second
+
+// pending tracks how far the records of an assignment have been delivered and acknowledged, so that a revoked
+// partition can wait for the subscriber to catch up before being handed over to another member
+type pending struct {
+	m sync.Mutex
+	// delivered and acknowledged are the offsets following the last record delivered and acknowledged
+	delivered    int64
+	acknowledged int64
+	// skipped is the offset following the last record filtered out rather than delivered
+	skipped int64
+	// acked is signalled on each acknowledgment
+	acked chan struct{}
+}
+
+func newPending() *pending {
+	return &pending{delivered: -1, acknowledged: -1, skipped: -1, acked: make(chan struct{}, 1)}
+}
+
+func (p *pending) deliver(offset int64) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if offset >= p.delivered {
+		p.delivered = offset + 1
+	}
+}
+
+func (p *pending) acknowledge(offset int64) {
+	p.m.Lock()
+	if offset >= p.acknowledged {
+		p.acknowledged = offset + 1
+	}
+	p.m.Unlock()
+	select {
+	case p.acked <- struct{}{}:
+	default:
+	}
+}
+
+// committable returns the offset that can be committed cumulatively once a record is acknowledged: past the records
+// skipped since, when all the records delivered are then acknowledged
+func (p *pending) committable(offset int64) int64 {
+	p.m.Lock()
+	defer p.m.Unlock()
+	commit := offset + 1
+	if max(p.acknowledged, offset+1) >= p.delivered && p.skipped > commit {
+		commit = p.skipped
+	}
+	return commit
+}
+
+// skip records that a record was filtered out rather than delivered, returning the offset that can be committed
+// cumulatively past it, or false while records delivered before it aren't acknowledged, the acknowledgment of the
+// last of them committing past it then
+func (p *pending) skip(offset int64) (int64, bool) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if offset >= p.skipped {
+		p.skipped = offset + 1
+	}
+	return p.skipped, p.acknowledged >= p.delivered
+}
+
+func (p *pending) settled() bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.acknowledged >= p.delivered
+}
+
+// drained tells whether all the records delivered have been acknowledged, individually with redelivery,
+// cumulatively otherwise
+func (a *assignment) drained() bool {
+	if a.inFlight != nil {
+		return a.inFlight.empty()
+	}
+	return a.pending.settled()
+}
+
+// drain waits for the records delivered from a revoked partition to be acknowledged, for at most the drain
+// timeout or until the subscriber disconnects, so that the member the partition is assigned to next doesn't
+// receive them again
+func (s *Server) drain(ctx context.Context, a *assignment) {
+	timeout := s.DrainTimeout
+	if timeout == 0 {
+		timeout = DefaultDrainTimeout
+	}
+	if timeout < 0 || a.drained() {
+		return
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for !a.drained() {
+		select {
+		case <-a.pending.acked:
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			s.Logger.Warn("Handing over partition with records not acknowledged", "topic", a.claim.Topic(), "partition", a.claim.Partition(), "group", a.groupID)
+			return
+		}
+	}
+}
+
+const shuttingDown = "the gateway is shutting down, subscribe again"
+
+// startSubscription counts a subscription in, returning a channel closed when the gateway shuts down, or
+// false when it already has
+func (s *Server) startSubscription() (<-chan struct{}, bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.stopping == nil {
+		s.stopping = make(chan struct{})
+	}
+	if s.shutDown {
+		return nil, false
+	}
+	s.subscriptions.Add(1)
+	return s.stopping, true
+}
+
+// Shutdown ends the subscriptions, their partitions being drained and their offsets committed, and waits
+// for them to leave their consumer groups or for ctx to be done. Subscriptions then fail with an
+// UNAVAILABLE status, for subscribers to subscribe again to another replica.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.m.Lock()
+	if s.stopping == nil {
+		s.stopping = make(chan struct{})
+	}
+	if !s.shutDown {
+		s.shutDown = true
+		close(s.stopping)
+	}
+	s.m.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.subscriptions.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,207 @@
+package gateway_test
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+)
+
+// fakeConsumerGroup assigns all its claims to the single member consuming it
+type fakeConsumerGroup struct {
+	claims  []*fakeClaim
+	session *fakeSession
+
+	m      sync.Mutex
+	closed bool
+	paused bool
+}
+
+func (g *fakeConsumerGroup) Consume(ctx context.Context, _ []string, handler sarama.ConsumerGroupHandler) error {
+	sessionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	g.session.setContext(sessionCtx)
+	if err := handler.Setup(g.session); err != nil {
+		return err
+	}
+	wg := sync.WaitGroup{}
+	for _, claim := range g.claims {
+		wg.Add(1)
+		go func(claim *fakeClaim) {
+			defer wg.Done()
+			_ = handler.ConsumeClaim(g.session, claim)
+		}(claim)
+	}
+	<-ctx.Done()
+	cancel()
+	wg.Wait()
+	return handler.Cleanup(g.session)
+}
+
+func (g *fakeConsumerGroup) Errors() <-chan error {
+	return nil
+}
+
+func (g *fakeConsumerGroup) Pause(map[string][]int32) {
+	g.m.Lock()
+	defer g.m.Unlock()
+	g.paused = true
+}
+
+func (g *fakeConsumerGroup) Resume(map[string][]int32) {
+	g.m.Lock()
+	defer g.m.Unlock()
+	g.paused = false
+}
+
+func (g *fakeConsumerGroup) Paused() bool {
+	g.m.Lock()
+	defer g.m.Unlock()
+	return g.paused
+}
+
+func (g *fakeConsumerGroup) PauseAll() {}
+
+func (g *fakeConsumerGroup) ResumeAll() {}
+
+func (g *fakeConsumerGroup) Close() error {
+	g.m.Lock()
+	defer g.m.Unlock()
+	g.closed = true
+	return nil
+}
+
+func (g *fakeConsumerGroup) Closed() bool {
+	g.m.Lock()
+	defer g.m.Unlock()
+	return g.closed
+}
+
+type fakeSession struct {
+	ctx context.Context
+
+	m       sync.Mutex
+	marked  map[int32]int64
+	commits int
+}
+
+func (s *fakeSession) Claims() map[string][]int32 {
+	return nil
+}
+
+func (s *fakeSession) MemberID() string {
+	return "member"
+}
+
+func (s *fakeSession) GenerationID() int32 {
+	return 1
+}
+
+func (s *fakeSession) MarkOffset(_ string, partition int32, offset int64, _ string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.marked == nil {
+		s.marked = make(map[int32]int64)
+	}
+	s.marked[partition] = offset
+}
+
+func (s *fakeSession) Marked(partition int32) int64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.marked[partition]
+}
+
+func (s *fakeSession) Commit() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.commits++
+}
+
+func (s *fakeSession) Commits() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.commits
+}
+
+func (s *fakeSession) ResetOffset(string, int32, int64, string) {}
+
+func (s *fakeSession) MarkMessage(message *sarama.ConsumerMessage, metadata string) {
+	s.MarkOffset(message.Topic, message.Partition, message.Offset+1, metadata)
+}
+
+func (s *fakeSession) setContext(ctx context.Context) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.ctx = ctx
+}
+
+func (s *fakeSession) Context() context.Context {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.ctx
+}
+
+type fakeClaim struct {
+	topic         string
+	partition     int32
+	messages      chan *sarama.ConsumerMessage
+	highWaterMark int64
+}
+
+func (c *fakeClaim) Topic() string {
+	return c.topic
+}
+
+func (c *fakeClaim) Partition() int32 {
+	return c.partition
+}
+
+func (c *fakeClaim) InitialOffset() int64 {
+	return 0
+}
+
+func (c *fakeClaim) HighWaterMarkOffset() int64 {
+	return c.highWaterMark
+}
+
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return c.messages
+}
+
+// recordingProducer records the offsets committed by transactions and whether they were aborted
+type recordingProducer struct {
+	*mocks.SyncProducer
+
+	offsets map[string][]*sarama.PartitionOffsetMetadata
+	groupID string
+	aborted bool
+	closed  bool
+}
+
+func newRecordingProducer(t mocks.ErrorReporter, transactionalID string) *recordingProducer {
+	config := sarama.NewConfig()
+	config.Version = sarama.V0_11_0_0
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Idempotent = true
+	config.Net.MaxOpenRequests = 1
+	config.Producer.Transaction.ID = transactionalID
+	return &recordingProducer{SyncProducer: mocks.NewSyncProducer(t, config)}
+}
+
+func (p *recordingProducer) AddOffsetsToTxn(offsets map[string][]*sarama.PartitionOffsetMetadata, groupID string) error {
+	p.offsets, p.groupID = offsets, groupID
+	return p.SyncProducer.AddOffsetsToTxn(offsets, groupID)
+}
+
+func (p *recordingProducer) AbortTxn() error {
+	p.aborted = true
+	return p.SyncProducer.AbortTxn()
+}
+
+func (p *recordingProducer) Close() error {
+	p.closed = true
+	return p.SyncProducer.Close()
+}
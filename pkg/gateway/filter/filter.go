@@ -0,0 +1,114 @@
+// Package filter selects the records delivered to subscribers by matching expressions on their headers, so that
+// subscribers interested in a subset of the records of a stream don't receive and discard the others
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/cloudevents"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/content"
+)
+
+// Filter matches records whose headers satisfy all of its conditions
+type Filter struct {
+	conditions []condition
+}
+
+// condition compares the value of a header, or of a CloudEvent attribute, to a value
+type condition struct {
+	header string
+	// attribute is the CloudEvent attribute the condition is on, if any
+	attribute string
+	value     string
+	negated   bool
+}
+
+// Parse parses expressions of the form `<header> = "<value>"` or `<header> != "<value>"`, quotes being optional
+// for values without spaces. Headers are matched ignoring case. Headers prefixed with ce- or ce_ name attributes of
+// CloudEvents, in either binary or structured mode. A nil filter is returned when there is no expression.
+func Parse(expressions []string) (*Filter, error) {
+	if len(expressions) == 0 {
+		return nil, nil
+	}
+	f := &Filter{}
+	for _, expression := range expressions {
+		c, err := parseCondition(expression)
+		if err != nil {
+			return nil, err
+		}
+		f.conditions = append(f.conditions, c)
+	}
+	return f, nil
+}
+
+func parseCondition(expression string) (condition, error) {
+	c := condition{}
+	operator := "="
+	i := strings.Index(expression, "=")
+	if i < 0 {
+		return c, fmt.Errorf("filter %q should be of the form <header> = \"<value>\" or <header> != \"<value>\"", expression)
+	}
+	name := expression[:i]
+	if strings.HasSuffix(name, "!") {
+		operator = "!="
+		name = strings.TrimSuffix(name, "!")
+		c.negated = true
+	}
+	c.header = strings.ToLower(strings.TrimSpace(name))
+	if c.header == "" {
+		return c, fmt.Errorf("filter %q should name a header before %s", expression, operator)
+	}
+	value := strings.TrimSpace(expression[i+1:])
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return c, fmt.Errorf("filter %q has an invalid quoted value: %v", expression, err)
+		}
+		value = unquoted
+	} else if strings.ContainsAny(value, " \t") {
+		return c, fmt.Errorf("filter %q should quote values containing spaces", expression)
+	}
+	c.value = value
+	if strings.HasPrefix(c.header, cloudevents.HTTPPrefix) || strings.HasPrefix(c.header, cloudevents.KafkaPrefix) {
+		c.attribute = c.header[len(cloudevents.KafkaPrefix):]
+	}
+	return c, nil
+}
+
+// Matches tells whether a record satisfies the conditions of the filter, which a nil filter always does
+func (f *Filter) Matches(headers []*sarama.RecordHeader, value []byte) bool {
+	if f == nil {
+		return true
+	}
+	values := make(map[string][]byte, len(headers))
+	for _, header := range headers {
+		values[strings.ToLower(string(header.Key))] = header.Value
+	}
+	var event *cloudevents.Event
+	eventRead := false
+	for _, c := range f.conditions {
+		var actual string
+		var ok bool
+		if c.attribute != "" {
+			if !eventRead {
+				// events that can't be read have no attribute
+				event, _ = cloudevents.FromKafka(values, string(values[content.Header]), value)
+				eventRead = true
+			}
+			if event != nil {
+				actual, ok = event.Attributes[c.attribute]
+			}
+		} else {
+			var v []byte
+			v, ok = values[c.header]
+			actual = string(v)
+		}
+		if (ok && actual == c.value) == c.negated {
+			return false
+		}
+	}
+	return true
+}
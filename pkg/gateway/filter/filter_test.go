@@ -0,0 +1,60 @@
+package filter_test
+
+import (
+	"github.com/Shopify/sarama"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/filter"
+)
+
+var _ = Describe("Header filters", func() {
+
+	headers := []*sarama.RecordHeader{
+		{Key: []byte("ce_specversion"), Value: []byte("1.0")},
+		{Key: []byte("ce_id"), Value: []byte("42")},
+		{Key: []byte("ce_source"), Value: []byte("/orders")},
+		{Key: []byte("ce_type"), Value: []byte("order.created")},
+		{Key: []byte("Tenant"), Value: []byte("acme corp")},
+	}
+
+	DescribeTable("matching records",
+		func(expressions []string, matches bool) {
+			f, err := filter.Parse(expressions)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f.Matches(headers, []byte("{}"))).To(Equal(matches))
+		},
+		Entry("without expression", nil, true),
+		Entry("equal headers", []string{`ce_type = "order.created"`}, true),
+		Entry("CloudEvent attributes named as HTTP headers", []string{`ce-type="order.created"`}, true),
+		Entry("headers ignoring case", []string{`tenant = "acme corp"`}, true),
+		Entry("unquoted values", []string{`ce-source = /orders`}, true),
+		Entry("different headers", []string{`ce-type = "order.shipped"`}, false),
+		Entry("all the expressions", []string{`ce-type = "order.created"`, `tenant != "acme corp"`}, false),
+		Entry("missing headers", []string{`priority = "high"`}, false),
+		Entry("missing headers not equal to a value", []string{`priority != "high"`}, true),
+	)
+
+	It("matches the attributes of CloudEvents in structured mode", func() {
+		f, err := filter.Parse([]string{`ce-type = "order.created"`})
+		Expect(err).NotTo(HaveOccurred())
+
+		structured := []*sarama.RecordHeader{{Key: []byte("content-type"), Value: []byte("application/cloudevents+json")}}
+		event := `{"specversion": "1.0", "id": "42", "source": "/orders", "type": "order.created"}`
+		Expect(f.Matches(structured, []byte(event))).To(BeTrue())
+		Expect(f.Matches(structured, []byte("not an event"))).To(BeFalse())
+	})
+
+	DescribeTable("rejecting invalid expressions",
+		func(expression string) {
+			_, err := filter.Parse([]string{expression})
+
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("without operator", "ce-type"),
+		Entry("without header", `= "order.created"`),
+		Entry("with unterminated quotes", `ce-type = "order.created`),
+		Entry("with unquoted spaces", `tenant = acme corp`),
+	)
+})
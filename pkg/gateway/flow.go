@@ -0,0 +1,73 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/liiklus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// credits counts the records a subscriber controlling the flow of an assignment asked for and has not been
+// delivered yet
+type credits struct {
+	m         sync.Mutex
+	available int
+	// granted is signalled when credits are added
+	granted chan struct{}
+}
+
+func newCredits(initial uint32) *credits {
+	return &credits{available: int(initial), granted: make(chan struct{}, 1)}
+}
+
+func (c *credits) grant(count uint32) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.available += int(count)
+	select {
+	case c.granted <- struct{}{}:
+	default:
+	}
+}
+
+// remaining returns the number of records that can be delivered
+func (c *credits) remaining() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.available
+}
+
+// take uses the credit of a delivered record
+func (c *credits) take() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.available--
+}
+
+// RequestRecords asks for more records of a partition whose records are received with flow control
+func (s *Server) RequestRecords(_ context.Context, request *liiklus.RequestRecordsRequest) (*emptypb.Empty, error) {
+	a, err := s.findAssignment("", request.Topic, request.Group, request.GroupVersion, request.Partition)
+	if err != nil {
+		return nil, err
+	}
+	s.m.Lock()
+	credits := a.credits
+	s.m.Unlock()
+	if credits == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "records of partition %d are not received with flow control", request.Partition)
+	}
+	credits.grant(request.Count)
+	return &emptypb.Empty{}, nil
+}
+
+// pause stops fetching the records of the partition of an assignment, until resumed
+func (a *assignment) pause() {
+	a.group.Pause(map[string][]int32{a.claim.Topic(): {a.claim.Partition()}})
+}
+
+func (a *assignment) resume() {
+	a.group.Resume(map[string][]int32{a.claim.Topic(): {a.claim.Partition()}})
+}
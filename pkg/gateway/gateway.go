@@ -0,0 +1,433 @@
+// Package gateway implements the liiklus gRPC API on top of Kafka, so that stream processors can publish
+// and subscribe to the topics created by the provisioner
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/avro"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/content"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/liiklus"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/metrics"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/transform"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server serves the liiklus API
+type Server struct {
+	liiklus.UnimplementedLiiklusServiceServer
+
+	// Client is used to look up partitions and offsets
+	Client sarama.Client
+	// Producer publishes records
+	Producer sarama.SyncProducer
+	// Consumer reads records by offset
+	Consumer sarama.Consumer
+	// Admin manages consumer groups
+	Admin sarama.ClusterAdmin
+	// APIVersions, when set, are the versions of the APIs the cluster supports, calls relying on features it lacks
+	// being refused with guidance
+	APIVersions client.APIVersions
+	// StreamProducers publish the records of the topics compressed differently than by Producer
+	StreamProducers map[string]sarama.SyncProducer
+	// NewTransactionalProducer creates the producer of a transactional id, transactions being disabled when nil
+	NewTransactionalProducer func(transactionalID string) (sarama.SyncProducer, error)
+	// NewConsumerGroup joins a consumer group
+	NewConsumerGroup func(groupID string, options GroupOptions) (sarama.ConsumerGroup, error)
+	Logger           *slog.Logger
+	// CommitStrategy applies to subscriptions not choosing one, AUTO when DEFAULT
+	CommitStrategy liiklus.SubscribeRequest_CommitStrategy
+	// AutoCommitInterval applies to subscriptions committing automatically without choosing an interval,
+	// 1 second when zero
+	AutoCommitInterval time.Duration
+	// MaxPayloadBytes, when positive, is the size of the largest key and value accepted by Publish
+	MaxPayloadBytes int
+	// Transforms, when set, transform the records of streams as they are published and delivered
+	Transforms *transform.Hooks
+	// Messages, when set, are the protobuf message types of streams, whose values are then validated on publish
+	// and converted between protobuf and JSON as subscribers prefer
+	Messages *content.Messages
+	// Avro, when set, serializes the values of the streams having an Avro schema in the registry format
+	Avro *avro.Serializer
+	// Streams, when set, is the metadata the provisioner recorded for streams, calls on archived streams being
+	// refused, with UnaryInterceptor and StreamInterceptor installed on the gRPC server
+	Streams *StreamMetadata
+	// Metrics, when set, measure the throughput of streams and the lag of their consumer groups
+	Metrics *metrics.Metrics
+	// Authorization, when set, requires callers to present a bearer token allowed on the streams they call
+	// the gateway on, with UnaryInterceptor and StreamInterceptor installed on the gRPC server
+	Authorization *Authorization
+	// RedeliveryTimeout is how long a record may stay unacknowledged before being delivered again. Records
+	// are not redelivered when zero.
+	RedeliveryTimeout time.Duration
+	// DrainTimeout is how long partitions revoked by a rebalance or Shutdown wait for the records delivered to
+	// be acknowledged before being handed over, DefaultDrainTimeout when zero. Partitions are handed over right
+	// away when negative.
+	DrainTimeout time.Duration
+
+	m                      sync.Mutex
+	assignments            map[string]*assignment
+	transactionalProducers map[string]*transactionalProducer
+	stopping               chan struct{}
+	shutDown               bool
+	subscriptions          sync.WaitGroup
+}
+
+// ProducerOptions configures how records are published
+type ProducerOptions struct {
+	// Idempotent prevents retries from duplicating records
+	Idempotent bool
+	// Transactional enables the Transact call, records then being consumed in the read_committed isolation level
+	Transactional bool
+	// MaxPayloadBytes, when positive, is the size of the largest key and value accepted
+	MaxPayloadBytes int
+	// Compression applies to the records of all topics but those of StreamCompression. Records are not
+	// compressed when zero.
+	Compression Compression
+	// StreamCompression overrides the compression of the records of some topics, but those published by Transact,
+	// which are compressed as set by Compression
+	StreamCompression map[string]Compression
+	// Partitioner chooses the partitioner of each topic, records being partitioned by key hash when nil
+	Partitioner sarama.PartitionerConstructor
+	// Tuning adapts the connections to the brokers, idempotent producers keeping a single open request
+	Tuning client.Tuning
+}
+
+// NewServer connects to the given Kafka brokers
+func NewServer(brokers []string, options ProducerOptions, logger *slog.Logger) (*Server, error) {
+	config := sarama.NewConfig()
+	config.Version = sarama.V0_11_0_0
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Consumer.IsolationLevel = sarama.ReadCommitted
+	options.Tuning.Apply(config)
+	if options.Idempotent {
+		config.Producer.Idempotent = true
+		config.Net.MaxOpenRequests = 1
+	}
+	if maxMessageBytes := client.MaxMessageBytes(options.MaxPayloadBytes); options.MaxPayloadBytes > 0 && maxMessageBytes > config.Producer.MaxMessageBytes {
+		config.Producer.MaxMessageBytes = maxMessageBytes
+	}
+
+	if options.Compression == (Compression{}) {
+		options.Compression = DefaultCompression
+	}
+	options.Compression.configure(config)
+	if options.Partitioner != nil {
+		config.Producer.Partitioner = options.Partitioner
+	}
+
+	kafkaClient, err := sarama.NewClient(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+	producer, err := sarama.NewSyncProducerFromClient(kafkaClient)
+	if err != nil {
+		_ = kafkaClient.Close()
+		return nil, err
+	}
+	consumer, err := sarama.NewConsumerFromClient(kafkaClient)
+	if err != nil {
+		_ = producer.Close()
+		_ = kafkaClient.Close()
+		return nil, err
+	}
+	// deleting consumer groups requires Kafka 1.1
+	adminConfig := *config
+	if !adminConfig.Version.IsAtLeast(sarama.V1_1_0_0) {
+		adminConfig.Version = sarama.V1_1_0_0
+	}
+	admin, err := sarama.NewClusterAdmin(brokers, &adminConfig)
+	if err != nil {
+		_ = consumer.Close()
+		_ = producer.Close()
+		_ = kafkaClient.Close()
+		return nil, err
+	}
+	// topics compressed alike share a producer
+	producers := map[Compression]sarama.SyncProducer{options.Compression: producer}
+	streamProducers := make(map[string]sarama.SyncProducer)
+	for topic, compression := range options.StreamCompression {
+		if producers[compression] == nil {
+			producerConfig := *config
+			compression.configure(&producerConfig)
+			streamProducer, err := sarama.NewSyncProducer(brokers, &producerConfig)
+			if err != nil {
+				for _, p := range producers {
+					_ = p.Close()
+				}
+				_ = admin.Close()
+				_ = consumer.Close()
+				_ = kafkaClient.Close()
+				return nil, fmt.Errorf("error creating the producer of topic %s: %v", topic, err)
+			}
+			producers[compression] = streamProducer
+		}
+		streamProducers[topic] = producers[compression]
+	}
+	server := &Server{
+		Client:          kafkaClient,
+		Producer:        producer,
+		Consumer:        consumer,
+		Admin:           admin,
+		StreamProducers: streamProducers,
+		NewConsumerGroup: func(groupID string, options GroupOptions) (sarama.ConsumerGroup, error) {
+			groupConfig := *config
+			groupConfig.Consumer.Offsets.Initial = options.InitialOffset
+			groupConfig.Consumer.Offsets.AutoCommit.Enable = options.AutoCommitInterval > 0
+			if options.AutoCommitInterval > 0 {
+				groupConfig.Consumer.Offsets.AutoCommit.Interval = options.AutoCommitInterval
+			}
+			// both strategies are eager, sarama not implementing cooperative rebalancing: all partitions are revoked
+			// on rebalances, sticky assignments then giving them back to as many of their members as possible, range
+			// being kept for members of earlier versions to agree on a strategy during rolling updates
+			groupConfig.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.BalanceStrategySticky, sarama.BalanceStrategyRange}
+			return sarama.NewConsumerGroup(brokers, groupID, &groupConfig)
+		},
+		Logger:          logger,
+		MaxPayloadBytes: options.MaxPayloadBytes,
+	}
+	if options.Transactional {
+		server.NewTransactionalProducer = func(transactionalID string) (sarama.SyncProducer, error) {
+			producerConfig := *config
+			producerConfig.Producer.Idempotent = true
+			producerConfig.Net.MaxOpenRequests = 1
+			producerConfig.Producer.Transaction.ID = transactionalID
+			return sarama.NewSyncProducer(brokers, &producerConfig)
+		}
+	}
+	return server, nil
+}
+
+// Close disconnects from Kafka
+func (s *Server) Close() error {
+	s.closeTransactionalProducers()
+	closed := map[sarama.SyncProducer]bool{s.Producer: true}
+	for _, producer := range s.StreamProducers {
+		if !closed[producer] {
+			closed[producer] = true
+			_ = producer.Close()
+		}
+	}
+	if err := s.Producer.Close(); err != nil {
+		return err
+	}
+	if err := s.Consumer.Close(); err != nil {
+		return err
+	}
+	if err := s.Admin.Close(); err != nil {
+		return err
+	}
+	return s.Client.Close()
+}
+
+// Publish produces a record, unless a transformation filters it out, the reply then only telling its topic
+func (s *Server) Publish(ctx context.Context, request *liiklus.PublishRequest) (*liiklus.PublishReply, error) {
+	serialized, err := s.prepare(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if serialized == nil {
+		return &liiklus.PublishReply{Topic: request.Topic}, nil
+	}
+	partition, offset, err := s.producer(request.Topic).SendMessage(producerMessage(serialized))
+	if err != nil {
+		s.Logger.Error("Error publishing record", "topic", request.Topic, "error", err)
+		return nil, kafkaStatus(err)
+	}
+	s.Metrics.Published(request.Topic, len(serialized.Key)+len(serialized.Value))
+	return &liiklus.PublishReply{
+		Topic:     request.Topic,
+		Partition: uint32(partition),
+		Offset:    uint64(offset),
+	}, nil
+}
+
+// prepare validates, transforms and serializes a record to publish, returning nil when a transformation filters
+// it out
+func (s *Server) prepare(ctx context.Context, request *liiklus.PublishRequest) (*liiklus.PublishRequest, error) {
+	if request.Topic == "" {
+		return nil, status.Error(codes.InvalidArgument, "topic is required")
+	}
+	if err := s.checkPayloadSize(request); err != nil {
+		return nil, err
+	}
+	if err := s.checkContent(request); err != nil {
+		return nil, err
+	}
+	transformed, err := s.transformPublished(ctx, request)
+	if err != nil || transformed == nil {
+		return nil, err
+	}
+	return s.serialize(ctx, transformed)
+}
+
+// checkPayloadSize rejects records the topics can't store, rather than letting the broker reject them
+func (s *Server) checkPayloadSize(request *liiklus.PublishRequest) error {
+	if size := len(request.Key) + len(request.Value); s.MaxPayloadBytes > 0 && size > s.MaxPayloadBytes {
+		return status.Errorf(codes.ResourceExhausted, payloadTooLarge, size, s.MaxPayloadBytes)
+	}
+	return nil
+}
+
+// producer returns the producer of a topic, compressing its records as configured
+func (s *Server) producer(topic string) sarama.SyncProducer {
+	if producer, ok := s.StreamProducers[topic]; ok {
+		return producer
+	}
+	return s.Producer
+}
+
+// checkContent rejects values that aren't valid messages of the protobuf message type of their stream
+func (s *Server) checkContent(request *liiklus.PublishRequest) error {
+	if err := s.Messages.Validate(request.Topic, request.ContentType, request.Value); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
+
+const (
+	payloadGuidance = "split the payload into several records, or store it elsewhere (e.g. an object store) and publish a reference to it"
+	payloadTooLarge = "record of %d bytes exceeds the limit of %d bytes: " + payloadGuidance
+)
+
+// transformPublished returns nil when the record is filtered out. Transformations can't change the topic
+// a record is published to.
+func (s *Server) transformPublished(ctx context.Context, request *liiklus.PublishRequest) (*liiklus.PublishRequest, error) {
+	if s.Transforms == nil {
+		return request, nil
+	}
+	transformed, err := s.Transforms.Published(ctx, &transform.Record{Topic: request.Topic, Key: request.Key, Value: request.Value})
+	if err != nil {
+		s.Logger.Error("Error transforming record", "topic", request.Topic, "error", err)
+		return nil, status.Errorf(codes.Internal, "error transforming record: %v", err)
+	}
+	if transformed == nil {
+		return nil, nil
+	}
+	return &liiklus.PublishRequest{Topic: request.Topic, Key: transformed.Key, Value: transformed.Value, ContentType: request.ContentType, Headers: request.Headers}, nil
+}
+
+// serialize encodes the value of a record with the Avro schema of its stream, if any
+func (s *Server) serialize(ctx context.Context, request *liiklus.PublishRequest) (*liiklus.PublishRequest, error) {
+	value, err := s.Avro.Serialize(ctx, request.Topic, request.Value)
+	if err != nil {
+		if invalid, ok := err.(*avro.InvalidPayloadError); ok {
+			return nil, status.Error(codes.InvalidArgument, invalid.Error())
+		}
+		s.Logger.Error("Error serializing record", "topic", request.Topic, "error", err)
+		return nil, status.Errorf(codes.Unavailable, "error serializing record: %v", err)
+	}
+	return &liiklus.PublishRequest{Topic: request.Topic, Key: request.Key, Value: value, ContentType: request.ContentType, Headers: request.Headers}, nil
+}
+
+func producerMessage(request *liiklus.PublishRequest) *sarama.ProducerMessage {
+	message := &sarama.ProducerMessage{
+		Topic: request.Topic,
+		Value: sarama.ByteEncoder(request.Value),
+	}
+	if request.Key != nil {
+		message.Key = sarama.ByteEncoder(request.Key)
+	}
+	if request.ContentType != "" {
+		message.Headers = []sarama.RecordHeader{{Key: []byte(content.Header), Value: []byte(request.ContentType)}}
+	}
+	keys := make([]string, 0, len(request.Headers))
+	for key := range request.Headers {
+		if key != content.Header {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		message.Headers = append(message.Headers, sarama.RecordHeader{Key: []byte(key), Value: request.Headers[key]})
+	}
+	return message
+}
+
+// GetOffsets returns the offset of the last record acknowledged on each partition by the group
+func (s *Server) GetOffsets(_ context.Context, request *liiklus.GetOffsetsRequest) (*liiklus.GetOffsetsReply, error) {
+	partitions, err := s.Client.Partitions(request.Topic)
+	if err != nil {
+		return nil, kafkaStatus(err)
+	}
+	groupID := groupID(request.Group, request.GroupVersion)
+	coordinator, err := s.Client.Coordinator(groupID)
+	if err != nil {
+		return nil, kafkaStatus(err)
+	}
+	fetchRequest := &sarama.OffsetFetchRequest{ConsumerGroup: groupID, Version: 1}
+	for _, partition := range partitions {
+		fetchRequest.AddPartition(request.Topic, partition)
+	}
+	response, err := coordinator.FetchOffset(fetchRequest)
+	if err != nil {
+		return nil, kafkaStatus(err)
+	}
+	offsets := make(map[uint32]uint64)
+	for _, partition := range partitions {
+		block := response.GetBlock(request.Topic, partition)
+		if block == nil {
+			continue
+		}
+		if block.Err != sarama.ErrNoError {
+			return nil, kafkaStatus(block.Err)
+		}
+		// Kafka records the offset of the next record to consume
+		if block.Offset > 0 {
+			offsets[uint32(partition)] = uint64(block.Offset - 1)
+		}
+	}
+	return &liiklus.GetOffsetsReply{Offsets: offsets}, nil
+}
+
+// GetEndOffsets returns the offset of the last record of each non empty partition
+func (s *Server) GetEndOffsets(_ context.Context, request *liiklus.GetEndOffsetsRequest) (*liiklus.GetEndOffsetsReply, error) {
+	partitions, err := s.Client.Partitions(request.Topic)
+	if err != nil {
+		return nil, kafkaStatus(err)
+	}
+	offsets := make(map[uint32]uint64)
+	for _, partition := range partitions {
+		next, err := s.Client.GetOffset(request.Topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, kafkaStatus(err)
+		}
+		if next > 0 {
+			offsets[uint32(partition)] = uint64(next - 1)
+		}
+	}
+	return &liiklus.GetEndOffsetsReply{Offsets: offsets}, nil
+}
+
+// groupID names the Kafka consumer group of a liiklus group, bumping the version of a group starting
+// it over from scratch
+func groupID(group string, version uint32) string {
+	if version == 0 {
+		return group
+	}
+	return fmt.Sprintf("%s-v%d", group, version)
+}
+
+// kafkaStatus maps a Kafka error to the gRPC status telling clients whether to retry
+func kafkaStatus(err error) error {
+	if err == sarama.ErrUnknownTopicOrPartition {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	switch client.Classify(err) {
+	case client.Retryable:
+		return status.Error(codes.Unavailable, err.Error())
+	case client.Terminal:
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,941 @@
+package gateway_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/avro"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/content"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/liiklus"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/metrics"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/transform"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+var logger = slog.New(slog.NewTextHandler(ioutil.Discard, nil))
+
+var upperCase = transform.TransformerFunc(func(_ context.Context, record *transform.Record) (*transform.Record, error) {
+	return &transform.Record{Topic: record.Topic, Key: record.Key, Value: bytes.ToUpper(record.Value)}, nil
+})
+
+var dropAll = transform.TransformerFunc(func(context.Context, *transform.Record) (*transform.Record, error) {
+	return nil, nil
+})
+
+// schemaRegistry serves an Avro schema for the values of the ns_stream topic
+func schemaRegistry() *httptest.Server {
+	schema := `{"type": "record", "name": "Greeting", "fields": [{"name": "greeting", "type": "string"}]}`
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/subjects/ns_stream-value/versions/latest":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": 1, "schema": schema})
+		case "/schemas/ids/1":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"schema": schema})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code": 40401}`))
+		}
+	}))
+}
+
+var _ = Describe("Gateway", func() {
+
+	var (
+		server        *gateway.Server
+		producer      *mocks.SyncProducer
+		group         *fakeConsumerGroup
+		joinedGroup   string
+		joinedOptions gateway.GroupOptions
+		grpcServer    *grpc.Server
+		connection    *grpc.ClientConn
+		client        liiklus.LiiklusServiceClient
+		ctx           context.Context
+		cancel        context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		producer = mocks.NewSyncProducer(GinkgoT(), nil)
+		group = &fakeConsumerGroup{
+			claims: []*fakeClaim{
+				{topic: "ns_stream", partition: 0, messages: make(chan *sarama.ConsumerMessage, 10)},
+			},
+			session: &fakeSession{},
+		}
+		server = &gateway.Server{
+			Producer: producer,
+			NewConsumerGroup: func(groupID string, options gateway.GroupOptions) (sarama.ConsumerGroup, error) {
+				joinedGroup, joinedOptions = groupID, options
+				return group, nil
+			},
+			Logger: logger,
+		}
+
+		listener := bufconn.Listen(1024 * 1024)
+		grpcServer = grpc.NewServer()
+		liiklus.RegisterLiiklusServiceServer(grpcServer, server)
+		go func() {
+			_ = grpcServer.Serve(listener)
+		}()
+		var err error
+		connection, err = grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return listener.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).NotTo(HaveOccurred())
+		client = liiklus.NewLiiklusServiceClient(connection)
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	})
+
+	AfterEach(func() {
+		cancel()
+		_ = connection.Close()
+		grpcServer.Stop()
+		Expect(producer.Close()).To(Succeed())
+	})
+
+	Describe("publishing", func() {
+
+		It("produces the record to the topic", func() {
+			producer.ExpectSendMessageWithCheckerFunctionAndSucceed(func(value []byte) error {
+				Expect(string(value)).To(Equal("hello"))
+				return nil
+			})
+
+			reply, err := client.Publish(ctx, &liiklus.PublishRequest{Topic: "ns_stream", Key: []byte("key"), Value: []byte("hello")})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.Topic).To(Equal("ns_stream"))
+		})
+
+		It("transforms the record", func() {
+			server.Transforms = transform.NewHooks()
+			server.Transforms.OnPublish("ns_stream", "upper-case", upperCase)
+			producer.ExpectSendMessageWithCheckerFunctionAndSucceed(func(value []byte) error {
+				Expect(string(value)).To(Equal("HELLO"))
+				return nil
+			})
+
+			_, err := client.Publish(ctx, &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte("hello")})
+
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("drops records filtered out by a transformation", func() {
+			server.Transforms = transform.NewHooks()
+			server.Transforms.OnPublish("ns_stream", "drop-all", dropAll)
+
+			reply, err := client.Publish(ctx, &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte("hello")})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.Topic).To(Equal("ns_stream"))
+		})
+
+		It("rejects payloads larger than the limit with guidance", func() {
+			server.MaxPayloadBytes = 8
+
+			_, err := client.Publish(ctx, &liiklus.PublishRequest{Topic: "ns_stream", Key: []byte("key"), Value: []byte("hello!")})
+
+			Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+			Expect(status.Convert(err).Message()).To(HavePrefix("record of 9 bytes exceeds the limit of 8 bytes: split the payload"))
+		})
+
+		It("produces records with the producer of their stream when compressed differently", func() {
+			streamProducer := mocks.NewSyncProducer(GinkgoT(), nil)
+			defer streamProducer.Close()
+			server.StreamProducers = map[string]sarama.SyncProducer{"ns_compressed": streamProducer}
+			streamProducer.ExpectSendMessageAndSucceed()
+			producer.ExpectSendMessageAndSucceed()
+
+			_, err := client.Publish(ctx, &liiklus.PublishRequest{Topic: "ns_compressed", Value: []byte("hello")})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.Publish(ctx, &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte("hello")})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("records the content type and headers of the value", func() {
+			producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(message *sarama.ProducerMessage) error {
+				Expect(message.Headers).To(Equal([]sarama.RecordHeader{
+					{Key: []byte("content-type"), Value: []byte("application/json")},
+					{Key: []byte("ce_id"), Value: []byte("1234")},
+					{Key: []byte("ce_specversion"), Value: []byte("1.0")},
+				}))
+				return nil
+			})
+
+			_, err := client.Publish(ctx, &liiklus.PublishRequest{
+				Topic:       "ns_stream",
+				Value:       []byte(`"hello"`),
+				ContentType: "application/json",
+				Headers:     map[string][]byte{"ce_specversion": []byte("1.0"), "ce_id": []byte("1234")},
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects values that are not messages of the protobuf type of the stream", func() {
+			server.Messages = content.NewMessages()
+			server.Messages.Register("ns_stream", (&wrapperspb.StringValue{}).ProtoReflect().Type())
+
+			_, err := client.Publish(ctx, &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte(`{"hello": "world"}`), ContentType: "application/json"})
+
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+
+		Context("when the stream has an Avro schema", func() {
+
+			var registry *httptest.Server
+
+			BeforeEach(func() {
+				registry = schemaRegistry()
+				server.Avro = avro.NewSerializer(&avro.Registry{URL: registry.URL})
+			})
+
+			AfterEach(func() {
+				registry.Close()
+			})
+
+			It("produces values in the registry wire format", func() {
+				producer.ExpectSendMessageWithCheckerFunctionAndSucceed(func(value []byte) error {
+					Expect(value).To(Equal([]byte{0, 0, 0, 0, 1, 10, 'h', 'e', 'l', 'l', 'o'}))
+					return nil
+				})
+
+				_, err := client.Publish(ctx, &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte(`{"greeting": "hello"}`)})
+
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("rejects values not conforming to the schema", func() {
+				_, err := client.Publish(ctx, &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte(`{"salutation": "hello"}`)})
+
+				Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+			})
+		})
+
+		It("requires a topic", func() {
+			_, err := client.Publish(ctx, &liiklus.PublishRequest{Value: []byte("hello")})
+
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+
+		It("reports transient Kafka errors as unavailable", func() {
+			producer.ExpectSendMessageAndFail(sarama.ErrNotLeaderForPartition)
+
+			_, err := client.Publish(ctx, &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte("hello")})
+
+			Expect(status.Code(err)).To(Equal(codes.Unavailable))
+		})
+
+		It("reports unknown topics as not found", func() {
+			producer.ExpectSendMessageAndFail(sarama.ErrUnknownTopicOrPartition)
+
+			_, err := client.Publish(ctx, &liiklus.PublishRequest{Topic: "ns_stream", Value: []byte("hello")})
+
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+		})
+	})
+
+	Describe("publishing in batches", func() {
+
+		It("produces the records together and reports the result of each", func() {
+			server.MaxPayloadBytes = 8
+			producer.ExpectSendMessageAndSucceed()
+			producer.ExpectSendMessageAndSucceed()
+
+			reply, err := client.PublishBatch(ctx, &liiklus.PublishBatchRequest{Records: []*liiklus.PublishRequest{
+				{Topic: "ns_stream", Value: []byte("hello")},
+				{Topic: "ns_stream", Value: []byte("hello world")},
+				{Topic: "ns_stream", Value: []byte("world")},
+			}})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.Results).To(HaveLen(3))
+			Expect(reply.Results[0].Code).To(BeZero())
+			Expect(reply.Results[0].Record.Offset).To(Equal(uint64(1)))
+			Expect(codes.Code(reply.Results[1].Code)).To(Equal(codes.ResourceExhausted))
+			Expect(reply.Results[1].Record).To(BeNil())
+			Expect(reply.Results[2].Record.Offset).To(Equal(uint64(2)))
+		})
+
+		It("reports Kafka errors on the records", func() {
+			producer.ExpectSendMessageAndFail(sarama.ErrNotLeaderForPartition)
+
+			reply, err := client.PublishBatch(ctx, &liiklus.PublishBatchRequest{Records: []*liiklus.PublishRequest{
+				{Topic: "ns_stream", Value: []byte("hello")},
+			}})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(codes.Code(reply.Results[0].Code)).To(Equal(codes.Unavailable))
+		})
+
+		It("requires records", func() {
+			_, err := client.PublishBatch(ctx, &liiklus.PublishBatchRequest{})
+
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+
+		It("limits the size of batches", func() {
+			records := make([]*liiklus.PublishRequest, gateway.MaxBatchRecords+1)
+			for i := range records {
+				records[i] = &liiklus.PublishRequest{Topic: "ns_stream"}
+			}
+
+			_, err := client.PublishBatch(ctx, &liiklus.PublishBatchRequest{Records: records})
+
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+	})
+
+	Describe("subscribing", func() {
+
+		var assignment *liiklus.Assignment
+
+		BeforeEach(func() {
+			subscription, err := client.Subscribe(ctx, &liiklus.SubscribeRequest{
+				Topic:           "ns_stream",
+				Group:           "my-function",
+				GroupVersion:    2,
+				AutoOffsetReset: liiklus.SubscribeRequest_LATEST,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			reply, err := subscription.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			assignment = reply.GetAssignment()
+		})
+
+		It("joins the versioned consumer group", func() {
+			Expect(joinedGroup).To(Equal("my-function-v2"))
+			Expect(joinedOptions.InitialOffset).To(Equal(sarama.OffsetNewest))
+			Expect(assignment.Partition).To(Equal(uint32(0)))
+			Expect(assignment.SessionId).NotTo(BeEmpty())
+		})
+
+		It("delivers the records of the assigned partition", func() {
+			timestamp := time.Unix(1600000000, 0)
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: 3, Value: []byte("hello"), Timestamp: timestamp}
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: 4, Value: []byte("world"), Timestamp: timestamp}
+
+			receiver, err := client.Receive(ctx, &liiklus.ReceiveRequest{Assignment: assignment, LastKnownOffset: 3})
+			Expect(err).NotTo(HaveOccurred())
+
+			reply, err := receiver.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.GetRecord().Offset).To(Equal(uint64(3)))
+			Expect(reply.GetRecord().Value).To(Equal([]byte("hello")))
+			Expect(reply.GetRecord().Timestamp.AsTime()).To(BeTemporally("==", timestamp))
+			Expect(reply.GetRecord().Replay).To(BeTrue())
+			reply, err = receiver.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.GetRecord().Offset).To(Equal(uint64(4)))
+			Expect(reply.GetRecord().Replay).To(BeFalse())
+		})
+
+		It("transforms records before delivering them", func() {
+			server.Transforms = transform.NewHooks()
+			server.Transforms.OnDeliver("ns_stream", "drop-empty", transform.TransformerFunc(func(_ context.Context, record *transform.Record) (*transform.Record, error) {
+				if len(record.Value) == 0 {
+					return nil, nil
+				}
+				return record, nil
+			}))
+			server.Transforms.OnDeliver("ns_stream", "upper-case", upperCase)
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: 3}
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: 4, Value: []byte("hello")}
+
+			receiver, err := client.Receive(ctx, &liiklus.ReceiveRequest{Assignment: assignment})
+			Expect(err).NotTo(HaveOccurred())
+
+			reply, err := receiver.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.GetRecord().Offset).To(Equal(uint64(4)))
+			Expect(reply.GetRecord().Value).To(Equal([]byte("HELLO")))
+		})
+
+		It("commits past the records transforms drop once the records before them are acknowledged", func() {
+			server.Transforms = transform.NewHooks()
+			server.Transforms.OnDeliver("ns_stream", "drop-empty", transform.TransformerFunc(func(_ context.Context, record *transform.Record) (*transform.Record, error) {
+				if len(record.Value) == 0 {
+					return nil, nil
+				}
+				return record, nil
+			}))
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: 3, Value: []byte("hello")}
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: 4}
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: 5}
+
+			receiver, err := client.Receive(ctx, &liiklus.ReceiveRequest{Assignment: assignment})
+			Expect(err).NotTo(HaveOccurred())
+			reply, err := receiver.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.GetRecord().Offset).To(Equal(uint64(3)))
+			Consistently(func() int64 { return group.session.Marked(0) }).Should(BeZero())
+
+			_, err = client.Ack(ctx, &liiklus.AckRequest{Topic: "ns_stream", Group: "my-function", GroupVersion: 2, Partition: 0, Offset: 3})
+
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() int64 { return group.session.Marked(0) }).Should(Equal(int64(6)))
+		})
+
+		It("converts values to the content type the subscriber accepts", func() {
+			server.Messages = content.NewMessages()
+			server.Messages.Register("ns_stream", (&wrapperspb.StringValue{}).ProtoReflect().Type())
+			headers := []*sarama.RecordHeader{{Key: []byte("content-type"), Value: []byte("application/x-protobuf")}}
+			otherHeaders := []*sarama.RecordHeader{{Key: []byte("traceparent"), Value: []byte("00-abc")}}
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: 3, Value: []byte{10, 5, 'h', 'e', 'l', 'l', 'o'}, Headers: headers}
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: 4, Value: []byte("hello"), Headers: otherHeaders}
+
+			receiver, err := client.Receive(ctx, &liiklus.ReceiveRequest{Assignment: assignment, Accept: "application/json"})
+			Expect(err).NotTo(HaveOccurred())
+
+			reply, err := receiver.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.GetRecord().ContentType).To(Equal("application/json"))
+			Expect(reply.GetRecord().Value).To(MatchJSON(`"hello"`))
+			Expect(reply.GetRecord().Headers).To(BeEmpty())
+			reply, err = receiver.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.GetRecord().ContentType).To(BeEmpty())
+			Expect(reply.GetRecord().Value).To(Equal([]byte("hello")))
+			Expect(reply.GetRecord().Headers).To(Equal(map[string][]byte{"traceparent": []byte("00-abc")}))
+		})
+
+		It("delivers values in the registry wire format as JSON", func() {
+			registry := schemaRegistry()
+			defer registry.Close()
+			server.Avro = avro.NewSerializer(&avro.Registry{URL: registry.URL})
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: 3, Value: []byte{0, 0, 0, 0, 1, 10, 'h', 'e', 'l', 'l', 'o'}}
+
+			receiver, err := client.Receive(ctx, &liiklus.ReceiveRequest{Assignment: assignment})
+			Expect(err).NotTo(HaveOccurred())
+
+			reply, err := receiver.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.GetRecord().Value).To(MatchJSON(`{"greeting": "hello"}`))
+		})
+
+		It("only accepts requests for records received with flow control", func() {
+			_, err := client.RequestRecords(ctx, &liiklus.RequestRecordsRequest{Topic: "ns_stream", Group: "my-function", GroupVersion: 2, Partition: 0, Count: 5})
+
+			Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+			Expect(status.Convert(err).Message()).To(Equal("records of partition 0 are not received with flow control"))
+		})
+
+		It("commits the offset following the acknowledged record", func() {
+			_, err := client.Ack(ctx, &liiklus.AckRequest{Topic: "ns_stream", Group: "my-function", GroupVersion: 2, Partition: 0, Offset: 41})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(group.session.Marked(0)).To(Equal(int64(42)))
+		})
+
+		It("rejects acknowledgments for partitions it is not assigned", func() {
+			_, err := client.Ack(ctx, &liiklus.AckRequest{Topic: "ns_stream", Group: "my-function", Partition: 0, Offset: 41})
+
+			Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+		})
+
+		It("rejects unknown assignments", func() {
+			receiver, err := client.Receive(ctx, &liiklus.ReceiveRequest{Assignment: &liiklus.Assignment{SessionId: "unknown"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = receiver.Recv()
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+		})
+
+		It("leaves the consumer group when the subscriber disconnects", func() {
+			cancel()
+
+			Eventually(func() bool { return group.Closed() }).Should(BeTrue())
+		})
+
+		It("commits acknowledged offsets every second by default", func() {
+			Expect(joinedOptions.AutoCommitInterval).To(Equal(time.Second))
+
+			_, err := client.Ack(ctx, &liiklus.AckRequest{Topic: "ns_stream", Group: "my-function", GroupVersion: 2, Partition: 0, Offset: 41})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(group.session.Commits()).To(Equal(0))
+		})
+
+		It("refuses negative acknowledgments", func() {
+			_, err := client.Nack(ctx, &liiklus.NackRequest{Topic: "ns_stream", Group: "my-function", GroupVersion: 2, Partition: 0, Offset: 41})
+
+			Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+		})
+	})
+
+	Describe("committing", func() {
+
+		subscribe := func(request *liiklus.SubscribeRequest) {
+			subscription, err := client.Subscribe(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = subscription.Recv()
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		ack := func(offset uint64) error {
+			_, err := client.Ack(ctx, &liiklus.AckRequest{Topic: "ns_stream", Group: "my-function", Partition: 0, Offset: offset})
+			return err
+		}
+
+		It("honors the requested auto commit interval", func() {
+			subscribe(&liiklus.SubscribeRequest{Topic: "ns_stream", Group: "my-function", AutoCommitIntervalMs: 250})
+
+			Expect(joinedOptions.AutoCommitInterval).To(Equal(250 * time.Millisecond))
+		})
+
+		It("falls back to the strategy of the gateway", func() {
+			server.CommitStrategy = liiklus.SubscribeRequest_MANUAL
+
+			subscribe(&liiklus.SubscribeRequest{Topic: "ns_stream", Group: "my-function"})
+
+			Expect(joinedOptions.AutoCommitInterval).To(BeZero())
+		})
+
+		It("commits on each acknowledgment", func() {
+			subscribe(&liiklus.SubscribeRequest{Topic: "ns_stream", Group: "my-function", CommitStrategy: liiklus.SubscribeRequest_ON_ACK})
+			Expect(joinedOptions.AutoCommitInterval).To(BeZero())
+
+			Expect(ack(41)).To(Succeed())
+
+			Expect(group.session.Marked(0)).To(Equal(int64(42)))
+			Expect(group.session.Commits()).To(Equal(1))
+		})
+
+		It("commits on demand", func() {
+			subscribe(&liiklus.SubscribeRequest{Topic: "ns_stream", Group: "my-function", CommitStrategy: liiklus.SubscribeRequest_MANUAL})
+			Expect(joinedOptions.AutoCommitInterval).To(BeZero())
+			Expect(ack(41)).To(Succeed())
+			Expect(group.session.Commits()).To(Equal(0))
+
+			_, err := client.Commit(ctx, &liiklus.CommitRequest{Topic: "ns_stream", Group: "my-function"})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(group.session.Commits()).To(Equal(1))
+		})
+
+		It("rejects commits for groups without assignments", func() {
+			_, err := client.Commit(ctx, &liiklus.CommitRequest{Topic: "ns_stream", Group: "my-function"})
+
+			Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+		})
+	})
+
+	Describe("transacting", func() {
+
+		var (
+			producers        []*recordingProducer
+			transactionalIDs []string
+			request          *liiklus.TransactRequest
+		)
+
+		BeforeEach(func() {
+			producers, transactionalIDs = nil, nil
+			request = &liiklus.TransactRequest{
+				Records: []*liiklus.PublishRequest{
+					{Topic: "ns_output", Value: []byte("hello")},
+					{Topic: "ns_output", Value: []byte("world")},
+				},
+				Ack: &liiklus.AckRequest{Topic: "ns_input", Group: "my-function", Partition: 3, Offset: 41},
+			}
+		})
+
+		It("publishes records and commits the consumed offset atomically", func() {
+			server.NewTransactionalProducer = func(transactionalID string) (sarama.SyncProducer, error) {
+				producer := newRecordingProducer(GinkgoT(), transactionalID)
+				producer.ExpectSendMessageAndSucceed()
+				producer.ExpectSendMessageAndSucceed()
+				producers = append(producers, producer)
+				transactionalIDs = append(transactionalIDs, transactionalID)
+				return producer, nil
+			}
+
+			response, err := client.Transact(ctx, request)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Records).To(HaveLen(2))
+			Expect(transactionalIDs).To(Equal([]string{"my-function.ns_input.3"}))
+			Expect(producers[0].groupID).To(Equal("my-function"))
+			Expect(producers[0].offsets).To(HaveKey("ns_input"))
+			Expect(producers[0].offsets["ns_input"][0].Partition).To(Equal(int32(3)))
+			Expect(producers[0].offsets["ns_input"][0].Offset).To(Equal(int64(42)))
+			Expect(producers[0].TxnStatus()).To(Equal(sarama.ProducerTxnFlagReady))
+			Expect(producers[0].aborted).To(BeFalse())
+		})
+
+		It("aborts the transaction when publishing fails", func() {
+			server.NewTransactionalProducer = func(transactionalID string) (sarama.SyncProducer, error) {
+				producer := newRecordingProducer(GinkgoT(), transactionalID)
+				producer.ExpectSendMessageAndSucceed()
+				producer.ExpectSendMessageAndFail(sarama.ErrNotEnoughReplicas)
+				producers = append(producers, producer)
+				return producer, nil
+			}
+
+			_, err := client.Transact(ctx, request)
+
+			Expect(status.Code(err)).To(Equal(codes.Unavailable))
+			Expect(producers[0].aborted).To(BeTrue())
+			Expect(producers[0].offsets).To(BeNil())
+		})
+
+		It("requires the acknowledged record", func() {
+			server.NewTransactionalProducer = func(string) (sarama.SyncProducer, error) {
+				Fail("no producer should be created")
+				return nil, nil
+			}
+			request.Ack = nil
+
+			_, err := client.Transact(ctx, request)
+
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+
+		It("is disabled by default", func() {
+			server.NewTransactionalProducer = nil
+
+			_, err := client.Transact(ctx, request)
+
+			Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+		})
+	})
+
+	Describe("controlling the flow", func() {
+
+		var (
+			receiver liiklus.LiiklusService_ReceiveClient
+			received chan *liiklus.ReceiveReply_Record
+		)
+
+		BeforeEach(func() {
+			subscription, err := client.Subscribe(ctx, &liiklus.SubscribeRequest{Topic: "ns_stream", Group: "my-function"})
+			Expect(err).NotTo(HaveOccurred())
+			reply, err := subscription.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			receiver, err = client.Receive(ctx, &liiklus.ReceiveRequest{Assignment: reply.GetAssignment(), Credits: 2})
+			Expect(err).NotTo(HaveOccurred())
+
+			received = make(chan *liiklus.ReceiveReply_Record, 10)
+			go func() {
+				for {
+					reply, err := receiver.Recv()
+					if err != nil {
+						return
+					}
+					received <- reply.GetRecord()
+				}
+			}()
+			for offset := int64(0); offset < 3; offset++ {
+				group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: offset}
+			}
+		})
+
+		It("delivers as many records as requested and pauses the partition", func() {
+			Eventually(received).Should(Receive())
+			Eventually(received).Should(Receive())
+			Eventually(group.Paused).Should(BeTrue())
+			Consistently(received, 100*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("resumes delivering records when more are requested", func() {
+			Eventually(group.Paused).Should(BeTrue())
+
+			_, err := client.RequestRecords(ctx, &liiklus.RequestRecordsRequest{Topic: "ns_stream", Group: "my-function", Partition: 0, Count: 5})
+			Expect(err).NotTo(HaveOccurred())
+
+			var record *liiklus.ReceiveReply_Record
+			Eventually(received).Should(Receive())
+			Eventually(received).Should(Receive())
+			Eventually(received).Should(Receive(&record))
+			Expect(record.Offset).To(Equal(uint64(2)))
+			Eventually(group.Paused).Should(BeFalse())
+		})
+	})
+
+	Describe("filtering", func() {
+
+		subscribe := func(filters ...string) liiklus.LiiklusService_SubscribeClient {
+			subscription, err := client.Subscribe(ctx, &liiklus.SubscribeRequest{Topic: "ns_stream", Group: "my-function", HeaderFilters: filters})
+			Expect(err).NotTo(HaveOccurred())
+			return subscription
+		}
+
+		It("only delivers records whose headers match the filters", func() {
+			subscription := subscribe(`ce-type = "order.created"`)
+			reply, err := subscription.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			created := []*sarama.RecordHeader{{Key: []byte("ce_type"), Value: []byte("order.created")}, {Key: []byte("ce_specversion"), Value: []byte("1.0")}}
+			shipped := []*sarama.RecordHeader{{Key: []byte("ce_type"), Value: []byte("order.shipped")}, {Key: []byte("ce_specversion"), Value: []byte("1.0")}}
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: 3, Headers: shipped}
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: 4, Headers: created}
+
+			receiver, err := client.Receive(ctx, &liiklus.ReceiveRequest{Assignment: reply.GetAssignment()})
+			Expect(err).NotTo(HaveOccurred())
+
+			record, err := receiver.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(record.GetRecord().Offset).To(Equal(uint64(4)))
+		})
+
+		It("commits past the records filtered out", func() {
+			subscription := subscribe(`ce-type = "order.created"`)
+			reply, err := subscription.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			shipped := []*sarama.RecordHeader{{Key: []byte("ce_type"), Value: []byte("order.shipped")}, {Key: []byte("ce_specversion"), Value: []byte("1.0")}}
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: 3, Headers: shipped}
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: 4, Headers: shipped}
+
+			_, err = client.Receive(ctx, &liiklus.ReceiveRequest{Assignment: reply.GetAssignment()})
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func() int64 { return group.session.Marked(0) }).Should(Equal(int64(5)))
+		})
+
+		It("rejects invalid filters", func() {
+			_, err := subscribe("ce-type").Recv()
+
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+	})
+
+	Describe("measuring", func() {
+
+		var m *metrics.Metrics
+
+		BeforeEach(func() {
+			m = metrics.NewMetrics()
+			server.Metrics = m
+		})
+
+		scrape := func() string {
+			recorder := httptest.NewRecorder()
+			m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			return recorder.Body.String()
+		}
+
+		It("counts the records published to a stream", func() {
+			producer.ExpectSendMessageAndSucceed()
+
+			_, err := client.Publish(ctx, &liiklus.PublishRequest{Topic: "ns_stream", Key: []byte("key"), Value: []byte("hello")})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(scrape()).To(ContainSubstring(`riff_kafka_gateway_published_records_total{namespace="ns",stream="stream"} 1`))
+			Expect(scrape()).To(ContainSubstring(`riff_kafka_gateway_published_bytes_total{namespace="ns",stream="stream"} 8`))
+		})
+
+		It("counts the records delivered to a consumer group and its lag", func() {
+			subscription, err := client.Subscribe(ctx, &liiklus.SubscribeRequest{Topic: "ns_stream", Group: "my-function"})
+			Expect(err).NotTo(HaveOccurred())
+			reply, err := subscription.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			group.claims[0].highWaterMark = 10
+			group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Partition: 0, Offset: 6, Value: []byte("hello")}
+
+			receiver, err := client.Receive(ctx, &liiklus.ReceiveRequest{Assignment: reply.GetAssignment()})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = receiver.Recv()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(scrape()).To(ContainSubstring(`riff_kafka_gateway_delivered_records_total{group="my-function",namespace="ns",stream="stream"} 1`))
+			Expect(scrape()).To(ContainSubstring(`riff_kafka_gateway_delivered_bytes_total{group="my-function",namespace="ns",stream="stream"} 5`))
+			Expect(scrape()).To(ContainSubstring(`riff_kafka_gateway_consumer_lag_records{group="my-function",namespace="ns",partition="0",stream="stream"} 3`))
+		})
+	})
+
+	Describe("redelivering", func() {
+
+		var receiver liiklus.LiiklusService_ReceiveClient
+
+		BeforeEach(func() {
+			server.RedeliveryTimeout = 200 * time.Millisecond
+			subscription, err := client.Subscribe(ctx, &liiklus.SubscribeRequest{Topic: "ns_stream", Group: "my-function"})
+			Expect(err).NotTo(HaveOccurred())
+			reply, err := subscription.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			receiver, err = client.Receive(ctx, &liiklus.ReceiveRequest{Assignment: reply.GetAssignment()})
+			Expect(err).NotTo(HaveOccurred())
+
+			for offset := int64(0); offset < 3; offset++ {
+				group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: offset}
+			}
+			for offset := uint64(0); offset < 3; offset++ {
+				reply, err := receiver.Recv()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reply.GetRecord().Offset).To(Equal(offset))
+				Expect(reply.GetRecord().Replay).To(BeFalse())
+			}
+		})
+
+		ack := func(offset uint64) error {
+			_, err := client.Ack(ctx, &liiklus.AckRequest{Topic: "ns_stream", Group: "my-function", Partition: 0, Offset: offset})
+			return err
+		}
+
+		It("delivers unacknowledged records again after the timeout", func() {
+			Expect(ack(0)).To(Succeed())
+			Expect(ack(2)).To(Succeed())
+
+			reply, err := receiver.Recv()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.GetRecord().Offset).To(Equal(uint64(1)))
+			Expect(reply.GetRecord().Replay).To(BeTrue())
+		})
+
+		It("commits offsets up to the first record in flight", func() {
+			Expect(ack(0)).To(Succeed())
+			Expect(group.session.Marked(0)).To(Equal(int64(1)))
+
+			Expect(ack(2)).To(Succeed())
+			Expect(group.session.Marked(0)).To(Equal(int64(1)))
+
+			Expect(ack(1)).To(Succeed())
+			Expect(group.session.Marked(0)).To(Equal(int64(3)))
+		})
+
+		It("delivers negatively acknowledged records again right away", func() {
+			Expect(ack(0)).To(Succeed())
+			_, err := client.Nack(ctx, &liiklus.NackRequest{Topic: "ns_stream", Group: "my-function", Partition: 0, Offset: 2})
+			Expect(err).NotTo(HaveOccurred())
+
+			reply, err := receiver.Recv()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.GetRecord().Offset).To(Equal(uint64(2)))
+			Expect(reply.GetRecord().Replay).To(BeTrue())
+		})
+
+		It("rejects acknowledgments of records not in flight", func() {
+			Expect(ack(0)).To(Succeed())
+
+			Expect(status.Code(ack(0))).To(Equal(codes.NotFound))
+		})
+	})
+
+	Describe("draining", func() {
+
+		var subscription liiklus.LiiklusService_SubscribeClient
+
+		BeforeEach(func() {
+			var err error
+			subscription, err = client.Subscribe(ctx, &liiklus.SubscribeRequest{Topic: "ns_stream", Group: "my-function"})
+			Expect(err).NotTo(HaveOccurred())
+			reply, err := subscription.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			receiver, err := client.Receive(ctx, &liiklus.ReceiveRequest{Assignment: reply.GetAssignment()})
+			Expect(err).NotTo(HaveOccurred())
+
+			for offset := int64(0); offset < 2; offset++ {
+				group.claims[0].messages <- &sarama.ConsumerMessage{Topic: "ns_stream", Offset: offset}
+				_, err := receiver.Recv()
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		shutdown := func() <-chan error {
+			done := make(chan error, 1)
+			go func() {
+				done <- server.Shutdown(ctx)
+			}()
+			return done
+		}
+
+		It("hands partitions over once the records delivered are acknowledged", func() {
+			done := shutdown()
+			Consistently(done, 200*time.Millisecond).ShouldNot(Receive())
+
+			_, err := client.Ack(ctx, &liiklus.AckRequest{Topic: "ns_stream", Group: "my-function", Partition: 0, Offset: 1})
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(done).Should(Receive(BeNil()))
+			Expect(group.session.Marked(0)).To(Equal(int64(2)))
+			Expect(group.session.Commits()).To(Equal(1))
+			Expect(group.Closed()).To(BeTrue())
+			_, err = subscription.Recv()
+			Expect(status.Code(err)).To(Equal(codes.Unavailable))
+		})
+
+		It("hands partitions over after the drain timeout", func() {
+			server.DrainTimeout = 100 * time.Millisecond
+
+			Eventually(shutdown()).Should(Receive(BeNil()))
+			Expect(group.session.Commits()).To(Equal(1))
+		})
+
+		It("refuses subscriptions once shutting down", func() {
+			server.DrainTimeout = -1
+			Eventually(shutdown()).Should(Receive(BeNil()))
+
+			subscription, err := client.Subscribe(ctx, &liiklus.SubscribeRequest{Topic: "ns_stream", Group: "my-function"})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = subscription.Recv()
+			Expect(status.Code(err)).To(Equal(codes.Unavailable))
+		})
+	})
+})
+
+var _ = Describe("Gateway offsets", func() {
+
+	var (
+		broker *sarama.MockBroker
+		server *gateway.Server
+	)
+
+	BeforeEach(func() {
+		broker = sarama.NewMockBroker(GinkgoT(), int32(1))
+		broker.SetHandlerByMap(map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+				SetController(broker.BrokerID()).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("ns_stream", 0, broker.BrokerID()).
+				SetLeader("ns_stream", 1, broker.BrokerID()),
+			"OffsetRequest": sarama.NewMockOffsetResponse(GinkgoT()).
+				SetOffset("ns_stream", 0, sarama.OffsetNewest, 10).
+				SetOffset("ns_stream", 1, sarama.OffsetNewest, 0),
+			"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(GinkgoT()).
+				SetCoordinator(sarama.CoordinatorGroup, "my-function", broker),
+			"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(GinkgoT()).
+				SetOffset("my-function", "ns_stream", 0, 5, "", sarama.ErrNoError).
+				SetOffset("my-function", "ns_stream", 1, -1, "", sarama.ErrNoError),
+		})
+		var err error
+		server, err = gateway.NewServer([]string{broker.Addr()}, gateway.ProducerOptions{}, logger)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(server.Close()).To(Succeed())
+		broker.Close()
+	})
+
+	It("reports the last record of each non empty partition", func() {
+		reply, err := server.GetEndOffsets(context.Background(), &liiklus.GetEndOffsetsRequest{Topic: "ns_stream"})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reply.Offsets).To(Equal(map[uint32]uint64{0: 9}))
+	})
+
+	It("reports the last record acknowledged on each partition", func() {
+		reply, err := server.GetOffsets(context.Background(), &liiklus.GetOffsetsRequest{Topic: "ns_stream", Group: "my-function"})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reply.Offsets).To(Equal(map[uint32]uint64{0: 4}))
+	})
+})
@@ -0,0 +1,211 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/Shopify/sarama"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// groupSummary is what listing the consumer groups of a stream tells of each
+type groupSummary struct {
+	Group   string `json:"group"`
+	State   string `json:"state"`
+	Members int    `json:"members"`
+}
+
+// groupDescription describes a consumer group of a stream
+type groupDescription struct {
+	Group   string        `json:"group"`
+	State   string        `json:"state"`
+	Members []groupMember `json:"members"`
+	// Offsets are the committed offsets of the group, those of the next records to consume, keyed by partition
+	Offsets map[int32]int64 `json:"offsets"`
+}
+
+type groupMember struct {
+	MemberID   string  `json:"memberId"`
+	ClientID   string  `json:"clientId"`
+	ClientHost string  `json:"clientHost"`
+	Partitions []int32 `json:"partitions"`
+}
+
+// streamGroups returns the consumer groups associated with a topic: those whose members subscribe to it, and
+// those without member having committed offsets on it
+func (s *Server) streamGroups(topic string) ([]*sarama.GroupDescription, error) {
+	groups, err := s.Admin.ListConsumerGroups()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for id, protocolType := range groups {
+		if protocolType == "consumer" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	sort.Strings(ids)
+	descriptions, err := s.Admin.DescribeConsumerGroups(ids)
+	if err != nil {
+		return nil, err
+	}
+	var associated []*sarama.GroupDescription
+	for _, description := range descriptions {
+		ok, err := s.associated(topic, description)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			associated = append(associated, description)
+		}
+	}
+	return associated, nil
+}
+
+func (s *Server) associated(topic string, description *sarama.GroupDescription) (bool, error) {
+	if description.Err != sarama.ErrNoError {
+		return false, description.Err
+	}
+	for _, member := range description.Members {
+		metadata, err := member.GetMemberMetadata()
+		if err != nil {
+			return false, err
+		}
+		for _, t := range metadata.Topics {
+			if t == topic {
+				return true, nil
+			}
+		}
+	}
+	if len(description.Members) > 0 {
+		return false, nil
+	}
+	offsets, err := s.committedOffsets(description.GroupId, topic)
+	return len(offsets) > 0, err
+}
+
+// streamGroup describes a consumer group associated with a topic, returning nil when there is none
+func (s *Server) streamGroup(topic string, groupID string) (*groupDescription, error) {
+	descriptions, err := s.Admin.DescribeConsumerGroups([]string{groupID})
+	if err != nil {
+		return nil, err
+	}
+	if len(descriptions) == 0 || descriptions[0].State == "Dead" {
+		return nil, nil
+	}
+	ok, err := s.associated(topic, descriptions[0])
+	if err != nil || !ok {
+		return nil, err
+	}
+	description := &groupDescription{Group: groupID, State: descriptions[0].State, Members: []groupMember{}}
+	for _, member := range descriptions[0].Members {
+		m := groupMember{MemberID: member.MemberId, ClientID: member.ClientId, ClientHost: member.ClientHost, Partitions: []int32{}}
+		assignment, err := member.GetMemberAssignment()
+		if err != nil {
+			return nil, err
+		}
+		if assignment != nil {
+			m.Partitions = append(m.Partitions, assignment.Topics[topic]...)
+		}
+		description.Members = append(description.Members, m)
+	}
+	if description.Offsets, err = s.committedOffsets(groupID, topic); err != nil {
+		return nil, err
+	}
+	return description, nil
+}
+
+// committedOffsets returns the offsets committed by a group on the partitions of a topic
+func (s *Server) committedOffsets(groupID string, topic string) (map[int32]int64, error) {
+	partitions, err := s.Client.Partitions(topic)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.Admin.ListConsumerGroupOffsets(groupID, map[string][]int32{topic: partitions})
+	if err != nil {
+		return nil, err
+	}
+	offsets := make(map[int32]int64)
+	for _, partition := range partitions {
+		block := response.GetBlock(topic, partition)
+		if block == nil {
+			continue
+		}
+		if block.Err != sarama.ErrNoError {
+			return nil, block.Err
+		}
+		if block.Offset >= 0 {
+			offsets[partition] = block.Offset
+		}
+	}
+	return offsets, nil
+}
+
+// serveGroups lists the consumer groups of a stream on GET /<namespace>/<stream-name>/groups, describes one on
+// GET /<namespace>/<stream-name>/groups/<group> and deletes one without members on DELETE of the same URL. Groups
+// without members are rewound on POST /<namespace>/<stream-name>/groups/<group>/rewind.
+func (s *Server) serveGroups(writer http.ResponseWriter, request *http.Request, topic string, path []string) {
+	switch {
+	case len(path) == 0 && request.Method == http.MethodGet:
+		descriptions, err := s.streamGroups(topic)
+		if err != nil {
+			writeStatus(writer, kafkaStatus(err))
+			return
+		}
+		groups := make([]groupSummary, 0, len(descriptions))
+		for _, description := range descriptions {
+			groups = append(groups, groupSummary{Group: description.GroupId, State: description.State, Members: len(description.Members)})
+		}
+		writeJSON(writer, groups)
+	case len(path) == 1 && request.Method == http.MethodGet:
+		description, err := s.streamGroup(topic, path[0])
+		if err != nil {
+			writeStatus(writer, kafkaStatus(err))
+			return
+		}
+		if description == nil {
+			writeStatus(writer, status.Errorf(codes.NotFound, "group %q is not a consumer group of topic %q", path[0], topic))
+			return
+		}
+		writeJSON(writer, description)
+	case len(path) == 1 && request.Method == http.MethodDelete:
+		if s.APIVersions != nil {
+			if err := s.APIVersions.Require(client.FeatureGroupDeletion); err != nil {
+				writeStatus(writer, status.Error(codes.Unimplemented, err.Error()))
+				return
+			}
+		}
+		description, err := s.streamGroup(topic, path[0])
+		if err != nil {
+			writeStatus(writer, kafkaStatus(err))
+			return
+		}
+		if description == nil {
+			writeStatus(writer, status.Errorf(codes.NotFound, "group %q is not a consumer group of topic %q", path[0], topic))
+			return
+		}
+		if err := s.Admin.DeleteConsumerGroup(path[0]); err != nil {
+			if err == sarama.ErrNonEmptyGroup {
+				writer.WriteHeader(http.StatusConflict)
+				_, _ = fmt.Fprintf(writer, "group %q still has members, stop them before deleting it\n", path[0])
+				return
+			}
+			writeStatus(writer, kafkaStatus(err))
+			return
+		}
+		s.Logger.Info("Deleted consumer group", "topic", topic, "group", path[0])
+		writer.WriteHeader(http.StatusNoContent)
+	case len(path) == 2 && path[1] == "rewind" && request.Method == http.MethodPost:
+		s.rewindHTTP(writer, request, topic, path[0])
+	case len(path) <= 1, len(path) == 2 && path[1] == "rewind":
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		writer.WriteHeader(http.StatusNotFound)
+	}
+}
@@ -0,0 +1,265 @@
+package gateway_test
+
+import (
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/Shopify/sarama"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+var _ = Describe("HTTP consumer groups", func() {
+
+	var (
+		broker   *sarama.MockBroker
+		handlers map[string]sarama.MockResponse
+		server   *gateway.Server
+		recorder *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		broker = sarama.NewMockBroker(GinkgoT(), int32(1))
+		handlers = map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+				SetController(broker.BrokerID()).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("ns_stream", 0, broker.BrokerID()).
+				SetLeader("ns_stream", 1, broker.BrokerID()),
+			"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(GinkgoT()).
+				SetCoordinator(sarama.CoordinatorGroup, "my-function", broker).
+				SetCoordinator(sarama.CoordinatorGroup, "replayer", broker).
+				SetCoordinator(sarama.CoordinatorGroup, "other-function", broker).
+				SetCoordinator(sarama.CoordinatorGroup, "unknown", broker),
+			"ListGroupsRequest": sarama.NewMockListGroupsResponse(GinkgoT()).
+				AddGroup("my-function", "consumer").
+				AddGroup("replayer", "consumer").
+				AddGroup("other-function", "consumer").
+				AddGroup("connect-cluster", "connect"),
+			"DescribeGroupsRequest": sarama.NewMockDescribeGroupsResponse(GinkgoT()).
+				AddGroupDescription("my-function", &sarama.GroupDescription{
+					GroupId:      "my-function",
+					State:        "Stable",
+					ProtocolType: "consumer",
+					Members: map[string]*sarama.GroupMemberDescription{
+						"member-1": {
+							MemberId:         "member-1",
+							ClientId:         "gateway",
+							ClientHost:       "/10.0.0.1",
+							MemberMetadata:   memberMetadata("ns_stream"),
+							MemberAssignment: memberAssignment("ns_stream", 0, 1),
+						},
+					},
+				}).
+				AddGroupDescription("replayer", &sarama.GroupDescription{
+					GroupId:      "replayer",
+					State:        "Empty",
+					ProtocolType: "consumer",
+				}).
+				AddGroupDescription("other-function", &sarama.GroupDescription{
+					GroupId:      "other-function",
+					State:        "Stable",
+					ProtocolType: "consumer",
+					Members: map[string]*sarama.GroupMemberDescription{
+						"member-2": {
+							MemberId:         "member-2",
+							ClientId:         "gateway",
+							ClientHost:       "/10.0.0.2",
+							MemberMetadata:   memberMetadata("ns_other"),
+							MemberAssignment: memberAssignment("ns_other", 0),
+						},
+					},
+				}),
+			"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(GinkgoT()).
+				SetOffset("my-function", "ns_stream", 0, 5, "", sarama.ErrNoError).
+				SetOffset("my-function", "ns_stream", 1, -1, "", sarama.ErrNoError).
+				SetOffset("replayer", "ns_stream", 1, 3, "", sarama.ErrNoError),
+		}
+		broker.SetHandlerByMap(handlers)
+		var err error
+		server, err = gateway.NewServer([]string{broker.Addr()}, gateway.ProducerOptions{}, logger)
+		Expect(err).NotTo(HaveOccurred())
+		recorder = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		Expect(server.Close()).To(Succeed())
+		broker.Close()
+	})
+
+	It("lists the consumer groups of a stream", func() {
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ns/stream/groups", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(MatchJSON(`[
+			{"group": "my-function", "state": "Stable", "members": 1},
+			{"group": "replayer", "state": "Empty", "members": 0}
+		]`))
+	})
+
+	It("describes a consumer group of a stream", func() {
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ns/stream/groups/my-function", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(MatchJSON(`{
+			"group": "my-function",
+			"state": "Stable",
+			"members": [{"memberId": "member-1", "clientId": "gateway", "clientHost": "/10.0.0.1", "partitions": [0, 1]}],
+			"offsets": {"0": 5}
+		}`))
+	})
+
+	It("returns 404 for groups not consuming the stream", func() {
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ns/stream/groups/other-function", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("returns 404 for unknown groups", func() {
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/ns/stream/groups/unknown", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("deletes consumer groups without members", func() {
+		handlers["DeleteGroupsRequest"] = sarama.NewMockDeleteGroupsRequest(GinkgoT()).SetDeletedGroups([]string{"replayer"})
+		broker.SetHandlerByMap(handlers)
+
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/ns/stream/groups/replayer", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusNoContent))
+	})
+
+	It("returns 501 when the cluster can't delete consumer groups", func() {
+		server.APIVersions = client.APIVersions{0: 5}
+
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/ns/stream/groups/replayer", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusNotImplemented))
+		Expect(recorder.Body.String()).To(ContainSubstring("requires Kafka 1.1 or later"))
+	})
+
+	It("returns 409 for groups still having members", func() {
+		handlers["DeleteGroupsRequest"] = sarama.NewMockWrapper(&sarama.DeleteGroupsResponse{
+			GroupErrorCodes: map[string]sarama.KError{"my-function": sarama.ErrNonEmptyGroup},
+		})
+		broker.SetHandlerByMap(handlers)
+
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/ns/stream/groups/my-function", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusConflict))
+		Expect(recorder.Body.String()).To(ContainSubstring("still has members"))
+	})
+
+	Context("rewinding", func() {
+
+		var committed map[int32]int64
+
+		BeforeEach(func() {
+			committed = nil
+			handlers["OffsetRequest"] = sarama.NewMockOffsetResponse(GinkgoT()).
+				SetOffset("ns_stream", 0, sarama.OffsetOldest, 2).
+				SetOffset("ns_stream", 0, sarama.OffsetNewest, 10).
+				SetOffset("ns_stream", 1, sarama.OffsetOldest, 0).
+				SetOffset("ns_stream", 1, sarama.OffsetNewest, 4).
+				SetOffset("ns_stream", 0, 1577836800000, 7).
+				SetOffset("ns_stream", 1, 1577836800000, -1)
+			handlers["OffsetCommitRequest"] = sarama.NewMockOffsetCommitResponse(GinkgoT())
+			broker.SetHandlerByMap(handlers)
+		})
+
+		rewind := func(group string, body string) {
+			request := httptest.NewRequest(http.MethodPost, "/ns/stream/groups/"+group+"/rewind", strings.NewReader(body))
+			server.ServeHTTP(recorder, request)
+			for _, exchange := range broker.History() {
+				if commit, ok := exchange.Request.(*sarama.OffsetCommitRequest); ok {
+					committed = map[int32]int64{}
+					for _, partition := range []int32{0, 1} {
+						if offset, _, err := commit.Offset("ns_stream", partition); err == nil {
+							committed[partition] = offset
+						}
+					}
+				}
+			}
+		}
+
+		It("rewinds groups without members to the earliest records", func() {
+			rewind("replayer", `{"to": "earliest"}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(MatchJSON(`{"group": "replayer", "offsets": {"0": 2, "1": 0}}`))
+			Expect(committed).To(Equal(map[int32]int64{0: 2, 1: 0}))
+		})
+
+		It("rewinds groups to the first records published at a time", func() {
+			rewind("replayer", `{"timestamp": "2020-01-01T00:00:00Z"}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(committed).To(Equal(map[int32]int64{0: 7, 1: 4}))
+		})
+
+		It("rewinds partitions to offsets", func() {
+			rewind("replayer", `{"offsets": {"1": 1}}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(committed).To(Equal(map[int32]int64{1: 1}))
+		})
+
+		It("rejects offsets out of the retained range", func() {
+			rewind("replayer", `{"offsets": {"0": 1}}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(committed).To(BeNil())
+		})
+
+		It("rejects ambiguous requests", func() {
+			rewind("replayer", `{"to": "earliest", "offsets": {"0": 3}}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("returns 409 for groups having members", func() {
+			rewind("my-function", `{"to": "earliest"}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusConflict))
+			Expect(recorder.Body.String()).To(ContainSubstring("stop its members"))
+			Expect(committed).To(BeNil())
+		})
+	})
+
+	It("only accepts GET and DELETE requests", func() {
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/ns/stream/groups/my-function", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
+
+// memberMetadata encodes the version 0 metadata of a member subscribing to topics
+func memberMetadata(topics ...string) []byte {
+	encoded := binary.BigEndian.AppendUint16(nil, 0)
+	encoded = binary.BigEndian.AppendUint32(encoded, uint32(len(topics)))
+	for _, topic := range topics {
+		encoded = appendString(encoded, topic)
+	}
+	return binary.BigEndian.AppendUint32(encoded, 0xffffffff)
+}
+
+// memberAssignment encodes the version 0 assignment of partitions of a topic to a member
+func memberAssignment(topic string, partitions ...int32) []byte {
+	encoded := binary.BigEndian.AppendUint16(nil, 0)
+	encoded = binary.BigEndian.AppendUint32(encoded, 1)
+	encoded = appendString(encoded, topic)
+	encoded = binary.BigEndian.AppendUint32(encoded, uint32(len(partitions)))
+	for _, partition := range partitions {
+		encoded = binary.BigEndian.AppendUint32(encoded, uint32(partition))
+	}
+	return binary.BigEndian.AppendUint32(encoded, 0xffffffff)
+}
+
+func appendString(encoded []byte, s string) []byte {
+	return append(binary.BigEndian.AppendUint16(encoded, uint16(len(s))), s...)
+}
@@ -0,0 +1,237 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/cloudevents"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/content"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/liiklus"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ServeHTTP publishes the body of POST /<namespace>/<stream-name> requests as the value of a record, and
+// returns the record at an offset of a partition to GET /<namespace>/<stream-name>/<partition>/<offset> requests.
+// CloudEvents are accepted and returned in both binary and structured modes. Batches of records are published to
+// /<namespace>/<stream-name>/batch, and the consumer groups of a stream are managed under
+// /<namespace>/<stream-name>/groups.
+func (s *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	parts := strings.Split(strings.Trim(request.URL.Path, "/"), "/")
+	if len(parts) >= 2 {
+		verb := SubscribeVerb
+		switch {
+		case request.Method == http.MethodPost && (len(parts) == 2 || len(parts) == 3 && parts[2] == "batch"):
+			verb = PublishVerb
+		case len(parts) >= 3 && parts[2] == "groups" && request.Method != http.MethodGet:
+			// deleting and rewinding groups affects the other subscribers of the stream
+			verb = ManageVerb
+		}
+		if !s.authorizeHTTP(writer, request, validation.TopicName(parts[0], parts[1]), verb) {
+			return
+		}
+	}
+	switch {
+	case len(parts) >= 3 && parts[2] == "groups":
+		s.serveGroups(writer, request, validation.TopicName(parts[0], parts[1]), parts[3:])
+	case request.Method == http.MethodPost && len(parts) == 3 && parts[2] == "batch":
+		s.publishBatchHTTP(writer, request, validation.TopicName(parts[0], parts[1]))
+	case request.Method == http.MethodPost && len(parts) == 2:
+		s.publishHTTP(writer, request, validation.TopicName(parts[0], parts[1]))
+	case request.Method == http.MethodGet && len(parts) == 4:
+		partition, err := strconv.ParseInt(parts[2], 10, 32)
+		if err != nil || partition < 0 {
+			writer.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(writer, "Invalid partition %q\n", parts[2])
+			return
+		}
+		offset, err := strconv.ParseInt(parts[3], 10, 64)
+		if err != nil || offset < 0 {
+			writer.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(writer, "Invalid offset %q\n", parts[3])
+			return
+		}
+		s.fetchHTTP(writer, request, validation.TopicName(parts[0], parts[1]), int32(partition), offset)
+	case request.Method != http.MethodPost && request.Method != http.MethodGet:
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		writer.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(writer, "URLs should be of the form /<namespace>/<stream-name> to publish, or /<namespace>/<stream-name>/<partition>/<offset> to fetch a record\n")
+	}
+}
+
+func (s *Server) publishHTTP(writer http.ResponseWriter, request *http.Request, topic string) {
+	body := request.Body
+	if s.MaxPayloadBytes > 0 {
+		if request.ContentLength > int64(s.MaxPayloadBytes) {
+			writer.WriteHeader(http.StatusRequestEntityTooLarge)
+			_, _ = fmt.Fprintf(writer, payloadTooLarge+"\n", request.ContentLength, s.MaxPayloadBytes)
+			return
+		}
+		body = http.MaxBytesReader(writer, body, int64(s.MaxPayloadBytes))
+	}
+	value, err := ioutil.ReadAll(body)
+	if err != nil {
+		if _, ok := err.(*http.MaxBytesError); ok {
+			writer.WriteHeader(http.StatusRequestEntityTooLarge)
+			_, _ = fmt.Fprintf(writer, "record exceeds the limit of %d bytes: "+payloadGuidance+"\n", s.MaxPayloadBytes)
+			return
+		}
+		writer.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(writer, "Error reading the record: %v\n", err)
+		return
+	}
+
+	record := &liiklus.PublishRequest{
+		Topic:       topic,
+		Value:       value,
+		ContentType: request.Header.Get("Content-Type"),
+	}
+	envelope := s.Streams.Envelope(topic)
+	var event *cloudevents.Event
+	if envelope != client.RawEnvelope && envelope != client.RiffEnvelope {
+		// records of streams framed otherwise are published as is, even when they look like CloudEvents
+		event, err = cloudevents.FromHTTP(request.Header, value)
+	}
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintln(writer, err)
+		return
+	}
+	if event == nil && envelope == client.CloudEventsEnvelope {
+		writer.WriteHeader(http.StatusUnsupportedMediaType)
+		_, _ = fmt.Fprintf(writer, "the records of stream %q are CloudEvents, in binary or structured mode\n", topic)
+		return
+	}
+	if event != nil {
+		// structured events are published as is, binary ones with their attributes as headers
+		record.Key = event.Key()
+		if !cloudevents.IsStructured(record.ContentType) {
+			record.Value, record.ContentType, record.Headers = event.Data, event.ContentType(), event.KafkaHeaders()
+		}
+	}
+
+	reply, err := s.Publish(request.Context(), record)
+	if err != nil {
+		writeStatus(writer, err)
+		return
+	}
+	writeJSON(writer, publishResult{Topic: reply.Topic, Partition: reply.Partition, Offset: reply.Offset})
+}
+
+// fetchHTTP returns a record as its content type, or as a CloudEvent in the mode preferred by the Accept header
+// when it carries one, unless its stream is framed otherwise
+func (s *Server) fetchHTTP(writer http.ResponseWriter, request *http.Request, topic string, partition int32, offset int64) {
+	message, err := s.fetch(request.Context(), topic, partition, offset)
+	if err != nil {
+		writeStatus(writer, err)
+		return
+	}
+	headers := make(map[string][]byte)
+	for _, header := range message.Headers {
+		headers[string(header.Key)] = header.Value
+	}
+	contentType := string(headers[content.Header])
+
+	envelope := s.Streams.Envelope(topic)
+	var event *cloudevents.Event
+	if envelope != client.RawEnvelope && envelope != client.RiffEnvelope {
+		event, err = cloudevents.FromKafka(headers, contentType, message.Value)
+	}
+	if err != nil {
+		s.Logger.Warn("Invalid CloudEvent", "topic", topic, "partition", partition, "offset", offset, "error", err)
+	}
+	if event == nil || err != nil {
+		if contentType != "" {
+			writer.Header().Set("Content-Type", contentType)
+		}
+		_, _ = writer.Write(message.Value)
+		return
+	}
+	offers := []string{event.ContentType(), cloudevents.ContentType}
+	if cloudevents.IsStructured(contentType) {
+		offers = []string{cloudevents.ContentType, event.ContentType()}
+	}
+	accepted, _ := content.Negotiate(request.Header.Get("Accept"), offers...)
+	_ = event.WriteHTTP(writer, accepted == cloudevents.ContentType)
+}
+
+// fetch reads the record at an offset of a partition
+func (s *Server) fetch(ctx context.Context, topic string, partition int32, offset int64) (*sarama.ConsumerMessage, error) {
+	fetchStatus := func(err error) error {
+		if err == sarama.ErrOffsetOutOfRange {
+			return status.Errorf(codes.NotFound, "there is no record at offset %d of partition %d", offset, partition)
+		}
+		return kafkaStatus(err)
+	}
+	partitionConsumer, err := s.Consumer.ConsumePartition(topic, partition, offset)
+	if err != nil {
+		return nil, fetchStatus(err)
+	}
+	defer partitionConsumer.AsyncClose()
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	select {
+	case message := <-partitionConsumer.Messages():
+		if message.Offset != offset {
+			// the record has been compacted away, or the offset is the one of a transaction marker
+			return nil, status.Errorf(codes.NotFound, "there is no record at offset %d of partition %d", offset, partition)
+		}
+		return message, nil
+	case err := <-partitionConsumer.Errors():
+		return nil, fetchStatus(err.Err)
+	case <-ctx.Done():
+		return nil, status.Errorf(codes.NotFound, "there is no record at offset %d of partition %d yet", offset, partition)
+	}
+}
+
+// fetchTimeout is how long fetches wait for a record to be published at the offset following the last one
+const fetchTimeout = 5 * time.Second
+
+func writeJSON(writer http.ResponseWriter, v interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(writer).Encode(v)
+}
+
+// writeStatus writes the HTTP status and message of an error returned by the gateway
+func writeStatus(writer http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	writer.WriteHeader(httpStatus(st.Code()))
+	_, _ = fmt.Fprintln(writer, st.Message())
+}
+
+type publishResult struct {
+	Topic     string `json:"topic"`
+	Partition uint32 `json:"partition"`
+	Offset    uint64 `json:"offset"`
+}
+
+// httpStatus maps the gRPC status codes returned by the gateway to HTTP statuses
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.ResourceExhausted:
+		return http.StatusRequestEntityTooLarge
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,264 @@
+package gateway_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway"
+)
+
+var _ = Describe("HTTP publishing", func() {
+
+	var (
+		producer *mocks.SyncProducer
+		server   *gateway.Server
+		recorder *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		producer = mocks.NewSyncProducer(GinkgoT(), nil)
+		server = &gateway.Server{Producer: producer, Logger: logger, MaxPayloadBytes: 8}
+		recorder = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		Expect(producer.Close()).To(Succeed())
+	})
+
+	It("publishes the body to the topic of the stream", func() {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(message *sarama.ProducerMessage) error {
+			Expect(message.Topic).To(Equal("ns_stream"))
+			Expect(message.Value).To(Equal(sarama.ByteEncoder("hello")))
+			Expect(message.Headers).To(ConsistOf(sarama.RecordHeader{Key: []byte("content-type"), Value: []byte("text/plain")}))
+			return nil
+		})
+
+		request := httptest.NewRequest(http.MethodPost, "/ns/stream", strings.NewReader("hello"))
+		request.Header.Set("Content-Type", "text/plain")
+		server.ServeHTTP(recorder, request)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(MatchJSON(`{"topic": "ns_stream", "partition": 0, "offset": 1}`))
+	})
+
+	It("returns 413 with guidance for payloads larger than the limit", func() {
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/ns/stream", strings.NewReader("hello world")))
+
+		Expect(recorder.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		Expect(recorder.Body.String()).To(HavePrefix("record of 11 bytes exceeds the limit of 8 bytes: split the payload"))
+	})
+
+	It("returns 413 for payloads of unknown length larger than the limit", func() {
+		request := httptest.NewRequest(http.MethodPost, "/ns/stream", strings.NewReader("hello world"))
+		request.ContentLength = -1
+
+		server.ServeHTTP(recorder, request)
+
+		Expect(recorder.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		Expect(recorder.Body.String()).To(HavePrefix("record exceeds the limit of 8 bytes"))
+	})
+
+	It("returns 503 when Kafka is unavailable", func() {
+		producer.ExpectSendMessageAndFail(sarama.ErrNotEnoughReplicas)
+
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/ns/stream", strings.NewReader("hello")))
+
+		Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("returns 400 for malformed paths", func() {
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/stream", strings.NewReader("hello")))
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	Context("with CloudEvents", func() {
+
+		BeforeEach(func() {
+			server.MaxPayloadBytes = 0
+		})
+
+		It("publishes CloudEvents in binary mode with their attributes as headers", func() {
+			producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(message *sarama.ProducerMessage) error {
+				Expect(message.Key).To(Equal(sarama.ByteEncoder("customer-1")))
+				Expect(message.Value).To(Equal(sarama.ByteEncoder(`{"total": 42}`)))
+				Expect(message.Headers).To(Equal([]sarama.RecordHeader{
+					{Key: []byte("content-type"), Value: []byte("application/json")},
+					{Key: []byte("ce_id"), Value: []byte("1234")},
+					{Key: []byte("ce_partitionkey"), Value: []byte("customer-1")},
+					{Key: []byte("ce_source"), Value: []byte("/orders")},
+					{Key: []byte("ce_specversion"), Value: []byte("1.0")},
+					{Key: []byte("ce_type"), Value: []byte("order.created")},
+				}))
+				return nil
+			})
+
+			request := httptest.NewRequest(http.MethodPost, "/ns/stream", strings.NewReader(`{"total": 42}`))
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set("Ce-Specversion", "1.0")
+			request.Header.Set("Ce-Id", "1234")
+			request.Header.Set("Ce-Source", "/orders")
+			request.Header.Set("Ce-Type", "order.created")
+			request.Header.Set("Ce-Partitionkey", "customer-1")
+			server.ServeHTTP(recorder, request)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+		})
+
+		It("publishes CloudEvents in structured mode as is", func() {
+			event := `{"specversion": "1.0", "id": "1234", "source": "/orders", "type": "order.created", "data": {"total": 42}}`
+			producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(message *sarama.ProducerMessage) error {
+				Expect(message.Value).To(Equal(sarama.ByteEncoder(event)))
+				Expect(message.Headers).To(ConsistOf(sarama.RecordHeader{Key: []byte("content-type"), Value: []byte("application/cloudevents+json")}))
+				return nil
+			})
+
+			request := httptest.NewRequest(http.MethodPost, "/ns/stream", strings.NewReader(event))
+			request.Header.Set("Content-Type", "application/cloudevents+json")
+			server.ServeHTTP(recorder, request)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+		})
+
+		It("rejects invalid CloudEvents", func() {
+			request := httptest.NewRequest(http.MethodPost, "/ns/stream", strings.NewReader(`{"total": 42}`))
+			request.Header.Set("Ce-Specversion", "1.0")
+			request.Header.Set("Ce-Source", "/orders")
+			request.Header.Set("Ce-Type", "order.created")
+			server.ServeHTTP(recorder, request)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(recorder.Body.String()).To(Equal("the id attribute of the CloudEvent is required\n"))
+		})
+	})
+
+	Context("in batches", func() {
+
+		BeforeEach(func() {
+			server.MaxPayloadBytes = 32
+		})
+
+		It("publishes the JSON values of the records and returns the result of each", func() {
+			producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(message *sarama.ProducerMessage) error {
+				Expect(message.Topic).To(Equal("ns_stream"))
+				Expect(message.Key).To(Equal(sarama.ByteEncoder("customer-1")))
+				Expect(message.Value).To(Equal(sarama.ByteEncoder(`{"n":1}`)))
+				Expect(message.Headers).To(Equal([]sarama.RecordHeader{
+					{Key: []byte("content-type"), Value: []byte("application/json")},
+					{Key: []byte("tenant"), Value: []byte("acme")},
+				}))
+				return nil
+			})
+
+			batch := `[{"key": "customer-1", "value": {"n":1}, "headers": {"tenant": "acme"}}, {"value": "a record larger than the limit of the stream"}]`
+			server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/ns/stream/batch", strings.NewReader(batch)))
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(MatchJSON(`[
+				{"topic": "ns_stream", "partition": 23, "offset": 1},
+				{"status": 413, "error": "record of 46 bytes exceeds the limit of 32 bytes: split the payload into several records, or store it elsewhere (e.g. an object store) and publish a reference to it"}
+			]`))
+		})
+
+		It("returns 400 for bodies that aren't arrays of records", func() {
+			server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/ns/stream/batch", strings.NewReader(`{"value": 1}`)))
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	It("only accepts POST and GET requests", func() {
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/ns/stream", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
+
+var _ = Describe("HTTP fetching", func() {
+
+	var (
+		consumer *mocks.Consumer
+		server   *gateway.Server
+		recorder *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		consumer = mocks.NewConsumer(GinkgoT(), nil)
+		server = &gateway.Server{Consumer: consumer, Logger: logger}
+		recorder = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		Expect(consumer.Close()).To(Succeed())
+	})
+
+	binaryEvent := &sarama.ConsumerMessage{Topic: "ns_stream", Partition: 1, Offset: 7, Value: []byte(`{"total": 42}`), Headers: []*sarama.RecordHeader{
+		{Key: []byte("content-type"), Value: []byte("application/json")},
+		{Key: []byte("ce_specversion"), Value: []byte("1.0")},
+		{Key: []byte("ce_id"), Value: []byte("1234")},
+		{Key: []byte("ce_source"), Value: []byte("/orders")},
+		{Key: []byte("ce_type"), Value: []byte("order.created")},
+	}}
+
+	It("returns the record at an offset", func() {
+		consumer.ExpectConsumePartition("ns_stream", 1, 7).YieldMessage(&sarama.ConsumerMessage{Offset: 7, Value: []byte("hello"), Headers: []*sarama.RecordHeader{
+			{Key: []byte("content-type"), Value: []byte("text/plain")},
+		}})
+
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ns/stream/1/7", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Type")).To(Equal("text/plain"))
+		Expect(recorder.Body.String()).To(Equal("hello"))
+	})
+
+	It("returns CloudEvents in binary mode by default", func() {
+		consumer.ExpectConsumePartition("ns_stream", 1, 7).YieldMessage(binaryEvent)
+
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ns/stream/1/7", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+		Expect(recorder.Header().Get("Ce-Id")).To(Equal("1234"))
+		Expect(recorder.Header().Get("Ce-Type")).To(Equal("order.created"))
+		Expect(recorder.Body.String()).To(MatchJSON(`{"total": 42}`))
+	})
+
+	It("returns CloudEvents in structured mode when accepted", func() {
+		consumer.ExpectConsumePartition("ns_stream", 1, 7).YieldMessage(binaryEvent)
+
+		request := httptest.NewRequest(http.MethodGet, "/ns/stream/1/7", nil)
+		request.Header.Set("Accept", "application/cloudevents+json")
+		server.ServeHTTP(recorder, request)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Type")).To(Equal("application/cloudevents+json"))
+		Expect(recorder.Body.String()).To(MatchJSON(`{
+			"specversion": "1.0",
+			"id": "1234",
+			"source": "/orders",
+			"type": "order.created",
+			"datacontenttype": "application/json",
+			"data": {"total": 42}
+		}`))
+	})
+
+	It("returns 404 for offsets out of range", func() {
+		consumer.ExpectConsumePartition("ns_stream", 1, 7).YieldError(sarama.ErrOffsetOutOfRange)
+
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ns/stream/1/7", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("returns 400 for invalid offsets", func() {
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ns/stream/1/latest", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+	})
+})
@@ -0,0 +1,1707 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v3.21.12
+// source: liiklus.proto
+
+package liiklus
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SubscribeRequest_AutoOffsetReset int32
+
+const (
+	SubscribeRequest_EARLIEST SubscribeRequest_AutoOffsetReset = 0
+	SubscribeRequest_LATEST   SubscribeRequest_AutoOffsetReset = 1
+)
+
+// Enum value maps for SubscribeRequest_AutoOffsetReset.
+var (
+	SubscribeRequest_AutoOffsetReset_name = map[int32]string{
+		0: "EARLIEST",
+		1: "LATEST",
+	}
+	SubscribeRequest_AutoOffsetReset_value = map[string]int32{
+		"EARLIEST": 0,
+		"LATEST":   1,
+	}
+)
+
+func (x SubscribeRequest_AutoOffsetReset) Enum() *SubscribeRequest_AutoOffsetReset {
+	p := new(SubscribeRequest_AutoOffsetReset)
+	*p = x
+	return p
+}
+
+func (x SubscribeRequest_AutoOffsetReset) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SubscribeRequest_AutoOffsetReset) Descriptor() protoreflect.EnumDescriptor {
+	return file_liiklus_proto_enumTypes[0].Descriptor()
+}
+
+func (SubscribeRequest_AutoOffsetReset) Type() protoreflect.EnumType {
+	return &file_liiklus_proto_enumTypes[0]
+}
+
+func (x SubscribeRequest_AutoOffsetReset) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SubscribeRequest_AutoOffsetReset.Descriptor instead.
+func (SubscribeRequest_AutoOffsetReset) EnumDescriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{2, 0}
+}
+
+type SubscribeRequest_CommitStrategy int32
+
+const (
+	// the strategy configured on the gateway
+	SubscribeRequest_DEFAULT SubscribeRequest_CommitStrategy = 0
+	// periodically
+	SubscribeRequest_AUTO SubscribeRequest_CommitStrategy = 1
+	// as part of each acknowledgment
+	SubscribeRequest_ON_ACK SubscribeRequest_CommitStrategy = 2
+	// on Commit calls
+	SubscribeRequest_MANUAL SubscribeRequest_CommitStrategy = 3
+)
+
+// Enum value maps for SubscribeRequest_CommitStrategy.
+var (
+	SubscribeRequest_CommitStrategy_name = map[int32]string{
+		0: "DEFAULT",
+		1: "AUTO",
+		2: "ON_ACK",
+		3: "MANUAL",
+	}
+	SubscribeRequest_CommitStrategy_value = map[string]int32{
+		"DEFAULT": 0,
+		"AUTO":    1,
+		"ON_ACK":  2,
+		"MANUAL":  3,
+	}
+)
+
+func (x SubscribeRequest_CommitStrategy) Enum() *SubscribeRequest_CommitStrategy {
+	p := new(SubscribeRequest_CommitStrategy)
+	*p = x
+	return p
+}
+
+func (x SubscribeRequest_CommitStrategy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SubscribeRequest_CommitStrategy) Descriptor() protoreflect.EnumDescriptor {
+	return file_liiklus_proto_enumTypes[1].Descriptor()
+}
+
+func (SubscribeRequest_CommitStrategy) Type() protoreflect.EnumType {
+	return &file_liiklus_proto_enumTypes[1]
+}
+
+func (x SubscribeRequest_CommitStrategy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SubscribeRequest_CommitStrategy.Descriptor instead.
+func (SubscribeRequest_CommitStrategy) EnumDescriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{2, 1}
+}
+
+type PublishRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Topic string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Key   []byte                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value []byte                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	// Not part of liiklus: the content type of the value, recorded in the content-type header of the record
+	ContentType string `protobuf:"bytes,4,opt,name=contentType,proto3" json:"contentType,omitempty"`
+	// Not part of liiklus: the headers of the record, e.g. the ce_ attributes of CloudEvents
+	Headers       map[string][]byte `protobuf:"bytes,5,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishRequest) Reset() {
+	*x = PublishRequest{}
+	mi := &file_liiklus_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishRequest) ProtoMessage() {}
+
+func (x *PublishRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishRequest.ProtoReflect.Descriptor instead.
+func (*PublishRequest) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PublishRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *PublishRequest) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *PublishRequest) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *PublishRequest) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *PublishRequest) GetHeaders() map[string][]byte {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+type PublishReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Partition     uint32                 `protobuf:"varint,1,opt,name=partition,proto3" json:"partition,omitempty"`
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Topic         string                 `protobuf:"bytes,3,opt,name=topic,proto3" json:"topic,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishReply) Reset() {
+	*x = PublishReply{}
+	mi := &file_liiklus_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishReply) ProtoMessage() {}
+
+func (x *PublishReply) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishReply.ProtoReflect.Descriptor instead.
+func (*PublishReply) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PublishReply) GetPartition() uint32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *PublishReply) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *PublishReply) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+type SubscribeRequest struct {
+	state           protoimpl.MessageState           `protogen:"open.v1"`
+	Topic           string                           `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Group           string                           `protobuf:"bytes,2,opt,name=group,proto3" json:"group,omitempty"`
+	AutoOffsetReset SubscribeRequest_AutoOffsetReset `protobuf:"varint,3,opt,name=autoOffsetReset,proto3,enum=com.github.bsideup.liiklus.SubscribeRequest_AutoOffsetReset" json:"autoOffsetReset,omitempty"`
+	GroupVersion    uint32                           `protobuf:"varint,4,opt,name=groupVersion,proto3" json:"groupVersion,omitempty"`
+	// Not part of liiklus: when acknowledged offsets are committed to Kafka
+	CommitStrategy SubscribeRequest_CommitStrategy `protobuf:"varint,5,opt,name=commitStrategy,proto3,enum=com.github.bsideup.liiklus.SubscribeRequest_CommitStrategy" json:"commitStrategy,omitempty"`
+	// Not part of liiklus: the interval between commits with the AUTO commit strategy
+	AutoCommitIntervalMs uint32 `protobuf:"varint,6,opt,name=autoCommitIntervalMs,proto3" json:"autoCommitIntervalMs,omitempty"`
+	// Not part of liiklus: only records whose headers match all of these expressions, such as
+	// `ce-type = "order.created"`, are delivered
+	HeaderFilters []string `protobuf:"bytes,7,rep,name=headerFilters,proto3" json:"headerFilters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_liiklus_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SubscribeRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *SubscribeRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *SubscribeRequest) GetAutoOffsetReset() SubscribeRequest_AutoOffsetReset {
+	if x != nil {
+		return x.AutoOffsetReset
+	}
+	return SubscribeRequest_EARLIEST
+}
+
+func (x *SubscribeRequest) GetGroupVersion() uint32 {
+	if x != nil {
+		return x.GroupVersion
+	}
+	return 0
+}
+
+func (x *SubscribeRequest) GetCommitStrategy() SubscribeRequest_CommitStrategy {
+	if x != nil {
+		return x.CommitStrategy
+	}
+	return SubscribeRequest_DEFAULT
+}
+
+func (x *SubscribeRequest) GetAutoCommitIntervalMs() uint32 {
+	if x != nil {
+		return x.AutoCommitIntervalMs
+	}
+	return 0
+}
+
+func (x *SubscribeRequest) GetHeaderFilters() []string {
+	if x != nil {
+		return x.HeaderFilters
+	}
+	return nil
+}
+
+type Assignment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=sessionId,proto3" json:"sessionId,omitempty"`
+	Partition     uint32                 `protobuf:"varint,2,opt,name=partition,proto3" json:"partition,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Assignment) Reset() {
+	*x = Assignment{}
+	mi := &file_liiklus_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Assignment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Assignment) ProtoMessage() {}
+
+func (x *Assignment) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Assignment.ProtoReflect.Descriptor instead.
+func (*Assignment) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Assignment) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *Assignment) GetPartition() uint32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+type SubscribeReply struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Reply:
+	//
+	//	*SubscribeReply_Assignment
+	Reply         isSubscribeReply_Reply `protobuf_oneof:"reply"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeReply) Reset() {
+	*x = SubscribeReply{}
+	mi := &file_liiklus_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeReply) ProtoMessage() {}
+
+func (x *SubscribeReply) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeReply.ProtoReflect.Descriptor instead.
+func (*SubscribeReply) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SubscribeReply) GetReply() isSubscribeReply_Reply {
+	if x != nil {
+		return x.Reply
+	}
+	return nil
+}
+
+func (x *SubscribeReply) GetAssignment() *Assignment {
+	if x != nil {
+		if x, ok := x.Reply.(*SubscribeReply_Assignment); ok {
+			return x.Assignment
+		}
+	}
+	return nil
+}
+
+type isSubscribeReply_Reply interface {
+	isSubscribeReply_Reply()
+}
+
+type SubscribeReply_Assignment struct {
+	Assignment *Assignment `protobuf:"bytes,1,opt,name=assignment,proto3,oneof"`
+}
+
+func (*SubscribeReply_Assignment) isSubscribeReply_Reply() {}
+
+type ReceiveRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Assignment      *Assignment            `protobuf:"bytes,1,opt,name=assignment,proto3" json:"assignment,omitempty"`
+	LastKnownOffset uint64                 `protobuf:"varint,2,opt,name=lastKnownOffset,proto3" json:"lastKnownOffset,omitempty"`
+	// Not part of liiklus: the content types the subscriber accepts, as in an HTTP Accept header
+	Accept string `protobuf:"bytes,3,opt,name=accept,proto3" json:"accept,omitempty"`
+	// Not part of liiklus: when positive, enables flow control: the number of records delivered before the
+	// subscriber asks for more with RequestRecords
+	Credits       uint32 `protobuf:"varint,4,opt,name=credits,proto3" json:"credits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReceiveRequest) Reset() {
+	*x = ReceiveRequest{}
+	mi := &file_liiklus_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReceiveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReceiveRequest) ProtoMessage() {}
+
+func (x *ReceiveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReceiveRequest.ProtoReflect.Descriptor instead.
+func (*ReceiveRequest) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ReceiveRequest) GetAssignment() *Assignment {
+	if x != nil {
+		return x.Assignment
+	}
+	return nil
+}
+
+func (x *ReceiveRequest) GetLastKnownOffset() uint64 {
+	if x != nil {
+		return x.LastKnownOffset
+	}
+	return 0
+}
+
+func (x *ReceiveRequest) GetAccept() string {
+	if x != nil {
+		return x.Accept
+	}
+	return ""
+}
+
+func (x *ReceiveRequest) GetCredits() uint32 {
+	if x != nil {
+		return x.Credits
+	}
+	return 0
+}
+
+type ReceiveReply struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Reply:
+	//
+	//	*ReceiveReply_Record_
+	Reply         isReceiveReply_Reply `protobuf_oneof:"reply"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReceiveReply) Reset() {
+	*x = ReceiveReply{}
+	mi := &file_liiklus_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReceiveReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReceiveReply) ProtoMessage() {}
+
+func (x *ReceiveReply) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReceiveReply.ProtoReflect.Descriptor instead.
+func (*ReceiveReply) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ReceiveReply) GetReply() isReceiveReply_Reply {
+	if x != nil {
+		return x.Reply
+	}
+	return nil
+}
+
+func (x *ReceiveReply) GetRecord() *ReceiveReply_Record {
+	if x != nil {
+		if x, ok := x.Reply.(*ReceiveReply_Record_); ok {
+			return x.Record
+		}
+	}
+	return nil
+}
+
+type isReceiveReply_Reply interface {
+	isReceiveReply_Reply()
+}
+
+type ReceiveReply_Record_ struct {
+	Record *ReceiveReply_Record `protobuf:"bytes,1,opt,name=record,proto3,oneof"`
+}
+
+func (*ReceiveReply_Record_) isReceiveReply_Reply() {}
+
+type AckRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in liiklus.proto.
+	Assignment    *Assignment `protobuf:"bytes,1,opt,name=assignment,proto3" json:"assignment,omitempty"`
+	Offset        uint64      `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Topic         string      `protobuf:"bytes,3,opt,name=topic,proto3" json:"topic,omitempty"`
+	Group         string      `protobuf:"bytes,4,opt,name=group,proto3" json:"group,omitempty"`
+	GroupVersion  uint32      `protobuf:"varint,5,opt,name=groupVersion,proto3" json:"groupVersion,omitempty"`
+	Partition     uint32      `protobuf:"varint,6,opt,name=partition,proto3" json:"partition,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AckRequest) Reset() {
+	*x = AckRequest{}
+	mi := &file_liiklus_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AckRequest) ProtoMessage() {}
+
+func (x *AckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AckRequest.ProtoReflect.Descriptor instead.
+func (*AckRequest) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{7}
+}
+
+// Deprecated: Marked as deprecated in liiklus.proto.
+func (x *AckRequest) GetAssignment() *Assignment {
+	if x != nil {
+		return x.Assignment
+	}
+	return nil
+}
+
+func (x *AckRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *AckRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *AckRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *AckRequest) GetGroupVersion() uint32 {
+	if x != nil {
+		return x.GroupVersion
+	}
+	return 0
+}
+
+func (x *AckRequest) GetPartition() uint32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+type NackRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Group         string                 `protobuf:"bytes,2,opt,name=group,proto3" json:"group,omitempty"`
+	GroupVersion  uint32                 `protobuf:"varint,3,opt,name=groupVersion,proto3" json:"groupVersion,omitempty"`
+	Partition     uint32                 `protobuf:"varint,4,opt,name=partition,proto3" json:"partition,omitempty"`
+	Offset        uint64                 `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NackRequest) Reset() {
+	*x = NackRequest{}
+	mi := &file_liiklus_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NackRequest) ProtoMessage() {}
+
+func (x *NackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NackRequest.ProtoReflect.Descriptor instead.
+func (*NackRequest) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *NackRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *NackRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *NackRequest) GetGroupVersion() uint32 {
+	if x != nil {
+		return x.GroupVersion
+	}
+	return 0
+}
+
+func (x *NackRequest) GetPartition() uint32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *NackRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type RequestRecordsRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Topic        string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Group        string                 `protobuf:"bytes,2,opt,name=group,proto3" json:"group,omitempty"`
+	GroupVersion uint32                 `protobuf:"varint,3,opt,name=groupVersion,proto3" json:"groupVersion,omitempty"`
+	Partition    uint32                 `protobuf:"varint,4,opt,name=partition,proto3" json:"partition,omitempty"`
+	// the number of records to deliver, on top of those requested before
+	Count         uint32 `protobuf:"varint,5,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestRecordsRequest) Reset() {
+	*x = RequestRecordsRequest{}
+	mi := &file_liiklus_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestRecordsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestRecordsRequest) ProtoMessage() {}
+
+func (x *RequestRecordsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestRecordsRequest.ProtoReflect.Descriptor instead.
+func (*RequestRecordsRequest) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RequestRecordsRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *RequestRecordsRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *RequestRecordsRequest) GetGroupVersion() uint32 {
+	if x != nil {
+		return x.GroupVersion
+	}
+	return 0
+}
+
+func (x *RequestRecordsRequest) GetPartition() uint32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *RequestRecordsRequest) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type CommitRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Group         string                 `protobuf:"bytes,2,opt,name=group,proto3" json:"group,omitempty"`
+	GroupVersion  uint32                 `protobuf:"varint,3,opt,name=groupVersion,proto3" json:"groupVersion,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitRequest) Reset() {
+	*x = CommitRequest{}
+	mi := &file_liiklus_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitRequest) ProtoMessage() {}
+
+func (x *CommitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitRequest.ProtoReflect.Descriptor instead.
+func (*CommitRequest) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CommitRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *CommitRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *CommitRequest) GetGroupVersion() uint32 {
+	if x != nil {
+		return x.GroupVersion
+	}
+	return 0
+}
+
+type TransactRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Records       []*PublishRequest      `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	Ack           *AckRequest            `protobuf:"bytes,2,opt,name=ack,proto3" json:"ack,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransactRequest) Reset() {
+	*x = TransactRequest{}
+	mi := &file_liiklus_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransactRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransactRequest) ProtoMessage() {}
+
+func (x *TransactRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransactRequest.ProtoReflect.Descriptor instead.
+func (*TransactRequest) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TransactRequest) GetRecords() []*PublishRequest {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+func (x *TransactRequest) GetAck() *AckRequest {
+	if x != nil {
+		return x.Ack
+	}
+	return nil
+}
+
+type TransactReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Records       []*PublishReply        `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransactReply) Reset() {
+	*x = TransactReply{}
+	mi := &file_liiklus_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransactReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransactReply) ProtoMessage() {}
+
+func (x *TransactReply) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransactReply.ProtoReflect.Descriptor instead.
+func (*TransactReply) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TransactReply) GetRecords() []*PublishReply {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+type PublishBatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Records       []*PublishRequest      `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishBatchRequest) Reset() {
+	*x = PublishBatchRequest{}
+	mi := &file_liiklus_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishBatchRequest) ProtoMessage() {}
+
+func (x *PublishBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishBatchRequest.ProtoReflect.Descriptor instead.
+func (*PublishBatchRequest) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *PublishBatchRequest) GetRecords() []*PublishRequest {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+type PublishBatchReply struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the results of the records, in the order of the request
+	Results       []*PublishBatchResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishBatchReply) Reset() {
+	*x = PublishBatchReply{}
+	mi := &file_liiklus_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishBatchReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishBatchReply) ProtoMessage() {}
+
+func (x *PublishBatchReply) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishBatchReply.ProtoReflect.Descriptor instead.
+func (*PublishBatchReply) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *PublishBatchReply) GetResults() []*PublishBatchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type PublishBatchResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// set when the record has been published, or filtered out by a transformation
+	Record *PublishReply `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`
+	// the gRPC status code of the failure, OK when the record has been published
+	Code          uint32 `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishBatchResult) Reset() {
+	*x = PublishBatchResult{}
+	mi := &file_liiklus_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishBatchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishBatchResult) ProtoMessage() {}
+
+func (x *PublishBatchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishBatchResult.ProtoReflect.Descriptor instead.
+func (*PublishBatchResult) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *PublishBatchResult) GetRecord() *PublishReply {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *PublishBatchResult) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *PublishBatchResult) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type GetOffsetsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Group         string                 `protobuf:"bytes,2,opt,name=group,proto3" json:"group,omitempty"`
+	GroupVersion  uint32                 `protobuf:"varint,3,opt,name=groupVersion,proto3" json:"groupVersion,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOffsetsRequest) Reset() {
+	*x = GetOffsetsRequest{}
+	mi := &file_liiklus_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOffsetsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOffsetsRequest) ProtoMessage() {}
+
+func (x *GetOffsetsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOffsetsRequest.ProtoReflect.Descriptor instead.
+func (*GetOffsetsRequest) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetOffsetsRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *GetOffsetsRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *GetOffsetsRequest) GetGroupVersion() uint32 {
+	if x != nil {
+		return x.GroupVersion
+	}
+	return 0
+}
+
+type GetOffsetsReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offsets       map[uint32]uint64      `protobuf:"bytes,1,rep,name=offsets,proto3" json:"offsets,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOffsetsReply) Reset() {
+	*x = GetOffsetsReply{}
+	mi := &file_liiklus_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOffsetsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOffsetsReply) ProtoMessage() {}
+
+func (x *GetOffsetsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOffsetsReply.ProtoReflect.Descriptor instead.
+func (*GetOffsetsReply) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetOffsetsReply) GetOffsets() map[uint32]uint64 {
+	if x != nil {
+		return x.Offsets
+	}
+	return nil
+}
+
+type GetEndOffsetsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEndOffsetsRequest) Reset() {
+	*x = GetEndOffsetsRequest{}
+	mi := &file_liiklus_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEndOffsetsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEndOffsetsRequest) ProtoMessage() {}
+
+func (x *GetEndOffsetsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEndOffsetsRequest.ProtoReflect.Descriptor instead.
+func (*GetEndOffsetsRequest) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetEndOffsetsRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+type GetEndOffsetsReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offsets       map[uint32]uint64      `protobuf:"bytes,1,rep,name=offsets,proto3" json:"offsets,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEndOffsetsReply) Reset() {
+	*x = GetEndOffsetsReply{}
+	mi := &file_liiklus_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEndOffsetsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEndOffsetsReply) ProtoMessage() {}
+
+func (x *GetEndOffsetsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEndOffsetsReply.ProtoReflect.Descriptor instead.
+func (*GetEndOffsetsReply) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetEndOffsetsReply) GetOffsets() map[uint32]uint64 {
+	if x != nil {
+		return x.Offsets
+	}
+	return nil
+}
+
+type ReceiveReply_Record struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Offset    uint64                 `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	Key       []byte                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value     []byte                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Replay    bool                   `protobuf:"varint,5,opt,name=replay,proto3" json:"replay,omitempty"`
+	// Not part of liiklus: the content type of the value, when recorded
+	ContentType string `protobuf:"bytes,6,opt,name=contentType,proto3" json:"contentType,omitempty"`
+	// Not part of liiklus: the headers of the record, but the content-type one
+	Headers       map[string][]byte `protobuf:"bytes,7,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReceiveReply_Record) Reset() {
+	*x = ReceiveReply_Record{}
+	mi := &file_liiklus_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReceiveReply_Record) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReceiveReply_Record) ProtoMessage() {}
+
+func (x *ReceiveReply_Record) ProtoReflect() protoreflect.Message {
+	mi := &file_liiklus_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReceiveReply_Record.ProtoReflect.Descriptor instead.
+func (*ReceiveReply_Record) Descriptor() ([]byte, []int) {
+	return file_liiklus_proto_rawDescGZIP(), []int{6, 0}
+}
+
+func (x *ReceiveReply_Record) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ReceiveReply_Record) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *ReceiveReply_Record) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *ReceiveReply_Record) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *ReceiveReply_Record) GetReplay() bool {
+	if x != nil {
+		return x.Replay
+	}
+	return false
+}
+
+func (x *ReceiveReply_Record) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *ReceiveReply_Record) GetHeaders() map[string][]byte {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+var File_liiklus_proto protoreflect.FileDescriptor
+
+const file_liiklus_proto_rawDesc = "" +
+	"\n" +
+	"\rliiklus.proto\x12\x1acom.github.bsideup.liiklus\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xff\x01\n" +
+	"\x0ePublishRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\fR\x03key\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\fR\x05value\x12 \n" +
+	"\vcontentType\x18\x04 \x01(\tR\vcontentType\x12Q\n" +
+	"\aheaders\x18\x05 \x03(\v27.com.github.bsideup.liiklus.PublishRequest.HeadersEntryR\aheaders\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"Z\n" +
+	"\fPublishReply\x12\x1c\n" +
+	"\tpartition\x18\x01 \x01(\rR\tpartition\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x14\n" +
+	"\x05topic\x18\x03 \x01(\tR\x05topic\"\xf7\x03\n" +
+	"\x10SubscribeRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x14\n" +
+	"\x05group\x18\x02 \x01(\tR\x05group\x12f\n" +
+	"\x0fautoOffsetReset\x18\x03 \x01(\x0e2<.com.github.bsideup.liiklus.SubscribeRequest.AutoOffsetResetR\x0fautoOffsetReset\x12\"\n" +
+	"\fgroupVersion\x18\x04 \x01(\rR\fgroupVersion\x12c\n" +
+	"\x0ecommitStrategy\x18\x05 \x01(\x0e2;.com.github.bsideup.liiklus.SubscribeRequest.CommitStrategyR\x0ecommitStrategy\x122\n" +
+	"\x14autoCommitIntervalMs\x18\x06 \x01(\rR\x14autoCommitIntervalMs\x12$\n" +
+	"\rheaderFilters\x18\a \x03(\tR\rheaderFilters\"+\n" +
+	"\x0fAutoOffsetReset\x12\f\n" +
+	"\bEARLIEST\x10\x00\x12\n" +
+	"\n" +
+	"\x06LATEST\x10\x01\"?\n" +
+	"\x0eCommitStrategy\x12\v\n" +
+	"\aDEFAULT\x10\x00\x12\b\n" +
+	"\x04AUTO\x10\x01\x12\n" +
+	"\n" +
+	"\x06ON_ACK\x10\x02\x12\n" +
+	"\n" +
+	"\x06MANUAL\x10\x03\"H\n" +
+	"\n" +
+	"Assignment\x12\x1c\n" +
+	"\tsessionId\x18\x01 \x01(\tR\tsessionId\x12\x1c\n" +
+	"\tpartition\x18\x02 \x01(\rR\tpartition\"c\n" +
+	"\x0eSubscribeReply\x12H\n" +
+	"\n" +
+	"assignment\x18\x01 \x01(\v2&.com.github.bsideup.liiklus.AssignmentH\x00R\n" +
+	"assignmentB\a\n" +
+	"\x05reply\"\xb4\x01\n" +
+	"\x0eReceiveRequest\x12F\n" +
+	"\n" +
+	"assignment\x18\x01 \x01(\v2&.com.github.bsideup.liiklus.AssignmentR\n" +
+	"assignment\x12(\n" +
+	"\x0flastKnownOffset\x18\x02 \x01(\x04R\x0flastKnownOffset\x12\x16\n" +
+	"\x06accept\x18\x03 \x01(\tR\x06accept\x12\x18\n" +
+	"\acredits\x18\x04 \x01(\rR\acredits\"\xb5\x03\n" +
+	"\fReceiveReply\x12I\n" +
+	"\x06record\x18\x01 \x01(\v2/.com.github.bsideup.liiklus.ReceiveReply.RecordH\x00R\x06record\x1a\xd0\x02\n" +
+	"\x06Record\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x04R\x06offset\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\fR\x03key\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\fR\x05value\x128\n" +
+	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x16\n" +
+	"\x06replay\x18\x05 \x01(\bR\x06replay\x12 \n" +
+	"\vcontentType\x18\x06 \x01(\tR\vcontentType\x12V\n" +
+	"\aheaders\x18\a \x03(\v2<.com.github.bsideup.liiklus.ReceiveReply.Record.HeadersEntryR\aheaders\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01B\a\n" +
+	"\x05reply\"\xde\x01\n" +
+	"\n" +
+	"AckRequest\x12J\n" +
+	"\n" +
+	"assignment\x18\x01 \x01(\v2&.com.github.bsideup.liiklus.AssignmentB\x02\x18\x01R\n" +
+	"assignment\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x14\n" +
+	"\x05topic\x18\x03 \x01(\tR\x05topic\x12\x14\n" +
+	"\x05group\x18\x04 \x01(\tR\x05group\x12\"\n" +
+	"\fgroupVersion\x18\x05 \x01(\rR\fgroupVersion\x12\x1c\n" +
+	"\tpartition\x18\x06 \x01(\rR\tpartition\"\x93\x01\n" +
+	"\vNackRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x14\n" +
+	"\x05group\x18\x02 \x01(\tR\x05group\x12\"\n" +
+	"\fgroupVersion\x18\x03 \x01(\rR\fgroupVersion\x12\x1c\n" +
+	"\tpartition\x18\x04 \x01(\rR\tpartition\x12\x16\n" +
+	"\x06offset\x18\x05 \x01(\x04R\x06offset\"\x9b\x01\n" +
+	"\x15RequestRecordsRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x14\n" +
+	"\x05group\x18\x02 \x01(\tR\x05group\x12\"\n" +
+	"\fgroupVersion\x18\x03 \x01(\rR\fgroupVersion\x12\x1c\n" +
+	"\tpartition\x18\x04 \x01(\rR\tpartition\x12\x14\n" +
+	"\x05count\x18\x05 \x01(\rR\x05count\"_\n" +
+	"\rCommitRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x14\n" +
+	"\x05group\x18\x02 \x01(\tR\x05group\x12\"\n" +
+	"\fgroupVersion\x18\x03 \x01(\rR\fgroupVersion\"\x91\x01\n" +
+	"\x0fTransactRequest\x12D\n" +
+	"\arecords\x18\x01 \x03(\v2*.com.github.bsideup.liiklus.PublishRequestR\arecords\x128\n" +
+	"\x03ack\x18\x02 \x01(\v2&.com.github.bsideup.liiklus.AckRequestR\x03ack\"S\n" +
+	"\rTransactReply\x12B\n" +
+	"\arecords\x18\x01 \x03(\v2(.com.github.bsideup.liiklus.PublishReplyR\arecords\"[\n" +
+	"\x13PublishBatchRequest\x12D\n" +
+	"\arecords\x18\x01 \x03(\v2*.com.github.bsideup.liiklus.PublishRequestR\arecords\"]\n" +
+	"\x11PublishBatchReply\x12H\n" +
+	"\aresults\x18\x01 \x03(\v2..com.github.bsideup.liiklus.PublishBatchResultR\aresults\"\x84\x01\n" +
+	"\x12PublishBatchResult\x12@\n" +
+	"\x06record\x18\x01 \x01(\v2(.com.github.bsideup.liiklus.PublishReplyR\x06record\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\rR\x04code\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"c\n" +
+	"\x11GetOffsetsRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x14\n" +
+	"\x05group\x18\x02 \x01(\tR\x05group\x12\"\n" +
+	"\fgroupVersion\x18\x03 \x01(\rR\fgroupVersion\"\xa1\x01\n" +
+	"\x0fGetOffsetsReply\x12R\n" +
+	"\aoffsets\x18\x01 \x03(\v28.com.github.bsideup.liiklus.GetOffsetsReply.OffsetsEntryR\aoffsets\x1a:\n" +
+	"\fOffsetsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\rR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\",\n" +
+	"\x14GetEndOffsetsRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\"\xa7\x01\n" +
+	"\x12GetEndOffsetsReply\x12U\n" +
+	"\aoffsets\x18\x01 \x03(\v2;.com.github.bsideup.liiklus.GetEndOffsetsReply.OffsetsEntryR\aoffsets\x1a:\n" +
+	"\fOffsetsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\rR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x012\xbe\b\n" +
+	"\x0eLiiklusService\x12a\n" +
+	"\aPublish\x12*.com.github.bsideup.liiklus.PublishRequest\x1a(.com.github.bsideup.liiklus.PublishReply\"\x00\x12i\n" +
+	"\tSubscribe\x12,.com.github.bsideup.liiklus.SubscribeRequest\x1a*.com.github.bsideup.liiklus.SubscribeReply\"\x000\x01\x12c\n" +
+	"\aReceive\x12*.com.github.bsideup.liiklus.ReceiveRequest\x1a(.com.github.bsideup.liiklus.ReceiveReply\"\x000\x01\x12G\n" +
+	"\x03Ack\x12&.com.github.bsideup.liiklus.AckRequest\x1a\x16.google.protobuf.Empty\"\x00\x12I\n" +
+	"\x04Nack\x12'.com.github.bsideup.liiklus.NackRequest\x1a\x16.google.protobuf.Empty\"\x00\x12]\n" +
+	"\x0eRequestRecords\x121.com.github.bsideup.liiklus.RequestRecordsRequest\x1a\x16.google.protobuf.Empty\"\x00\x12M\n" +
+	"\x06Commit\x12).com.github.bsideup.liiklus.CommitRequest\x1a\x16.google.protobuf.Empty\"\x00\x12d\n" +
+	"\bTransact\x12+.com.github.bsideup.liiklus.TransactRequest\x1a).com.github.bsideup.liiklus.TransactReply\"\x00\x12p\n" +
+	"\fPublishBatch\x12/.com.github.bsideup.liiklus.PublishBatchRequest\x1a-.com.github.bsideup.liiklus.PublishBatchReply\"\x00\x12j\n" +
+	"\n" +
+	"GetOffsets\x12-.com.github.bsideup.liiklus.GetOffsetsRequest\x1a+.com.github.bsideup.liiklus.GetOffsetsReply\"\x00\x12s\n" +
+	"\rGetEndOffsets\x120.com.github.bsideup.liiklus.GetEndOffsetsRequest\x1a..com.github.bsideup.liiklus.GetEndOffsetsReply\"\x00Be\n" +
+	"#com.github.bsideup.liiklus.protocolP\x01Z<github.com/projectriff/kafka-provisioner/pkg/gateway/liiklusb\x06proto3"
+
+var (
+	file_liiklus_proto_rawDescOnce sync.Once
+	file_liiklus_proto_rawDescData []byte
+)
+
+func file_liiklus_proto_rawDescGZIP() []byte {
+	file_liiklus_proto_rawDescOnce.Do(func() {
+		file_liiklus_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_liiklus_proto_rawDesc), len(file_liiklus_proto_rawDesc)))
+	})
+	return file_liiklus_proto_rawDescData
+}
+
+var file_liiklus_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_liiklus_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
+var file_liiklus_proto_goTypes = []any{
+	(SubscribeRequest_AutoOffsetReset)(0), // 0: com.github.bsideup.liiklus.SubscribeRequest.AutoOffsetReset
+	(SubscribeRequest_CommitStrategy)(0),  // 1: com.github.bsideup.liiklus.SubscribeRequest.CommitStrategy
+	(*PublishRequest)(nil),                // 2: com.github.bsideup.liiklus.PublishRequest
+	(*PublishReply)(nil),                  // 3: com.github.bsideup.liiklus.PublishReply
+	(*SubscribeRequest)(nil),              // 4: com.github.bsideup.liiklus.SubscribeRequest
+	(*Assignment)(nil),                    // 5: com.github.bsideup.liiklus.Assignment
+	(*SubscribeReply)(nil),                // 6: com.github.bsideup.liiklus.SubscribeReply
+	(*ReceiveRequest)(nil),                // 7: com.github.bsideup.liiklus.ReceiveRequest
+	(*ReceiveReply)(nil),                  // 8: com.github.bsideup.liiklus.ReceiveReply
+	(*AckRequest)(nil),                    // 9: com.github.bsideup.liiklus.AckRequest
+	(*NackRequest)(nil),                   // 10: com.github.bsideup.liiklus.NackRequest
+	(*RequestRecordsRequest)(nil),         // 11: com.github.bsideup.liiklus.RequestRecordsRequest
+	(*CommitRequest)(nil),                 // 12: com.github.bsideup.liiklus.CommitRequest
+	(*TransactRequest)(nil),               // 13: com.github.bsideup.liiklus.TransactRequest
+	(*TransactReply)(nil),                 // 14: com.github.bsideup.liiklus.TransactReply
+	(*PublishBatchRequest)(nil),           // 15: com.github.bsideup.liiklus.PublishBatchRequest
+	(*PublishBatchReply)(nil),             // 16: com.github.bsideup.liiklus.PublishBatchReply
+	(*PublishBatchResult)(nil),            // 17: com.github.bsideup.liiklus.PublishBatchResult
+	(*GetOffsetsRequest)(nil),             // 18: com.github.bsideup.liiklus.GetOffsetsRequest
+	(*GetOffsetsReply)(nil),               // 19: com.github.bsideup.liiklus.GetOffsetsReply
+	(*GetEndOffsetsRequest)(nil),          // 20: com.github.bsideup.liiklus.GetEndOffsetsRequest
+	(*GetEndOffsetsReply)(nil),            // 21: com.github.bsideup.liiklus.GetEndOffsetsReply
+	nil,                                   // 22: com.github.bsideup.liiklus.PublishRequest.HeadersEntry
+	(*ReceiveReply_Record)(nil),           // 23: com.github.bsideup.liiklus.ReceiveReply.Record
+	nil,                                   // 24: com.github.bsideup.liiklus.ReceiveReply.Record.HeadersEntry
+	nil,                                   // 25: com.github.bsideup.liiklus.GetOffsetsReply.OffsetsEntry
+	nil,                                   // 26: com.github.bsideup.liiklus.GetEndOffsetsReply.OffsetsEntry
+	(*timestamppb.Timestamp)(nil),         // 27: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),                 // 28: google.protobuf.Empty
+}
+var file_liiklus_proto_depIdxs = []int32{
+	22, // 0: com.github.bsideup.liiklus.PublishRequest.headers:type_name -> com.github.bsideup.liiklus.PublishRequest.HeadersEntry
+	0,  // 1: com.github.bsideup.liiklus.SubscribeRequest.autoOffsetReset:type_name -> com.github.bsideup.liiklus.SubscribeRequest.AutoOffsetReset
+	1,  // 2: com.github.bsideup.liiklus.SubscribeRequest.commitStrategy:type_name -> com.github.bsideup.liiklus.SubscribeRequest.CommitStrategy
+	5,  // 3: com.github.bsideup.liiklus.SubscribeReply.assignment:type_name -> com.github.bsideup.liiklus.Assignment
+	5,  // 4: com.github.bsideup.liiklus.ReceiveRequest.assignment:type_name -> com.github.bsideup.liiklus.Assignment
+	23, // 5: com.github.bsideup.liiklus.ReceiveReply.record:type_name -> com.github.bsideup.liiklus.ReceiveReply.Record
+	5,  // 6: com.github.bsideup.liiklus.AckRequest.assignment:type_name -> com.github.bsideup.liiklus.Assignment
+	2,  // 7: com.github.bsideup.liiklus.TransactRequest.records:type_name -> com.github.bsideup.liiklus.PublishRequest
+	9,  // 8: com.github.bsideup.liiklus.TransactRequest.ack:type_name -> com.github.bsideup.liiklus.AckRequest
+	3,  // 9: com.github.bsideup.liiklus.TransactReply.records:type_name -> com.github.bsideup.liiklus.PublishReply
+	2,  // 10: com.github.bsideup.liiklus.PublishBatchRequest.records:type_name -> com.github.bsideup.liiklus.PublishRequest
+	17, // 11: com.github.bsideup.liiklus.PublishBatchReply.results:type_name -> com.github.bsideup.liiklus.PublishBatchResult
+	3,  // 12: com.github.bsideup.liiklus.PublishBatchResult.record:type_name -> com.github.bsideup.liiklus.PublishReply
+	25, // 13: com.github.bsideup.liiklus.GetOffsetsReply.offsets:type_name -> com.github.bsideup.liiklus.GetOffsetsReply.OffsetsEntry
+	26, // 14: com.github.bsideup.liiklus.GetEndOffsetsReply.offsets:type_name -> com.github.bsideup.liiklus.GetEndOffsetsReply.OffsetsEntry
+	27, // 15: com.github.bsideup.liiklus.ReceiveReply.Record.timestamp:type_name -> google.protobuf.Timestamp
+	24, // 16: com.github.bsideup.liiklus.ReceiveReply.Record.headers:type_name -> com.github.bsideup.liiklus.ReceiveReply.Record.HeadersEntry
+	2,  // 17: com.github.bsideup.liiklus.LiiklusService.Publish:input_type -> com.github.bsideup.liiklus.PublishRequest
+	4,  // 18: com.github.bsideup.liiklus.LiiklusService.Subscribe:input_type -> com.github.bsideup.liiklus.SubscribeRequest
+	7,  // 19: com.github.bsideup.liiklus.LiiklusService.Receive:input_type -> com.github.bsideup.liiklus.ReceiveRequest
+	9,  // 20: com.github.bsideup.liiklus.LiiklusService.Ack:input_type -> com.github.bsideup.liiklus.AckRequest
+	10, // 21: com.github.bsideup.liiklus.LiiklusService.Nack:input_type -> com.github.bsideup.liiklus.NackRequest
+	11, // 22: com.github.bsideup.liiklus.LiiklusService.RequestRecords:input_type -> com.github.bsideup.liiklus.RequestRecordsRequest
+	12, // 23: com.github.bsideup.liiklus.LiiklusService.Commit:input_type -> com.github.bsideup.liiklus.CommitRequest
+	13, // 24: com.github.bsideup.liiklus.LiiklusService.Transact:input_type -> com.github.bsideup.liiklus.TransactRequest
+	15, // 25: com.github.bsideup.liiklus.LiiklusService.PublishBatch:input_type -> com.github.bsideup.liiklus.PublishBatchRequest
+	18, // 26: com.github.bsideup.liiklus.LiiklusService.GetOffsets:input_type -> com.github.bsideup.liiklus.GetOffsetsRequest
+	20, // 27: com.github.bsideup.liiklus.LiiklusService.GetEndOffsets:input_type -> com.github.bsideup.liiklus.GetEndOffsetsRequest
+	3,  // 28: com.github.bsideup.liiklus.LiiklusService.Publish:output_type -> com.github.bsideup.liiklus.PublishReply
+	6,  // 29: com.github.bsideup.liiklus.LiiklusService.Subscribe:output_type -> com.github.bsideup.liiklus.SubscribeReply
+	8,  // 30: com.github.bsideup.liiklus.LiiklusService.Receive:output_type -> com.github.bsideup.liiklus.ReceiveReply
+	28, // 31: com.github.bsideup.liiklus.LiiklusService.Ack:output_type -> google.protobuf.Empty
+	28, // 32: com.github.bsideup.liiklus.LiiklusService.Nack:output_type -> google.protobuf.Empty
+	28, // 33: com.github.bsideup.liiklus.LiiklusService.RequestRecords:output_type -> google.protobuf.Empty
+	28, // 34: com.github.bsideup.liiklus.LiiklusService.Commit:output_type -> google.protobuf.Empty
+	14, // 35: com.github.bsideup.liiklus.LiiklusService.Transact:output_type -> com.github.bsideup.liiklus.TransactReply
+	16, // 36: com.github.bsideup.liiklus.LiiklusService.PublishBatch:output_type -> com.github.bsideup.liiklus.PublishBatchReply
+	19, // 37: com.github.bsideup.liiklus.LiiklusService.GetOffsets:output_type -> com.github.bsideup.liiklus.GetOffsetsReply
+	21, // 38: com.github.bsideup.liiklus.LiiklusService.GetEndOffsets:output_type -> com.github.bsideup.liiklus.GetEndOffsetsReply
+	28, // [28:39] is the sub-list for method output_type
+	17, // [17:28] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
+}
+
+func init() { file_liiklus_proto_init() }
+func file_liiklus_proto_init() {
+	if File_liiklus_proto != nil {
+		return
+	}
+	file_liiklus_proto_msgTypes[4].OneofWrappers = []any{
+		(*SubscribeReply_Assignment)(nil),
+	}
+	file_liiklus_proto_msgTypes[6].OneofWrappers = []any{
+		(*ReceiveReply_Record_)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_liiklus_proto_rawDesc), len(file_liiklus_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   25,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_liiklus_proto_goTypes,
+		DependencyIndexes: file_liiklus_proto_depIdxs,
+		EnumInfos:         file_liiklus_proto_enumTypes,
+		MessageInfos:      file_liiklus_proto_msgTypes,
+	}.Build()
+	File_liiklus_proto = out.File
+	file_liiklus_proto_goTypes = nil
+	file_liiklus_proto_depIdxs = nil
+}
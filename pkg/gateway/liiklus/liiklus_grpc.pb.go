@@ -0,0 +1,527 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             v3.21.12
+// source: liiklus.proto
+
+package liiklus
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	LiiklusService_Publish_FullMethodName        = "/com.github.bsideup.liiklus.LiiklusService/Publish"
+	LiiklusService_Subscribe_FullMethodName      = "/com.github.bsideup.liiklus.LiiklusService/Subscribe"
+	LiiklusService_Receive_FullMethodName        = "/com.github.bsideup.liiklus.LiiklusService/Receive"
+	LiiklusService_Ack_FullMethodName            = "/com.github.bsideup.liiklus.LiiklusService/Ack"
+	LiiklusService_Nack_FullMethodName           = "/com.github.bsideup.liiklus.LiiklusService/Nack"
+	LiiklusService_RequestRecords_FullMethodName = "/com.github.bsideup.liiklus.LiiklusService/RequestRecords"
+	LiiklusService_Commit_FullMethodName         = "/com.github.bsideup.liiklus.LiiklusService/Commit"
+	LiiklusService_Transact_FullMethodName       = "/com.github.bsideup.liiklus.LiiklusService/Transact"
+	LiiklusService_PublishBatch_FullMethodName   = "/com.github.bsideup.liiklus.LiiklusService/PublishBatch"
+	LiiklusService_GetOffsets_FullMethodName     = "/com.github.bsideup.liiklus.LiiklusService/GetOffsets"
+	LiiklusService_GetEndOffsets_FullMethodName  = "/com.github.bsideup.liiklus.LiiklusService/GetEndOffsets"
+)
+
+// LiiklusServiceClient is the client API for LiiklusService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// The liiklus (https://github.com/bsideup/liiklus) API spoken by riff stream processors and clients.
+// Regenerate the Go code with `make gen-proto` after editing this file.
+type LiiklusServiceClient interface {
+	Publish(ctx context.Context, in *PublishRequest, opts ...grpc.CallOption) (*PublishReply, error)
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubscribeReply], error)
+	Receive(ctx context.Context, in *ReceiveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReceiveReply], error)
+	Ack(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Not part of liiklus: asks for an unacknowledged record to be delivered again, when redelivery is enabled
+	Nack(ctx context.Context, in *NackRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Not part of liiklus: asks for more records of a partition received with flow control
+	RequestRecords(ctx context.Context, in *RequestRecordsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Not part of liiklus: commits the offsets acknowledged by a subscription with the MANUAL commit strategy
+	Commit(ctx context.Context, in *CommitRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Not part of liiklus: publishes records and acknowledges the record they were produced from atomically,
+	// when transactions are enabled
+	Transact(ctx context.Context, in *TransactRequest, opts ...grpc.CallOption) (*TransactReply, error)
+	// Not part of liiklus: publishes records in a single batch, each record being published or failing on its own
+	PublishBatch(ctx context.Context, in *PublishBatchRequest, opts ...grpc.CallOption) (*PublishBatchReply, error)
+	GetOffsets(ctx context.Context, in *GetOffsetsRequest, opts ...grpc.CallOption) (*GetOffsetsReply, error)
+	GetEndOffsets(ctx context.Context, in *GetEndOffsetsRequest, opts ...grpc.CallOption) (*GetEndOffsetsReply, error)
+}
+
+type liiklusServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLiiklusServiceClient(cc grpc.ClientConnInterface) LiiklusServiceClient {
+	return &liiklusServiceClient{cc}
+}
+
+func (c *liiklusServiceClient) Publish(ctx context.Context, in *PublishRequest, opts ...grpc.CallOption) (*PublishReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PublishReply)
+	err := c.cc.Invoke(ctx, LiiklusService_Publish_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liiklusServiceClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubscribeReply], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LiiklusService_ServiceDesc.Streams[0], LiiklusService_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, SubscribeReply]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LiiklusService_SubscribeClient = grpc.ServerStreamingClient[SubscribeReply]
+
+func (c *liiklusServiceClient) Receive(ctx context.Context, in *ReceiveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReceiveReply], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LiiklusService_ServiceDesc.Streams[1], LiiklusService_Receive_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReceiveRequest, ReceiveReply]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LiiklusService_ReceiveClient = grpc.ServerStreamingClient[ReceiveReply]
+
+func (c *liiklusServiceClient) Ack(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, LiiklusService_Ack_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liiklusServiceClient) Nack(ctx context.Context, in *NackRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, LiiklusService_Nack_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liiklusServiceClient) RequestRecords(ctx context.Context, in *RequestRecordsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, LiiklusService_RequestRecords_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liiklusServiceClient) Commit(ctx context.Context, in *CommitRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, LiiklusService_Commit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liiklusServiceClient) Transact(ctx context.Context, in *TransactRequest, opts ...grpc.CallOption) (*TransactReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransactReply)
+	err := c.cc.Invoke(ctx, LiiklusService_Transact_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liiklusServiceClient) PublishBatch(ctx context.Context, in *PublishBatchRequest, opts ...grpc.CallOption) (*PublishBatchReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PublishBatchReply)
+	err := c.cc.Invoke(ctx, LiiklusService_PublishBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liiklusServiceClient) GetOffsets(ctx context.Context, in *GetOffsetsRequest, opts ...grpc.CallOption) (*GetOffsetsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOffsetsReply)
+	err := c.cc.Invoke(ctx, LiiklusService_GetOffsets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liiklusServiceClient) GetEndOffsets(ctx context.Context, in *GetEndOffsetsRequest, opts ...grpc.CallOption) (*GetEndOffsetsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetEndOffsetsReply)
+	err := c.cc.Invoke(ctx, LiiklusService_GetEndOffsets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LiiklusServiceServer is the server API for LiiklusService service.
+// All implementations must embed UnimplementedLiiklusServiceServer
+// for forward compatibility.
+//
+// The liiklus (https://github.com/bsideup/liiklus) API spoken by riff stream processors and clients.
+// Regenerate the Go code with `make gen-proto` after editing this file.
+type LiiklusServiceServer interface {
+	Publish(context.Context, *PublishRequest) (*PublishReply, error)
+	Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[SubscribeReply]) error
+	Receive(*ReceiveRequest, grpc.ServerStreamingServer[ReceiveReply]) error
+	Ack(context.Context, *AckRequest) (*emptypb.Empty, error)
+	// Not part of liiklus: asks for an unacknowledged record to be delivered again, when redelivery is enabled
+	Nack(context.Context, *NackRequest) (*emptypb.Empty, error)
+	// Not part of liiklus: asks for more records of a partition received with flow control
+	RequestRecords(context.Context, *RequestRecordsRequest) (*emptypb.Empty, error)
+	// Not part of liiklus: commits the offsets acknowledged by a subscription with the MANUAL commit strategy
+	Commit(context.Context, *CommitRequest) (*emptypb.Empty, error)
+	// Not part of liiklus: publishes records and acknowledges the record they were produced from atomically,
+	// when transactions are enabled
+	Transact(context.Context, *TransactRequest) (*TransactReply, error)
+	// Not part of liiklus: publishes records in a single batch, each record being published or failing on its own
+	PublishBatch(context.Context, *PublishBatchRequest) (*PublishBatchReply, error)
+	GetOffsets(context.Context, *GetOffsetsRequest) (*GetOffsetsReply, error)
+	GetEndOffsets(context.Context, *GetEndOffsetsRequest) (*GetEndOffsetsReply, error)
+	mustEmbedUnimplementedLiiklusServiceServer()
+}
+
+// UnimplementedLiiklusServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLiiklusServiceServer struct{}
+
+func (UnimplementedLiiklusServiceServer) Publish(context.Context, *PublishRequest) (*PublishReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Publish not implemented")
+}
+func (UnimplementedLiiklusServiceServer) Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[SubscribeReply]) error {
+	return status.Error(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedLiiklusServiceServer) Receive(*ReceiveRequest, grpc.ServerStreamingServer[ReceiveReply]) error {
+	return status.Error(codes.Unimplemented, "method Receive not implemented")
+}
+func (UnimplementedLiiklusServiceServer) Ack(context.Context, *AckRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Ack not implemented")
+}
+func (UnimplementedLiiklusServiceServer) Nack(context.Context, *NackRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Nack not implemented")
+}
+func (UnimplementedLiiklusServiceServer) RequestRecords(context.Context, *RequestRecordsRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method RequestRecords not implemented")
+}
+func (UnimplementedLiiklusServiceServer) Commit(context.Context, *CommitRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Commit not implemented")
+}
+func (UnimplementedLiiklusServiceServer) Transact(context.Context, *TransactRequest) (*TransactReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Transact not implemented")
+}
+func (UnimplementedLiiklusServiceServer) PublishBatch(context.Context, *PublishBatchRequest) (*PublishBatchReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method PublishBatch not implemented")
+}
+func (UnimplementedLiiklusServiceServer) GetOffsets(context.Context, *GetOffsetsRequest) (*GetOffsetsReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetOffsets not implemented")
+}
+func (UnimplementedLiiklusServiceServer) GetEndOffsets(context.Context, *GetEndOffsetsRequest) (*GetEndOffsetsReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetEndOffsets not implemented")
+}
+func (UnimplementedLiiklusServiceServer) mustEmbedUnimplementedLiiklusServiceServer() {}
+func (UnimplementedLiiklusServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeLiiklusServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LiiklusServiceServer will
+// result in compilation errors.
+type UnsafeLiiklusServiceServer interface {
+	mustEmbedUnimplementedLiiklusServiceServer()
+}
+
+func RegisterLiiklusServiceServer(s grpc.ServiceRegistrar, srv LiiklusServiceServer) {
+	// If the following call panics, it indicates UnimplementedLiiklusServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LiiklusService_ServiceDesc, srv)
+}
+
+func _LiiklusService_Publish_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublishRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiiklusServiceServer).Publish(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiiklusService_Publish_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiiklusServiceServer).Publish(ctx, req.(*PublishRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiiklusService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LiiklusServiceServer).Subscribe(m, &grpc.GenericServerStream[SubscribeRequest, SubscribeReply]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LiiklusService_SubscribeServer = grpc.ServerStreamingServer[SubscribeReply]
+
+func _LiiklusService_Receive_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReceiveRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LiiklusServiceServer).Receive(m, &grpc.GenericServerStream[ReceiveRequest, ReceiveReply]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LiiklusService_ReceiveServer = grpc.ServerStreamingServer[ReceiveReply]
+
+func _LiiklusService_Ack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiiklusServiceServer).Ack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiiklusService_Ack_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiiklusServiceServer).Ack(ctx, req.(*AckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiiklusService_Nack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiiklusServiceServer).Nack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiiklusService_Nack_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiiklusServiceServer).Nack(ctx, req.(*NackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiiklusService_RequestRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiiklusServiceServer).RequestRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiiklusService_RequestRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiiklusServiceServer).RequestRecords(ctx, req.(*RequestRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiiklusService_Commit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiiklusServiceServer).Commit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiiklusService_Commit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiiklusServiceServer).Commit(ctx, req.(*CommitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiiklusService_Transact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiiklusServiceServer).Transact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiiklusService_Transact_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiiklusServiceServer).Transact(ctx, req.(*TransactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiiklusService_PublishBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublishBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiiklusServiceServer).PublishBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiiklusService_PublishBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiiklusServiceServer).PublishBatch(ctx, req.(*PublishBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiiklusService_GetOffsets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOffsetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiiklusServiceServer).GetOffsets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiiklusService_GetOffsets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiiklusServiceServer).GetOffsets(ctx, req.(*GetOffsetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiiklusService_GetEndOffsets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEndOffsetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiiklusServiceServer).GetEndOffsets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiiklusService_GetEndOffsets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiiklusServiceServer).GetEndOffsets(ctx, req.(*GetEndOffsetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LiiklusService_ServiceDesc is the grpc.ServiceDesc for LiiklusService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LiiklusService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "com.github.bsideup.liiklus.LiiklusService",
+	HandlerType: (*LiiklusServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Publish",
+			Handler:    _LiiklusService_Publish_Handler,
+		},
+		{
+			MethodName: "Ack",
+			Handler:    _LiiklusService_Ack_Handler,
+		},
+		{
+			MethodName: "Nack",
+			Handler:    _LiiklusService_Nack_Handler,
+		},
+		{
+			MethodName: "RequestRecords",
+			Handler:    _LiiklusService_RequestRecords_Handler,
+		},
+		{
+			MethodName: "Commit",
+			Handler:    _LiiklusService_Commit_Handler,
+		},
+		{
+			MethodName: "Transact",
+			Handler:    _LiiklusService_Transact_Handler,
+		},
+		{
+			MethodName: "PublishBatch",
+			Handler:    _LiiklusService_PublishBatch_Handler,
+		},
+		{
+			MethodName: "GetOffsets",
+			Handler:    _LiiklusService_GetOffsets_Handler,
+		},
+		{
+			MethodName: "GetEndOffsets",
+			Handler:    _LiiklusService_GetEndOffsets_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _LiiklusService_Subscribe_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Receive",
+			Handler:       _LiiklusService_Receive_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "liiklus.proto",
+}
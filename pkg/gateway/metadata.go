@@ -0,0 +1,128 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StreamMetadata follows the metadata the provisioner records for streams in its metadata topic, so that the
+// gateway applies what was chosen for streams when they were provisioned
+type StreamMetadata struct {
+	// Consumer reads the metadata topic
+	Consumer sarama.Consumer
+	// RetryInterval is how long to wait before consuming the metadata topic again after an error, e.g. while
+	// it doesn't exist yet
+	RetryInterval time.Duration
+	Logger        *slog.Logger
+
+	m       sync.RWMutex
+	streams map[string]client.StreamMetadata
+}
+
+// Run follows the metadata topic from its start until ctx is done
+func (sm *StreamMetadata) Run(ctx context.Context) {
+	offset := sarama.OffsetOldest
+	for {
+		offset = sm.follow(ctx, offset)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(sm.RetryInterval):
+		}
+	}
+}
+
+// follow consumes the metadata topic from an offset until ctx is done or an error occurs, returning the offset
+// to resume from
+func (sm *StreamMetadata) follow(ctx context.Context, offset int64) int64 {
+	partitionConsumer, err := sm.Consumer.ConsumePartition(client.MetadataTopic, 0, offset)
+	if err != nil {
+		if err != sarama.ErrUnknownTopicOrPartition {
+			sm.Logger.Error("Error consuming the stream metadata topic", "topic", client.MetadataTopic, "error", err)
+		}
+		return offset
+	}
+	defer partitionConsumer.AsyncClose()
+	for {
+		select {
+		case <-ctx.Done():
+			return offset
+		case message, ok := <-partitionConsumer.Messages():
+			if !ok {
+				return offset
+			}
+			sm.record(message)
+			offset = message.Offset + 1
+		case consumerError, ok := <-partitionConsumer.Errors():
+			if !ok {
+				return offset
+			}
+			sm.Logger.Error("Error consuming the stream metadata topic", "topic", client.MetadataTopic, "error", consumerError.Err)
+		}
+	}
+}
+
+func (sm *StreamMetadata) record(message *sarama.ConsumerMessage) {
+	topic := string(message.Key)
+	var metadata client.StreamMetadata
+	// empty values delete the metadata of a topic
+	if len(message.Value) > 0 {
+		if err := json.Unmarshal(message.Value, &metadata); err != nil {
+			sm.Logger.Warn("Ignoring invalid stream metadata", "topic", topic, "offset", message.Offset, "error", err)
+			return
+		}
+	}
+	sm.m.Lock()
+	defer sm.m.Unlock()
+	if sm.streams == nil {
+		sm.streams = make(map[string]client.StreamMetadata)
+	}
+	if len(message.Value) == 0 {
+		delete(sm.streams, topic)
+		return
+	}
+	sm.streams[topic] = metadata
+}
+
+// Get returns the metadata recorded for a topic, false when there is none
+func (sm *StreamMetadata) Get(topic string) (client.StreamMetadata, bool) {
+	sm.m.RLock()
+	defer sm.m.RUnlock()
+	metadata, ok := sm.streams[topic]
+	return metadata, ok
+}
+
+// checkArchived returns a NOT_FOUND status when a topic is the topic of a stream deleted and archived
+func (sm *StreamMetadata) checkArchived(topic string) error {
+	if sm == nil {
+		return nil
+	}
+	if metadata, ok := sm.Get(topic); ok && metadata.Archived != nil {
+		return status.Errorf(codes.NotFound, "the stream of topic %q was deleted, its topic being archived until %s", topic, metadata.Archived.DeleteAfter.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Envelope returns the envelope recorded for the stream of a topic, empty when unspecified
+func (sm *StreamMetadata) Envelope(topic string) string {
+	if sm == nil {
+		return ""
+	}
+	metadata, _ := sm.Get(topic)
+	return metadata.Envelope
+}
+
+// SchemaSubject returns the Schema Registry subject of the values of a topic, following the naming strategy
+// chosen for its stream, the <topic>-value subject by default
+func (sm *StreamMetadata) SchemaSubject(topic string) string {
+	metadata, _ := sm.Get(topic)
+	return metadata.Schema.Subject(topic)
+}
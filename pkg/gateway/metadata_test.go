@@ -0,0 +1,128 @@
+package gateway_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/liiklus"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Stream metadata", func() {
+
+	var (
+		consumer       *mocks.Consumer
+		partition      *mocks.PartitionConsumer
+		streamMetadata *gateway.StreamMetadata
+		cancel         context.CancelFunc
+		done           chan struct{}
+	)
+
+	BeforeEach(func() {
+		consumer = mocks.NewConsumer(GinkgoT(), nil)
+		partition = consumer.ExpectConsumePartition(client.MetadataTopic, 0, sarama.OffsetOldest)
+		streamMetadata = &gateway.StreamMetadata{Consumer: consumer, RetryInterval: time.Millisecond, Logger: slog.Default()}
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		done = make(chan struct{})
+		go func() {
+			defer close(done)
+			streamMetadata.Run(ctx)
+		}()
+	})
+
+	AfterEach(func() {
+		cancel()
+		Eventually(done).Should(BeClosed())
+	})
+
+	yield := func(topic string, value string) {
+		partition.YieldMessage(&sarama.ConsumerMessage{Key: []byte(topic), Value: []byte(value)})
+	}
+
+	It("follows the metadata recorded for streams", func() {
+		yield("ns_orders", `{"contentType": "application/json"}`)
+
+		Eventually(func() string {
+			metadata, _ := streamMetadata.Get("ns_orders")
+			return metadata.ContentType
+		}).Should(Equal("application/json"))
+	})
+
+	It("forgets the metadata deleted", func() {
+		yield("ns_orders", `{"contentType": "application/json"}`)
+		yield("ns_orders", "")
+		yield("ns_other", `{}`)
+
+		Eventually(func() bool {
+			_, ok := streamMetadata.Get("ns_other")
+			return ok
+		}).Should(BeTrue())
+		_, ok := streamMetadata.Get("ns_orders")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("names schema subjects after the strategy chosen for streams", func() {
+		yield("ns_orders", `{"schema": {"subjectNameStrategy": "topic-record-name", "recordName": "com.example.Order"}}`)
+
+		Eventually(func() string {
+			return streamMetadata.SchemaSubject("ns_orders")
+		}).Should(Equal("ns_orders-com.example.Order"))
+		Expect(streamMetadata.SchemaSubject("ns_other")).To(Equal("ns_other-value"))
+	})
+
+	It("publishes the records of streams over HTTP framed as their envelope", func() {
+		yield("ns_raw", `{"envelope": "raw"}`)
+		yield("ns_events", `{"envelope": "cloudevents"}`)
+		Eventually(func() string {
+			return streamMetadata.Envelope("ns_events")
+		}).Should(Equal(client.CloudEventsEnvelope))
+		producer := mocks.NewSyncProducer(GinkgoT(), nil)
+		defer producer.Close()
+		server := &gateway.Server{Producer: producer, Streams: streamMetadata, Logger: slog.Default()}
+
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(message *sarama.ProducerMessage) error {
+			// the attributes of what looks like a CloudEvent aren't moved to headers
+			Expect(message.Value).To(Equal(sarama.ByteEncoder(`{"total": 42}`)))
+			Expect(message.Headers).To(ConsistOf(sarama.RecordHeader{Key: []byte("content-type"), Value: []byte("application/json")}))
+			return nil
+		})
+		request := httptest.NewRequest(http.MethodPost, "/ns/raw", strings.NewReader(`{"total": 42}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Ce-Specversion", "1.0")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+
+		recorder = httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/ns/events", strings.NewReader(`{"total": 42}`)))
+		Expect(recorder.Code).To(Equal(http.StatusUnsupportedMediaType))
+		Expect(recorder.Body.String()).To(Equal("the records of stream \"ns_events\" are CloudEvents, in binary or structured mode\n"))
+	})
+
+	It("refuses calls on archived streams", func() {
+		yield("ns_orders", `{"archived": {"since": "2020-01-01T00:00:00Z", "deleteAfter": "2020-01-08T00:00:00Z"}}`)
+		server := &gateway.Server{Streams: streamMetadata}
+		handler := func(ctx context.Context, request interface{}) (interface{}, error) {
+			return &liiklus.PublishReply{}, nil
+		}
+
+		Eventually(func() codes.Code {
+			_, err := server.UnaryInterceptor(context.Background(), &liiklus.PublishRequest{Topic: "ns_orders"}, nil, handler)
+			return status.Code(err)
+		}).Should(Equal(codes.NotFound))
+		_, err := server.UnaryInterceptor(context.Background(), &liiklus.PublishRequest{Topic: "ns_other"}, nil, handler)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
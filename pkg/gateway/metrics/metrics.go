@@ -0,0 +1,120 @@
+// Package metrics exports the throughput of the streams flowing through the gateway, and the lag of the
+// consumer groups subscribed to them, labeled by namespace and stream
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "riff"
+	subsystem = "kafka_gateway"
+)
+
+// Metrics holds the collectors exported by the gateway. Its methods do nothing on a nil *Metrics.
+type Metrics struct {
+	Registry         *prometheus.Registry
+	publishedRecords *prometheus.CounterVec
+	publishedBytes   *prometheus.CounterVec
+	deliveredRecords *prometheus.CounterVec
+	deliveredBytes   *prometheus.CounterVec
+	consumerLag      *prometheus.GaugeVec
+}
+
+func NewMetrics() *Metrics {
+	streamLabels := []string{"namespace", "stream"}
+	groupLabels := []string{"namespace", "stream", "group"}
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		publishedRecords: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "published_records_total",
+			Help:      "Number of records published to a stream.",
+		}, streamLabels),
+		publishedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "published_bytes_total",
+			Help:      "Size of the keys and values of the records published to a stream.",
+		}, streamLabels),
+		deliveredRecords: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "delivered_records_total",
+			Help:      "Number of records of a stream delivered to the subscribers of a consumer group, redeliveries included.",
+		}, groupLabels),
+		deliveredBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "delivered_bytes_total",
+			Help:      "Size of the keys and values of the records of a stream delivered to the subscribers of a consumer group.",
+		}, groupLabels),
+		consumerLag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "consumer_lag_records",
+			Help:      "Number of records of a partition of a stream a consumer group has yet to receive.",
+		}, append(groupLabels, "partition")),
+	}
+	m.Registry.MustRegister(m.publishedRecords, m.publishedBytes, m.deliveredRecords, m.deliveredBytes, m.consumerLag)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})
+}
+
+// Published counts a record published to a topic
+func (m *Metrics) Published(topic string, size int) {
+	if m == nil {
+		return
+	}
+	ns, stream := streamLabels(topic)
+	m.publishedRecords.WithLabelValues(ns, stream).Inc()
+	m.publishedBytes.WithLabelValues(ns, stream).Add(float64(size))
+}
+
+// Delivered counts a record of a topic delivered to a subscriber of a consumer group
+func (m *Metrics) Delivered(topic string, group string, size int) {
+	if m == nil {
+		return
+	}
+	ns, stream := streamLabels(topic)
+	m.deliveredRecords.WithLabelValues(ns, stream, group).Inc()
+	m.deliveredBytes.WithLabelValues(ns, stream, group).Add(float64(size))
+}
+
+// SetLag records how many records of a partition follow the last one a consumer group received
+func (m *Metrics) SetLag(topic string, group string, partition int32, lag int64) {
+	if m == nil {
+		return
+	}
+	ns, stream := streamLabels(topic)
+	m.consumerLag.WithLabelValues(ns, stream, group, strconv.Itoa(int(partition))).Set(float64(lag))
+}
+
+// DeleteLag forgets the lag of a partition no longer assigned to a consumer group through the gateway
+func (m *Metrics) DeleteLag(topic string, group string, partition int32) {
+	if m == nil {
+		return
+	}
+	ns, stream := streamLabels(topic)
+	m.consumerLag.DeleteLabelValues(ns, stream, group, strconv.Itoa(int(partition)))
+}
+
+// streamLabels returns the namespace, unescaped, and name of the stream a topic backs, other topics having no
+// namespace
+func streamLabels(topic string) (string, string) {
+	ns, stream, ok := validation.ParseTopicName(topic)
+	if !ok {
+		return "", topic
+	}
+	return ns, stream
+}
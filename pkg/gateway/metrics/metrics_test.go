@@ -0,0 +1,66 @@
+package metrics_test
+
+import (
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/metrics"
+)
+
+var _ = Describe("Metrics", func() {
+
+	var m *metrics.Metrics
+
+	BeforeEach(func() {
+		m = metrics.NewMetrics()
+	})
+
+	scrape := func() string {
+		recorder := httptest.NewRecorder()
+		m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+		return recorder.Body.String()
+	}
+
+	It("labels records by namespace and stream", func() {
+		m.Published("ns_my_stream", 3)
+		m.Published("ns_my_stream", 4)
+
+		body := scrape()
+		Expect(body).To(ContainSubstring(`riff_kafka_gateway_published_records_total{namespace="ns",stream="my_stream"} 2`))
+		Expect(body).To(ContainSubstring(`riff_kafka_gateway_published_bytes_total{namespace="ns",stream="my_stream"} 7`))
+	})
+
+	It("labels the records of escaped namespaces by their namespace", func() {
+		m.Published("a-5fb-_c", 1)
+
+		Expect(scrape()).To(ContainSubstring(`riff_kafka_gateway_published_records_total{namespace="a_b",stream="c"} 1`))
+	})
+
+	It("leaves the namespace of other topics empty", func() {
+		m.Delivered("orders", "my-function", 3)
+
+		Expect(scrape()).To(ContainSubstring(`riff_kafka_gateway_delivered_records_total{group="my-function",namespace="",stream="orders"} 1`))
+	})
+
+	It("forgets the lag of partitions no longer assigned", func() {
+		m.SetLag("ns_stream", "my-function", 0, 5)
+		m.SetLag("ns_stream", "my-function", 1, 2)
+		m.DeleteLag("ns_stream", "my-function", 0)
+
+		body := scrape()
+		Expect(body).NotTo(ContainSubstring(`partition="0"`))
+		Expect(body).To(ContainSubstring(`riff_kafka_gateway_consumer_lag_records{group="my-function",namespace="ns",partition="1",stream="stream"} 2`))
+	})
+
+	It("does nothing when disabled", func() {
+		var disabled *metrics.Metrics
+
+		Expect(func() {
+			disabled.Published("ns_stream", 1)
+			disabled.Delivered("ns_stream", "my-function", 1)
+			disabled.SetLag("ns_stream", "my-function", 0, 1)
+			disabled.DeleteLag("ns_stream", "my-function", 0)
+		}).NotTo(Panic())
+	})
+})
@@ -0,0 +1,162 @@
+// Package partition lets streams choose how their records are spread across partitions, for workloads whose
+// records are not keyed by the Kafka record key, e.g. by hashing a field of their JSON values
+package partition
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+// Factory creates a partitioner, given the argument following its name in the configuration of a stream, if any
+type Factory func(argument string) (sarama.Partitioner, error)
+
+// Registry names the partitioners streams may choose
+type Registry struct {
+	factories map[string]Factory
+}
+
+// NewRegistry returns a registry of the built-in partitioners:
+// * hash: by hash of the record key, records without key being spread randomly. The default.
+// * round-robin: in turn, whatever the key.
+// * random: randomly, whatever the key.
+// * json-field:<path>: by hash of the field of JSON values at a dot separated path, e.g. customer.id, records
+// without the field being partitioned by key.
+func NewRegistry() *Registry {
+	r := &Registry{factories: make(map[string]Factory)}
+	r.Register("hash", func(string) (sarama.Partitioner, error) {
+		return sarama.NewHashPartitioner(""), nil
+	})
+	r.Register("round-robin", func(string) (sarama.Partitioner, error) {
+		return sarama.NewRoundRobinPartitioner(""), nil
+	})
+	r.Register("random", func(string) (sarama.Partitioner, error) {
+		return sarama.NewRandomPartitioner(""), nil
+	})
+	r.Register("json-field", NewJSONFieldPartitioner)
+	return r
+}
+
+// Register names a partitioner, replacing any registered under the same name
+func (r *Registry) Register(name string, factory Factory) {
+	r.factories[name] = factory
+}
+
+// Names lists the registered partitioners, sorted
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates a partitioner from its configuration, a name optionally followed by an argument, e.g.
+// json-field:customer.id
+func (r *Registry) New(config string) (sarama.Partitioner, error) {
+	name, argument, _ := strings.Cut(config, ":")
+	factory, ok := r.factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown partitioner %q, should be one of %s", name, strings.Join(r.Names(), ", "))
+	}
+	partitioner, err := factory(argument)
+	if err != nil {
+		return nil, fmt.Errorf("invalid partitioner %q: %v", config, err)
+	}
+	return partitioner, nil
+}
+
+// Constructor returns the constructor producers call for the partitioner of each topic, from the configuration
+// of the topics choosing one, the other topics being partitioned by key hash. Partitioners are created upfront so
+// that configuration errors surface on startup.
+func (r *Registry) Constructor(streams map[string]string) (sarama.PartitionerConstructor, error) {
+	for topic, config := range streams {
+		if _, err := r.New(config); err != nil {
+			return nil, fmt.Errorf("error creating the partitioner of topic %s: %v", topic, err)
+		}
+	}
+	return func(topic string) sarama.Partitioner {
+		if config, ok := streams[topic]; ok {
+			// validated above
+			partitioner, _ := r.New(config)
+			return partitioner
+		}
+		return sarama.NewHashPartitioner(topic)
+	}, nil
+}
+
+type jsonFieldPartitioner struct {
+	path     []string
+	fallback sarama.Partitioner
+}
+
+// NewJSONFieldPartitioner partitions records by hash of the field of their JSON value at a dot separated path,
+// records whose value isn't JSON or lacks the field being partitioned by key
+func NewJSONFieldPartitioner(path string) (sarama.Partitioner, error) {
+	if path == "" {
+		return nil, fmt.Errorf("the path of the JSON field is required, e.g. json-field:customer.id")
+	}
+	fields := strings.Split(path, ".")
+	for _, field := range fields {
+		if field == "" {
+			return nil, fmt.Errorf("JSON field path %q has an empty field", path)
+		}
+	}
+	return &jsonFieldPartitioner{path: fields, fallback: sarama.NewHashPartitioner("")}, nil
+}
+
+func (p *jsonFieldPartitioner) Partition(message *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	field, ok := p.field(message)
+	if !ok {
+		return p.fallback.Partition(message, numPartitions)
+	}
+	hasher := fnv.New32a()
+	_, _ = hasher.Write(field)
+	return int32(hasher.Sum32() % uint32(numPartitions)), nil
+}
+
+// field returns the canonical JSON encoding of the field, so that 42 and "42" are told apart while formatting
+// doesn't matter
+func (p *jsonFieldPartitioner) field(message *sarama.ProducerMessage) ([]byte, bool) {
+	if message.Value == nil {
+		return nil, false
+	}
+	value, err := message.Value.Encode()
+	if err != nil {
+		return nil, false
+	}
+	field := json.RawMessage(value)
+	for _, name := range p.path {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(field, &object); err != nil {
+			return nil, false
+		}
+		next, ok := object[name]
+		if !ok || string(next) == "null" {
+			return nil, false
+		}
+		field = next
+	}
+	decoder := json.NewDecoder(bytes.NewReader(field))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, false
+	}
+	canonical, err := json.Marshal(decoded)
+	if err != nil {
+		return nil, false
+	}
+	return canonical, true
+}
+
+// RequiresConsistency is true, records with the same field going to the same partition
+func (p *jsonFieldPartitioner) RequiresConsistency() bool {
+	return true
+}
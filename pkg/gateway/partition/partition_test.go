@@ -0,0 +1,122 @@
+package partition_test
+
+import (
+	"github.com/Shopify/sarama"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/partition"
+)
+
+var _ = Describe("Partitioners", func() {
+
+	const partitions = 16
+
+	var registry *partition.Registry
+
+	BeforeEach(func() {
+		registry = partition.NewRegistry()
+	})
+
+	record := func(key string, value string) *sarama.ProducerMessage {
+		message := &sarama.ProducerMessage{Topic: "ns_orders", Value: sarama.StringEncoder(value)}
+		if key != "" {
+			message.Key = sarama.StringEncoder(key)
+		}
+		return message
+	}
+
+	partitionOf := func(partitioner sarama.Partitioner, message *sarama.ProducerMessage) int32 {
+		p, err := partitioner.Partition(message, partitions)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p).To(BeNumerically(">=", 0))
+		Expect(p).To(BeNumerically("<", partitions))
+		return p
+	}
+
+	Describe("json-field", func() {
+
+		var partitioner sarama.Partitioner
+
+		BeforeEach(func() {
+			var err error
+			partitioner, err = registry.New("json-field:customer.id")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("partitions records by the field, whatever their key", func() {
+			first := partitionOf(partitioner, record("a", `{"customer": {"id": "c-42"}, "total": 10}`))
+
+			Expect(partitionOf(partitioner, record("b", `{"total": 20, "customer": {"id":"c-42"}}`))).To(Equal(first))
+			Expect(partitioner.RequiresConsistency()).To(BeTrue())
+		})
+
+		It("spreads records with different fields", func() {
+			seen := make(map[int32]bool)
+			for _, id := range []string{"1", "2", "3", "4", "5", "6", "7", "8"} {
+				seen[partitionOf(partitioner, record("", `{"customer": {"id": `+id+`}}`))] = true
+			}
+
+			Expect(len(seen)).To(BeNumerically(">", 1))
+		})
+
+		DescribeTable("falling back to the key",
+			func(value string) {
+				hash := sarama.NewHashPartitioner("ns_orders")
+
+				Expect(partitionOf(partitioner, record("key", value))).To(Equal(partitionOf(hash, record("key", value))))
+			},
+			Entry("for values that aren't JSON", "not json"),
+			Entry("for missing fields", `{"customer": {}}`),
+			Entry("for null fields", `{"customer": {"id": null}}`),
+			Entry("for fields of non objects", `{"customer": "c-42"}`),
+		)
+	})
+
+	It("lets custom partitioners be registered", func() {
+		registry.Register("first", func(string) (sarama.Partitioner, error) {
+			return sarama.NewManualPartitioner(""), nil
+		})
+
+		partitioner, err := registry.New("first")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(partitionOf(partitioner, record("key", "value"))).To(Equal(int32(0)))
+	})
+
+	DescribeTable("rejecting invalid configurations",
+		func(config string, message string) {
+			_, err := registry.New(config)
+
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("of unknown partitioners", "modulo", "should be one of hash, json-field, random, round-robin"),
+		Entry("of json-field without path", "json-field", "the path of the JSON field is required"),
+		Entry("of json-field with empty fields", "json-field:customer..id", "has an empty field"),
+	)
+
+	Describe("the constructor of producers", func() {
+
+		It("creates the partitioner of each topic", func() {
+			constructor, err := registry.Constructor(map[string]string{"ns_orders": "round-robin"})
+			Expect(err).NotTo(HaveOccurred())
+
+			partitioner := constructor("ns_orders")
+			Expect(partitionOf(partitioner, record("key", "value"))).To(Equal(int32(0)))
+			Expect(partitionOf(partitioner, record("key", "value"))).To(Equal(int32(1)))
+		})
+
+		It("partitions other topics by key", func() {
+			constructor, err := registry.Constructor(map[string]string{"ns_orders": "round-robin"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(constructor("ns_other").RequiresConsistency()).To(BeTrue())
+		})
+
+		It("reports invalid configurations upfront", func() {
+			_, err := registry.Constructor(map[string]string{"ns_orders": "modulo"})
+
+			Expect(err).To(MatchError(ContainSubstring("error creating the partitioner of topic ns_orders")))
+		})
+	})
+})
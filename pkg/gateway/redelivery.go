@@ -0,0 +1,118 @@
+package gateway
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// inFlight tracks the records of an assignment delivered but not acknowledged yet, so that they can be
+// delivered again when they are not acknowledged in time
+type inFlight struct {
+	m       sync.Mutex
+	records map[int64]*inFlightRecord
+	// next is the offset following the last record delivered
+	next int64
+	// nacked is signalled when a record should be delivered again right away
+	nacked chan struct{}
+}
+
+type inFlightRecord struct {
+	message  *sarama.ConsumerMessage
+	deadline time.Time
+}
+
+func newInFlight() *inFlight {
+	return &inFlight{
+		records: make(map[int64]*inFlightRecord),
+		next:    -1,
+		nacked:  make(chan struct{}, 1),
+	}
+}
+
+func (f *inFlight) delivered(message *sarama.ConsumerMessage, deadline time.Time) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.records[message.Offset] = &inFlightRecord{message: message, deadline: deadline}
+	if message.Offset >= f.next {
+		f.next = message.Offset + 1
+	}
+}
+
+// ack forgets about a record, returning the offset that can be committed: the one of the first record
+// still in flight, or the one following the last record delivered when none are
+func (f *inFlight) ack(offset int64) (int64, bool) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if _, ok := f.records[offset]; !ok {
+		return 0, false
+	}
+	delete(f.records, offset)
+	commit := f.next
+	for o := range f.records {
+		if o < commit {
+			commit = o
+		}
+	}
+	return commit, true
+}
+
+// skip records that a record was filtered out rather than delivered, returning the offset that can be committed past
+// it, or false while records are in flight, the acknowledgment of the last of them committing past it then
+func (f *inFlight) skip(offset int64) (int64, bool) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if offset >= f.next {
+		f.next = offset + 1
+	}
+	return f.next, len(f.records) == 0
+}
+
+func (f *inFlight) empty() bool {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return len(f.records) == 0
+}
+
+// nack makes a record due for delivery
+func (f *inFlight) nack(offset int64) bool {
+	f.m.Lock()
+	defer f.m.Unlock()
+	record, ok := f.records[offset]
+	if !ok {
+		return false
+	}
+	record.deadline = time.Time{}
+	select {
+	case f.nacked <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// due returns at most limit records not acknowledged before their deadline, all of them when negative, in
+// offset order, pushing their deadline to the next time they are due
+func (f *inFlight) due(now time.Time, next time.Time, limit int) []*sarama.ConsumerMessage {
+	f.m.Lock()
+	defer f.m.Unlock()
+	var records []*inFlightRecord
+	for _, record := range f.records {
+		if !record.deadline.After(now) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].message.Offset < records[j].message.Offset
+	})
+	if limit >= 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	messages := make([]*sarama.ConsumerMessage, len(records))
+	for i, record := range records {
+		record.deadline = next
+		messages[i] = record.message
+	}
+	return messages
+}
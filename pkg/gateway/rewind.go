@@ -0,0 +1,175 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// rewindRequest tells where to rewind a consumer group to, exactly one of its fields being set
+type rewindRequest struct {
+	// To is "earliest" to reprocess all the records retained
+	To string `json:"to"`
+	// Timestamp rewinds each partition to its first record published at or after it
+	Timestamp *time.Time `json:"timestamp"`
+	// Offsets are the offsets of the next records to consume, keyed by partition, other partitions being left alone
+	Offsets map[int32]int64 `json:"offsets"`
+}
+
+type rewindResult struct {
+	Group   string          `json:"group"`
+	Offsets map[int32]int64 `json:"offsets"`
+}
+
+// rewindHTTP commits the offsets a consumer group without members should consume a stream from on
+// POST /<namespace>/<stream-name>/groups/<group>/rewind
+func (s *Server) rewindHTTP(writer http.ResponseWriter, request *http.Request, topic string, groupID string) {
+	rewind := rewindRequest{}
+	if err := json.NewDecoder(request.Body).Decode(&rewind); err != nil {
+		writeStatus(writer, status.Errorf(codes.InvalidArgument, "invalid rewind request: %v", err))
+		return
+	}
+	description, err := s.streamGroup(topic, groupID)
+	if err != nil {
+		writeStatus(writer, kafkaStatus(err))
+		return
+	}
+	if description == nil {
+		writeStatus(writer, status.Errorf(codes.NotFound, "group %q is not a consumer group of topic %q", groupID, topic))
+		return
+	}
+	if description.State != "Empty" {
+		writer.WriteHeader(http.StatusConflict)
+		_, _ = fmt.Fprintf(writer, "group %q is %s, stop its members before rewinding it\n", groupID, description.State)
+		return
+	}
+	offsets, err := s.rewindOffsets(topic, rewind)
+	if err != nil {
+		writeStatus(writer, err)
+		return
+	}
+	if err := s.commitOffsets(groupID, topic, offsets); err != nil {
+		if err == sarama.ErrUnknownMemberId || err == sarama.ErrIllegalGeneration || err == sarama.ErrRebalanceInProgress {
+			writer.WriteHeader(http.StatusConflict)
+			_, _ = fmt.Fprintf(writer, "group %q got members while being rewound, stop them and try again\n", groupID)
+			return
+		}
+		writeStatus(writer, kafkaStatus(err))
+		return
+	}
+	s.Logger.Info("Rewound consumer group", "topic", topic, "group", groupID, "offsets", offsets)
+	writeJSON(writer, rewindResult{Group: groupID, Offsets: offsets})
+}
+
+// rewindOffsets resolves the offsets of the partitions of a topic a rewind request targets
+func (s *Server) rewindOffsets(topic string, rewind rewindRequest) (map[int32]int64, error) {
+	set := 0
+	if rewind.To != "" {
+		if rewind.To != "earliest" {
+			return nil, status.Errorf(codes.InvalidArgument, "groups can only be rewound to %q, not %q", "earliest", rewind.To)
+		}
+		set++
+	}
+	if rewind.Timestamp != nil {
+		set++
+	}
+	if rewind.Offsets != nil {
+		set++
+	}
+	if set != 1 {
+		return nil, status.Error(codes.InvalidArgument, "exactly one of to, timestamp or offsets should be set")
+	}
+
+	partitions, err := s.Client.Partitions(topic)
+	if err != nil {
+		return nil, kafkaStatus(err)
+	}
+	offsets := make(map[int32]int64)
+	if rewind.Offsets != nil {
+		for partition, offset := range rewind.Offsets {
+			if !containsPartition(partitions, partition) {
+				return nil, status.Errorf(codes.InvalidArgument, "topic %q has no partition %d", topic, partition)
+			}
+			oldest, newest, err := s.offsetRange(topic, partition)
+			if err != nil {
+				return nil, err
+			}
+			if offset < oldest || offset > newest {
+				return nil, status.Errorf(codes.InvalidArgument, "offset %d of partition %d is out of the retained range [%d, %d]", offset, partition, oldest, newest)
+			}
+			offsets[partition] = offset
+		}
+		return offsets, nil
+	}
+	for _, partition := range partitions {
+		var offset int64
+		if rewind.Timestamp != nil {
+			offset, err = s.Client.GetOffset(topic, partition, rewind.Timestamp.UnixNano()/int64(time.Millisecond))
+			if err == nil && offset < 0 {
+				// no record was published since, consume the next ones
+				offset, err = s.Client.GetOffset(topic, partition, sarama.OffsetNewest)
+			}
+		} else {
+			offset, err = s.Client.GetOffset(topic, partition, sarama.OffsetOldest)
+		}
+		if err != nil {
+			return nil, kafkaStatus(err)
+		}
+		offsets[partition] = offset
+	}
+	return offsets, nil
+}
+
+func (s *Server) offsetRange(topic string, partition int32) (int64, int64, error) {
+	oldest, err := s.Client.GetOffset(topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return 0, 0, kafkaStatus(err)
+	}
+	newest, err := s.Client.GetOffset(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, 0, kafkaStatus(err)
+	}
+	return oldest, newest, nil
+}
+
+// commitOffsets commits offsets on behalf of a group without members, which the coordinator refuses for groups
+// having some
+func (s *Server) commitOffsets(groupID string, topic string, offsets map[int32]int64) error {
+	coordinator, err := s.Client.Coordinator(groupID)
+	if err != nil {
+		return err
+	}
+	request := &sarama.OffsetCommitRequest{
+		Version:                 2,
+		ConsumerGroup:           groupID,
+		ConsumerGroupGeneration: -1,
+		RetentionTime:           -1,
+	}
+	for partition, offset := range offsets {
+		request.AddBlock(topic, partition, offset, -1, 0, "")
+	}
+	response, err := coordinator.CommitOffset(request)
+	if err != nil {
+		return err
+	}
+	for partition := range offsets {
+		if err := response.Errors[topic][partition]; err != sarama.ErrNoError {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsPartition(partitions []int32, partition int32) bool {
+	for _, p := range partitions {
+		if p == partition {
+			return true
+		}
+	}
+	return false
+}
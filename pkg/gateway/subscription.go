@@ -0,0 +1,501 @@
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/content"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/filter"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/liiklus"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/transform"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// assignment is a partition claimed by a subscription, whose records are delivered by Receive
+type assignment struct {
+	id        string
+	groupID   string
+	session   sarama.ConsumerGroupSession
+	claim     sarama.ConsumerGroupClaim
+	receiving bool
+	// commitStrategy is never DEFAULT
+	commitStrategy liiklus.SubscribeRequest_CommitStrategy
+	// inFlight is nil when records are not redelivered, acknowledgments then being cumulative
+	inFlight *inFlight
+	// group is paused when the subscriber runs out of credits
+	group sarama.ConsumerGroup
+	// credits is nil unless records are being received with flow control
+	credits *credits
+	// filter is nil when all the records are delivered
+	filter *filter.Filter
+	// pending tracks acknowledgments for the partition to be drained when revoked
+	pending *pending
+	// marking makes the offsets marked cumulatively past skipped records consistent with the acknowledgments
+	marking sync.Mutex
+}
+
+// Subscribe joins the consumer group and streams the partitions assigned to the subscriber, until the
+// subscriber disconnects or the gateway shuts down
+func (s *Server) Subscribe(request *liiklus.SubscribeRequest, stream liiklus.LiiklusService_SubscribeServer) error {
+	if request.Topic == "" || request.Group == "" {
+		return status.Error(codes.InvalidArgument, "topic and group are required")
+	}
+	options := GroupOptions{InitialOffset: sarama.OffsetOldest}
+	if request.AutoOffsetReset == liiklus.SubscribeRequest_LATEST {
+		options.InitialOffset = sarama.OffsetNewest
+	}
+	commitStrategy := s.commitStrategy(request.CommitStrategy)
+	if commitStrategy == liiklus.SubscribeRequest_AUTO {
+		options.AutoCommitInterval = s.autoCommitInterval(request.AutoCommitIntervalMs)
+	}
+	headerFilter, err := filter.Parse(request.HeaderFilters)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	stopping, ok := s.startSubscription()
+	if !ok {
+		return status.Error(codes.Unavailable, shuttingDown)
+	}
+	defer s.subscriptions.Done()
+	groupID := groupID(request.Group, request.GroupVersion)
+	group, err := s.NewConsumerGroup(groupID, options)
+	if err != nil {
+		s.Logger.Error("Error joining consumer group", "topic", request.Topic, "group", groupID, "error", err)
+		return kafkaStatus(err)
+	}
+	defer func() {
+		if err := group.Close(); err != nil {
+			s.Logger.Error("Error leaving consumer group", "group", groupID, "error", err)
+		}
+	}()
+
+	handler := &subscription{server: s, groupID: groupID, commitStrategy: commitStrategy, stream: stream, group: group, filter: headerFilter}
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopping:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	for ctx.Err() == nil {
+		if err := group.Consume(ctx, []string{request.Topic}, handler); err != nil {
+			s.Logger.Error("Error consuming", "topic", request.Topic, "group", groupID, "error", err)
+			return kafkaStatus(err)
+		}
+	}
+	select {
+	case <-stopping:
+		return status.Error(codes.Unavailable, shuttingDown)
+	default:
+		return nil
+	}
+}
+
+// Receive streams the records of a partition assigned by Subscribe, until the subscriber disconnects or
+// the partition is assigned to another member of the group
+func (s *Server) Receive(request *liiklus.ReceiveRequest, stream liiklus.LiiklusService_ReceiveServer) error {
+	if request.Assignment == nil {
+		return status.Error(codes.InvalidArgument, "assignment is required")
+	}
+	var flow *credits
+	if request.Credits > 0 {
+		flow = newCredits(request.Credits)
+	}
+	a, err := s.startReceiving(request.Assignment.SessionId, flow)
+	if err != nil {
+		return err
+	}
+	defer s.stopReceiving(a)
+
+	// toRecord returns nil for records filtered out by the subscription or by a transformation
+	toRecord := func(message *sarama.ConsumerMessage, replay bool) (*liiklus.ReceiveReply_Record, error) {
+		if !a.filter.Matches(message.Headers, message.Value) {
+			return nil, nil
+		}
+		value, err := s.Avro.Deserialize(stream.Context(), message.Topic, message.Value)
+		if err != nil {
+			s.Logger.Error("Error deserializing record", "topic", message.Topic, "partition", message.Partition, "offset", message.Offset, "error", err)
+			return nil, status.Errorf(codes.Internal, "error deserializing record %d: %v", message.Offset, err)
+		}
+		transformed, err := s.Transforms.Delivered(stream.Context(), &transform.Record{Topic: message.Topic, Key: message.Key, Value: value})
+		if err != nil {
+			s.Logger.Error("Error transforming record", "topic", message.Topic, "partition", message.Partition, "offset", message.Offset, "error", err)
+			return nil, status.Errorf(codes.Internal, "error transforming record %d: %v", message.Offset, err)
+		}
+		if transformed == nil {
+			return nil, nil
+		}
+		contentType, value := s.negotiate(message, transformed.Value, request.Accept)
+		var headers map[string][]byte
+		for _, header := range message.Headers {
+			if key := string(header.Key); key != content.Header {
+				if headers == nil {
+					headers = make(map[string][]byte)
+				}
+				headers[key] = header.Value
+			}
+		}
+		return &liiklus.ReceiveReply_Record{
+			Offset:      uint64(message.Offset),
+			Key:         transformed.Key,
+			Value:       value,
+			Timestamp:   timestamppb.New(message.Timestamp),
+			Replay:      replay || request.LastKnownOffset > 0 && uint64(message.Offset) <= request.LastKnownOffset,
+			ContentType: contentType,
+			Headers:     headers,
+		}, nil
+	}
+	send := func(record *liiklus.ReceiveReply_Record) error {
+		if flow != nil {
+			flow.take()
+		}
+		s.Metrics.Delivered(a.claim.Topic(), a.groupID, len(record.Key)+len(record.Value))
+		return stream.Send(&liiklus.ReceiveReply{Reply: &liiklus.ReceiveReply_Record_{Record: record}})
+	}
+	var redeliveries <-chan time.Time
+	var nacked <-chan struct{}
+	if a.inFlight != nil {
+		ticker := time.NewTicker(redeliveryCheckInterval(s.RedeliveryTimeout))
+		defer ticker.Stop()
+		redeliveries = ticker.C
+		nacked = a.inFlight.nacked
+	}
+	redeliver := func() error {
+		limit := -1
+		if flow != nil {
+			if limit = flow.remaining(); limit <= 0 {
+				return nil
+			}
+		}
+		now := time.Now()
+		for _, message := range a.inFlight.due(now, now.Add(s.RedeliveryTimeout), limit) {
+			s.Logger.Debug("Redelivering record", "topic", message.Topic, "partition", message.Partition, "offset", message.Offset)
+			record, err := toRecord(message, true)
+			if err != nil {
+				return err
+			}
+			if record == nil {
+				_ = a.acknowledge(message.Offset)
+				continue
+			}
+			if err := send(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// with flow control, the partition is paused while the subscriber has no credits left, rather than
+	// buffering the records Kafka keeps delivering
+	var granted <-chan struct{}
+	if flow != nil {
+		granted = flow.granted
+	}
+	paused := false
+	defer func() {
+		if paused {
+			a.resume()
+		}
+	}()
+	for {
+		messages := a.claim.Messages()
+		if flow != nil && flow.remaining() <= 0 {
+			messages = nil
+			if !paused {
+				a.pause()
+				paused = true
+			}
+		} else if paused {
+			a.resume()
+			paused = false
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-a.session.Context().Done():
+			return nil
+		case <-granted:
+		case <-redeliveries:
+			if err := redeliver(); err != nil {
+				return err
+			}
+		case <-nacked:
+			if err := redeliver(); err != nil {
+				return err
+			}
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			s.Metrics.SetLag(message.Topic, a.groupID, message.Partition, a.claim.HighWaterMarkOffset()-message.Offset-1)
+			record, err := toRecord(message, false)
+			if err != nil {
+				return err
+			}
+			if record == nil {
+				a.skip(message.Offset)
+				continue
+			}
+			if a.inFlight != nil {
+				a.inFlight.delivered(message, time.Now().Add(s.RedeliveryTimeout))
+			}
+			a.pending.deliver(message.Offset)
+			if err := send(record); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// negotiate converts the value of a record to the content type the subscriber prefers, when its stream has a
+// message type. Values that can't be converted are delivered as recorded.
+func (s *Server) negotiate(message *sarama.ConsumerMessage, value []byte, accept string) (string, []byte) {
+	var contentType string
+	for _, header := range message.Headers {
+		if string(header.Key) == content.Header {
+			contentType = string(header.Value)
+		}
+	}
+	accepted, ok := content.Negotiate(accept, s.Messages.Offers(message.Topic, contentType)...)
+	if !ok || accepted == contentType {
+		return contentType, value
+	}
+	converted, err := s.Messages.Convert(message.Topic, value, contentType, accepted)
+	if err != nil {
+		s.Logger.Warn("Error converting record", "topic", message.Topic, "partition", message.Partition, "offset", message.Offset, "contentType", accepted, "error", err)
+		return contentType, value
+	}
+	return accepted, converted
+}
+
+// redeliveryCheckInterval is how often records are checked for expired deadlines, a fraction of the
+// timeout so that they are not redelivered much later than it
+func redeliveryCheckInterval(timeout time.Duration) time.Duration {
+	if interval := timeout / 10; interval > 10*time.Millisecond {
+		return interval
+	}
+	return 10 * time.Millisecond
+}
+
+// Ack records that a record has been processed. Without redelivery, acknowledgments are cumulative: all
+// the records of the partition up to the acknowledged one are considered processed. With redelivery, each
+// record is acknowledged on its own and offsets are committed up to the first record still in flight.
+func (s *Server) Ack(_ context.Context, request *liiklus.AckRequest) (*emptypb.Empty, error) {
+	var sessionID string
+	if request.Assignment != nil {
+		sessionID = request.Assignment.SessionId
+	}
+	a, err := s.findAssignment(sessionID, request.Topic, request.Group, request.GroupVersion, request.Partition)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.acknowledge(int64(request.Offset)); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (a *assignment) acknowledge(recordOffset int64) error {
+	var offset int64
+	if a.inFlight != nil {
+		var ok bool
+		if offset, ok = a.inFlight.ack(recordOffset); !ok {
+			return status.Errorf(codes.NotFound, "record %d of partition %d is not in flight", recordOffset, a.claim.Partition())
+		}
+	} else {
+		a.marking.Lock()
+		defer a.marking.Unlock()
+		offset = a.pending.committable(recordOffset)
+	}
+	a.commit(offset)
+	// acknowledged once committed, a drained partition being handed over with its offsets
+	a.pending.acknowledge(recordOffset)
+	return nil
+}
+
+// skip commits past a record filtered out by the subscription or by a transformation, once the records delivered
+// before it are acknowledged, so that records never delivered don't count as lag nor are consumed again by the next
+// member of the group
+func (a *assignment) skip(recordOffset int64) {
+	var offset int64
+	var ok bool
+	if a.inFlight != nil {
+		offset, ok = a.inFlight.skip(recordOffset)
+	} else {
+		a.marking.Lock()
+		defer a.marking.Unlock()
+		offset, ok = a.pending.skip(recordOffset)
+	}
+	if ok {
+		a.commit(offset)
+	}
+}
+
+// commit marks the offset of the next record to consume, committing it right away with the ON_ACK strategy
+func (a *assignment) commit(offset int64) {
+	a.session.MarkOffset(a.claim.Topic(), a.claim.Partition(), offset, "")
+	if a.commitStrategy == liiklus.SubscribeRequest_ON_ACK {
+		a.session.Commit()
+	}
+}
+
+// Nack asks for a record that could not be processed to be delivered again right away, rather than after
+// the redelivery timeout
+func (s *Server) Nack(_ context.Context, request *liiklus.NackRequest) (*emptypb.Empty, error) {
+	if s.RedeliveryTimeout <= 0 {
+		return nil, status.Error(codes.FailedPrecondition, "redelivery is disabled")
+	}
+	a, err := s.findAssignment("", request.Topic, request.Group, request.GroupVersion, request.Partition)
+	if err != nil {
+		return nil, err
+	}
+	if !a.inFlight.nack(int64(request.Offset)) {
+		return nil, status.Errorf(codes.NotFound, "record %d of partition %d is not in flight", request.Offset, request.Partition)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) register(h *subscription, session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) (*assignment, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	a := &assignment{
+		id:             hex.EncodeToString(id),
+		groupID:        h.groupID,
+		session:        session,
+		claim:          claim,
+		commitStrategy: h.commitStrategy,
+		group:          h.group,
+		filter:         h.filter,
+		pending:        newPending(),
+	}
+	if s.RedeliveryTimeout > 0 {
+		a.inFlight = newInFlight()
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.assignments == nil {
+		s.assignments = make(map[string]*assignment)
+	}
+	s.assignments[a.id] = a
+	return a, nil
+}
+
+func (s *Server) unregister(a *assignment) {
+	s.Metrics.DeleteLag(a.claim.Topic(), a.groupID, a.claim.Partition())
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.assignments, a.id)
+}
+
+func (s *Server) startReceiving(sessionID string, flow *credits) (*assignment, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	a, ok := s.assignments[sessionID]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown assignment %q, the partition may have been reassigned", sessionID)
+	}
+	if a.receiving {
+		return nil, status.Errorf(codes.AlreadyExists, "records of assignment %q are already being received", sessionID)
+	}
+	a.receiving = true
+	a.credits = flow
+	return a, nil
+}
+
+func (s *Server) stopReceiving(a *assignment) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	a.receiving = false
+	a.credits = nil
+}
+
+// findAssignment looks up an assignment by session id when set, by group and partition otherwise
+func (s *Server) findAssignment(sessionID string, topic string, group string, groupVersion uint32, partition uint32) (*assignment, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if sessionID != "" {
+		if a, ok := s.assignments[sessionID]; ok {
+			return a, nil
+		}
+		return nil, status.Errorf(codes.FailedPrecondition, "unknown assignment %q, the partition may have been reassigned", sessionID)
+	}
+	groupID := groupID(group, groupVersion)
+	for _, a := range s.assignments {
+		if a.groupID == groupID && a.claim.Topic() == topic && a.claim.Partition() == int32(partition) {
+			return a, nil
+		}
+	}
+	return nil, status.Errorf(codes.FailedPrecondition, "partition %d of topic %q is not assigned to group %q", partition, topic, groupID)
+}
+
+// findGroupAssignment looks up any of the assignments of a group on a topic
+func (s *Server) findGroupAssignment(topic string, group string, groupVersion uint32) (*assignment, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	groupID := groupID(group, groupVersion)
+	for _, a := range s.assignments {
+		if a.groupID == groupID && a.claim.Topic() == topic {
+			return a, nil
+		}
+	}
+	return nil, status.Errorf(codes.FailedPrecondition, "no partition of topic %q is assigned to group %q", topic, groupID)
+}
+
+// subscription hands the partitions claimed by a consumer group member to its subscriber
+type subscription struct {
+	server         *Server
+	groupID        string
+	commitStrategy liiklus.SubscribeRequest_CommitStrategy
+	stream         liiklus.LiiklusService_SubscribeServer
+	group          sarama.ConsumerGroup
+	filter         *filter.Filter
+
+	m sync.Mutex
+}
+
+func (h *subscription) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h *subscription) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim lasts as long as the partition is assigned, its records being consumed by Receive. Once
+// revoked, the partition is drained and its offsets committed before being handed over, so that the next
+// member doesn't receive again the records acknowledged in the meantime.
+func (h *subscription) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	a, err := h.server.register(h, session, claim)
+	if err != nil {
+		return err
+	}
+	defer h.server.unregister(a)
+
+	h.m.Lock()
+	err = h.stream.Send(&liiklus.SubscribeReply{Reply: &liiklus.SubscribeReply_Assignment{Assignment: &liiklus.Assignment{
+		SessionId: a.id,
+		Partition: uint32(claim.Partition()),
+	}}})
+	h.m.Unlock()
+	if err != nil {
+		return err
+	}
+	<-session.Context().Done()
+	h.server.drain(h.stream.Context(), a)
+	if a.commitStrategy != liiklus.SubscribeRequest_MANUAL {
+		session.Commit()
+	}
+	return nil
+}
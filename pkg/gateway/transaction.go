@@ -0,0 +1,144 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/liiklus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// transactionalProducer serializes the transactions of a transactional id
+type transactionalProducer struct {
+	m        sync.Mutex
+	producer sarama.SyncProducer
+}
+
+// Transact publishes records and acknowledges the consumed record they were produced from in a single Kafka
+// transaction, so that a processor reading from a stream and writing to others processes each record exactly
+// once. The transactional id is derived from the group and the consumed partition, fencing off a previous
+// member of the group the partition was assigned to. Records are compressed with the codec of Compression rather
+// than that of their stream, a transaction being produced by a single producer, whose codec applies to all its
+// records.
+func (s *Server) Transact(ctx context.Context, request *liiklus.TransactRequest) (*liiklus.TransactReply, error) {
+	if s.NewTransactionalProducer == nil {
+		return nil, status.Error(codes.FailedPrecondition, "transactions are disabled")
+	}
+	ack := request.Ack
+	if ack == nil || ack.Topic == "" || ack.Group == "" {
+		return nil, status.Error(codes.InvalidArgument, "the acknowledged record is required")
+	}
+	transformed := make([]*liiklus.PublishRequest, len(request.Records))
+	for i, record := range request.Records {
+		var err error
+		if transformed[i], err = s.prepare(ctx, record); err != nil {
+			return nil, err
+		}
+	}
+	groupID := groupID(ack.Group, ack.GroupVersion)
+	transactionalID := fmt.Sprintf("%s.%s.%d", groupID, ack.Topic, ack.Partition)
+
+	tp, err := s.transactionalProducer(transactionalID)
+	if err != nil {
+		s.Logger.Error("Error creating transactional producer", "transactionalId", transactionalID, "error", err)
+		return nil, kafkaStatus(err)
+	}
+	defer tp.m.Unlock()
+
+	reply, err := s.transact(tp.producer, request, transformed, groupID)
+	if err != nil {
+		s.Logger.Error("Error publishing transactionally", "transactionalId", transactionalID, "error", err)
+		if abortErr := tp.producer.AbortTxn(); abortErr != nil || tp.producer.TxnStatus()&sarama.ProducerTxnFlagFatalError != 0 {
+			// the producer can't be used anymore, e.g. because it has been fenced off
+			_ = tp.producer.Close()
+			tp.producer = nil
+		}
+		return nil, kafkaStatus(err)
+	}
+
+	for _, record := range transformed {
+		if record != nil {
+			s.Metrics.Published(record.Topic, len(record.Key)+len(record.Value))
+		}
+	}
+	// the consumed offset is committed, keep the subscription consistent with it
+	if a, err := s.findAssignment("", ack.Topic, ack.Group, ack.GroupVersion, ack.Partition); err == nil {
+		_ = a.acknowledge(int64(ack.Offset))
+	}
+	return reply, nil
+}
+
+// transact publishes the transformed records, those filtered out being nil
+func (s *Server) transact(producer sarama.SyncProducer, request *liiklus.TransactRequest, transformed []*liiklus.PublishRequest, groupID string) (*liiklus.TransactReply, error) {
+	if err := producer.BeginTxn(); err != nil {
+		return nil, err
+	}
+	reply := &liiklus.TransactReply{}
+	for i, record := range transformed {
+		if record == nil {
+			reply.Records = append(reply.Records, &liiklus.PublishReply{Topic: request.Records[i].Topic})
+			continue
+		}
+		partition, offset, err := producer.SendMessage(producerMessage(record))
+		if err != nil {
+			return nil, err
+		}
+		reply.Records = append(reply.Records, &liiklus.PublishReply{
+			Topic:     record.Topic,
+			Partition: uint32(partition),
+			Offset:    uint64(offset),
+		})
+	}
+	offsets := map[string][]*sarama.PartitionOffsetMetadata{
+		request.Ack.Topic: {{Partition: int32(request.Ack.Partition), Offset: int64(request.Ack.Offset) + 1}},
+	}
+	if err := producer.AddOffsetsToTxn(offsets, groupID); err != nil {
+		return nil, err
+	}
+	if err := producer.CommitTxn(); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// transactionalProducer returns the locked producer of a transactional id, creating it on first use
+func (s *Server) transactionalProducer(transactionalID string) (*transactionalProducer, error) {
+	s.m.Lock()
+	if s.transactionalProducers == nil {
+		s.transactionalProducers = make(map[string]*transactionalProducer)
+	}
+	tp, ok := s.transactionalProducers[transactionalID]
+	if !ok {
+		tp = &transactionalProducer{}
+		s.transactionalProducers[transactionalID] = tp
+	}
+	s.m.Unlock()
+
+	tp.m.Lock()
+	if tp.producer == nil {
+		producer, err := s.NewTransactionalProducer(transactionalID)
+		if err != nil {
+			tp.m.Unlock()
+			return nil, err
+		}
+		tp.producer = producer
+	}
+	return tp, nil
+}
+
+func (s *Server) closeTransactionalProducers() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for transactionalID, tp := range s.transactionalProducers {
+		tp.m.Lock()
+		if tp.producer != nil {
+			if err := tp.producer.Close(); err != nil {
+				s.Logger.Error("Error closing transactional producer", "transactionalId", transactionalID, "error", err)
+			}
+		}
+		tp.m.Unlock()
+	}
+}
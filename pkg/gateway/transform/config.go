@@ -0,0 +1,75 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/tetratelabs/wazero"
+)
+
+// Config names WebAssembly modules and tells which transform the records of each stream
+type Config struct {
+	// Modules maps names to the paths of WebAssembly modules, relative to the config file
+	Modules map[string]string `json:"modules"`
+	// Streams maps topics to their transformations
+	Streams map[string]StreamConfig `json:"streams"`
+}
+
+// StreamConfig lists the modules transforming the records of a stream, in order
+type StreamConfig struct {
+	Publish []string `json:"publish,omitempty"`
+	Deliver []string `json:"deliver,omitempty"`
+}
+
+// LoadHooks reads a JSON config file and instantiates the modules it names
+func LoadHooks(ctx context.Context, path string) (*Hooks, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := Config{}
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("invalid transformation config %q: %v", path, err)
+	}
+
+	hooks := NewHooks()
+	runtime := wazero.NewRuntime(ctx)
+	hooks.closers = append(hooks.closers, runtime.Close)
+	transformers := make(map[string]Transformer)
+	for name, modulePath := range config.Modules {
+		if !filepath.IsAbs(modulePath) {
+			modulePath = filepath.Join(filepath.Dir(path), modulePath)
+		}
+		code, err := ioutil.ReadFile(modulePath)
+		if err != nil {
+			_ = hooks.Close(ctx)
+			return nil, err
+		}
+		if transformers[name], err = NewWASMTransformer(ctx, runtime, name, code); err != nil {
+			_ = hooks.Close(ctx)
+			return nil, err
+		}
+	}
+	for topic, stream := range config.Streams {
+		for _, name := range stream.Publish {
+			transformer, ok := transformers[name]
+			if !ok {
+				_ = hooks.Close(ctx)
+				return nil, fmt.Errorf("stream %q refers to unknown module %q", topic, name)
+			}
+			hooks.OnPublish(topic, name, transformer)
+		}
+		for _, name := range stream.Deliver {
+			transformer, ok := transformers[name]
+			if !ok {
+				_ = hooks.Close(ctx)
+				return nil, fmt.Errorf("stream %q refers to unknown module %q", topic, name)
+			}
+			hooks.OnDeliver(topic, name, transformer)
+		}
+	}
+	return hooks, nil
+}
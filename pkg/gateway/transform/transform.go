@@ -0,0 +1,99 @@
+// Package transform lets operators mutate, enrich or filter the records of a stream as they are published
+// or before they are delivered, e.g. to inject tenant ids or strip personal data
+package transform
+
+import (
+	"context"
+	"fmt"
+)
+
+// Record is what transformers see of a record
+type Record struct {
+	Topic string `json:"topic"`
+	Key   []byte `json:"key,omitempty"`
+	Value []byte `json:"value"`
+}
+
+// Transformer returns the transformed record, or nil to filter it out
+type Transformer interface {
+	Transform(ctx context.Context, record *Record) (*Record, error)
+}
+
+// TransformerFunc adapts a function to the Transformer interface
+type TransformerFunc func(ctx context.Context, record *Record) (*Record, error)
+
+func (f TransformerFunc) Transform(ctx context.Context, record *Record) (*Record, error) {
+	return f(ctx, record)
+}
+
+// Chain applies transformers in order, stopping as soon as one filters the record out
+type Chain []namedTransformer
+
+type namedTransformer struct {
+	name        string
+	transformer Transformer
+}
+
+func (c Chain) Transform(ctx context.Context, record *Record) (*Record, error) {
+	for _, t := range c {
+		var err error
+		if record, err = t.transformer.Transform(ctx, record); err != nil {
+			return nil, fmt.Errorf("transformer %q failed: %v", t.name, err)
+		}
+		if record == nil {
+			return nil, nil
+		}
+	}
+	return record, nil
+}
+
+// Hooks are the transformations of each stream, keyed by topic
+type Hooks struct {
+	publish map[string]Chain
+	deliver map[string]Chain
+	closers []func(context.Context) error
+}
+
+// NewHooks creates hooks without any transformation
+func NewHooks() *Hooks {
+	return &Hooks{publish: make(map[string]Chain), deliver: make(map[string]Chain)}
+}
+
+// OnPublish adds a transformer applied to the records published to a topic
+func (h *Hooks) OnPublish(topic string, name string, transformer Transformer) {
+	h.publish[topic] = append(h.publish[topic], namedTransformer{name: name, transformer: transformer})
+}
+
+// OnDeliver adds a transformer applied to the records of a topic before they are delivered to subscribers
+func (h *Hooks) OnDeliver(topic string, name string, transformer Transformer) {
+	h.deliver[topic] = append(h.deliver[topic], namedTransformer{name: name, transformer: transformer})
+}
+
+// Published transforms a record being published, returning nil when it is filtered out
+func (h *Hooks) Published(ctx context.Context, record *Record) (*Record, error) {
+	if h == nil {
+		return record, nil
+	}
+	return h.publish[record.Topic].Transform(ctx, record)
+}
+
+// Delivered transforms a record being delivered, returning nil when it is filtered out
+func (h *Hooks) Delivered(ctx context.Context, record *Record) (*Record, error) {
+	if h == nil {
+		return record, nil
+	}
+	return h.deliver[record.Topic].Transform(ctx, record)
+}
+
+// Close releases the resources of the transformers
+func (h *Hooks) Close(ctx context.Context) error {
+	if h == nil {
+		return nil
+	}
+	for _, close := range h.closers {
+		if err := close(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package transform_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/transform"
+	"github.com/tetratelabs/wazero"
+)
+
+// wasmModule assembles a module exporting memory, an allocate function always returning offset 1024 and a
+// transform function with the given body
+func wasmModule(transformBody ...byte) []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	section := func(id byte, content ...byte) {
+		module = append(module, id, byte(len(content)))
+		module = append(module, content...)
+	}
+	// (i32) -> i32 and (i32, i32) -> i64
+	section(0x01, 0x02, 0x60, 0x01, 0x7f, 0x01, 0x7f, 0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e)
+	section(0x03, 0x02, 0x00, 0x01)
+	section(0x05, 0x01, 0x00, 0x01)
+	exports := []byte{0x03}
+	exports = append(exports, append([]byte{0x06}, "memory"...)...)
+	exports = append(exports, 0x02, 0x00)
+	exports = append(exports, append([]byte{0x08}, "allocate"...)...)
+	exports = append(exports, 0x00, 0x00)
+	exports = append(exports, append([]byte{0x09}, "transform"...)...)
+	exports = append(exports, 0x00, 0x01)
+	section(0x07, exports...)
+	allocate := []byte{0x00, 0x41, 0x80, 0x08, 0x0b}
+	transform := append([]byte{0x00}, append(transformBody, 0x0b)...)
+	code := []byte{0x02, byte(len(allocate))}
+	code = append(code, allocate...)
+	code = append(code, byte(len(transform)))
+	code = append(code, transform...)
+	section(0x0a, code...)
+	return module
+}
+
+// identity returns its input: local.get 0, i64.extend_i32_u, i64.const 32, i64.shl, local.get 1, i64.extend_i32_u, i64.or
+var identity = wasmModule(0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x01, 0xad, 0x84)
+
+// filter returns 0: i64.const 0
+var filter = wasmModule(0x42, 0x00)
+
+var _ = Describe("Transformations", func() {
+
+	var (
+		ctx    context.Context
+		record *transform.Record
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		record = &transform.Record{Topic: "ns_stream", Key: []byte("key"), Value: []byte("value")}
+	})
+
+	upperCase := transform.TransformerFunc(func(_ context.Context, record *transform.Record) (*transform.Record, error) {
+		return &transform.Record{Topic: record.Topic, Key: record.Key, Value: bytes.ToUpper(record.Value)}, nil
+	})
+	dropAll := transform.TransformerFunc(func(context.Context, *transform.Record) (*transform.Record, error) {
+		return nil, nil
+	})
+
+	Describe("hooks", func() {
+
+		It("applies the transformers of the stream in order", func() {
+			hooks := transform.NewHooks()
+			hooks.OnPublish("ns_stream", "upper-case", upperCase)
+			hooks.OnPublish("ns_stream", "suffix", transform.TransformerFunc(func(_ context.Context, record *transform.Record) (*transform.Record, error) {
+				return &transform.Record{Topic: record.Topic, Value: append(record.Value, '!')}, nil
+			}))
+
+			published, err := hooks.Published(ctx, record)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(published.Value)).To(Equal("VALUE!"))
+		})
+
+		It("stops once a record is filtered out", func() {
+			hooks := transform.NewHooks()
+			hooks.OnDeliver("ns_stream", "drop-all", dropAll)
+			hooks.OnDeliver("ns_stream", "fail", transform.TransformerFunc(func(context.Context, *transform.Record) (*transform.Record, error) {
+				return nil, errors.New("should not be called")
+			}))
+
+			Expect(hooks.Delivered(ctx, record)).To(BeNil())
+		})
+
+		It("only transforms the records of the configured stage and stream", func() {
+			hooks := transform.NewHooks()
+			hooks.OnPublish("ns_other", "upper-case", upperCase)
+			hooks.OnDeliver("ns_stream", "upper-case", upperCase)
+
+			Expect(hooks.Published(ctx, record)).To(Equal(record))
+		})
+
+		It("names the failing transformer", func() {
+			hooks := transform.NewHooks()
+			hooks.OnPublish("ns_stream", "broken", transform.TransformerFunc(func(context.Context, *transform.Record) (*transform.Record, error) {
+				return nil, errors.New("boom")
+			}))
+
+			_, err := hooks.Published(ctx, record)
+
+			Expect(err).To(MatchError(`transformer "broken" failed: boom`))
+		})
+
+		It("leaves records untouched without hooks", func() {
+			var hooks *transform.Hooks
+
+			Expect(hooks.Published(ctx, record)).To(Equal(record))
+			Expect(hooks.Close(ctx)).To(Succeed())
+		})
+	})
+
+	Describe("WebAssembly modules", func() {
+
+		var runtime wazero.Runtime
+
+		BeforeEach(func() {
+			runtime = wazero.NewRuntime(ctx)
+		})
+
+		AfterEach(func() {
+			Expect(runtime.Close(ctx)).To(Succeed())
+		})
+
+		It("exchanges JSON encoded records with the module", func() {
+			transformer, err := transform.NewWASMTransformer(ctx, runtime, "identity", identity)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(transformer.Transform(ctx, record)).To(Equal(record))
+		})
+
+		It("lets modules filter records out", func() {
+			transformer, err := transform.NewWASMTransformer(ctx, runtime, "filter", filter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(transformer.Transform(ctx, record)).To(BeNil())
+		})
+
+		It("requires the module to export its functions", func() {
+			_, err := transform.NewWASMTransformer(ctx, runtime, "empty", []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00})
+
+			Expect(err).To(MatchError(ContainSubstring("should export memory, allocate and transform")))
+		})
+	})
+
+	Describe("loading hooks", func() {
+
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "transform")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "filter.wasm"), filter, 0644)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		load := func(config string) (*transform.Hooks, error) {
+			path := filepath.Join(dir, "transforms.json")
+			Expect(ioutil.WriteFile(path, []byte(config), 0644)).To(Succeed())
+			return transform.LoadHooks(ctx, path)
+		}
+
+		It("instantiates the modules of each stream", func() {
+			hooks, err := load(`{"modules": {"filter": "filter.wasm"}, "streams": {"ns_stream": {"deliver": ["filter"]}}}`)
+			Expect(err).NotTo(HaveOccurred())
+			defer hooks.Close(ctx)
+
+			Expect(hooks.Published(ctx, record)).To(Equal(record))
+			Expect(hooks.Delivered(ctx, record)).To(BeNil())
+		})
+
+		It("rejects unknown modules", func() {
+			_, err := load(`{"streams": {"ns_stream": {"publish": ["missing"]}}}`)
+
+			Expect(err).To(MatchError(`stream "ns_stream" refers to unknown module "missing"`))
+		})
+	})
+})
@@ -0,0 +1,80 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// WASMTransformer runs a WebAssembly module, which must export:
+//   - memory
+//   - allocate(size i32) i32, returning where the host should write an input of the given size
+//   - transform(ptr i32, size i32) i64, transforming the JSON encoded Record written by the host, and returning
+//     where the JSON encoded transformed record can be read, packed as ptr << 32 | size, or 0 to filter it out
+//
+// Calls are serialized, modules being single threaded.
+type WASMTransformer struct {
+	m         sync.Mutex
+	module    api.Module
+	memory    api.Memory
+	allocate  api.Function
+	transform api.Function
+}
+
+// NewWASMTransformer instantiates a module within the given runtime
+func NewWASMTransformer(ctx context.Context, runtime wazero.Runtime, name string, code []byte) (*WASMTransformer, error) {
+	module, err := runtime.InstantiateWithConfig(ctx, code, wazero.NewModuleConfig().WithName(name))
+	if err != nil {
+		return nil, fmt.Errorf("error instantiating WebAssembly module %q: %v", name, err)
+	}
+	t := &WASMTransformer{
+		module:    module,
+		memory:    module.Memory(),
+		allocate:  module.ExportedFunction("allocate"),
+		transform: module.ExportedFunction("transform"),
+	}
+	if t.memory == nil || t.allocate == nil || t.transform == nil {
+		_ = module.Close(ctx)
+		return nil, fmt.Errorf("WebAssembly module %q should export memory, allocate and transform", name)
+	}
+	return t, nil
+}
+
+func (t *WASMTransformer) Transform(ctx context.Context, record *Record) (*Record, error) {
+	input, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	results, err := t.allocate.Call(ctx, uint64(len(input)))
+	if err != nil {
+		return nil, err
+	}
+	ptr := uint32(results[0])
+	if !t.memory.Write(ptr, input) {
+		return nil, fmt.Errorf("allocated memory at %d is out of range for %d bytes", ptr, len(input))
+	}
+	results, err = t.transform.Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		return nil, err
+	}
+	if results[0] == 0 {
+		return nil, nil
+	}
+	outputPtr, outputSize := uint32(results[0]>>32), uint32(results[0])
+	output, ok := t.memory.Read(outputPtr, outputSize)
+	if !ok {
+		return nil, fmt.Errorf("transformed record at %d is out of range for %d bytes", outputPtr, outputSize)
+	}
+	transformed := &Record{}
+	if err := json.Unmarshal(output, transformed); err != nil {
+		return nil, fmt.Errorf("invalid transformed record: %v", err)
+	}
+	return transformed, nil
+}
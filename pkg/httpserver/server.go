@@ -0,0 +1,72 @@
+// Package httpserver creates the HTTP servers of the provisioner, the gateway and the webhook, guarded against
+// clients holding on to connections, e.g. by sending their headers slowly
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/projectriff/kafka-provisioner/pkg/env"
+)
+
+const (
+	// DefaultReadHeaderTimeout is how long clients have to send the headers of a request
+	DefaultReadHeaderTimeout = 10 * time.Second
+	// DefaultIdleTimeout is how long connections are kept open between requests
+	DefaultIdleTimeout = 2 * time.Minute
+	// DefaultMaxHeaderBytes bounds the size of the headers of a request
+	DefaultMaxHeaderBytes = 64 * 1024
+)
+
+// New creates a server of handler at addr, configured by environment variables:
+//   - HTTP_READ_HEADER_TIMEOUT, HTTP_IDLE_TIMEOUT and HTTP_MAX_HEADER_BYTES bound how long clients take to send the
+//     headers of requests, how long idle connections are kept open and the size of headers
+//   - HTTP2 serves HTTP/2 over cleartext connections as well as TLS ones when true, and only HTTP/1.1 when false.
+//     HTTP/2 is only served over TLS when unset.
+//
+// Request bodies and responses aren't bounded in time, as subscriptions stream their records for as long as they
+// last.
+func New(addr string, handler http.Handler) (*http.Server, error) {
+	readHeaderTimeout, err := env.Duration("HTTP_READ_HEADER_TIMEOUT", DefaultReadHeaderTimeout)
+	if err != nil {
+		return nil, err
+	}
+	idleTimeout, err := env.Duration("HTTP_IDLE_TIMEOUT", DefaultIdleTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if readHeaderTimeout <= 0 || idleTimeout <= 0 {
+		return nil, fmt.Errorf("environment variables HTTP_READ_HEADER_TIMEOUT and HTTP_IDLE_TIMEOUT should be positive durations")
+	}
+	maxHeaderBytes, err := env.Int("HTTP_MAX_HEADER_BYTES")
+	if err != nil {
+		return nil, err
+	}
+	if maxHeaderBytes < 0 {
+		return nil, fmt.Errorf("environment variable HTTP_MAX_HEADER_BYTES should be positive, got %d", maxHeaderBytes)
+	}
+	if maxHeaderBytes == 0 {
+		maxHeaderBytes = DefaultMaxHeaderBytes
+	}
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		IdleTimeout:       idleTimeout,
+		MaxHeaderBytes:    maxHeaderBytes,
+	}
+	if value := os.Getenv("HTTP2"); value != "" {
+		http2, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable HTTP2 should be a boolean: %v", err)
+		}
+		server.Protocols = &http.Protocols{}
+		server.Protocols.SetHTTP1(true)
+		server.Protocols.SetHTTP2(http2)
+		server.Protocols.SetUnencryptedHTTP2(http2)
+	}
+	return server, nil
+}
@@ -0,0 +1,97 @@
+package httpserver_test
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/httpserver"
+)
+
+var _ = Describe("HTTP server", func() {
+
+	variables := []string{"HTTP_READ_HEADER_TIMEOUT", "HTTP_IDLE_TIMEOUT", "HTTP_MAX_HEADER_BYTES", "HTTP2"}
+
+	AfterEach(func() {
+		for _, name := range variables {
+			Expect(os.Unsetenv(name)).To(Succeed())
+		}
+	})
+
+	It("bounds headers and idle connections by default", func() {
+		server, err := httpserver.New(":8080", http.NotFoundHandler())
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server.Addr).To(Equal(":8080"))
+		Expect(server.ReadHeaderTimeout).To(Equal(httpserver.DefaultReadHeaderTimeout))
+		Expect(server.IdleTimeout).To(Equal(httpserver.DefaultIdleTimeout))
+		Expect(server.MaxHeaderBytes).To(Equal(httpserver.DefaultMaxHeaderBytes))
+		Expect(server.Protocols).To(BeNil())
+	})
+
+	It("reads its limits from the environment", func() {
+		Expect(os.Setenv("HTTP_READ_HEADER_TIMEOUT", "2s")).To(Succeed())
+		Expect(os.Setenv("HTTP_IDLE_TIMEOUT", "30s")).To(Succeed())
+		Expect(os.Setenv("HTTP_MAX_HEADER_BYTES", "8192")).To(Succeed())
+
+		server, err := httpserver.New(":8080", http.NotFoundHandler())
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server.ReadHeaderTimeout).To(Equal(2 * time.Second))
+		Expect(server.IdleTimeout).To(Equal(30 * time.Second))
+		Expect(server.MaxHeaderBytes).To(Equal(8192))
+	})
+
+	It("rejects invalid limits", func() {
+		for name, value := range map[string]string{
+			"HTTP_READ_HEADER_TIMEOUT": "0s",
+			"HTTP_IDLE_TIMEOUT":        "-1s",
+			"HTTP_MAX_HEADER_BYTES":    "-1",
+			"HTTP2":                    "maybe",
+		} {
+			Expect(os.Setenv(name, value)).To(Succeed())
+
+			_, err := httpserver.New(":8080", http.NotFoundHandler())
+
+			Expect(err).To(MatchError(ContainSubstring(name)), name)
+			Expect(os.Unsetenv(name)).To(Succeed())
+		}
+	})
+
+	It("only serves HTTP/1.1 when HTTP/2 is disabled", func() {
+		Expect(os.Setenv("HTTP2", "false")).To(Succeed())
+
+		server, err := httpserver.New(":8080", http.NotFoundHandler())
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server.Protocols.HTTP1()).To(BeTrue())
+		Expect(server.Protocols.HTTP2()).To(BeFalse())
+		Expect(server.Protocols.UnencryptedHTTP2()).To(BeFalse())
+	})
+
+	It("serves HTTP/2 over cleartext connections when enabled", func() {
+		Expect(os.Setenv("HTTP2", "true")).To(Succeed())
+		server, err := httpserver.New("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Proto", r.Proto)
+		}))
+		Expect(err).NotTo(HaveOccurred())
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		go func() {
+			_ = server.Serve(listener)
+		}()
+		defer server.Close()
+
+		protocols := &http.Protocols{}
+		protocols.SetUnencryptedHTTP2(true)
+		httpClient := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+		response, err := httpClient.Get("http://" + listener.Addr().String())
+
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+		Expect(response.Header.Get("X-Proto")).To(Equal("HTTP/2.0"))
+	})
+})
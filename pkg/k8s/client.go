@@ -0,0 +1,128 @@
+// Package k8s is a minimal client for the Kubernetes API, sufficient for the few resources this repository deals with
+package k8s
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// Client sends JSON requests to the Kubernetes API server, authenticated with a bearer token
+type Client struct {
+	Host       string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// StatusError is returned when the API server answers with an unexpected status
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (se *StatusError) Error() string {
+	return fmt.Sprintf("kubernetes API returned status %d: %s", se.Code, se.Message)
+}
+
+// IsNotFound tells whether err reports a missing resource
+func IsNotFound(err error) bool {
+	statusError, ok := err.(*StatusError)
+	return ok && statusError.Code == http.StatusNotFound
+}
+
+// IsConflict tells whether err reports a conflicting update or an already existing resource
+func IsConflict(err error) bool {
+	statusError, ok := err.(*StatusError)
+	return ok && statusError.Code == http.StatusConflict
+}
+
+// NewInClusterClient returns a client authenticated as the service account of the pod it runs in
+func NewInClusterClient() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a kubernetes cluster: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+	}
+	token, err := ioutil.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, err
+	}
+	ca, err := ioutil.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate found in %s/ca.crt", serviceAccountDir)
+	}
+	return &Client{
+		Host:  "https://" + net.JoinHostPort(host, port),
+		Token: string(token),
+		HTTPClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+	}, nil
+}
+
+// InClusterNamespace returns the namespace of the pod it runs in
+func InClusterNamespace() (string, error) {
+	namespace, err := ioutil.ReadFile(serviceAccountDir + "/namespace")
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSpace(namespace)), nil
+}
+
+// Do sends body, if any, as JSON to path and decodes the response into result, if any
+func (c *Client) Do(method, path string, body interface{}, result interface{}) error {
+	return c.DoWithContentType(method, path, "application/json", body, result)
+}
+
+// DoWithContentType is like Do, with the given request content type (e.g. for patches)
+func (c *Client) DoWithContentType(method, path, contentType string, body interface{}, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	request, err := http.NewRequest(method, c.Host+path, reader)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+c.Token)
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", contentType)
+	}
+	response, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		status := struct {
+			Message string `json:"message"`
+		}{}
+		_ = json.NewDecoder(response.Body).Decode(&status)
+		return &StatusError{Code: response.StatusCode, Message: status.Message}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
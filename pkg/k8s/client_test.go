@@ -0,0 +1,55 @@
+package k8s_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/k8s"
+)
+
+var _ = Describe("Kubernetes client", func() {
+
+	var (
+		server *httptest.Server
+		client *k8s.Client
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer some-token"))
+			switch r.URL.Path {
+			case "/api/v1/namespaces/some-ns":
+				_, _ = w.Write([]byte(`{"metadata": {"name": "some-ns"}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"kind": "Status", "message": "not found"}`))
+			}
+		}))
+		client = &k8s.Client{Host: server.URL, Token: "some-token", HTTPClient: server.Client()}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("decodes responses", func() {
+		namespace := struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		}{}
+
+		Expect(client.Do(http.MethodGet, "/api/v1/namespaces/some-ns", nil, &namespace)).To(Succeed())
+		Expect(namespace.Metadata.Name).To(Equal("some-ns"))
+	})
+
+	It("reports error statuses", func() {
+		err := client.Do(http.MethodGet, "/api/v1/namespaces/other-ns", nil, nil)
+
+		Expect(err).To(MatchError("kubernetes API returned status 404: not found"))
+		Expect(k8s.IsNotFound(err)).To(BeTrue())
+		Expect(k8s.IsConflict(err)).To(BeFalse())
+	})
+})
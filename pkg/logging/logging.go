@@ -0,0 +1,110 @@
+// Package logging provides JSON loggers whose verbosity can be changed at runtime
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Shopify/sarama"
+)
+
+// Logging creates loggers sharing a level, and controls the logging of the sarama library
+type Logging struct {
+	output io.Writer
+	level  *slog.LevelVar
+
+	m      sync.Mutex
+	sarama bool
+}
+
+func NewLogging(output io.Writer, level slog.Level) *Logging {
+	l := &Logging{output: output, level: &slog.LevelVar{}}
+	l.level.Set(level)
+	return l
+}
+
+// Logger returns a JSON logger honoring the current level
+func (l *Logging) Logger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(l.output, &slog.HandlerOptions{Level: l.level}))
+}
+
+// SetLevel changes the level of all loggers
+func (l *Logging) SetLevel(level slog.Level) {
+	l.level.Set(level)
+}
+
+// SetSaramaLogging turns the (chatty) logging of the sarama library on or off. When on, sarama
+// logs through a structured logger with a component=sarama attribute.
+func (l *Logging) SetSaramaLogging(enabled bool) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.sarama = enabled
+	if enabled {
+		sarama.Logger = &saramaLogger{logger: l.Logger().With("component", "sarama")}
+	} else {
+		sarama.Logger = log.New(ioutil.Discard, "", 0)
+	}
+}
+
+func (l *Logging) saramaLogging() bool {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.sarama
+}
+
+// ParseLevel parses one of debug, info, warn or error
+func ParseLevel(value string) (slog.Level, error) {
+	level := slog.LevelInfo
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return level, fmt.Errorf("log level should be one of debug, info, warn or error, got %q", value)
+	}
+	return level, nil
+}
+
+type settings struct {
+	Level  string `json:"level"`
+	Sarama *bool  `json:"sarama,omitempty"`
+}
+
+// Handler reports the current settings on GET, and changes them on PUT with a body of the form
+// {"level": "debug", "sarama": true}, where each field is optional
+func (l *Logging) Handler() http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			update := settings{}
+			if err := json.NewDecoder(request.Body).Decode(&update); err != nil {
+				responseWriter.WriteHeader(http.StatusBadRequest)
+				_, _ = fmt.Fprintf(responseWriter, "Invalid log settings: %v\n", err)
+				return
+			}
+			if update.Level != "" {
+				level, err := ParseLevel(update.Level)
+				if err != nil {
+					responseWriter.WriteHeader(http.StatusBadRequest)
+					_, _ = fmt.Fprintf(responseWriter, "Invalid log settings: %v\n", err)
+					return
+				}
+				l.SetLevel(level)
+			}
+			if update.Sarama != nil {
+				l.SetSaramaLogging(*update.Sarama)
+			}
+			l.Logger().Info("Changed log settings", "level", l.level.Level().String(), "sarama", l.saramaLogging())
+		default:
+			responseWriter.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		sarama := l.saramaLogging()
+		responseWriter.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(responseWriter).Encode(settings{Level: strings.ToLower(l.level.Level().String()), Sarama: &sarama})
+	})
+}
@@ -0,0 +1,93 @@
+package logging_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/Shopify/sarama"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/logging"
+)
+
+var _ = Describe("Logging", func() {
+
+	var (
+		output  *bytes.Buffer
+		logs    *logging.Logging
+		handler http.Handler
+	)
+
+	BeforeEach(func() {
+		output = &bytes.Buffer{}
+		logs = logging.NewLogging(output, slog.LevelInfo)
+		logs.SetSaramaLogging(false)
+		handler = logs.Handler()
+	})
+
+	put := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/log-level", strings.NewReader(body)))
+		return recorder
+	}
+
+	It("writes JSON logs honoring the level", func() {
+		logger := logs.Logger()
+
+		logger.Debug("hidden")
+		logger.Info("shown", "topic", "ns_stream")
+
+		Expect(output.String()).NotTo(ContainSubstring("hidden"))
+		Expect(output.String()).To(ContainSubstring(`"msg":"shown","topic":"ns_stream"`))
+	})
+
+	It("reports the current settings", func() {
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/log-level", nil))
+
+		Expect(recorder.Body.String()).To(MatchJSON(`{"level": "info", "sarama": false}`))
+	})
+
+	It("changes the level of existing loggers at runtime", func() {
+		logger := logs.Logger()
+
+		recorder := put(`{"level": "debug"}`)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(MatchJSON(`{"level": "debug", "sarama": false}`))
+		logger.Debug("now shown")
+		Expect(output.String()).To(ContainSubstring("now shown"))
+	})
+
+	It("toggles the logging of sarama", func() {
+		put(`{"sarama": true, "level": "debug"}`)
+		sarama.Logger.Println("some sarama line")
+		Expect(output.String()).To(ContainSubstring(`"level":"DEBUG","msg":"some sarama line","component":"sarama"`))
+
+		output.Reset()
+		put(`{"sarama": false}`)
+		sarama.Logger.Println("some sarama line")
+		Expect(output.String()).NotTo(ContainSubstring("some sarama line"))
+	})
+
+	It("logs sarama problems as warnings", func() {
+		logs.SetSaramaLogging(true)
+
+		sarama.Logger.Printf("client/metadata fetching metadata for all topics from broker %s\n", "localhost:9092")
+		sarama.Logger.Printf("Failed to connect to broker %s: %v\n", "localhost:9092", "connection refused")
+
+		Expect(output.String()).NotTo(ContainSubstring("fetching metadata"))
+		Expect(output.String()).To(ContainSubstring(`"level":"WARN","msg":"Failed to connect to broker localhost:9092: connection refused","component":"sarama"`))
+	})
+
+	It("rejects unknown levels", func() {
+		recorder := put(`{"level": "chatty"}`)
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		Expect(recorder.Body.String()).To(ContainSubstring(`log level should be one of debug, info, warn or error, got "chatty"`))
+	})
+})
@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// saramaProblem matches the sarama log lines reporting failures, which deserve more attention than the rest
+var saramaProblem = regexp.MustCompile(`(?i)\b(error|errors|failed|failure|unable|cannot|could not|timeout|timed out)\b`)
+
+// saramaLogger adapts a structured logger to sarama.StdLogger, logging problems as warnings and anything else
+// (connections, metadata refreshes, retries) as debug
+type saramaLogger struct {
+	logger *slog.Logger
+}
+
+func (sl *saramaLogger) Print(v ...interface{}) {
+	sl.log(fmt.Sprint(v...))
+}
+
+func (sl *saramaLogger) Printf(format string, v ...interface{}) {
+	sl.log(fmt.Sprintf(format, v...))
+}
+
+func (sl *saramaLogger) Println(v ...interface{}) {
+	sl.log(fmt.Sprintln(v...))
+}
+
+func (sl *saramaLogger) log(message string) {
+	message = strings.TrimSpace(message)
+	level := slog.LevelDebug
+	if saramaProblem.MatchString(message) {
+		level = slog.LevelWarn
+	}
+	sl.logger.Log(context.Background(), level, message)
+}
@@ -0,0 +1,108 @@
+// Package absence remembers briefly which topics don't exist, so that bursts of existence probes from reconciling
+// controllers, describing streams they are about to provision, don't each cost a metadata round trip to the brokers
+package absence
+
+import (
+	"sync"
+	"time"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+// maxAbsentTopics bounds the cache, expired topics being evicted when reached
+const maxAbsentTopics = 10000
+
+// Cache remembers the topics found not to exist for a TTL, across all the clients it wraps. Topics created through
+// them are forgotten right away; those created by other means, e.g. another provisioner, are reported missing until
+// the TTL expires.
+type Cache struct {
+	ttl time.Duration
+
+	mu     sync.Mutex
+	absent map[string]time.Time
+	// generation counts the topics created, a probe that raced with a creation not being remembered
+	generation uint64
+}
+
+// New creates a cache remembering absent topics for ttl, not caching anything when ttl is not positive
+func New(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, absent: make(map[string]time.Time)}
+}
+
+// Disabled tells whether existence probes all reach the brokers
+func (c *Cache) Disabled() bool {
+	return c.ttl <= 0
+}
+
+// WrapKafkaClient answers the existence probes of kafkaClient about topics recently found not to exist from the
+// cache
+func (c *Cache) WrapKafkaClient(kafkaClient client.KafkaClient) client.KafkaClient {
+	if c.Disabled() {
+		return kafkaClient
+	}
+	return &cachedClient{KafkaClient: kafkaClient, cache: c}
+}
+
+// absentSince tells whether a topic is remembered not to exist, and the generation of the cache
+func (c *Cache) absentSince(topicName string, now time.Time) (bool, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expiry, ok := c.absent[topicName]
+	if ok && !now.Before(expiry) {
+		delete(c.absent, topicName)
+		ok = false
+	}
+	return ok, c.generation
+}
+
+// remember records that a topic doesn't exist, unless a topic was created since the generation it was probed at
+func (c *Cache) remember(topicName string, generation uint64, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if len(c.absent) >= maxAbsentTopics {
+		for name, expiry := range c.absent {
+			if !now.Before(expiry) {
+				delete(c.absent, name)
+			}
+		}
+		if len(c.absent) >= maxAbsentTopics {
+			c.absent = make(map[string]time.Time)
+		}
+	}
+	c.absent[topicName] = now.Add(c.ttl)
+}
+
+// forget drops a topic from the cache, once it exists or may exist
+func (c *Cache) forget(topicName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	delete(c.absent, topicName)
+}
+
+type cachedClient struct {
+	client.KafkaClient
+	cache *Cache
+}
+
+func (c *cachedClient) TopicExists(topicName string) (bool, *client.KafkaError) {
+	now := time.Now()
+	absent, generation := c.cache.absentSince(topicName, now)
+	if absent {
+		return false, nil
+	}
+	exists, kafkaError := c.KafkaClient.TopicExists(topicName)
+	if kafkaError == nil && !exists {
+		c.cache.remember(topicName, generation, now)
+	}
+	return exists, kafkaError
+}
+
+// CreateTopic forgets the topic whether or not it is created, failures such as timeouts leaving it possibly created
+func (c *cachedClient) CreateTopic(topicName string, spec client.TopicSpec) error {
+	defer c.cache.forget(topicName)
+	return c.KafkaClient.CreateTopic(topicName, spec)
+}
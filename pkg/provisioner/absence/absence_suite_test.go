@@ -0,0 +1,13 @@
+package absence_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestAbsence(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Absence Suite")
+}
@@ -0,0 +1,117 @@
+package absence_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Shopify/sarama"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/absence"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka/kafkafakes"
+)
+
+var _ = Describe("Absence cache", func() {
+
+	var (
+		fakeKafkaClient *kafkafakes.FakeKafkaClient
+		kafkaClient     client.KafkaClient
+	)
+
+	BeforeEach(func() {
+		fakeKafkaClient = &kafkafakes.FakeKafkaClient{}
+		kafkaClient = absence.New(time.Minute).WrapKafkaClient(fakeKafkaClient)
+	})
+
+	It("remembers the topics that don't exist", func() {
+		for i := 0; i < 3; i++ {
+			exists, kafkaError := kafkaClient.TopicExists("ns_foo")
+
+			Expect(kafkaError).To(BeNil())
+			Expect(exists).To(BeFalse())
+		}
+		Expect(fakeKafkaClient.TopicExistsCallCount()).To(Equal(1))
+	})
+
+	It("shares what it remembers across the clients it wraps", func() {
+		cache := absence.New(time.Minute)
+		_, _ = cache.WrapKafkaClient(fakeKafkaClient).TopicExists("ns_foo")
+
+		_, _ = cache.WrapKafkaClient(&kafkafakes.FakeKafkaClient{}).TopicExists("ns_foo")
+
+		Expect(fakeKafkaClient.TopicExistsCallCount()).To(Equal(1))
+	})
+
+	It("probes the topics that exist each time", func() {
+		fakeKafkaClient.TopicExistsReturns(true, nil)
+
+		_, _ = kafkaClient.TopicExists("ns_foo")
+		exists, _ := kafkaClient.TopicExists("ns_foo")
+
+		Expect(exists).To(BeTrue())
+		Expect(fakeKafkaClient.TopicExistsCallCount()).To(Equal(2))
+	})
+
+	It("doesn't remember failed probes", func() {
+		fakeKafkaClient.TopicExistsReturns(false, &client.KafkaError{KError: sarama.ErrRequestTimedOut})
+
+		_, _ = kafkaClient.TopicExists("ns_foo")
+		_, _ = kafkaClient.TopicExists("ns_foo")
+
+		Expect(fakeKafkaClient.TopicExistsCallCount()).To(Equal(2))
+	})
+
+	It("forgets topics once created", func() {
+		_, _ = kafkaClient.TopicExists("ns_foo")
+
+		Expect(kafkaClient.CreateTopic("ns_foo", client.DefaultTopicSpec())).To(Succeed())
+		fakeKafkaClient.TopicExistsReturns(true, nil)
+		exists, _ := kafkaClient.TopicExists("ns_foo")
+
+		Expect(exists).To(BeTrue())
+		Expect(fakeKafkaClient.TopicExistsCallCount()).To(Equal(2))
+	})
+
+	It("forgets topics that failed to be created, as they may have been", func() {
+		fakeKafkaClient.CreateTopicReturns(errors.New("timed out"))
+		_, _ = kafkaClient.TopicExists("ns_foo")
+
+		Expect(kafkaClient.CreateTopic("ns_foo", client.DefaultTopicSpec())).NotTo(Succeed())
+		_, _ = kafkaClient.TopicExists("ns_foo")
+
+		Expect(fakeKafkaClient.TopicExistsCallCount()).To(Equal(2))
+	})
+
+	It("doesn't remember probes that raced with a creation", func() {
+		fakeKafkaClient.TopicExistsStub = func(topicName string) (bool, *client.KafkaError) {
+			// the topic is created while it is being probed
+			if fakeKafkaClient.TopicExistsCallCount() == 1 {
+				Expect(kafkaClient.CreateTopic(topicName, client.DefaultTopicSpec())).To(Succeed())
+			}
+			return false, nil
+		}
+
+		_, _ = kafkaClient.TopicExists("ns_foo")
+		_, _ = kafkaClient.TopicExists("ns_foo")
+
+		Expect(fakeKafkaClient.TopicExistsCallCount()).To(Equal(2))
+	})
+
+	It("probes topics again once the TTL expires", func() {
+		kafkaClient = absence.New(10 * time.Millisecond).WrapKafkaClient(fakeKafkaClient)
+
+		_, _ = kafkaClient.TopicExists("ns_foo")
+		time.Sleep(20 * time.Millisecond)
+		_, _ = kafkaClient.TopicExists("ns_foo")
+
+		Expect(fakeKafkaClient.TopicExistsCallCount()).To(Equal(2))
+	})
+
+	It("returns clients as they are when disabled", func() {
+		cache := absence.New(0)
+
+		Expect(cache.Disabled()).To(BeTrue())
+		Expect(cache.WrapKafkaClient(fakeKafkaClient)).To(BeIdenticalTo(fakeKafkaClient))
+	})
+})
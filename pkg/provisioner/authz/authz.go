@@ -0,0 +1,127 @@
+package authz
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/projectriff/kafka-provisioner/pkg/k8s"
+)
+
+const (
+	// StreamsGroup and StreamsResource identify the riff resources callers need permissions on
+	StreamsGroup    = "streaming.projectriff.io"
+	StreamsResource = "streams"
+)
+
+// Decision is the outcome of an authorization check
+type Decision struct {
+	// Authenticated is false when the token itself was rejected
+	Authenticated bool
+	Allowed       bool
+	Reason        string
+	// Namespace is the namespace of the caller's identity, when it is a service account
+	Namespace string
+	// Username is the user the caller authenticated as, when known
+	Username string
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Authorizer
+type Authorizer interface {
+	// Authorize checks whether the bearer of token may perform verb on streams in namespace
+	Authorize(token, namespace, verb string) (Decision, error)
+}
+
+type kubernetesAuthorizer struct {
+	client *k8s.Client
+}
+
+// NewKubernetesAuthorizer returns an Authorizer identifying callers with a TokenReview and checking
+// their permissions with a SubjectAccessReview. The client's own identity should be allowed to create both.
+func NewKubernetesAuthorizer(client *k8s.Client) Authorizer {
+	return &kubernetesAuthorizer{client: client}
+}
+
+type userInfo struct {
+	Username string              `json:"username"`
+	UID      string              `json:"uid"`
+	Groups   []string            `json:"groups"`
+	Extra    map[string][]string `json:"extra,omitempty"`
+}
+
+type tokenReview struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Spec       struct {
+		Token string `json:"token"`
+	} `json:"spec"`
+	Status struct {
+		Authenticated bool     `json:"authenticated"`
+		User          userInfo `json:"user"`
+		Error         string   `json:"error"`
+	} `json:"status"`
+}
+
+type resourceAttributes struct {
+	Namespace string `json:"namespace"`
+	Verb      string `json:"verb"`
+	Group     string `json:"group"`
+	Resource  string `json:"resource"`
+}
+
+type subjectAccessReview struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Spec       struct {
+		ResourceAttributes resourceAttributes  `json:"resourceAttributes"`
+		User               string              `json:"user"`
+		UID                string              `json:"uid"`
+		Groups             []string            `json:"groups"`
+		Extra              map[string][]string `json:"extra,omitempty"`
+	} `json:"spec"`
+	Status struct {
+		Allowed bool   `json:"allowed"`
+		Reason  string `json:"reason"`
+	} `json:"status"`
+}
+
+func (ka *kubernetesAuthorizer) Authorize(token, namespace, verb string) (Decision, error) {
+	review := tokenReview{APIVersion: "authentication.k8s.io/v1", Kind: "TokenReview"}
+	review.Spec.Token = token
+	if err := ka.client.Do(http.MethodPost, "/apis/authentication.k8s.io/v1/tokenreviews", review, &review); err != nil {
+		return Decision{}, fmt.Errorf("error reviewing token: %v", err)
+	}
+	if !review.Status.Authenticated {
+		return Decision{Reason: review.Status.Error}, nil
+	}
+
+	user := review.Status.User
+	access := subjectAccessReview{APIVersion: "authorization.k8s.io/v1", Kind: "SubjectAccessReview"}
+	access.Spec.ResourceAttributes = resourceAttributes{
+		Namespace: namespace,
+		Verb:      verb,
+		Group:     StreamsGroup,
+		Resource:  StreamsResource,
+	}
+	access.Spec.User = user.Username
+	access.Spec.UID = user.UID
+	access.Spec.Groups = user.Groups
+	access.Spec.Extra = user.Extra
+	if err := ka.client.Do(http.MethodPost, "/apis/authorization.k8s.io/v1/subjectaccessreviews", access, &access); err != nil {
+		return Decision{}, fmt.Errorf("error reviewing access of %q: %v", user.Username, err)
+	}
+	reason := access.Status.Reason
+	if !access.Status.Allowed && reason == "" {
+		reason = fmt.Sprintf("%q may not %s %s in namespace %q", user.Username, verb, StreamsResource, namespace)
+	}
+	return Decision{Authenticated: true, Allowed: access.Status.Allowed, Reason: reason, Namespace: serviceAccountNamespace(user.Username), Username: user.Username}, nil
+}
+
+// serviceAccountNamespace returns the namespace of usernames of the form system:serviceaccount:<namespace>:<name>
+func serviceAccountNamespace(username string) string {
+	parts := strings.Split(username, ":")
+	if len(parts) == 4 && parts[0] == "system" && parts[1] == "serviceaccount" {
+		return parts[2]
+	}
+	return ""
+}
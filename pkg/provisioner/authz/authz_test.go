@@ -0,0 +1,91 @@
+package authz_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/k8s"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/authz"
+)
+
+var _ = Describe("Kubernetes authorizer", func() {
+
+	var (
+		server        *httptest.Server
+		authenticated bool
+		allowed       bool
+		accessReview  map[string]interface{}
+		authorizer    authz.Authorizer
+	)
+
+	BeforeEach(func() {
+		authenticated, allowed = true, true
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer provisioner-token"))
+			switch r.URL.Path {
+			case "/apis/authentication.k8s.io/v1/tokenreviews":
+				review := map[string]interface{}{}
+				Expect(json.NewDecoder(r.Body).Decode(&review)).To(Succeed())
+				Expect(review["spec"]).To(Equal(map[string]interface{}{"token": "caller-token"}))
+				review["status"] = map[string]interface{}{
+					"authenticated": authenticated,
+					"user":          map[string]interface{}{"username": "system:serviceaccount:ns:riff", "groups": []string{"system:serviceaccounts"}},
+				}
+				Expect(json.NewEncoder(w).Encode(review)).To(Succeed())
+			case "/apis/authorization.k8s.io/v1/subjectaccessreviews":
+				accessReview = map[string]interface{}{}
+				Expect(json.NewDecoder(r.Body).Decode(&accessReview)).To(Succeed())
+				accessReview["status"] = map[string]interface{}{"allowed": allowed}
+				Expect(json.NewEncoder(w).Encode(accessReview)).To(Succeed())
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		authorizer = authz.NewKubernetesAuthorizer(&k8s.Client{Host: server.URL, Token: "provisioner-token", HTTPClient: server.Client()})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("reviews the access of the token's user to streams of the namespace", func() {
+		decision, err := authorizer.Authorize("caller-token", "ns", "create")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision).To(Equal(authz.Decision{Authenticated: true, Allowed: true, Namespace: "ns", Username: "system:serviceaccount:ns:riff"}))
+		Expect(accessReview["spec"]).To(Equal(map[string]interface{}{
+			"user":   "system:serviceaccount:ns:riff",
+			"uid":    "",
+			"groups": []interface{}{"system:serviceaccounts"},
+			"resourceAttributes": map[string]interface{}{
+				"namespace": "ns",
+				"verb":      "create",
+				"group":     "streaming.projectriff.io",
+				"resource":  "streams",
+			},
+		}))
+	})
+
+	It("denies access with a reason", func() {
+		allowed = false
+
+		decision, err := authorizer.Authorize("caller-token", "ns", "create")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision.Allowed).To(BeFalse())
+		Expect(decision.Reason).To(Equal(`"system:serviceaccount:ns:riff" may not create streams in namespace "ns"`))
+	})
+
+	It("reports unauthenticated tokens", func() {
+		authenticated = false
+
+		decision, err := authorizer.Authorize("caller-token", "ns", "create")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision.Authenticated).To(BeFalse())
+		Expect(decision.Allowed).To(BeFalse())
+	})
+})
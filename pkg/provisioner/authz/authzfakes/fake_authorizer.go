@@ -0,0 +1,118 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package authzfakes
+
+import (
+	"sync"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/authz"
+)
+
+type FakeAuthorizer struct {
+	AuthorizeStub        func(string, string, string) (authz.Decision, error)
+	authorizeMutex       sync.RWMutex
+	authorizeArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	authorizeReturns struct {
+		result1 authz.Decision
+		result2 error
+	}
+	authorizeReturnsOnCall map[int]struct {
+		result1 authz.Decision
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeAuthorizer) Authorize(arg1 string, arg2 string, arg3 string) (authz.Decision, error) {
+	fake.authorizeMutex.Lock()
+	ret, specificReturn := fake.authorizeReturnsOnCall[len(fake.authorizeArgsForCall)]
+	fake.authorizeArgsForCall = append(fake.authorizeArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.AuthorizeStub
+	fakeReturns := fake.authorizeReturns
+	fake.recordInvocation("Authorize", []interface{}{arg1, arg2, arg3})
+	fake.authorizeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAuthorizer) AuthorizeCallCount() int {
+	fake.authorizeMutex.RLock()
+	defer fake.authorizeMutex.RUnlock()
+	return len(fake.authorizeArgsForCall)
+}
+
+func (fake *FakeAuthorizer) AuthorizeCalls(stub func(string, string, string) (authz.Decision, error)) {
+	fake.authorizeMutex.Lock()
+	defer fake.authorizeMutex.Unlock()
+	fake.AuthorizeStub = stub
+}
+
+func (fake *FakeAuthorizer) AuthorizeArgsForCall(i int) (string, string, string) {
+	fake.authorizeMutex.RLock()
+	defer fake.authorizeMutex.RUnlock()
+	argsForCall := fake.authorizeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeAuthorizer) AuthorizeReturns(result1 authz.Decision, result2 error) {
+	fake.authorizeMutex.Lock()
+	defer fake.authorizeMutex.Unlock()
+	fake.AuthorizeStub = nil
+	fake.authorizeReturns = struct {
+		result1 authz.Decision
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAuthorizer) AuthorizeReturnsOnCall(i int, result1 authz.Decision, result2 error) {
+	fake.authorizeMutex.Lock()
+	defer fake.authorizeMutex.Unlock()
+	fake.AuthorizeStub = nil
+	if fake.authorizeReturnsOnCall == nil {
+		fake.authorizeReturnsOnCall = make(map[int]struct {
+			result1 authz.Decision
+			result2 error
+		})
+	}
+	fake.authorizeReturnsOnCall[i] = struct {
+		result1 authz.Decision
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAuthorizer) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeAuthorizer) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ authz.Authorizer = new(FakeAuthorizer)
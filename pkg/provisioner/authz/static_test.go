@@ -0,0 +1,100 @@
+package authz_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/authz"
+)
+
+var _ = Describe("Static authorizer", func() {
+
+	var authorizer authz.Authorizer
+
+	BeforeEach(func() {
+		authorizer = authz.NewStaticAuthorizer(map[string][]string{
+			"ns-token":    {"ns", "other-ns"},
+			"admin-token": {authz.AllNamespaces},
+			"sub-token":   {"ns:subscribe", "*:publish,subscribe"},
+		})
+	})
+
+	It("allows tokens on the streams of their namespaces", func() {
+		decision, err := authorizer.Authorize("ns-token", "other-ns", "publish")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision).To(Equal(authz.Decision{Authenticated: true, Allowed: true}))
+	})
+
+	It("allows tokens of all namespaces", func() {
+		decision, err := authorizer.Authorize("admin-token", "ns", "publish")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision.Allowed).To(BeTrue())
+	})
+
+	It("denies tokens on other namespaces", func() {
+		decision, err := authorizer.Authorize("ns-token", "third-ns", "publish")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision.Authenticated).To(BeTrue())
+		Expect(decision.Allowed).To(BeFalse())
+		Expect(decision.Reason).To(Equal(`the token may not publish streams in namespace "third-ns"`))
+	})
+
+	It("only allows the verbs listed for a namespace", func() {
+		for namespace, verbs := range map[string]map[string]bool{
+			"ns":       {"subscribe": true, "publish": true, "manage": false},
+			"other-ns": {"subscribe": true, "publish": true, "manage": false},
+		} {
+			for verb, allowed := range verbs {
+				decision, err := authorizer.Authorize("sub-token", namespace, verb)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(decision.Allowed).To(Equal(allowed), namespace+" "+verb)
+			}
+		}
+		decision, _ := authorizer.Authorize("sub-token", "ns", "manage")
+		Expect(decision.Reason).To(Equal(`the token may not manage streams in namespace "ns"`))
+	})
+
+	It("reports unknown tokens", func() {
+		decision, err := authorizer.Authorize("guess", "ns", "publish")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision.Authenticated).To(BeFalse())
+	})
+
+	Describe("loading tokens", func() {
+
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "tokens")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		It("reads tokens and their namespaces", func() {
+			path := filepath.Join(dir, "tokens.json")
+			Expect(ioutil.WriteFile(path, []byte(`{"ns-token": ["ns"]}`), 0600)).To(Succeed())
+
+			Expect(authz.LoadStaticTokens(path)).To(Equal(map[string][]string{"ns-token": {"ns"}}))
+		})
+
+		It("rejects empty tokens", func() {
+			path := filepath.Join(dir, "tokens.json")
+			Expect(ioutil.WriteFile(path, []byte(`{"": ["ns"]}`), 0600)).To(Succeed())
+
+			_, err := authz.LoadStaticTokens(path)
+			Expect(err).To(MatchError(ContainSubstring("tokens can't be empty")))
+		})
+	})
+})
@@ -0,0 +1,270 @@
+// Package breaker stops the provisioner from waiting on Kafka while its brokers are known to be unavailable
+package breaker
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+// OpenError is returned by Allow while the breaker is open
+type OpenError struct {
+	// RetryAfter is how long until the breaker lets a probe request through
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("Kafka is unavailable, retry in %s", e.RetryAfter)
+}
+
+// Breaker opens after Threshold consecutive failures, rejecting requests until Cooldown has elapsed.
+// It then lets a single probe request through, closing again if it succeeds and re-opening otherwise.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+	Logger    *slog.Logger
+
+	now      func() time.Time
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// New creates a breaker, disabled when threshold is not positive
+func New(threshold int, cooldown time.Duration, logger *slog.Logger) *Breaker {
+	return &Breaker{Threshold: threshold, Cooldown: cooldown, Logger: logger, now: time.Now}
+}
+
+// Disabled tells whether the breaker never opens
+func (b *Breaker) Disabled() bool {
+	return b == nil || b.Threshold <= 0
+}
+
+// Allow returns an *OpenError when a request should fail fast rather than reach Kafka
+func (b *Breaker) Allow() error {
+	if b.Disabled() {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.Threshold {
+		return nil
+	}
+	remaining := b.Cooldown - b.now().Sub(b.openedAt)
+	if remaining > 0 {
+		return &OpenError{RetryAfter: remaining}
+	}
+	if b.probing {
+		return &OpenError{RetryAfter: b.Cooldown}
+	}
+	b.probing = true
+	return nil
+}
+
+// RetryAfter returns how long until the breaker lets a probe request through while it is open, zero while it is
+// closed
+func (b *Breaker) RetryAfter() time.Duration {
+	if b.Disabled() {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.Threshold {
+		return 0
+	}
+	if remaining := b.Cooldown - b.now().Sub(b.openedAt); remaining > 0 {
+		return remaining
+	}
+	if b.probing {
+		return b.Cooldown
+	}
+	return 0
+}
+
+// Success records a request that reached Kafka, closing the breaker
+func (b *Breaker) Success() {
+	if b.Disabled() {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures >= b.Threshold {
+		b.Logger.Info("Kafka is reachable again, closing circuit breaker")
+	}
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure records a request that could not reach Kafka, opening the breaker once the threshold is reached
+// or when the probe request failed
+func (b *Breaker) Failure() {
+	if b.Disabled() {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures == b.Threshold || b.probing {
+		b.Logger.Warn("Kafka is unavailable, opening circuit breaker", "failures", b.failures, "cooldown", b.Cooldown)
+		b.openedAt = b.now()
+	}
+	b.probing = false
+}
+
+// Record tells the breaker about the outcome of a Kafka operation. Only retryable errors count as failures,
+// errors caused by the request itself prove Kafka is reachable.
+func (b *Breaker) Record(err error) {
+	if err != nil && client.Classify(err) == client.Retryable {
+		b.Failure()
+	} else {
+		b.Success()
+	}
+}
+
+// WrapKafkaClient records the outcome of every operation of kafkaClient
+func (b *Breaker) WrapKafkaClient(kafkaClient client.KafkaClient) client.KafkaClient {
+	return &recordingClient{delegate: kafkaClient, breaker: b}
+}
+
+type recordingClient struct {
+	delegate client.KafkaClient
+	breaker  *Breaker
+}
+
+func (c *recordingClient) TopicExists(topicName string) (bool, *client.KafkaError) {
+	exists, kafkaError := c.delegate.TopicExists(topicName)
+	if kafkaError != nil {
+		c.breaker.Record(kafkaError)
+	} else {
+		c.breaker.Record(nil)
+	}
+	return exists, kafkaError
+}
+
+func (c *recordingClient) CreateTopic(topicName string, spec client.TopicSpec) error {
+	err := c.delegate.CreateTopic(topicName, spec)
+	c.breaker.Record(err)
+	return err
+}
+
+func (c *recordingClient) DeleteTopic(topicName string) error {
+	err := c.delegate.DeleteTopic(topicName)
+	c.breaker.Record(err)
+	return err
+}
+
+func (c *recordingClient) DeleteTopics(topicNames []string) (map[string]error, error) {
+	topicErrors, err := c.delegate.DeleteTopics(topicNames)
+	c.breaker.Record(err)
+	return topicErrors, err
+}
+
+func (c *recordingClient) AlterTopicConfig(topicName string, configEntries map[string]*string) error {
+	err := c.delegate.AlterTopicConfig(topicName, configEntries)
+	c.breaker.Record(err)
+	return err
+}
+
+func (c *recordingClient) ListTopics() (map[string]client.TopicSpec, error) {
+	topics, err := c.delegate.ListTopics()
+	c.breaker.Record(err)
+	return topics, err
+}
+
+func (c *recordingClient) DescribeTopic(topicName string) (*client.TopicSpec, error) {
+	spec, err := c.delegate.DescribeTopic(topicName)
+	c.breaker.Record(err)
+	return spec, err
+}
+
+func (c *recordingClient) WriteMetadata(topicName string, metadata client.StreamMetadata) error {
+	err := c.delegate.WriteMetadata(topicName, metadata)
+	c.breaker.Record(err)
+	return err
+}
+
+func (c *recordingClient) DeleteMetadata(topicName string) error {
+	err := c.delegate.DeleteMetadata(topicName)
+	c.breaker.Record(err)
+	return err
+}
+
+func (c *recordingClient) ReadMetadata(topicName string) (*client.StreamMetadata, error) {
+	metadata, err := c.delegate.ReadMetadata(topicName)
+	c.breaker.Record(err)
+	return metadata, err
+}
+
+func (c *recordingClient) ListMetadata() (map[string]client.StreamMetadata, error) {
+	metadata, err := c.delegate.ListMetadata()
+	c.breaker.Record(err)
+	return metadata, err
+}
+
+func (c *recordingClient) RecordDecision(topicName string, decision client.Decision) error {
+	err := c.delegate.RecordDecision(topicName, decision)
+	c.breaker.Record(err)
+	return err
+}
+
+func (c *recordingClient) ListDecisions() (map[string]client.Decision, error) {
+	decisions, err := c.delegate.ListDecisions()
+	c.breaker.Record(err)
+	return decisions, err
+}
+
+func (c *recordingClient) GroupProgress(groupID, topicName string) ([]client.PartitionProgress, error) {
+	progress, err := c.delegate.GroupProgress(groupID, topicName)
+	c.breaker.Record(err)
+	return progress, err
+}
+
+func (c *recordingClient) CommitGroupOffsets(groupID, topicName string, startAt int64) (map[int32]int64, error) {
+	offsets, err := c.delegate.CommitGroupOffsets(groupID, topicName, startAt)
+	c.breaker.Record(err)
+	return offsets, err
+}
+
+func (c *recordingClient) TopicHealth(topicName string) (*client.TopicHealth, error) {
+	health, err := c.delegate.TopicHealth(topicName)
+	c.breaker.Record(err)
+	return health, err
+}
+
+func (c *recordingClient) ElectPreferredLeaders(topicName string) ([]client.LeaderElection, error) {
+	elections, err := c.delegate.ElectPreferredLeaders(topicName)
+	c.breaker.Record(err)
+	return elections, err
+}
+
+func (c *recordingClient) Brokers() ([]int32, error) {
+	brokers, err := c.delegate.Brokers()
+	c.breaker.Record(err)
+	return brokers, err
+}
+
+func (c *recordingClient) TopicConfigKeys() (client.TopicConfigKeys, error) {
+	keys, err := c.delegate.TopicConfigKeys()
+	c.breaker.Record(err)
+	return keys, err
+}
+
+func (c *recordingClient) BrokerConfig(name string) (*string, error) {
+	value, err := c.delegate.BrokerConfig(name)
+	c.breaker.Record(err)
+	return value, err
+}
+
+func (c *recordingClient) GrantTransactionalIDs(prefix, principal string) error {
+	err := c.delegate.GrantTransactionalIDs(prefix, principal)
+	c.breaker.Record(err)
+	return err
+}
+
+func (c *recordingClient) Close() error {
+	return c.delegate.Close()
+}
@@ -0,0 +1,152 @@
+package breaker_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"log/slog"
+	"time"
+
+	"github.com/Shopify/sarama"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/breaker"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka/kafkafakes"
+)
+
+var logger = slog.New(slog.NewTextHandler(ioutil.Discard, nil))
+
+var _ = Describe("Breaker", func() {
+
+	var b *breaker.Breaker
+
+	BeforeEach(func() {
+		b = breaker.New(2, 50*time.Millisecond, logger)
+	})
+
+	It("allows requests below the threshold", func() {
+		b.Failure()
+
+		Expect(b.Allow()).To(Succeed())
+	})
+
+	It("fails fast once the threshold is reached", func() {
+		b.Failure()
+		b.Failure()
+
+		err := b.Allow()
+		Expect(err).To(BeAssignableToTypeOf(&breaker.OpenError{}))
+		Expect(err.(*breaker.OpenError).RetryAfter).To(BeNumerically("~", 50*time.Millisecond, 10*time.Millisecond))
+	})
+
+	It("tells how long until it lets a probe through", func() {
+		Expect(b.RetryAfter()).To(BeZero())
+		b.Failure()
+		Expect(b.RetryAfter()).To(BeZero())
+		b.Failure()
+
+		Expect(b.RetryAfter()).To(BeNumerically("~", 50*time.Millisecond, 10*time.Millisecond))
+		time.Sleep(60 * time.Millisecond)
+		Expect(b.RetryAfter()).To(BeZero())
+		Expect(b.Allow()).To(Succeed())
+		Expect(b.RetryAfter()).To(Equal(50 * time.Millisecond))
+	})
+
+	It("resets the count of consecutive failures on success", func() {
+		b.Failure()
+		b.Success()
+		b.Failure()
+
+		Expect(b.Allow()).To(Succeed())
+	})
+
+	It("lets a single probe through after the cooldown", func() {
+		b.Failure()
+		b.Failure()
+		time.Sleep(60 * time.Millisecond)
+
+		Expect(b.Allow()).To(Succeed())
+		Expect(b.Allow()).NotTo(Succeed())
+	})
+
+	It("closes when the probe succeeds", func() {
+		b.Failure()
+		b.Failure()
+		time.Sleep(60 * time.Millisecond)
+		Expect(b.Allow()).To(Succeed())
+
+		b.Success()
+
+		Expect(b.Allow()).To(Succeed())
+		Expect(b.Allow()).To(Succeed())
+	})
+
+	It("re-opens when the probe fails", func() {
+		b.Failure()
+		b.Failure()
+		time.Sleep(60 * time.Millisecond)
+		Expect(b.Allow()).To(Succeed())
+
+		b.Failure()
+
+		Expect(b.Allow()).NotTo(Succeed())
+	})
+
+	It("never opens when disabled", func() {
+		b = breaker.New(0, time.Minute, logger)
+		for i := 0; i < 10; i++ {
+			b.Failure()
+		}
+
+		Expect(b.Allow()).To(Succeed())
+	})
+
+	It("only counts retryable errors as failures", func() {
+		b.Record(sarama.ErrOutOfBrokers)
+		b.Record(sarama.ErrInvalidPartitions)
+		b.Record(sarama.ErrOutOfBrokers)
+
+		Expect(b.Allow()).To(Succeed())
+
+		b.Record(errors.New("unclassified"))
+		b.Record(sarama.ErrNotController)
+		b.Record(sarama.ErrRequestTimedOut)
+
+		Expect(b.Allow()).NotTo(Succeed())
+	})
+
+	Context("wrapping a Kafka client", func() {
+
+		var (
+			fakeKafkaClient *kafkafakes.FakeKafkaClient
+			kafkaClient     client.KafkaClient
+		)
+
+		BeforeEach(func() {
+			fakeKafkaClient = &kafkafakes.FakeKafkaClient{}
+			kafkaClient = b.WrapKafkaClient(fakeKafkaClient)
+		})
+
+		It("records failed operations", func() {
+			fakeKafkaClient.TopicExistsReturns(false, &client.KafkaError{GeneralError: sarama.ErrOutOfBrokers})
+			fakeKafkaClient.CreateTopicReturns(&sarama.TopicError{Err: sarama.ErrRequestTimedOut})
+
+			_, kafkaError := kafkaClient.TopicExists("ns_stream")
+			Expect(kafkaError).NotTo(BeNil())
+			Expect(kafkaClient.CreateTopic("ns_stream", client.DefaultTopicSpec())).NotTo(Succeed())
+
+			Expect(b.Allow()).NotTo(Succeed())
+		})
+
+		It("records successful operations", func() {
+			b.Failure()
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{}, nil)
+
+			_, err := kafkaClient.ListTopics()
+			Expect(err).NotTo(HaveOccurred())
+			b.Failure()
+
+			Expect(b.Allow()).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,141 @@
+// Package clusterlink manages the mirror topics of a Confluent cluster link with the REST API of the destination
+// cluster, so that streams can be consumed read-only on the linked cluster
+package clusterlink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client manages the mirror topics of a cluster link, the topics of the source cluster being mirrored under the
+// same name on the destination cluster
+type Client struct {
+	// URL is the base URL of the REST API of the destination cluster, e.g. https://pkc-12345.us-west-2.aws.confluent.cloud:443
+	URL string
+	// ClusterID is the id of the destination cluster, e.g. lkc-12345
+	ClusterID string
+	// Link is the name of the cluster link, from the source cluster to the destination cluster
+	Link string
+	// APIKey and APISecret, when set, authenticate requests with basic authentication
+	APIKey    string
+	APISecret string
+	Client    *http.Client
+}
+
+// Mirror is a mirror topic of the destination cluster
+type Mirror struct {
+	Link        string `json:"link_name"`
+	Topic       string `json:"mirror_topic_name"`
+	SourceTopic string `json:"source_topic_name"`
+	// Status is the status of mirroring, e.g. ACTIVE while records are mirrored, or STOPPED once promoted
+	Status string `json:"mirror_status"`
+}
+
+// CreateMirror mirrors a topic of the source cluster on the destination cluster
+func (c *Client) CreateMirror(ctx context.Context, topicName string) error {
+	body := map[string]string{"source_topic_name": topicName}
+	_, err := c.do(ctx, http.MethodPost, c.linkPath()+"/mirrors", body, nil)
+	return err
+}
+
+// DescribeMirror returns the mirror of a topic, nil when the topic isn't mirrored
+func (c *Client) DescribeMirror(ctx context.Context, topicName string) (*Mirror, error) {
+	mirror := &Mirror{}
+	found, err := c.do(ctx, http.MethodGet, c.linkPath()+"/mirrors/"+url.PathEscape(topicName), nil, mirror)
+	if err != nil || !found {
+		return nil, err
+	}
+	return mirror, nil
+}
+
+// PromoteMirror stops mirroring a topic once the mirror caught up with its source, the mirror becoming a regular
+// topic producers can write to, e.g. when failing over to the destination cluster
+func (c *Client) PromoteMirror(ctx context.Context, topicName string) error {
+	body := map[string][]string{"mirror_topic_names": {topicName}}
+	var result struct {
+		Data []struct {
+			Topic        string `json:"mirror_topic_name"`
+			ErrorMessage string `json:"error_message"`
+		} `json:"data"`
+	}
+	found, err := c.do(ctx, http.MethodPost, c.linkPath()+"/mirrors:promote", body, &result)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("cluster link %q does not exist", c.Link)
+	}
+	for _, promoted := range result.Data {
+		if promoted.ErrorMessage != "" {
+			return fmt.Errorf("error promoting mirror topic %q: %s", promoted.Topic, promoted.ErrorMessage)
+		}
+	}
+	return nil
+}
+
+// DeleteMirror deletes the mirror of a topic, unknown mirrors being left alone
+func (c *Client) DeleteMirror(ctx context.Context, topicName string) error {
+	_, err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/kafka/v3/clusters/%s/topics/%s", url.PathEscape(c.ClusterID), url.PathEscape(topicName)), nil, nil)
+	return err
+}
+
+func (c *Client) linkPath() string {
+	return fmt.Sprintf("/kafka/v3/clusters/%s/links/%s", url.PathEscape(c.ClusterID), url.PathEscape(c.Link))
+}
+
+// do sends a request to the REST API, decoding the response into v when set, and returning false when what it acts
+// on is not found
+func (c *Client) do(ctx context.Context, method, path string, body, v interface{}) (bool, error) {
+	var content io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return false, err
+		}
+		content = bytes.NewReader(encoded)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.URL, "/")+path, content)
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if c.APIKey != "" {
+		request.SetBasicAuth(c.APIKey, c.APISecret)
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return false, fmt.Errorf("error reaching the REST API of the linked cluster: %v", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		var restError struct {
+			ErrorCode int    `json:"error_code"`
+			Message   string `json:"message"`
+		}
+		_ = json.NewDecoder(response.Body).Decode(&restError)
+		return false, fmt.Errorf("the REST API of the linked cluster responded %d: %s", response.StatusCode, restError.Message)
+	}
+	if v != nil {
+		if err := json.NewDecoder(response.Body).Decode(v); err != nil {
+			return false, fmt.Errorf("error decoding the response of the REST API of the linked cluster: %v", err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,13 @@
+package clusterlink_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestClusterLink(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cluster Link Suite")
+}
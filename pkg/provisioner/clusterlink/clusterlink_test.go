@@ -0,0 +1,108 @@
+package clusterlink_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/clusterlink"
+)
+
+var _ = Describe("Cluster link", func() {
+
+	const linkPath = "/kafka/v3/clusters/lkc-123/links/dr-link"
+
+	var (
+		requests []*http.Request
+		bodies   []map[string]interface{}
+		respond  func(w http.ResponseWriter, r *http.Request)
+		server   *httptest.Server
+		link     *clusterlink.Client
+	)
+
+	BeforeEach(func() {
+		requests, bodies = nil, nil
+		respond = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body := map[string]interface{}{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			requests, bodies = append(requests, r), append(bodies, body)
+			respond(w, r)
+		}))
+		link = &clusterlink.Client{URL: server.URL + "/", ClusterID: "lkc-123", Link: "dr-link", APIKey: "key", APISecret: "secret"}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("mirrors topics under the same name, authenticating with the API key", func() {
+		Expect(link.CreateMirror(context.Background(), "ns_orders")).To(Succeed())
+
+		Expect(requests[0].Method).To(Equal(http.MethodPost))
+		Expect(requests[0].URL.Path).To(Equal(linkPath + "/mirrors"))
+		Expect(bodies[0]).To(Equal(map[string]interface{}{"source_topic_name": "ns_orders"}))
+		username, password, ok := requests[0].BasicAuth()
+		Expect(ok).To(BeTrue())
+		Expect(username + ":" + password).To(Equal("key:secret"))
+	})
+
+	It("describes mirrors", func() {
+		respond = func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"kind": "KafkaMirrorTopic", "link_name": "dr-link", "mirror_topic_name": "ns_orders", "source_topic_name": "ns_orders", "mirror_status": "ACTIVE"}`))
+		}
+
+		mirror, err := link.DescribeMirror(context.Background(), "ns_orders")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests[0].URL.Path).To(Equal(linkPath + "/mirrors/ns_orders"))
+		Expect(mirror).To(Equal(&clusterlink.Mirror{Link: "dr-link", Topic: "ns_orders", SourceTopic: "ns_orders", Status: "ACTIVE"}))
+	})
+
+	It("describes topics that aren't mirrored as nil", func() {
+		respond = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code": 404, "message": "Topic not found"}`))
+		}
+
+		Expect(link.DescribeMirror(context.Background(), "ns_orders")).To(BeNil())
+	})
+
+	It("promotes mirrors, reporting the error of the topic", func() {
+		respond = func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"data": [{"mirror_topic_name": "ns_orders", "error_message": "mirror lag is not zero"}]}`))
+		}
+
+		err := link.PromoteMirror(context.Background(), "ns_orders")
+
+		Expect(err).To(MatchError(`error promoting mirror topic "ns_orders": mirror lag is not zero`))
+		Expect(requests[0].URL.Path).To(Equal(linkPath + "/mirrors:promote"))
+		Expect(bodies[0]).To(Equal(map[string]interface{}{"mirror_topic_names": []interface{}{"ns_orders"}}))
+	})
+
+	It("deletes mirrors as topics, leaving unknown ones alone", func() {
+		respond = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}
+
+		Expect(link.DeleteMirror(context.Background(), "ns_orders")).To(Succeed())
+		Expect(requests[0].Method).To(Equal(http.MethodDelete))
+		Expect(requests[0].URL.Path).To(Equal("/kafka/v3/clusters/lkc-123/topics/ns_orders"))
+	})
+
+	It("reports the errors of the REST API", func() {
+		respond = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error_code": 400, "message": "Cluster link 'dr-link' is paused"}`))
+		}
+
+		err := link.CreateMirror(context.Background(), "ns_orders")
+
+		Expect(err).To(MatchError("the REST API of the linked cluster responded 400: Cluster link 'dr-link' is paused"))
+	})
+})
@@ -0,0 +1,475 @@
+// Package controller guarantees the topics of riff streams are deprovisioned when the streams are deleted, holding
+// on to the streams with a finalizer until they are, reports their address in their status, and provisions the
+// streams of riff processors
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectriff/kafka-provisioner/pkg/k8s"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/metrics"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/workqueue"
+)
+
+const (
+	// Finalizer is set on streams until their topic is deprovisioned
+	Finalizer = "streaming.projectriff.io/kafka-topic"
+	// OrphanAnnotation set to "true" on a stream lets it be deleted without deprovisioning its topic
+	OrphanAnnotation = "streaming.projectriff.io/orphan-topic"
+	// TransactionalAnnotation set to "true" on a processor reserves a prefix of transactional ids for it
+	TransactionalAnnotation = "streaming.projectriff.io/transactional"
+	// StreamsPath lists the streams of all namespaces
+	StreamsPath = "/apis/streaming.projectriff.io/v1alpha1/streams"
+	// ProcessorsPath lists the processors of all namespaces
+	ProcessorsPath = "/apis/streaming.projectriff.io/v1alpha1/processors"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Deprovisioner
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Provisioner
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Addresser
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Reserver
+
+// Deprovisioner archives or deletes the topic of a stream, succeeding when the stream has none
+type Deprovisioner interface {
+	DeprovisionStream(ctx context.Context, namespace, stream string) error
+}
+
+// DeprovisionerFunc is a function deprovisioning the topics of streams
+type DeprovisionerFunc func(ctx context.Context, namespace, stream string) error
+
+func (f DeprovisionerFunc) DeprovisionStream(ctx context.Context, namespace, stream string) error {
+	return f(ctx, namespace, stream)
+}
+
+// Provisioner provisions the streams of a processor all at once, creating the topics of none of them on failure
+type Provisioner interface {
+	ProvisionStreams(ctx context.Context, namespace string, streams []string) error
+}
+
+// ProvisionerFunc is a function provisioning the streams of processors
+type ProvisionerFunc func(ctx context.Context, namespace string, streams []string) error
+
+func (f ProvisionerFunc) ProvisionStreams(ctx context.Context, namespace string, streams []string) error {
+	return f(ctx, namespace, streams)
+}
+
+// Reserver reserves a prefix of transactional ids for a processor, returning the prefix
+type Reserver interface {
+	ReserveTransactionalIDs(ctx context.Context, namespace, processor string) (string, error)
+}
+
+// ReserverFunc is a function reserving the transactional ids of processors
+type ReserverFunc func(ctx context.Context, namespace, processor string) (string, error)
+
+func (f ReserverFunc) ReserveTransactionalIDs(ctx context.Context, namespace, processor string) (string, error) {
+	return f(ctx, namespace, processor)
+}
+
+// Address tells clients of a stream where to reach it, as reported in the status of the stream
+type Address struct {
+	Topic string `json:"topic"`
+	// Gateway is the address of the gRPC endpoint of the gateway
+	Gateway    string `json:"gateway"`
+	Partitions int32  `json:"partitions"`
+}
+
+// Addresser returns the addresses of all the provisioned streams, by <namespace>/<stream>
+type Addresser interface {
+	StreamAddresses(ctx context.Context) (map[string]Address, error)
+}
+
+// AddresserFunc is a function returning the addresses of streams
+type AddresserFunc func(ctx context.Context) (map[string]Address, error)
+
+func (f AddresserFunc) StreamAddresses(ctx context.Context) (map[string]Address, error) {
+	return f(ctx)
+}
+
+// Controller adds its finalizer to streams, and removes it from the streams being deleted once their topic is
+// deprovisioned, kubernetes keeping them until then
+type Controller struct {
+	Client        *k8s.Client
+	Deprovisioner Deprovisioner
+	// Addresser, when set, reports the addresses of the provisioned streams in their status
+	Addresser Addresser
+	// Provisioner, when set, provisions the streams the processors of the cluster read, write and retry from
+	Provisioner Provisioner
+	// Reserver, when set, reserves a prefix of transactional ids for the processors annotated with
+	// TransactionalAnnotation, reporting it in their status
+	Reserver Reserver
+	// Provider, when set, restricts the streams handled to those of the provider of that name
+	Provider string
+	// RateLimiter delays the retries of the streams and processors failing, DefaultRateLimiter when nil
+	RateLimiter *workqueue.RateLimiter
+	// Metrics, when set, records the depth of the queue of streams and processors to reconcile, and the retries
+	Metrics *metrics.Metrics
+	Logger  *slog.Logger
+
+	mu sync.Mutex
+	// addresses are those of the streams when last listed
+	addresses map[string]Address
+}
+
+type streamList struct {
+	Items []stream `json:"items"`
+}
+
+type stream struct {
+	Metadata objectMeta `json:"metadata"`
+	Spec     struct {
+		Provider string `json:"provider"`
+	} `json:"spec"`
+	Status streamStatus `json:"status"`
+}
+
+type streamStatus struct {
+	Address *Address `json:"address,omitempty"`
+}
+
+type processorList struct {
+	Items []processor `json:"items"`
+}
+
+type processor struct {
+	Metadata objectMeta `json:"metadata"`
+	Spec     struct {
+		Inputs  []streamRef `json:"inputs"`
+		Outputs []streamRef `json:"outputs"`
+		Retries []streamRef `json:"retries"`
+	} `json:"spec"`
+	Status processorStatus `json:"status"`
+}
+
+type processorStatus struct {
+	TransactionalIDPrefix string `json:"transactionalIdPrefix,omitempty"`
+}
+
+// streamRef names a stream of the namespace of a processor
+type streamRef struct {
+	Stream string `json:"stream"`
+}
+
+type objectMeta struct {
+	Name              string            `json:"name,omitempty"`
+	Namespace         string            `json:"namespace,omitempty"`
+	ResourceVersion   string            `json:"resourceVersion,omitempty"`
+	DeletionTimestamp *time.Time        `json:"deletionTimestamp,omitempty"`
+	Finalizers        []string          `json:"finalizers"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+}
+
+// Run reconciles the streams and processors at each interval until ctx is done. Those failing are retried once
+// their backoff is over, rather than at each interval, within the global rate limit of retries.
+func (c *Controller) Run(ctx context.Context, interval time.Duration) {
+	limiter := c.RateLimiter
+	if limiter == nil {
+		limiter = workqueue.DefaultRateLimiter()
+	}
+	queue := workqueue.New(limiter)
+	defer queue.ShutDown()
+	go c.work(ctx, queue)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := c.enqueue(ctx, queue); err != nil {
+			c.Logger.Error("Error listing streams and processors to reconcile", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// enqueue queues the keys of the streams and processors to reconcile, refreshing the addresses of the streams
+func (c *Controller) enqueue(ctx context.Context, queue *workqueue.Queue) error {
+	list := streamList{}
+	if err := c.Client.Do(http.MethodGet, StreamsPath, nil, &list); err != nil {
+		return fmt.Errorf("error listing streams: %v", err)
+	}
+	if c.Addresser != nil {
+		addresses, err := c.Addresser.StreamAddresses(ctx)
+		if err != nil {
+			c.Logger.Error("Error listing the addresses of streams", "error", err)
+		} else {
+			c.mu.Lock()
+			c.addresses = addresses
+			c.mu.Unlock()
+		}
+	}
+	for _, s := range list.Items {
+		if c.Provider == "" || s.Spec.Provider == c.Provider {
+			queue.Add(key(streamsResource, s.Metadata))
+		}
+	}
+	if c.Provisioner != nil {
+		processors := processorList{}
+		if err := c.Client.Do(http.MethodGet, ProcessorsPath, nil, &processors); err != nil {
+			return fmt.Errorf("error listing processors: %v", err)
+		}
+		for _, p := range processors.Items {
+			queue.Add(key(processorsResource, p.Metadata))
+		}
+	}
+	c.setQueueDepth(queue)
+	return nil
+}
+
+// work reconciles the streams and processors queued until the queue is shut down, requeuing those failing
+func (c *Controller) work(ctx context.Context, queue *workqueue.Queue) {
+	for {
+		k, ok := queue.Get(ctx)
+		if !ok {
+			return
+		}
+		c.setQueueDepth(queue)
+		if err := c.reconcileKey(ctx, k); err != nil {
+			queue.AddRateLimited(k)
+			if c.Metrics != nil {
+				c.Metrics.IncControllerRetries()
+			}
+			c.Logger.Error("Error reconciling, retrying", "key", k, "retries", queue.NumRequeues(k), "error", err)
+		} else {
+			queue.Forget(k)
+		}
+		queue.Done(k)
+	}
+}
+
+func (c *Controller) setQueueDepth(queue *workqueue.Queue) {
+	if c.Metrics != nil {
+		c.Metrics.SetControllerQueueDepth(queue.Len())
+	}
+}
+
+const (
+	streamsResource    = "streams"
+	processorsResource = "processors"
+)
+
+// key identifies an object in the queue, as <resource>/<namespace>/<name>
+func key(resource string, metadata objectMeta) string {
+	return resource + "/" + metadata.Namespace + "/" + metadata.Name
+}
+
+// reconcileKey reconciles the stream or processor of a key as it is now, those deleted in the meantime being done
+func (c *Controller) reconcileKey(ctx context.Context, k string) error {
+	parts := strings.SplitN(k, "/", 3)
+	if len(parts) != 3 {
+		return nil
+	}
+	path := objectPath(parts[0], parts[1], parts[2])
+	switch parts[0] {
+	case streamsResource:
+		s := stream{}
+		if err := c.Client.Do(http.MethodGet, path, nil, &s); err != nil {
+			if k8s.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		c.mu.Lock()
+		addresses := c.addresses
+		c.mu.Unlock()
+		return c.reconcileStream(ctx, s, addresses)
+	case processorsResource:
+		p := processor{}
+		if err := c.Client.Do(http.MethodGet, path, nil, &p); err != nil {
+			if k8s.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		return c.reconcileProcessor(ctx, p)
+	}
+	return nil
+}
+
+func objectPath(resource, namespace, name string) string {
+	return fmt.Sprintf("/apis/streaming.projectriff.io/v1alpha1/namespaces/%s/%s/%s", namespace, resource, name)
+}
+
+// Reconcile adds the finalizer to the streams lacking it, and deprovisions the topics of the streams being deleted,
+// removing the finalizer once done, then provisions the streams of processors when provisioning. Streams and
+// processors failing are retried by the next reconciliation, the first error being returned.
+func (c *Controller) Reconcile(ctx context.Context) error {
+	err := c.reconcileStreams(ctx)
+	if c.Provisioner != nil {
+		if processorsErr := c.reconcileProcessors(ctx); err == nil {
+			err = processorsErr
+		}
+	}
+	return err
+}
+
+func (c *Controller) reconcileStreams(ctx context.Context) error {
+	list := streamList{}
+	if err := c.Client.Do(http.MethodGet, StreamsPath, nil, &list); err != nil {
+		return fmt.Errorf("error listing streams: %v", err)
+	}
+	var addresses map[string]Address
+	if c.Addresser != nil {
+		var err error
+		// streams are still finalized without their addresses, reported by the next reconciliation
+		if addresses, err = c.Addresser.StreamAddresses(ctx); err != nil {
+			c.Logger.Error("Error listing the addresses of streams", "error", err)
+		}
+	}
+	var firstErr error
+	for _, s := range list.Items {
+		if c.Provider != "" && s.Spec.Provider != c.Provider {
+			continue
+		}
+		if err := c.reconcileStream(ctx, s, addresses); err != nil {
+			c.Logger.Error("Error reconciling stream", "namespace", s.Metadata.Namespace, "stream", s.Metadata.Name, "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// reconcileProcessors provisions the streams of the processors
+func (c *Controller) reconcileProcessors(ctx context.Context) error {
+	list := processorList{}
+	if err := c.Client.Do(http.MethodGet, ProcessorsPath, nil, &list); err != nil {
+		return fmt.Errorf("error listing processors: %v", err)
+	}
+	var firstErr error
+	for _, p := range list.Items {
+		if err := c.reconcileProcessor(ctx, p); err != nil {
+			c.Logger.Error("Error provisioning the streams of processor", "namespace", p.Metadata.Namespace, "processor", p.Metadata.Name, "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// reconcileProcessor provisions the streams of a processor, and reserves its transactional ids when it needs
+// transactions, unless it is being deleted
+func (c *Controller) reconcileProcessor(ctx context.Context, p processor) error {
+	if p.Metadata.DeletionTimestamp != nil {
+		return nil
+	}
+	var streams []string
+	for _, refs := range [][]streamRef{p.Spec.Inputs, p.Spec.Outputs, p.Spec.Retries} {
+		for _, ref := range refs {
+			streams = append(streams, ref.Stream)
+		}
+	}
+	if len(streams) > 0 {
+		if err := c.Provisioner.ProvisionStreams(ctx, p.Metadata.Namespace, streams); err != nil {
+			return err
+		}
+	}
+	if c.Reserver == nil || p.Metadata.Annotations[TransactionalAnnotation] != "true" {
+		return nil
+	}
+	prefix, err := c.Reserver.ReserveTransactionalIDs(ctx, p.Metadata.Namespace, p.Metadata.Name)
+	if err != nil {
+		return err
+	}
+	return c.reportTransactionalIDPrefix(p, prefix)
+}
+
+// reportTransactionalIDPrefix writes the prefix of the transactional ids reserved for a processor in its status,
+// unless already there
+func (c *Controller) reportTransactionalIDPrefix(p processor, prefix string) error {
+	if p.Status.TransactionalIDPrefix == prefix {
+		return nil
+	}
+	patch := map[string]processorStatus{"status": {TransactionalIDPrefix: prefix}}
+	path := objectPath(processorsResource, p.Metadata.Namespace, p.Metadata.Name) + "/status"
+	err := c.Client.DoWithContentType(http.MethodPatch, path, "application/merge-patch+json", patch, nil)
+	if k8s.IsNotFound(err) {
+		return nil
+	}
+	if err == nil {
+		c.Logger.Info("Reserved the transactional ids of processor", "namespace", p.Metadata.Namespace, "processor", p.Metadata.Name, "prefix", prefix)
+	}
+	return err
+}
+
+func (c *Controller) reconcileStream(ctx context.Context, s stream, addresses map[string]Address) error {
+	finalized := hasFinalizer(s.Metadata.Finalizers)
+	if s.Metadata.DeletionTimestamp == nil {
+		if !finalized {
+			if err := c.patchFinalizers(s, append(s.Metadata.Finalizers, Finalizer)); err != nil {
+				return err
+			}
+		}
+		if address, ok := addresses[s.Metadata.Namespace+"/"+s.Metadata.Name]; ok {
+			return c.reportAddress(s, address)
+		}
+		return nil
+	}
+	if !finalized {
+		return nil
+	}
+	if s.Metadata.Annotations[OrphanAnnotation] == "true" {
+		c.Logger.Warn("Leaving the topic of deleted stream behind", "namespace", s.Metadata.Namespace, "stream", s.Metadata.Name)
+	} else {
+		if err := c.Deprovisioner.DeprovisionStream(ctx, s.Metadata.Namespace, s.Metadata.Name); err != nil {
+			return err
+		}
+		c.Logger.Info("Deprovisioned the topic of deleted stream", "namespace", s.Metadata.Namespace, "stream", s.Metadata.Name)
+	}
+	var finalizers []string
+	for _, finalizer := range s.Metadata.Finalizers {
+		if finalizer != Finalizer {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+	return c.patchFinalizers(s, finalizers)
+}
+
+// patchFinalizers replaces the finalizers of a stream, unless it changed since listed, in which case the next
+// reconciliation sees the change
+func (c *Controller) patchFinalizers(s stream, finalizers []string) error {
+	if finalizers == nil {
+		finalizers = []string{}
+	}
+	patch := map[string]objectMeta{"metadata": {ResourceVersion: s.Metadata.ResourceVersion, Finalizers: finalizers}}
+	path := objectPath(streamsResource, s.Metadata.Namespace, s.Metadata.Name)
+	err := c.Client.DoWithContentType(http.MethodPatch, path, "application/merge-patch+json", patch, nil)
+	if k8s.IsConflict(err) || k8s.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// reportAddress writes the address of a provisioned stream in its status, unless already there
+func (c *Controller) reportAddress(s stream, address Address) error {
+	if s.Status.Address != nil && *s.Status.Address == address {
+		return nil
+	}
+	patch := map[string]streamStatus{"status": {Address: &address}}
+	path := objectPath(streamsResource, s.Metadata.Namespace, s.Metadata.Name) + "/status"
+	err := c.Client.DoWithContentType(http.MethodPatch, path, "application/merge-patch+json", patch, nil)
+	if k8s.IsNotFound(err) {
+		return nil
+	}
+	if err == nil {
+		c.Logger.Info("Reported the address of stream", "namespace", s.Metadata.Namespace, "stream", s.Metadata.Name, "topic", address.Topic)
+	}
+	return err
+}
+
+func hasFinalizer(finalizers []string) bool {
+	for _, finalizer := range finalizers {
+		if finalizer == Finalizer {
+			return true
+		}
+	}
+	return false
+}
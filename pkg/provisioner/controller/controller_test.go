@@ -0,0 +1,324 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/k8s"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/controller"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/controller/controllerfakes"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/workqueue"
+)
+
+var _ = Describe("Stream controller", func() {
+
+	var (
+		server            *httptest.Server
+		streams           string
+		processors        string
+		objects           map[string]string
+		patchStatus       int
+		mu                sync.Mutex
+		patches           map[string]string
+		fakeDeprovisioner *controllerfakes.FakeDeprovisioner
+		streamController  *controller.Controller
+	)
+
+	BeforeEach(func() {
+		streams, processors = `{"items": []}`, `{"items": []}`
+		objects = map[string]string{}
+		patchStatus = http.StatusOK
+		patches = map[string]string{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				switch r.URL.Path {
+				case controller.StreamsPath:
+					_, _ = w.Write([]byte(streams))
+				case controller.ProcessorsPath:
+					_, _ = w.Write([]byte(processors))
+				default:
+					object, ok := objects[r.URL.Path]
+					if !ok {
+						w.WriteHeader(http.StatusNotFound)
+						_, _ = w.Write([]byte(`{"kind": "Status", "message": "not found"}`))
+						return
+					}
+					_, _ = w.Write([]byte(object))
+				}
+			case http.MethodPatch:
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/merge-patch+json"))
+				body, err := io.ReadAll(r.Body)
+				Expect(err).NotTo(HaveOccurred())
+				mu.Lock()
+				patches[r.URL.Path] = string(body)
+				mu.Unlock()
+				w.WriteHeader(patchStatus)
+				_, _ = w.Write([]byte(`{"kind": "Status", "message": "patched"}`))
+			}
+		}))
+		fakeDeprovisioner = &controllerfakes.FakeDeprovisioner{}
+		streamController = &controller.Controller{
+			Client:        &k8s.Client{Host: server.URL, Token: "some-token", HTTPClient: server.Client()},
+			Deprovisioner: fakeDeprovisioner,
+			Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	streamsOf := func(items ...string) string {
+		return fmt.Sprintf(`{"items": [%s]}`, strings.Join(items, ","))
+	}
+
+	finalizersPatched := func(namespace, name string) []string {
+		patch := struct {
+			Metadata struct {
+				ResourceVersion string   `json:"resourceVersion"`
+				Finalizers      []string `json:"finalizers"`
+			} `json:"metadata"`
+		}{}
+		body, ok := patches[fmt.Sprintf("/apis/streaming.projectriff.io/v1alpha1/namespaces/%s/streams/%s", namespace, name)]
+		Expect(ok).To(BeTrue())
+		Expect(json.Unmarshal([]byte(body), &patch)).To(Succeed())
+		Expect(patch.Metadata.ResourceVersion).To(Equal("42"))
+		return patch.Metadata.Finalizers
+	}
+
+	It("adds its finalizer to the streams lacking it", func() {
+		streams = streamsOf(
+			`{"metadata": {"name": "orders", "namespace": "ns", "resourceVersion": "42", "finalizers": ["other"]}}`,
+			`{"metadata": {"name": "payments", "namespace": "ns", "resourceVersion": "42", "finalizers": ["`+controller.Finalizer+`"]}}`,
+		)
+
+		Expect(streamController.Reconcile(context.Background())).To(Succeed())
+
+		Expect(patches).To(HaveLen(1))
+		Expect(finalizersPatched("ns", "orders")).To(Equal([]string{"other", controller.Finalizer}))
+		Expect(fakeDeprovisioner.DeprovisionStreamCallCount()).To(BeZero())
+	})
+
+	It("deprovisions the topics of deleted streams before removing its finalizer", func() {
+		streams = streamsOf(`{"metadata": {"name": "orders", "namespace": "ns", "resourceVersion": "42", "deletionTimestamp": "2026-01-01T00:00:00Z", "finalizers": ["` + controller.Finalizer + `"]}}`)
+
+		Expect(streamController.Reconcile(context.Background())).To(Succeed())
+
+		_, namespace, stream := fakeDeprovisioner.DeprovisionStreamArgsForCall(0)
+		Expect(namespace).To(Equal("ns"))
+		Expect(stream).To(Equal("orders"))
+		Expect(finalizersPatched("ns", "orders")).To(BeEmpty())
+	})
+
+	It("keeps its finalizer on deleted streams whose topic can't be deprovisioned", func() {
+		streams = streamsOf(`{"metadata": {"name": "orders", "namespace": "ns", "resourceVersion": "42", "deletionTimestamp": "2026-01-01T00:00:00Z", "finalizers": ["` + controller.Finalizer + `"]}}`)
+		fakeDeprovisioner.DeprovisionStreamReturns(fmt.Errorf("kafka: broker not available"))
+
+		Expect(streamController.Reconcile(context.Background())).To(MatchError("kafka: broker not available"))
+
+		Expect(patches).To(BeEmpty())
+	})
+
+	It("leaves the topics of deleted streams annotated as orphans behind", func() {
+		streams = streamsOf(`{"metadata": {"name": "orders", "namespace": "ns", "resourceVersion": "42", "deletionTimestamp": "2026-01-01T00:00:00Z", "finalizers": ["` + controller.Finalizer + `", "other"], "annotations": {"` + controller.OrphanAnnotation + `": "true"}}}`)
+
+		Expect(streamController.Reconcile(context.Background())).To(Succeed())
+
+		Expect(fakeDeprovisioner.DeprovisionStreamCallCount()).To(BeZero())
+		Expect(finalizersPatched("ns", "orders")).To(Equal([]string{"other"}))
+	})
+
+	It("leaves deleted streams without its finalizer alone", func() {
+		streams = streamsOf(`{"metadata": {"name": "orders", "namespace": "ns", "resourceVersion": "42", "deletionTimestamp": "2026-01-01T00:00:00Z", "finalizers": ["other"]}}`)
+
+		Expect(streamController.Reconcile(context.Background())).To(Succeed())
+
+		Expect(fakeDeprovisioner.DeprovisionStreamCallCount()).To(BeZero())
+		Expect(patches).To(BeEmpty())
+	})
+
+	It("only handles the streams of its provider when set", func() {
+		streamController.Provider = "kafka"
+		streams = streamsOf(
+			`{"metadata": {"name": "orders", "namespace": "ns", "resourceVersion": "42"}, "spec": {"provider": "kafka"}}`,
+			`{"metadata": {"name": "payments", "namespace": "ns", "resourceVersion": "42"}, "spec": {"provider": "pulsar"}}`,
+		)
+
+		Expect(streamController.Reconcile(context.Background())).To(Succeed())
+
+		Expect(patches).To(HaveLen(1))
+		Expect(finalizersPatched("ns", "orders")).To(Equal([]string{controller.Finalizer}))
+	})
+
+	It("leaves streams changed since listed to the next reconciliation", func() {
+		streams = streamsOf(`{"metadata": {"name": "orders", "namespace": "ns", "resourceVersion": "42"}}`)
+		patchStatus = http.StatusConflict
+
+		Expect(streamController.Reconcile(context.Background())).To(Succeed())
+	})
+
+	Context("when reporting the addresses of streams", func() {
+		var fakeAddresser *controllerfakes.FakeAddresser
+
+		BeforeEach(func() {
+			fakeAddresser = &controllerfakes.FakeAddresser{}
+			fakeAddresser.StreamAddressesReturns(map[string]controller.Address{
+				"ns/orders":   {Topic: "ns_orders", Gateway: "gateway:6565", Partitions: 3},
+				"ns/payments": {Topic: "ns_payments", Gateway: "gateway:6565", Partitions: 1},
+			}, nil)
+			streamController.Addresser = fakeAddresser
+		})
+
+		It("writes the address of provisioned streams in their status", func() {
+			streams = streamsOf(
+				`{"metadata": {"name": "orders", "namespace": "ns", "finalizers": ["`+controller.Finalizer+`"]}}`,
+				`{"metadata": {"name": "payments", "namespace": "ns", "finalizers": ["`+controller.Finalizer+`"]}, "status": {"address": {"topic": "ns_payments", "gateway": "gateway:6565", "partitions": 1}}}`,
+				`{"metadata": {"name": "pending", "namespace": "ns", "finalizers": ["`+controller.Finalizer+`"]}}`,
+			)
+
+			Expect(streamController.Reconcile(context.Background())).To(Succeed())
+
+			Expect(patches).To(HaveLen(1))
+			Expect(patches).To(HaveKeyWithValue("/apis/streaming.projectriff.io/v1alpha1/namespaces/ns/streams/orders/status",
+				MatchJSON(`{"status": {"address": {"topic": "ns_orders", "gateway": "gateway:6565", "partitions": 3}}}`)))
+		})
+
+		It("still finalizes streams when their addresses can't be listed", func() {
+			fakeAddresser.StreamAddressesReturns(nil, fmt.Errorf("kafka: broker not available"))
+			streams = streamsOf(`{"metadata": {"name": "orders", "namespace": "ns", "resourceVersion": "42"}}`)
+
+			Expect(streamController.Reconcile(context.Background())).To(Succeed())
+
+			Expect(finalizersPatched("ns", "orders")).To(Equal([]string{controller.Finalizer}))
+		})
+	})
+
+	Context("when provisioning the streams of processors", func() {
+		var fakeProvisioner *controllerfakes.FakeProvisioner
+
+		BeforeEach(func() {
+			fakeProvisioner = &controllerfakes.FakeProvisioner{}
+			streamController.Provisioner = fakeProvisioner
+		})
+
+		It("provisions the input, output and retry streams of each processor at once", func() {
+			processors = streamsOf(
+				`{"metadata": {"name": "billing", "namespace": "ns"}, "spec": {"inputs": [{"stream": "orders"}], "outputs": [{"stream": "invoices"}], "retries": [{"stream": "orders-retry"}]}}`,
+				`{"metadata": {"name": "deleted", "namespace": "ns", "deletionTimestamp": "2026-01-01T00:00:00Z"}, "spec": {"inputs": [{"stream": "payments"}]}}`,
+			)
+
+			Expect(streamController.Reconcile(context.Background())).To(Succeed())
+
+			Expect(fakeProvisioner.ProvisionStreamsCallCount()).To(Equal(1))
+			_, namespace, provisioned := fakeProvisioner.ProvisionStreamsArgsForCall(0)
+			Expect(namespace).To(Equal("ns"))
+			Expect(provisioned).To(Equal([]string{"orders", "invoices", "orders-retry"}))
+		})
+
+		It("retries the processors whose streams can't be provisioned at the next reconciliation", func() {
+			processors = streamsOf(
+				`{"metadata": {"name": "billing", "namespace": "ns"}, "spec": {"inputs": [{"stream": "orders"}]}}`,
+				`{"metadata": {"name": "shipping", "namespace": "ns"}, "spec": {"inputs": [{"stream": "parcels"}]}}`,
+			)
+			fakeProvisioner.ProvisionStreamsReturnsOnCall(0, fmt.Errorf("topic quota exceeded"))
+
+			Expect(streamController.Reconcile(context.Background())).To(MatchError("topic quota exceeded"))
+
+			Expect(fakeProvisioner.ProvisionStreamsCallCount()).To(Equal(2))
+		})
+
+		Context("when reserving transactional ids", func() {
+			var fakeReserver *controllerfakes.FakeReserver
+
+			BeforeEach(func() {
+				fakeReserver = &controllerfakes.FakeReserver{}
+				fakeReserver.ReserveTransactionalIDsCalls(func(_ context.Context, namespace, processor string) (string, error) {
+					return namespace + "_" + processor + "_", nil
+				})
+				streamController.Reserver = fakeReserver
+			})
+
+			It("reserves the transactional ids of the processors needing transactions, reporting their prefix", func() {
+				processors = streamsOf(
+					`{"metadata": {"name": "billing", "namespace": "ns", "annotations": {"`+controller.TransactionalAnnotation+`": "true"}}, "spec": {"inputs": [{"stream": "orders"}]}}`,
+					`{"metadata": {"name": "shipping", "namespace": "ns", "annotations": {"`+controller.TransactionalAnnotation+`": "true"}}, "status": {"transactionalIdPrefix": "ns_shipping_"}}`,
+					`{"metadata": {"name": "audit", "namespace": "ns"}, "spec": {"inputs": [{"stream": "orders"}]}}`,
+				)
+
+				Expect(streamController.Reconcile(context.Background())).To(Succeed())
+
+				Expect(fakeReserver.ReserveTransactionalIDsCallCount()).To(Equal(2))
+				_, namespace, processor := fakeReserver.ReserveTransactionalIDsArgsForCall(0)
+				Expect([]string{namespace, processor}).To(Equal([]string{"ns", "billing"}))
+				Expect(patches).To(HaveLen(1))
+				Expect(patches).To(HaveKeyWithValue("/apis/streaming.projectriff.io/v1alpha1/namespaces/ns/processors/billing/status",
+					MatchJSON(`{"status": {"transactionalIdPrefix": "ns_billing_"}}`)))
+			})
+
+			It("reserves no transactional ids for processors whose streams can't be provisioned", func() {
+				processors = streamsOf(`{"metadata": {"name": "billing", "namespace": "ns", "annotations": {"` + controller.TransactionalAnnotation + `": "true"}}, "spec": {"inputs": [{"stream": "orders"}]}}`)
+				fakeProvisioner.ProvisionStreamsReturns(fmt.Errorf("topic quota exceeded"))
+
+				Expect(streamController.Reconcile(context.Background())).To(MatchError("topic quota exceeded"))
+
+				Expect(fakeReserver.ReserveTransactionalIDsCallCount()).To(BeZero())
+			})
+		})
+	})
+
+	Context("when running", func() {
+		var (
+			ctx    context.Context
+			cancel context.CancelFunc
+		)
+
+		BeforeEach(func() {
+			ctx, cancel = context.WithCancel(context.Background())
+			streamController.RateLimiter = workqueue.NewRateLimiter(10*time.Millisecond, time.Second, 0, 0)
+		})
+
+		AfterEach(func() {
+			cancel()
+		})
+
+		It("retries the streams failing with a backoff rather than at each interval", func() {
+			deleted := `{"metadata": {"name": "orders", "namespace": "ns", "resourceVersion": "42", "deletionTimestamp": "2026-01-01T00:00:00Z", "finalizers": ["` + controller.Finalizer + `"]}}`
+			streams = streamsOf(deleted)
+			objects["/apis/streaming.projectriff.io/v1alpha1/namespaces/ns/streams/orders"] = deleted
+			fakeDeprovisioner.DeprovisionStreamReturnsOnCall(0, fmt.Errorf("kafka: broker not available"))
+			fakeDeprovisioner.DeprovisionStreamReturnsOnCall(1, fmt.Errorf("kafka: broker not available"))
+
+			go streamController.Run(ctx, time.Hour)
+
+			Eventually(fakeDeprovisioner.DeprovisionStreamCallCount).Should(Equal(3))
+			Eventually(func() int {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(patches)
+			}).Should(Equal(1))
+		})
+
+		It("forgets the streams deleted in the meantime", func() {
+			streams = streamsOf(`{"metadata": {"name": "orders", "namespace": "ns", "resourceVersion": "42"}}`)
+
+			go streamController.Run(ctx, time.Hour)
+
+			Consistently(func() int {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(patches)
+			}, 100*time.Millisecond).Should(BeZero())
+		})
+	})
+})
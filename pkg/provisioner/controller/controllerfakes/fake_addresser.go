@@ -0,0 +1,115 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package controllerfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/controller"
+)
+
+type FakeAddresser struct {
+	StreamAddressesStub        func(context.Context) (map[string]controller.Address, error)
+	streamAddressesMutex       sync.RWMutex
+	streamAddressesArgsForCall []struct {
+		arg1 context.Context
+	}
+	streamAddressesReturns struct {
+		result1 map[string]controller.Address
+		result2 error
+	}
+	streamAddressesReturnsOnCall map[int]struct {
+		result1 map[string]controller.Address
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeAddresser) StreamAddresses(arg1 context.Context) (map[string]controller.Address, error) {
+	fake.streamAddressesMutex.Lock()
+	ret, specificReturn := fake.streamAddressesReturnsOnCall[len(fake.streamAddressesArgsForCall)]
+	fake.streamAddressesArgsForCall = append(fake.streamAddressesArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.StreamAddressesStub
+	fakeReturns := fake.streamAddressesReturns
+	fake.recordInvocation("StreamAddresses", []interface{}{arg1})
+	fake.streamAddressesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAddresser) StreamAddressesCallCount() int {
+	fake.streamAddressesMutex.RLock()
+	defer fake.streamAddressesMutex.RUnlock()
+	return len(fake.streamAddressesArgsForCall)
+}
+
+func (fake *FakeAddresser) StreamAddressesCalls(stub func(context.Context) (map[string]controller.Address, error)) {
+	fake.streamAddressesMutex.Lock()
+	defer fake.streamAddressesMutex.Unlock()
+	fake.StreamAddressesStub = stub
+}
+
+func (fake *FakeAddresser) StreamAddressesArgsForCall(i int) context.Context {
+	fake.streamAddressesMutex.RLock()
+	defer fake.streamAddressesMutex.RUnlock()
+	argsForCall := fake.streamAddressesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAddresser) StreamAddressesReturns(result1 map[string]controller.Address, result2 error) {
+	fake.streamAddressesMutex.Lock()
+	defer fake.streamAddressesMutex.Unlock()
+	fake.StreamAddressesStub = nil
+	fake.streamAddressesReturns = struct {
+		result1 map[string]controller.Address
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAddresser) StreamAddressesReturnsOnCall(i int, result1 map[string]controller.Address, result2 error) {
+	fake.streamAddressesMutex.Lock()
+	defer fake.streamAddressesMutex.Unlock()
+	fake.StreamAddressesStub = nil
+	if fake.streamAddressesReturnsOnCall == nil {
+		fake.streamAddressesReturnsOnCall = make(map[int]struct {
+			result1 map[string]controller.Address
+			result2 error
+		})
+	}
+	fake.streamAddressesReturnsOnCall[i] = struct {
+		result1 map[string]controller.Address
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAddresser) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeAddresser) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ controller.Addresser = new(FakeAddresser)
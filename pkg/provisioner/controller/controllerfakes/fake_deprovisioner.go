@@ -0,0 +1,114 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package controllerfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/controller"
+)
+
+type FakeDeprovisioner struct {
+	DeprovisionStreamStub        func(context.Context, string, string) error
+	deprovisionStreamMutex       sync.RWMutex
+	deprovisionStreamArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	deprovisionStreamReturns struct {
+		result1 error
+	}
+	deprovisionStreamReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeDeprovisioner) DeprovisionStream(arg1 context.Context, arg2 string, arg3 string) error {
+	fake.deprovisionStreamMutex.Lock()
+	ret, specificReturn := fake.deprovisionStreamReturnsOnCall[len(fake.deprovisionStreamArgsForCall)]
+	fake.deprovisionStreamArgsForCall = append(fake.deprovisionStreamArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.DeprovisionStreamStub
+	fakeReturns := fake.deprovisionStreamReturns
+	fake.recordInvocation("DeprovisionStream", []interface{}{arg1, arg2, arg3})
+	fake.deprovisionStreamMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDeprovisioner) DeprovisionStreamCallCount() int {
+	fake.deprovisionStreamMutex.RLock()
+	defer fake.deprovisionStreamMutex.RUnlock()
+	return len(fake.deprovisionStreamArgsForCall)
+}
+
+func (fake *FakeDeprovisioner) DeprovisionStreamCalls(stub func(context.Context, string, string) error) {
+	fake.deprovisionStreamMutex.Lock()
+	defer fake.deprovisionStreamMutex.Unlock()
+	fake.DeprovisionStreamStub = stub
+}
+
+func (fake *FakeDeprovisioner) DeprovisionStreamArgsForCall(i int) (context.Context, string, string) {
+	fake.deprovisionStreamMutex.RLock()
+	defer fake.deprovisionStreamMutex.RUnlock()
+	argsForCall := fake.deprovisionStreamArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDeprovisioner) DeprovisionStreamReturns(result1 error) {
+	fake.deprovisionStreamMutex.Lock()
+	defer fake.deprovisionStreamMutex.Unlock()
+	fake.DeprovisionStreamStub = nil
+	fake.deprovisionStreamReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDeprovisioner) DeprovisionStreamReturnsOnCall(i int, result1 error) {
+	fake.deprovisionStreamMutex.Lock()
+	defer fake.deprovisionStreamMutex.Unlock()
+	fake.DeprovisionStreamStub = nil
+	if fake.deprovisionStreamReturnsOnCall == nil {
+		fake.deprovisionStreamReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deprovisionStreamReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDeprovisioner) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeDeprovisioner) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ controller.Deprovisioner = new(FakeDeprovisioner)
@@ -0,0 +1,119 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package controllerfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/controller"
+)
+
+type FakeProvisioner struct {
+	ProvisionStreamsStub        func(context.Context, string, []string) error
+	provisionStreamsMutex       sync.RWMutex
+	provisionStreamsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 []string
+	}
+	provisionStreamsReturns struct {
+		result1 error
+	}
+	provisionStreamsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeProvisioner) ProvisionStreams(arg1 context.Context, arg2 string, arg3 []string) error {
+	var arg3Copy []string
+	if arg3 != nil {
+		arg3Copy = make([]string, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.provisionStreamsMutex.Lock()
+	ret, specificReturn := fake.provisionStreamsReturnsOnCall[len(fake.provisionStreamsArgsForCall)]
+	fake.provisionStreamsArgsForCall = append(fake.provisionStreamsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 []string
+	}{arg1, arg2, arg3Copy})
+	stub := fake.ProvisionStreamsStub
+	fakeReturns := fake.provisionStreamsReturns
+	fake.recordInvocation("ProvisionStreams", []interface{}{arg1, arg2, arg3Copy})
+	fake.provisionStreamsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeProvisioner) ProvisionStreamsCallCount() int {
+	fake.provisionStreamsMutex.RLock()
+	defer fake.provisionStreamsMutex.RUnlock()
+	return len(fake.provisionStreamsArgsForCall)
+}
+
+func (fake *FakeProvisioner) ProvisionStreamsCalls(stub func(context.Context, string, []string) error) {
+	fake.provisionStreamsMutex.Lock()
+	defer fake.provisionStreamsMutex.Unlock()
+	fake.ProvisionStreamsStub = stub
+}
+
+func (fake *FakeProvisioner) ProvisionStreamsArgsForCall(i int) (context.Context, string, []string) {
+	fake.provisionStreamsMutex.RLock()
+	defer fake.provisionStreamsMutex.RUnlock()
+	argsForCall := fake.provisionStreamsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeProvisioner) ProvisionStreamsReturns(result1 error) {
+	fake.provisionStreamsMutex.Lock()
+	defer fake.provisionStreamsMutex.Unlock()
+	fake.ProvisionStreamsStub = nil
+	fake.provisionStreamsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProvisioner) ProvisionStreamsReturnsOnCall(i int, result1 error) {
+	fake.provisionStreamsMutex.Lock()
+	defer fake.provisionStreamsMutex.Unlock()
+	fake.ProvisionStreamsStub = nil
+	if fake.provisionStreamsReturnsOnCall == nil {
+		fake.provisionStreamsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.provisionStreamsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProvisioner) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeProvisioner) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ controller.Provisioner = new(FakeProvisioner)
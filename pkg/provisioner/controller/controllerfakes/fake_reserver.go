@@ -0,0 +1,119 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package controllerfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/controller"
+)
+
+type FakeReserver struct {
+	ReserveTransactionalIDsStub        func(context.Context, string, string) (string, error)
+	reserveTransactionalIDsMutex       sync.RWMutex
+	reserveTransactionalIDsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	reserveTransactionalIDsReturns struct {
+		result1 string
+		result2 error
+	}
+	reserveTransactionalIDsReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeReserver) ReserveTransactionalIDs(arg1 context.Context, arg2 string, arg3 string) (string, error) {
+	fake.reserveTransactionalIDsMutex.Lock()
+	ret, specificReturn := fake.reserveTransactionalIDsReturnsOnCall[len(fake.reserveTransactionalIDsArgsForCall)]
+	fake.reserveTransactionalIDsArgsForCall = append(fake.reserveTransactionalIDsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.ReserveTransactionalIDsStub
+	fakeReturns := fake.reserveTransactionalIDsReturns
+	fake.recordInvocation("ReserveTransactionalIDs", []interface{}{arg1, arg2, arg3})
+	fake.reserveTransactionalIDsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReserver) ReserveTransactionalIDsCallCount() int {
+	fake.reserveTransactionalIDsMutex.RLock()
+	defer fake.reserveTransactionalIDsMutex.RUnlock()
+	return len(fake.reserveTransactionalIDsArgsForCall)
+}
+
+func (fake *FakeReserver) ReserveTransactionalIDsCalls(stub func(context.Context, string, string) (string, error)) {
+	fake.reserveTransactionalIDsMutex.Lock()
+	defer fake.reserveTransactionalIDsMutex.Unlock()
+	fake.ReserveTransactionalIDsStub = stub
+}
+
+func (fake *FakeReserver) ReserveTransactionalIDsArgsForCall(i int) (context.Context, string, string) {
+	fake.reserveTransactionalIDsMutex.RLock()
+	defer fake.reserveTransactionalIDsMutex.RUnlock()
+	argsForCall := fake.reserveTransactionalIDsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeReserver) ReserveTransactionalIDsReturns(result1 string, result2 error) {
+	fake.reserveTransactionalIDsMutex.Lock()
+	defer fake.reserveTransactionalIDsMutex.Unlock()
+	fake.ReserveTransactionalIDsStub = nil
+	fake.reserveTransactionalIDsReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReserver) ReserveTransactionalIDsReturnsOnCall(i int, result1 string, result2 error) {
+	fake.reserveTransactionalIDsMutex.Lock()
+	defer fake.reserveTransactionalIDsMutex.Unlock()
+	fake.ReserveTransactionalIDsStub = nil
+	if fake.reserveTransactionalIDsReturnsOnCall == nil {
+		fake.reserveTransactionalIDsReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.reserveTransactionalIDsReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReserver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeReserver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ controller.Reserver = new(FakeReserver)
@@ -0,0 +1,207 @@
+// Package election elects the provisioner replica running the loops that should only run once per cluster, such as
+// the stream controller, holding a kubernetes Lease while it leads
+package election
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/projectriff/kafka-provisioner/pkg/k8s"
+)
+
+// microTime is the layout of the times of leases
+const microTime = "2006-01-02T15:04:05.000000Z07:00"
+
+type lease struct {
+	APIVersion string    `json:"apiVersion"`
+	Kind       string    `json:"kind"`
+	Metadata   leaseMeta `json:"metadata"`
+	Spec       leaseSpec `json:"spec"`
+}
+
+type leaseMeta struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int    `json:"leaseTransitions"`
+}
+
+// Elector takes the lead by holding a Lease, which it renews while leading. Other replicas take over once the lease
+// isn't renewed for its duration.
+type Elector struct {
+	Client    *k8s.Client
+	Namespace string
+	Name      string
+	// Identity tells replicas apart, e.g. the name of their pod
+	Identity string
+	// LeaseDuration is how long other replicas wait for the lease to be renewed before taking over
+	LeaseDuration time.Duration
+	// RenewDeadline is how long the leader keeps trying to renew the lease before stepping down, shorter than
+	// LeaseDuration so that it steps down before another replica takes over
+	RenewDeadline time.Duration
+	// RetryPeriod is how often the lease is renewed, or tried to be acquired
+	RetryPeriod time.Duration
+	Logger      *slog.Logger
+
+	now func() time.Time
+}
+
+// New creates an elector holding the lease of a namespace with the timings of kubernetes controllers
+func New(client *k8s.Client, namespace, name, identity string, logger *slog.Logger) *Elector {
+	return &Elector{
+		Client:        client,
+		Namespace:     namespace,
+		Name:          name,
+		Identity:      identity,
+		LeaseDuration: 15 * time.Second,
+		RenewDeadline: 10 * time.Second,
+		RetryPeriod:   2 * time.Second,
+		Logger:        logger,
+		now:           time.Now,
+	}
+}
+
+// Run calls lead each time the replica takes the lead, canceling the context it is given once it steps down, until
+// ctx is done, the lease being released then
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	for {
+		if !e.acquire(ctx) {
+			return
+		}
+		e.Logger.Info("Leading", "lease", e.Name, "identity", e.Identity)
+		leadCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			lead(leadCtx)
+		}()
+		e.renew(ctx)
+		cancel()
+		<-done
+		if ctx.Err() != nil {
+			e.release()
+			return
+		}
+		e.Logger.Warn("Stepped down, the lease couldn't be renewed", "lease", e.Name, "identity", e.Identity)
+	}
+}
+
+// acquire tries to acquire the lease every RetryPeriod until it does or ctx is done
+func (e *Elector) acquire(ctx context.Context) bool {
+	ticker := time.NewTicker(e.RetryPeriod)
+	defer ticker.Stop()
+	for {
+		acquired, err := e.TryAcquireOrRenew()
+		if err != nil {
+			e.Logger.Error("Error acquiring lease", "lease", e.Name, "error", err)
+		}
+		if acquired {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// renew renews the lease every RetryPeriod until ctx is done, or it couldn't for RenewDeadline
+func (e *Elector) renew(ctx context.Context) {
+	ticker := time.NewTicker(e.RetryPeriod)
+	defer ticker.Stop()
+	renewed := e.now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		ok, err := e.TryAcquireOrRenew()
+		if err != nil {
+			e.Logger.Error("Error renewing lease", "lease", e.Name, "error", err)
+		}
+		if ok {
+			renewed = e.now()
+		} else if err == nil || e.now().Sub(renewed) > e.RenewDeadline {
+			// the lease being held by another replica, or unknown for too long, this one must not lead anymore
+			return
+		}
+	}
+}
+
+// TryAcquireOrRenew takes the lease when it is free or expired, or renews it when held, telling whether the
+// replica holds it afterwards
+func (e *Elector) TryAcquireOrRenew() (bool, error) {
+	now := e.now()
+	current := lease{}
+	err := e.Client.Do(http.MethodGet, e.path(), nil, &current)
+	if k8s.IsNotFound(err) {
+		created := e.lease(leaseSpec{AcquireTime: now.Format(microTime)}, now)
+		err := e.Client.Do(http.MethodPost, fmt.Sprintf("/apis/coordination.k8s.io/v1/namespaces/%s/leases", e.Namespace), created, nil)
+		if k8s.IsConflict(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+	spec := current.Spec
+	if spec.HolderIdentity != e.Identity && spec.HolderIdentity != "" {
+		renewTime, err := time.Parse(microTime, spec.RenewTime)
+		expiry := time.Duration(spec.LeaseDurationSeconds) * time.Second
+		if err == nil && now.Before(renewTime.Add(expiry)) {
+			return false, nil
+		}
+	}
+	if spec.HolderIdentity != e.Identity {
+		spec.AcquireTime = now.Format(microTime)
+		spec.LeaseTransitions++
+	}
+	updated := e.lease(spec, now)
+	updated.Metadata.ResourceVersion = current.Metadata.ResourceVersion
+	err = e.Client.Do(http.MethodPut, e.path(), updated, nil)
+	if k8s.IsConflict(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// release lets other replicas take the lead right away
+func (e *Elector) release() {
+	current := lease{}
+	if err := e.Client.Do(http.MethodGet, e.path(), nil, &current); err != nil || current.Spec.HolderIdentity != e.Identity {
+		return
+	}
+	current.Spec.HolderIdentity = ""
+	if err := e.Client.Do(http.MethodPut, e.path(), current, nil); err != nil {
+		e.Logger.Warn("Error releasing lease", "lease", e.Name, "error", err)
+	}
+}
+
+func (e *Elector) lease(spec leaseSpec, now time.Time) lease {
+	spec.HolderIdentity = e.Identity
+	spec.LeaseDurationSeconds = int(e.LeaseDuration / time.Second)
+	spec.RenewTime = now.Format(microTime)
+	return lease{
+		APIVersion: "coordination.k8s.io/v1",
+		Kind:       "Lease",
+		Metadata:   leaseMeta{Name: e.Name, Namespace: e.Namespace},
+		Spec:       spec,
+	}
+}
+
+func (e *Elector) path() string {
+	return fmt.Sprintf("/apis/coordination.k8s.io/v1/namespaces/%s/leases/%s", e.Namespace, e.Name)
+}
@@ -0,0 +1,13 @@
+package election_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestElection(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Election Suite")
+}
@@ -0,0 +1,185 @@
+package election_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/k8s"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/election"
+)
+
+const leasePath = "/apis/coordination.k8s.io/v1/namespaces/riff-system/leases/kafka-provisioner"
+
+var _ = Describe("Leader election", func() {
+
+	var (
+		server    *httptest.Server
+		mu        sync.Mutex
+		lease     map[string]interface{}
+		version   int
+		putStatus int
+		elector   *election.Elector
+	)
+
+	spec := func() map[string]interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		if lease == nil {
+			return nil
+		}
+		return lease["spec"].(map[string]interface{})
+	}
+
+	holdLease := func(holder string, renewed time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		version++
+		lease = map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "kafka-provisioner", "resourceVersion": strconv.Itoa(version)},
+			"spec": map[string]interface{}{
+				"holderIdentity":       holder,
+				"leaseDurationSeconds": float64(15),
+				"renewTime":            renewed.Format("2006-01-02T15:04:05.000000Z07:00"),
+				"leaseTransitions":     float64(0),
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		lease, version, putStatus = nil, 0, http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			var body map[string]interface{}
+			if r.Body != nil {
+				_ = json.NewDecoder(r.Body).Decode(&body)
+			}
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == leasePath:
+				if lease == nil {
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"kind": "Status", "message": "not found"}`))
+					return
+				}
+				_ = json.NewEncoder(w).Encode(lease)
+			case r.Method == http.MethodPost && r.URL.Path+"/kafka-provisioner" == leasePath:
+				if lease != nil {
+					w.WriteHeader(http.StatusConflict)
+					_, _ = w.Write([]byte(`{"kind": "Status", "message": "already exists"}`))
+					return
+				}
+				version++
+				body["metadata"].(map[string]interface{})["resourceVersion"] = strconv.Itoa(version)
+				lease = body
+				_ = json.NewEncoder(w).Encode(lease)
+			case r.Method == http.MethodPut && r.URL.Path == leasePath:
+				current := lease["metadata"].(map[string]interface{})["resourceVersion"]
+				if putStatus != http.StatusOK || body["metadata"].(map[string]interface{})["resourceVersion"] != current {
+					w.WriteHeader(http.StatusConflict)
+					_, _ = w.Write([]byte(`{"kind": "Status", "message": "the object has been modified"}`))
+					return
+				}
+				version++
+				body["metadata"].(map[string]interface{})["resourceVersion"] = strconv.Itoa(version)
+				lease = body
+				_ = json.NewEncoder(w).Encode(lease)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		}))
+		elector = election.New(&k8s.Client{Host: server.URL, Token: "some-token", HTTPClient: server.Client()},
+			"riff-system", "kafka-provisioner", "replica-1", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("creates the lease when there is none", func() {
+		Expect(elector.TryAcquireOrRenew()).To(BeTrue())
+
+		Expect(spec()).To(HaveKeyWithValue("holderIdentity", "replica-1"))
+		Expect(spec()).To(HaveKeyWithValue("leaseDurationSeconds", float64(15)))
+	})
+
+	It("doesn't take the lease held by another replica renewing it", func() {
+		holdLease("replica-2", time.Now())
+
+		Expect(elector.TryAcquireOrRenew()).To(BeFalse())
+
+		Expect(spec()).To(HaveKeyWithValue("holderIdentity", "replica-2"))
+	})
+
+	It("takes over the lease another replica stopped renewing", func() {
+		holdLease("replica-2", time.Now().Add(-time.Minute))
+
+		Expect(elector.TryAcquireOrRenew()).To(BeTrue())
+
+		Expect(spec()).To(HaveKeyWithValue("holderIdentity", "replica-1"))
+		Expect(spec()).To(HaveKeyWithValue("leaseTransitions", float64(1)))
+	})
+
+	It("renews the lease it holds", func() {
+		renewed := time.Now().Add(-5 * time.Second)
+		holdLease("replica-1", renewed)
+
+		Expect(elector.TryAcquireOrRenew()).To(BeTrue())
+
+		renewTime, err := time.Parse("2006-01-02T15:04:05.000000Z07:00", spec()["renewTime"].(string))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(renewTime).To(BeTemporally(">", renewed))
+		Expect(spec()).To(HaveKeyWithValue("leaseTransitions", float64(0)))
+	})
+
+	It("doesn't take the lease another replica took in the meantime", func() {
+		holdLease("replica-2", time.Now().Add(-time.Minute))
+		putStatus = http.StatusConflict
+
+		Expect(elector.TryAcquireOrRenew()).To(BeFalse())
+	})
+
+	It("leads once it holds the lease, and releases it once done", func() {
+		elector.RetryPeriod = 10 * time.Millisecond
+		ctx, cancel := context.WithCancel(context.Background())
+		leading := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			elector.Run(ctx, func(ctx context.Context) {
+				close(leading)
+				<-ctx.Done()
+			})
+		}()
+
+		Eventually(leading).Should(BeClosed())
+		cancel()
+		Eventually(done).Should(BeClosed())
+		Expect(spec()).To(HaveKeyWithValue("holderIdentity", ""))
+	})
+
+	It("steps down once another replica took the lease", func() {
+		elector.RetryPeriod = 10 * time.Millisecond
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		leads := make(chan context.Context, 2)
+		go elector.Run(ctx, func(ctx context.Context) {
+			leads <- ctx
+			<-ctx.Done()
+		})
+
+		var lead context.Context
+		Eventually(leads).Should(Receive(&lead))
+		holdLease("replica-2", time.Now())
+		Eventually(lead.Done()).Should(BeClosed())
+		Consistently(leads, 50*time.Millisecond).ShouldNot(Receive())
+	})
+})
@@ -0,0 +1,180 @@
+// Package events publishes CloudEvents telling the lifecycle of streams, provisioned, altered or deleted, to an HTTP
+// sink or a Kafka topic, so that other automation can react to it
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/cloudevents"
+)
+
+// The types of the events of streams
+const (
+	StreamProvisioned = "io.projectriff.stream.provisioned"
+	StreamAltered     = "io.projectriff.stream.altered"
+	StreamDeleted     = "io.projectriff.stream.deleted"
+)
+
+// Stream is the data of the events of a stream
+type Stream struct {
+	Namespace         string             `json:"namespace"`
+	Stream            string             `json:"stream"`
+	Topic             string             `json:"topic"`
+	Partitions        int32              `json:"partitions,omitempty"`
+	ReplicationFactor int16              `json:"replicationFactor,omitempty"`
+	Config            map[string]*string `json:"config,omitempty"`
+	// Archived tells deleted streams whose topic is archived rather than deleted right away
+	Archived bool `json:"archived,omitempty"`
+}
+
+// Sink receives events
+type Sink interface {
+	Send(ctx context.Context, event *cloudevents.Event) error
+}
+
+// HTTPSink posts events in structured mode to a URL, e.g. a knative broker
+type HTTPSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *HTTPSink) Send(ctx context.Context, event *cloudevents.Event) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(event.Structured()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", cloudevents.ContentType)
+	response, err := s.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("event sink %q returned status %d", s.URL, response.StatusCode)
+	}
+	return nil
+}
+
+// KafkaSink produces events in binary mode to a topic, keyed by the topic of their stream so that the events of a
+// stream are ordered
+type KafkaSink struct {
+	Topic       string
+	NewProducer func() (sarama.SyncProducer, error)
+
+	producer sarama.SyncProducer
+}
+
+func (s *KafkaSink) Send(_ context.Context, event *cloudevents.Event) error {
+	if s.producer == nil {
+		producer, err := s.NewProducer()
+		if err != nil {
+			return err
+		}
+		s.producer = producer
+	}
+	message := &sarama.ProducerMessage{Topic: s.Topic, Key: sarama.ByteEncoder(event.Key()), Value: sarama.ByteEncoder(event.Data)}
+	for name, value := range event.KafkaHeaders() {
+		message.Headers = append(message.Headers, sarama.RecordHeader{Key: []byte(name), Value: value})
+	}
+	message.Headers = append(message.Headers, sarama.RecordHeader{Key: []byte("content-type"), Value: []byte(event.ContentType())})
+	if _, _, err := s.producer.SendMessage(message); err != nil {
+		// the next event connects again
+		_ = s.producer.Close()
+		s.producer = nil
+		return err
+	}
+	return nil
+}
+
+// Publisher queues events for a sink, so that provisioning requests don't wait on it. Events failing to be sent are
+// retried until they are, those published while the queue is full being dropped.
+type Publisher struct {
+	Sink Sink
+	// Source is the source attribute of the events
+	Source string
+	// RetryInterval is how long to wait before sending an event again
+	RetryInterval time.Duration
+	Logger        *slog.Logger
+
+	queue chan *cloudevents.Event
+}
+
+// NewPublisher creates a publisher queuing up to size events
+func NewPublisher(sink Sink, source string, size int, logger *slog.Logger) *Publisher {
+	return &Publisher{Sink: sink, Source: source, RetryInterval: 5 * time.Second, Logger: logger, queue: make(chan *cloudevents.Event, size)}
+}
+
+// Publish queues an event about a stream
+func (p *Publisher) Publish(eventType string, stream Stream) {
+	event, err := p.event(eventType, stream)
+	if err != nil {
+		p.Logger.Error("Error creating event", "type", eventType, "topic", stream.Topic, "error", err)
+		return
+	}
+	select {
+	case p.queue <- event:
+	default:
+		p.Logger.Warn("Dropping event, too many are waiting to be sent", "type", eventType, "topic", stream.Topic)
+	}
+}
+
+func (p *Publisher) event(eventType string, stream Stream) (*cloudevents.Event, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(stream)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudevents.Event{
+		Attributes: map[string]string{
+			"specversion":     "1.0",
+			"id":              hex.EncodeToString(id),
+			"source":          p.Source,
+			"type":            eventType,
+			"subject":         stream.Topic,
+			"time":            time.Now().UTC().Format(time.RFC3339Nano),
+			"datacontenttype": "application/json",
+			"partitionkey":    stream.Topic,
+		},
+		Data: data,
+	}, nil
+}
+
+// Run sends the events queued, in order, until ctx is done
+func (p *Publisher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-p.queue:
+			p.send(ctx, event)
+		}
+	}
+}
+
+func (p *Publisher) send(ctx context.Context, event *cloudevents.Event) {
+	for {
+		err := p.Sink.Send(ctx, event)
+		if err == nil {
+			p.Logger.Debug("Sent event", "type", event.Attributes["type"], "topic", event.Attributes["subject"])
+			return
+		}
+		p.Logger.Error("Error sending event, retrying", "type", event.Attributes["type"], "topic", event.Attributes["subject"], "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.RetryInterval):
+		}
+	}
+}
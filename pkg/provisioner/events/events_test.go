@@ -0,0 +1,170 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/gateway/cloudevents"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/events"
+)
+
+// sinkFunc sends events by calling itself
+type sinkFunc func(ctx context.Context, event *cloudevents.Event) error
+
+func (f sinkFunc) Send(ctx context.Context, event *cloudevents.Event) error {
+	return f(ctx, event)
+}
+
+var _ = Describe("Events", func() {
+
+	var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	Context("publishing events", func() {
+		var (
+			mu        sync.Mutex
+			sent      []*cloudevents.Event
+			failures  int
+			publisher *events.Publisher
+			ctx       context.Context
+			cancel    context.CancelFunc
+		)
+
+		sentEvents := func() []*cloudevents.Event {
+			mu.Lock()
+			defer mu.Unlock()
+			return sent
+		}
+
+		BeforeEach(func() {
+			sent, failures = nil, 0
+			publisher = events.NewPublisher(sinkFunc(func(ctx context.Context, event *cloudevents.Event) error {
+				mu.Lock()
+				defer mu.Unlock()
+				if failures > 0 {
+					failures--
+					return fmt.Errorf("sink unavailable")
+				}
+				sent = append(sent, event)
+				return nil
+			}), "/apis/streaming.projectriff.io/kafka-provisioner", 2, logger)
+			publisher.RetryInterval = 10 * time.Millisecond
+			ctx, cancel = context.WithCancel(context.Background())
+		})
+
+		AfterEach(func() {
+			cancel()
+		})
+
+		It("sends CloudEvents about streams", func() {
+			go publisher.Run(ctx)
+
+			publisher.Publish(events.StreamProvisioned, events.Stream{Namespace: "ns", Stream: "orders", Topic: "ns_orders", Partitions: 3})
+
+			Eventually(sentEvents).Should(HaveLen(1))
+			event := sentEvents()[0]
+			Expect(event.Validate()).To(Succeed())
+			Expect(event.Attributes).To(HaveKeyWithValue("type", events.StreamProvisioned))
+			Expect(event.Attributes).To(HaveKeyWithValue("source", "/apis/streaming.projectriff.io/kafka-provisioner"))
+			Expect(event.Attributes).To(HaveKeyWithValue("subject", "ns_orders"))
+			Expect(event.Key()).To(Equal([]byte("ns_orders")))
+			Expect(event.Data).To(MatchJSON(`{"namespace": "ns", "stream": "orders", "topic": "ns_orders", "partitions": 3}`))
+		})
+
+		It("retries the events that couldn't be sent, in order", func() {
+			failures = 2
+			go publisher.Run(ctx)
+
+			publisher.Publish(events.StreamProvisioned, events.Stream{Topic: "ns_orders"})
+			publisher.Publish(events.StreamDeleted, events.Stream{Topic: "ns_orders"})
+
+			Eventually(sentEvents).Should(HaveLen(2))
+			Expect(sentEvents()[0].Attributes["type"]).To(Equal(events.StreamProvisioned))
+			Expect(sentEvents()[1].Attributes["type"]).To(Equal(events.StreamDeleted))
+		})
+
+		It("drops the events published while the queue is full", func() {
+			for i := 0; i < 3; i++ {
+				publisher.Publish(events.StreamAltered, events.Stream{Topic: fmt.Sprintf("ns_stream-%d", i)})
+			}
+			go publisher.Run(ctx)
+
+			Eventually(sentEvents).Should(HaveLen(2))
+			Consistently(sentEvents, 50*time.Millisecond).Should(HaveLen(2))
+		})
+	})
+
+	Context("sending events over HTTP", func() {
+		var event *cloudevents.Event
+
+		BeforeEach(func() {
+			event = &cloudevents.Event{
+				Attributes: map[string]string{"specversion": "1.0", "id": "1", "source": "provisioner", "type": events.StreamDeleted, "datacontenttype": "application/json"},
+				Data:       []byte(`{"topic": "ns_orders"}`),
+			}
+		})
+
+		It("posts events in structured mode", func() {
+			var received map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Header.Get("Content-Type")).To(Equal(cloudevents.ContentType))
+				Expect(json.NewDecoder(r.Body).Decode(&received)).To(Succeed())
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer server.Close()
+
+			sink := &events.HTTPSink{URL: server.URL, Client: server.Client()}
+
+			Expect(sink.Send(context.Background(), event)).To(Succeed())
+			Expect(received).To(HaveKeyWithValue("type", events.StreamDeleted))
+			Expect(received).To(HaveKeyWithValue("data", map[string]interface{}{"topic": "ns_orders"}))
+		})
+
+		It("fails when the sink refuses events", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			sink := &events.HTTPSink{URL: server.URL, Client: server.Client()}
+
+			Expect(sink.Send(context.Background(), event)).To(MatchError(ContainSubstring("returned status 503")))
+		})
+	})
+
+	Context("sending events to Kafka", func() {
+		It("produces events in binary mode, keyed by the topic of their stream", func() {
+			producer := mocks.NewSyncProducer(GinkgoT(), nil)
+			var message *sarama.ProducerMessage
+			producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(m *sarama.ProducerMessage) error {
+				message = m
+				return nil
+			})
+			sink := &events.KafkaSink{Topic: "stream-events", NewProducer: func() (sarama.SyncProducer, error) {
+				return producer, nil
+			}}
+			event := &cloudevents.Event{
+				Attributes: map[string]string{"specversion": "1.0", "type": events.StreamProvisioned, "datacontenttype": "application/json", "partitionkey": "ns_orders"},
+				Data:       []byte(`{"topic": "ns_orders"}`),
+			}
+
+			Expect(sink.Send(context.Background(), event)).To(Succeed())
+
+			Expect(message.Topic).To(Equal("stream-events"))
+			Expect(message.Key).To(Equal(sarama.ByteEncoder("ns_orders")))
+			Expect(message.Headers).To(ContainElement(sarama.RecordHeader{Key: []byte("ce_type"), Value: []byte(events.StreamProvisioned)}))
+			Expect(message.Headers).To(ContainElement(sarama.RecordHeader{Key: []byte("content-type"), Value: []byte("application/json")}))
+			Expect(producer.Close()).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,116 @@
+// Package failover picks the gRPC endpoint of the gateway provisioning responses point to among several, so that a
+// single dead gateway pod doesn't break the bindings of new streams
+package failover
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/metrics"
+)
+
+// Selector health-checks the endpoints of the gateway, sticking to the selected one while it is healthy and failing
+// over to the next healthy one otherwise
+type Selector struct {
+	Endpoints []string
+	// Check tells whether an endpoint is healthy, DialCheck when nil
+	Check   func(ctx context.Context, address string) error
+	Timeout time.Duration
+	// Metrics, when set, counts the failovers
+	Metrics *metrics.Metrics
+	Logger  *slog.Logger
+
+	mu       sync.Mutex
+	selected int
+}
+
+// New creates a selector of endpoints, the first being selected until health-checked
+func New(endpoints []string, timeout time.Duration, logger *slog.Logger) *Selector {
+	return &Selector{Endpoints: endpoints, Timeout: timeout, Logger: logger}
+}
+
+// DialCheck considers endpoints accepting TCP connections healthy
+func DialCheck(ctx context.Context, address string) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// Selected returns the endpoint provisioning responses point to
+func (s *Selector) Selected() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Endpoints[s.selected]
+}
+
+// Run health-checks the endpoints right away, then every interval until ctx is done
+func (s *Selector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.CheckEndpoints(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckEndpoints health-checks the endpoints, failing over from the selected one when it is unhealthy to the next
+// healthy one, if any, in the order the endpoints are configured
+func (s *Selector) CheckEndpoints(ctx context.Context) {
+	if len(s.Endpoints) < 2 {
+		return
+	}
+	healthy := make([]bool, len(s.Endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range s.Endpoints {
+		wg.Add(1)
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			healthy[i] = s.check(ctx, endpoint)
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if healthy[s.selected] {
+		return
+	}
+	for n := 1; n < len(s.Endpoints); n++ {
+		next := (s.selected + n) % len(s.Endpoints)
+		if healthy[next] {
+			s.Logger.Warn("Failing over to another gateway endpoint", "from", s.Endpoints[s.selected], "to", s.Endpoints[next])
+			s.selected = next
+			if s.Metrics != nil {
+				s.Metrics.IncGatewayFailovers()
+			}
+			return
+		}
+	}
+	s.Logger.Error("No gateway endpoint is healthy", "selected", s.Endpoints[s.selected])
+}
+
+func (s *Selector) check(ctx context.Context, endpoint string) bool {
+	check := s.Check
+	if check == nil {
+		check = DialCheck
+	}
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	if err := check(ctx, endpoint); err != nil {
+		s.Logger.Debug("Gateway endpoint is unhealthy", "endpoint", endpoint, "error", err)
+		return false
+	}
+	return true
+}
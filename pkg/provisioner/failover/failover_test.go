@@ -0,0 +1,96 @@
+package failover_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/failover"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/metrics"
+)
+
+var _ = Describe("Gateway failover", func() {
+
+	var (
+		mu        sync.Mutex
+		unhealthy map[string]bool
+		selector  *failover.Selector
+	)
+
+	BeforeEach(func() {
+		unhealthy = map[string]bool{}
+		selector = failover.New([]string{"gateway-0:6565", "gateway-1:6565", "gateway-2:6565"}, time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		selector.Check = func(ctx context.Context, address string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if unhealthy[address] {
+				return fmt.Errorf("connection refused")
+			}
+			return nil
+		}
+	})
+
+	It("selects the first endpoint until health-checked", func() {
+		Expect(selector.Selected()).To(Equal("gateway-0:6565"))
+	})
+
+	It("sticks to the selected endpoint while it is healthy", func() {
+		unhealthy["gateway-1:6565"] = true
+
+		selector.CheckEndpoints(context.Background())
+
+		Expect(selector.Selected()).To(Equal("gateway-0:6565"))
+	})
+
+	It("fails over to the next healthy endpoint, counting failovers", func() {
+		selector.Metrics = metrics.NewMetrics()
+		unhealthy["gateway-0:6565"], unhealthy["gateway-1:6565"] = true, true
+
+		selector.CheckEndpoints(context.Background())
+
+		Expect(selector.Selected()).To(Equal("gateway-2:6565"))
+		families, err := selector.Metrics.Registry.Gather()
+		Expect(err).NotTo(HaveOccurred())
+		failovers := 0.0
+		for _, family := range families {
+			if family.GetName() == "riff_kafka_provisioner_gateway_failovers_total" {
+				failovers = family.GetMetric()[0].GetCounter().GetValue()
+			}
+		}
+		Expect(failovers).To(Equal(1.0))
+	})
+
+	It("doesn't fail back once the endpoint it failed over from recovers", func() {
+		unhealthy["gateway-0:6565"] = true
+		selector.CheckEndpoints(context.Background())
+		delete(unhealthy, "gateway-0:6565")
+
+		selector.CheckEndpoints(context.Background())
+
+		Expect(selector.Selected()).To(Equal("gateway-1:6565"))
+	})
+
+	It("keeps the selected endpoint when none is healthy", func() {
+		unhealthy["gateway-0:6565"], unhealthy["gateway-1:6565"], unhealthy["gateway-2:6565"] = true, true, true
+
+		selector.CheckEndpoints(context.Background())
+
+		Expect(selector.Selected()).To(Equal("gateway-0:6565"))
+	})
+
+	It("considers endpoints accepting connections healthy by default", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		address := listener.Addr().String()
+
+		Expect(failover.DialCheck(context.Background(), address)).To(Succeed())
+		Expect(listener.Close()).To(Succeed())
+		Expect(failover.DialCheck(context.Background(), address)).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,26 @@
+package handler
+
+import "github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+
+// StreamAddress tells clients of a stream where to reach it
+type StreamAddress struct {
+	Topic string
+	// Gateway is the address of the gRPC endpoint of the gateway
+	Gateway    string
+	Partitions int32
+}
+
+// StreamAddresses returns the addresses of all the provisioned streams, by <namespace>/<stream>
+func (rh *TopicCreationRequestHandler) StreamAddresses() (map[string]StreamAddress, error) {
+	topics, err := rh.KafkaClient.ListTopics()
+	if err != nil {
+		return nil, err
+	}
+	addresses := make(map[string]StreamAddress, len(topics))
+	for topicName, spec := range topics {
+		if namespace, stream, ok := validation.ParseTopicName(topicName); ok {
+			addresses[namespace+"/"+stream] = StreamAddress{Topic: topicName, Gateway: rh.gateway(namespace), Partitions: spec.NumPartitions}
+		}
+	}
+	return addresses, nil
+}
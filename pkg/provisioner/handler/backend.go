@@ -0,0 +1,396 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/clusterlink"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/events"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/policy"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Backend
+
+// Backend provisions the streams of PUT, GET and DELETE requests on a broker, so that brokers other than Kafka can
+// be added without touching the HTTP layer. Backends report failures as a StatusError to pick the status of the
+// response, other errors being reported with a 500 status.
+type Backend interface {
+	// CreateStream provisions a stream, unless it exists, telling whether it was created
+	CreateStream(ctx context.Context, request StreamRequest) (*Stream, bool, error)
+	// DeleteStream deprovisions a stream, returning it when it is kept archived rather than deleted
+	DeleteStream(ctx context.Context, namespace, stream string) (*Stream, error)
+	// DescribeStream returns a provisioned stream with its health
+	DescribeStream(ctx context.Context, namespace, stream string) (*Stream, error)
+	// Capabilities tells what the backend supports
+	Capabilities() Capabilities
+}
+
+// StreamRequest asks for a stream to be provisioned
+type StreamRequest struct {
+	Namespace string
+	Stream    string
+	// Metadata, when set, is recorded for the stream
+	Metadata *client.StreamMetadata
+	// Replicate flags the stream for cross-cluster replication
+	Replicate bool
+	// MaxMessageBytes, when positive, is the largest record the stream takes, e.g. to carry images or documents
+	MaxMessageBytes int
+	// Retention is how long, and how much of, its records the stream retains, the backend's defaults applying to
+	// those unset
+	Retention Retention
+	// TieredStorage moves the records of the stream to remote storage once they are older than Retention.LocalMs,
+	// so that long retentions don't fill the disks of the brokers
+	TieredStorage bool
+	// Group, when set, is a consumer group of the stream to create at a starting position, unless it exists
+	Group *GroupRequest
+	// Repartition also provisions the companion repartition topic of the stream, for processors repartitioning its
+	// records by key
+	Repartition bool
+	// Mirror mirrors the stream on the linked cluster, to be consumed there read-only
+	Mirror bool
+	// Spec, when set, is the layout and config of the topic of the stream asked for, those unset being chosen by the
+	// backend
+	Spec *client.TopicSpec
+}
+
+// GroupRequest asks for a consumer group of a stream to be created, so that a processor deployed later starts
+// consuming the stream where intended rather than where its client defaults to
+type GroupRequest struct {
+	Name string
+	// StartAt is the timestamp in milliseconds of the first record the group consumes, or sarama.OffsetOldest or
+	// sarama.OffsetNewest to start from the oldest record retained or the next one published
+	StartAt int64
+}
+
+// Group is a consumer group of a stream
+type Group struct {
+	Name string `json:"name"`
+	// Offsets are those the group resumes from, by partition
+	Offsets map[int32]int64 `json:"offsets"`
+	// Created tells whether the group was created, rather than already consuming the stream
+	Created bool `json:"created"`
+}
+
+// Retention is how long, and how much of, their records streams retain, whichever limit is reached first. -1 is
+// unlimited, and 0 unset or unknown.
+type Retention struct {
+	Ms    int64 `json:"ms,omitempty"`
+	Bytes int64 `json:"bytes,omitempty"`
+	// LocalMs, with tiered storage, is how long records are retained on the disks of the brokers, before only
+	// remaining in remote storage
+	LocalMs int64 `json:"localMs,omitempty"`
+}
+
+// Stream is a provisioned stream
+type Stream struct {
+	// Topic is the name of the stream on the broker
+	Topic string
+	// Gateway, when set, is the address clients reach the stream at, rather than the gateway of the handler, e.g.
+	// the service URL of the broker
+	Gateway  string
+	Metadata *client.StreamMetadata
+	Health   *client.TopicHealth
+	// Retention, when set, is the retention in effect for a created stream, whether requested or the default
+	Retention *Retention
+	// Group, when set, is the consumer group requested with the stream
+	Group *Group
+	// Repartition, when set, is the companion repartition topic of the stream
+	Repartition *Repartition
+	// Warnings are reported to callers in Warning headers
+	Warnings []string
+	// secondary, when set, is the topic of the stream in the secondary region
+	secondary *regionStream
+	// mirror, when set, is the mirror of the topic of the stream on the linked cluster
+	mirror *clusterlink.Mirror
+}
+
+// StatusError reports a failure with the status of the response
+type StatusError struct {
+	Status int
+	// RetryAfter, when positive, is suggested to callers in a Retry-After header
+	RetryAfter time.Duration
+	// Code, when set, tells clients why the request failed, the code of the status otherwise
+	Code    ErrorCode
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+// backend returns the backend of streams, Kafka unless another is set
+func (rh *TopicCreationRequestHandler) backend() Backend {
+	if rh.Backend != nil {
+		return rh.Backend
+	}
+	return kafkaBackend{rh}
+}
+
+// kafkaBackend provisions streams as Kafka topics, with the policies of the handler
+type kafkaBackend struct {
+	rh *TopicCreationRequestHandler
+}
+
+func (b kafkaBackend) CreateStream(ctx context.Context, request StreamRequest) (*Stream, bool, error) {
+	rh := b.rh
+	topicName, err := b.topic(request.Namespace, request.Stream)
+	if err != nil {
+		return nil, false, err
+	}
+	rh.Logger.Debug("Provisioning topic", "topic", topicName)
+	topicExists, err := b.exists(topicName)
+	if err != nil {
+		return nil, false, err
+	}
+	metadata := request.Metadata
+	created := false
+	var warnings []string
+	var retention *Retention
+	if !topicExists {
+		spec := client.DefaultTopicSpec()
+		if rh.BrokerDefaults {
+			spec.NumPartitions, spec.ReplicationFactor = client.BrokerDefault, client.BrokerDefault
+		}
+		if request.Replicate {
+			spec.ConfigEntries = rh.Replication.ConfigEntries
+		}
+		spec = withRequested(spec, request.Spec)
+		if request.MaxMessageBytes > 0 {
+			spec = withConfigEntry(spec, "max.message.bytes", strconv.Itoa(request.MaxMessageBytes))
+		}
+		if request.Retention.Ms != 0 {
+			spec = withConfigEntry(spec, "retention.ms", strconv.FormatInt(request.Retention.Ms, 10))
+		}
+		if request.Retention.Bytes != 0 {
+			spec = withConfigEntry(spec, "retention.bytes", strconv.FormatInt(request.Retention.Bytes, 10))
+		}
+		if request.TieredStorage {
+			spec = withConfigEntry(spec, "remote.storage.enable", "true")
+		}
+		if request.Retention.LocalMs != 0 {
+			spec = withConfigEntry(spec, "local.retention.ms", strconv.FormatInt(request.Retention.LocalMs, 10))
+		}
+		if rh.Policy != nil {
+			decision, err := rh.Policy.Evaluate(policy.Input{Namespace: request.Namespace, Stream: request.Stream, Topic: topicName, Spec: spec})
+			if err != nil {
+				rh.Logger.Error("Error evaluating the provisioning policy", "topic", topicName, "error", err)
+				return nil, false, &StatusError{Status: http.StatusInternalServerError, Message: fmt.Sprintf("Error evaluating the provisioning policy for topic %q: %v", topicName, err)}
+			}
+			if !decision.Allow {
+				rh.Logger.Info("Provisioning policy denied topic", "topic", topicName, "reason", decision.Reason)
+				return nil, false, &StatusError{Status: http.StatusForbidden, Code: CodePolicyDenied, Message: fmt.Sprintf("Provisioning policy denied topic %q: %s", topicName, decision.Reason)}
+			}
+			if decision.Spec != nil {
+				spec = *decision.Spec
+			}
+		}
+		if err := rh.Rules.ValidateSpec(spec); err != nil {
+			rh.Logger.Info("Refusing to create topic", "topic", topicName, "error", err)
+			return nil, false, &StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to create topic %q: %v", topicName, err)}
+		}
+		if err := rh.checkConfigs(topicName, spec.ConfigEntries); err != nil {
+			return nil, false, err
+		}
+		if request.MaxMessageBytes > 0 {
+			if err := rh.checkMaxMessageBytes(topicName, request.MaxMessageBytes); err != nil {
+				return nil, false, err
+			}
+		}
+		if request.TieredStorage {
+			if err := rh.checkTieredStorage(topicName); err != nil {
+				return nil, false, err
+			}
+		}
+		if rh.MaxPayloadBytes > 0 {
+			spec = withMaxMessageBytes(spec, rh.MaxPayloadBytes)
+		}
+		warning, err := rh.checkCapacity(request.Namespace, topicName, spec)
+		if err != nil {
+			return nil, false, err
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+		err = rh.KafkaClient.CreateTopic(topicName, spec)
+		if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+			// another replica created the topic in the meantime, and records its metadata
+			rh.Logger.Debug("Topic was created concurrently", "topic", topicName)
+			metadata = nil
+		} else if err != nil {
+			rh.Logger.Error("Error creating topic", "topic", topicName, "error", err)
+			return nil, false, rh.kafkaFailure(err, "Error creating topic %q: %v", topicName, err)
+		} else {
+			rh.Logger.Debug("Created topic", "topic", topicName, "partitions", spec.NumPartitions, "replicationFactor", spec.ReplicationFactor)
+			rh.publish(events.StreamProvisioned, topicName, &spec, false)
+			created = true
+			if metadata == nil {
+				metadata = &client.StreamMetadata{}
+			}
+			metadata.Spec = &spec
+			retention = rh.retention(topicName, spec)
+		}
+	} else {
+		rh.Logger.Debug("Topic already exists", "topic", topicName)
+		if rh.Archive.Enabled() {
+			if metadata, err = rh.restore(topicName, metadata); err != nil {
+				return nil, false, err
+			}
+		}
+		if metadata != nil {
+			if metadata, err = rh.keepRecorded(topicName, metadata); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+	if metadata != nil {
+		if err := rh.KafkaClient.WriteMetadata(topicName, *metadata); err != nil {
+			rh.Logger.Error("Error recording stream metadata", "topic", topicName, "error", err)
+			return nil, false, rh.kafkaFailure(err, "Error recording the metadata of topic %q: %v", topicName, err)
+		}
+	}
+	var group *Group
+	if request.Group != nil {
+		if group, err = rh.createGroup(topicName, *request.Group); err != nil {
+			return nil, false, err
+		}
+	}
+	var repartition *Repartition
+	if request.Repartition {
+		var warning string
+		if repartition, warning, err = rh.createRepartition(request.Namespace, request.Stream, topicName); err != nil {
+			return nil, false, err
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+	var secondary *regionStream
+	if rh.Secondary != nil {
+		if secondary, err = rh.provisionSecondary(topicName, request.Replicate, metadata); err != nil {
+			return nil, false, err
+		}
+	}
+	var mirror *clusterlink.Mirror
+	if request.Mirror {
+		if mirror, err = rh.createMirror(ctx, topicName); err != nil {
+			return nil, false, err
+		}
+	}
+	return &Stream{Topic: topicName, Metadata: metadata, Retention: retention, Group: group, Repartition: repartition, Warnings: warnings, secondary: secondary, mirror: mirror}, created, nil
+}
+
+func (b kafkaBackend) DeleteStream(ctx context.Context, namespace, stream string) (*Stream, error) {
+	rh := b.rh
+	topicName, err := b.topic(namespace, stream)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := b.recorded(topicName)
+	if err != nil {
+		return nil, err
+	}
+	if rh.Mirrors != nil {
+		// mirrors outlive their source topic, as regular topics of the linked cluster
+		if err := rh.Mirrors.DeleteMirror(ctx, topicName); err != nil {
+			return nil, rh.linkFailure(err, "Error deleting the mirror of topic %q: %v", topicName, err)
+		}
+	}
+	if rh.Secondary != nil {
+		// provisioning the stream again, e.g. restoring it from its archive, provisions its secondary topic again
+		if err := rh.deleteSecondary(topicName); err != nil {
+			return nil, err
+		}
+	}
+	if rh.Archive.Enabled() {
+		archived, err := rh.archiveStream(topicName, metadata)
+		if err != nil {
+			return nil, rh.kafkaFailure(err, "Error archiving topic %q: %v", topicName, err)
+		}
+		return &Stream{Topic: topicName, Metadata: archived}, nil
+	}
+	if err := rh.deleteStream(ctx, topicName, metadata); err != nil {
+		if _, ok := err.(*subjectError); ok {
+			return nil, &StatusError{Status: http.StatusBadGateway, Message: fmt.Sprintf("Error deleting topic %q: %v", topicName, err)}
+		}
+		return nil, rh.kafkaFailure(err, "Error deleting topic %q: %v", topicName, err)
+	}
+	return nil, nil
+}
+
+func (b kafkaBackend) DescribeStream(ctx context.Context, namespace, stream string) (*Stream, error) {
+	rh := b.rh
+	topicName, err := b.topic(namespace, stream)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := b.recorded(topicName)
+	if err != nil {
+		return nil, err
+	}
+	health, err := rh.KafkaClient.TopicHealth(topicName)
+	if err != nil {
+		rh.Logger.Error("Error describing the partitions of topic", "topic", topicName, "error", err)
+		return nil, rh.kafkaFailure(err, "Error describing the partitions of topic %q: %v", topicName, err)
+	}
+	var secondary *regionStream
+	if rh.Secondary != nil {
+		if secondary, err = rh.describeSecondary(topicName); err != nil {
+			return nil, err
+		}
+	}
+	var mirror *clusterlink.Mirror
+	if rh.Mirrors != nil {
+		if mirror, err = rh.Mirrors.DescribeMirror(ctx, topicName); err != nil {
+			return nil, rh.linkFailure(err, "Error describing the mirror of topic %q: %v", topicName, err)
+		}
+	}
+	return &Stream{Topic: topicName, Metadata: metadata, Health: health, secondary: secondary, mirror: mirror}, nil
+}
+
+// topic returns the name of the topic of a stream, checking that Kafka accepts it
+func (b kafkaBackend) topic(namespace, stream string) (string, error) {
+	return StreamName(validation.KafkaNaming{}, namespace, stream)
+}
+
+// StreamName returns the name of a stream on a backend, failing with a 400 status when its naming rules refuse it
+func StreamName(naming validation.Naming, namespace, stream string) (string, error) {
+	name, err := naming.Name(namespace, stream)
+	if err != nil {
+		return "", &StatusError{Status: http.StatusBadRequest, Code: CodeInvalidName, Message: fmt.Sprintf("Invalid stream: %v", err)}
+	}
+	return name, nil
+}
+
+// exists tells whether a topic exists
+func (b kafkaBackend) exists(topicName string) (bool, error) {
+	topicExists, kafkaError := b.rh.KafkaClient.TopicExists(topicName)
+	if kafkaError != nil {
+		b.rh.Logger.Error("Error trying to list topics to see if topic exists", "topic", topicName, "error", kafkaError)
+		return false, b.rh.kafkaFailure(kafkaError, "Error trying to list topics to see if %q exists: %v", topicName, kafkaError)
+	}
+	return topicExists, nil
+}
+
+// recorded returns the metadata recorded for an existing topic, failing with a 404 status when it doesn't exist
+func (b kafkaBackend) recorded(topicName string) (*client.StreamMetadata, error) {
+	topicExists, err := b.exists(topicName)
+	if err != nil {
+		return nil, err
+	}
+	if !topicExists {
+		return nil, &StatusError{Status: http.StatusNotFound, Message: fmt.Sprintf("Topic %q does not exist", topicName)}
+	}
+	metadata, err := b.rh.KafkaClient.ReadMetadata(topicName)
+	if err != nil {
+		b.rh.Logger.Error("Error reading stream metadata", "topic", topicName, "error", err)
+		return nil, b.rh.kafkaFailure(err, "Error reading the metadata of topic %q: %v", topicName, err)
+	}
+	return metadata, nil
+}
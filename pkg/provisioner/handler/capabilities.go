@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// CapabilitiesPath describes what the backend of streams supports
+const CapabilitiesPath = "/capabilities"
+
+// Capabilities tell what the backend of streams supports, so that clients such as the stream controller check the
+// specs of streams before provisioning them
+type Capabilities struct {
+	// Backend names the broker streams are provisioned on, e.g. kafka
+	Backend string `json:"backend"`
+	// Partitions tells whether streams may have several partitions
+	Partitions bool `json:"partitions"`
+	// Compaction tells whether streams may retain only the last record of each key
+	Compaction bool `json:"compaction"`
+	// Transactions tells whether records may be published atomically, across streams
+	Transactions bool `json:"transactions"`
+	// Metadata tells whether the metadata of provisioning requests is recorded
+	Metadata bool `json:"metadata"`
+	// Replication tells whether streams may be flagged for cross-cluster replication
+	Replication bool `json:"replication"`
+	// MaxNameLength is the longest the namespace and name of a stream may be, joined by an underscore, zero when
+	// the backend sets no limit
+	MaxNameLength int `json:"maxNameLength"`
+	// ReadOnly tells that the provisioner is a standby, rejecting the requests changing streams
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+func (b kafkaBackend) Capabilities() Capabilities {
+	return Capabilities{
+		Backend:       "kafka",
+		Partitions:    true,
+		Compaction:    true,
+		Transactions:  true,
+		Metadata:      true,
+		Replication:   b.rh.Replication != nil,
+		MaxNameLength: validation.KafkaNaming{}.MaxLength(),
+	}
+}
+
+func (rh *TopicCreationRequestHandler) capabilities(responseWriter http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		rh.writeErrorf(responseWriter, http.StatusMethodNotAllowed, "", "Method %s is not allowed", request.Method)
+		return
+	}
+	backend, err := rh.namedBackend(ChosenBackend(request))
+	if err != nil {
+		rh.writeError(responseWriter, err)
+		return
+	}
+	capabilities := backend.Capabilities()
+	capabilities.ReadOnly = rh.ReadOnly
+	rh.writeDocument(responseWriter, request, http.StatusOK, capabilities)
+}
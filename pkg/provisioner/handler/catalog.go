@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// CatalogPath lists the streams provisioned across namespaces
+const CatalogPath = "/streams"
+
+// catalogPage is a page of the catalog, Continue telling where the next page starts, if any
+type catalogPage struct {
+	Streams  []catalogEntry `json:"streams"`
+	Continue string         `json:"continue,omitempty"`
+}
+
+type catalogEntry struct {
+	Namespace         string         `json:"namespace"`
+	Stream            string         `json:"stream"`
+	Topic             string         `json:"topic"`
+	Partitions        int32          `json:"partitions"`
+	ReplicationFactor int16          `json:"replicationFactor"`
+	Gateways          gatewaysResult `json:"gateways"`
+	*client.StreamMetadata
+}
+
+// catalog lists the streams of all namespaces, sorted by topic, with their layout, metadata and gateways. Pages
+// hold at most the number of streams of the limit parameter, the continue parameter asking for the page
+// following the one that returned it. The labelSelector and fieldSelector parameters restrict the streams listed to
+// those whose labels and fields they select.
+func (rh *TopicCreationRequestHandler) catalog(responseWriter http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		rh.writeErrorf(responseWriter, http.StatusMethodNotAllowed, "", "Method %s is not allowed", request.Method)
+		return
+	}
+	if rh.Authorizer != nil && !rh.authorize(responseWriter, request, "", "list") {
+		return
+	}
+	pagination, err := parsePagination(request)
+	if err != nil {
+		rh.writeError(responseWriter, err)
+		return
+	}
+	selector, err := parseLabelSelector(request.URL.Query().Get("labelSelector"))
+	if err != nil {
+		rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid value for parameter \"labelSelector\": %v", err)
+		return
+	}
+	fields, err := parseFieldSelector(request.URL.Query().Get("fieldSelector"))
+	if err != nil {
+		rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid value for parameter \"fieldSelector\": %v", err)
+		return
+	}
+
+	topics, err := rh.KafkaClient.ListTopics()
+	if err != nil {
+		rh.Logger.Error("Error listing topics for the catalog", "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error listing topics: %v", err))
+		return
+	}
+	names := make([]string, 0, len(topics))
+	for name := range topics {
+		if _, _, ok := validation.ParseTopicName(name); ok && pagination.includes(name) {
+			names = append(names, name)
+		}
+	}
+	page := catalogPage{Streams: []catalogEntry{}}
+	if len(names) == 0 {
+		rh.writeCatalog(responseWriter, request, page)
+		return
+	}
+
+	metadata, err := rh.KafkaClient.ListMetadata()
+	if err != nil {
+		rh.Logger.Error("Error reading stream metadata for the catalog", "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error reading stream metadata: %v", err))
+		return
+	}
+	if len(selector) > 0 || len(fields) > 0 {
+		selected := names[:0]
+		for _, name := range names {
+			m := metadata[name]
+			if selector.matches(m.Labels) && fields.matches(streamField(name, topics[name], m)) {
+				selected = append(selected, name)
+			}
+		}
+		names = selected
+	}
+	sort.Strings(names)
+	names, page.Continue = pagination.page(names)
+	for _, name := range names {
+		namespace, stream, _ := validation.ParseTopicName(name)
+		entry := catalogEntry{
+			Namespace:         namespace,
+			Stream:            stream,
+			Topic:             name,
+			Partitions:        topics[name].NumPartitions,
+			ReplicationFactor: topics[name].ReplicationFactor,
+			Gateways:          rh.gateways(namespace, stream),
+		}
+		if m, ok := metadata[name]; ok {
+			entry.StreamMetadata = described(&m)
+		}
+		page.Streams = append(page.Streams, entry)
+	}
+	rh.writeCatalog(responseWriter, request, page)
+}
+
+func (rh *TopicCreationRequestHandler) writeCatalog(responseWriter http.ResponseWriter, request *http.Request, page catalogPage) {
+	rh.writeDocument(responseWriter, request, http.StatusOK, page)
+}
+
+// streamField looks the fields of the stream of a topic up for field selectors: its namespace, stream, topic,
+// partitions, replicationFactor, contentType and envelope, other fields being the configs set on its topic
+func streamField(topicName string, spec client.TopicSpec, metadata client.StreamMetadata) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		namespace, stream, _ := validation.ParseTopicName(topicName)
+		switch name {
+		case "namespace":
+			return namespace, true
+		case "stream":
+			return stream, true
+		case "topic":
+			return topicName, true
+		case "partitions":
+			return strconv.Itoa(int(spec.NumPartitions)), true
+		case "replicationFactor":
+			return strconv.Itoa(int(spec.ReplicationFactor)), true
+		case "contentType":
+			return metadata.ContentType, metadata.ContentType != ""
+		case "envelope":
+			return metadata.Envelope, metadata.Envelope != ""
+		}
+		value, ok := spec.ConfigEntries[name]
+		if !ok || value == nil {
+			return "", false
+		}
+		return *value, true
+	}
+}
+
+// labelSelector selects streams by their labels, as kubernetes selects resources: its requirements are separated by
+// commas, and are all met by the labels selected
+type labelSelector []labelRequirement
+
+// labelRequirement is met by labels having, or not having, a label of some value, or of any value when Value is
+// unset
+type labelRequirement struct {
+	Name   string
+	Value  *string
+	Negate bool
+}
+
+func parseLabelSelector(selector string) (labelSelector, error) {
+	if strings.TrimSpace(selector) == "" {
+		return nil, nil
+	}
+	var requirements labelSelector
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		var requirement labelRequirement
+		switch {
+		case strings.Contains(term, "!="):
+			name, value, _ := strings.Cut(term, "!=")
+			requirement = labelRequirement{Name: strings.TrimSpace(name), Value: &value, Negate: true}
+		case strings.Contains(term, "="):
+			name, value, _ := strings.Cut(strings.Replace(term, "==", "=", 1), "=")
+			requirement = labelRequirement{Name: strings.TrimSpace(name), Value: &value}
+		case strings.HasPrefix(term, "!"):
+			requirement = labelRequirement{Name: strings.TrimSpace(term[1:]), Negate: true}
+		default:
+			requirement = labelRequirement{Name: term}
+		}
+		if requirement.Name == "" || strings.ContainsAny(requirement.Name, labelSeparators) {
+			return nil, fmt.Errorf("%q should be of the form name, !name, name=value or name!=value", term)
+		}
+		if requirement.Value != nil {
+			value := strings.TrimSpace(*requirement.Value)
+			requirement.Value = &value
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements, nil
+}
+
+func (s labelSelector) matches(labels map[string]string) bool {
+	for _, requirement := range s {
+		value, ok := labels[requirement.Name]
+		met := ok && (requirement.Value == nil || value == *requirement.Value)
+		if met == requirement.Negate {
+			return false
+		}
+	}
+	return true
+}
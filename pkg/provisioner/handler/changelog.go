@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/events"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/policy"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// ChangelogsSegment is the segment of the path provisioning the changelog topic of a store of a stateful processor,
+// e.g. /my-ns/my-app/changelogs/my-store
+const ChangelogsSegment = "changelogs"
+
+// ChangelogName returns the name of the stream of the changelog of a store of an application, following the
+// <app>-<store>-changelog convention of Kafka Streams, so that processors whose application id is
+// <namespace>_<app> find the topic rather than creating it
+func ChangelogName(app, store string) string {
+	return fmt.Sprintf("%s-%s-changelog", app, store)
+}
+
+type changelogResult struct {
+	Topic      string             `json:"topic"`
+	Partitions int32              `json:"partitions,omitempty"`
+	Config     map[string]*string `json:"config,omitempty"`
+}
+
+// changelog provisions the compacted changelog topic of a store of an application on PUT, with as many partitions
+// as the source stream the application consumes when given, so that stateful processors don't rely on the brokers
+// creating topics automatically
+func (rh *TopicCreationRequestHandler) changelog(responseWriter http.ResponseWriter, request *http.Request, namespace, app, store string) {
+	if request.Method != http.MethodPut {
+		rh.writeErrorf(responseWriter, http.StatusMethodNotAllowed, "", "Method %s is not allowed", request.Method)
+		return
+	}
+	if rh.Authorizer != nil && !rh.authorize(responseWriter, request, namespace, "create") {
+		return
+	}
+	if store == "" {
+		rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidName, "URLs should be of the form /<namespace>/<app>/%s/<store>", ChangelogsSegment)
+		return
+	}
+	topicName, err := StreamName(validation.KafkaNaming{}, namespace, ChangelogName(app, store))
+	if err != nil {
+		rh.writeError(responseWriter, err)
+		return
+	}
+	windowed, err := parseBoolParameter(request, "windowed")
+	if err != nil {
+		rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid value for parameter \"windowed\": %v", err)
+		return
+	}
+	var retentionMs int64
+	if text := request.URL.Query().Get("retentionMs"); text != "" {
+		retentionMs, err = strconv.ParseInt(text, 10, 64)
+		if err != nil || retentionMs <= 0 || !windowed {
+			rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid value for parameter \"retentionMs\": windowed stores retain their changelog for a number of milliseconds, got %q", text)
+			return
+		}
+	}
+	rh.Logger.Debug("Provisioning changelog topic", "topic", topicName)
+	topics, err := rh.KafkaClient.ListTopics()
+	if err != nil {
+		rh.Logger.Error("Error listing topics", "topic", topicName, "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error listing topics to provision %q: %v", topicName, err))
+		return
+	}
+	if existing, ok := topics[topicName]; ok {
+		rh.Logger.Debug("Changelog topic already exists", "topic", topicName)
+		rh.writeChangelog(responseWriter, http.StatusOK, changelogResult{Topic: topicName, Partitions: existing.NumPartitions})
+		return
+	}
+	spec, warning, err := rh.changelogSpec(request, namespace, ChangelogName(app, store), topicName, topics, windowed, retentionMs)
+	if err != nil {
+		rh.writeError(responseWriter, err)
+		return
+	}
+	if warning != "" {
+		responseWriter.Header().Add("Warning", fmt.Sprintf("299 - %q", warning))
+	}
+	err = rh.KafkaClient.CreateTopic(topicName, spec)
+	if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+		rh.Logger.Debug("Changelog topic was created concurrently", "topic", topicName)
+		rh.writeChangelog(responseWriter, http.StatusOK, changelogResult{Topic: topicName})
+		return
+	}
+	if err == nil {
+		err = rh.KafkaClient.WriteMetadata(topicName, client.StreamMetadata{Spec: &spec})
+	}
+	if err != nil {
+		rh.Logger.Error("Error creating changelog topic", "topic", topicName, "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error creating topic %q: %v", topicName, err))
+		return
+	}
+	rh.Logger.Info("Created changelog topic", "topic", topicName, "partitions", spec.NumPartitions, "replicationFactor", spec.ReplicationFactor)
+	rh.publish(events.StreamProvisioned, topicName, &spec, false)
+	rh.writeChangelog(responseWriter, http.StatusCreated, changelogResult{Topic: topicName, Partitions: spec.NumPartitions, Config: spec.ConfigEntries})
+}
+
+// changelogSpec chooses the spec of a changelog topic: compacted, with windowed stores also deleting the records
+// older than their retention, and with as many partitions as its source stream. The provisioning policy may deny
+// changelogs, but not change their layout, which stateful processors rely on.
+func (rh *TopicCreationRequestHandler) changelogSpec(request *http.Request, namespace, stream, topicName string, topics map[string]client.TopicSpec, windowed bool, retentionMs int64) (client.TopicSpec, string, error) {
+	spec := client.DefaultTopicSpec()
+	if rh.BrokerDefaults {
+		spec.NumPartitions, spec.ReplicationFactor = client.BrokerDefault, client.BrokerDefault
+	}
+	if source := request.URL.Query().Get("source"); source != "" {
+		sourceTopic, err := StreamName(validation.KafkaNaming{}, namespace, source)
+		if err != nil {
+			return spec, "", err
+		}
+		sourceSpec, ok := topics[sourceTopic]
+		if !ok {
+			return spec, "", &StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to create topic %q: source topic %q does not exist", topicName, sourceTopic)}
+		}
+		spec.NumPartitions = sourceSpec.NumPartitions
+	}
+	if windowed {
+		spec = withConfigEntry(spec, "cleanup.policy", "compact,delete")
+		if retentionMs > 0 {
+			spec = withConfigEntry(spec, "retention.ms", strconv.FormatInt(retentionMs, 10))
+		}
+	} else {
+		spec = withConfigEntry(spec, "cleanup.policy", "compact")
+	}
+	if rh.Policy != nil {
+		decision, err := rh.Policy.Evaluate(policy.Input{Namespace: namespace, Stream: stream, Topic: topicName, Spec: spec})
+		if err != nil {
+			rh.Logger.Error("Error evaluating the provisioning policy", "topic", topicName, "error", err)
+			return spec, "", &StatusError{Status: http.StatusInternalServerError, Message: fmt.Sprintf("Error evaluating the provisioning policy for topic %q: %v", topicName, err)}
+		}
+		if !decision.Allow {
+			rh.Logger.Info("Provisioning policy denied topic", "topic", topicName, "reason", decision.Reason)
+			return spec, "", &StatusError{Status: http.StatusForbidden, Code: CodePolicyDenied, Message: fmt.Sprintf("Provisioning policy denied topic %q: %s", topicName, decision.Reason)}
+		}
+	}
+	if err := rh.Rules.ValidateSpec(spec); err != nil {
+		rh.Logger.Info("Refusing to create topic", "topic", topicName, "error", err)
+		return spec, "", &StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to create topic %q: %v", topicName, err)}
+	}
+	if err := rh.checkConfigs(topicName, spec.ConfigEntries); err != nil {
+		return spec, "", err
+	}
+	warning, err := rh.checkCapacity(namespace, topicName, spec)
+	return spec, warning, err
+}
+
+func (rh *TopicCreationRequestHandler) writeChangelog(responseWriter http.ResponseWriter, statusCode int, res changelogResult) {
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(statusCode)
+	if err := json.NewEncoder(responseWriter).Encode(res); err != nil {
+		rh.Logger.Error("Failed to write json response", "error", err)
+	}
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// minCompressedBytes is the size from which documents are compressed, smaller ones not being worth it
+const minCompressedBytes = 1024
+
+// Encodings documents are compressed with
+const (
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+)
+
+var zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) {
+	return zstd.NewWriter(nil)
+})
+
+// writeBody responds with a document, compressed with the encoding the request prefers when it is large enough, as
+// lists and exports of clusters with thousands of streams are
+func (rh *TopicCreationRequestHandler) writeBody(responseWriter http.ResponseWriter, request *http.Request, statusCode int, contentType string, body []byte) {
+	responseWriter.Header().Set("Content-Type", contentType)
+	responseWriter.Header().Add("Vary", "Accept-Encoding")
+	if len(body) >= minCompressedBytes {
+		if encoding := acceptedEncoding(request.Header.Get("Accept-Encoding")); encoding != "" {
+			compressed, err := compress(encoding, body)
+			if err == nil {
+				responseWriter.Header().Set("Content-Encoding", encoding)
+				body = compressed
+			} else {
+				rh.Logger.Error("Failed to compress response, sending it as is", "encoding", encoding, "error", err)
+			}
+		}
+	}
+	responseWriter.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	responseWriter.WriteHeader(statusCode)
+	_, _ = responseWriter.Write(body)
+}
+
+// acceptedEncoding returns the encoding an Accept-Encoding header prefers among gzip and zstd, zstd when they are
+// equally preferred, or "" when neither is accepted
+func acceptedEncoding(acceptEncoding string) string {
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		quality := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			var err error
+			if quality, err = strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+				continue
+			}
+		}
+		if coding != "" {
+			qualities[coding] = quality
+		}
+	}
+	best, bestQuality := "", 0.0
+	for _, encoding := range []string{EncodingZstd, EncodingGzip} {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality, ok = qualities["*"]
+		}
+		if ok && quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+func compress(encoding string, body []byte) ([]byte, error) {
+	if encoding == EncodingZstd {
+		encoder, err := zstdEncoder()
+		if err != nil {
+			return nil, err
+		}
+		return encoder.EncodeAll(body, make([]byte, 0, len(body)/4)), nil
+	}
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/events"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . SubjectDeleter
+
+// SubjectDeleter deletes the subjects of a Schema Registry
+type SubjectDeleter interface {
+	// DeleteSubject deletes a subject, softly unless permanent, unknown subjects being left alone
+	DeleteSubject(ctx context.Context, subject string, permanent bool) error
+}
+
+// ArchivePolicy keeps the topics of deleted streams for a grace period before deleting them, guarding against
+// accidental deletions
+type ArchivePolicy struct {
+	// GracePeriod is how long the topics of deleted streams are kept, topics being deleted right away when zero
+	GracePeriod time.Duration
+	// Retention is the retention of the records of archived topics
+	Retention time.Duration
+}
+
+// Enabled tells whether deleted streams are archived
+func (p ArchivePolicy) Enabled() bool {
+	return p.GracePeriod > 0
+}
+
+// subjectError reports a schema subject that couldn't be deleted
+type subjectError struct {
+	subject string
+	err     error
+}
+
+func (e *subjectError) Error() string {
+	return fmt.Sprintf("error deleting schema subject %q: %v", e.subject, e.err)
+}
+
+// DeprovisionStream archives or deletes the topic of a stream as DELETE does, streams without topic being already
+// deprovisioned
+func (rh *TopicCreationRequestHandler) DeprovisionStream(ctx context.Context, namespace, stream string) error {
+	topicName := validation.TopicName(namespace, stream)
+	topicExists, kafkaError := rh.KafkaClient.TopicExists(topicName)
+	if kafkaError != nil {
+		return kafkaError
+	}
+	if !topicExists {
+		return nil
+	}
+	metadata, err := rh.KafkaClient.ReadMetadata(topicName)
+	if err != nil {
+		return err
+	}
+	if rh.Archive.Enabled() {
+		_, err = rh.archiveStream(topicName, metadata)
+		return err
+	}
+	return rh.deleteStream(ctx, topicName, metadata)
+}
+
+// deleteStream deletes the topic of a stream and the metadata recorded for it, after the schema subjects the
+// stream owns so that failures leave something to retry the deletion on
+func (rh *TopicCreationRequestHandler) deleteStream(ctx context.Context, topicName string, metadata *client.StreamMetadata) error {
+	if err := rh.deleteSubjects(ctx, topicName, metadata); err != nil {
+		return err
+	}
+	// topics deleted in the meantime only leave their metadata to delete
+	if err := rh.KafkaClient.DeleteTopic(topicName); err != nil && !errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+		rh.Logger.Error("Error deleting topic", "topic", topicName, "error", err)
+		return err
+	}
+	rh.topicDeleted(topicName, metadata)
+	return nil
+}
+
+// deleteSubjects deletes the schema subjects the stream of a topic owns, before its topic is
+func (rh *TopicCreationRequestHandler) deleteSubjects(ctx context.Context, topicName string, metadata *client.StreamMetadata) error {
+	if rh.Subjects == nil {
+		return nil
+	}
+	for _, subject := range ownedSubjects(topicName, metadata) {
+		if err := rh.Subjects.DeleteSubject(ctx, subject, rh.HardDeleteSubjects); err != nil {
+			rh.Logger.Error("Error deleting schema subject", "topic", topicName, "subject", subject, "error", err)
+			return &subjectError{subject: subject, err: err}
+		}
+		rh.Logger.Debug("Deleted schema subject", "topic", topicName, "subject", subject, "permanent", rh.HardDeleteSubjects)
+	}
+	return nil
+}
+
+// topicDeleted reports the stream of a deleted topic deleted, and deletes the metadata recorded for it
+func (rh *TopicCreationRequestHandler) topicDeleted(topicName string, metadata *client.StreamMetadata) {
+	rh.Logger.Info("Deleted topic", "topic", topicName)
+	// archived streams were reported deleted when archived
+	if metadata == nil || metadata.Archived == nil {
+		rh.publish(events.StreamDeleted, topicName, nil, false)
+	}
+	if metadata != nil {
+		if err := rh.KafkaClient.DeleteMetadata(topicName); err != nil {
+			// the topic is gone, the stale metadata applying to a stream provisioned again under the same name
+			// until replaced
+			rh.Logger.Warn("Error deleting stream metadata", "topic", topicName, "error", err)
+		}
+	}
+}
+
+// archiveStream archives the topic of a stream, unless already archived, returning the metadata recorded for the
+// stream
+func (rh *TopicCreationRequestHandler) archiveStream(topicName string, metadata *client.StreamMetadata) (*client.StreamMetadata, error) {
+	if metadata != nil && metadata.Archived != nil {
+		return metadata, nil
+	}
+	topics, err := rh.KafkaClient.ListTopics()
+	if err != nil {
+		rh.Logger.Error("Error listing topics to archive topic", "topic", topicName, "error", err)
+		return nil, err
+	}
+	configEntries := topics[topicName].ConfigEntries
+	archivedEntries := make(map[string]*string, len(configEntries)+1)
+	for name, value := range configEntries {
+		archivedEntries[name] = value
+	}
+	retention := strconv.FormatInt(rh.Archive.Retention.Milliseconds(), 10)
+	archivedEntries["retention.ms"] = &retention
+	if err := rh.KafkaClient.AlterTopicConfig(topicName, archivedEntries); err != nil {
+		rh.Logger.Error("Error shortening the retention of archived topic", "topic", topicName, "error", err)
+		return nil, err
+	}
+
+	archived := client.StreamMetadata{}
+	if metadata != nil {
+		archived = *metadata
+	}
+	now := time.Now().UTC()
+	archived.Archived = &client.Archive{Since: now, DeleteAfter: now.Add(rh.Archive.GracePeriod), ConfigEntries: configEntries}
+	if err := rh.KafkaClient.WriteMetadata(topicName, archived); err != nil {
+		rh.Logger.Error("Error recording archived stream", "topic", topicName, "error", err)
+		if err := rh.KafkaClient.AlterTopicConfig(topicName, configEntries); err != nil {
+			rh.Logger.Error("Error restoring the retention of topic", "topic", topicName, "error", err)
+		}
+		return nil, err
+	}
+	rh.Logger.Info("Archived topic", "topic", topicName, "deleteAfter", archived.Archived.DeleteAfter)
+	rh.publish(events.StreamDeleted, topicName, nil, true)
+	return &archived, nil
+}
+
+// restore reverts the archival of the topic of a stream provisioned again, returning the metadata to record for
+// the stream, that of the request when it has any
+func (rh *TopicCreationRequestHandler) restore(topicName string, metadata *client.StreamMetadata) (*client.StreamMetadata, error) {
+	recorded, err := rh.KafkaClient.ReadMetadata(topicName)
+	if err != nil {
+		rh.Logger.Error("Error reading stream metadata", "topic", topicName, "error", err)
+		return nil, rh.kafkaFailure(err, "Error reading the metadata of topic %q: %v", topicName, err)
+	}
+	if recorded == nil || recorded.Archived == nil {
+		return metadata, nil
+	}
+	if err := rh.KafkaClient.AlterTopicConfig(topicName, recorded.Archived.ConfigEntries); err != nil {
+		rh.Logger.Error("Error restoring archived topic", "topic", topicName, "error", err)
+		return nil, rh.kafkaFailure(err, "Error restoring archived topic %q: %v", topicName, err)
+	}
+	rh.Logger.Info("Restored archived topic", "topic", topicName)
+	rh.publish(events.StreamProvisioned, topicName, recorded.Spec, false)
+	if metadata == nil {
+		restored := *recorded
+		restored.Archived = nil
+		// the stream being provisioned again under its old name, its records are no longer copied to its new one
+		restored.Migration = nil
+		metadata = &restored
+	}
+	return metadata, nil
+}
+
+// DeleteArchived deletes the topics of the archived streams whose grace period is over, returning the first error
+// met, if any
+func (rh *TopicCreationRequestHandler) DeleteArchived(ctx context.Context) error {
+	recorded, err := rh.KafkaClient.ListMetadata()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var firstErr error
+	for topicName, metadata := range recorded {
+		if metadata.Archived == nil || now.Before(metadata.Archived.DeleteAfter) {
+			continue
+		}
+		if err := rh.deleteStream(ctx, topicName, &metadata); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// ownedSubjects returns the subjects of the schemas of a topic, but for those named after records alone, which
+// other topics may share
+func ownedSubjects(topicName string, metadata *client.StreamMetadata) []string {
+	var schema *client.SchemaSubject
+	if metadata != nil {
+		schema = metadata.Schema
+	}
+	switch {
+	case schema == nil || schema.SubjectNameStrategy == client.TopicNameStrategy:
+		return []string{topicName + "-key", topicName + "-value"}
+	case schema.SubjectNameStrategy == client.TopicRecordNameStrategy:
+		return []string{schema.Subject(topicName)}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// writeDocument responds with a document describing or listing streams, in YAML when the request accepts it, so that
+// it can be pasted as is in the manifests of GitOps repositories, and in JSON otherwise
+func (rh *TopicCreationRequestHandler) writeDocument(responseWriter http.ResponseWriter, request *http.Request, statusCode int, document interface{}) {
+	body, err := json.Marshal(document)
+	// as json.Encoder does, ending JSON documents with a newline
+	body, contentType := append(body, '\n'), "application/json"
+	if err == nil && acceptsYAML(request) {
+		contentType = "application/yaml"
+		body, err = toYAML(body)
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to encode response", "error", err)
+		rh.writeErrorf(responseWriter, http.StatusInternalServerError, "", "Error encoding response: %v", err)
+		return
+	}
+	responseWriter.Header().Add("Vary", "Accept")
+	rh.writeBody(responseWriter, request, statusCode, contentType, body)
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrorCode tells clients why a request failed, and whether retrying it may succeed, without parsing the message of
+// the error. Codes are stable: new ones may be added, existing ones are never renamed.
+type ErrorCode string
+
+// Codes of the errors of provisioning requests
+const (
+	// CodeInvalidRequest is a request that is malformed other than by its name, parameters or body
+	CodeInvalidRequest ErrorCode = "INVALID_REQUEST"
+	// CodeInvalidName is a request for a namespace or stream whose name is invalid
+	CodeInvalidName ErrorCode = "INVALID_NAME"
+	// CodeInvalidParameter is a request with a query parameter whose value is invalid
+	CodeInvalidParameter ErrorCode = "INVALID_PARAMETER"
+	// CodeInvalidBody is a request whose body is invalid
+	CodeInvalidBody ErrorCode = "INVALID_BODY"
+	// CodeNotConfigured is a request for a feature this provisioner is not configured for
+	CodeNotConfigured ErrorCode = "NOT_CONFIGURED"
+	// CodeUnauthenticated is a request without a valid bearer token
+	CodeUnauthenticated ErrorCode = "UNAUTHENTICATED"
+	// CodeForbidden is a request the caller is not allowed to make
+	CodeForbidden ErrorCode = "FORBIDDEN"
+	// CodePolicyDenied is a stream the provisioning policy denies
+	CodePolicyDenied ErrorCode = "POLICY_DENIED"
+	// CodeQuotaExceeded is a stream exceeding the quota of its namespace or the partition budget of the cluster
+	CodeQuotaExceeded ErrorCode = "QUOTA_EXCEEDED"
+	// CodeNotFound is a request for a stream, or a resource of a stream, that doesn't exist
+	CodeNotFound ErrorCode = "NOT_FOUND"
+	// CodeMethodNotAllowed is a request with a method the path doesn't take
+	CodeMethodNotAllowed ErrorCode = "METHOD_NOT_ALLOWED"
+	// CodeConflict is a request conflicting with the current state of a stream
+	CodeConflict ErrorCode = "CONFLICT"
+	// CodePreconditionFailed is a change asked of a stream that changed since the caller described it
+	CodePreconditionFailed ErrorCode = "PRECONDITION_FAILED"
+	// CodePreconditionRequired is a change asked of a stream without the ETag it was described with
+	CodePreconditionRequired ErrorCode = "PRECONDITION_REQUIRED"
+	// CodeRefused is a stream the rules of the provisioner, the brokers or the backend refuse as asked for
+	CodeRefused ErrorCode = "REFUSED"
+	// CodeNotImplemented is a request the backend doesn't implement
+	CodeNotImplemented ErrorCode = "NOT_IMPLEMENTED"
+	// CodeReadOnly is a change asked of a read-only standby
+	CodeReadOnly ErrorCode = "READ_ONLY"
+	// CodeBrokerUnavailable is a request that failed on an error of the brokers expected to be transient
+	CodeBrokerUnavailable ErrorCode = "BROKER_UNAVAILABLE"
+	// CodeUpstreamUnavailable is a request that failed on an error of another service, such as the linked cluster
+	CodeUpstreamUnavailable ErrorCode = "UPSTREAM_UNAVAILABLE"
+	// CodeTimeout is a request that took longer than the request timeout of the provisioner
+	CodeTimeout ErrorCode = "TIMEOUT"
+	// CodeInternal is any other failure
+	CodeInternal ErrorCode = "INTERNAL"
+)
+
+// codesByStatus are the codes of the errors that don't tell theirs
+var codesByStatus = map[int]ErrorCode{
+	http.StatusBadRequest:           CodeInvalidRequest,
+	http.StatusUnauthorized:         CodeUnauthenticated,
+	http.StatusForbidden:            CodeForbidden,
+	http.StatusNotFound:             CodeNotFound,
+	http.StatusMethodNotAllowed:     CodeMethodNotAllowed,
+	http.StatusConflict:             CodeConflict,
+	http.StatusPreconditionFailed:   CodePreconditionFailed,
+	http.StatusUnprocessableEntity:  CodeRefused,
+	http.StatusPreconditionRequired: CodePreconditionRequired,
+	http.StatusNotImplemented:       CodeNotImplemented,
+	http.StatusBadGateway:           CodeUpstreamUnavailable,
+	http.StatusServiceUnavailable:   CodeBrokerUnavailable,
+	http.StatusInsufficientStorage:  CodeQuotaExceeded,
+}
+
+// Retryable tells whether retrying a request that failed with the code may succeed as is, after the delay of the
+// Retry-After header when there is one. Other requests need to change, or the state they conflict with.
+func (c ErrorCode) Retryable() bool {
+	switch c {
+	case CodeBrokerUnavailable, CodeUpstreamUnavailable, CodeTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// errorResult is the body of error responses
+type errorResult struct {
+	Code      ErrorCode `json:"code"`
+	Message   string    `json:"message"`
+	Retryable bool      `json:"retryable"`
+}
+
+// code returns the code of the error, the one of its status unless it tells another
+func (e *StatusError) code() ErrorCode {
+	if e.Code != "" {
+		return e.Code
+	}
+	if code, ok := codesByStatus[e.Status]; ok {
+		return code
+	}
+	return CodeInternal
+}
+
+// ErrorBody returns the body of an error response, for the errors responded outside of handlers
+func ErrorBody(code ErrorCode, message string) string {
+	body, _ := json.Marshal(errorResult{Code: code, Message: message, Retryable: code.Retryable()})
+	return string(body) + "\n"
+}
+
+// WriteError responds with the status, code and message of an error, 500 unless it is a StatusError
+func WriteError(responseWriter http.ResponseWriter, err error) {
+	statusError, ok := err.(*StatusError)
+	if !ok {
+		statusError = &StatusError{Status: http.StatusInternalServerError, Message: err.Error()}
+	}
+	if statusError.RetryAfter > 0 {
+		responseWriter.Header().Set("Retry-After", retryAfterSeconds(statusError.RetryAfter))
+	}
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(statusError.Status)
+	_, _ = fmt.Fprint(responseWriter, ErrorBody(statusError.code(), statusError.Message))
+}
+
+// retryAfterSeconds returns the value of a Retry-After header, rounded up to the second so that callers never retry
+// too early
+func retryAfterSeconds(retryAfter time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+}
+
+// writeError responds with the status, code and message of a backend error, 500 unless it is a StatusError
+func (rh *TopicCreationRequestHandler) writeError(responseWriter http.ResponseWriter, err error) {
+	WriteError(responseWriter, err)
+}
+
+// writeErrorf responds with an error of the status and code, the code of the status when empty
+func (rh *TopicCreationRequestHandler) writeErrorf(responseWriter http.ResponseWriter, status int, code ErrorCode, format string, args ...interface{}) {
+	WriteError(responseWriter, &StatusError{Status: status, Code: code, Message: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+// streamState is what the ETag of a stream is derived from: the layout and config overrides of its topic, and its
+// metadata
+type streamState struct {
+	Partitions        int32                  `json:"partitions"`
+	ReplicationFactor int16                  `json:"replicationFactor"`
+	Config            map[string]*string     `json:"config,omitempty"`
+	Metadata          *client.StreamMetadata `json:"metadata,omitempty"`
+}
+
+// preconditions serializes the requests changing the same Kafka stream from checking their precondition through
+// writing the stream, so that two requests carrying the same ETag don't both pass their check. Replicas of the
+// provisioner don't share it: requests reaching different replicas can still both pass their check, the last one
+// written winning.
+var preconditions = &topicLocks{locks: make(map[string]*topicLock)}
+
+type topicLocks struct {
+	mu    sync.Mutex
+	locks map[string]*topicLock
+}
+
+type topicLock struct {
+	sync.Mutex
+	// holders counts the requests holding or waiting for the lock, which is dropped when none are left
+	holders int
+}
+
+// lock locks a topic, returning the function unlocking it
+func (l *topicLocks) lock(topicName string) func() {
+	l.mu.Lock()
+	lock, ok := l.locks[topicName]
+	if !ok {
+		lock = &topicLock{}
+		l.locks[topicName] = lock
+	}
+	lock.holders++
+	l.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if lock.holders--; lock.holders == 0 {
+			delete(l.locks, topicName)
+		}
+	}
+}
+
+// etag returns the strong ETag of the current state of a topic, changing whenever its config or metadata does, and
+// whether the topic exists
+func (rh *TopicCreationRequestHandler) etag(topicName string) (string, bool, error) {
+	spec, err := rh.KafkaClient.DescribeTopic(topicName)
+	if err != nil {
+		rh.Logger.Error("Error describing topic to tag it", "topic", topicName, "error", err)
+		return "", false, rh.kafkaFailure(err, "Error describing topic %q to tag it: %v", topicName, err)
+	}
+	if spec == nil {
+		return "", false, nil
+	}
+	metadata, err := rh.KafkaClient.ReadMetadata(topicName)
+	if err != nil {
+		rh.Logger.Error("Error reading stream metadata", "topic", topicName, "error", err)
+		return "", false, rh.kafkaFailure(err, "Error reading the metadata of topic %q: %v", topicName, err)
+	}
+	// maps are encoded with sorted keys, the same state always hashing the same
+	state, err := json.Marshal(streamState{Partitions: spec.NumPartitions, ReplicationFactor: spec.ReplicationFactor, Config: spec.ConfigEntries, Metadata: metadata})
+	if err != nil {
+		return "", false, err
+	}
+	sum := sha256.Sum256(state)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, true, nil
+}
+
+// tagged tells whether the ETags of the streams of a backend are returned and checked
+func (rh *TopicCreationRequestHandler) tagged(backend Backend) bool {
+	return (rh.ETags || rh.RequireIfMatch) && backend.Capabilities().Backend == "kafka"
+}
+
+// writeETag sets the ETag header of a response describing a stream to the ETag of its topic, when it exists
+func (rh *TopicCreationRequestHandler) writeETag(responseWriter http.ResponseWriter, topicName string) error {
+	etag, exists, err := rh.etag(topicName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		responseWriter.Header().Set("ETag", etag)
+	}
+	return nil
+}
+
+// checkPrecondition checks the If-Match header of a request changing a stream against the ETag of its topic, failing
+// with a 412 status when the topic changed since the caller described it, so that concurrent controllers don't
+// clobber each other's changes. When If-Match is required, changing the metadata of an existing topic without it
+// fails with a 428 status.
+func (rh *TopicCreationRequestHandler) checkPrecondition(request *http.Request, topicName string, changing bool) error {
+	ifMatch := strings.TrimSpace(request.Header.Get("If-Match"))
+	if ifMatch == "" && !(rh.RequireIfMatch && changing) {
+		return nil
+	}
+	etag, exists, err := rh.etag(topicName)
+	if err != nil {
+		return err
+	}
+	switch {
+	case ifMatch == "" && exists:
+		return &StatusError{Status: http.StatusPreconditionRequired, Message: "Requests changing streams should carry the ETag they were described with in an If-Match header"}
+	case ifMatch == "":
+		return nil
+	case !exists:
+		return &StatusError{Status: http.StatusPreconditionFailed, Message: fmt.Sprintf("If-Match doesn't match topic %q, which doesn't exist", topicName)}
+	case !matches(ifMatch, etag):
+		return &StatusError{Status: http.StatusPreconditionFailed, Message: fmt.Sprintf("Topic %q changed since it was described, describe it again for its current ETag", topicName)}
+	}
+	return nil
+}
+
+// matches tells whether an If-Match header lists an ETag, or is "*", strong comparison not matching weak ETags
+func matches(ifMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		if candidate = strings.TrimSpace(candidate); candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/events"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . EventPublisher
+
+// EventPublisher publishes the events of the lifecycle of streams
+type EventPublisher interface {
+	Publish(eventType string, stream events.Stream)
+}
+
+// publish publishes an event about the stream of a topic, with its spec when known, and journals the decision
+func (rh *TopicCreationRequestHandler) publish(eventType, topicName string, spec *client.TopicSpec, archived bool) {
+	rh.journal(eventType, topicName, spec, archived)
+	if rh.Events == nil {
+		return
+	}
+	namespace, stream, _ := validation.ParseTopicName(topicName)
+	event := events.Stream{Namespace: namespace, Stream: stream, Topic: topicName, Archived: archived}
+	if spec != nil {
+		event.Partitions, event.ReplicationFactor, event.Config = spec.NumPartitions, spec.ReplicationFactor, spec.ConfigEntries
+		// the partitions and replicas the broker defaults to are only known to the broker
+		if spec.NumPartitions == client.BrokerDefault {
+			event.Partitions = 0
+		}
+		if spec.ReplicationFactor == client.BrokerDefault {
+			event.ReplicationFactor = 0
+		}
+	}
+	rh.Events.Publish(eventType, event)
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fieldOperators are the operators of the requirements of field selectors, those that others start with first
+var fieldOperators = []string{"!=", "==", ">=", "<=", "=", ">", "<"}
+
+// fieldSelector selects the items of a listing by their fields, as kubernetes selects resources, with comparisons of
+// numbers on top: its requirements are separated by commas, and are all met by the items selected
+type fieldSelector []fieldRequirement
+
+// fieldRequirement is met by items whose field has a value equal to, different from, or, for numbers, greater or
+// less than Value. Items without the field only meet != requirements.
+type fieldRequirement struct {
+	Field    string
+	Operator string
+	Value    string
+	number   float64
+}
+
+func parseFieldSelector(selector string) (fieldSelector, error) {
+	if strings.TrimSpace(selector) == "" {
+		return nil, nil
+	}
+	var requirements fieldSelector
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		var requirement fieldRequirement
+		for _, operator := range fieldOperators {
+			if field, value, ok := strings.Cut(term, operator); ok {
+				requirement = fieldRequirement{Field: strings.TrimSpace(field), Operator: operator, Value: strings.TrimSpace(value)}
+				break
+			}
+		}
+		if requirement.Field == "" || strings.ContainsAny(requirement.Field, "!=<>") {
+			return nil, fmt.Errorf("%q should be of the form field=value, field!=value, field>number or field<number", term)
+		}
+		if requirement.Operator == "==" {
+			requirement.Operator = "="
+		}
+		if strings.ContainsAny(requirement.Operator, "<>") {
+			number, err := strconv.ParseFloat(requirement.Value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q should compare %s with a number, got %q", term, requirement.Field, requirement.Value)
+			}
+			requirement.number = number
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements, nil
+}
+
+// matches tells whether the item whose fields are looked up meets all the requirements
+func (s fieldSelector) matches(field func(name string) (string, bool)) bool {
+	for _, requirement := range s {
+		value, ok := field(requirement.Field)
+		if !requirement.meets(value, ok) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r fieldRequirement) meets(value string, ok bool) bool {
+	switch r.Operator {
+	case "=":
+		return ok && value == r.Value
+	case "!=":
+		return !ok || value != r.Value
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if !ok || err != nil {
+		return false
+	}
+	switch r.Operator {
+	case ">":
+		return number > r.number
+	case "<":
+		return number < r.number
+	case ">=":
+		return number >= r.number
+	default:
+		return number <= r.number
+	}
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// parseGroup returns the consumer group requested with the group parameter, nil when none is, starting at the
+// position of the startAt parameter: earliest, latest (the default) or an RFC 3339 timestamp
+func parseGroup(request *http.Request) (*GroupRequest, error) {
+	name := request.URL.Query().Get("group")
+	startAt := request.URL.Query().Get("startAt")
+	if name == "" {
+		if startAt != "" {
+			return nil, fmt.Errorf("startAt requires a group")
+		}
+		return nil, nil
+	}
+	group := &GroupRequest{Name: name, StartAt: sarama.OffsetNewest}
+	switch startAt {
+	case "", "latest":
+	case "earliest":
+		group.StartAt = sarama.OffsetOldest
+	default:
+		timestamp, err := time.Parse(time.RFC3339, startAt)
+		if err != nil {
+			return nil, fmt.Errorf("startAt should be earliest, latest or an RFC 3339 timestamp, got %q", startAt)
+		}
+		group.StartAt = timestamp.UnixMilli()
+	}
+	return group, nil
+}
+
+// createGroup commits the offsets a consumer group starts consuming a topic from, unless it already committed some,
+// in which case the group is left alone
+func (rh *TopicCreationRequestHandler) createGroup(topicName string, request GroupRequest) (*Group, error) {
+	progress, err := rh.KafkaClient.GroupProgress(request.Name, topicName)
+	if err != nil {
+		rh.Logger.Error("Error describing the progress of consumer group", "topic", topicName, "group", request.Name, "error", err)
+		return nil, rh.kafkaFailure(err, "Error describing consumer group %q of topic %q: %v", request.Name, topicName, err)
+	}
+	committed := map[int32]int64{}
+	for _, partition := range progress {
+		if partition.Committed >= 0 {
+			committed[partition.Partition] = partition.Committed
+		}
+	}
+	if len(committed) > 0 {
+		rh.Logger.Debug("Consumer group already exists", "topic", topicName, "group", request.Name)
+		return &Group{Name: request.Name, Offsets: committed}, nil
+	}
+	offsets, err := rh.KafkaClient.CommitGroupOffsets(request.Name, topicName, request.StartAt)
+	if err == sarama.ErrUnknownMemberId || err == sarama.ErrIllegalGeneration || err == sarama.ErrRebalanceInProgress {
+		rh.Logger.Info("Refusing to create consumer group", "topic", topicName, "group", request.Name, "reason", "the group has members")
+		return nil, &StatusError{Status: http.StatusConflict, Message: fmt.Sprintf("Refusing to create consumer group %q of topic %q: the group has members", request.Name, topicName)}
+	}
+	if err != nil {
+		rh.Logger.Error("Error creating consumer group", "topic", topicName, "group", request.Name, "error", err)
+		return nil, rh.kafkaFailure(err, "Error creating consumer group %q of topic %q: %v", request.Name, topicName, err)
+	}
+	rh.Logger.Info("Created consumer group", "topic", topicName, "group", request.Name, "offsets", offsets)
+	return &Group{Name: request.Name, Offsets: offsets, Created: true}, nil
+}
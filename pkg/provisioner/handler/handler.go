@@ -1,74 +1,696 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/authz"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/history"
 	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
-	"io"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/policy"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/quota"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+	"io/ioutil"
+	"log/slog"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 )
 
 type TopicCreationRequestHandler struct {
 	KafkaClient client.KafkaClient
-	Gateway     string
-	Writer      io.Writer
+	// Backend, when set, provisions the streams of PUT, GET and DELETE requests rather than KafkaClient
+	Backend Backend
+	// Backends, when set, are the other backends requests may choose by name, with the backend parameter or the
+	// X-Riff-Backend header
+	Backends map[string]NamedBackend
+	// Gateway is the address of the gRPC endpoint of the gateway
+	Gateway string
+	// GatewaySelector, when set, picks the address of the gRPC endpoint of the gateway among several, rather than
+	// Gateway
+	GatewaySelector GatewaySelector
+	// GatewayTemplate, when set, renders the address of the gRPC endpoint of the gateway of each namespace, e.g.
+	// gateway.{{.Namespace}}.svc.cluster.local:6565, for deployments running a gateway per namespace
+	GatewayTemplate *template.Template
+	// GatewayTLS tells whether the gRPC endpoint of the gateway is served over TLS
+	GatewayTLS bool
+	// GatewayHTTP, when set, is the base URL of the HTTP API of the gateway, e.g. https://gateway.example.com:8080
+	GatewayHTTP string
+	Logger      *slog.Logger
+	// Replication, when set, allows streams to be flagged for cross-cluster replication
+	Replication *ReplicationPolicy
+	// Quota limits what each namespace may provision
+	Quota quota.Limits
+	// PartitionBudget limits the total number of partitions of the cluster
+	PartitionBudget quota.ClusterBudget
+	// Policy, when set, may deny or amend the creation of topics
+	Policy policy.Evaluator
+	// Rules restrict the specs of the topics that may be created
+	Rules validation.Rules
+	// BrokerDefaults creates the topics of streams with the default partitions and replication factor of the
+	// broker, rather than a single partition and replica, unless the provisioning policy chooses their spec
+	BrokerDefaults bool
+	// MaxPayloadBytes, when positive, sizes the max.message.bytes config of created topics for the payloads
+	// the gateway accepts
+	MaxPayloadBytes int
+	// RepartitionRetention is how long the companion repartition topics of streams retain their records,
+	// DefaultRepartitionRetention when zero
+	RepartitionRetention time.Duration
+	// RetryAfter is suggested to callers of requests failing with a transient Kafka error, 5 seconds when zero
+	RetryAfter time.Duration
+	// Backoff, when set, tells how long until Kafka is expected to take requests again, suggested to callers instead
+	// of RetryAfter when longer, e.g. while a circuit breaker is open
+	Backoff func() time.Duration
+	// Authorizer, when set, requires callers to present a bearer token allowed to manage streams in the namespace
+	Authorizer authz.Authorizer
+	// Subjects, when set, deletes the schema subjects of the streams deleted
+	Subjects SubjectDeleter
+	// HardDeleteSubjects deletes schema subjects permanently, rather than softly
+	HardDeleteSubjects bool
+	// Archive, when enabled, archives the topics of deleted streams rather than deleting them right away
+	Archive ArchivePolicy
+	// Migrator, when set, allows streams to be migrated to a new name, copying their records to its topic
+	Migrator Migrator
+	// Events, when set, publishes the events of streams being provisioned, altered and deleted
+	Events EventPublisher
+	// TransactionalIDPrincipal, when set, renders the Kafka principal of each processor granted the transactional ids
+	// reserved for it, e.g. User:{{.Namespace}}.{{.Processor}}
+	TransactionalIDPrincipal *template.Template
+	// Operations, when set, keeps the recent provisioning operations, listed at /operations
+	Operations *history.History
+	// Journal records each provisioning decision in the compacted journal topic, listed at /journal
+	Journal bool
+	// Secondary, when set, is the passive region the streams of Kafka are provisioned in as well
+	Secondary *SecondaryRegion
+	// Mirrors, when set, allows streams to be mirrored on a linked cluster, to be consumed there read-only
+	Mirrors MirrorManager
+	// ReadOnly serves the streams, topics and health of the cluster as a standby would, e.g. pointed at a replica
+	// cluster for disaster recovery, rejecting the requests changing them with a 503 status
+	ReadOnly bool
+	// ETags returns the ETag of the topic of Kafka streams when describing them, checking the If-Match header of the
+	// requests changing them against it
+	ETags bool
+	// RequireIfMatch rejects the requests changing the metadata of existing Kafka streams without an If-Match header
+	RequireIfMatch bool
+}
+
+// GatewaySelector picks the gRPC endpoint of the gateway provisioning responses point to among several
+type GatewaySelector interface {
+	Selected() string
 }
 
 func (rh *TopicCreationRequestHandler) GetHandlerFunc() http.HandlerFunc {
 	return func(responseWriter http.ResponseWriter, request *http.Request) {
+		if rh.rejectMutation(responseWriter, request) {
+			return
+		}
+		if request.URL.Path == CapabilitiesPath {
+			rh.capabilities(responseWriter, request)
+			return
+		}
+		if request.URL.Path == OperationsPath {
+			rh.operations(responseWriter, request)
+			return
+		}
 		parts := strings.Split(request.URL.Path[1:], "/")
-		if len(parts) != 2 {
-			responseWriter.WriteHeader(http.StatusBadRequest)
-			_, _ = fmt.Fprintf(responseWriter, "URLs should be of the form /<namespace>/<stream-name>\n")
-			return
-		}
-		// NOTE: choice of underscore as separator is important as it is not allowed in k8s names
-		topicName := fmt.Sprintf("%s_%s", parts[0], parts[1])
-		topicExists, kafkaError := rh.KafkaClient.TopicExists(topicName)
-		if kafkaError != nil {
-			responseWriter.WriteHeader(http.StatusInternalServerError)
-			if err := kafkaError.GeneralError; err != nil {
-				_, _ = fmt.Fprintf(rh.Writer, "Error trying to list topics to see if %q exists: %v\n", topicName, err)
-				_, _ = fmt.Fprintf(responseWriter, "Error trying to list topics to see if %q exists: %v\n", topicName, err)
+		// but for streams and capabilities, the API manages Kafka topics
+		if rh.KafkaClient == nil && (len(parts) != 2 || request.URL.Path == PlanPath) {
+			rh.writeErrorf(responseWriter, http.StatusNotImplemented, "", "Only streams at /<namespace>/<stream-name> are provisioned by this backend")
+			return
+		}
+		if request.URL.Path == CatalogPath {
+			rh.catalog(responseWriter, request)
+			return
+		}
+		if request.URL.Path == StatePath {
+			rh.state(responseWriter, request)
+			return
+		}
+		if request.URL.Path == PlanPath {
+			rh.plan(responseWriter, request)
+			return
+		}
+		if request.URL.Path == ReconcilePath {
+			rh.reconcile(responseWriter, request)
+			return
+		}
+		if request.URL.Path == JournalPath {
+			rh.listJournal(responseWriter, request)
+			return
+		}
+		if len(parts) == 1 && parts[0] != "" && request.Method == http.MethodDelete {
+			rh.deprovisionNamespace(responseWriter, request, parts[0])
+			return
+		}
+		if len(parts) == 3 && parts[2] == LeadersSegment {
+			rh.electLeaders(responseWriter, request, parts[0], parts[1])
+			return
+		}
+		if len(parts) == 3 && parts[2] == MirrorSegment {
+			rh.mirror(responseWriter, request, parts[0], parts[1])
+			return
+		}
+		if len(parts) == 4 && parts[2] == ChangelogsSegment {
+			rh.changelog(responseWriter, request, parts[0], parts[1], parts[3])
+			return
+		}
+		migrating := len(parts) == 3 && parts[2] == MigrationSegment
+		if len(parts) != 2 && !migrating {
+			rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidName, "URLs should be of the form /<namespace>/<stream-name>")
+			return
+		}
+		if _, ok := verbs[request.Method]; !ok {
+			rh.writeErrorf(responseWriter, http.StatusMethodNotAllowed, "", "Method %s is not allowed", request.Method)
+			return
+		}
+		if rh.Authorizer != nil && !rh.authorize(responseWriter, request, parts[0], verbs[request.Method]) {
+			return
+		}
+		if migrating {
+			rh.migration(responseWriter, request, parts[0], parts[1])
+			return
+		}
+		replicate, err := parseBoolParameter(request, "replicate")
+		if err != nil {
+			rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid value for parameter \"replicate\": %v", err)
+			return
+		}
+		if replicate && rh.Replication == nil {
+			rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeNotConfigured, "Cross-cluster replication is not configured for this provisioner")
+			return
+		}
+		namespace, name := parts[0], parts[1]
+		backend, err := rh.selectBackend(request, namespace)
+		if err != nil {
+			rh.writeError(responseWriter, err)
+			return
+		}
+		switch request.Method {
+		case http.MethodGet:
+			stream, err := backend.DescribeStream(request.Context(), namespace, name)
+			if err != nil {
+				rh.writeError(responseWriter, err)
+				return
+			}
+			if rh.tagged(backend) {
+				if err := rh.writeETag(responseWriter, stream.Topic); err != nil {
+					rh.writeError(responseWriter, err)
+					return
+				}
+			}
+			rh.writeStream(responseWriter, request, http.StatusOK, namespace, name, stream, false)
+		case http.MethodDelete:
+			stream, err := backend.DeleteStream(request.Context(), namespace, name)
+			if err != nil {
+				rh.writeError(responseWriter, err)
 				return
 			}
+			if stream == nil {
+				responseWriter.WriteHeader(http.StatusNoContent)
+				return
+			}
+			rh.writeStream(responseWriter, request, http.StatusAccepted, namespace, name, stream, false)
+		default:
+			metadata, spec, err := parseBody(responseWriter, request)
+			if err != nil {
+				rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidBody, "Invalid stream metadata: %v", err)
+				return
+			}
+			if spec != nil && backend.Capabilities().Backend != "kafka" {
+				rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidBody, "The spec of topics only applies to streams provisioned on Kafka")
+				return
+			}
+			maxMessageBytes, err := parseIntParameter(request, "maxMessageBytes")
+			if err != nil || maxMessageBytes < 0 {
+				rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid value for parameter \"maxMessageBytes\": should be a number of bytes, got %q", request.URL.Query().Get("maxMessageBytes"))
+				return
+			}
+			tieredStorage, err := parseBoolParameter(request, "tieredStorage")
+			if err != nil {
+				rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid value for parameter \"tieredStorage\": %v", err)
+				return
+			}
+			retention, err := parseRetention(request, tieredStorage)
+			if err != nil {
+				rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid retention: %v", err)
+				return
+			}
+			group, err := parseGroup(request)
+			if err != nil {
+				rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid group: %v", err)
+				return
+			}
+			repartition, err := parseBoolParameter(request, "repartition")
+			if err != nil {
+				rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid value for parameter \"repartition\": %v", err)
+				return
+			}
+			mirror, err := parseBoolParameter(request, "mirror")
+			if err != nil {
+				rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid value for parameter \"mirror\": %v", err)
+				return
+			}
+			if mirror && rh.Mirrors == nil {
+				rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeNotConfigured, "Cluster linking is not configured for this provisioner")
+				return
+			}
+			if rh.tagged(backend) {
+				topicName, err := StreamName(validation.KafkaNaming{}, namespace, name)
+				if err == nil {
+					// the ETag checked holds until the stream is written and its new ETag returned
+					defer preconditions.lock(topicName)()
+					err = rh.checkPrecondition(request, topicName, metadata != nil || spec != nil)
+				}
+				if err != nil {
+					rh.writeError(responseWriter, err)
+					return
+				}
+			}
+			rh.Logger.Debug("Received provisioning request", "namespace", namespace, "stream", name)
+			stream, created, err := backend.CreateStream(request.Context(), StreamRequest{Namespace: namespace, Stream: name, Metadata: metadata, Replicate: replicate, MaxMessageBytes: maxMessageBytes, Retention: retention, TieredStorage: tieredStorage, Group: group, Repartition: repartition, Mirror: mirror, Spec: spec})
+			if err != nil {
+				rh.writeError(responseWriter, err)
+				return
+			}
+			statusCode := http.StatusOK
+			if created {
+				statusCode = http.StatusCreated
+			}
+			if rh.tagged(backend) {
+				if err := rh.writeETag(responseWriter, stream.Topic); err != nil {
+					rh.writeError(responseWriter, err)
+					return
+				}
+			}
+			rh.writeStream(responseWriter, request, statusCode, namespace, name, stream, replicate)
+			rh.Logger.Info("Reported successful topic", "topic", stream.Topic)
+		}
+	}
+}
 
-			kafkaErrorCode := kafkaError.KError
-			_, _ = fmt.Fprintf(rh.Writer, "Error trying to list topics to see if %q exists: %v\n", topicName, kafkaErrorCode)
-			_, _ = fmt.Fprintf(responseWriter, "Error trying to list topics to see if %q exists: %v\n", topicName, kafkaErrorCode)
-			return
+// writeStream responds with the coordinates of a stream and its metadata, and the warnings of its backend
+func (rh *TopicCreationRequestHandler) writeStream(responseWriter http.ResponseWriter, request *http.Request, statusCode int, namespace, name string, stream *Stream, replicate bool) {
+	for _, warning := range stream.Warnings {
+		responseWriter.Header().Add("Warning", fmt.Sprintf("299 - %q", warning))
+	}
+	res := result{
+		Gateway:        rh.gateway(namespace),
+		Gateways:       rh.gateways(namespace, name),
+		Topic:          stream.Topic,
+		Health:         stream.Health,
+		Retention:      stream.Retention,
+		Group:          stream.Group,
+		Repartition:    stream.Repartition,
+		StreamMetadata: described(stream.Metadata),
+	}
+	if stream.Gateway != "" {
+		res.Gateway, res.Gateways = stream.Gateway, gatewaysResult{GRPC: grpcEndpoint{Address: stream.Gateway}}
+	}
+	if replicate {
+		res.Replication = rh.Replication.describe(stream.Topic)
+	}
+	res.Mirror = describedMirror(stream.mirror)
+	if stream.secondary != nil {
+		res.Regions = rh.regions(namespace, stream.Topic, stream.secondary)
+	}
+	rh.writeDocument(responseWriter, request, statusCode, res)
+}
+
+// described returns the metadata of a stream as described to clients, leaving out the spec of its topic, which is
+// bookkeeping for reconciliation
+func described(metadata *client.StreamMetadata) *client.StreamMetadata {
+	if metadata == nil || metadata.Spec == nil {
+		return metadata
+	}
+	m := *metadata
+	m.Spec = nil
+	return &m
+}
+
+// maxMetadataBytes bounds the body of provisioning requests
+const maxMetadataBytes = 64 * 1024
+
+// parseBody reads the optional metadata of a stream from the body of a provisioning request, and the spec of its
+// topic when the body is of version SpecVersion, returning nil for those there are none of. Bodies without a version
+// carry the metadata of the stream only, as they did before bodies were versioned.
+func parseBody(responseWriter http.ResponseWriter, request *http.Request) (*client.StreamMetadata, *client.TopicSpec, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(responseWriter, request.Body, maxMetadataBytes))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return nil, nil, nil
+	}
+	var version struct {
+		APIVersion string `json:"apiVersion"`
+	}
+	if err := json.Unmarshal(body, &version); err != nil {
+		return nil, nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	switch version.APIVersion {
+	case "":
+		metadata := &client.StreamMetadata{}
+		if err := decoder.Decode(metadata); err != nil {
+			return nil, nil, err
 		}
-		if !topicExists {
-			if err := rh.KafkaClient.CreateTopic(topicName); err != nil {
-				responseWriter.WriteHeader(http.StatusInternalServerError)
-				_, _ = fmt.Fprintf(rh.Writer, "Error creating topic %q: %v\n", topicName, err)
-				_, _ = fmt.Fprintf(responseWriter, "Error creating topic %q: %v\n", topicName, err)
-				return
+		if err := validateMetadata(metadata); err != nil {
+			return nil, nil, err
+		}
+		return metadata, nil, nil
+	case SpecVersion:
+		spec := &streamSpec{}
+		if err := decoder.Decode(spec); err != nil {
+			return nil, nil, err
+		}
+		if err := spec.validate(); err != nil {
+			return nil, nil, err
+		}
+		metadata := spec.metadata()
+		if metadata != nil {
+			if err := validateMetadata(metadata); err != nil {
+				return nil, nil, err
 			}
-			responseWriter.WriteHeader(http.StatusCreated)
-		} else {
-			responseWriter.WriteHeader(http.StatusOK)
 		}
+		return metadata, spec.topicSpec(), nil
+	default:
+		return nil, nil, fmt.Errorf("apiVersion should be %s, got %q", SpecVersion, version.APIVersion)
+	}
+}
+
+// labelSeparators are the characters label names can't contain, as they separate the terms of label selectors
+const labelSeparators = "=!, \t"
+
+// validateMetadata checks the metadata requested for a stream
+func validateMetadata(metadata *client.StreamMetadata) error {
+	if metadata.ContentType != "" {
+		if mediaType, _, err := mime.ParseMediaType(metadata.ContentType); err != nil || !strings.Contains(mediaType, "/") {
+			return fmt.Errorf("content type %q should be a media type, e.g. application/json", metadata.ContentType)
+		}
+	}
+	if err := client.ValidateEnvelope(metadata.Envelope); err != nil {
+		return err
+	}
+	for name := range metadata.Labels {
+		if name == "" {
+			return fmt.Errorf("label names can't be empty")
+		}
+		if strings.ContainsAny(name, labelSeparators) {
+			return fmt.Errorf("label name %q can't contain whitespace or any of %q, which select labels", name, "=!,")
+		}
+	}
+	if metadata.Archived != nil {
+		return fmt.Errorf("streams are archived by deleting them")
+	}
+	if metadata.Migration != nil {
+		return fmt.Errorf("streams are migrated at /<namespace>/<stream-name>/%s", MigrationSegment)
+	}
+	if metadata.Spec != nil {
+		return fmt.Errorf("the spec of the topics of streams is recorded when they are provisioned")
+	}
+	if metadata.Schema != nil {
+		return metadata.Schema.Validate()
+	}
+	return nil
+}
+
+// gatewayAddress is what gateway templates are rendered with
+type gatewayAddress struct {
+	Namespace string
+}
+
+// ParseGatewayTemplate parses the template of the address of the gateway of each namespace, checking that it renders
+func ParseGatewayTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("gateway").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(ioutil.Discard, gatewayAddress{Namespace: "my-ns"}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// gateway returns the address of the gRPC endpoint of the gateway of a namespace
+func (rh *TopicCreationRequestHandler) gateway(namespace string) string {
+	if rh.GatewayTemplate != nil {
+		address := &strings.Builder{}
+		if err := rh.GatewayTemplate.Execute(address, gatewayAddress{Namespace: namespace}); err != nil {
+			rh.Logger.Error("Error rendering the address of the gateway", "namespace", namespace, "error", err)
+			return rh.Gateway
+		}
+		return address.String()
+	}
+	if rh.GatewaySelector != nil {
+		return rh.GatewaySelector.Selected()
+	}
+	return rh.Gateway
+}
+
+// gateways describes the endpoints of the gateway by protocol, the HTTP one being the URL of the stream
+func (rh *TopicCreationRequestHandler) gateways(namespace, stream string) gatewaysResult {
+	gateways := gatewaysResult{GRPC: grpcEndpoint{Address: rh.gateway(namespace), TLS: rh.GatewayTLS}}
+	if rh.GatewayHTTP != "" {
+		gateways.HTTP = &httpEndpoint{
+			URL: fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(rh.GatewayHTTP, "/"), namespace, stream),
+			TLS: strings.HasPrefix(rh.GatewayHTTP, "https://"),
+		}
+	}
+	return gateways
+}
+
+// withMaxMessageBytes sizes the messages of a topic for the payloads the gateway accepts, unless the spec
+// already does
+func withMaxMessageBytes(spec client.TopicSpec, maxPayloadBytes int) client.TopicSpec {
+	if _, ok := spec.ConfigEntries["max.message.bytes"]; ok {
+		return spec
+	}
+	return withConfigEntry(spec, "max.message.bytes", strconv.Itoa(client.MaxMessageBytes(maxPayloadBytes)))
+}
+
+// withConfigEntry sets a config of a topic, leaving the config entries of the spec, which may be shared, alone
+func withConfigEntry(spec client.TopicSpec, name, value string) client.TopicSpec {
+	configEntries := make(map[string]*string, len(spec.ConfigEntries)+1)
+	for name, value := range spec.ConfigEntries {
+		configEntries[name] = value
+	}
+	configEntries[name] = &value
+	spec.ConfigEntries = configEntries
+	return spec
+}
+
+// kafkaErrorStatus returns the status reporting a Kafka error: 503 with a Retry-After header when retrying
+// may succeed, 422 when the request itself is at fault and 500 otherwise
+func (rh *TopicCreationRequestHandler) kafkaErrorStatus(responseWriter http.ResponseWriter, err error) int {
+	status, retryAfter := rh.classify(err)
+	if retryAfter > 0 {
+		responseWriter.Header().Set("Retry-After", retryAfterSeconds(retryAfter))
+	}
+	return status
+}
+
+// kafkaFailure reports a Kafka error with the status kafkaErrorStatus tells
+func (rh *TopicCreationRequestHandler) kafkaFailure(err error, format string, args ...interface{}) *StatusError {
+	status, retryAfter := rh.classify(err)
+	return &StatusError{Status: status, RetryAfter: retryAfter, Message: fmt.Sprintf(format, args...)}
+}
 
-		if err := encodeResponse(responseWriter, rh.Gateway, topicName); err != nil {
-			_, _ = fmt.Fprintf(rh.Writer, "Failed to write json response: %v", err)
+// classify returns the status reporting a Kafka error, and how long to suggest waiting before retrying, if at all
+func (rh *TopicCreationRequestHandler) classify(err error) (int, time.Duration) {
+	switch client.Classify(err) {
+	case client.Retryable:
+		return http.StatusServiceUnavailable, rh.retryAfter()
+	case client.Terminal:
+		return http.StatusUnprocessableEntity, 0
+	default:
+		return http.StatusInternalServerError, 0
+	}
+}
+
+// retryAfter returns how long to suggest waiting before retrying requests failing transiently
+func (rh *TopicCreationRequestHandler) retryAfter() time.Duration {
+	retryAfter := rh.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = 5 * time.Second
+	}
+	if rh.Backoff != nil {
+		retryAfter = max(retryAfter, rh.Backoff())
+	}
+	return retryAfter
+}
+
+// authorize checks the permissions of the bearer token of the request on streams of the namespace, all namespaces
+// when empty, writing an error response and returning false when the caller is not allowed
+func (rh *TopicCreationRequestHandler) authorize(responseWriter http.ResponseWriter, request *http.Request, namespace, verb string) bool {
+	token := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == request.Header.Get("Authorization") {
+		responseWriter.Header().Set("WWW-Authenticate", "Bearer")
+		rh.writeErrorf(responseWriter, http.StatusUnauthorized, "", "Requests should carry a kubernetes bearer token")
+		return false
+	}
+	decision, err := rh.Authorizer.Authorize(token, namespace, verb)
+	if err != nil {
+		rh.Logger.Error("Error authorizing request", "namespace", namespace, "error", err)
+		rh.writeErrorf(responseWriter, http.StatusInternalServerError, "", "Error authorizing request on namespace %q: %v", namespace, err)
+		return false
+	}
+	if decision.Username != "" {
+		history.SetRequester(request.Context(), decision.Username)
+	}
+	if !decision.Authenticated {
+		responseWriter.Header().Set("WWW-Authenticate", "Bearer")
+		rh.writeErrorf(responseWriter, http.StatusUnauthorized, "", "Invalid bearer token: %s", decision.Reason)
+		return false
+	}
+	if !decision.Allowed {
+		rh.Logger.Info("Denied request", "namespace", namespace, "reason", decision.Reason)
+		rh.writeErrorf(responseWriter, http.StatusForbidden, "", "Forbidden: %s", decision.Reason)
+		return false
+	}
+	return true
+}
+
+// AuthorizeClusterWide requires the callers of an endpoint administering the provisioner, such as changing its log
+// level, to be granted the list verb on streams across the cluster to read it, and the update verb to change it,
+// serving any caller when Authorizer isn't set
+func (rh *TopicCreationRequestHandler) AuthorizeClusterWide(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		verb := "update"
+		if request.Method == http.MethodGet {
+			verb = "list"
+		}
+		if rh.Authorizer != nil && !rh.authorize(responseWriter, request, "", verb) {
 			return
 		}
-		_, _ = fmt.Fprintf(rh.Writer, "Reported successful topic %q\n", topicName)
+		next.ServeHTTP(responseWriter, request)
+	})
+}
+
+// verbs maps HTTP methods to the kubernetes verbs callers need to be granted on streams
+var verbs = map[string]string{
+	http.MethodGet:    "get",
+	http.MethodPut:    "create",
+	http.MethodDelete: "delete",
+}
+
+// checkConfigs verifies that the topics of the cluster take the given config entries
+func (rh *TopicCreationRequestHandler) checkConfigs(topicName string, configEntries map[string]*string) error {
+	if len(configEntries) == 0 {
+		return nil
+	}
+	keys, err := rh.KafkaClient.TopicConfigKeys()
+	if err != nil {
+		rh.Logger.Error("Error describing topic configs", "topic", topicName, "error", err)
+		return rh.kafkaFailure(err, "Error describing topic configs for topic %q: %v", topicName, err)
 	}
+	if err := validation.ValidateConfigs(keys, configEntries); err != nil {
+		rh.Logger.Info("Refusing to provision topic", "topic", topicName, "error", err)
+		return &StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: %v", topicName, err)}
+	}
+	return nil
 }
 
-func encodeResponse(w http.ResponseWriter, gateway string, topicName string) error {
-	w.Header().Set("Content-Type", "application/json")
-	res := result{
-		Gateway: gateway,
-		Topic:   topicName,
+// checkMaxMessageBytes verifies that the brokers take the records a topic is sized for, their message.max.bytes
+// capping the max.message.bytes of the topics streams ask for
+func (rh *TopicCreationRequestHandler) checkMaxMessageBytes(topicName string, maxMessageBytes int) error {
+	value, err := rh.KafkaClient.BrokerConfig("message.max.bytes")
+	if err != nil {
+		rh.Logger.Error("Error describing broker configs", "topic", topicName, "error", err)
+		return rh.kafkaFailure(err, "Error describing broker configs for topic %q: %v", topicName, err)
 	}
-	return json.NewEncoder(w).Encode(res)
+	if value == nil {
+		return nil
+	}
+	if limit, err := strconv.Atoi(*value); err == nil && maxMessageBytes > limit {
+		rh.Logger.Info("Refusing to create topic", "topic", topicName, "maxMessageBytes", maxMessageBytes, "limit", limit)
+		return &StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to create topic %q: max.message.bytes %d exceeds the message.max.bytes of the brokers, %d", topicName, maxMessageBytes, limit)}
+	}
+	return nil
+}
+
+// checkCapacity verifies that the namespace quota and the cluster partition budget leave room for the topic,
+// returning the warning of the budget, if any
+func (rh *TopicCreationRequestHandler) checkCapacity(namespace, topicName string, spec client.TopicSpec) (string, error) {
+	if rh.Quota.Unlimited() && rh.PartitionBudget.Unlimited() {
+		return "", nil
+	}
+	topics, err := rh.KafkaClient.ListTopics()
+	if err != nil {
+		rh.Logger.Error("Error listing topics to check capacity", "topic", topicName, "error", err)
+		return "", rh.kafkaFailure(err, "Error listing topics to check capacity for topic %q: %v", topicName, err)
+	}
+	partitions := int(spec.NumPartitions)
+	// the partitions the broker defaults to are only known once the topic is created
+	if spec.NumPartitions == client.BrokerDefault {
+		partitions = 1
+	}
+	if err := rh.Quota.Check(quota.NamespaceUsage(topics, namespace), partitions); err != nil {
+		rh.Logger.Info("Refusing to create topic over namespace quota", "topic", topicName, "namespace", namespace, "error", err)
+		return "", &StatusError{Status: http.StatusForbidden, Code: CodeQuotaExceeded, Message: fmt.Sprintf("Refusing to create topic %q for namespace %q: %v", topicName, namespace, err)}
+	}
+	warning, err := rh.PartitionBudget.Check(quota.ClusterPartitions(topics), partitions)
+	if err != nil {
+		rh.Logger.Warn("Refusing to create topic over cluster partition budget", "topic", topicName, "error", err)
+		return "", &StatusError{Status: http.StatusInsufficientStorage, Message: fmt.Sprintf("Refusing to create topic %q: %v", topicName, err)}
+	}
+	if warning != "" {
+		rh.Logger.Warn("Warning while creating topic", "topic", topicName, "warning", warning)
+	}
+	return warning, nil
+}
+
+func parseBoolParameter(request *http.Request, name string) (bool, error) {
+	value := request.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+func parseIntParameter(request *http.Request, name string) (int, error) {
+	value := request.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
 }
 
 type result struct {
-	Gateway string `json:"gateway"`
-	Topic   string `json:"topic"`
+	// Gateway is the address of the gRPC endpoint, kept for the clients predating Gateways
+	Gateway     string             `json:"gateway"`
+	Gateways    gatewaysResult     `json:"gateways"`
+	Topic       string             `json:"topic"`
+	Replication *replicationResult `json:"replication,omitempty"`
+	// Health tells the stream controller whether the stream is degraded, only described by GET requests
+	Health *client.TopicHealth `json:"health,omitempty"`
+	// Retention is the retention in effect for streams created by PUT requests
+	Retention *Retention `json:"retention,omitempty"`
+	// Group is the consumer group requested with the stream by PUT requests
+	Group *Group `json:"group,omitempty"`
+	// Repartition is the companion repartition topic requested with the stream by PUT requests
+	Repartition *Repartition `json:"repartition,omitempty"`
+	// Mirror is the mirror of the topic of the stream on the linked cluster, when it is mirrored
+	Mirror *mirrorResult `json:"mirror,omitempty"`
+	// Regions are the coordinates of the stream in the primary and secondary regions, when there is a secondary one
+	Regions map[string]regionResult `json:"regions,omitempty"`
+	*client.StreamMetadata
+}
+
+type gatewaysResult struct {
+	GRPC grpcEndpoint  `json:"grpc"`
+	HTTP *httpEndpoint `json:"http,omitempty"`
+}
+
+type grpcEndpoint struct {
+	Address string `json:"address"`
+	TLS     bool   `json:"tls"`
+}
+
+type httpEndpoint struct {
+	URL string `json:"url"`
+	TLS bool   `json:"tls"`
 }
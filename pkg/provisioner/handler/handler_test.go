@@ -1,16 +1,35 @@
 package handler_test
 
 import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/Shopify/sarama"
+	"github.com/klauspost/compress/zstd"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/authz"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/authz/authzfakes"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/clusterlink"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/events"
 	"github.com/projectriff/kafka-provisioner/pkg/provisioner/handler"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/handler/handlerfakes"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/history"
 	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
 	"github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka/kafkafakes"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/policy"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/policy/policyfakes"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/quota"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
 )
 
 var _ = Describe("Provisioner HTTP Handler", func() {
@@ -36,7 +55,7 @@ var _ = Describe("Provisioner HTTP Handler", func() {
 		creationHandler := &handler.TopicCreationRequestHandler{
 			KafkaClient: fakeKafkaClient,
 			Gateway:     gateway,
-			Writer:      ioutil.Discard}
+			Logger:      logger}
 		creationHandlerFunc = creationHandler.GetHandlerFunc()
 	})
 
@@ -50,8 +69,9 @@ var _ = Describe("Provisioner HTTP Handler", func() {
 		Expect(responseRecorder.Body.String()).To(MatchJSON(
 			fmt.Sprintf("{"+
 				"	\"gateway\":\"%s\","+
+				"	\"gateways\":{\"grpc\":{\"address\":\"%s\",\"tls\":false}},"+
 				"	\"topic\":\"%s_%s\""+
-				"}", gateway, existingTopicNamespace, existingTopicName)))
+				"}", gateway, gateway, existingTopicNamespace, existingTopicName)))
 	})
 
 	It("returns 201 if the topic is successfully created", func() {
@@ -63,7 +83,3139 @@ var _ = Describe("Provisioner HTTP Handler", func() {
 		Expect(responseRecorder.Code).To(Equal(http.StatusCreated),
 			fmt.Sprintf("Expected %d after topic creation request but got %d", http.StatusCreated, responseRecorder.Code))
 		Expect(responseRecorder.Body.String()).To(MatchJSON(
-			fmt.Sprintf(`{"gateway": "%s", "topic": "%s_%s"}`, gateway, existingTopicNamespace, existingTopicName)))
+			fmt.Sprintf(`{"gateway": "%s", "gateways": {"grpc": {"address": "%s", "tls": false}}, "topic": "%s_%s"}`,
+				gateway, gateway, existingTopicNamespace, existingTopicName)))
+	})
+
+	It("describes streams in YAML when the request accepts it", func() {
+		fakeKafkaClient.TopicExistsReturns(true, nil)
+		request := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/%s/%s", existingTopicNamespace, existingTopicName), nil)
+		request.Header.Set("Accept", "application/yaml")
+
+		creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		Expect(responseRecorder.Header().Get("Content-Type")).To(Equal("application/yaml"))
+		Expect(responseRecorder.Body.String()).To(Equal(fmt.Sprintf(
+			"gateway: %[1]s\ngateways:\n  grpc:\n    address: %[1]s\n    tls: false\ntopic: %[2]s\n", gateway, kafkaTopicName)))
+	})
+
+	It("describes the endpoints of the gateway by protocol", func() {
+		fakeKafkaClient.TopicExistsReturns(true, nil)
+		creationHandler := &handler.TopicCreationRequestHandler{
+			KafkaClient: fakeKafkaClient,
+			Gateway:     gateway,
+			GatewayTLS:  true,
+			GatewayHTTP: "https://gateway.example.com:8080/",
+			Logger:      logger,
+		}
+
+		creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, request)
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		Expect(responseRecorder.Body.String()).To(MatchJSON(fmt.Sprintf(`{
+			"gateway": "%s",
+			"gateways": {
+				"grpc": {"address": "%s", "tls": true},
+				"http": {"url": "https://gateway.example.com:8080/%s/%s", "tls": true}
+			},
+			"topic": "%s"
+		}`, gateway, gateway, existingTopicNamespace, existingTopicName, kafkaTopicName)))
+	})
+
+	It("points to the gateway endpoint selected among several", func() {
+		fakeKafkaClient.TopicExistsReturns(true, nil)
+		creationHandler := &handler.TopicCreationRequestHandler{
+			KafkaClient:     fakeKafkaClient,
+			Gateway:         gateway,
+			GatewaySelector: selectedGateway("liiklus-1.example.com"),
+			Logger:          logger,
+		}
+
+		creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, request)
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		Expect(responseRecorder.Body.String()).To(MatchJSON(fmt.Sprintf(
+			`{"gateway": "liiklus-1.example.com", "gateways": {"grpc": {"address": "liiklus-1.example.com", "tls": false}}, "topic": "%s"}`,
+			kafkaTopicName)))
+	})
+
+	It("renders the address of the gateway of the namespace of the stream", func() {
+		fakeKafkaClient.TopicExistsReturns(true, nil)
+		gatewayTemplate, err := handler.ParseGatewayTemplate("gateway.{{.Namespace}}.svc.cluster.local:6565")
+		Expect(err).NotTo(HaveOccurred())
+		creationHandler := &handler.TopicCreationRequestHandler{
+			KafkaClient:     fakeKafkaClient,
+			Gateway:         gateway,
+			GatewayTemplate: gatewayTemplate,
+			Logger:          logger,
+		}
+
+		creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, request)
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		address := fmt.Sprintf("gateway.%s.svc.cluster.local:6565", existingTopicNamespace)
+		Expect(responseRecorder.Body.String()).To(MatchJSON(fmt.Sprintf(
+			`{"gateway": "%s", "gateways": {"grpc": {"address": "%s", "tls": false}}, "topic": "%s"}`,
+			address, address, kafkaTopicName)))
+	})
+
+	It("refuses gateway templates that don't render", func() {
+		_, err := handler.ParseGatewayTemplate("gateway.{{.Tenant}}:6565")
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("lets the broker choose the partitions and replication factor of topics when configured to", func() {
+		fakeKafkaClient.TopicExistsReturns(false, nil)
+		creationHandler := &handler.TopicCreationRequestHandler{
+			KafkaClient:    fakeKafkaClient,
+			Gateway:        gateway,
+			Logger:         logger,
+			BrokerDefaults: true,
+		}
+
+		creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, request)
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+		_, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+		Expect(spec.NumPartitions).To(Equal(int32(client.BrokerDefault)))
+		Expect(spec.ReplicationFactor).To(Equal(int16(client.BrokerDefault)))
+	})
+
+	It("returns 422 when the cluster can't apply the defaults of the broker", func() {
+		fakeKafkaClient.TopicExistsReturns(false, nil)
+		fakeKafkaClient.CreateTopicReturns(&client.UnsupportedFeatureError{Feature: client.FeatureBrokerDefaults})
+		creationHandler := &handler.TopicCreationRequestHandler{
+			KafkaClient:    fakeKafkaClient,
+			Gateway:        gateway,
+			Logger:         logger,
+			BrokerDefaults: true,
+		}
+
+		creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, request)
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+	})
+
+	Context("when a stream is flagged for replication", func() {
+		var replicatedRequest *http.Request
+
+		BeforeEach(func() {
+			replicatedRequest = putRequest(fmt.Sprintf("/%s/%s?replicate=true", existingTopicNamespace, existingTopicName))
+		})
+
+		It("returns 400 if replication is not configured", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, replicatedRequest)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(errorOf(responseRecorder).Message).
+				To(Equal("Cross-cluster replication is not configured for this provisioner"))
+			Expect(errorOf(responseRecorder).Code).To(Equal(handler.CodeNotConfigured))
+			Expect(fakeKafkaClient.TopicExistsCallCount()).To(Equal(0))
+		})
+
+		It("creates the topic with the replication configs and reports the remote topic", func() {
+			minInSyncReplicas := "2"
+			creationHandler := &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Replication: &handler.ReplicationPolicy{
+					SourceClusterAlias: "primary",
+					ConfigEntries:      map[string]*string{"min.insync.replicas": &minInSyncReplicas},
+				},
+			}
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+
+			creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, replicatedRequest)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			_, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(spec.ConfigEntries).To(HaveKeyWithValue("min.insync.replicas", &minInSyncReplicas))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(fmt.Sprintf(`{
+				"gateway": "%s",
+				"gateways": {"grpc": {"address": "%s", "tls": false}},
+				"topic": "%s",
+				"replication": {
+					"sourceCluster": "primary",
+					"remoteTopic": "primary.%s",
+					"topicFilter": "%s"
+				}
+			}`, gateway, gateway, kafkaTopicName, kafkaTopicName, kafkaTopicName)))
+		})
+
+		It("returns 400 if the replication flag is not a boolean", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest(fmt.Sprintf("/%s/%s?replicate=maybe", existingTopicNamespace, existingTopicName)))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(errorOf(responseRecorder).Message).To(HavePrefix("Invalid value for parameter \"replicate\""))
+		})
+	})
+
+	Context("with a secondary region", func() {
+		var (
+			secondaryKafkaClient *kafkafakes.FakeKafkaClient
+			creationHandler      *handler.TopicCreationRequestHandler
+		)
+
+		BeforeEach(func() {
+			secondaryKafkaClient = &kafkafakes.FakeKafkaClient{}
+			creationHandler = &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Secondary: &handler.SecondaryRegion{
+					Name:        "us-west",
+					PrimaryName: "us-east",
+					Gateway:     "liiklus.us-west.example.com",
+					Connect: func() (client.KafkaClient, error) {
+						return secondaryKafkaClient, nil
+					},
+				},
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+		})
+
+		It("provisions the stream in both regions, with the same spec", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+			secondaryKafkaClient.TopicExistsReturns(false, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(secondaryKafkaClient.CreateTopicCallCount()).To(Equal(1))
+			topicName, spec := secondaryKafkaClient.CreateTopicArgsForCall(0)
+			Expect(topicName).To(Equal(kafkaTopicName))
+			_, primarySpec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(spec).To(Equal(primarySpec))
+			topicName, metadata := secondaryKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(topicName).To(Equal(kafkaTopicName))
+			Expect(metadata.Spec).To(Equal(&primarySpec))
+			Expect(secondaryKafkaClient.CloseCallCount()).To(Equal(1))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(fmt.Sprintf(`{
+				"gateway": "%s",
+				"gateways": {"grpc": {"address": "%s", "tls": false}},
+				"topic": "%s",
+				"regions": {
+					"us-east": {"gateway": "%s", "topic": "%s"},
+					"us-west": {"gateway": "liiklus.us-west.example.com", "topic": "%s", "state": "provisioned"}
+				}
+			}`, gateway, gateway, kafkaTopicName, gateway, kafkaTopicName, kafkaTopicName)))
+		})
+
+		It("provisions existing streams in the secondary region with the spec of their topic", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{kafkaTopicName: {NumPartitions: 6, ReplicationFactor: 3}}, nil)
+			secondaryKafkaClient.TopicExistsReturns(false, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			_, spec := secondaryKafkaClient.CreateTopicArgsForCall(0)
+			Expect(spec).To(Equal(client.TopicSpec{NumPartitions: 6, ReplicationFactor: 3}))
+		})
+
+		It("verifies the remote topic of streams flagged for replication, rather than creating it", func() {
+			creationHandler.Replication = &handler.ReplicationPolicy{SourceClusterAlias: "us-east"}
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+			secondaryKafkaClient.TopicExistsReturns(false, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest(fmt.Sprintf("/%s/%s?replicate=true", existingTopicNamespace, existingTopicName)))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(secondaryKafkaClient.CreateTopicCallCount()).To(BeZero())
+			Expect(secondaryKafkaClient.TopicExistsArgsForCall(0)).To(Equal("us-east." + kafkaTopicName))
+			result := map[string]interface{}{}
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &result)).To(Succeed())
+			Expect(result["regions"]).To(HaveKeyWithValue("us-west", map[string]interface{}{
+				"gateway": "liiklus.us-west.example.com", "topic": "us-east." + kafkaTopicName, "state": "pending",
+			}))
+
+			secondaryKafkaClient.TopicExistsReturns(true, nil)
+			responseRecorder = httptest.NewRecorder()
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest(fmt.Sprintf("/%s/%s?replicate=true", existingTopicNamespace, existingTopicName)))
+
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"state":"replicated"`))
+		})
+
+		It("describes the topic of the stream in the secondary region", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			fakeKafkaClient.TopicHealthReturns(&client.TopicHealth{}, nil)
+			secondaryKafkaClient.TopicExistsReturns(false, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/"+existingTopicNamespace+"/"+existingTopicName, nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"state":"missing"`))
+		})
+
+		It("deletes the topic of the stream in both regions", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			secondaryKafkaClient.DeleteTopicReturns(sarama.ErrUnknownTopicOrPartition)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodDelete, "/"+existingTopicNamespace+"/"+existingTopicName, nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNoContent))
+			Expect(secondaryKafkaClient.DeleteTopicArgsForCall(0)).To(Equal(kafkaTopicName))
+			Expect(fakeKafkaClient.DeleteTopicCallCount()).To(Equal(1))
+		})
+
+		It("fails provisioning with a 503 when the secondary region can't be reached", func() {
+			creationHandler.Secondary.Connect = func() (client.KafkaClient, error) {
+				return nil, sarama.ErrOutOfBrokers
+			}
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(responseRecorder.Header().Get("Retry-After")).To(Equal("5"))
+			Expect(errorOf(responseRecorder).Message).To(ContainSubstring(`Error connecting to region "us-west"`))
+		})
+	})
+
+	Context("with a cluster link", func() {
+		var mirrors *handlerfakes.FakeMirrorManager
+
+		BeforeEach(func() {
+			mirrors = &handlerfakes.FakeMirrorManager{}
+			creationHandler := &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Mirrors:     mirrors,
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+		})
+
+		It("mirrors the topic of streams asking for it", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+			mirrors.DescribeMirrorReturnsOnCall(1, &clusterlink.Mirror{Link: "dr-link", Topic: kafkaTopicName, Status: "ACTIVE"}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest(fmt.Sprintf("/%s/%s?mirror=true", existingTopicNamespace, existingTopicName)))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(mirrors.CreateMirrorCallCount()).To(Equal(1))
+			_, topicName := mirrors.CreateMirrorArgsForCall(0)
+			Expect(topicName).To(Equal(kafkaTopicName))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(fmt.Sprintf(`"mirror":{"link":"dr-link","topic":"%s","status":"ACTIVE"}`, kafkaTopicName)))
+		})
+
+		It("leaves the topics mirrored already alone", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			mirrors.DescribeMirrorReturns(&clusterlink.Mirror{Link: "dr-link", Topic: kafkaTopicName, Status: "ACTIVE"}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest(fmt.Sprintf("/%s/%s?mirror=true", existingTopicNamespace, existingTopicName)))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(mirrors.CreateMirrorCallCount()).To(BeZero())
+		})
+
+		It("doesn't mirror the topic of other streams", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(mirrors.Invocations()).To(BeEmpty())
+		})
+
+		It("fails with a 502 when the linked cluster fails", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			mirrors.CreateMirrorReturns(fmt.Errorf("the REST API of the linked cluster responded 400: Cluster link 'dr-link' is paused"))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest(fmt.Sprintf("/%s/%s?mirror=true", existingTopicNamespace, existingTopicName)))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadGateway))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring("is paused"))
+		})
+
+		It("deletes the mirror with the stream", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodDelete, "/"+existingTopicNamespace+"/"+existingTopicName, nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNoContent))
+			_, topicName := mirrors.DeleteMirrorArgsForCall(0)
+			Expect(topicName).To(Equal(kafkaTopicName))
+		})
+
+		It("describes and promotes mirrors", func() {
+			mirrors.DescribeMirrorReturnsOnCall(0, &clusterlink.Mirror{Link: "dr-link", Topic: kafkaTopicName, Status: "ACTIVE"}, nil)
+			mirrors.DescribeMirrorReturnsOnCall(1, &clusterlink.Mirror{Link: "dr-link", Topic: kafkaTopicName, Status: "ACTIVE"}, nil)
+			mirrors.DescribeMirrorReturnsOnCall(2, &clusterlink.Mirror{Link: "dr-link", Topic: kafkaTopicName, Status: "STOPPED"}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/"+existingTopicNamespace+"/"+existingTopicName+"/mirror", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(fmt.Sprintf(`{"link": "dr-link", "topic": "%s", "status": "ACTIVE"}`, kafkaTopicName)))
+
+			responseRecorder = httptest.NewRecorder()
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, "/"+existingTopicNamespace+"/"+existingTopicName+"/mirror", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(mirrors.PromoteMirrorCallCount()).To(Equal(1))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(fmt.Sprintf(`{"link": "dr-link", "topic": "%s", "status": "STOPPED"}`, kafkaTopicName)))
+		})
+
+		It("returns 404 for streams that aren't mirrored", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, "/"+existingTopicNamespace+"/"+existingTopicName+"/mirror", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+			Expect(mirrors.PromoteMirrorCallCount()).To(BeZero())
+		})
+	})
+
+	It("refuses to mirror streams unless cluster linking is configured", func() {
+		creationHandlerFunc.ServeHTTP(responseRecorder, putRequest(fmt.Sprintf("/%s/%s?mirror=true", existingTopicNamespace, existingTopicName)))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		Expect(errorOf(responseRecorder).Message).To(Equal("Cluster linking is not configured for this provisioner"))
+	})
+
+	Context("with a retention", func() {
+		BeforeEach(func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+		})
+
+		It("retains the records of created topics by time and size, whichever limit is reached first", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?retentionMs=604800000&retentionBytes=53687091200"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			_, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(*spec.ConfigEntries["retention.ms"]).To(Equal("604800000"))
+			Expect(*spec.ConfigEntries["retention.bytes"]).To(Equal("53687091200"))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"retention":{"ms":604800000,"bytes":53687091200}`))
+			Expect(fakeKafkaClient.BrokerConfigCallCount()).To(BeZero())
+		})
+
+		It("reports the retention of the brokers for the limits left unset", func() {
+			hours, bytes := "168", "-1"
+			fakeKafkaClient.BrokerConfigStub = func(name string) (*string, error) {
+				switch name {
+				case "log.retention.hours":
+					return &hours, nil
+				case "log.retention.bytes":
+					return &bytes, nil
+				}
+				return nil, nil
+			}
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"retention":{"ms":604800000,"bytes":-1}`))
+		})
+
+		It("stores the records of topics remotely with tiered storage", func() {
+			enabled := "true"
+			fakeKafkaClient.BrokerConfigReturns(&enabled, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?tieredStorage=true&retentionMs=2592000000&localRetentionMs=86400000"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(fakeKafkaClient.BrokerConfigArgsForCall(0)).To(Equal("remote.log.storage.system.enable"))
+			_, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(*spec.ConfigEntries["remote.storage.enable"]).To(Equal("true"))
+			Expect(*spec.ConfigEntries["local.retention.ms"]).To(Equal("86400000"))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"localMs":86400000`))
+		})
+
+		It("refuses tiered storage when the brokers don't support it", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?tieredStorage=true"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring("tiered storage is not enabled on the brokers"))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+
+		It("returns 400 for local retentions without tiered storage", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?localRetentionMs=86400000"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(errorOf(responseRecorder).Message).To(Equal("Invalid retention: localRetentionMs requires tieredStorage=true"))
+		})
+
+		It("returns 400 for invalid retentions", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?retentionMs=0"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(errorOf(responseRecorder).Message).To(Equal("Invalid retention: retentionMs should be a number of milliseconds, or -1 for unlimited, got \"0\""))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+	})
+
+	Context("with a consumer group", func() {
+		BeforeEach(func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+			fakeKafkaClient.GroupProgressReturns([]client.PartitionProgress{{Partition: 0, Start: 0, End: 0, Committed: -1}}, nil)
+			fakeKafkaClient.CommitGroupOffsetsReturns(map[int32]int64{0: 0}, nil)
+		})
+
+		It("creates the group at the start of the stream", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?group=billing&startAt=earliest"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			groupID, topicName, startAt := fakeKafkaClient.CommitGroupOffsetsArgsForCall(0)
+			Expect([]string{groupID, topicName}).To(Equal([]string{"billing", kafkaTopicName}))
+			Expect(startAt).To(Equal(sarama.OffsetOldest))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"group":{"name":"billing","offsets":{"0":0},"created":true}`))
+		})
+
+		It("starts groups from the next records, or the first published at a timestamp", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?group=billing"))
+			creationHandlerFunc.ServeHTTP(httptest.NewRecorder(), putRequest("/some-namespace/some-topic?group=billing&startAt=2026-01-01T00:00:00Z"))
+
+			_, _, startAt := fakeKafkaClient.CommitGroupOffsetsArgsForCall(0)
+			Expect(startAt).To(Equal(sarama.OffsetNewest))
+			_, _, startAt = fakeKafkaClient.CommitGroupOffsetsArgsForCall(1)
+			Expect(startAt).To(Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()))
+		})
+
+		It("leaves the groups that committed offsets alone", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			fakeKafkaClient.GroupProgressReturns([]client.PartitionProgress{{Partition: 0, Start: 0, End: 10, Committed: 7}}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?group=billing&startAt=earliest"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(fakeKafkaClient.CommitGroupOffsetsCallCount()).To(BeZero())
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"group":{"name":"billing","offsets":{"0":7},"created":false}`))
+		})
+
+		It("returns 409 when the group got members in the meantime", func() {
+			fakeKafkaClient.CommitGroupOffsetsReturns(nil, sarama.ErrUnknownMemberId)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?group=billing"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusConflict))
+		})
+
+		It("returns 400 for invalid starting positions", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?group=billing&startAt=yesterday"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(errorOf(responseRecorder).Message).To(Equal("Invalid group: startAt should be earliest, latest or an RFC 3339 timestamp, got \"yesterday\""))
+
+			responseRecorder = httptest.NewRecorder()
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?startAt=earliest"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+	})
+
+	Context("when the gateway limits payload sizes", func() {
+		var creationHandler *handler.TopicCreationRequestHandler
+
+		BeforeEach(func() {
+			creationHandler = &handler.TopicCreationRequestHandler{
+				KafkaClient:     fakeKafkaClient,
+				Gateway:         gateway,
+				Logger:          logger,
+				MaxPayloadBytes: 2 * 1024 * 1024,
+			}
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+		})
+
+		It("sizes the messages of the created topic accordingly", func() {
+			creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			_, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(spec.ConfigEntries).To(HaveKey("max.message.bytes"))
+			Expect(*spec.ConfigEntries["max.message.bytes"]).To(Equal("2113536"))
+		})
+
+		It("keeps the message size set by the policy", func() {
+			maxMessageBytes := "1000"
+			fakePolicy := &policyfakes.FakeEvaluator{}
+			fakePolicy.EvaluateReturns(policy.Decision{Allow: true, Spec: &client.TopicSpec{
+				NumPartitions:     1,
+				ReplicationFactor: 1,
+				ConfigEntries:     map[string]*string{"max.message.bytes": &maxMessageBytes},
+			}}, nil)
+			creationHandler.Policy = fakePolicy
+
+			creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			_, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(spec.ConfigEntries).To(HaveKeyWithValue("max.message.bytes", &maxMessageBytes))
+		})
+
+		It("raises the message size of streams carrying large payloads", func() {
+			messageMaxBytes := "20971520"
+			fakeKafkaClient.BrokerConfigReturns(&messageMaxBytes, nil)
+
+			creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?maxMessageBytes=10485760"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(fakeKafkaClient.BrokerConfigArgsForCall(0)).To(Equal("message.max.bytes"))
+			_, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(*spec.ConfigEntries["max.message.bytes"]).To(Equal("10485760"))
+		})
+
+		It("refuses message sizes the brokers don't take", func() {
+			messageMaxBytes := "1048588"
+			fakeKafkaClient.BrokerConfigReturns(&messageMaxBytes, nil)
+
+			creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?maxMessageBytes=10485760"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(errorOf(responseRecorder).Message).To(Equal(fmt.Sprintf("Refusing to create topic %q: max.message.bytes 10485760 exceeds the message.max.bytes of the brokers, 1048588", kafkaTopicName)))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+
+		It("returns 400 for invalid message sizes", func() {
+			creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?maxMessageBytes=10MB"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(errorOf(responseRecorder).Message).To(Equal("Invalid value for parameter \"maxMessageBytes\": should be a number of bytes, got \"10MB\""))
+		})
+	})
+
+	Context("with a versioned request body", func() {
+
+		It("creates the topic with the partitions, replication factor and configs asked for", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+			request := httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic", strings.NewReader(`{
+				"apiVersion": "v1",
+				"partitions": 6,
+				"replicationFactor": 3,
+				"config": {"cleanup.policy": "compact"},
+				"contentType": "application/json",
+				"labels": {"team": "orders"}
+			}`))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			_, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(spec.NumPartitions).To(Equal(int32(6)))
+			Expect(spec.ReplicationFactor).To(Equal(int16(3)))
+			Expect(*spec.ConfigEntries["cleanup.policy"]).To(Equal("compact"))
+			_, metadata := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(metadata.ContentType).To(Equal("application/json"))
+			Expect(metadata.Labels).To(Equal(map[string]string{"team": "orders"}))
+		})
+
+		It("lets the provisioner choose what isn't asked for", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+			request := httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic", strings.NewReader(`{"apiVersion": "v1", "partitions": 4}`))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			_, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(spec.NumPartitions).To(Equal(int32(4)))
+			Expect(spec.ReplicationFactor).To(Equal(int16(1)))
+			Expect(fakeKafkaClient.WriteMetadataCallCount()).To(Equal(1))
+		})
+
+		DescribeTable("returns 400 for invalid bodies",
+			func(body string) {
+				request := httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic", strings.NewReader(body))
+
+				creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+				Expect(errorOf(responseRecorder).Message).To(HavePrefix("Invalid stream metadata"))
+				Expect(fakeKafkaClient.TopicExistsCallCount()).To(BeZero())
+			},
+			Entry("unknown version", `{"apiVersion": "v2"}`),
+			Entry("unknown field", `{"apiVersion": "v1", "partition": 3}`),
+			Entry("negative partitions", `{"apiVersion": "v1", "partitions": -2}`),
+			Entry("negative replication factor", `{"apiVersion": "v1", "replicationFactor": -3}`),
+			Entry("config without a value", `{"apiVersion": "v1", "config": {"cleanup.policy": null}}`),
+			Entry("invalid content type", `{"apiVersion": "v1", "contentType": "json"}`),
+			Entry("spec in an unversioned body", `{"partitions": 3}`),
+		)
+
+		It("still applies the rules of the provisioner", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+			creationHandler := &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Rules:       validation.Rules{MaxPartitions: 8},
+			}
+			request := httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic", strings.NewReader(`{"apiVersion": "v1", "partitions": 12}`))
+
+			creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+	})
+
+	Context("with stream metadata", func() {
+
+		It("records the content type and labels of the stream", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+			request := httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic",
+				strings.NewReader(`{"contentType": "application/json", "labels": {"team": "orders"}}`))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			topicName, metadata := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(topicName).To(Equal(kafkaTopicName))
+			Expect(metadata).To(Equal(client.StreamMetadata{
+				ContentType: "application/json",
+				Labels:      map[string]string{"team": "orders"},
+				Spec:        &client.TopicSpec{NumPartitions: 1, ReplicationFactor: 1},
+			}))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(fmt.Sprintf(`{
+				"gateway": "%s",
+				"gateways": {"grpc": {"address": "%s", "tls": false}},
+				"topic": "%s",
+				"contentType": "application/json",
+				"labels": {"team": "orders"}
+			}`, gateway, gateway, kafkaTopicName)))
+		})
+
+		It("leaves the metadata alone when the request has none", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(fakeKafkaClient.WriteMetadataCallCount()).To(BeZero())
+		})
+
+		It("returns 400 for invalid metadata", func() {
+			request := httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic", strings.NewReader(`{"contentType": "json"}`))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(errorOf(responseRecorder).Message).To(HavePrefix("Invalid stream metadata"))
+			Expect(fakeKafkaClient.TopicExistsCallCount()).To(BeZero())
+		})
+
+		It("records the envelope of the records of streams", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+			request := httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic", strings.NewReader(`{"envelope": "cloudevents"}`))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			_, metadata := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(metadata.Envelope).To(Equal(client.CloudEventsEnvelope))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"envelope":"cloudevents"`))
+		})
+
+		It("returns 400 for unknown envelopes", func() {
+			request := httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic", strings.NewReader(`{"envelope": "avro"}`))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(errorOf(responseRecorder).Message).To(ContainSubstring(`unknown envelope "avro", should be one of raw, cloudevents or riff`))
+		})
+
+		It("returns 400 for label names that couldn't be selected", func() {
+			request := httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic", strings.NewReader(`{"labels": {"team=web": "true"}}`))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(errorOf(responseRecorder).Message).To(ContainSubstring(`label name "team=web" can't contain whitespace`))
+		})
+
+		It("records the subject name strategy of the schema", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			request := httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic",
+				strings.NewReader(`{"schema": {"subjectNameStrategy": "record-name", "recordName": "com.example.Order"}}`))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			_, metadata := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(metadata.Schema).To(Equal(&client.SchemaSubject{SubjectNameStrategy: client.RecordNameStrategy, RecordName: "com.example.Order"}))
+			Expect(metadata.Schema.Subject(kafkaTopicName)).To(Equal("com.example.Order"))
+		})
+
+		It("returns 400 for unknown subject name strategies or missing record names", func() {
+			for _, schema := range []string{
+				`{"subjectNameStrategy": "record"}`,
+				`{"subjectNameStrategy": "topic-record-name"}`,
+				`{"subjectNameStrategy": "record-name", "recordName": "com..Order"}`,
+			} {
+				responseRecorder := httptest.NewRecorder()
+				request := httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic", strings.NewReader(`{"schema": `+schema+`}`))
+
+				creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest), schema)
+			}
+			Expect(fakeKafkaClient.WriteMetadataCallCount()).To(BeZero())
+		})
+
+		It("returns 503 when the metadata can't be recorded", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			fakeKafkaClient.WriteMetadataReturns(sarama.ErrNotEnoughReplicas)
+			request := httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic", strings.NewReader(`{"contentType": "text/plain"}`))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+		})
+
+		It("describes streams with their recorded metadata", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			fakeKafkaClient.ReadMetadataReturns(&client.StreamMetadata{ContentType: "application/json"}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/some-namespace/some-topic", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(fmt.Sprintf(`{
+				"gateway": "%s",
+				"gateways": {"grpc": {"address": "%s", "tls": false}},
+				"topic": "%s",
+				"contentType": "application/json"
+			}`, gateway, gateway, kafkaTopicName)))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+
+		It("describes the health of the partitions of streams", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			fakeKafkaClient.TopicHealthReturns(client.NewTopicHealth([]client.PartitionHealth{
+				{Partition: 0, Leader: 1, Replicas: 3, InSync: 3},
+				{Partition: 1, Leader: 2, Replicas: 3, InSync: 2},
+			}), nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/some-namespace/some-topic", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(fmt.Sprintf(`{
+				"gateway": "%s",
+				"gateways": {"grpc": {"address": "%s", "tls": false}},
+				"topic": "%s",
+				"health": {
+					"status": "underReplicated",
+					"underReplicatedPartitions": [1],
+					"partitions": [
+						{"partition": 0, "leader": 1, "replicas": 3, "inSync": 3},
+						{"partition": 1, "leader": 2, "replicas": 3, "inSync": 2}
+					]
+				}
+			}`, gateway, gateway, kafkaTopicName)))
+		})
+
+		It("returns 503 when the partitions of streams can't be described", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			fakeKafkaClient.TopicHealthReturns(nil, sarama.ErrLeaderNotAvailable)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/some-namespace/some-topic", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+		})
+
+		It("returns 404 when describing streams without topic", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/some-namespace/some-topic", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+	})
+
+	Context("when namespace quotas are enforced", func() {
+		BeforeEach(func() {
+			creationHandler := &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Quota:       quota.Limits{MaxTopics: 2},
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+		})
+
+		It("creates the topic when the namespace is within its quota", func() {
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+				existingTopicNamespace + "_other-topic": {NumPartitions: 1},
+				"other-namespace_topic":                 {NumPartitions: 1},
+			}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(Equal(1))
+		})
+
+		It("returns 403 when the namespace has exhausted its quota", func() {
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+				existingTopicNamespace + "_other-topic":   {NumPartitions: 1},
+				existingTopicNamespace + "_another-topic": {NumPartitions: 1},
+			}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusForbidden))
+			Expect(errorOf(responseRecorder).Message).To(Equal("Refusing to create topic \"" + kafkaTopicName +
+				"\" for namespace \"" + existingTopicNamespace + "\": topic quota exceeded: 2 of 2 topics already provisioned"))
+			Expect(errorOf(responseRecorder).Code).To(Equal(handler.CodeQuotaExceeded))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(Equal(0))
+		})
+
+		It("does not check the quota of topics that already exist", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(fakeKafkaClient.ListTopicsCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when a cluster partition budget is set", func() {
+		BeforeEach(func() {
+			creationHandler := &handler.TopicCreationRequestHandler{
+				KafkaClient:     fakeKafkaClient,
+				Gateway:         gateway,
+				Logger:          logger,
+				PartitionBudget: quota.ClusterBudget{MaxPartitions: 10, WarnRatio: 0.5},
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+		})
+
+		It("creates the topic with a warning when the cluster is close to its budget", func() {
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{"other-namespace_topic": {NumPartitions: 6}}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(responseRecorder.Header().Get("Warning")).
+				To(Equal(`299 - "cluster is close to its partition budget: 7 of 10 partitions in use"`))
+		})
+
+		It("returns 507 when the cluster has exhausted its budget", func() {
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{"other-namespace_topic": {NumPartitions: 10}}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusInsufficientStorage))
+			Expect(errorOf(responseRecorder).Message).To(Equal("Refusing to create topic \"" + kafkaTopicName +
+				"\": cluster partition budget exceeded: 10 of 10 partitions already in use, 1 requested"))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when a provisioning policy is set", func() {
+		var fakePolicy *policyfakes.FakeEvaluator
+
+		BeforeEach(func() {
+			fakePolicy = &policyfakes.FakeEvaluator{}
+			creationHandler := &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Policy:      fakePolicy,
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+		})
+
+		It("submits the request to the policy", func() {
+			fakePolicy.EvaluateReturns(policy.Decision{Allow: true}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(fakePolicy.EvaluateArgsForCall(0)).To(Equal(policy.Input{
+				Namespace: existingTopicNamespace,
+				Stream:    existingTopicName,
+				Topic:     kafkaTopicName,
+				Spec:      client.DefaultTopicSpec(),
+			}))
+		})
+
+		It("creates the topic with the spec amended by the policy", func() {
+			fakePolicy.EvaluateReturns(policy.Decision{Allow: true, Spec: &client.TopicSpec{NumPartitions: 1, ReplicationFactor: 3}}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			_, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(spec).To(Equal(client.TopicSpec{NumPartitions: 1, ReplicationFactor: 3}))
+		})
+
+		It("returns 422 listing the configs of the policy's spec the cluster doesn't take", func() {
+			retention := "a week"
+			fakePolicy.EvaluateReturns(policy.Decision{Allow: true, Spec: &client.TopicSpec{
+				NumPartitions:     1,
+				ReplicationFactor: 3,
+				ConfigEntries:     map[string]*string{"retention.ms": &retention},
+			}}, nil)
+			fakeKafkaClient.TopicConfigKeysReturns(client.TopicConfigKeys{"retention.ms": {Kind: client.ConfigInteger}}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(errorOf(responseRecorder).Message).To(Equal("Refusing to provision topic \"" + kafkaTopicName +
+				"\": invalid topic configs: retention.ms should be an integer, got \"a week\""))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(Equal(0))
+		})
+
+		It("returns 403 when the policy denies the topic", func() {
+			fakePolicy.EvaluateReturns(policy.Decision{Allow: false, Reason: "streams are frozen"}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusForbidden))
+			Expect(errorOf(responseRecorder).Message).
+				To(Equal("Provisioning policy denied topic \"" + kafkaTopicName + "\": streams are frozen"))
+			Expect(errorOf(responseRecorder).Code).To(Equal(handler.CodePolicyDenied))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(Equal(0))
+		})
+
+		It("returns 500 when the policy cannot be evaluated", func() {
+			fakePolicy.EvaluateReturns(policy.Decision{}, fmt.Errorf("oopsie"))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusInternalServerError))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("deprovisioning streams", func() {
+		var (
+			fakeSubjects    *handlerfakes.FakeSubjectDeleter
+			deleteRequest   *http.Request
+			creationHandler *handler.TopicCreationRequestHandler
+		)
+
+		BeforeEach(func() {
+			fakeSubjects = &handlerfakes.FakeSubjectDeleter{}
+			creationHandler = &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Subjects:    fakeSubjects,
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+			deleteRequest = httptest.NewRequest(http.MethodDelete, "/some-namespace/some-topic", nil)
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+		})
+
+		deletedSubjects := func() []string {
+			var subjects []string
+			for i := 0; i < fakeSubjects.DeleteSubjectCallCount(); i++ {
+				_, subject, permanent := fakeSubjects.DeleteSubjectArgsForCall(i)
+				Expect(permanent).To(BeFalse())
+				subjects = append(subjects, subject)
+			}
+			return subjects
+		}
+
+		It("deletes the topic, its metadata and its schema subjects", func() {
+			fakeKafkaClient.ReadMetadataReturns(&client.StreamMetadata{ContentType: "application/json"}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, deleteRequest)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNoContent))
+			Expect(fakeKafkaClient.DeleteTopicArgsForCall(0)).To(Equal(kafkaTopicName))
+			Expect(fakeKafkaClient.DeleteMetadataArgsForCall(0)).To(Equal(kafkaTopicName))
+			Expect(deletedSubjects()).To(Equal([]string{kafkaTopicName + "-key", kafkaTopicName + "-value"}))
+		})
+
+		It("deletes the subjects named after the topic and record", func() {
+			fakeKafkaClient.ReadMetadataReturns(&client.StreamMetadata{Schema: &client.SchemaSubject{
+				SubjectNameStrategy: client.TopicRecordNameStrategy,
+				RecordName:          "com.example.Order",
+			}}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, deleteRequest)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNoContent))
+			Expect(deletedSubjects()).To(Equal([]string{kafkaTopicName + "-com.example.Order"}))
+		})
+
+		It("leaves the subjects named after records alone, as other streams may share them", func() {
+			fakeKafkaClient.ReadMetadataReturns(&client.StreamMetadata{Schema: &client.SchemaSubject{
+				SubjectNameStrategy: client.RecordNameStrategy,
+				RecordName:          "com.example.Order",
+			}}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, deleteRequest)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNoContent))
+			Expect(fakeSubjects.DeleteSubjectCallCount()).To(BeZero())
+		})
+
+		It("keeps the topic when its subjects can't be deleted", func() {
+			fakeSubjects.DeleteSubjectReturns(fmt.Errorf("schema registry responded 500: oops"))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, deleteRequest)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadGateway))
+			Expect(fakeKafkaClient.DeleteTopicCallCount()).To(BeZero())
+		})
+
+		It("returns 503 when the topic can't be deleted", func() {
+			fakeKafkaClient.DeleteTopicReturns(sarama.ErrRequestTimedOut)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, deleteRequest)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+		})
+
+		It("returns 404 for streams without topic", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, deleteRequest)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+			Expect(fakeKafkaClient.DeleteTopicCallCount()).To(BeZero())
+		})
+
+		It("deprovisions streams on behalf of the stream controller", func() {
+			Expect(creationHandler.DeprovisionStream(context.Background(), "some-namespace", "some-topic")).To(Succeed())
+
+			Expect(fakeKafkaClient.DeleteTopicArgsForCall(0)).To(Equal(kafkaTopicName))
+		})
+
+		It("considers streams without topic deprovisioned", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+
+			Expect(creationHandler.DeprovisionStream(context.Background(), "some-namespace", "some-topic")).To(Succeed())
+			Expect(fakeKafkaClient.DeleteTopicCallCount()).To(BeZero())
+		})
+
+		It("fails to deprovision streams whose topic can't be deleted", func() {
+			fakeKafkaClient.DeleteTopicReturns(sarama.ErrRequestTimedOut)
+
+			Expect(creationHandler.DeprovisionStream(context.Background(), "some-namespace", "some-topic")).To(MatchError(sarama.ErrRequestTimedOut))
+		})
+	})
+
+	Context("deprovisioning namespaces", func() {
+		BeforeEach(func() {
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+				"ns_orders":          {NumPartitions: 1, ReplicationFactor: 1},
+				"ns_payments":        {NumPartitions: 1, ReplicationFactor: 1},
+				"ns-2_orders":        {NumPartitions: 1, ReplicationFactor: 1},
+				"__consumer_offsets": {NumPartitions: 50, ReplicationFactor: 1},
+			}, nil)
+			fakeKafkaClient.ListMetadataReturns(map[string]client.StreamMetadata{
+				"ns_orders": {ContentType: "application/json"},
+			}, nil)
+		})
+
+		It("deletes the topics of all the streams of the namespace at once", func() {
+			fakeKafkaClient.DeleteTopicsReturns(map[string]error{"ns_orders": nil, "ns_payments": nil}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodDelete, "/ns", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"namespace": "ns", "streams": [
+				{"topic": "ns_orders", "status": "deleted"},
+				{"topic": "ns_payments", "status": "deleted"}
+			]}`))
+			Expect(fakeKafkaClient.DeleteTopicCallCount()).To(BeZero())
+			Expect(fakeKafkaClient.DeleteTopicsCallCount()).To(Equal(1))
+			Expect(fakeKafkaClient.DeleteTopicsArgsForCall(0)).To(Equal([]string{"ns_orders", "ns_payments"}))
+			Expect(fakeKafkaClient.DeleteMetadataCallCount()).To(Equal(1))
+			Expect(fakeKafkaClient.DeleteMetadataArgsForCall(0)).To(Equal("ns_orders"))
+		})
+
+		It("archives the topics of the streams of the namespace when deleted streams are archived", func() {
+			creationHandler := &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Archive:     handler.ArchivePolicy{GracePeriod: time.Hour, Retention: time.Hour},
+			}
+
+			creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodDelete, "/ns", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"namespace": "ns", "streams": [
+				{"topic": "ns_orders", "status": "archived"},
+				{"topic": "ns_payments", "status": "archived"}
+			]}`))
+			Expect(fakeKafkaClient.DeleteTopicCallCount()).To(BeZero())
+			Expect(fakeKafkaClient.WriteMetadataCallCount()).To(Equal(2))
+			_, metadata := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(metadata.ContentType).To(Equal("application/json"))
+			Expect(metadata.Archived).NotTo(BeNil())
+		})
+
+		It("reports the streams that couldn't be deprovisioned", func() {
+			fakeKafkaClient.DeleteTopicsReturns(map[string]error{"ns_orders": sarama.ErrUnknownTopicOrPartition, "ns_payments": sarama.ErrRequestTimedOut}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodDelete, "/ns", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`{"topic":"ns_orders","status":"deleted"}`))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`{"topic":"ns_payments","status":"failed"`))
+		})
+
+		It("reports all the streams failed when the topics can't be deleted", func() {
+			fakeKafkaClient.DeleteTopicsReturns(nil, sarama.ErrNotController)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodDelete, "/ns", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`{"topic":"ns_orders","status":"failed"`))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`{"topic":"ns_payments","status":"failed"`))
+			Expect(fakeKafkaClient.DeleteMetadataCallCount()).To(BeZero())
+		})
+
+		It("returns 400 for invalid namespaces", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodDelete, "/Not_A_Namespace", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(fakeKafkaClient.ListTopicsCallCount()).To(BeZero())
+		})
+	})
+
+	Context("when deleted streams are archived", func() {
+		var creationHandler *handler.TopicCreationRequestHandler
+
+		BeforeEach(func() {
+			creationHandler = &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Archive:     handler.ArchivePolicy{GracePeriod: 7 * 24 * time.Hour, Retention: time.Hour},
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+		})
+
+		It("shortens the retention of the topic and records the stream as archived", func() {
+			compact := "compact"
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+				kafkaTopicName: {NumPartitions: 1, ConfigEntries: map[string]*string{"cleanup.policy": &compact}},
+			}, nil)
+			fakeKafkaClient.ReadMetadataReturns(&client.StreamMetadata{ContentType: "application/json"}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodDelete, "/some-namespace/some-topic", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusAccepted))
+			Expect(fakeKafkaClient.DeleteTopicCallCount()).To(BeZero())
+			topicName, configEntries := fakeKafkaClient.AlterTopicConfigArgsForCall(0)
+			Expect(topicName).To(Equal(kafkaTopicName))
+			Expect(configEntries).To(HaveKeyWithValue("cleanup.policy", &compact))
+			Expect(*configEntries["retention.ms"]).To(Equal("3600000"))
+			_, metadata := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(metadata.ContentType).To(Equal("application/json"))
+			Expect(metadata.Archived.ConfigEntries).To(Equal(map[string]*string{"cleanup.policy": &compact}))
+			Expect(metadata.Archived.DeleteAfter.Sub(metadata.Archived.Since)).To(Equal(7 * 24 * time.Hour))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"archived":{"since":`))
+		})
+
+		It("leaves archived streams alone", func() {
+			fakeKafkaClient.ReadMetadataReturns(&client.StreamMetadata{Archived: &client.Archive{}}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodDelete, "/some-namespace/some-topic", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusAccepted))
+			Expect(fakeKafkaClient.AlterTopicConfigCallCount()).To(BeZero())
+			Expect(fakeKafkaClient.WriteMetadataCallCount()).To(BeZero())
+		})
+
+		It("restores archived streams provisioned again", func() {
+			compact := "compact"
+			fakeKafkaClient.ReadMetadataReturns(&client.StreamMetadata{
+				ContentType: "application/json",
+				Archived:    &client.Archive{ConfigEntries: map[string]*string{"cleanup.policy": &compact}},
+			}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			_, configEntries := fakeKafkaClient.AlterTopicConfigArgsForCall(0)
+			Expect(configEntries).To(Equal(map[string]*string{"cleanup.policy": &compact}))
+			_, metadata := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(metadata).To(Equal(client.StreamMetadata{ContentType: "application/json"}))
+		})
+
+		It("refuses metadata marking streams as archived", func() {
+			request := httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic", strings.NewReader(`{"archived": {}}`))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("deletes the topics of the streams whose grace period is over", func() {
+			fakeKafkaClient.ListMetadataReturns(map[string]client.StreamMetadata{
+				"ns_expired": {Archived: &client.Archive{DeleteAfter: time.Now().Add(-time.Minute)}},
+				"ns_pending": {Archived: &client.Archive{DeleteAfter: time.Now().Add(time.Hour)}},
+				"ns_live":    {ContentType: "text/plain"},
+			}, nil)
+
+			Expect(creationHandler.DeleteArchived(context.Background())).To(Succeed())
+
+			Expect(fakeKafkaClient.DeleteTopicCallCount()).To(Equal(1))
+			Expect(fakeKafkaClient.DeleteTopicArgsForCall(0)).To(Equal("ns_expired"))
+			Expect(fakeKafkaClient.DeleteMetadataArgsForCall(0)).To(Equal("ns_expired"))
+		})
+	})
+
+	Context("with a companion repartition topic", func() {
+		BeforeEach(func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+				kafkaTopicName: {NumPartitions: 6, ReplicationFactor: 3},
+			}, nil)
+		})
+
+		It("creates the repartition topic with as many partitions and replicas as the stream, retained shortly", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?repartition=true"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			topicName, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(topicName).To(Equal(kafkaTopicName + "-repartition"))
+			Expect(spec.NumPartitions).To(Equal(int32(6)))
+			Expect(spec.ReplicationFactor).To(Equal(int16(3)))
+			Expect(*spec.ConfigEntries["cleanup.policy"]).To(Equal("delete"))
+			Expect(*spec.ConfigEntries["retention.ms"]).To(Equal("86400000"))
+			recorded, _ := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(recorded).To(Equal(kafkaTopicName + "-repartition"))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"repartition":{"topic":"some-namespace_some-topic-repartition","partitions":6,"created":true}`))
+		})
+
+		It("leaves existing repartition topics alone", func() {
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+				kafkaTopicName:                  {NumPartitions: 6, ReplicationFactor: 3},
+				kafkaTopicName + "-repartition": {NumPartitions: 6, ReplicationFactor: 3},
+			}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?repartition=true"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"repartition":{"topic":"some-namespace_some-topic-repartition","partitions":6,"created":false}`))
+		})
+
+		It("retains the records of repartition topics as configured", func() {
+			creationHandler := &handler.TopicCreationRequestHandler{KafkaClient: fakeKafkaClient, Gateway: gateway, Logger: logger, RepartitionRetention: time.Hour}
+
+			creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?repartition=true"))
+
+			_, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(*spec.ConfigEntries["retention.ms"]).To(Equal("3600000"))
+		})
+
+		It("suggests retrying while the topic of the stream isn't listed yet", func() {
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?repartition=true"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(responseRecorder.Header().Get("Retry-After")).To(Equal("5"))
+		})
+	})
+
+	Context("provisioning the changelogs of stateful processors", func() {
+		BeforeEach(func() {
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+				"ns_orders": {NumPartitions: 6, ReplicationFactor: 3},
+			}, nil)
+		})
+
+		It("creates compacted changelog topics with as many partitions as their source", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/ns/billing/"+handler.ChangelogsSegment+"/totals?source=orders"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			topicName, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(topicName).To(Equal("ns_billing-totals-changelog"))
+			Expect(spec.NumPartitions).To(Equal(int32(6)))
+			Expect(*spec.ConfigEntries["cleanup.policy"]).To(Equal("compact"))
+			recorded, metadata := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(recorded).To(Equal("ns_billing-totals-changelog"))
+			Expect(*metadata.Spec).To(Equal(spec))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{
+				"topic": "ns_billing-totals-changelog",
+				"partitions": 6,
+				"config": {"cleanup.policy": "compact"}
+			}`))
+		})
+
+		It("also deletes the records of windowed stores older than their retention", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/ns/billing/"+handler.ChangelogsSegment+"/hourly?windowed=true&retentionMs=86400000"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			_, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(*spec.ConfigEntries["cleanup.policy"]).To(Equal("compact,delete"))
+			Expect(*spec.ConfigEntries["retention.ms"]).To(Equal("86400000"))
+		})
+
+		It("leaves existing changelog topics alone", func() {
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+				"ns_billing-totals-changelog": {NumPartitions: 6, ReplicationFactor: 3},
+			}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/ns/billing/"+handler.ChangelogsSegment+"/totals"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"topic": "ns_billing-totals-changelog", "partitions": 6}`))
+		})
+
+		It("returns 422 when the source stream doesn't exist", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/ns/billing/"+handler.ChangelogsSegment+"/totals?source=payments"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(errorOf(responseRecorder).Message).To(ContainSubstring(`source topic "ns_payments" does not exist`))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+
+		It("returns 400 for retentions of stores that aren't windowed", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/ns/billing/"+handler.ChangelogsSegment+"/totals?retentionMs=86400000"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("only provisions changelogs on PUT", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/ns/billing/"+handler.ChangelogsSegment+"/totals", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+
+	Context("electing the preferred leaders of the partitions of streams", func() {
+		var leadersRequest *http.Request
+
+		BeforeEach(func() {
+			leadersRequest = httptest.NewRequest(http.MethodPost, "/some-namespace/some-topic/"+handler.LeadersSegment, nil)
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+		})
+
+		It("reports the outcome of the election of each partition", func() {
+			fakeKafkaClient.ElectPreferredLeadersReturns([]client.LeaderElection{
+				{Partition: 0, Outcome: client.LeaderElected},
+				{Partition: 1, Outcome: client.LeaderElectionNotNeeded},
+			}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, leadersRequest)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(fakeKafkaClient.ElectPreferredLeadersArgsForCall(0)).To(Equal(kafkaTopicName))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(fmt.Sprintf(`{
+				"topic": "%s",
+				"partitions": [
+					{"partition": 0, "outcome": "elected"},
+					{"partition": 1, "outcome": "notNeeded"}
+				]
+			}`, kafkaTopicName)))
+		})
+
+		It("returns 503 when the leaders of some partitions couldn't be elected", func() {
+			fakeKafkaClient.ElectPreferredLeadersReturns([]client.LeaderElection{
+				{Partition: 0, Outcome: client.LeaderElected},
+				{Partition: 1, Outcome: client.LeaderElectionFailed, Error: "The preferred leader was not available."},
+			}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, leadersRequest)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"error":"The preferred leader was not available."`))
+		})
+
+		It("returns 422 when the cluster can't elect preferred leaders", func() {
+			fakeKafkaClient.ElectPreferredLeadersReturns(nil, &client.UnsupportedFeatureError{Feature: client.FeatureLeaderElection})
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, leadersRequest)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("returns 404 for streams without topic", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, leadersRequest)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+			Expect(fakeKafkaClient.ElectPreferredLeadersCallCount()).To(BeZero())
+		})
+
+		It("returns 405 for other methods than POST", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic/"+handler.LeadersSegment, nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+
+	It("lists the addresses of the provisioned streams for the stream controller", func() {
+		creationHandler := &handler.TopicCreationRequestHandler{KafkaClient: fakeKafkaClient, Gateway: gateway, Logger: logger}
+		fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+			"ns_orders":     {NumPartitions: 3, ReplicationFactor: 1},
+			"__consumer_id": {NumPartitions: 50, ReplicationFactor: 1},
+		}, nil)
+
+		addresses, err := creationHandler.StreamAddresses()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(addresses).To(Equal(map[string]handler.StreamAddress{
+			"ns/orders": {Topic: "ns_orders", Gateway: gateway, Partitions: 3},
+		}))
+	})
+
+	It("describes the capabilities of Kafka", func() {
+		creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, handler.CapabilitiesPath, nil))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		Expect(responseRecorder.Body.String()).To(MatchJSON(`{"backend": "kafka", "partitions": true, "compaction": true, "transactions": true, "metadata": true, "replication": false, "maxNameLength": 249}`))
+		Expect(fakeKafkaClient.Invocations()).To(BeEmpty())
+	})
+
+	Context("with another backend", func() {
+		var fakeBackend *handlerfakes.FakeBackend
+
+		BeforeEach(func() {
+			fakeBackend = &handlerfakes.FakeBackend{}
+			creationHandler := &handler.TopicCreationRequestHandler{
+				Backend: fakeBackend,
+				Gateway: gateway,
+				Logger:  logger,
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+		})
+
+		It("provisions streams on the backend", func() {
+			fakeBackend.CreateStreamReturns(&handler.Stream{Topic: "some-namespace.some-topic"}, true, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?replicate=false"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(fmt.Sprintf(
+				`{"gateway": "%s", "gateways": {"grpc": {"address": "%s", "tls": false}}, "topic": "some-namespace.some-topic"}`, gateway, gateway)))
+			_, streamRequest := fakeBackend.CreateStreamArgsForCall(0)
+			Expect(streamRequest).To(Equal(handler.StreamRequest{Namespace: existingTopicNamespace, Stream: existingTopicName}))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+
+		It("describes streams from the backend", func() {
+			fakeBackend.DescribeStreamReturns(&handler.Stream{
+				Topic:    "some-namespace.some-topic",
+				Metadata: &client.StreamMetadata{ContentType: "application/json"},
+			}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/some-namespace/some-topic", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"contentType":"application/json"`))
+			_, namespace, stream := fakeBackend.DescribeStreamArgsForCall(0)
+			Expect([]string{namespace, stream}).To(Equal([]string{existingTopicNamespace, existingTopicName}))
+		})
+
+		It("deletes streams from the backend", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodDelete, "/some-namespace/some-topic", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNoContent))
+			Expect(fakeBackend.DeleteStreamCallCount()).To(Equal(1))
+		})
+
+		It("responds with the status of the errors of the backend", func() {
+			fakeBackend.CreateStreamReturns(nil, false, &handler.StatusError{Status: http.StatusServiceUnavailable, RetryAfter: 10 * time.Second, Message: "broker unavailable"})
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(responseRecorder.Header().Get("Retry-After")).To(Equal("10"))
+			Expect(errorOf(responseRecorder).Message).To(Equal("broker unavailable"))
+			Expect(errorOf(responseRecorder)).To(Equal(errorResult{Code: handler.CodeBrokerUnavailable, Message: "broker unavailable", Retryable: true}))
+		})
+
+		It("responds with a 500 status to other errors of the backend", func() {
+			fakeBackend.DescribeStreamReturns(nil, fmt.Errorf("oopsie"))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/some-namespace/some-topic", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusInternalServerError))
+			Expect(errorOf(responseRecorder).Message).To(Equal("oopsie"))
+		})
+
+		It("points clients to the gateway of the backend when it has one", func() {
+			fakeBackend.CreateStreamReturns(&handler.Stream{Topic: "persistent://riff/some-namespace/some-topic", Gateway: "pulsar://pulsar:6650"}, true, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"gateway":"pulsar://pulsar:6650"`))
+		})
+
+		It("describes the capabilities of the backend", func() {
+			fakeBackend.CapabilitiesReturns(handler.Capabilities{Backend: "pulsar", Partitions: true})
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/capabilities", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"backend": "pulsar", "partitions": true, "compaction": false, "transactions": false, "metadata": false, "replication": false, "maxNameLength": 0}`))
+		})
+
+		It("doesn't serve the APIs managing Kafka topics", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/some-namespace", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotImplemented))
+			Expect(fakeBackend.DescribeStreamCallCount()).To(BeZero())
+		})
+	})
+
+	Context("with several backends", func() {
+		var fakeBackend *handlerfakes.FakeBackend
+
+		BeforeEach(func() {
+			fakeBackend = &handlerfakes.FakeBackend{}
+			fakeBackend.CreateStreamReturns(&handler.Stream{Topic: "persistent://riff/some-namespace/some-topic", Gateway: "pulsar://pulsar:6650"}, true, nil)
+			fakeBackend.CapabilitiesReturns(handler.Capabilities{Backend: "pulsar", Partitions: true})
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+			creationHandler := &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Backends: map[string]handler.NamedBackend{
+					"pulsar": {Backend: fakeBackend, Namespaces: []string{"some-namespace"}},
+				},
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+		})
+
+		It("provisions streams on the backend requests choose", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?backend=pulsar"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"gateway":"pulsar://pulsar:6650"`))
+			Expect(fakeBackend.CreateStreamCallCount()).To(Equal(1))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+
+		It("lets requests choose the backend with a header", func() {
+			request := httptest.NewRequest(http.MethodDelete, "/some-namespace/some-topic", nil)
+			request.Header.Set(handler.BackendHeader, "pulsar")
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNoContent))
+			Expect(fakeBackend.DeleteStreamCallCount()).To(Equal(1))
+			Expect(fakeKafkaClient.DeleteTopicCallCount()).To(BeZero())
+		})
+
+		It("provisions streams on Kafka by default", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(Equal(1))
+			Expect(fakeBackend.CreateStreamCallCount()).To(BeZero())
+		})
+
+		It("provisions streams on Kafka when requests name it", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/other-namespace/some-topic?backend=kafka"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(Equal(1))
+		})
+
+		It("forbids namespaces to choose backends they aren't allowed", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/other-namespace/some-topic?backend=pulsar"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusForbidden))
+			Expect(errorOf(responseRecorder).Message).To(Equal("Namespace \"other-namespace\" may not provision streams on backend \"pulsar\""))
+			Expect(fakeBackend.CreateStreamCallCount()).To(BeZero())
+		})
+
+		It("rejects unknown backends", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?backend=rabbitmq"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(errorOf(responseRecorder).Message).To(Equal("Unknown backend \"rabbitmq\""))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+
+		It("describes the capabilities of the backend requests choose", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/capabilities?backend=pulsar", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"backend":"pulsar"`))
+		})
+	})
+
+	Context("publishing the events of streams", func() {
+		var (
+			fakeEvents      *handlerfakes.FakeEventPublisher
+			creationHandler *handler.TopicCreationRequestHandler
+		)
+
+		BeforeEach(func() {
+			fakeEvents = &handlerfakes.FakeEventPublisher{}
+			creationHandler = &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Events:      fakeEvents,
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+		})
+
+		It("publishes that a stream is provisioned when its topic is created", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(fakeEvents.PublishCallCount()).To(Equal(1))
+			eventType, stream := fakeEvents.PublishArgsForCall(0)
+			Expect(eventType).To(Equal(events.StreamProvisioned))
+			Expect(stream).To(Equal(events.Stream{
+				Namespace:         existingTopicNamespace,
+				Stream:            existingTopicName,
+				Topic:             kafkaTopicName,
+				Partitions:        1,
+				ReplicationFactor: 1,
+			}))
+		})
+
+		It("publishes nothing when the topic of the stream exists", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(fakeEvents.PublishCallCount()).To(BeZero())
+		})
+
+		It("publishes that a stream is deleted when its topic is", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodDelete, "/some-namespace/some-topic", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNoContent))
+			eventType, stream := fakeEvents.PublishArgsForCall(0)
+			Expect(eventType).To(Equal(events.StreamDeleted))
+			Expect(stream).To(Equal(events.Stream{Namespace: existingTopicNamespace, Stream: existingTopicName, Topic: kafkaTopicName}))
+		})
+
+		It("publishes that a stream is deleted once when its topic is archived", func() {
+			creationHandler.Archive = handler.ArchivePolicy{GracePeriod: time.Hour, Retention: time.Hour}
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+
+			creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodDelete, "/some-namespace/some-topic", nil))
+			fakeKafkaClient.ListMetadataReturns(map[string]client.StreamMetadata{
+				kafkaTopicName: {Archived: &client.Archive{DeleteAfter: time.Now().Add(-time.Minute)}},
+			}, nil)
+			Expect(creationHandler.DeleteArchived(context.Background())).To(Succeed())
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusAccepted))
+			Expect(fakeKafkaClient.DeleteTopicCallCount()).To(Equal(1))
+			Expect(fakeEvents.PublishCallCount()).To(Equal(1))
+			eventType, stream := fakeEvents.PublishArgsForCall(0)
+			Expect(eventType).To(Equal(events.StreamDeleted))
+			Expect(stream.Archived).To(BeTrue())
+		})
+
+		It("publishes that a stream is altered when its drifted config is reverted", func() {
+			retention := "3600000"
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{kafkaTopicName: {NumPartitions: 1, ReplicationFactor: 1}}, nil)
+			fakeKafkaClient.ListMetadataReturns(map[string]client.StreamMetadata{
+				kafkaTopicName: {Spec: &client.TopicSpec{NumPartitions: 1, ReplicationFactor: 1, ConfigEntries: map[string]*string{"retention.ms": &retention}}},
+			}, nil)
+
+			_, err := creationHandler.Reconcile(true)
+
+			Expect(err).NotTo(HaveOccurred())
+			eventType, stream := fakeEvents.PublishArgsForCall(0)
+			Expect(eventType).To(Equal(events.StreamAltered))
+			Expect(stream.Config).To(HaveKeyWithValue("retention.ms", &retention))
+		})
+	})
+
+	Context("provisioning the streams of processors", func() {
+		var creationHandler *handler.TopicCreationRequestHandler
+
+		BeforeEach(func() {
+			creationHandler = &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+			}
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+				"ns_orders": {NumPartitions: 1, ReplicationFactor: 1},
+			}, nil)
+		})
+
+		createdTopics := func() []string {
+			var topics []string
+			for i := 0; i < fakeKafkaClient.CreateTopicCallCount(); i++ {
+				topicName, _ := fakeKafkaClient.CreateTopicArgsForCall(i)
+				topics = append(topics, topicName)
+			}
+			return topics
+		}
+
+		It("creates the topics of the streams that don't exist, recording their spec", func() {
+			Expect(creationHandler.ProvisionStreams("ns", []string{"orders", "invoices", "invoices-retry"})).To(Succeed())
+
+			Expect(createdTopics()).To(Equal([]string{"ns_invoices", "ns_invoices-retry"}))
+			topicName, metadata := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(topicName).To(Equal("ns_invoices"))
+			Expect(*metadata.Spec).To(Equal(client.DefaultTopicSpec()))
+		})
+
+		It("deletes the topics it created when a topic can't be created", func() {
+			fakeKafkaClient.CreateTopicReturnsOnCall(1, sarama.ErrRequestTimedOut)
+
+			err := creationHandler.ProvisionStreams("ns", []string{"invoices", "invoices-retry"})
+
+			Expect(err).To(MatchError(sarama.ErrRequestTimedOut))
+			Expect(fakeKafkaClient.DeleteTopicCallCount()).To(Equal(1))
+			Expect(fakeKafkaClient.DeleteTopicArgsForCall(0)).To(Equal("ns_invoices"))
+		})
+
+		It("deletes the topics it created when their spec can't be recorded", func() {
+			fakeKafkaClient.WriteMetadataReturnsOnCall(1, sarama.ErrRequestTimedOut)
+
+			err := creationHandler.ProvisionStreams("ns", []string{"invoices", "invoices-retry"})
+
+			Expect(err).To(MatchError(sarama.ErrRequestTimedOut))
+			Expect(fakeKafkaClient.DeleteTopicCallCount()).To(Equal(2))
+		})
+
+		It("leaves the topics another replica created concurrently alone", func() {
+			fakeKafkaClient.CreateTopicReturnsOnCall(0, &sarama.TopicError{Err: sarama.ErrTopicAlreadyExists})
+			fakeKafkaClient.CreateTopicReturnsOnCall(1, sarama.ErrRequestTimedOut)
+
+			err := creationHandler.ProvisionStreams("ns", []string{"invoices", "invoices-retry"})
+
+			Expect(err).To(MatchError(sarama.ErrRequestTimedOut))
+			Expect(fakeKafkaClient.WriteMetadataCallCount()).To(BeZero())
+			Expect(fakeKafkaClient.DeleteTopicCallCount()).To(BeZero())
+		})
+
+		It("creates none of the topics when they exceed the quota of the namespace together", func() {
+			creationHandler.Quota = quota.Limits{MaxTopics: 2}
+
+			err := creationHandler.ProvisionStreams("ns", []string{"invoices", "invoices-retry"})
+
+			Expect(err).To(MatchError(`refusing to create topic "ns_invoices-retry" for namespace "ns": topic quota exceeded: 2 of 2 topics already provisioned`))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+
+		It("creates none of the topics when one of the streams is invalid", func() {
+			err := creationHandler.ProvisionStreams("ns", []string{"invoices", "in/valid"})
+
+			Expect(err).To(HaveOccurred())
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+
+		It("grants the principal of processors the transactional ids reserved for them", func() {
+			principal, err := handler.ParseTransactionalIDPrincipal("User:{{.Namespace}}.{{.Processor}}")
+			Expect(err).NotTo(HaveOccurred())
+			creationHandler.TransactionalIDPrincipal = principal
+
+			prefix, err := creationHandler.ReserveTransactionalIDs("ns", "billing")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(prefix).To(Equal("ns_billing_"))
+			Expect(fakeKafkaClient.GrantTransactionalIDsCallCount()).To(Equal(1))
+			granted, grantee := fakeKafkaClient.GrantTransactionalIDsArgsForCall(0)
+			Expect([]string{granted, grantee}).To(Equal([]string{"ns_billing_", "User:ns.billing"}))
+
+			fakeKafkaClient.GrantTransactionalIDsReturns(sarama.ErrSecurityDisabled)
+
+			_, err = creationHandler.ReserveTransactionalIDs("ns", "billing")
+
+			Expect(err).To(MatchError(sarama.ErrSecurityDisabled))
+		})
+
+		It("refuses principal templates that don't render", func() {
+			_, err := handler.ParseTransactionalIDPrincipal("User:{{.Name}}")
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("migrating streams to a new name", func() {
+		var migrator *handlerfakes.FakeMigrator
+
+		BeforeEach(func() {
+			migrator = &handlerfakes.FakeMigrator{}
+			creationHandler := &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Migrator:    migrator,
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+			fakeKafkaClient.TopicExistsStub = func(topicName string) (bool, *client.KafkaError) {
+				return topicName == kafkaTopicName, nil
+			}
+			compact := "compact"
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+				kafkaTopicName: {NumPartitions: 3, ReplicationFactor: 2, ConfigEntries: map[string]*string{"cleanup.policy": &compact}},
+			}, nil)
+		})
+
+		migrate := func(body string) *http.Request {
+			return httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic/migration", strings.NewReader(body))
+		}
+
+		It("creates the topic of the new name like the old one and copies the records there", func() {
+			fakeKafkaClient.ReadMetadataReturns(&client.StreamMetadata{ContentType: "application/json"}, nil)
+			fakeKafkaClient.GroupProgressReturns([]client.PartitionProgress{{Partition: 0, Start: 10, End: 25, Committed: -1}}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, migrate(`{"stream": "renamed"}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusAccepted))
+			topicName, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(topicName).To(Equal("some-namespace_renamed"))
+			Expect(spec).To(Equal(client.TopicSpec{NumPartitions: 3, ReplicationFactor: 2, ConfigEntries: spec.ConfigEntries}))
+			Expect(*spec.ConfigEntries["cleanup.policy"]).To(Equal("compact"))
+
+			topicName, metadata := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(topicName).To(Equal(kafkaTopicName))
+			Expect(metadata.ContentType).To(Equal("application/json"))
+			Expect(metadata.Migration.To).To(Equal("some-namespace_renamed"))
+			topicName, metadata = fakeKafkaClient.WriteMetadataArgsForCall(1)
+			Expect(topicName).To(Equal("some-namespace_renamed"))
+			Expect(metadata).To(Equal(client.StreamMetadata{ContentType: "application/json", Spec: &spec}))
+
+			source, target := migrator.StartArgsForCall(0)
+			Expect(source).To(Equal(kafkaTopicName))
+			Expect(target).To(Equal("some-namespace_renamed"))
+			groupID, _ := fakeKafkaClient.GroupProgressArgsForCall(0)
+			Expect(groupID).To(Equal("riff-migration." + kafkaTopicName))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"state":"copying","lag":15`))
+		})
+
+		It("reports the migration ready for cutover once all records are copied", func() {
+			fakeKafkaClient.ReadMetadataReturns(&client.StreamMetadata{Migration: &client.Migration{To: "some-namespace_renamed"}}, nil)
+			fakeKafkaClient.GroupProgressReturns([]client.PartitionProgress{
+				{Partition: 0, Start: 10, End: 25, Committed: 25},
+				{Partition: 1, Start: 0, End: 0, Committed: -1},
+			}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/some-namespace/some-topic/migration", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"state":"ready","lag":0`))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"to":"some-namespace_renamed"`))
+		})
+
+		It("returns 404 when describing the migration of streams not being migrated", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/some-namespace/some-topic/migration", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("resumes migrations requested again, creating the topic left to create", func() {
+			fakeKafkaClient.ReadMetadataReturns(&client.StreamMetadata{Migration: &client.Migration{To: "some-namespace_renamed"}}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, migrate(`{"stream": "renamed"}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusAccepted))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(Equal(1))
+			topicName, _ := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(topicName).To(Equal("some-namespace_renamed"))
+			Expect(migrator.StartCallCount()).To(Equal(1))
+		})
+
+		It("returns 409 when the stream is being migrated elsewhere", func() {
+			fakeKafkaClient.ReadMetadataReturns(&client.StreamMetadata{Migration: &client.Migration{To: "some-namespace_other"}}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, migrate(`{"stream": "renamed"}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusConflict))
+			Expect(migrator.StartCallCount()).To(BeZero())
+		})
+
+		It("returns 409 when the topic of the new name already exists", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			fakeKafkaClient.TopicExistsStub = nil
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, migrate(`{"stream": "renamed"}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusConflict))
+			Expect(fakeKafkaClient.WriteMetadataCallCount()).To(BeZero())
+		})
+
+		It("returns 404 for streams without topic", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPut, "/some-namespace/missing/migration", strings.NewReader(`{"stream": "renamed"}`)))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("returns 400 for invalid new names", func() {
+			for _, body := range []string{``, `{}`, `{"stream": "some-topic"}`, `{"stream": "in/valid"}`} {
+				responseRecorder = httptest.NewRecorder()
+
+				creationHandlerFunc.ServeHTTP(responseRecorder, migrate(body))
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest), body)
+			}
+		})
+
+		It("keeps copying the records of streams whose metadata is replaced", func() {
+			fakeKafkaClient.ReadMetadataReturns(&client.StreamMetadata{Migration: &client.Migration{To: "some-namespace_renamed"}}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic", strings.NewReader(`{"contentType": "text/plain"}`)))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			_, metadata := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(metadata.ContentType).To(Equal("text/plain"))
+			Expect(metadata.Migration.To).To(Equal("some-namespace_renamed"))
+		})
+
+		It("lists the migrations whose records are being copied", func() {
+			Expect(handler.Migrations(map[string]client.StreamMetadata{
+				"ns_a": {Migration: &client.Migration{To: "ns_b"}},
+				"ns_c": {Migration: &client.Migration{To: "ns_d"}, Archived: &client.Archive{}},
+				"ns_e": {},
+			})).To(Equal(map[string]string{"ns_a": "ns_b"}))
+		})
+
+		It("returns 400 when migrations are not configured", func() {
+			creationHandler := &handler.TopicCreationRequestHandler{KafkaClient: fakeKafkaClient, Gateway: gateway, Logger: logger}
+
+			creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, migrate(`{"stream": "renamed"}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Context("listing the catalog of streams", func() {
+		BeforeEach(func() {
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+				"ns-2_orders":        {NumPartitions: 3, ReplicationFactor: 2},
+				"ns-1_payments":      {NumPartitions: 1, ReplicationFactor: 1},
+				"ns-1_clicks":        {NumPartitions: 6, ReplicationFactor: 3},
+				"__consumer_offsets": {NumPartitions: 50, ReplicationFactor: 3},
+				client.MetadataTopic: {NumPartitions: 1, ReplicationFactor: 3},
+				"dr.ns-1_replicated": {NumPartitions: 1, ReplicationFactor: 3},
+			}, nil)
+			fakeKafkaClient.ListMetadataReturns(map[string]client.StreamMetadata{
+				"ns-1_clicks": {ContentType: "application/json", Labels: map[string]string{"team": "web"}},
+			}, nil)
+		})
+
+		It("lists the streams of all namespaces, sorted by topic", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/streams", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(fmt.Sprintf(`{"streams": [
+				{"namespace": "ns-1", "stream": "clicks", "topic": "ns-1_clicks", "partitions": 6, "replicationFactor": 3,
+				 "gateways": {"grpc": {"address": "%[1]s", "tls": false}},
+				 "contentType": "application/json", "labels": {"team": "web"}},
+				{"namespace": "ns-1", "stream": "payments", "topic": "ns-1_payments", "partitions": 1, "replicationFactor": 1,
+				 "gateways": {"grpc": {"address": "%[1]s", "tls": false}}},
+				{"namespace": "ns-2", "stream": "orders", "topic": "ns-2_orders", "partitions": 3, "replicationFactor": 2,
+				 "gateways": {"grpc": {"address": "%[1]s", "tls": false}}}
+			]}`, gateway)))
+		})
+
+		It("lists the streams in YAML when the request accepts it", func() {
+			request := httptest.NewRequest(http.MethodGet, "/streams", nil)
+			request.Header.Set("Accept", "text/yaml;q=0.9")
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Header().Get("Content-Type")).To(Equal("application/yaml"))
+			Expect(responseRecorder.Body.String()).To(HavePrefix("streams:\n- namespace: ns-1\n  stream: clicks\n  topic: ns-1_clicks\n"))
+		})
+
+		Context("when the catalog is large", func() {
+			BeforeEach(func() {
+				topics := map[string]client.TopicSpec{}
+				for i := 0; i < 50; i++ {
+					topics[fmt.Sprintf("ns-1_stream-%02d", i)] = client.TopicSpec{NumPartitions: 1, ReplicationFactor: 1}
+				}
+				fakeKafkaClient.ListTopicsReturns(topics, nil)
+			})
+
+			DescribeTable("compresses it with the encoding the request prefers",
+				func(acceptEncoding, encoding string) {
+					request := httptest.NewRequest(http.MethodGet, "/streams", nil)
+					request.Header.Set("Accept-Encoding", acceptEncoding)
+
+					creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+					Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+					Expect(responseRecorder.Header().Get("Content-Encoding")).To(Equal(encoding))
+					body := responseRecorder.Body.Bytes()
+					switch encoding {
+					case handler.EncodingGzip:
+						reader, err := gzip.NewReader(responseRecorder.Body)
+						Expect(err).NotTo(HaveOccurred())
+						body, err = ioutil.ReadAll(reader)
+						Expect(err).NotTo(HaveOccurred())
+					case handler.EncodingZstd:
+						decoder, err := zstd.NewReader(nil)
+						Expect(err).NotTo(HaveOccurred())
+						body, err = decoder.DecodeAll(body, nil)
+						Expect(err).NotTo(HaveOccurred())
+					}
+					Expect(string(body)).To(HavePrefix(`{"streams":[{"namespace":"ns-1","stream":"stream-00"`))
+				},
+				Entry("gzip", "gzip", handler.EncodingGzip),
+				Entry("zstd", "zstd", handler.EncodingZstd),
+				Entry("preferring zstd", "gzip, deflate, br, zstd", handler.EncodingZstd),
+				Entry("by quality", "zstd;q=0.5, gzip", handler.EncodingGzip),
+				Entry("any", "*", handler.EncodingZstd),
+				Entry("none accepted", "br, zstd;q=0", ""),
+				Entry("none asked for", "", ""),
+			)
+		})
+
+		It("leaves small documents uncompressed", func() {
+			request := httptest.NewRequest(http.MethodGet, "/streams", nil)
+			request.Header.Set("Accept-Encoding", "gzip")
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Header().Get("Content-Encoding")).To(BeEmpty())
+			Expect(responseRecorder.Body.String()).To(HavePrefix(`{"streams":`))
+		})
+
+		It("pages through the streams", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/streams?limit=2", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"continue":"ns-1_payments"`))
+
+			responseRecorder = httptest.NewRecorder()
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/streams?limit=2&continue=ns-1_payments", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(fmt.Sprintf(`{"streams": [
+				{"namespace": "ns-2", "stream": "orders", "topic": "ns-2_orders", "partitions": 3, "replicationFactor": 2,
+				 "gateways": {"grpc": {"address": "%s", "tls": false}}}
+			]}`, gateway)))
+		})
+
+		It("lists the streams whose labels are selected", func() {
+			fakeKafkaClient.ListMetadataReturns(map[string]client.StreamMetadata{
+				"ns-1_clicks":   {Labels: map[string]string{"team": "web", "cost-center": "42"}},
+				"ns-1_payments": {Labels: map[string]string{"team": "billing", "cost-center": "42"}},
+				"ns-2_orders":   {Labels: map[string]string{"team": "web"}},
+			}, nil)
+
+			for selector, topics := range map[string][]string{
+				"team=web":                 {"ns-1_clicks", "ns-2_orders"},
+				"team==web":                {"ns-1_clicks", "ns-2_orders"},
+				"team!=web":                {"ns-1_payments"},
+				"cost-center":              {"ns-1_clicks", "ns-1_payments"},
+				"!cost-center":             {"ns-2_orders"},
+				"team=web, cost-center=42": {"ns-1_clicks"},
+				"team=web,cost-center=43":  {},
+			} {
+				responseRecorder := httptest.NewRecorder()
+				request := httptest.NewRequest(http.MethodGet, "/streams?labelSelector="+url.QueryEscape(selector), nil)
+
+				creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusOK), selector)
+				page := struct {
+					Streams []struct {
+						Topic string `json:"topic"`
+					} `json:"streams"`
+				}{}
+				Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &page)).To(Succeed())
+				listed := []string{}
+				for _, stream := range page.Streams {
+					listed = append(listed, stream.Topic)
+				}
+				Expect(listed).To(Equal(topics), selector)
+			}
+		})
+
+		It("pages through the streams whose labels are selected", func() {
+			fakeKafkaClient.ListMetadataReturns(map[string]client.StreamMetadata{
+				"ns-1_clicks": {Labels: map[string]string{"team": "web"}},
+				"ns-2_orders": {Labels: map[string]string{"team": "web"}},
+			}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/streams?limit=1&labelSelector=team%3Dweb", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"continue":"ns-1_clicks"`))
+
+			responseRecorder = httptest.NewRecorder()
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/streams?limit=1&labelSelector=team%3Dweb&continue=ns-1_clicks", nil))
+
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"topic":"ns-2_orders"`))
+			Expect(responseRecorder.Body.String()).NotTo(ContainSubstring(`"continue"`))
+		})
+
+		It("lists the streams whose fields are selected", func() {
+			compact, retention := "compact", "86400000"
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+				"ns-1_clicks":   {NumPartitions: 6, ReplicationFactor: 3, ConfigEntries: map[string]*string{"cleanup.policy": &compact}},
+				"ns-1_payments": {NumPartitions: 1, ReplicationFactor: 1, ConfigEntries: map[string]*string{"retention.ms": &retention}},
+				"ns-2_orders":   {NumPartitions: 3, ReplicationFactor: 2},
+			}, nil)
+			fakeKafkaClient.ListMetadataReturns(map[string]client.StreamMetadata{
+				"ns-1_clicks": {ContentType: "application/json", Labels: map[string]string{"team": "web"}},
+				"ns-2_orders": {ContentType: "application/json", Envelope: client.CloudEventsEnvelope},
+			}, nil)
+
+			for selector, topics := range map[string][]string{
+				"partitions>1":                                  {"ns-1_clicks", "ns-2_orders"},
+				"partitions>=3,replicationFactor<3":             {"ns-2_orders"},
+				"partitions<=1":                                 {"ns-1_payments"},
+				"cleanup.policy=compact":                        {"ns-1_clicks"},
+				"cleanup.policy!=compact":                       {"ns-1_payments", "ns-2_orders"},
+				"retention.ms<604800000":                        {"ns-1_payments"},
+				"namespace==ns-1, contentType=application/json": {"ns-1_clicks"},
+				"envelope=cloudevents":                          {"ns-2_orders"},
+				"stream=orders,partitions>3":                    {},
+			} {
+				responseRecorder := httptest.NewRecorder()
+				request := httptest.NewRequest(http.MethodGet, "/streams?fieldSelector="+url.QueryEscape(selector), nil)
+
+				creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusOK), selector)
+				page := struct {
+					Streams []struct {
+						Topic string `json:"topic"`
+					} `json:"streams"`
+				}{}
+				Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &page)).To(Succeed())
+				listed := []string{}
+				for _, stream := range page.Streams {
+					listed = append(listed, stream.Topic)
+				}
+				Expect(listed).To(Equal(topics), selector)
+			}
+		})
+
+		It("lists the streams whose labels and fields are both selected", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/streams?labelSelector=team%3Dweb&fieldSelector=partitions%3C6", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"streams": []}`))
+		})
+
+		It("returns 400 for invalid field selectors", func() {
+			for selector, message := range map[string]string{
+				"partitions":      `"partitions" should be of the form field=value, field!=value, field>number or field<number`,
+				"=1":              `"=1" should be of the form field=value, field!=value, field>number or field<number`,
+				"partitions>many": `"partitions>many" should compare partitions with a number, got "many"`,
+			} {
+				responseRecorder := httptest.NewRecorder()
+				creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/streams?fieldSelector="+url.QueryEscape(selector), nil))
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest), selector)
+				Expect(errorOf(responseRecorder).Message).To(ContainSubstring(message), selector)
+			}
+			Expect(fakeKafkaClient.ListTopicsCallCount()).To(BeZero())
+		})
+
+		It("returns 400 for invalid label selectors", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/streams?labelSelector="+url.QueryEscape("team=web,=a"), nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(errorOf(responseRecorder).Message).To(ContainSubstring(`"=a" should be of the form name, !name, name=value or name!=value`))
+		})
+
+		It("returns 400 for invalid limits", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/streams?limit=0", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(fakeKafkaClient.ListTopicsCallCount()).To(BeZero())
+		})
+
+		It("returns 503 when the metadata can't be read", func() {
+			fakeKafkaClient.ListMetadataReturns(nil, sarama.ErrLeaderNotAvailable)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/streams", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+		})
+
+		It("returns 405 for other methods than GET", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/streams"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+
+	Context("exporting and importing the provisioning state", func() {
+		var compact, retention string
+
+		BeforeEach(func() {
+			compact, retention = "compact", "3600000"
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+				"ns_orders":            {NumPartitions: 3, ReplicationFactor: 2, ConfigEntries: map[string]*string{"cleanup.policy": &compact}},
+				"ns_archived":          {NumPartitions: 1, ReplicationFactor: 1, ConfigEntries: map[string]*string{"retention.ms": &retention}},
+				"__consumer_offsets":   {NumPartitions: 50, ReplicationFactor: 3},
+				client.MetadataTopic:   {NumPartitions: 1, ReplicationFactor: 1},
+				"other-ns_payments-v2": {NumPartitions: 1, ReplicationFactor: 1},
+			}, nil)
+			fakeKafkaClient.ListMetadataReturns(map[string]client.StreamMetadata{
+				"ns_orders":   {ContentType: "application/json", Migration: &client.Migration{To: "ns_orders2"}},
+				"ns_archived": {Archived: &client.Archive{}},
+			}, nil)
+		})
+
+		importRequest := func(contentType, body string) *http.Request {
+			request := httptest.NewRequest(http.MethodPut, handler.StatePath, strings.NewReader(body))
+			request.Header.Set("Content-Type", contentType)
+			return request
+		}
+
+		It("exports the definitions of the streams, leaving deleted streams and migrations out", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, handler.StatePath, nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Header().Get("Content-Type")).To(Equal("application/json"))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"streams": [
+				{"namespace": "ns", "stream": "orders", "partitions": 3, "replicationFactor": 2, "config": {"cleanup.policy": "compact"}, "contentType": "application/json"},
+				{"namespace": "other-ns", "stream": "payments-v2", "partitions": 1, "replicationFactor": 1}
+			]}`))
+		})
+
+		It("exports YAML when the request accepts it", func() {
+			request := httptest.NewRequest(http.MethodGet, handler.StatePath, nil)
+			request.Header.Set("Accept", "text/plain, application/yaml")
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Header().Get("Content-Type")).To(Equal("application/yaml"))
+			Expect(responseRecorder.Body.String()).To(HavePrefix("streams:\n- namespace: ns\n  stream: orders\n  partitions: 3\n"))
+		})
+
+		It("creates the topics that don't exist and aligns the others", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, importRequest("application/json", `{"streams": [
+				{"namespace": "ns", "stream": "orders", "partitions": 3, "replicationFactor": 2, "config": {"cleanup.policy": "delete"}, "contentType": "application/json"},
+				{"namespace": "ns", "stream": "new", "partitions": 2, "replicationFactor": 3, "labels": {"team": "a"}},
+				{"namespace": "other-ns", "stream": "payments-v2", "partitions": 1, "replicationFactor": 1}
+			]}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"streams": [
+				{"topic": "ns_orders", "status": "updated"},
+				{"topic": "ns_new", "status": "created"},
+				{"topic": "other-ns_payments-v2", "status": "unchanged"}
+			]}`))
+			topicName, configEntries := fakeKafkaClient.AlterTopicConfigArgsForCall(0)
+			Expect(topicName).To(Equal("ns_orders"))
+			Expect(*configEntries["cleanup.policy"]).To(Equal("delete"))
+			topicName, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(topicName).To(Equal("ns_new"))
+			Expect(spec).To(Equal(client.TopicSpec{NumPartitions: 2, ReplicationFactor: 3}))
+			// the specs of the existing topics are recorded as imported
+			Expect(fakeKafkaClient.WriteMetadataCallCount()).To(Equal(3))
+			topicName, metadata := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(topicName).To(Equal("ns_orders"))
+			Expect(*metadata.Spec.ConfigEntries["cleanup.policy"]).To(Equal("delete"))
+			Expect(metadata.Migration.To).To(Equal("ns_orders2"))
+			topicName, metadata = fakeKafkaClient.WriteMetadataArgsForCall(1)
+			Expect(topicName).To(Equal("ns_new"))
+			Expect(metadata.Labels).To(Equal(map[string]string{"team": "a"}))
+			Expect(metadata.Spec).To(Equal(&client.TopicSpec{NumPartitions: 2, ReplicationFactor: 3}))
+		})
+
+		It("restores archived streams imported", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, importRequest("application/json", `{"streams": [
+				{"namespace": "ns", "stream": "archived", "partitions": 1, "replicationFactor": 1}
+			]}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			topicName, configEntries := fakeKafkaClient.AlterTopicConfigArgsForCall(0)
+			Expect(topicName).To(Equal("ns_archived"))
+			Expect(configEntries).To(BeEmpty())
+			_, metadata := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(metadata).To(Equal(client.StreamMetadata{Spec: &client.TopicSpec{NumPartitions: 1, ReplicationFactor: 1}}))
+		})
+
+		It("imports YAML documents", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, importRequest("application/yaml", `
+streams:
+- namespace: ns
+  stream: new
+  partitions: 1
+  replicationFactor: 1
+  config:
+    retention.ms: "86400000"
+  schema:
+    subjectNameStrategy: topic-name
+`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			_, spec := fakeKafkaClient.CreateTopicArgsForCall(0)
+			Expect(*spec.ConfigEntries["retention.ms"]).To(Equal("86400000"))
+			_, metadata := fakeKafkaClient.WriteMetadataArgsForCall(0)
+			Expect(metadata.Schema.SubjectNameStrategy).To(Equal(client.TopicNameStrategy))
+		})
+
+		It("returns 422 listing the streams whose configs the cluster doesn't take, importing none", func() {
+			fakeKafkaClient.TopicConfigKeysReturns(client.TopicConfigKeys{
+				"cleanup.policy": {Kind: client.ConfigString},
+				"retention.ms":   {Kind: client.ConfigInteger},
+			}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, importRequest("application/json", `{"streams": [
+				{"namespace": "ns", "stream": "orders", "partitions": 3, "replicationFactor": 2, "config": {"cleanup.policy": "delete"}},
+				{"namespace": "ns", "stream": "new", "partitions": 1, "replicationFactor": 1, "config": {"retention.ms": "a week", "retention.mss": "1"}}
+			]}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(errorOf(responseRecorder).Message).To(Equal("Invalid state: stream \"new\" of namespace \"ns\": invalid topic configs: " +
+				"retention.ms should be an integer, got \"a week\"; retention.mss is not a topic config"))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+			Expect(fakeKafkaClient.AlterTopicConfigCallCount()).To(BeZero())
+		})
+
+		It("reports the streams that couldn't be imported", func() {
+			fakeKafkaClient.CreateTopicReturns(sarama.ErrNotController)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, importRequest("application/json", `{"streams": [
+				{"namespace": "ns", "stream": "new", "partitions": 1, "replicationFactor": 1}
+			]}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"status":"failed"`))
+		})
+
+		It("plans the changes of an import without applying them", func() {
+			request := httptest.NewRequest(http.MethodPost, handler.PlanPath+"?prune=true", strings.NewReader(`{"streams": [
+				{"namespace": "ns", "stream": "orders", "partitions": 6, "replicationFactor": 2, "config": {"cleanup.policy": "delete"}, "contentType": "text/plain", "labels": {"team": "a"}},
+				{"namespace": "ns", "stream": "new", "partitions": 2, "replicationFactor": 3}
+			]}`))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"streams": [
+				{
+					"topic": "ns_orders",
+					"action": "update",
+					"changes": [
+						{"field": "config.cleanup.policy", "from": "compact", "to": "delete"},
+						{"field": "contentType", "from": "application/json", "to": "text/plain"},
+						{"field": "labels.team", "to": "a"}
+					],
+					"warnings": ["the topic has 3 partitions rather than 6, which imports leave alone"]
+				},
+				{
+					"topic": "ns_new",
+					"action": "create",
+					"changes": [{"field": "partitions", "to": 2}, {"field": "replicationFactor", "to": 3}]
+				}
+			]}`))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+			Expect(fakeKafkaClient.AlterTopicConfigCallCount()).To(BeZero())
+			Expect(fakeKafkaClient.WriteMetadataCallCount()).To(BeZero())
+		})
+
+		It("plans the deletion of the streams of the namespaces of the document it doesn't define when pruning", func() {
+			request := httptest.NewRequest(http.MethodPost, handler.PlanPath+"?prune=true", strings.NewReader(`{"streams": [
+				{"namespace": "other-ns", "stream": "new", "partitions": 1, "replicationFactor": 1}
+			]}`))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"streams": [
+				{"topic": "other-ns_new", "action": "create", "changes": [{"field": "partitions", "to": 1}, {"field": "replicationFactor", "to": 1}]},
+				{"topic": "other-ns_payments-v2", "action": "delete"}
+			]}`))
+		})
+
+		It("deletes the streams planned for deletion when importing with pruning", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, importRequest("application/json", `{"streams": [
+				{"namespace": "ns", "stream": "orders", "partitions": 3, "replicationFactor": 2, "config": {"cleanup.policy": "compact"}, "contentType": "application/json"}
+			]}`))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"streams": [{"topic": "ns_orders", "status": "unchanged"}]}`))
+
+			responseRecorder = httptest.NewRecorder()
+			request := importRequest("application/json", `{"streams": [{"namespace": "other-ns", "stream": "new", "partitions": 1, "replicationFactor": 1}]}`)
+			request.URL.RawQuery = "prune=true"
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"streams": [
+				{"topic": "other-ns_new", "status": "created"},
+				{"topic": "other-ns_payments-v2", "status": "deleted"}
+			]}`))
+			Expect(fakeKafkaClient.DeleteTopicArgsForCall(0)).To(Equal("other-ns_payments-v2"))
+		})
+
+		It("returns 405 for plans requested with other methods than POST", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, handler.PlanPath, nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+
+		It("returns 400 for invalid documents", func() {
+			for _, body := range []string{
+				`{"streams": [{"namespace": "ns", "stream": "a", "partitions": 1, "replicationFactor": 1, "unknown": true}]}`,
+				`{"streams": [{"namespace": "Not_A_Label", "stream": "a", "partitions": 1, "replicationFactor": 1}]}`,
+				`{"streams": [{"namespace": "ns", "stream": "a", "partitions": 0, "replicationFactor": 1}]}`,
+				`{"streams": [{"namespace": "ns", "stream": "a", "partitions": 1, "replicationFactor": 1, "archived": {}}]}`,
+				`{"streams": [{"namespace": "ns", "stream": "a", "partitions": 1, "replicationFactor": 1}, {"namespace": "ns", "stream": "a", "partitions": 1, "replicationFactor": 1}]}`,
+			} {
+				responseRecorder = httptest.NewRecorder()
+
+				creationHandlerFunc.ServeHTTP(responseRecorder, importRequest("application/json", body))
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest), body)
+			}
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+	})
+
+	Context("reconciling the config of topics with their provisioned spec", func() {
+		var compact, deleted string
+
+		BeforeEach(func() {
+			compact, deleted = "compact", "delete"
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{
+				"ns_orders":   {NumPartitions: 6, ReplicationFactor: 2, ConfigEntries: map[string]*string{"cleanup.policy": &deleted}},
+				"ns_aligned":  {NumPartitions: 1, ReplicationFactor: 1},
+				"ns_legacy":   {NumPartitions: 1, ReplicationFactor: 1, ConfigEntries: map[string]*string{"cleanup.policy": &deleted}},
+				"ns_archived": {NumPartitions: 1, ReplicationFactor: 1, ConfigEntries: map[string]*string{"retention.ms": &deleted}},
+			}, nil)
+			fakeKafkaClient.ListMetadataReturns(map[string]client.StreamMetadata{
+				"ns_orders":   {Spec: &client.TopicSpec{NumPartitions: 3, ReplicationFactor: 2, ConfigEntries: map[string]*string{"cleanup.policy": &compact}}},
+				"ns_aligned":  {Spec: &client.TopicSpec{NumPartitions: 1, ReplicationFactor: 1}},
+				"ns_legacy":   {ContentType: "text/plain"},
+				"ns_archived": {Spec: &client.TopicSpec{NumPartitions: 1, ReplicationFactor: 1}, Archived: &client.Archive{}},
+			}, nil)
+		})
+
+		It("reports the drift of topics without reverting it", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, handler.ReconcilePath, nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"streams": [
+				{
+					"topic": "ns_orders",
+					"changes": [
+						{"field": "partitions", "from": 3, "to": 6},
+						{"field": "config.cleanup.policy", "from": "compact", "to": "delete"}
+					],
+					"repaired": false
+				}
+			]}`))
+			Expect(fakeKafkaClient.AlterTopicConfigCallCount()).To(BeZero())
+		})
+
+		It("reverts the config of drifted topics, leaving their layout alone", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, handler.ReconcilePath, nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"repaired":true`))
+			Expect(fakeKafkaClient.AlterTopicConfigCallCount()).To(Equal(1))
+			topicName, configEntries := fakeKafkaClient.AlterTopicConfigArgsForCall(0)
+			Expect(topicName).To(Equal("ns_orders"))
+			Expect(*configEntries["cleanup.policy"]).To(Equal("compact"))
+		})
+
+		It("reports the drift that couldn't be reverted", func() {
+			fakeKafkaClient.AlterTopicConfigReturns(sarama.ErrNotController)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, handler.ReconcilePath, nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"repaired":false,"error"`))
+		})
+
+		It("counts the drifted topics when reconciling periodically", func() {
+			creationHandler := &handler.TopicCreationRequestHandler{KafkaClient: fakeKafkaClient, Logger: logger}
+
+			drifted, err := creationHandler.Reconcile(true)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drifted).To(Equal(1))
+			Expect(fakeKafkaClient.AlterTopicConfigCallCount()).To(Equal(1))
+		})
+
+		It("returns 405 for other methods than GET and POST", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPut, handler.ReconcilePath, nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+
+	Context("journaling provisioning decisions", func() {
+		var creationHandler *handler.TopicCreationRequestHandler
+
+		BeforeEach(func() {
+			creationHandler = &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Journal:     true,
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+		})
+
+		It("records the topics provisioned and deleted", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(fakeKafkaClient.RecordDecisionCallCount()).To(Equal(1))
+			topicName, decision := fakeKafkaClient.RecordDecisionArgsForCall(0)
+			Expect(topicName).To(Equal(kafkaTopicName))
+			Expect(decision.Action).To(Equal(client.DecisionProvisioned))
+			Expect(decision.Spec).To(Equal(&client.TopicSpec{NumPartitions: 1, ReplicationFactor: 1}))
+			Expect(decision.At).NotTo(BeZero())
+
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			creationHandlerFunc.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/"+existingTopicNamespace+"/"+existingTopicName, nil))
+
+			Expect(fakeKafkaClient.RecordDecisionCallCount()).To(Equal(2))
+			_, decision = fakeKafkaClient.RecordDecisionArgsForCall(1)
+			Expect(decision.Action).To(Equal(client.DecisionDeleted))
+		})
+
+		It("provisions streams even when their decision can't be journaled", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+			fakeKafkaClient.RecordDecisionReturns(sarama.ErrNotEnoughReplicas)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+		})
+
+		It("lists the last decision about each topic", func() {
+			at := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+			fakeKafkaClient.ListDecisionsReturns(map[string]client.Decision{
+				"ns_payments": {Action: client.DecisionDeleted, At: at},
+				"ns_orders":   {Action: client.DecisionProvisioned, At: at},
+			}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/journal", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"topics": [
+				{"topic": "ns_orders", "action": "provisioned", "at": "2026-10-15T09:00:00Z"},
+				{"topic": "ns_payments", "action": "deleted", "at": "2026-10-15T09:00:00Z"}
+			]}`))
+		})
+
+		It("lists the journal by pages", func() {
+			at := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+			fakeKafkaClient.ListDecisionsReturns(map[string]client.Decision{
+				"ns_payments": {Action: client.DecisionDeleted, At: at},
+				"ns_orders":   {Action: client.DecisionProvisioned, At: at},
+				"ns_refunds":  {Action: client.DecisionProvisioned, At: at},
+			}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/journal?limit=2", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"topics": [
+				{"topic": "ns_orders", "action": "provisioned", "at": "2026-10-15T09:00:00Z"},
+				{"topic": "ns_payments", "action": "deleted", "at": "2026-10-15T09:00:00Z"}
+			], "continue": "ns_payments"}`))
+
+			responseRecorder = httptest.NewRecorder()
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/journal?limit=2&continue=ns_payments", nil))
+
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"topics": [
+				{"topic": "ns_refunds", "action": "provisioned", "at": "2026-10-15T09:00:00Z"}
+			]}`))
+		})
+
+		It("lists the topics whose decision is selected", func() {
+			at := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+			fakeKafkaClient.ListDecisionsReturns(map[string]client.Decision{
+				"ns_payments": {Action: client.DecisionDeleted, At: at, Archived: true},
+				"ns_orders":   {Action: client.DecisionProvisioned, At: at},
+				"other_foo":   {Action: client.DecisionDeleted, At: at},
+			}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/journal?fieldSelector="+url.QueryEscape("action=deleted,namespace!=other"), nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"topics": [
+				{"topic": "ns_payments", "action": "deleted", "at": "2026-10-15T09:00:00Z", "archived": true}
+			]}`))
+		})
+
+		It("returns 400 for invalid limits of the journal", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/journal?limit=1001", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(errorOf(responseRecorder).Code).To(Equal(handler.CodeInvalidParameter))
+			Expect(fakeKafkaClient.ListDecisionsCallCount()).To(BeZero())
+		})
+
+		It("reports the topics owned that disappeared while it was down, once", func() {
+			at := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+			spec := &client.TopicSpec{NumPartitions: 3, ReplicationFactor: 2}
+			fakeKafkaClient.ListDecisionsReturns(map[string]client.Decision{
+				"ns_orders":   {Action: client.DecisionProvisioned, At: at},
+				"ns_payments": {Action: client.DecisionAltered, At: at, Spec: spec},
+				"ns_refunds":  {Action: client.DecisionDeleted, At: at},
+				"ns_returns":  {Action: client.DecisionDisappeared, At: at},
+			}, nil)
+			fakeKafkaClient.ListTopicsReturns(map[string]client.TopicSpec{"ns_orders": {NumPartitions: 1}}, nil)
+
+			recovery, err := creationHandler.RecoverJournal()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recovery).To(Equal(&handler.JournalRecovery{Owned: 1, Disappeared: []string{"ns_payments"}}))
+			Expect(fakeKafkaClient.RecordDecisionCallCount()).To(Equal(1))
+			topicName, decision := fakeKafkaClient.RecordDecisionArgsForCall(0)
+			Expect(topicName).To(Equal("ns_payments"))
+			Expect(decision.Action).To(Equal(client.DecisionDisappeared))
+			Expect(decision.Spec).To(Equal(spec))
+		})
+
+		It("fails to recover when the journal can't be read", func() {
+			fakeKafkaClient.ListDecisionsReturns(nil, sarama.ErrOutOfBrokers)
+
+			_, err := creationHandler.RecoverJournal()
+
+			Expect(err).To(MatchError(sarama.ErrOutOfBrokers))
+			Expect(fakeKafkaClient.RecordDecisionCallCount()).To(BeZero())
+		})
+
+		It("journals nothing unless enabled", func() {
+			creationHandler := &handler.TopicCreationRequestHandler{KafkaClient: fakeKafkaClient, Gateway: gateway, Logger: logger}
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+
+			creationHandler.GetHandlerFunc().ServeHTTP(httptest.NewRecorder(), request)
+			creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/journal", nil))
+
+			Expect(fakeKafkaClient.RecordDecisionCallCount()).To(BeZero())
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Context("as a read-only standby", func() {
+		BeforeEach(func() {
+			creationHandler := &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				ReadOnly:    true,
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+		})
+
+		It("rejects the requests changing streams", func() {
+			for _, mutation := range []*http.Request{
+				request,
+				httptest.NewRequest(http.MethodDelete, "/ns/orders", nil),
+				httptest.NewRequest(http.MethodDelete, "/ns", nil),
+				httptest.NewRequest(http.MethodPost, "/ns/orders/leaders", nil),
+				httptest.NewRequest(http.MethodPut, handler.StatePath, strings.NewReader(`{"streams": []}`)),
+				httptest.NewRequest(http.MethodPost, handler.ReconcilePath, nil),
+			} {
+				responseRecorder := httptest.NewRecorder()
+				creationHandlerFunc.ServeHTTP(responseRecorder, mutation)
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable), mutation.Method+" "+mutation.URL.Path)
+				Expect(responseRecorder.Body.String()).To(ContainSubstring("read-only standby"))
+			}
+			Expect(fakeKafkaClient.Invocations()).To(BeEmpty())
+		})
+
+		It("describes streams", func() {
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/"+existingTopicNamespace+"/"+existingTopicName, nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+
+		It("plans imports, which change nothing", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, handler.PlanPath, strings.NewReader(`{"streams": []}`)))
+
+			Expect(responseRecorder.Code).NotTo(Equal(http.StatusServiceUnavailable))
+		})
+
+		It("tells clients it is read-only", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, handler.CapabilitiesPath, nil))
+
+			Expect(responseRecorder.Body.String()).To(MatchJSON(`{"backend": "kafka", "partitions": true, "compaction": true, "transactions": true, "metadata": true, "replication": false, "maxNameLength": 249, "readOnly": true}`))
+		})
+
+		It("reports the topics that disappeared without journaling them", func() {
+			fakeKafkaClient.ListDecisionsReturns(map[string]client.Decision{"ns_orders": {Action: client.DecisionProvisioned}}, nil)
+
+			recovery, err := (&handler.TopicCreationRequestHandler{KafkaClient: fakeKafkaClient, Logger: logger, ReadOnly: true}).RecoverJournal()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recovery.Disappeared).To(ConsistOf("ns_orders"))
+			Expect(fakeKafkaClient.RecordDecisionCallCount()).To(BeZero())
+		})
+	})
+
+	Context("with conditional updates", func() {
+		var creationHandler *handler.TopicCreationRequestHandler
+
+		describe := func() string {
+			responseRecorder := httptest.NewRecorder()
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/some-namespace/some-topic", nil))
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			return responseRecorder.Header().Get("ETag")
+		}
+
+		relabel := func(ifMatch string) *http.Request {
+			request := httptest.NewRequest(http.MethodPut, "/some-namespace/some-topic", strings.NewReader(`{"labels": {"team": "orders"}}`))
+			if ifMatch != "" {
+				request.Header.Set("If-Match", ifMatch)
+			}
+			return request
+		}
+
+		BeforeEach(func() {
+			creationHandler = &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				ETags:       true,
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			fakeKafkaClient.DescribeTopicReturns(&client.TopicSpec{NumPartitions: 6, ReplicationFactor: 3}, nil)
+		})
+
+		It("tags streams with the state of their topic", func() {
+			retention := "1000"
+			etag := describe()
+			Expect(etag).To(MatchRegexp(`^"[0-9a-f]{32}"$`))
+			Expect(describe()).To(Equal(etag))
+
+			fakeKafkaClient.DescribeTopicReturns(&client.TopicSpec{NumPartitions: 6, ReplicationFactor: 3, ConfigEntries: map[string]*string{"retention.ms": &retention}}, nil)
+			Expect(describe()).NotTo(Equal(etag))
+			Expect(fakeKafkaClient.DescribeTopicArgsForCall(0)).To(Equal(kafkaTopicName))
+			Expect(fakeKafkaClient.ListTopicsCallCount()).To(BeZero())
+		})
+
+		It("tags streams with their metadata", func() {
+			etag := describe()
+
+			fakeKafkaClient.ReadMetadataReturns(&client.StreamMetadata{Labels: map[string]string{"team": "orders"}}, nil)
+
+			Expect(describe()).NotTo(Equal(etag))
+		})
+
+		It("changes streams described with their current ETag", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, relabel(describe()))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Header().Get("ETag")).NotTo(BeEmpty())
+			Expect(fakeKafkaClient.WriteMetadataCallCount()).To(Equal(1))
+		})
+
+		It("refuses to change streams that changed since they were described", func() {
+			etag := describe()
+			fakeKafkaClient.ReadMetadataReturns(&client.StreamMetadata{ContentType: "text/plain"}, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, relabel(etag))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusPreconditionFailed))
+			Expect(errorOf(responseRecorder).Code).To(Equal(handler.CodePreconditionFailed))
+			Expect(fakeKafkaClient.WriteMetadataCallCount()).To(BeZero())
+		})
+
+		It("lets a single one of the concurrent changes carrying the same ETag through", func() {
+			etag := describe()
+			fakeKafkaClient.WriteMetadataStub = func(topicName string, metadata client.StreamMetadata) error {
+				// the write lands after the other change had time to check its precondition
+				time.Sleep(20 * time.Millisecond)
+				fakeKafkaClient.ReadMetadataReturns(&metadata, nil)
+				return nil
+			}
+
+			codes := make(chan int, 2)
+			for i := 0; i < 2; i++ {
+				go func() {
+					defer GinkgoRecover()
+					responseRecorder := httptest.NewRecorder()
+					creationHandlerFunc.ServeHTTP(responseRecorder, relabel(etag))
+					codes <- responseRecorder.Code
+				}()
+			}
+
+			Expect([]int{<-codes, <-codes}).To(ConsistOf(http.StatusOK, http.StatusPreconditionFailed))
+			Expect(fakeKafkaClient.WriteMetadataCallCount()).To(Equal(1))
+		})
+
+		It("matches any of the ETags listed, or any stream that exists", func() {
+			for _, ifMatch := range []string{`"stale", ` + describe(), "*"} {
+				responseRecorder := httptest.NewRecorder()
+				creationHandlerFunc.ServeHTTP(responseRecorder, relabel(ifMatch))
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusOK), ifMatch)
+			}
+		})
+
+		It("refuses to change streams that don't exist when If-Match is set", func() {
+			fakeKafkaClient.DescribeTopicReturns(nil, nil)
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, relabel("*"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusPreconditionFailed))
+			Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
+		})
+
+		It("doesn't require If-Match unless told to", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, relabel(""))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+
+		Context("when If-Match is required", func() {
+			BeforeEach(func() {
+				creationHandler.RequireIfMatch = true
+				creationHandlerFunc = creationHandler.GetHandlerFunc()
+			})
+
+			It("refuses to change the metadata of existing streams without If-Match", func() {
+				creationHandlerFunc.ServeHTTP(responseRecorder, relabel(""))
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusPreconditionRequired))
+				Expect(errorOf(responseRecorder).Code).To(Equal(handler.CodePreconditionRequired))
+				Expect(fakeKafkaClient.WriteMetadataCallCount()).To(BeZero())
+			})
+
+			It("creates streams without If-Match", func() {
+				fakeKafkaClient.TopicExistsReturns(false, nil)
+				fakeKafkaClient.DescribeTopicReturns(nil, nil)
+
+				creationHandlerFunc.ServeHTTP(responseRecorder, relabel(""))
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			})
+
+			It("provisions existing streams without a body or If-Match", func() {
+				creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			})
+		})
+
+		It("doesn't tag the streams of other backends", func() {
+			fakeBackend := &handlerfakes.FakeBackend{}
+			fakeBackend.CapabilitiesReturns(handler.Capabilities{Backend: "pulsar"})
+			fakeBackend.CreateStreamReturns(&handler.Stream{Topic: "some-namespace.some-topic"}, true, nil)
+			creationHandler.Backend = fakeBackend
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some-topic?replicate=false"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(responseRecorder.Header().Get("ETag")).To(BeEmpty())
+		})
+	})
+
+	Context("listing the recent operations", func() {
+		var operations *history.History
+
+		BeforeEach(func() {
+			operations = history.New(10)
+			creationHandler := &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Operations:  operations,
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+		})
+
+		It("lists the operations recorded, the most recent first", func() {
+			fakeKafkaClient.TopicExistsReturns(false, nil)
+			fakeKafkaClient.CreateTopicReturnsOnCall(1, sarama.ErrRequestTimedOut)
+			instrumented := operations.Instrument(creationHandlerFunc, func(err error) { Fail(err.Error()) })
+			instrumented.ServeHTTP(httptest.NewRecorder(), putRequest("/ns/orders"))
+			instrumented.ServeHTTP(httptest.NewRecorder(), putRequest("/ns/payments"))
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/operations?limit=1", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			result := map[string][]history.Operation{}
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &result)).To(Succeed())
+			Expect(result["operations"]).To(HaveLen(1))
+			Expect(result["operations"][0].Path).To(Equal("/ns/payments"))
+			Expect(result["operations"][0].Status).To(Equal(http.StatusServiceUnavailable))
+			Expect(result["operations"][0].Error).To(ContainSubstring("ns_payments"))
+		})
+
+		It("records who asked for operations, once authenticated", func() {
+			fakeAuthorizer := &authzfakes.FakeAuthorizer{}
+			fakeAuthorizer.AuthorizeReturns(authz.Decision{Authenticated: true, Allowed: true, Username: "jane"}, nil)
+			creationHandler := &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Authorizer:  fakeAuthorizer,
+				Operations:  operations,
+			}
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+			request.Header.Set("Authorization", "Bearer some-token")
+
+			operations.Instrument(creationHandler.GetHandlerFunc(), func(err error) { Fail(err.Error()) }).ServeHTTP(responseRecorder, request)
+
+			Expect(operations.Recent(1)[0].Requester).To(Equal("jane"))
+		})
+
+		It("returns 400 for invalid limits", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/operations?limit=0", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("returns 404 when no history is kept", func() {
+			creationHandler := &handler.TopicCreationRequestHandler{KafkaClient: fakeKafkaClient, Gateway: gateway, Logger: logger}
+
+			creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/operations", nil))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Context("when callers are authorized against kubernetes", func() {
+		var (
+			fakeAuthorizer *authzfakes.FakeAuthorizer
+			// adminHandler stands for an endpoint administering the provisioner, such as /log-level
+			adminHandler http.Handler
+		)
+
+		BeforeEach(func() {
+			fakeAuthorizer = &authzfakes.FakeAuthorizer{}
+			creationHandler := &handler.TopicCreationRequestHandler{
+				KafkaClient: fakeKafkaClient,
+				Gateway:     gateway,
+				Logger:      logger,
+				Authorizer:  fakeAuthorizer,
+			}
+			creationHandlerFunc = creationHandler.GetHandlerFunc()
+			adminHandler = creationHandler.AuthorizeClusterWide(http.HandlerFunc(func(responseWriter http.ResponseWriter, _ *http.Request) {
+				responseWriter.WriteHeader(http.StatusNoContent)
+			}))
+			fakeKafkaClient.TopicExistsReturns(true, nil)
+		})
+
+		It("requires a token to administer the provisioner", func() {
+			adminHandler.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPut, "/log-level", strings.NewReader(`{"level": "debug"}`)))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnauthorized))
+			Expect(fakeAuthorizer.AuthorizeCallCount()).To(BeZero())
+		})
+
+		It("checks the token may update streams in all namespaces to administer the provisioner", func() {
+			fakeAuthorizer.AuthorizeReturns(authz.Decision{Authenticated: true, Reason: "denied"}, nil)
+			request := httptest.NewRequest(http.MethodPut, "/log-level", strings.NewReader(`{"level": "debug"}`))
+			request.Header.Set("Authorization", "Bearer some-token")
+
+			adminHandler.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusForbidden))
+			token, namespace, verb := fakeAuthorizer.AuthorizeArgsForCall(0)
+			Expect([]string{token, namespace, verb}).To(Equal([]string{"some-token", "", "update"}))
+		})
+
+		It("checks the token may list streams in all namespaces to read the settings of the provisioner", func() {
+			fakeAuthorizer.AuthorizeReturns(authz.Decision{Authenticated: true, Allowed: true}, nil)
+			request := httptest.NewRequest(http.MethodGet, "/log-level", nil)
+			request.Header.Set("Authorization", "Bearer some-token")
+
+			adminHandler.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNoContent))
+			_, namespace, verb := fakeAuthorizer.AuthorizeArgsForCall(0)
+			Expect([]string{namespace, verb}).To(Equal([]string{"", "list"}))
+		})
+
+		It("returns 401 when the request carries no token", func() {
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnauthorized))
+			Expect(responseRecorder.Header().Get("WWW-Authenticate")).To(Equal("Bearer"))
+			Expect(fakeAuthorizer.AuthorizeCallCount()).To(Equal(0))
+		})
+
+		It("checks the token may create streams in the namespace", func() {
+			fakeAuthorizer.AuthorizeReturns(authz.Decision{Authenticated: true, Allowed: true}, nil)
+			request.Header.Set("Authorization", "Bearer some-token")
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			token, namespace, verb := fakeAuthorizer.AuthorizeArgsForCall(0)
+			Expect([]string{token, namespace, verb}).To(Equal([]string{"some-token", existingTopicNamespace, "create"}))
+		})
+
+		It("returns 401 when the token is rejected", func() {
+			fakeAuthorizer.AuthorizeReturns(authz.Decision{Reason: "token expired"}, nil)
+			request.Header.Set("Authorization", "Bearer some-token")
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnauthorized))
+			Expect(errorOf(responseRecorder).Message).To(Equal("Invalid bearer token: token expired"))
+		})
+
+		It("returns 403 when the caller may not manage streams in the namespace", func() {
+			fakeAuthorizer.AuthorizeReturns(authz.Decision{Authenticated: true, Reason: "no RBAC policy matched"}, nil)
+			request.Header.Set("Authorization", "Bearer some-token")
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusForbidden))
+			Expect(errorOf(responseRecorder).Message).To(Equal("Forbidden: no RBAC policy matched"))
+			Expect(fakeKafkaClient.TopicExistsCallCount()).To(Equal(0))
+		})
+
+		It("checks the token may list streams in all namespaces for the catalog", func() {
+			fakeAuthorizer.AuthorizeReturns(authz.Decision{Authenticated: true, Allowed: true}, nil)
+			request := httptest.NewRequest(http.MethodGet, "/streams", nil)
+			request.Header.Set("Authorization", "Bearer some-token")
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			token, namespace, verb := fakeAuthorizer.AuthorizeArgsForCall(0)
+			Expect([]string{token, namespace, verb}).To(Equal([]string{"some-token", "", "list"}))
+		})
+
+		It("checks the token may delete all the streams of a namespace to deprovision it", func() {
+			fakeAuthorizer.AuthorizeReturns(authz.Decision{Authenticated: true, Allowed: false, Reason: "denied"}, nil)
+			request := httptest.NewRequest(http.MethodDelete, "/some-namespace", nil)
+			request.Header.Set("Authorization", "Bearer some-token")
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusForbidden))
+			token, namespace, verb := fakeAuthorizer.AuthorizeArgsForCall(0)
+			Expect([]string{token, namespace, verb}).To(Equal([]string{"some-token", "some-namespace", "deletecollection"}))
+			Expect(fakeKafkaClient.DeleteTopicCallCount()).To(BeZero())
+		})
+
+		It("checks the token may create streams in all namespaces to import them", func() {
+			fakeAuthorizer.AuthorizeReturns(authz.Decision{Authenticated: true, Allowed: false, Reason: "denied"}, nil)
+			request := httptest.NewRequest(http.MethodPut, handler.StatePath, strings.NewReader(`{"streams": []}`))
+			request.Header.Set("Authorization", "Bearer some-token")
+
+			creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusForbidden))
+			token, namespace, verb := fakeAuthorizer.AuthorizeArgsForCall(0)
+			Expect([]string{token, namespace, verb}).To(Equal([]string{"some-token", "", "create"}))
+			Expect(fakeKafkaClient.ListTopicsCallCount()).To(BeZero())
+		})
+	})
+
+	It("returns 405 for other methods than GET, PUT and DELETE on streams", func() {
+		creationHandlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, "/some-namespace/some-topic", nil))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusMethodNotAllowed))
+		Expect(responseRecorder.Header().Get("Content-Type")).To(Equal("application/json"))
+		Expect(responseRecorder.Body.String()).To(MatchJSON(`{"code": "METHOD_NOT_ALLOWED", "message": "Method POST is not allowed", "retryable": false}`))
+		Expect(fakeKafkaClient.CreateTopicCallCount()).To(BeZero())
 	})
 
 	It("returns 400 if the the topic is not properly specified", func() {
@@ -71,8 +3223,38 @@ var _ = Describe("Provisioner HTTP Handler", func() {
 
 		Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest),
 			fmt.Sprintf("Expected %d after topic creation request but got %d", http.StatusBadRequest, responseRecorder.Code))
-		Expect(responseRecorder.Body.String()).
-			To(Equal("URLs should be of the form /<namespace>/<stream-name>\n"))
+		Expect(errorOf(responseRecorder).Message).
+			To(Equal("URLs should be of the form /<namespace>/<stream-name>"))
+	})
+
+	It("returns 400 if the stream name makes for an invalid topic name", func() {
+		creationHandlerFunc.ServeHTTP(responseRecorder, putRequest("/some-namespace/some%20topic"))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		Expect(errorOf(responseRecorder).Message).To(HavePrefix("Invalid stream: topic name \"some-namespace_some topic\" contains characters"))
+		Expect(errorOf(responseRecorder).Code).To(Equal(handler.CodeInvalidName))
+		Expect(fakeKafkaClient.TopicExistsCallCount()).To(Equal(0))
+	})
+
+	It("returns 422 if the topic spec violates the rules", func() {
+		creationHandler := &handler.TopicCreationRequestHandler{
+			KafkaClient: fakeKafkaClient,
+			Gateway:     gateway,
+			Logger:      logger,
+			Rules:       validation.Rules{AllowedConfigs: []string{"retention.ms"}},
+			Replication: &handler.ReplicationPolicy{
+				SourceClusterAlias: "primary",
+				ConfigEntries:      map[string]*string{"min.insync.replicas": nil},
+			},
+		}
+		fakeKafkaClient.TopicExistsReturns(false, nil)
+
+		creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, putRequest(request.URL.Path+"?replicate=true"))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+		Expect(errorOf(responseRecorder).Message).
+			To(Equal("Refusing to create topic \"" + kafkaTopicName + "\": invalid topic spec: configs min.insync.replicas are not allowed"))
+		Expect(fakeKafkaClient.CreateTopicCallCount()).To(Equal(0))
 	})
 
 	It("returns 500 if an unexpected error occurred while listing topics", func() {
@@ -82,19 +3264,76 @@ var _ = Describe("Provisioner HTTP Handler", func() {
 
 		Expect(responseRecorder.Code).To(Equal(http.StatusInternalServerError),
 			fmt.Sprintf("Expected %d after topic creation request but got %d", http.StatusInternalServerError, responseRecorder.Code))
-		Expect(responseRecorder.Body.String()).
-			To(Equal("Error trying to list topics to see if \"" + kafkaTopicName + "\" exists: oopsie\n"))
+		Expect(errorOf(responseRecorder).Message).
+			To(Equal("Error trying to list topics to see if \"" + kafkaTopicName + "\" exists: oopsie"))
 	})
 
-	It("returns 500 if a server error occurred while listing topics", func() {
+	It("returns 422 if a terminal server error occurred while listing topics", func() {
 		fakeKafkaClient.TopicExistsReturns(false, &client.KafkaError{KError: sarama.ErrInvalidPartitions})
 
 		creationHandlerFunc.ServeHTTP(responseRecorder, request)
 
-		Expect(responseRecorder.Code).To(Equal(http.StatusInternalServerError),
-			fmt.Sprintf("Expected %d after topic creation request but got %d", http.StatusInternalServerError, responseRecorder.Code))
-		Expect(responseRecorder.Body.String()).
-			To(Equal("Error trying to list topics to see if \"" + kafkaTopicName + "\" exists: kafka server: Number of partitions is invalid.\n"))
+		Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity),
+			fmt.Sprintf("Expected %d after topic creation request but got %d", http.StatusUnprocessableEntity, responseRecorder.Code))
+		Expect(errorOf(responseRecorder).Message).
+			To(Equal("Error trying to list topics to see if \"" + kafkaTopicName + "\" exists: kafka server: Number of partitions is invalid"))
+	})
+
+	It("returns 503 with a Retry-After header if a transient server error occurred while listing topics", func() {
+		fakeKafkaClient.TopicExistsReturns(false, &client.KafkaError{KError: sarama.ErrNotController})
+
+		creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(responseRecorder.Header().Get("Retry-After")).To(Equal("5"))
+	})
+
+	It("suggests retrying once Kafka is expected to take requests again, rounded up to the second", func() {
+		fakeKafkaClient.TopicExistsReturns(false, &client.KafkaError{KError: sarama.ErrNotController})
+		creationHandler := &handler.TopicCreationRequestHandler{
+			KafkaClient: fakeKafkaClient,
+			Gateway:     gateway,
+			Logger:      logger,
+			Backoff:     func() time.Duration { return 12300 * time.Millisecond },
+		}
+
+		creationHandler.GetHandlerFunc().ServeHTTP(responseRecorder, request)
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(responseRecorder.Header().Get("Retry-After")).To(Equal("13"))
+	})
+
+	It("returns 200 if another replica created the topic concurrently", func() {
+		fakeKafkaClient.TopicExistsReturns(false, nil)
+		fakeKafkaClient.CreateTopicReturns(&sarama.TopicError{Err: sarama.ErrTopicAlreadyExists})
+
+		creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		Expect(responseRecorder.Body.String()).To(MatchJSON(
+			fmt.Sprintf(`{"gateway": "%s", "gateways": {"grpc": {"address": "%s", "tls": false}}, "topic": "%s_%s"}`,
+				gateway, gateway, existingTopicNamespace, existingTopicName)))
+		Expect(fakeKafkaClient.WriteMetadataCallCount()).To(BeZero())
+	})
+
+	It("returns 503 with a Retry-After header if the broker is unreachable while creating a topic", func() {
+		fakeKafkaClient.TopicExistsReturns(false, nil)
+		fakeKafkaClient.CreateTopicReturns(sarama.ErrOutOfBrokers)
+
+		creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(responseRecorder.Header().Get("Retry-After")).To(Equal("5"))
+	})
+
+	It("returns 422 if the topic creation is rejected by the broker", func() {
+		fakeKafkaClient.TopicExistsReturns(false, nil)
+		fakeKafkaClient.CreateTopicReturns(&sarama.TopicError{Err: sarama.ErrInvalidReplicationFactor})
+
+		creationHandlerFunc.ServeHTTP(responseRecorder, request)
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+		Expect(responseRecorder.Header().Get("Retry-After")).To(BeEmpty())
 	})
 
 	It("returns 500 if an error occurred while creating a topic", func() {
@@ -105,11 +3344,51 @@ var _ = Describe("Provisioner HTTP Handler", func() {
 
 		Expect(responseRecorder.Code).To(Equal(http.StatusInternalServerError),
 			fmt.Sprintf("Expected %d after topic creation request but got %d", http.StatusInternalServerError, responseRecorder.Code))
-		Expect(responseRecorder.Body.String()).
-			To(Equal("Error creating topic \"" + kafkaTopicName + "\": oopsie\n"))
+		Expect(errorOf(responseRecorder).Message).
+			To(Equal("Error creating topic \"" + kafkaTopicName + "\": oopsie"))
 	})
 })
 
+var logger = slog.New(slog.NewTextHandler(ioutil.Discard, nil))
+
+// selectedGateway is a gateway selector always selecting the same endpoint
+type selectedGateway string
+
+func (g selectedGateway) Selected() string {
+	return string(g)
+}
+
 func putRequest(path string) *http.Request {
 	return httptest.NewRequest("PUT", path, nil)
 }
+
+var _ = DescribeTable("Route",
+	func(path, route string) {
+		Expect(handler.Route(path)).To(Equal(route))
+	},
+	Entry("a stream", "/ns/orders", "/{namespace}/{stream}"),
+	Entry("a namespace", "/ns", "/{namespace}"),
+	Entry("the leaders of a stream", "/ns/orders/leaders", "/{namespace}/{stream}/leaders"),
+	Entry("the mirror of a stream", "/ns/orders/mirror", "/{namespace}/{stream}/mirror"),
+	Entry("the migration of a stream", "/ns/orders/migration", "/{namespace}/{stream}/migration"),
+	Entry("a changelog", "/ns/app/changelogs/store", "/{namespace}/{app}/changelogs/{store}"),
+	Entry("the catalog", "/streams", "/streams"),
+	Entry("the plan", "/state/plan", "/state/plan"),
+	Entry("the journal", "/journal", "/journal"),
+	Entry("the root", "/", "other"),
+	Entry("an empty segment", "/ns//orders", "other"),
+	Entry("too many segments", "/ns/orders/a/b/c", "other"),
+)
+
+// errorResult is the body of error responses
+type errorResult struct {
+	Code      handler.ErrorCode `json:"code"`
+	Message   string            `json:"message"`
+	Retryable bool              `json:"retryable"`
+}
+
+func errorOf(recorder *httptest.ResponseRecorder) errorResult {
+	result := errorResult{}
+	ExpectWithOffset(1, json.Unmarshal(recorder.Body.Bytes(), &result)).To(Succeed())
+	return result
+}
@@ -0,0 +1,347 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package handlerfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/handler"
+)
+
+type FakeBackend struct {
+	CapabilitiesStub        func() handler.Capabilities
+	capabilitiesMutex       sync.RWMutex
+	capabilitiesArgsForCall []struct {
+	}
+	capabilitiesReturns struct {
+		result1 handler.Capabilities
+	}
+	capabilitiesReturnsOnCall map[int]struct {
+		result1 handler.Capabilities
+	}
+	CreateStreamStub        func(context.Context, handler.StreamRequest) (*handler.Stream, bool, error)
+	createStreamMutex       sync.RWMutex
+	createStreamArgsForCall []struct {
+		arg1 context.Context
+		arg2 handler.StreamRequest
+	}
+	createStreamReturns struct {
+		result1 *handler.Stream
+		result2 bool
+		result3 error
+	}
+	createStreamReturnsOnCall map[int]struct {
+		result1 *handler.Stream
+		result2 bool
+		result3 error
+	}
+	DeleteStreamStub        func(context.Context, string, string) (*handler.Stream, error)
+	deleteStreamMutex       sync.RWMutex
+	deleteStreamArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	deleteStreamReturns struct {
+		result1 *handler.Stream
+		result2 error
+	}
+	deleteStreamReturnsOnCall map[int]struct {
+		result1 *handler.Stream
+		result2 error
+	}
+	DescribeStreamStub        func(context.Context, string, string) (*handler.Stream, error)
+	describeStreamMutex       sync.RWMutex
+	describeStreamArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	describeStreamReturns struct {
+		result1 *handler.Stream
+		result2 error
+	}
+	describeStreamReturnsOnCall map[int]struct {
+		result1 *handler.Stream
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeBackend) Capabilities() handler.Capabilities {
+	fake.capabilitiesMutex.Lock()
+	ret, specificReturn := fake.capabilitiesReturnsOnCall[len(fake.capabilitiesArgsForCall)]
+	fake.capabilitiesArgsForCall = append(fake.capabilitiesArgsForCall, struct {
+	}{})
+	stub := fake.CapabilitiesStub
+	fakeReturns := fake.capabilitiesReturns
+	fake.recordInvocation("Capabilities", []interface{}{})
+	fake.capabilitiesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBackend) CapabilitiesCallCount() int {
+	fake.capabilitiesMutex.RLock()
+	defer fake.capabilitiesMutex.RUnlock()
+	return len(fake.capabilitiesArgsForCall)
+}
+
+func (fake *FakeBackend) CapabilitiesCalls(stub func() handler.Capabilities) {
+	fake.capabilitiesMutex.Lock()
+	defer fake.capabilitiesMutex.Unlock()
+	fake.CapabilitiesStub = stub
+}
+
+func (fake *FakeBackend) CapabilitiesReturns(result1 handler.Capabilities) {
+	fake.capabilitiesMutex.Lock()
+	defer fake.capabilitiesMutex.Unlock()
+	fake.CapabilitiesStub = nil
+	fake.capabilitiesReturns = struct {
+		result1 handler.Capabilities
+	}{result1}
+}
+
+func (fake *FakeBackend) CapabilitiesReturnsOnCall(i int, result1 handler.Capabilities) {
+	fake.capabilitiesMutex.Lock()
+	defer fake.capabilitiesMutex.Unlock()
+	fake.CapabilitiesStub = nil
+	if fake.capabilitiesReturnsOnCall == nil {
+		fake.capabilitiesReturnsOnCall = make(map[int]struct {
+			result1 handler.Capabilities
+		})
+	}
+	fake.capabilitiesReturnsOnCall[i] = struct {
+		result1 handler.Capabilities
+	}{result1}
+}
+
+func (fake *FakeBackend) CreateStream(arg1 context.Context, arg2 handler.StreamRequest) (*handler.Stream, bool, error) {
+	fake.createStreamMutex.Lock()
+	ret, specificReturn := fake.createStreamReturnsOnCall[len(fake.createStreamArgsForCall)]
+	fake.createStreamArgsForCall = append(fake.createStreamArgsForCall, struct {
+		arg1 context.Context
+		arg2 handler.StreamRequest
+	}{arg1, arg2})
+	stub := fake.CreateStreamStub
+	fakeReturns := fake.createStreamReturns
+	fake.recordInvocation("CreateStream", []interface{}{arg1, arg2})
+	fake.createStreamMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeBackend) CreateStreamCallCount() int {
+	fake.createStreamMutex.RLock()
+	defer fake.createStreamMutex.RUnlock()
+	return len(fake.createStreamArgsForCall)
+}
+
+func (fake *FakeBackend) CreateStreamCalls(stub func(context.Context, handler.StreamRequest) (*handler.Stream, bool, error)) {
+	fake.createStreamMutex.Lock()
+	defer fake.createStreamMutex.Unlock()
+	fake.CreateStreamStub = stub
+}
+
+func (fake *FakeBackend) CreateStreamArgsForCall(i int) (context.Context, handler.StreamRequest) {
+	fake.createStreamMutex.RLock()
+	defer fake.createStreamMutex.RUnlock()
+	argsForCall := fake.createStreamArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeBackend) CreateStreamReturns(result1 *handler.Stream, result2 bool, result3 error) {
+	fake.createStreamMutex.Lock()
+	defer fake.createStreamMutex.Unlock()
+	fake.CreateStreamStub = nil
+	fake.createStreamReturns = struct {
+		result1 *handler.Stream
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeBackend) CreateStreamReturnsOnCall(i int, result1 *handler.Stream, result2 bool, result3 error) {
+	fake.createStreamMutex.Lock()
+	defer fake.createStreamMutex.Unlock()
+	fake.CreateStreamStub = nil
+	if fake.createStreamReturnsOnCall == nil {
+		fake.createStreamReturnsOnCall = make(map[int]struct {
+			result1 *handler.Stream
+			result2 bool
+			result3 error
+		})
+	}
+	fake.createStreamReturnsOnCall[i] = struct {
+		result1 *handler.Stream
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeBackend) DeleteStream(arg1 context.Context, arg2 string, arg3 string) (*handler.Stream, error) {
+	fake.deleteStreamMutex.Lock()
+	ret, specificReturn := fake.deleteStreamReturnsOnCall[len(fake.deleteStreamArgsForCall)]
+	fake.deleteStreamArgsForCall = append(fake.deleteStreamArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteStreamStub
+	fakeReturns := fake.deleteStreamReturns
+	fake.recordInvocation("DeleteStream", []interface{}{arg1, arg2, arg3})
+	fake.deleteStreamMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBackend) DeleteStreamCallCount() int {
+	fake.deleteStreamMutex.RLock()
+	defer fake.deleteStreamMutex.RUnlock()
+	return len(fake.deleteStreamArgsForCall)
+}
+
+func (fake *FakeBackend) DeleteStreamCalls(stub func(context.Context, string, string) (*handler.Stream, error)) {
+	fake.deleteStreamMutex.Lock()
+	defer fake.deleteStreamMutex.Unlock()
+	fake.DeleteStreamStub = stub
+}
+
+func (fake *FakeBackend) DeleteStreamArgsForCall(i int) (context.Context, string, string) {
+	fake.deleteStreamMutex.RLock()
+	defer fake.deleteStreamMutex.RUnlock()
+	argsForCall := fake.deleteStreamArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeBackend) DeleteStreamReturns(result1 *handler.Stream, result2 error) {
+	fake.deleteStreamMutex.Lock()
+	defer fake.deleteStreamMutex.Unlock()
+	fake.DeleteStreamStub = nil
+	fake.deleteStreamReturns = struct {
+		result1 *handler.Stream
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) DeleteStreamReturnsOnCall(i int, result1 *handler.Stream, result2 error) {
+	fake.deleteStreamMutex.Lock()
+	defer fake.deleteStreamMutex.Unlock()
+	fake.DeleteStreamStub = nil
+	if fake.deleteStreamReturnsOnCall == nil {
+		fake.deleteStreamReturnsOnCall = make(map[int]struct {
+			result1 *handler.Stream
+			result2 error
+		})
+	}
+	fake.deleteStreamReturnsOnCall[i] = struct {
+		result1 *handler.Stream
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) DescribeStream(arg1 context.Context, arg2 string, arg3 string) (*handler.Stream, error) {
+	fake.describeStreamMutex.Lock()
+	ret, specificReturn := fake.describeStreamReturnsOnCall[len(fake.describeStreamArgsForCall)]
+	fake.describeStreamArgsForCall = append(fake.describeStreamArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.DescribeStreamStub
+	fakeReturns := fake.describeStreamReturns
+	fake.recordInvocation("DescribeStream", []interface{}{arg1, arg2, arg3})
+	fake.describeStreamMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBackend) DescribeStreamCallCount() int {
+	fake.describeStreamMutex.RLock()
+	defer fake.describeStreamMutex.RUnlock()
+	return len(fake.describeStreamArgsForCall)
+}
+
+func (fake *FakeBackend) DescribeStreamCalls(stub func(context.Context, string, string) (*handler.Stream, error)) {
+	fake.describeStreamMutex.Lock()
+	defer fake.describeStreamMutex.Unlock()
+	fake.DescribeStreamStub = stub
+}
+
+func (fake *FakeBackend) DescribeStreamArgsForCall(i int) (context.Context, string, string) {
+	fake.describeStreamMutex.RLock()
+	defer fake.describeStreamMutex.RUnlock()
+	argsForCall := fake.describeStreamArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeBackend) DescribeStreamReturns(result1 *handler.Stream, result2 error) {
+	fake.describeStreamMutex.Lock()
+	defer fake.describeStreamMutex.Unlock()
+	fake.DescribeStreamStub = nil
+	fake.describeStreamReturns = struct {
+		result1 *handler.Stream
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) DescribeStreamReturnsOnCall(i int, result1 *handler.Stream, result2 error) {
+	fake.describeStreamMutex.Lock()
+	defer fake.describeStreamMutex.Unlock()
+	fake.DescribeStreamStub = nil
+	if fake.describeStreamReturnsOnCall == nil {
+		fake.describeStreamReturnsOnCall = make(map[int]struct {
+			result1 *handler.Stream
+			result2 error
+		})
+	}
+	fake.describeStreamReturnsOnCall[i] = struct {
+		result1 *handler.Stream
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeBackend) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ handler.Backend = new(FakeBackend)
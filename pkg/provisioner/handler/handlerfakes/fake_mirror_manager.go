@@ -0,0 +1,340 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package handlerfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/clusterlink"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/handler"
+)
+
+type FakeMirrorManager struct {
+	CreateMirrorStub        func(context.Context, string) error
+	createMirrorMutex       sync.RWMutex
+	createMirrorArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	createMirrorReturns struct {
+		result1 error
+	}
+	createMirrorReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteMirrorStub        func(context.Context, string) error
+	deleteMirrorMutex       sync.RWMutex
+	deleteMirrorArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	deleteMirrorReturns struct {
+		result1 error
+	}
+	deleteMirrorReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DescribeMirrorStub        func(context.Context, string) (*clusterlink.Mirror, error)
+	describeMirrorMutex       sync.RWMutex
+	describeMirrorArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	describeMirrorReturns struct {
+		result1 *clusterlink.Mirror
+		result2 error
+	}
+	describeMirrorReturnsOnCall map[int]struct {
+		result1 *clusterlink.Mirror
+		result2 error
+	}
+	PromoteMirrorStub        func(context.Context, string) error
+	promoteMirrorMutex       sync.RWMutex
+	promoteMirrorArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	promoteMirrorReturns struct {
+		result1 error
+	}
+	promoteMirrorReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeMirrorManager) CreateMirror(arg1 context.Context, arg2 string) error {
+	fake.createMirrorMutex.Lock()
+	ret, specificReturn := fake.createMirrorReturnsOnCall[len(fake.createMirrorArgsForCall)]
+	fake.createMirrorArgsForCall = append(fake.createMirrorArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.CreateMirrorStub
+	fakeReturns := fake.createMirrorReturns
+	fake.recordInvocation("CreateMirror", []interface{}{arg1, arg2})
+	fake.createMirrorMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMirrorManager) CreateMirrorCallCount() int {
+	fake.createMirrorMutex.RLock()
+	defer fake.createMirrorMutex.RUnlock()
+	return len(fake.createMirrorArgsForCall)
+}
+
+func (fake *FakeMirrorManager) CreateMirrorCalls(stub func(context.Context, string) error) {
+	fake.createMirrorMutex.Lock()
+	defer fake.createMirrorMutex.Unlock()
+	fake.CreateMirrorStub = stub
+}
+
+func (fake *FakeMirrorManager) CreateMirrorArgsForCall(i int) (context.Context, string) {
+	fake.createMirrorMutex.RLock()
+	defer fake.createMirrorMutex.RUnlock()
+	argsForCall := fake.createMirrorArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeMirrorManager) CreateMirrorReturns(result1 error) {
+	fake.createMirrorMutex.Lock()
+	defer fake.createMirrorMutex.Unlock()
+	fake.CreateMirrorStub = nil
+	fake.createMirrorReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMirrorManager) CreateMirrorReturnsOnCall(i int, result1 error) {
+	fake.createMirrorMutex.Lock()
+	defer fake.createMirrorMutex.Unlock()
+	fake.CreateMirrorStub = nil
+	if fake.createMirrorReturnsOnCall == nil {
+		fake.createMirrorReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createMirrorReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMirrorManager) DeleteMirror(arg1 context.Context, arg2 string) error {
+	fake.deleteMirrorMutex.Lock()
+	ret, specificReturn := fake.deleteMirrorReturnsOnCall[len(fake.deleteMirrorArgsForCall)]
+	fake.deleteMirrorArgsForCall = append(fake.deleteMirrorArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DeleteMirrorStub
+	fakeReturns := fake.deleteMirrorReturns
+	fake.recordInvocation("DeleteMirror", []interface{}{arg1, arg2})
+	fake.deleteMirrorMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMirrorManager) DeleteMirrorCallCount() int {
+	fake.deleteMirrorMutex.RLock()
+	defer fake.deleteMirrorMutex.RUnlock()
+	return len(fake.deleteMirrorArgsForCall)
+}
+
+func (fake *FakeMirrorManager) DeleteMirrorCalls(stub func(context.Context, string) error) {
+	fake.deleteMirrorMutex.Lock()
+	defer fake.deleteMirrorMutex.Unlock()
+	fake.DeleteMirrorStub = stub
+}
+
+func (fake *FakeMirrorManager) DeleteMirrorArgsForCall(i int) (context.Context, string) {
+	fake.deleteMirrorMutex.RLock()
+	defer fake.deleteMirrorMutex.RUnlock()
+	argsForCall := fake.deleteMirrorArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeMirrorManager) DeleteMirrorReturns(result1 error) {
+	fake.deleteMirrorMutex.Lock()
+	defer fake.deleteMirrorMutex.Unlock()
+	fake.DeleteMirrorStub = nil
+	fake.deleteMirrorReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMirrorManager) DeleteMirrorReturnsOnCall(i int, result1 error) {
+	fake.deleteMirrorMutex.Lock()
+	defer fake.deleteMirrorMutex.Unlock()
+	fake.DeleteMirrorStub = nil
+	if fake.deleteMirrorReturnsOnCall == nil {
+		fake.deleteMirrorReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteMirrorReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMirrorManager) DescribeMirror(arg1 context.Context, arg2 string) (*clusterlink.Mirror, error) {
+	fake.describeMirrorMutex.Lock()
+	ret, specificReturn := fake.describeMirrorReturnsOnCall[len(fake.describeMirrorArgsForCall)]
+	fake.describeMirrorArgsForCall = append(fake.describeMirrorArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DescribeMirrorStub
+	fakeReturns := fake.describeMirrorReturns
+	fake.recordInvocation("DescribeMirror", []interface{}{arg1, arg2})
+	fake.describeMirrorMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMirrorManager) DescribeMirrorCallCount() int {
+	fake.describeMirrorMutex.RLock()
+	defer fake.describeMirrorMutex.RUnlock()
+	return len(fake.describeMirrorArgsForCall)
+}
+
+func (fake *FakeMirrorManager) DescribeMirrorCalls(stub func(context.Context, string) (*clusterlink.Mirror, error)) {
+	fake.describeMirrorMutex.Lock()
+	defer fake.describeMirrorMutex.Unlock()
+	fake.DescribeMirrorStub = stub
+}
+
+func (fake *FakeMirrorManager) DescribeMirrorArgsForCall(i int) (context.Context, string) {
+	fake.describeMirrorMutex.RLock()
+	defer fake.describeMirrorMutex.RUnlock()
+	argsForCall := fake.describeMirrorArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeMirrorManager) DescribeMirrorReturns(result1 *clusterlink.Mirror, result2 error) {
+	fake.describeMirrorMutex.Lock()
+	defer fake.describeMirrorMutex.Unlock()
+	fake.DescribeMirrorStub = nil
+	fake.describeMirrorReturns = struct {
+		result1 *clusterlink.Mirror
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMirrorManager) DescribeMirrorReturnsOnCall(i int, result1 *clusterlink.Mirror, result2 error) {
+	fake.describeMirrorMutex.Lock()
+	defer fake.describeMirrorMutex.Unlock()
+	fake.DescribeMirrorStub = nil
+	if fake.describeMirrorReturnsOnCall == nil {
+		fake.describeMirrorReturnsOnCall = make(map[int]struct {
+			result1 *clusterlink.Mirror
+			result2 error
+		})
+	}
+	fake.describeMirrorReturnsOnCall[i] = struct {
+		result1 *clusterlink.Mirror
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMirrorManager) PromoteMirror(arg1 context.Context, arg2 string) error {
+	fake.promoteMirrorMutex.Lock()
+	ret, specificReturn := fake.promoteMirrorReturnsOnCall[len(fake.promoteMirrorArgsForCall)]
+	fake.promoteMirrorArgsForCall = append(fake.promoteMirrorArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.PromoteMirrorStub
+	fakeReturns := fake.promoteMirrorReturns
+	fake.recordInvocation("PromoteMirror", []interface{}{arg1, arg2})
+	fake.promoteMirrorMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMirrorManager) PromoteMirrorCallCount() int {
+	fake.promoteMirrorMutex.RLock()
+	defer fake.promoteMirrorMutex.RUnlock()
+	return len(fake.promoteMirrorArgsForCall)
+}
+
+func (fake *FakeMirrorManager) PromoteMirrorCalls(stub func(context.Context, string) error) {
+	fake.promoteMirrorMutex.Lock()
+	defer fake.promoteMirrorMutex.Unlock()
+	fake.PromoteMirrorStub = stub
+}
+
+func (fake *FakeMirrorManager) PromoteMirrorArgsForCall(i int) (context.Context, string) {
+	fake.promoteMirrorMutex.RLock()
+	defer fake.promoteMirrorMutex.RUnlock()
+	argsForCall := fake.promoteMirrorArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeMirrorManager) PromoteMirrorReturns(result1 error) {
+	fake.promoteMirrorMutex.Lock()
+	defer fake.promoteMirrorMutex.Unlock()
+	fake.PromoteMirrorStub = nil
+	fake.promoteMirrorReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMirrorManager) PromoteMirrorReturnsOnCall(i int, result1 error) {
+	fake.promoteMirrorMutex.Lock()
+	defer fake.promoteMirrorMutex.Unlock()
+	fake.PromoteMirrorStub = nil
+	if fake.promoteMirrorReturnsOnCall == nil {
+		fake.promoteMirrorReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.promoteMirrorReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMirrorManager) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeMirrorManager) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ handler.MirrorManager = new(FakeMirrorManager)
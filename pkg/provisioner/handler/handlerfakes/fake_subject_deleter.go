@@ -0,0 +1,114 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package handlerfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/handler"
+)
+
+type FakeSubjectDeleter struct {
+	DeleteSubjectStub        func(context.Context, string, bool) error
+	deleteSubjectMutex       sync.RWMutex
+	deleteSubjectArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 bool
+	}
+	deleteSubjectReturns struct {
+		result1 error
+	}
+	deleteSubjectReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSubjectDeleter) DeleteSubject(arg1 context.Context, arg2 string, arg3 bool) error {
+	fake.deleteSubjectMutex.Lock()
+	ret, specificReturn := fake.deleteSubjectReturnsOnCall[len(fake.deleteSubjectArgsForCall)]
+	fake.deleteSubjectArgsForCall = append(fake.deleteSubjectArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 bool
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteSubjectStub
+	fakeReturns := fake.deleteSubjectReturns
+	fake.recordInvocation("DeleteSubject", []interface{}{arg1, arg2, arg3})
+	fake.deleteSubjectMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSubjectDeleter) DeleteSubjectCallCount() int {
+	fake.deleteSubjectMutex.RLock()
+	defer fake.deleteSubjectMutex.RUnlock()
+	return len(fake.deleteSubjectArgsForCall)
+}
+
+func (fake *FakeSubjectDeleter) DeleteSubjectCalls(stub func(context.Context, string, bool) error) {
+	fake.deleteSubjectMutex.Lock()
+	defer fake.deleteSubjectMutex.Unlock()
+	fake.DeleteSubjectStub = stub
+}
+
+func (fake *FakeSubjectDeleter) DeleteSubjectArgsForCall(i int) (context.Context, string, bool) {
+	fake.deleteSubjectMutex.RLock()
+	defer fake.deleteSubjectMutex.RUnlock()
+	argsForCall := fake.deleteSubjectArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeSubjectDeleter) DeleteSubjectReturns(result1 error) {
+	fake.deleteSubjectMutex.Lock()
+	defer fake.deleteSubjectMutex.Unlock()
+	fake.DeleteSubjectStub = nil
+	fake.deleteSubjectReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSubjectDeleter) DeleteSubjectReturnsOnCall(i int, result1 error) {
+	fake.deleteSubjectMutex.Lock()
+	defer fake.deleteSubjectMutex.Unlock()
+	fake.DeleteSubjectStub = nil
+	if fake.deleteSubjectReturnsOnCall == nil {
+		fake.deleteSubjectReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteSubjectReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSubjectDeleter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeSubjectDeleter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ handler.SubjectDeleter = new(FakeSubjectDeleter)
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/events"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// JournalPath lists the last provisioning decision about each topic, as recorded in the journal topic
+const JournalPath = "/journal"
+
+// decisionActions maps the events of streams to the actions of the decisions journaled
+var decisionActions = map[string]string{
+	events.StreamProvisioned: client.DecisionProvisioned,
+	events.StreamAltered:     client.DecisionAltered,
+	events.StreamDeleted:     client.DecisionDeleted,
+}
+
+type journalEntry struct {
+	Topic string `json:"topic"`
+	client.Decision
+}
+
+// journalResult is a page of the journal, Continue telling where the next page starts, if any
+type journalResult struct {
+	Topics   []journalEntry `json:"topics"`
+	Continue string         `json:"continue,omitempty"`
+}
+
+// journal records a provisioning decision about a topic in the journal topic, when journaling. The decision being
+// carried out already, failing to record it is only logged.
+func (rh *TopicCreationRequestHandler) journal(eventType, topicName string, spec *client.TopicSpec, archived bool) {
+	if !rh.Journal {
+		return
+	}
+	decision := client.Decision{Action: decisionActions[eventType], At: time.Now().UTC(), Spec: spec, Archived: archived}
+	if err := rh.KafkaClient.RecordDecision(topicName, decision); err != nil {
+		rh.Logger.Warn("Error journaling provisioning decision", "topic", topicName, "action", decision.Action, "error", err)
+	}
+}
+
+// JournalRecovery is what replaying the journal found
+type JournalRecovery struct {
+	// Owned is the number of topics the provisioner owns, provisioned and not deleted since
+	Owned int
+	// Disappeared are the topics owned that no longer exist, sorted
+	Disappeared []string
+}
+
+// RecoverJournal replays the journal to rebuild which topics the provisioner owns, and reconciles them against the
+// topics of the cluster. Topics owned that were deleted behind its back, e.g. while it was down, are reported, and
+// journaled as disappeared so that they are reported once.
+func (rh *TopicCreationRequestHandler) RecoverJournal() (*JournalRecovery, error) {
+	decisions, err := rh.KafkaClient.ListDecisions()
+	if err != nil {
+		rh.Logger.Error("Error reading the journal to recover", "error", err)
+		return nil, err
+	}
+	topics, err := rh.KafkaClient.ListTopics()
+	if err != nil {
+		rh.Logger.Error("Error listing topics to recover the journal", "error", err)
+		return nil, err
+	}
+	recovery := &JournalRecovery{}
+	for topicName, decision := range decisions {
+		if !decision.Owns() {
+			continue
+		}
+		if _, ok := topics[topicName]; ok {
+			recovery.Owned++
+			continue
+		}
+		recovery.Disappeared = append(recovery.Disappeared, topicName)
+	}
+	sort.Strings(recovery.Disappeared)
+	for _, topicName := range recovery.Disappeared {
+		rh.Logger.Warn("Topic disappeared", "topic", topicName, "provisioned", decisions[topicName].At)
+		if rh.ReadOnly {
+			// standbys leave the journal to the provisioner of the cluster it is replicated from
+			continue
+		}
+		disappeared := client.Decision{Action: client.DecisionDisappeared, At: time.Now().UTC(), Spec: decisions[topicName].Spec}
+		if err := rh.KafkaClient.RecordDecision(topicName, disappeared); err != nil {
+			// the topic is reported again on the next recovery
+			rh.Logger.Warn("Error journaling disappeared topic", "topic", topicName, "error", err)
+		}
+	}
+	rh.Logger.Info("Recovered the journal", "owned", recovery.Owned, "disappeared", len(recovery.Disappeared))
+	return recovery, nil
+}
+
+// decisionField looks the fields of the decision about a topic up for field selectors: its topic, namespace, action
+// and archived
+func decisionField(topicName string, decision client.Decision) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		switch name {
+		case "topic":
+			return topicName, true
+		case "namespace":
+			namespace, _, ok := validation.ParseTopicName(topicName)
+			return namespace, ok
+		case "action":
+			return decision.Action, true
+		case "archived":
+			return strconv.FormatBool(decision.Archived), true
+		}
+		return "", false
+	}
+}
+
+// listJournal lists the last provisioning decision about each topic, sorted by topic, by pages as the catalog does.
+// The fieldSelector parameter restricts the topics listed to those whose decision it selects.
+func (rh *TopicCreationRequestHandler) listJournal(responseWriter http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		rh.writeErrorf(responseWriter, http.StatusMethodNotAllowed, "", "Method %s is not allowed", request.Method)
+		return
+	}
+	if !rh.Journal {
+		rh.writeErrorf(responseWriter, http.StatusNotFound, CodeNotConfigured, "Provisioning decisions are not journaled by this provisioner")
+		return
+	}
+	if rh.Authorizer != nil && !rh.authorize(responseWriter, request, "", "list") {
+		return
+	}
+	pagination, err := parsePagination(request)
+	if err != nil {
+		rh.writeError(responseWriter, err)
+		return
+	}
+	fields, err := parseFieldSelector(request.URL.Query().Get("fieldSelector"))
+	if err != nil {
+		rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid value for parameter \"fieldSelector\": %v", err)
+		return
+	}
+	decisions, err := rh.KafkaClient.ListDecisions()
+	if err != nil {
+		rh.Logger.Error("Error reading the journal", "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error reading the journal: %v", err))
+		return
+	}
+	topicNames := make([]string, 0, len(decisions))
+	for topicName, decision := range decisions {
+		if pagination.includes(topicName) && fields.matches(decisionField(topicName, decision)) {
+			topicNames = append(topicNames, topicName)
+		}
+	}
+	sort.Strings(topicNames)
+	result := journalResult{}
+	topicNames, result.Continue = pagination.page(topicNames)
+	result.Topics = make([]journalEntry, 0, len(topicNames))
+	for _, topicName := range topicNames {
+		result.Topics = append(result.Topics, journalEntry{Topic: topicName, Decision: decisions[topicName]})
+	}
+	rh.writeDocument(responseWriter, request, http.StatusOK, result)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// LeadersSegment is the last segment of the path electing the preferred leaders of the partitions of a stream, e.g.
+// /my-ns/foo/leaders
+const LeadersSegment = "leaders"
+
+type leadersResult struct {
+	Topic      string                  `json:"topic"`
+	Partitions []client.LeaderElection `json:"partitions"`
+}
+
+// electLeaders elects the preferred replicas of the partitions of the topic of a stream as their leaders on POST, so
+// that leadership moved away from brokers during their maintenance is given back to them
+func (rh *TopicCreationRequestHandler) electLeaders(responseWriter http.ResponseWriter, request *http.Request, namespace, stream string) {
+	if request.Method != http.MethodPost {
+		rh.writeErrorf(responseWriter, http.StatusMethodNotAllowed, "", "Method %s is not allowed", request.Method)
+		return
+	}
+	if rh.Authorizer != nil && !rh.authorize(responseWriter, request, namespace, "update") {
+		return
+	}
+	topicName, err := validation.KafkaNaming{}.Name(namespace, stream)
+	if err != nil {
+		rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidName, "Invalid stream: %v", err)
+		return
+	}
+	topicExists, kafkaError := rh.KafkaClient.TopicExists(topicName)
+	if kafkaError != nil {
+		rh.Logger.Error("Error trying to list topics to see if topic exists", "topic", topicName, "error", kafkaError)
+		rh.writeError(responseWriter, rh.kafkaFailure(kafkaError, "Error trying to list topics to see if %q exists: %v", topicName, kafkaError))
+		return
+	}
+	if !topicExists {
+		rh.writeErrorf(responseWriter, http.StatusNotFound, "", "Topic %q does not exist", topicName)
+		return
+	}
+	elections, err := rh.KafkaClient.ElectPreferredLeaders(topicName)
+	if err != nil {
+		rh.Logger.Error("Error electing preferred leaders", "topic", topicName, "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error electing the preferred leaders of topic %q: %v", topicName, err))
+		return
+	}
+	statusCode := http.StatusOK
+	for _, election := range elections {
+		// preferred replicas out of sync may catch up, the election succeeding when retried
+		if election.Outcome == client.LeaderElectionFailed {
+			statusCode = http.StatusServiceUnavailable
+			rh.Logger.Warn("Error electing the preferred leader of partition", "topic", topicName, "partition", election.Partition, "error", election.Error)
+		}
+	}
+	rh.Logger.Info("Elected preferred leaders", "topic", topicName)
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(statusCode)
+	if err := json.NewEncoder(responseWriter).Encode(leadersResult{Topic: topicName, Partitions: elections}); err != nil {
+		rh.Logger.Error("Failed to write json response", "error", err)
+	}
+}
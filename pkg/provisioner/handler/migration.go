@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/events"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/migration"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Migrator
+
+// Migrator copies the records of renamed streams to the topic of their new name
+type Migrator interface {
+	// Start copies the records of a topic to another, unless already copying them
+	Start(source, target string)
+}
+
+// MigrationSegment is the last segment of the path of the migration of a stream, e.g. /my-ns/foo/migration
+const MigrationSegment = "migration"
+
+// Cutover states of migrations
+const (
+	// MigrationCopying tells that records of the old topic remain to be copied
+	MigrationCopying = "copying"
+	// MigrationReady tells that all the records of the old topic were copied, clients being free to move to the
+	// new stream
+	MigrationReady = "ready"
+)
+
+type migrationRequest struct {
+	// Stream is the new name of the stream, in the same namespace
+	Stream string `json:"stream"`
+}
+
+type migrationResult struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Gateways are the endpoints of the new stream
+	Gateways gatewaysResult `json:"gateways"`
+	Since    time.Time      `json:"since"`
+	State    string         `json:"state"`
+	// Lag is the number of records of the old topic that remain to be copied
+	Lag        int64                      `json:"lag"`
+	Partitions []client.PartitionProgress `json:"partitions"`
+}
+
+// migration starts the migration of a stream to a new name on PUT, and reports its cutover status on GET
+func (rh *TopicCreationRequestHandler) migration(responseWriter http.ResponseWriter, request *http.Request, namespace, stream string) {
+	if request.Method != http.MethodPut && request.Method != http.MethodGet {
+		rh.writeErrorf(responseWriter, http.StatusMethodNotAllowed, "", "Method %s is not allowed", request.Method)
+		return
+	}
+	if rh.Migrator == nil {
+		rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeNotConfigured, "Stream migrations are not configured for this provisioner")
+		return
+	}
+	topicName, err := validation.KafkaNaming{}.Name(namespace, stream)
+	if err != nil {
+		rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidName, "Invalid stream: %v", err)
+		return
+	}
+	var target string
+	if request.Method == http.MethodPut {
+		body, err := ioutil.ReadAll(http.MaxBytesReader(responseWriter, request.Body, maxMetadataBytes))
+		migrationRequest := migrationRequest{}
+		if err == nil {
+			err = json.Unmarshal(body, &migrationRequest)
+		}
+		if err == nil && migrationRequest.Stream == "" {
+			err = fmt.Errorf("the new name of the stream is required, e.g. {\"stream\": \"bar\"}")
+		}
+		if err == nil {
+			target, err = validation.KafkaNaming{}.Name(namespace, migrationRequest.Stream)
+		}
+		if err == nil && target == topicName {
+			err = fmt.Errorf("the stream is already named %q", stream)
+		}
+		if err != nil {
+			rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidBody, "Invalid migration: %v", err)
+			return
+		}
+	}
+
+	topicExists, kafkaError := rh.KafkaClient.TopicExists(topicName)
+	if kafkaError != nil {
+		rh.Logger.Error("Error trying to list topics to see if topic exists", "topic", topicName, "error", kafkaError)
+		rh.writeError(responseWriter, rh.kafkaFailure(kafkaError, "Error trying to list topics to see if %q exists: %v", topicName, kafkaError))
+		return
+	}
+	if !topicExists {
+		rh.writeErrorf(responseWriter, http.StatusNotFound, "", "Topic %q does not exist", topicName)
+		return
+	}
+	metadata, err := rh.KafkaClient.ReadMetadata(topicName)
+	if err != nil {
+		rh.Logger.Error("Error reading stream metadata", "topic", topicName, "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error reading the metadata of topic %q: %v", topicName, err))
+		return
+	}
+	if request.Method == http.MethodGet {
+		if metadata == nil || metadata.Migration == nil {
+			rh.writeErrorf(responseWriter, http.StatusNotFound, "", "Stream %q is not being migrated", stream)
+			return
+		}
+		rh.writeMigration(responseWriter, http.StatusOK, topicName, metadata.Migration)
+		return
+	}
+
+	switch {
+	case metadata != nil && metadata.Archived != nil:
+		rh.writeErrorf(responseWriter, http.StatusConflict, "", "Stream %q was deleted, its topic %q being archived", stream, topicName)
+		return
+	case metadata != nil && metadata.Migration != nil && metadata.Migration.To != target:
+		rh.writeErrorf(responseWriter, http.StatusConflict, "", "Stream %q is already being migrated to topic %q", stream, metadata.Migration.To)
+		return
+	case metadata == nil || metadata.Migration == nil:
+		targetExists, kafkaError := rh.KafkaClient.TopicExists(target)
+		if kafkaError != nil {
+			rh.Logger.Error("Error trying to list topics to see if topic exists", "topic", target, "error", kafkaError)
+			rh.writeError(responseWriter, rh.kafkaFailure(kafkaError, "Error trying to list topics to see if %q exists: %v", target, kafkaError))
+			return
+		}
+		if targetExists {
+			rh.writeErrorf(responseWriter, http.StatusConflict, "", "Topic %q already exists", target)
+			return
+		}
+		// the migration is recorded before its topic is created, so that requests failing after are retried as
+		// requests for the same migration
+		migrated := client.StreamMetadata{}
+		if metadata != nil {
+			migrated = *metadata
+		}
+		migrated.Migration = &client.Migration{To: target, Since: time.Now().UTC()}
+		if err := rh.KafkaClient.WriteMetadata(topicName, migrated); err != nil {
+			rh.Logger.Error("Error recording stream migration", "topic", topicName, "error", err)
+			rh.writeError(responseWriter, rh.kafkaFailure(err, "Error recording the migration of topic %q: %v", topicName, err))
+			return
+		}
+		metadata = &migrated
+	}
+	if !rh.provisionMigrated(responseWriter, namespace, topicName, target, metadata) {
+		return
+	}
+	rh.Migrator.Start(topicName, target)
+	rh.Logger.Info("Migrating topic", "topic", topicName, "target", target)
+	rh.writeMigration(responseWriter, http.StatusAccepted, topicName, metadata.Migration)
+}
+
+// provisionMigrated creates the topic a stream is migrated to, unless it exists, with the layout and config of the
+// topic of the stream so that records are copied to the same partition, and the metadata of the stream. It writes
+// an error response and returns false on failure.
+func (rh *TopicCreationRequestHandler) provisionMigrated(responseWriter http.ResponseWriter, namespace, topicName, target string, metadata *client.StreamMetadata) bool {
+	targetExists, kafkaError := rh.KafkaClient.TopicExists(target)
+	if kafkaError != nil {
+		rh.Logger.Error("Error trying to list topics to see if topic exists", "topic", target, "error", kafkaError)
+		rh.writeError(responseWriter, rh.kafkaFailure(kafkaError, "Error trying to list topics to see if %q exists: %v", target, kafkaError))
+		return false
+	}
+	if targetExists {
+		return true
+	}
+	topics, err := rh.KafkaClient.ListTopics()
+	if err != nil {
+		rh.Logger.Error("Error listing topics to migrate topic", "topic", topicName, "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error reading the spec of topic %q: %v", topicName, err))
+		return false
+	}
+	spec := topics[topicName]
+	warning, err := rh.checkCapacity(namespace, target, spec)
+	if err != nil {
+		rh.writeError(responseWriter, err)
+		return false
+	}
+	if warning != "" {
+		responseWriter.Header().Add("Warning", fmt.Sprintf("299 - %q", warning))
+	}
+	migrated := *metadata
+	migrated.Migration = nil
+	migrated.Spec = &spec
+	if err := rh.KafkaClient.WriteMetadata(target, migrated); err != nil {
+		rh.Logger.Error("Error recording stream metadata", "topic", target, "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error recording the metadata of topic %q: %v", target, err))
+		return false
+	}
+	if err := rh.KafkaClient.CreateTopic(target, spec); err != nil {
+		rh.Logger.Error("Error creating topic", "topic", target, "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error creating topic %q: %v", target, err))
+		return false
+	}
+	rh.Logger.Debug("Created topic", "topic", target, "partitions", spec.NumPartitions, "replicationFactor", spec.ReplicationFactor)
+	rh.publish(events.StreamProvisioned, target, &spec, false)
+	return true
+}
+
+// writeMigration reports how far the records of a migrated topic were copied
+func (rh *TopicCreationRequestHandler) writeMigration(responseWriter http.ResponseWriter, statusCode int, topicName string, m *client.Migration) {
+	progress, err := rh.KafkaClient.GroupProgress(migration.GroupID(topicName), topicName)
+	if err != nil {
+		rh.Logger.Error("Error reading the progress of migration", "topic", topicName, "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error reading the progress of the migration of topic %q: %v", topicName, err))
+		return
+	}
+	namespace, stream, _ := validation.ParseTopicName(m.To)
+	res := migrationResult{
+		From:       topicName,
+		To:         m.To,
+		Gateways:   rh.gateways(namespace, stream),
+		Since:      m.Since,
+		State:      MigrationReady,
+		Partitions: progress,
+	}
+	for _, p := range progress {
+		res.Lag += p.Lag()
+	}
+	if res.Lag > 0 {
+		res.State = MigrationCopying
+	}
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(statusCode)
+	if err := json.NewEncoder(responseWriter).Encode(res); err != nil {
+		rh.Logger.Error("Failed to write json response", "error", err)
+	}
+}
+
+// keepRecorded carries the spec and migration recorded for an existing stream over to the metadata replacing its
+// own, so that its topic keeps being reconciled and its records copied
+func (rh *TopicCreationRequestHandler) keepRecorded(topicName string, metadata *client.StreamMetadata) (*client.StreamMetadata, error) {
+	recorded, err := rh.KafkaClient.ReadMetadata(topicName)
+	if err != nil {
+		rh.Logger.Error("Error reading stream metadata", "topic", topicName, "error", err)
+		return nil, rh.kafkaFailure(err, "Error reading the metadata of topic %q: %v", topicName, err)
+	}
+	if recorded == nil {
+		return metadata, nil
+	}
+	kept := *metadata
+	kept.Spec = recorded.Spec
+	if recorded.Archived == nil {
+		kept.Migration = recorded.Migration
+	}
+	return &kept, nil
+}
+
+// Migrations returns the migrations whose records are being copied, by the topic they copy, leaving out those of
+// the streams deleted since
+func Migrations(recorded map[string]client.StreamMetadata) map[string]string {
+	migrations := make(map[string]string)
+	for topicName, metadata := range recorded {
+		if metadata.Migration != nil && metadata.Archived == nil {
+			migrations[topicName] = metadata.Migration.To
+		}
+	}
+	return migrations
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/clusterlink"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . MirrorManager
+
+// MirrorManager manages the mirror topics of a cluster link, on which streams are consumed read-only on the linked
+// cluster
+type MirrorManager interface {
+	// CreateMirror mirrors a topic on the linked cluster
+	CreateMirror(ctx context.Context, topicName string) error
+	// DescribeMirror returns the mirror of a topic, nil when it isn't mirrored
+	DescribeMirror(ctx context.Context, topicName string) (*clusterlink.Mirror, error)
+	// PromoteMirror stops mirroring a topic, its mirror becoming writable
+	PromoteMirror(ctx context.Context, topicName string) error
+	// DeleteMirror deletes the mirror of a topic, unknown mirrors being left alone
+	DeleteMirror(ctx context.Context, topicName string) error
+}
+
+// MirrorSegment is the last segment of the path describing the mirror of a stream on the linked cluster on GET, and
+// promoting it on POST, e.g. /my-ns/foo/mirror
+const MirrorSegment = "mirror"
+
+type mirrorResult struct {
+	Link   string `json:"link"`
+	Topic  string `json:"topic"`
+	Status string `json:"status,omitempty"`
+}
+
+func describedMirror(mirror *clusterlink.Mirror) *mirrorResult {
+	if mirror == nil {
+		return nil
+	}
+	return &mirrorResult{Link: mirror.Link, Topic: mirror.Topic, Status: mirror.Status}
+}
+
+// linkFailure reports an error of the cluster link with a 502 status, the linked cluster failing rather than the
+// provisioner
+func (rh *TopicCreationRequestHandler) linkFailure(err error, format string, args ...interface{}) *StatusError {
+	rh.Logger.Error("Error managing mirror topic", "error", err)
+	return &StatusError{Status: http.StatusBadGateway, Message: fmt.Sprintf(format, args...)}
+}
+
+// createMirror mirrors the topic of a stream on the linked cluster, unless it is mirrored already
+func (rh *TopicCreationRequestHandler) createMirror(ctx context.Context, topicName string) (*clusterlink.Mirror, error) {
+	mirror, err := rh.Mirrors.DescribeMirror(ctx, topicName)
+	if err != nil {
+		return nil, rh.linkFailure(err, "Error describing the mirror of topic %q: %v", topicName, err)
+	}
+	if mirror != nil {
+		return mirror, nil
+	}
+	if err := rh.Mirrors.CreateMirror(ctx, topicName); err != nil {
+		return nil, rh.linkFailure(err, "Error mirroring topic %q: %v", topicName, err)
+	}
+	rh.Logger.Info("Mirrored topic on the linked cluster", "topic", topicName)
+	if mirror, err = rh.Mirrors.DescribeMirror(ctx, topicName); err != nil {
+		return nil, rh.linkFailure(err, "Error describing the mirror of topic %q: %v", topicName, err)
+	}
+	return mirror, nil
+}
+
+// mirror describes the mirror of the topic of a stream on GET, and promotes it on POST, e.g. when failing over to the
+// linked cluster
+func (rh *TopicCreationRequestHandler) mirror(responseWriter http.ResponseWriter, request *http.Request, namespace, stream string) {
+	if request.Method != http.MethodGet && request.Method != http.MethodPost {
+		rh.writeErrorf(responseWriter, http.StatusMethodNotAllowed, "", "Method %s is not allowed", request.Method)
+		return
+	}
+	if rh.Mirrors == nil {
+		rh.writeErrorf(responseWriter, http.StatusNotFound, CodeNotConfigured, "Cluster linking is not configured for this provisioner")
+		return
+	}
+	verb := "get"
+	if request.Method == http.MethodPost {
+		verb = "update"
+	}
+	if rh.Authorizer != nil && !rh.authorize(responseWriter, request, namespace, verb) {
+		return
+	}
+	topicName, err := validation.KafkaNaming{}.Name(namespace, stream)
+	if err != nil {
+		rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidName, "Invalid stream: %v", err)
+		return
+	}
+	mirror, err := rh.Mirrors.DescribeMirror(request.Context(), topicName)
+	if err != nil {
+		rh.writeError(responseWriter, rh.linkFailure(err, "Error describing the mirror of topic %q: %v", topicName, err))
+		return
+	}
+	if mirror == nil {
+		rh.writeErrorf(responseWriter, http.StatusNotFound, "", "Topic %q is not mirrored", topicName)
+		return
+	}
+	if request.Method == http.MethodPost {
+		if err := rh.Mirrors.PromoteMirror(request.Context(), topicName); err != nil {
+			rh.writeError(responseWriter, rh.linkFailure(err, "Error promoting the mirror of topic %q: %v", topicName, err))
+			return
+		}
+		rh.Logger.Info("Promoted mirror topic", "topic", topicName)
+		promoted, err := rh.Mirrors.DescribeMirror(request.Context(), topicName)
+		if err != nil {
+			rh.writeError(responseWriter, rh.linkFailure(err, "Error describing the mirror of topic %q: %v", topicName, err))
+			return
+		}
+		if promoted != nil {
+			mirror = promoted
+		} else {
+			// the mirror, promoted, may already be a regular topic
+			mirror.Status = ""
+		}
+	}
+	rh.writeDocument(responseWriter, request, http.StatusOK, describedMirror(mirror))
+}
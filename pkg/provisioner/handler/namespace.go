@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/Shopify/sarama"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// NamespaceArchived tells that the stream of a namespace deprovisioned was archived, rather than deleted
+const NamespaceArchived = "archived"
+
+type namespaceResult struct {
+	Namespace string           `json:"namespace"`
+	Streams   []importedStream `json:"streams"`
+}
+
+// deprovisionNamespace archives or deletes the topics of all the streams of a namespace, so that tearing
+// down a tenant doesn't leave its topics behind. Streams already archived are left to their grace period. Topics are
+// deleted with a single request to the controller, reporting the result of each. Requests failing for some streams
+// are retried for the streams left.
+func (rh *TopicCreationRequestHandler) deprovisionNamespace(responseWriter http.ResponseWriter, request *http.Request, namespace string) {
+	if err := validation.ValidateNamespace(namespace); err != nil {
+		rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidName, "Invalid namespace: %v", err)
+		return
+	}
+	if rh.Authorizer != nil && !rh.authorize(responseWriter, request, namespace, "deletecollection") {
+		return
+	}
+	topics, err := rh.KafkaClient.ListTopics()
+	if err != nil {
+		rh.Logger.Error("Error listing topics to deprovision namespace", "namespace", namespace, "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error listing topics: %v", err))
+		return
+	}
+	recorded, err := rh.KafkaClient.ListMetadata()
+	if err != nil {
+		rh.Logger.Error("Error reading stream metadata to deprovision namespace", "namespace", namespace, "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error reading stream metadata: %v", err))
+		return
+	}
+	var names []string
+	for topicName := range topics {
+		if ns, _, ok := validation.ParseTopicName(topicName); ok && ns == namespace {
+			names = append(names, topicName)
+		}
+	}
+	sort.Strings(names)
+
+	statusCode := http.StatusOK
+	result := namespaceResult{Namespace: namespace, Streams: make([]importedStream, 0, len(names))}
+	failed := func(deprovisioned *importedStream, err error) {
+		deprovisioned.Status, deprovisioned.Error = ImportFailed, err.Error()
+		if statusCode == http.StatusOK {
+			if _, ok := err.(*subjectError); ok {
+				statusCode = http.StatusBadGateway
+			} else {
+				statusCode = rh.kafkaErrorStatus(responseWriter, err)
+			}
+		}
+	}
+	// the topics left to delete once their subjects are, by index in the result
+	deleting := make(map[string]int, len(names))
+	for _, topicName := range names {
+		var metadata *client.StreamMetadata
+		if m, ok := recorded[topicName]; ok {
+			metadata = &m
+		}
+		deprovisioned := importedStream{Topic: topicName, Status: ImportDeleted}
+		if rh.Archive.Enabled() {
+			deprovisioned.Status = NamespaceArchived
+			if _, err := rh.archiveStream(topicName, metadata); err != nil {
+				failed(&deprovisioned, err)
+			}
+		} else if err := rh.deleteSubjects(request.Context(), topicName, metadata); err != nil {
+			failed(&deprovisioned, err)
+		} else {
+			deleting[topicName] = len(result.Streams)
+		}
+		result.Streams = append(result.Streams, deprovisioned)
+	}
+	if len(deleting) > 0 {
+		rh.deleteTopics(deleting, recorded, &result, failed)
+	}
+	rh.Logger.Info("Deprovisioned namespace", "namespace", namespace, "streams", len(names))
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(statusCode)
+	if err := json.NewEncoder(responseWriter).Encode(result); err != nil {
+		rh.Logger.Error("Failed to write json response", "error", err)
+	}
+}
+
+// deleteTopics deletes the topics of a namespace at once, reporting the result of each topic in the result of the
+// namespace
+func (rh *TopicCreationRequestHandler) deleteTopics(deleting map[string]int, recorded map[string]client.StreamMetadata, result *namespaceResult, failed func(*importedStream, error)) {
+	topicNames := make([]string, 0, len(deleting))
+	for topicName := range deleting {
+		topicNames = append(topicNames, topicName)
+	}
+	sort.Strings(topicNames)
+	topicErrors, err := rh.KafkaClient.DeleteTopics(topicNames)
+	if err != nil {
+		rh.Logger.Error("Error deleting topics", "namespace", result.Namespace, "topics", len(topicNames), "error", err)
+	}
+	for _, topicName := range topicNames {
+		deprovisioned := &result.Streams[deleting[topicName]]
+		topicErr := err
+		if topicErr == nil {
+			topicErr = topicErrors[topicName]
+		}
+		// topics deleted in the meantime only leave their metadata to delete
+		if topicErr != nil && !errors.Is(topicErr, sarama.ErrUnknownTopicOrPartition) {
+			rh.Logger.Error("Error deleting topic", "topic", topicName, "error", topicErr)
+			failed(deprovisioned, topicErr)
+			continue
+		}
+		var metadata *client.StreamMetadata
+		if m, ok := recorded[topicName]; ok {
+			metadata = &m
+		}
+		rh.topicDeleted(topicName, metadata)
+	}
+}
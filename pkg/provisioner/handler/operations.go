@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/history"
+)
+
+// OperationsPath lists the recent provisioning operations
+const OperationsPath = "/operations"
+
+type operationsResult struct {
+	Operations []history.Operation `json:"operations"`
+}
+
+// operations lists the recent provisioning operations, the most recent first, at most as many as the limit
+// parameter asks for
+func (rh *TopicCreationRequestHandler) operations(responseWriter http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		rh.writeErrorf(responseWriter, http.StatusMethodNotAllowed, "", "Method %s is not allowed", request.Method)
+		return
+	}
+	if rh.Operations == nil {
+		rh.writeErrorf(responseWriter, http.StatusNotFound, CodeNotConfigured, "The history of operations is not kept by this provisioner")
+		return
+	}
+	if rh.Authorizer != nil && !rh.authorize(responseWriter, request, "", "list") {
+		return
+	}
+	limit := 0
+	if value := request.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid value for parameter \"limit\": should be a positive number, got %q", value)
+			return
+		}
+	}
+	rh.writeDocument(responseWriter, request, http.StatusOK, operationsResult{Operations: rh.Operations.Recent(limit)})
+}
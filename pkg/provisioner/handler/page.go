@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// defaultPageLimit and maxPageLimit bound the number of items of a page of the listings
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// pagination is the page of a listing sorted by topic a request asks for: at most limit items, those following the
+// topic of the continue parameter, which the previous page returned
+type pagination struct {
+	limit int
+	after string
+}
+
+// parsePagination reads the limit and continue parameters of a request listing topics, failing with a 400 status
+// when the limit isn't one
+func parsePagination(request *http.Request) (pagination, error) {
+	p := pagination{limit: defaultPageLimit, after: request.URL.Query().Get("continue")}
+	if value := request.URL.Query().Get("limit"); value != "" {
+		var err error
+		if p.limit, err = strconv.Atoi(value); err != nil || p.limit < 1 || p.limit > maxPageLimit {
+			return pagination{}, &StatusError{Status: http.StatusBadRequest, Code: CodeInvalidParameter, Message: fmt.Sprintf("Invalid value for parameter \"limit\": should be a number between 1 and %d", maxPageLimit)}
+		}
+	}
+	return p, nil
+}
+
+// includes tells whether a topic follows the previous page
+func (p pagination) includes(topicName string) bool {
+	return topicName > p.after
+}
+
+// page returns the page of sorted topics, those included, and where the next page starts, if any
+func (p pagination) page(topicNames []string) ([]string, string) {
+	if len(topicNames) <= p.limit {
+		return topicNames, ""
+	}
+	return topicNames[:p.limit], topicNames[p.limit-1]
+}
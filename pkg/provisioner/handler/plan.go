@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/events"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// PlanPath compares the streams of a document with those provisioned, without applying anything
+const PlanPath = StatePath + "/plan"
+
+// Actions importing a document takes on streams
+const (
+	PlanCreate = "create"
+	PlanUpdate = "update"
+	PlanDelete = "delete"
+	PlanNone   = "none"
+)
+
+type planResult struct {
+	Streams []streamPlan `json:"streams"`
+}
+
+// streamPlan is what importing a document would change about a stream
+type streamPlan struct {
+	Topic   string        `json:"topic"`
+	Action  string        `json:"action"`
+	Changes []fieldChange `json:"changes,omitempty"`
+	// Warnings tell the differences imports leave alone
+	Warnings []string `json:"warnings,omitempty"`
+
+	// spec is the spec of the topic to create, or whose config overrides to apply when alterConfig is set
+	spec        client.TopicSpec
+	alterConfig bool
+	// metadata is the metadata to record, nil when left alone
+	metadata *client.StreamMetadata
+	// current is the metadata recorded for the stream, deleted with it
+	current *client.StreamMetadata
+}
+
+// fieldChange is a field of a stream whose value would change, from nothing when created and to nothing when
+// removed
+type fieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from,omitempty"`
+	To    json.RawMessage `json:"to,omitempty"`
+}
+
+// plan returns what importing a document would change, without applying anything
+func (rh *TopicCreationRequestHandler) plan(responseWriter http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		rh.writeErrorf(responseWriter, http.StatusMethodNotAllowed, "", "Method %s is not allowed", request.Method)
+		return
+	}
+	if rh.Authorizer != nil && !rh.authorize(responseWriter, request, "", "list") {
+		return
+	}
+	prune, err := parseBoolParameter(request, "prune")
+	if err != nil {
+		rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid value for parameter \"prune\": %v", err)
+		return
+	}
+	plans, ok := rh.planState(responseWriter, request, prune)
+	if !ok {
+		return
+	}
+	responseWriter.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(responseWriter).Encode(planResult{Streams: plans}); err != nil {
+		rh.Logger.Error("Failed to write json response", "error", err)
+	}
+}
+
+// planState reads the document of a request and plans its import against the streams provisioned, deleting the
+// streams of the namespaces of the document that it doesn't define when pruning. It writes an error response and
+// returns false on failure.
+func (rh *TopicCreationRequestHandler) planState(responseWriter http.ResponseWriter, request *http.Request, prune bool) ([]streamPlan, bool) {
+	document, err := parseState(responseWriter, request)
+	if err != nil {
+		rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidBody, "Invalid state: %v", err)
+		return nil, false
+	}
+	topics, err := rh.KafkaClient.ListTopics()
+	if err != nil {
+		rh.Logger.Error("Error listing topics to plan an import", "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error listing topics: %v", err))
+		return nil, false
+	}
+	recorded, err := rh.KafkaClient.ListMetadata()
+	if err != nil {
+		rh.Logger.Error("Error reading stream metadata to plan an import", "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error reading stream metadata: %v", err))
+		return nil, false
+	}
+
+	invalid, err := rh.invalidConfigs(document)
+	if err != nil {
+		rh.Logger.Error("Error describing topic configs to plan an import", "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error describing topic configs: %v", err))
+		return nil, false
+	}
+	if len(invalid) > 0 {
+		rh.Logger.Info("Refusing to import streams with invalid topic configs", "streams", len(invalid))
+		rh.writeErrorf(responseWriter, http.StatusUnprocessableEntity, "", "Invalid state: %s", strings.Join(invalid, "; "))
+		return nil, false
+	}
+
+	plans := make([]streamPlan, 0, len(document.Streams))
+	defined := make(map[string]bool, len(document.Streams))
+	namespaces := make(map[string]bool)
+	for _, definition := range document.Streams {
+		topicName := validation.TopicName(definition.Namespace, definition.Stream)
+		plans = append(plans, planStream(topicName, definition, topics, recorded))
+		defined[topicName] = true
+		namespaces[definition.Namespace] = true
+	}
+	if !prune {
+		return plans, true
+	}
+	var pruned []string
+	for topicName := range topics {
+		namespace, _, ok := validation.ParseTopicName(topicName)
+		if !ok || !namespaces[namespace] || defined[topicName] {
+			continue
+		}
+		if metadata, ok := recorded[topicName]; ok && metadata.Archived != nil {
+			continue
+		}
+		pruned = append(pruned, topicName)
+	}
+	sort.Strings(pruned)
+	for _, topicName := range pruned {
+		plan := streamPlan{Topic: topicName, Action: PlanDelete}
+		if metadata, ok := recorded[topicName]; ok {
+			plan.current = &metadata
+		}
+		plans = append(plans, plan)
+	}
+	return plans, true
+}
+
+// invalidConfigs checks the configs of the streams of a document against those topics take on the cluster,
+// describing the streams whose configs are invalid
+func (rh *TopicCreationRequestHandler) invalidConfigs(document *stateDocument) ([]string, error) {
+	var keys client.TopicConfigKeys
+	var invalid []string
+	for _, definition := range document.Streams {
+		if len(definition.ConfigEntries) == 0 {
+			continue
+		}
+		if keys == nil {
+			var err error
+			if keys, err = rh.KafkaClient.TopicConfigKeys(); err != nil || keys == nil {
+				return nil, err
+			}
+		}
+		if err := validation.ValidateConfigs(keys, definition.ConfigEntries); err != nil {
+			invalid = append(invalid, fmt.Sprintf("stream %q of namespace %q: %v", definition.Stream, definition.Namespace, err))
+		}
+	}
+	return invalid, nil
+}
+
+// planStream compares the definition of a stream with the stream provisioned, if any. The layout of existing topics
+// is left alone, as the partitions of topics can't be removed and adding some would move keys to other partitions.
+func planStream(topicName string, definition streamDefinition, topics map[string]client.TopicSpec, recorded map[string]client.StreamMetadata) streamPlan {
+	plan := streamPlan{Topic: topicName, Action: PlanNone, spec: definition.TopicSpec}
+	spec, exists := topics[topicName]
+	if exists {
+		if definition.NumPartitions != client.BrokerDefault && spec.NumPartitions != definition.NumPartitions {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("the topic has %d partitions rather than %d, which imports leave alone", spec.NumPartitions, definition.NumPartitions))
+		}
+		if definition.ReplicationFactor != client.BrokerDefault && spec.ReplicationFactor != definition.ReplicationFactor {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("the topic has a replication factor of %d rather than %d, which imports leave alone", spec.ReplicationFactor, definition.ReplicationFactor))
+		}
+		plan.alterConfig = !sameConfig(spec.ConfigEntries, definition.ConfigEntries)
+	} else {
+		plan.Action = PlanCreate
+		plan.Changes = append(plan.Changes,
+			fieldChange{Field: "partitions", To: rawJSON(definition.NumPartitions)},
+			fieldChange{Field: "replicationFactor", To: rawJSON(definition.ReplicationFactor)},
+		)
+	}
+	plan.Changes = append(plan.Changes, changes("config.", configFields(spec.ConfigEntries), configFields(definition.ConfigEntries))...)
+
+	// definitions without metadata leave the metadata recorded alone, but for the spec of the topic
+	current, hasCurrent := recorded[topicName]
+	desired := current
+	if definition.StreamMetadata != nil {
+		desired = *definition.StreamMetadata
+	}
+	desired.Archived, desired.Migration = nil, nil
+	// streams being migrated keep being copied
+	if hasCurrent && current.Archived == nil {
+		desired.Migration = current.Migration
+	}
+	provisioned := definition.TopicSpec
+	if exists {
+		provisioned.NumPartitions, provisioned.ReplicationFactor = spec.NumPartitions, spec.ReplicationFactor
+	}
+	desired.Spec = &provisioned
+	if !hasCurrent || !sameMetadata(current, desired) {
+		plan.metadata = &desired
+		plan.Changes = append(plan.Changes, metadataChanges(current, desired)...)
+	}
+	if exists && len(plan.Changes) > 0 {
+		plan.Action = PlanUpdate
+	}
+	return plan
+}
+
+// applyPlan imports the definition of a stream as planned
+func (rh *TopicCreationRequestHandler) applyPlan(ctx context.Context, plan streamPlan) error {
+	switch plan.Action {
+	case PlanDelete:
+		if rh.Archive.Enabled() {
+			_, err := rh.archiveStream(plan.Topic, plan.current)
+			return err
+		}
+		return rh.deleteStream(ctx, plan.Topic, plan.current)
+	case PlanCreate:
+		if err := rh.KafkaClient.CreateTopic(plan.Topic, plan.spec); err != nil {
+			return err
+		}
+		rh.Logger.Info("Created imported topic", "topic", plan.Topic, "partitions", plan.spec.NumPartitions, "replicationFactor", plan.spec.ReplicationFactor)
+		rh.publish(events.StreamProvisioned, plan.Topic, &plan.spec, false)
+	}
+	if plan.alterConfig {
+		if err := rh.KafkaClient.AlterTopicConfig(plan.Topic, plan.spec.ConfigEntries); err != nil {
+			return err
+		}
+		rh.Logger.Info("Updated the config of imported topic", "topic", plan.Topic)
+		if plan.Action != PlanCreate {
+			rh.publish(events.StreamAltered, plan.Topic, &plan.spec, false)
+		}
+	}
+	if plan.metadata != nil {
+		return rh.KafkaClient.WriteMetadata(plan.Topic, *plan.metadata)
+	}
+	return nil
+}
+
+// metadataChanges compares metadata field by field, labels label by label, leaving the spec of the topic out as
+// its changes are those of its config
+func metadataChanges(from, to client.StreamMetadata) []fieldChange {
+	fromFields, toFields := jsonFields(from), jsonFields(to)
+	changed := changes("", without(fromFields, "labels", "spec"), without(toFields, "labels", "spec"))
+	return append(changed, changes("labels.", jsonFields(from.Labels), jsonFields(to.Labels))...)
+}
+
+// changes compares fields by name, sorted, prefixing their names
+func changes(prefix string, from, to map[string]json.RawMessage) []fieldChange {
+	names := make([]string, 0, len(from)+len(to))
+	for name := range from {
+		names = append(names, name)
+	}
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var changed []fieldChange
+	for _, name := range names {
+		if string(from[name]) != string(to[name]) {
+			changed = append(changed, fieldChange{Field: prefix + name, From: from[name], To: to[name]})
+		}
+	}
+	return changed
+}
+
+// jsonFields returns the fields of the JSON encoding of v by name
+func jsonFields(v interface{}) map[string]json.RawMessage {
+	fields := make(map[string]json.RawMessage)
+	if encoded, err := json.Marshal(v); err == nil {
+		_ = json.Unmarshal(encoded, &fields)
+	}
+	return fields
+}
+
+func configFields(configEntries map[string]*string) map[string]json.RawMessage {
+	fields := make(map[string]json.RawMessage, len(configEntries))
+	for name, value := range configEntries {
+		fields[name] = rawJSON(value)
+	}
+	return fields
+}
+
+func without(fields map[string]json.RawMessage, names ...string) map[string]json.RawMessage {
+	for _, name := range names {
+		delete(fields, name)
+	}
+	return fields
+}
+
+func rawJSON(v interface{}) json.RawMessage {
+	encoded, _ := json.Marshal(v)
+	return encoded
+}
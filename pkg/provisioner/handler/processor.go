@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/events"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/policy"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/quota"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// ProvisionStreams provisions the streams a processor of a namespace reads, writes and retries from all at once:
+// either the topics of all of them exist afterwards, or those it created are deleted again, so that processors are
+// never left half-wired. The specs of the topics are chosen as for PUT requests, and checked against the quota of the
+// namespace and the cluster partition budget as a whole before any is created.
+func (rh *TopicCreationRequestHandler) ProvisionStreams(namespace string, streams []string) error {
+	topics, err := rh.KafkaClient.ListTopics()
+	if err != nil {
+		return err
+	}
+	usage := quota.NamespaceUsage(topics, namespace)
+	total := quota.ClusterPartitions(topics)
+	var missing []string
+	specs := map[string]client.TopicSpec{}
+	for _, stream := range streams {
+		topicName, err := validation.KafkaNaming{}.Name(namespace, stream)
+		if err != nil {
+			return fmt.Errorf("invalid stream %q: %v", stream, err)
+		}
+		if _, ok := topics[topicName]; ok {
+			continue
+		}
+		if _, ok := specs[topicName]; ok {
+			continue
+		}
+		spec, err := rh.processorStreamSpec(namespace, stream, topicName)
+		if err != nil {
+			return err
+		}
+		partitions := int(spec.NumPartitions)
+		// the partitions the broker defaults to are only known once the topic is created
+		if spec.NumPartitions == client.BrokerDefault {
+			partitions = 1
+		}
+		if err := rh.Quota.Check(usage, partitions); err != nil {
+			return fmt.Errorf("refusing to create topic %q for namespace %q: %v", topicName, namespace, err)
+		}
+		if _, err := rh.PartitionBudget.Check(total, partitions); err != nil {
+			return fmt.Errorf("refusing to create topic %q: %v", topicName, err)
+		}
+		usage.Topics, usage.Partitions, total = usage.Topics+1, usage.Partitions+partitions, total+partitions
+		missing = append(missing, topicName)
+		specs[topicName] = spec
+	}
+
+	var created []string
+	for _, topicName := range missing {
+		spec := specs[topicName]
+		err := rh.KafkaClient.CreateTopic(topicName, spec)
+		if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+			// another replica provisioned the stream in the meantime, the topic isn't this one's to roll back
+			rh.Logger.Debug("Processor topic was created concurrently", "topic", topicName)
+			continue
+		}
+		if err == nil {
+			created = append(created, topicName)
+			err = rh.KafkaClient.WriteMetadata(topicName, client.StreamMetadata{Spec: &spec})
+		}
+		if err != nil {
+			rh.Logger.Error("Error provisioning the streams of processor, rolling back", "topic", topicName, "error", err)
+			rh.rollBack(created)
+			return err
+		}
+		rh.Logger.Info("Created processor topic", "topic", topicName, "partitions", spec.NumPartitions, "replicationFactor", spec.ReplicationFactor)
+	}
+	for _, topicName := range created {
+		spec := specs[topicName]
+		rh.publish(events.StreamProvisioned, topicName, &spec, false)
+	}
+	return nil
+}
+
+// processorStreamSpec chooses the spec of the topic of a stream of a processor as PUT requests do
+func (rh *TopicCreationRequestHandler) processorStreamSpec(namespace, stream, topicName string) (client.TopicSpec, error) {
+	spec := client.DefaultTopicSpec()
+	if rh.BrokerDefaults {
+		spec.NumPartitions, spec.ReplicationFactor = client.BrokerDefault, client.BrokerDefault
+	}
+	if rh.Policy != nil {
+		decision, err := rh.Policy.Evaluate(policy.Input{Namespace: namespace, Stream: stream, Topic: topicName, Spec: spec})
+		if err != nil {
+			return spec, fmt.Errorf("error evaluating the provisioning policy for topic %q: %v", topicName, err)
+		}
+		if !decision.Allow {
+			return spec, fmt.Errorf("provisioning policy denied topic %q: %s", topicName, decision.Reason)
+		}
+		if decision.Spec != nil {
+			spec = *decision.Spec
+		}
+	}
+	if err := rh.Rules.ValidateSpec(spec); err != nil {
+		return spec, fmt.Errorf("refusing to create topic %q: %v", topicName, err)
+	}
+	if len(spec.ConfigEntries) > 0 {
+		keys, err := rh.KafkaClient.TopicConfigKeys()
+		if err != nil {
+			return spec, err
+		}
+		if err := validation.ValidateConfigs(keys, spec.ConfigEntries); err != nil {
+			return spec, fmt.Errorf("refusing to provision topic %q: %v", topicName, err)
+		}
+	}
+	if rh.MaxPayloadBytes > 0 {
+		spec = withMaxMessageBytes(spec, rh.MaxPayloadBytes)
+	}
+	return spec, nil
+}
+
+// rollBack deletes the topics created for a processor, and their metadata, failures leaving topics behind for the
+// next attempt to provision the processor to reuse
+func (rh *TopicCreationRequestHandler) rollBack(created []string) {
+	for _, topicName := range created {
+		if err := rh.KafkaClient.DeleteTopic(topicName); err != nil {
+			rh.Logger.Error("Error rolling back processor topic", "topic", topicName, "error", err)
+			continue
+		}
+		if err := rh.KafkaClient.DeleteMetadata(topicName); err != nil {
+			rh.Logger.Warn("Error deleting the metadata of rolled back processor topic", "topic", topicName, "error", err)
+		}
+		rh.Logger.Info("Rolled back processor topic", "topic", topicName)
+	}
+}
+
+// transactionalIDPrincipal is what the principals of processors are rendered with
+type transactionalIDPrincipal struct {
+	Namespace string
+	Processor string
+}
+
+// ParseTransactionalIDPrincipal parses the template of the Kafka principal of each processor, checking that it renders
+func ParseTransactionalIDPrincipal(text string) (*template.Template, error) {
+	tmpl, err := template.New("principal").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(io.Discard, transactionalIDPrincipal{Namespace: "my-ns", Processor: "my-processor"}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// ReserveTransactionalIDs reserves a prefix of transactional ids for a processor of a namespace, granting its
+// principal the transactional ids starting with it, so that exactly-once processors never fence off each other's
+// transactions. The prefix is returned, granting it again being harmless.
+func (rh *TopicCreationRequestHandler) ReserveTransactionalIDs(namespace, processor string) (string, error) {
+	if rh.TransactionalIDPrincipal == nil {
+		return "", fmt.Errorf("no principal is configured for the transactional ids of processors")
+	}
+	prefix := validation.TransactionalIDPrefix(namespace, processor)
+	principal := &strings.Builder{}
+	if err := rh.TransactionalIDPrincipal.Execute(principal, transactionalIDPrincipal{Namespace: namespace, Processor: processor}); err != nil {
+		return "", fmt.Errorf("error rendering the principal of processor %q: %v", processor, err)
+	}
+	if err := rh.KafkaClient.GrantTransactionalIDs(prefix, principal.String()); err != nil {
+		return "", fmt.Errorf("error granting transactional ids %q* to %q: %w", prefix, principal.String(), err)
+	}
+	rh.Logger.Debug("Granted the transactional ids of processor", "namespace", namespace, "processor", processor, "prefix", prefix, "principal", principal.String())
+	return prefix, nil
+}
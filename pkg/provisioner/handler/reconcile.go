@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/events"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// ReconcilePath reports the topics whose live config drifted from the spec they were provisioned with, and reverts
+// the drift on POST
+const ReconcilePath = "/reconcile"
+
+type reconcileResult struct {
+	Streams []streamDrift `json:"streams"`
+}
+
+// streamDrift is how the topic of a stream drifted from its spec, its changes going from the provisioned value to
+// the live one
+type streamDrift struct {
+	Topic   string        `json:"topic"`
+	Changes []fieldChange `json:"changes"`
+	// Repaired tells whether the config overrides provisioned were applied again, layouts being left alone
+	Repaired bool   `json:"repaired"`
+	Error    string `json:"error,omitempty"`
+
+	err error
+}
+
+// reconcile reports the drift of the topics of all namespaces on GET, and reverts it on POST
+func (rh *TopicCreationRequestHandler) reconcile(responseWriter http.ResponseWriter, request *http.Request) {
+	var repair bool
+	switch request.Method {
+	case http.MethodGet:
+		if rh.Authorizer != nil && !rh.authorize(responseWriter, request, "", "list") {
+			return
+		}
+	case http.MethodPost:
+		if rh.Authorizer != nil && !rh.authorize(responseWriter, request, "", "update") {
+			return
+		}
+		repair = true
+	default:
+		rh.writeErrorf(responseWriter, http.StatusMethodNotAllowed, "", "Method %s is not allowed", request.Method)
+		return
+	}
+	drifts, err := rh.drift(repair)
+	if err != nil {
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error reconciling topics: %v", err))
+		return
+	}
+	statusCode := http.StatusOK
+	for _, drift := range drifts {
+		if drift.err != nil {
+			statusCode = rh.kafkaErrorStatus(responseWriter, drift.err)
+			break
+		}
+	}
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(statusCode)
+	if err := json.NewEncoder(responseWriter).Encode(reconcileResult{Streams: drifts}); err != nil {
+		rh.Logger.Error("Failed to write json response", "error", err)
+	}
+}
+
+// Reconcile looks for the topics whose live config drifted from the spec they were provisioned with, reverting the
+// drift when repairing, and returns the number of topics that drifted
+func (rh *TopicCreationRequestHandler) Reconcile(repair bool) (int, error) {
+	drifts, err := rh.drift(repair)
+	return len(drifts), err
+}
+
+// drift compares the live config of the topics of streams with the spec recorded when they were provisioned, sorted
+// by topic. Streams provisioned before specs were recorded, and archived streams, whose retention was shortened on
+// purpose, are left out.
+func (rh *TopicCreationRequestHandler) drift(repair bool) ([]streamDrift, error) {
+	topics, err := rh.KafkaClient.ListTopics()
+	if err != nil {
+		rh.Logger.Error("Error listing topics to reconcile", "error", err)
+		return nil, err
+	}
+	recorded, err := rh.KafkaClient.ListMetadata()
+	if err != nil {
+		rh.Logger.Error("Error reading stream metadata to reconcile", "error", err)
+		return nil, err
+	}
+	names := make([]string, 0, len(topics))
+	for name := range topics {
+		if _, _, ok := validation.ParseTopicName(name); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	drifts := []streamDrift{}
+	for _, name := range names {
+		metadata, ok := recorded[name]
+		if !ok || metadata.Spec == nil || metadata.Archived != nil {
+			continue
+		}
+		provisioned, live := *metadata.Spec, topics[name]
+		var changed []fieldChange
+		// the defaults of the broker may change without the topics created with them drifting
+		if provisioned.NumPartitions != client.BrokerDefault && provisioned.NumPartitions != live.NumPartitions {
+			changed = append(changed, fieldChange{Field: "partitions", From: rawJSON(provisioned.NumPartitions), To: rawJSON(live.NumPartitions)})
+		}
+		if provisioned.ReplicationFactor != client.BrokerDefault && provisioned.ReplicationFactor != live.ReplicationFactor {
+			changed = append(changed, fieldChange{Field: "replicationFactor", From: rawJSON(provisioned.ReplicationFactor), To: rawJSON(live.ReplicationFactor)})
+		}
+		configChanged := changes("config.", configFields(provisioned.ConfigEntries), configFields(live.ConfigEntries))
+		changed = append(changed, configChanged...)
+		if len(changed) == 0 {
+			continue
+		}
+		drift := streamDrift{Topic: name, Changes: changed}
+		rh.Logger.Warn("Topic drifted from its provisioned spec", "topic", name, "changes", len(changed))
+		if repair && len(configChanged) > 0 {
+			if err := rh.KafkaClient.AlterTopicConfig(name, provisioned.ConfigEntries); err != nil {
+				rh.Logger.Error("Error reverting the config of drifted topic", "topic", name, "error", err)
+				drift.Error, drift.err = err.Error(), err
+			} else {
+				rh.Logger.Info("Reverted the config of drifted topic", "topic", name)
+				rh.publish(events.StreamAltered, name, &provisioned, false)
+				drift.Repaired = true
+			}
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts, nil
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Shopify/sarama"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+// The states of the topic of a stream in the secondary region
+const (
+	// RegionProvisioned tells that the topic exists in the secondary cluster, created by the provisioner
+	RegionProvisioned = "provisioned"
+	// RegionReplicated tells that MirrorMaker 2 created the remote topic of a stream flagged for replication
+	RegionReplicated = "replicated"
+	// RegionPending tells that MirrorMaker 2 is yet to create the remote topic of a stream flagged for replication
+	RegionPending = "pending"
+	// RegionMissing tells that the topic doesn't exist in the secondary cluster, e.g. for streams provisioned before
+	// the region was added, until they are provisioned again
+	RegionMissing = "missing"
+)
+
+// SecondaryRegion is the passive region of an active/passive deployment, the streams provisioned in the cluster of
+// the handler being provisioned in its cluster as well, so that clients can fail over to its gateway
+type SecondaryRegion struct {
+	// Name tells the region apart in responses, e.g. us-west
+	Name string
+	// PrimaryName tells the region of the cluster of the handler apart in responses, e.g. us-east
+	PrimaryName string
+	// Gateway is the address of the gRPC endpoint of the gateway of the region
+	Gateway string
+	// Connect connects to the cluster of the region, the connection being closed once the request is served
+	Connect func() (client.KafkaClient, error)
+}
+
+// regionStream is the topic of a stream in the secondary region
+type regionStream struct {
+	Topic string
+	State string
+}
+
+type regionResult struct {
+	Gateway string `json:"gateway"`
+	Topic   string `json:"topic"`
+	State   string `json:"state,omitempty"`
+}
+
+// connectSecondary connects to the cluster of the secondary region, failing with a 503 status when it can't
+func (rh *TopicCreationRequestHandler) connectSecondary() (client.KafkaClient, error) {
+	kafkaClient, err := rh.Secondary.Connect()
+	if err != nil {
+		rh.Logger.Error("Error connecting to the secondary region", "region", rh.Secondary.Name, "error", err)
+		return nil, &StatusError{Status: http.StatusServiceUnavailable, RetryAfter: rh.retryAfter(), Message: fmt.Sprintf("Error connecting to region %q: %v", rh.Secondary.Name, err)}
+	}
+	return kafkaClient, nil
+}
+
+// provisionSecondary provisions the topic of a stream in the secondary region, with the spec of the topic of the
+// primary region, unless it is flagged for replication, its remote topic being created by MirrorMaker 2
+func (rh *TopicCreationRequestHandler) provisionSecondary(topicName string, replicate bool, metadata *client.StreamMetadata) (*regionStream, error) {
+	secondary, err := rh.connectSecondary()
+	if err != nil {
+		return nil, err
+	}
+	defer secondary.Close()
+	if replicate {
+		return rh.describeReplicated(secondary, topicName)
+	}
+	exists, kafkaError := secondary.TopicExists(topicName)
+	if kafkaError != nil {
+		return nil, rh.kafkaFailure(kafkaError, "Error checking whether topic %q exists in region %q: %v", topicName, rh.Secondary.Name, kafkaError)
+	}
+	if exists {
+		return &regionStream{Topic: topicName, State: RegionProvisioned}, nil
+	}
+	spec, err := rh.primarySpec(topicName, metadata)
+	if err != nil {
+		return nil, rh.kafkaFailure(err, "Error describing topic %q to provision it in region %q: %v", topicName, rh.Secondary.Name, err)
+	}
+	if err := secondary.CreateTopic(topicName, spec); err != nil && !errors.Is(err, sarama.ErrTopicAlreadyExists) {
+		rh.Logger.Error("Error creating topic in the secondary region", "topic", topicName, "region", rh.Secondary.Name, "error", err)
+		return nil, rh.kafkaFailure(err, "Error creating topic %q in region %q: %v", topicName, rh.Secondary.Name, err)
+	}
+	rh.Logger.Debug("Created topic in the secondary region", "topic", topicName, "region", rh.Secondary.Name)
+	if metadata != nil {
+		// a standby provisioner of the region describes the stream as this one does
+		if err := secondary.WriteMetadata(topicName, *metadata); err != nil {
+			return nil, rh.kafkaFailure(err, "Error recording the metadata of topic %q in region %q: %v", topicName, rh.Secondary.Name, err)
+		}
+	}
+	return &regionStream{Topic: topicName, State: RegionProvisioned}, nil
+}
+
+// primarySpec returns the spec of the topic of the primary region, as recorded in its metadata when known, or else
+// as described by the cluster
+func (rh *TopicCreationRequestHandler) primarySpec(topicName string, metadata *client.StreamMetadata) (client.TopicSpec, error) {
+	if metadata == nil || metadata.Spec == nil {
+		var err error
+		if metadata, err = rh.KafkaClient.ReadMetadata(topicName); err != nil {
+			return client.TopicSpec{}, err
+		}
+	}
+	if metadata != nil && metadata.Spec != nil {
+		return *metadata.Spec, nil
+	}
+	topics, err := rh.KafkaClient.ListTopics()
+	if err != nil {
+		return client.TopicSpec{}, err
+	}
+	spec, ok := topics[topicName]
+	if !ok {
+		return client.TopicSpec{}, sarama.ErrUnknownTopicOrPartition
+	}
+	return spec, nil
+}
+
+// describeSecondary returns the state of the topic of a stream in the secondary region, the remote topic MirrorMaker
+// 2 creates when replication is configured and the stream isn't provisioned there
+func (rh *TopicCreationRequestHandler) describeSecondary(topicName string) (*regionStream, error) {
+	secondary, err := rh.connectSecondary()
+	if err != nil {
+		return nil, err
+	}
+	defer secondary.Close()
+	exists, kafkaError := secondary.TopicExists(topicName)
+	if kafkaError != nil {
+		return nil, rh.kafkaFailure(kafkaError, "Error checking whether topic %q exists in region %q: %v", topicName, rh.Secondary.Name, kafkaError)
+	}
+	if exists {
+		return &regionStream{Topic: topicName, State: RegionProvisioned}, nil
+	}
+	if rh.Replication != nil {
+		return rh.describeReplicated(secondary, topicName)
+	}
+	return &regionStream{Topic: topicName, State: RegionMissing}, nil
+}
+
+// describeReplicated returns the state of the remote topic MirrorMaker 2 creates in the secondary region
+func (rh *TopicCreationRequestHandler) describeReplicated(secondary client.KafkaClient, topicName string) (*regionStream, error) {
+	remoteTopic := rh.Replication.describe(topicName).RemoteTopic
+	exists, kafkaError := secondary.TopicExists(remoteTopic)
+	if kafkaError != nil {
+		return nil, rh.kafkaFailure(kafkaError, "Error checking whether topic %q exists in region %q: %v", remoteTopic, rh.Secondary.Name, kafkaError)
+	}
+	if exists {
+		return &regionStream{Topic: remoteTopic, State: RegionReplicated}, nil
+	}
+	return &regionStream{Topic: remoteTopic, State: RegionPending}, nil
+}
+
+// deleteSecondary deletes the topic of a stream in the secondary region, and the remote topic MirrorMaker 2 created
+// when replication is configured, which it doesn't delete itself
+func (rh *TopicCreationRequestHandler) deleteSecondary(topicName string) error {
+	secondary, err := rh.connectSecondary()
+	if err != nil {
+		return err
+	}
+	defer secondary.Close()
+	topicNames := []string{topicName}
+	if rh.Replication != nil {
+		topicNames = append(topicNames, rh.Replication.describe(topicName).RemoteTopic)
+	}
+	for _, name := range topicNames {
+		if err := secondary.DeleteTopic(name); err != nil && !errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+			rh.Logger.Error("Error deleting topic in the secondary region", "topic", name, "region", rh.Secondary.Name, "error", err)
+			return rh.kafkaFailure(err, "Error deleting topic %q in region %q: %v", name, rh.Secondary.Name, err)
+		}
+		if err := secondary.DeleteMetadata(name); err != nil {
+			rh.Logger.Warn("Error deleting the metadata of topic in the secondary region", "topic", name, "region", rh.Secondary.Name, "error", err)
+		}
+	}
+	return nil
+}
+
+// regions returns the coordinates of a stream in both regions
+func (rh *TopicCreationRequestHandler) regions(namespace, topicName string, secondary *regionStream) map[string]regionResult {
+	return map[string]regionResult{
+		rh.Secondary.PrimaryName: {Gateway: rh.gateway(namespace), Topic: topicName},
+		rh.Secondary.Name:        {Gateway: rh.Secondary.Gateway, Topic: secondary.Topic, State: secondary.State},
+	}
+}
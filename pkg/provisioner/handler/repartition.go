@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/events"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// DefaultRepartitionRetention is how long repartition topics retain their records unless configured otherwise,
+// processors consuming them right after producing them
+const DefaultRepartitionRetention = 24 * time.Hour
+
+// Repartition is the companion repartition topic of a stream
+type Repartition struct {
+	Topic      string `json:"topic"`
+	Partitions int32  `json:"partitions"`
+	// Created tells whether the topic was created, rather than existing already
+	Created bool `json:"created"`
+}
+
+// RepartitionName returns the name of the companion repartition stream of a stream
+func RepartitionName(stream string) string {
+	return stream + "-repartition"
+}
+
+// createRepartition creates the companion repartition topic of the topic of a stream, unless it exists, with as many
+// partitions and replicas as the topic of the stream, and a short retention. The warning of the cluster partition
+// budget is returned, if any.
+func (rh *TopicCreationRequestHandler) createRepartition(namespace, stream, topicName string) (*Repartition, string, error) {
+	repartitionName, err := StreamName(validation.KafkaNaming{}, namespace, RepartitionName(stream))
+	if err != nil {
+		return nil, "", err
+	}
+	topics, err := rh.KafkaClient.ListTopics()
+	if err != nil {
+		rh.Logger.Error("Error listing topics", "topic", repartitionName, "error", err)
+		return nil, "", rh.kafkaFailure(err, "Error listing topics to provision %q: %v", repartitionName, err)
+	}
+	if existing, ok := topics[repartitionName]; ok {
+		rh.Logger.Debug("Repartition topic already exists", "topic", repartitionName)
+		return &Repartition{Topic: repartitionName, Partitions: existing.NumPartitions}, "", nil
+	}
+	source, ok := topics[topicName]
+	if !ok {
+		// the topic of the stream was just created, and isn't listed yet
+		return nil, "", &StatusError{Status: http.StatusServiceUnavailable, RetryAfter: rh.retryAfter(), Message: fmt.Sprintf("Error creating topic %q: topic %q is not listed yet", repartitionName, topicName)}
+	}
+	retention := rh.RepartitionRetention
+	if retention <= 0 {
+		retention = DefaultRepartitionRetention
+	}
+	spec := client.TopicSpec{NumPartitions: source.NumPartitions, ReplicationFactor: source.ReplicationFactor}
+	spec = withConfigEntry(spec, "cleanup.policy", "delete")
+	spec = withConfigEntry(spec, "retention.ms", strconv.FormatInt(retention.Milliseconds(), 10))
+	if err := rh.Rules.ValidateSpec(spec); err != nil {
+		rh.Logger.Info("Refusing to create topic", "topic", repartitionName, "error", err)
+		return nil, "", &StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to create topic %q: %v", repartitionName, err)}
+	}
+	if rh.MaxPayloadBytes > 0 {
+		spec = withMaxMessageBytes(spec, rh.MaxPayloadBytes)
+	}
+	warning, err := rh.checkCapacity(namespace, repartitionName, spec)
+	if err != nil {
+		return nil, "", err
+	}
+	err = rh.KafkaClient.CreateTopic(repartitionName, spec)
+	if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+		rh.Logger.Debug("Repartition topic was created concurrently", "topic", repartitionName)
+		return &Repartition{Topic: repartitionName, Partitions: spec.NumPartitions}, warning, nil
+	}
+	if err == nil {
+		err = rh.KafkaClient.WriteMetadata(repartitionName, client.StreamMetadata{Spec: &spec})
+	}
+	if err != nil {
+		rh.Logger.Error("Error creating repartition topic", "topic", repartitionName, "error", err)
+		return nil, "", rh.kafkaFailure(err, "Error creating topic %q: %v", repartitionName, err)
+	}
+	rh.Logger.Info("Created repartition topic", "topic", repartitionName, "partitions", spec.NumPartitions, "retention", retention)
+	rh.publish(events.StreamProvisioned, repartitionName, &spec, false)
+	return &Repartition{Topic: repartitionName, Partitions: spec.NumPartitions, Created: true}, warning, nil
+}
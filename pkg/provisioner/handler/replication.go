@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ReplicationPolicy describes how streams flagged for cross-cluster replication are provisioned,
+// mirroring the settings of the MirrorMaker 2 instance that replicates this cluster.
+type ReplicationPolicy struct {
+	// SourceClusterAlias is the MirrorMaker 2 alias of the cluster topics are created in
+	SourceClusterAlias string
+	// Separator is the MirrorMaker 2 replication.policy.separator, "." if empty
+	Separator string
+	// ConfigEntries are applied to topics created for replicated streams
+	ConfigEntries map[string]*string
+}
+
+func (rp *ReplicationPolicy) describe(topicName string) *replicationResult {
+	separator := rp.Separator
+	if separator == "" {
+		separator = "."
+	}
+	return &replicationResult{
+		SourceCluster: rp.SourceClusterAlias,
+		RemoteTopic:   fmt.Sprintf("%s%s%s", rp.SourceClusterAlias, separator, topicName),
+		TopicFilter:   regexp.QuoteMeta(topicName),
+	}
+}
+
+type replicationResult struct {
+	SourceCluster string `json:"sourceCluster"`
+	// RemoteTopic is the name MirrorMaker 2 gives the topic on the target cluster
+	RemoteTopic string `json:"remoteTopic"`
+	// TopicFilter is suitable for inclusion in the MirrorMaker 2 topics list
+	TopicFilter string `json:"topicFilter"`
+}
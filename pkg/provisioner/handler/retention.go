@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+// parseRetention returns the retention requested with the retentionMs and retentionBytes parameters, each either
+// positive or -1 for unlimited, and localRetentionMs with tiered storage
+func parseRetention(request *http.Request, tieredStorage bool) (Retention, error) {
+	retention := Retention{}
+	for _, parameter := range []struct {
+		name  string
+		unit  string
+		value *int64
+	}{
+		{name: "retentionMs", unit: "milliseconds", value: &retention.Ms},
+		{name: "retentionBytes", unit: "bytes", value: &retention.Bytes},
+	} {
+		text := request.URL.Query().Get(parameter.name)
+		if text == "" {
+			continue
+		}
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil || value == 0 || value < -1 {
+			return Retention{}, fmt.Errorf("%s should be a number of %s, or -1 for unlimited, got %q", parameter.name, parameter.unit, text)
+		}
+		*parameter.value = value
+	}
+	if text := request.URL.Query().Get("localRetentionMs"); text != "" {
+		if !tieredStorage {
+			return Retention{}, fmt.Errorf("localRetentionMs requires tieredStorage=true")
+		}
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil || value <= 0 {
+			return Retention{}, fmt.Errorf("localRetentionMs should be a number of milliseconds, got %q", text)
+		}
+		retention.LocalMs = value
+	}
+	return retention, nil
+}
+
+// retention returns the retention in effect for a created topic: that of its config, or the default of the brokers,
+// nil when neither is known
+func (rh *TopicCreationRequestHandler) retention(topicName string, spec client.TopicSpec) *Retention {
+	retention := Retention{Ms: configValue(spec.ConfigEntries, "retention.ms"), Bytes: configValue(spec.ConfigEntries, "retention.bytes")}
+	if retention.Ms == 0 {
+		retention.Ms = rh.brokerRetentionMs(topicName)
+	}
+	if retention.Bytes == 0 {
+		retention.Bytes = rh.brokerConfigValue(topicName, "log.retention.bytes")
+	}
+	if value, ok := spec.ConfigEntries["remote.storage.enable"]; ok && value != nil && *value == "true" {
+		retention.LocalMs = configValue(spec.ConfigEntries, "local.retention.ms")
+		if retention.LocalMs == 0 {
+			retention.LocalMs = rh.brokerConfigValue(topicName, "log.local.retention.ms")
+		}
+		// -2, the default, retains records locally as long as in remote storage
+		if retention.LocalMs == -2 {
+			retention.LocalMs = retention.Ms
+		}
+	}
+	if retention == (Retention{}) {
+		return nil
+	}
+	rh.Logger.Info("Topic retention", "topic", topicName, "retentionMs", retention.Ms, "retentionBytes", retention.Bytes, "localRetentionMs", retention.LocalMs)
+	return &retention
+}
+
+// checkTieredStorage verifies that the brokers store the records of topics remotely when asked to, which takes
+// Kafka 3.6 or later with remote.log.storage.system.enable
+func (rh *TopicCreationRequestHandler) checkTieredStorage(topicName string) error {
+	value, err := rh.KafkaClient.BrokerConfig("remote.log.storage.system.enable")
+	if err != nil {
+		rh.Logger.Error("Error describing broker configs", "topic", topicName, "error", err)
+		return rh.kafkaFailure(err, "Error describing broker configs for topic %q: %v", topicName, err)
+	}
+	if value == nil || *value != "true" {
+		rh.Logger.Info("Refusing to create topic", "topic", topicName, "reason", "tiered storage is not enabled")
+		return &StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to create topic %q: tiered storage is not enabled on the brokers, with remote.log.storage.system.enable", topicName)}
+	}
+	return nil
+}
+
+// brokerRetentionMs returns the default retention.ms of topics, which brokers set in milliseconds, minutes or hours
+func (rh *TopicCreationRequestHandler) brokerRetentionMs(topicName string) int64 {
+	if ms := rh.brokerConfigValue(topicName, "log.retention.ms"); ms != 0 {
+		return ms
+	}
+	if minutes := rh.brokerConfigValue(topicName, "log.retention.minutes"); minutes != 0 {
+		return minutes * 60 * 1000
+	}
+	return rh.brokerConfigValue(topicName, "log.retention.hours") * 60 * 60 * 1000
+}
+
+// brokerConfigValue returns the integer value of a config of the brokers, 0 when unknown. Failing to describe it
+// only leaves the retention of the topic unreported, the topic being created already.
+func (rh *TopicCreationRequestHandler) brokerConfigValue(topicName, name string) int64 {
+	value, err := rh.KafkaClient.BrokerConfig(name)
+	if err != nil {
+		rh.Logger.Warn("Error describing broker configs", "topic", topicName, "config", name, "error", err)
+		return 0
+	}
+	if value == nil {
+		return 0
+	}
+	parsed, _ := strconv.ParseInt(*value, 10, 64)
+	return parsed
+}
+
+// configValue returns the integer value of a config entry, 0 when unset
+func configValue(configEntries map[string]*string, name string) int64 {
+	value, ok := configEntries[name]
+	if !ok || value == nil {
+		return 0
+	}
+	parsed, _ := strconv.ParseInt(*value, 10, 64)
+	return parsed
+}
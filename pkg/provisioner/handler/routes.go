@@ -0,0 +1,34 @@
+package handler
+
+import "strings"
+
+// Route returns the template of the route of the API serving path, such as "/{namespace}/{stream}", so that requests
+// can be measured per route without a label per stream. Paths of no route are "other".
+func Route(path string) string {
+	switch path {
+	case CapabilitiesPath, OperationsPath, CatalogPath, StatePath, PlanPath, ReconcilePath, JournalPath:
+		return path
+	}
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for _, part := range parts {
+		if part == "" {
+			return "other"
+		}
+	}
+	switch {
+	case len(parts) == 1:
+		return "/{namespace}"
+	case len(parts) == 2:
+		return "/{namespace}/{stream}"
+	case len(parts) == 3 && parts[2] == LeadersSegment:
+		return "/{namespace}/{stream}/" + LeadersSegment
+	case len(parts) == 3 && parts[2] == MirrorSegment:
+		return "/{namespace}/{stream}/" + MirrorSegment
+	case len(parts) == 3 && parts[2] == MigrationSegment:
+		return "/{namespace}/{stream}/" + MigrationSegment
+	case len(parts) == 4 && parts[2] == ChangelogsSegment:
+		return "/{namespace}/{app}/" + ChangelogsSegment + "/{store}"
+	default:
+		return "other"
+	}
+}
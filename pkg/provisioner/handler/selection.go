@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const (
+	// BackendParameter names the backend a request chooses among the named backends of the handler
+	BackendParameter = "backend"
+	// BackendHeader names the backend a request chooses, when the backend parameter doesn't
+	BackendHeader = "X-Riff-Backend"
+)
+
+// NamedBackend is a backend requests may choose by name, rather than the default one, e.g. to serve mixed Kafka and
+// Pulsar estates with one provisioner
+type NamedBackend struct {
+	Backend Backend
+	// Namespaces are those whose requests may choose the backend, "*" allowing all of them
+	Namespaces []string
+}
+
+// ChosenBackend returns the name of the backend a request chooses, empty for the default one
+func ChosenBackend(request *http.Request) string {
+	if name := request.URL.Query().Get(BackendParameter); name != "" {
+		return name
+	}
+	return request.Header.Get(BackendHeader)
+}
+
+// selectBackend returns the backend the request of a namespace chooses, the default one unless it names another,
+// failing with a 403 status when the namespace isn't allowed to choose it
+func (rh *TopicCreationRequestHandler) selectBackend(request *http.Request, namespace string) (Backend, error) {
+	name := ChosenBackend(request)
+	named, ok := rh.Backends[name]
+	if !ok {
+		return rh.namedBackend(name)
+	}
+	for _, allowed := range named.Namespaces {
+		if allowed == "*" || allowed == namespace {
+			return named.Backend, nil
+		}
+	}
+	return nil, &StatusError{Status: http.StatusForbidden, Message: fmt.Sprintf("Namespace %q may not provision streams on backend %q", namespace, name)}
+}
+
+// namedBackend returns the backend of a name, the default one when empty or naming it
+func (rh *TopicCreationRequestHandler) namedBackend(name string) (Backend, error) {
+	if named, ok := rh.Backends[name]; ok {
+		return named.Backend, nil
+	}
+	backend := rh.backend()
+	if name == "" || name == backend.Capabilities().Backend {
+		return backend, nil
+	}
+	return nil, &StatusError{Status: http.StatusBadRequest, Message: fmt.Sprintf("Unknown backend %q", name)}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"fmt"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+// SpecVersion is the version of the schema of provisioning request bodies asking for the spec of the topic of a
+// stream, bodies without a version carrying its metadata only
+const SpecVersion = "v1"
+
+// streamSpec is a provisioning request body of version SpecVersion, asking for the layout and config of the topic of
+// a stream along with its metadata
+type streamSpec struct {
+	APIVersion string `json:"apiVersion"`
+	// Partitions and ReplicationFactor are the layout of the topic, the defaults of the provisioner applying when
+	// zero, and those of the broker when -1
+	Partitions        int32                 `json:"partitions,omitempty"`
+	ReplicationFactor int16                 `json:"replicationFactor,omitempty"`
+	Config            map[string]*string    `json:"config,omitempty"`
+	ContentType       string                `json:"contentType,omitempty"`
+	Labels            map[string]string     `json:"labels,omitempty"`
+	Envelope          string                `json:"envelope,omitempty"`
+	Schema            *client.SchemaSubject `json:"schema,omitempty"`
+}
+
+// validate checks the layout and config asked for, the rules of the provisioner and the configs topics take being
+// checked once the spec of the topic is known
+func (s *streamSpec) validate() error {
+	if s.Partitions < 0 && s.Partitions != client.BrokerDefault {
+		return fmt.Errorf("partitions should be at least 1, or -1 for the broker default, got %d", s.Partitions)
+	}
+	if s.ReplicationFactor < 0 && s.ReplicationFactor != client.BrokerDefault {
+		return fmt.Errorf("replication factor should be at least 1, or -1 for the broker default, got %d", s.ReplicationFactor)
+	}
+	for name, value := range s.Config {
+		if name == "" {
+			return fmt.Errorf("config names can't be empty")
+		}
+		if value == nil {
+			return fmt.Errorf("config %q should have a value", name)
+		}
+	}
+	return nil
+}
+
+// metadata returns the metadata of the stream asked for, nil when there is none
+func (s *streamSpec) metadata() *client.StreamMetadata {
+	if s.ContentType == "" && s.Labels == nil && s.Envelope == "" && s.Schema == nil {
+		return nil
+	}
+	return &client.StreamMetadata{ContentType: s.ContentType, Labels: s.Labels, Envelope: s.Envelope, Schema: s.Schema}
+}
+
+// topicSpec returns the layout and config of the topic asked for, nil when there is none
+func (s *streamSpec) topicSpec() *client.TopicSpec {
+	if s.Partitions == 0 && s.ReplicationFactor == 0 && len(s.Config) == 0 {
+		return nil
+	}
+	return &client.TopicSpec{NumPartitions: s.Partitions, ReplicationFactor: s.ReplicationFactor, ConfigEntries: s.Config}
+}
+
+// withRequested applies the layout and config of the topic asked for to a spec
+func withRequested(spec client.TopicSpec, requested *client.TopicSpec) client.TopicSpec {
+	if requested == nil {
+		return spec
+	}
+	if requested.NumPartitions != 0 {
+		spec.NumPartitions = requested.NumPartitions
+	}
+	if requested.ReplicationFactor != 0 {
+		spec.ReplicationFactor = requested.ReplicationFactor
+	}
+	for name, value := range requested.ConfigEntries {
+		spec = withConfigEntry(spec, name, *value)
+	}
+	return spec
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+)
+
+// mutates tells whether a request may change streams or topics: all requests but GET and HEAD ones, and plans, which
+// only tell what importing a document would change
+func mutates(request *http.Request) bool {
+	if request.Method == http.MethodGet || request.Method == http.MethodHead {
+		return false
+	}
+	return request.URL.Path != PlanPath
+}
+
+// rejectMutation fails requests that would change streams or topics with a 503 status when the provisioner is a
+// read-only standby, telling whether it did
+func (rh *TopicCreationRequestHandler) rejectMutation(responseWriter http.ResponseWriter, request *http.Request) bool {
+	if !rh.ReadOnly || !mutates(request) {
+		return false
+	}
+	rh.writeErrorf(responseWriter, http.StatusServiceUnavailable, CodeReadOnly, "This provisioner is a read-only standby, streams can't be changed")
+	return true
+}
@@ -0,0 +1,303 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// StatePath exports and imports the definitions of the streams provisioned across namespaces
+	StatePath = "/state"
+	// maxStateBytes bounds the documents imported
+	maxStateBytes = 32 * 1024 * 1024
+)
+
+// Outcomes of the import of a stream
+const (
+	ImportCreated   = "created"
+	ImportUpdated   = "updated"
+	ImportUnchanged = "unchanged"
+	ImportDeleted   = "deleted"
+	ImportFailed    = "failed"
+)
+
+// stateDocument is the provisioning state of a cluster, the definitions of its streams sorted by topic
+type stateDocument struct {
+	Streams []streamDefinition `json:"streams"`
+}
+
+// streamDefinition is what it takes to provision a stream again: the layout and config overrides of its topic and
+// its metadata
+type streamDefinition struct {
+	Namespace string `json:"namespace"`
+	Stream    string `json:"stream"`
+	client.TopicSpec
+	*client.StreamMetadata
+}
+
+type importResult struct {
+	Streams []importedStream `json:"streams"`
+}
+
+type importedStream struct {
+	Topic  string `json:"topic"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// state exports the definitions of the streams of all namespaces on GET, and imports them on PUT
+func (rh *TopicCreationRequestHandler) state(responseWriter http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		if rh.Authorizer != nil && !rh.authorize(responseWriter, request, "", "list") {
+			return
+		}
+		rh.exportState(responseWriter, request)
+	case http.MethodPut:
+		if rh.Authorizer != nil && !rh.authorize(responseWriter, request, "", "create") {
+			return
+		}
+		rh.importState(responseWriter, request)
+	default:
+		rh.writeErrorf(responseWriter, http.StatusMethodNotAllowed, "", "Method %s is not allowed", request.Method)
+	}
+}
+
+// exportState writes the definitions of the streams as JSON, or YAML when the request accepts it. Deleted streams
+// whose topic is archived are left out, as are the migrations of streams, the streams they migrate to being
+// exported themselves.
+func (rh *TopicCreationRequestHandler) exportState(responseWriter http.ResponseWriter, request *http.Request) {
+	topics, err := rh.KafkaClient.ListTopics()
+	if err != nil {
+		rh.Logger.Error("Error listing topics to export", "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error listing topics: %v", err))
+		return
+	}
+	metadata, err := rh.KafkaClient.ListMetadata()
+	if err != nil {
+		rh.Logger.Error("Error reading stream metadata to export", "error", err)
+		rh.writeError(responseWriter, rh.kafkaFailure(err, "Error reading stream metadata: %v", err))
+		return
+	}
+	names := make([]string, 0, len(topics))
+	for name := range topics {
+		if _, _, ok := validation.ParseTopicName(name); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	document := stateDocument{Streams: []streamDefinition{}}
+	for _, name := range names {
+		namespace, stream, _ := validation.ParseTopicName(name)
+		definition := streamDefinition{Namespace: namespace, Stream: stream, TopicSpec: topics[name]}
+		if m, ok := metadata[name]; ok {
+			if m.Archived != nil {
+				continue
+			}
+			m.Migration, m.Spec = nil, nil
+			definition.StreamMetadata = &m
+		}
+		document.Streams = append(document.Streams, definition)
+	}
+
+	body, err := json.Marshal(document)
+	contentType := "application/json"
+	if err == nil && acceptsYAML(request) {
+		contentType = "application/yaml"
+		body, err = toYAML(body)
+	}
+	if err != nil {
+		rh.Logger.Error("Error encoding the exported state", "error", err)
+		rh.writeErrorf(responseWriter, http.StatusInternalServerError, "", "Error encoding the exported state: %v", err)
+		return
+	}
+	rh.writeBody(responseWriter, request, http.StatusOK, contentType, body)
+}
+
+// importState provisions the streams of a document, be it JSON or YAML, creating the topics that don't exist and
+// bringing the config overrides and metadata of the others in line, as planned by planStream. Importing a document
+// again changes nothing. When pruning, the streams of the namespaces of the document that it doesn't define are
+// deleted.
+func (rh *TopicCreationRequestHandler) importState(responseWriter http.ResponseWriter, request *http.Request) {
+	prune, err := parseBoolParameter(request, "prune")
+	if err != nil {
+		rh.writeErrorf(responseWriter, http.StatusBadRequest, CodeInvalidParameter, "Invalid value for parameter \"prune\": %v", err)
+		return
+	}
+	if prune && rh.Authorizer != nil && !rh.authorize(responseWriter, request, "", "delete") {
+		return
+	}
+	plans, ok := rh.planState(responseWriter, request, prune)
+	if !ok {
+		return
+	}
+
+	statusCode := http.StatusOK
+	result := importResult{Streams: make([]importedStream, 0, len(plans))}
+	for _, plan := range plans {
+		imported := importedStream{Topic: plan.Topic, Status: importStatuses[plan.Action]}
+		if err := rh.applyPlan(request.Context(), plan); err != nil {
+			rh.Logger.Error("Error importing stream", "topic", plan.Topic, "error", err)
+			imported.Status, imported.Error = ImportFailed, err.Error()
+			if statusCode == http.StatusOK {
+				if _, ok := err.(*subjectError); ok {
+					statusCode = http.StatusBadGateway
+				} else {
+					statusCode = rh.kafkaErrorStatus(responseWriter, err)
+				}
+			}
+		}
+		result.Streams = append(result.Streams, imported)
+	}
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(statusCode)
+	if err := json.NewEncoder(responseWriter).Encode(result); err != nil {
+		rh.Logger.Error("Failed to write json response", "error", err)
+	}
+}
+
+// importStatuses tells what became of streams by the action planned for them
+var importStatuses = map[string]string{
+	PlanCreate: ImportCreated,
+	PlanUpdate: ImportUpdated,
+	PlanNone:   ImportUnchanged,
+	PlanDelete: ImportDeleted,
+}
+
+// parseState reads and validates the document of an import request
+func parseState(responseWriter http.ResponseWriter, request *http.Request) (*stateDocument, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(responseWriter, request.Body, maxStateBytes))
+	if err != nil {
+		return nil, err
+	}
+	if mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type")); isYAML(mediaType) {
+		if body, err = fromYAML(body); err != nil {
+			return nil, err
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	document := &stateDocument{}
+	if err := decoder.Decode(document); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(document.Streams))
+	for _, definition := range document.Streams {
+		topicName := validation.TopicName(definition.Namespace, definition.Stream)
+		if validation.ValidateNamespace(definition.Namespace) != nil || definition.Stream == "" {
+			return nil, fmt.Errorf("stream %q of namespace %q: namespaces should be DNS labels and streams named", definition.Stream, definition.Namespace)
+		}
+		if err := validation.ValidateTopicName(topicName); err != nil {
+			return nil, fmt.Errorf("stream %q of namespace %q: %v", definition.Stream, definition.Namespace, err)
+		}
+		if seen[topicName] {
+			return nil, fmt.Errorf("stream %q of namespace %q is defined more than once", definition.Stream, definition.Namespace)
+		}
+		seen[topicName] = true
+		if (definition.NumPartitions < 1 && definition.NumPartitions != client.BrokerDefault) || (definition.ReplicationFactor < 1 && definition.ReplicationFactor != client.BrokerDefault) {
+			return nil, fmt.Errorf("stream %q of namespace %q should have at least one partition and one replica, or -1 for the broker defaults", definition.Stream, definition.Namespace)
+		}
+		if definition.StreamMetadata != nil {
+			if err := validateMetadata(definition.StreamMetadata); err != nil {
+				return nil, fmt.Errorf("stream %q of namespace %q: %v", definition.Stream, definition.Namespace, err)
+			}
+		}
+	}
+	return document, nil
+}
+
+// sameMetadata tells whether two metadata would be recorded the same, empty labels being left out alike
+func sameMetadata(a, b client.StreamMetadata) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+// sameConfig tells whether two sets of config overrides are equal
+func sameConfig(a, b map[string]*string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		other, ok := b[name]
+		if !ok || (value == nil) != (other == nil) || (value != nil && *value != *other) {
+			return false
+		}
+	}
+	return true
+}
+
+func acceptsYAML(request *http.Request) bool {
+	for _, accepted := range strings.Split(request.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted)); err == nil && isYAML(mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+func isYAML(mediaType string) bool {
+	return mediaType == "application/yaml" || mediaType == "application/x-yaml" || mediaType == "text/yaml"
+}
+
+// toYAML converts a JSON document to YAML, keeping the order of its fields
+func toYAML(body []byte) ([]byte, error) {
+	var document yaml.MapSlice
+	if err := yaml.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(document)
+}
+
+// fromYAML converts a YAML document to JSON
+func fromYAML(body []byte) ([]byte, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+	converted, err := jsonValue(document)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(converted)
+}
+
+// jsonValue converts the maps decoded from YAML, which may have keys of any type, to maps JSON can encode
+func jsonValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("keys should be strings, got %v", key)
+			}
+			var err error
+			if converted[name], err = jsonValue(item); err != nil {
+				return nil, err
+			}
+		}
+		return converted, nil
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			var err error
+			if converted[i], err = jsonValue(item); err != nil {
+				return nil, err
+			}
+		}
+		return converted, nil
+	default:
+		return v, nil
+	}
+}
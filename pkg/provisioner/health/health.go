@@ -0,0 +1,94 @@
+// Package health probes the Kafka brokers in the background, so that degraded connectivity shows in the readiness of
+// the provisioner and its metrics before the next provisioning request fails
+package health
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/metrics"
+)
+
+// ReadyPath is where the readiness of the provisioner is served
+const ReadyPath = "/ready"
+
+// errNotProbed is the readiness of a prober yet to probe the brokers
+var errNotProbed = fmt.Errorf("the Kafka brokers weren't probed yet")
+
+// Prober checks the metadata of the brokers, recording whether they were reachable at the last probe
+type Prober struct {
+	// Check fetches the metadata of the brokers, failing when they can't be reached
+	Check func() error
+	// Metrics, when set, exports whether the brokers were reachable
+	Metrics *metrics.Metrics
+	Logger  *slog.Logger
+
+	mu  sync.Mutex
+	err error
+}
+
+// New creates a prober checking the brokers with check, not ready until it probed them
+func New(check func() error, logger *slog.Logger) *Prober {
+	return &Prober{Check: check, Logger: logger, err: errNotProbed}
+}
+
+// Run probes the brokers right away, then every interval until ctx is done
+func (p *Prober) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.Probe()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Probe checks the brokers once, logging when they become unreachable and when they recover
+func (p *Prober) Probe() {
+	err := p.Check()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case err != nil && p.err == nil:
+		p.Logger.Warn("Kafka brokers became unreachable", "error", err)
+	case err != nil:
+		p.Logger.Debug("Kafka brokers are still unreachable", "error", err)
+	case p.err != nil && p.err != errNotProbed:
+		p.Logger.Info("Kafka brokers are reachable again")
+	}
+	p.err = err
+	if p.Metrics != nil {
+		p.Metrics.SetBrokerUp(err == nil)
+	}
+}
+
+// Err returns why the brokers were unreachable at the last probe, nil when they were reachable
+func (p *Prober) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Handler serves the readiness of the provisioner: 200 when the brokers were reachable at the last probe, 503 with
+// the error otherwise
+func (p *Prober) Handler() http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet && request.Method != http.MethodHead {
+			responseWriter.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if err := p.Err(); err != nil {
+			responseWriter.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = fmt.Fprintf(responseWriter, "Kafka brokers are unreachable: %v\n", err)
+			return
+		}
+		_, _ = fmt.Fprintln(responseWriter, "ok")
+	})
+}
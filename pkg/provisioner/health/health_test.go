@@ -0,0 +1,109 @@
+package health_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/health"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/metrics"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var _ = Describe("Broker health", func() {
+
+	var (
+		mu     sync.Mutex
+		down   error
+		m      *metrics.Metrics
+		prober *health.Prober
+		ready  func() (int, string)
+		scrape func() string
+	)
+
+	BeforeEach(func() {
+		down = nil
+		m = metrics.NewMetrics()
+		prober = health.New(func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			return down
+		}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		prober.Metrics = m
+		ready = func() (int, string) {
+			recorder := httptest.NewRecorder()
+			prober.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, health.ReadyPath, nil))
+			return recorder.Code, recorder.Body.String()
+		}
+		scrape = func() string {
+			recorder := httptest.NewRecorder()
+			promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{}).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+			body, _ := ioutil.ReadAll(recorder.Body)
+			return string(body)
+		}
+	})
+
+	It("isn't ready until the brokers were probed", func() {
+		code, body := ready()
+
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(ContainSubstring("weren't probed yet"))
+	})
+
+	It("is ready while the brokers are reachable", func() {
+		prober.Probe()
+
+		code, _ := ready()
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(scrape()).To(ContainSubstring("riff_kafka_provisioner_broker_up 1"))
+	})
+
+	It("isn't ready while the brokers are unreachable, until they recover", func() {
+		down = fmt.Errorf("connection refused")
+		prober.Probe()
+
+		code, body := ready()
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(Equal("Kafka brokers are unreachable: connection refused\n"))
+		Expect(scrape()).To(ContainSubstring("riff_kafka_provisioner_broker_up 0"))
+
+		down = nil
+		prober.Probe()
+
+		Expect(prober.Err()).NotTo(HaveOccurred())
+		Expect(scrape()).To(ContainSubstring("riff_kafka_provisioner_broker_up 1"))
+	})
+
+	It("probes the brokers periodically until stopped", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			prober.Run(ctx, 10*time.Millisecond)
+		}()
+
+		Eventually(prober.Err).ShouldNot(HaveOccurred())
+		mu.Lock()
+		down = fmt.Errorf("connection refused")
+		mu.Unlock()
+		Eventually(prober.Err).Should(MatchError("connection refused"))
+
+		cancel()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("rejects other methods than GET and HEAD", func() {
+		recorder := httptest.NewRecorder()
+		prober.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, health.ReadyPath, nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
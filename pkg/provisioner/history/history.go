@@ -0,0 +1,224 @@
+// Package history keeps the recent provisioning operations, when they ran, who asked for them and how they ended, so
+// that on-call engineers can tell what changed recently without digging through logs
+package history
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxErrorLength bounds the error recorded for failed operations, the message of the response, or its first line
+const maxErrorLength = 256
+
+// Operation is a provisioning request that ran
+type Operation struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Path   string    `json:"path"`
+	// Requester is the user the caller authenticated as, when authorization is enabled, or else its address
+	Requester  string `json:"requester,omitempty"`
+	Status     int    `json:"status"`
+	Succeeded  bool   `json:"succeeded"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// History keeps the most recent operations in memory, appending them to a file when persisted so that they survive
+// restarts
+type History struct {
+	max int
+
+	mu         sync.Mutex
+	operations []Operation
+	file       *os.File
+	// lines is the number of operations in the file, which is rewritten with the most recent ones once it holds
+	// twice as many as kept
+	lines int
+}
+
+// New creates a history keeping the max most recent operations in memory
+func New(max int) *History {
+	return &History{max: max}
+}
+
+// Open creates a history keeping the max most recent operations, persisted in the file at path, those recorded in
+// it before being read back
+func Open(max int, path string) (*History, error) {
+	h := New(max)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		operation := Operation{}
+		if err := json.Unmarshal(scanner.Bytes(), &operation); err != nil {
+			// a line cut short by a crash is left behind
+			continue
+		}
+		h.append(operation)
+		h.lines++
+	}
+	if err := scanner.Err(); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("error reading operations from %q: %v", path, err)
+	}
+	h.file = file
+	return h, nil
+}
+
+// Record adds an operation to the history, forgetting the oldest beyond the max
+func (h *History) Record(operation Operation) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.append(operation)
+	if h.file == nil {
+		return nil
+	}
+	if h.lines >= 2*h.max {
+		return h.rewrite()
+	}
+	line, err := json.Marshal(operation)
+	if err != nil {
+		return err
+	}
+	if _, err := h.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	h.lines++
+	return nil
+}
+
+// Recent returns up to limit operations, the most recent first, all of those kept when limit is not positive
+func (h *History) Recent(limit int) []Operation {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if limit <= 0 || limit > len(h.operations) {
+		limit = len(h.operations)
+	}
+	recent := make([]Operation, 0, limit)
+	for i := len(h.operations) - 1; i >= len(h.operations)-limit; i-- {
+		recent = append(recent, h.operations[i])
+	}
+	return recent
+}
+
+// Close closes the file of persisted histories
+func (h *History) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil {
+		return nil
+	}
+	return h.file.Close()
+}
+
+func (h *History) append(operation Operation) {
+	h.operations = append(h.operations, operation)
+	if len(h.operations) > h.max {
+		h.operations = append(h.operations[:0], h.operations[len(h.operations)-h.max:]...)
+	}
+}
+
+// rewrite replaces the content of the file with the operations kept
+func (h *History) rewrite() error {
+	if err := h.file.Truncate(0); err != nil {
+		return err
+	}
+	var content []byte
+	for _, operation := range h.operations {
+		line, err := json.Marshal(operation)
+		if err != nil {
+			return err
+		}
+		content = append(append(content, line...), '\n')
+	}
+	if _, err := h.file.Write(content); err != nil {
+		return err
+	}
+	h.lines = len(h.operations)
+	return nil
+}
+
+type requesterKey struct{}
+
+// requester is who asked for an operation, set while serving its request, which may outlive the response when it
+// times out
+type requester struct {
+	mu   sync.Mutex
+	name string
+}
+
+// SetRequester tells who asked for the operation of a request recorded, once the caller is authenticated
+func SetRequester(ctx context.Context, name string) {
+	if r, ok := ctx.Value(requesterKey{}).(*requester); ok {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.name = name
+	}
+}
+
+// Instrument records the requests to next changing streams, PUT, POST and DELETE, other requests only reading them.
+// Failing to persist an operation is reported to onError, the request being served anyway.
+func (h *History) Instrument(next http.Handler, onError func(error)) http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodPut && request.Method != http.MethodPost && request.Method != http.MethodDelete {
+			next.ServeHTTP(responseWriter, request)
+			return
+		}
+		operation := Operation{Time: time.Now().UTC(), Method: request.Method, Path: request.URL.RequestURI()}
+		requester := &requester{name: request.RemoteAddr}
+		recorder := &responseRecorder{ResponseWriter: responseWriter, status: http.StatusOK}
+		next.ServeHTTP(recorder, request.WithContext(context.WithValue(request.Context(), requesterKey{}, requester)))
+
+		requester.mu.Lock()
+		operation.Requester = requester.name
+		requester.mu.Unlock()
+		operation.DurationMs = time.Since(operation.Time).Milliseconds()
+		operation.Status = recorder.status
+		operation.Succeeded = recorder.status < http.StatusBadRequest
+		if !operation.Succeeded {
+			message, _, _ := strings.Cut(recorder.body.String(), "\n")
+			// errors are JSON documents with a code and message, the message being what on-call engineers read
+			var document struct {
+				Message string `json:"message"`
+			}
+			if json.Unmarshal([]byte(message), &document) == nil && document.Message != "" {
+				message = document.Message
+			}
+			if len(message) > maxErrorLength {
+				message = message[:maxErrorLength]
+			}
+			operation.Error = message
+		}
+		if err := h.Record(operation); err != nil {
+			onError(err)
+		}
+	})
+}
+
+// responseRecorder records the status of a response, and the beginning of its body when it is an error
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   strings.Builder
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	rr.status = status
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status >= http.StatusBadRequest && rr.body.Len() < maxErrorLength {
+		rr.body.Write(b)
+	}
+	return rr.ResponseWriter.Write(b)
+}
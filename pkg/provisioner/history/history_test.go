@@ -0,0 +1,122 @@
+package history_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/history"
+)
+
+var _ = Describe("History", func() {
+
+	paths := func(operations []history.Operation) []string {
+		var paths []string
+		for _, operation := range operations {
+			paths = append(paths, operation.Path)
+		}
+		return paths
+	}
+
+	It("keeps the most recent operations, the most recent first", func() {
+		h := history.New(3)
+		for i := 0; i < 5; i++ {
+			Expect(h.Record(history.Operation{Path: fmt.Sprintf("/ns/%d", i)})).To(Succeed())
+		}
+
+		Expect(paths(h.Recent(0))).To(Equal([]string{"/ns/4", "/ns/3", "/ns/2"}))
+		Expect(paths(h.Recent(2))).To(Equal([]string{"/ns/4", "/ns/3"}))
+	})
+
+	Describe("persisted", func() {
+
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "history")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		It("reads back the operations persisted, keeping the file bounded", func() {
+			path := filepath.Join(dir, "operations")
+			h, err := history.Open(2, path)
+			Expect(err).NotTo(HaveOccurred())
+			for i := 0; i < 7; i++ {
+				Expect(h.Record(history.Operation{Path: fmt.Sprintf("/ns/%d", i)})).To(Succeed())
+			}
+			Expect(h.Close()).To(Succeed())
+
+			content, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.Count(string(content), "\n")).To(BeNumerically("<=", 4))
+
+			h, err = history.Open(2, path)
+			Expect(err).NotTo(HaveOccurred())
+			defer h.Close()
+			Expect(paths(h.Recent(0))).To(Equal([]string{"/ns/6", "/ns/5"}))
+		})
+	})
+
+	It("records the requests changing streams, with their requester and outcome", func() {
+		h := history.New(10)
+		handler := h.Instrument(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			history.SetRequester(r.Context(), "system:serviceaccount:ns:deployer")
+			if r.Method == http.MethodDelete {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = fmt.Fprintf(w, "Error deleting topic \"ns_foo\": timed out\nmore details\n")
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}), func(err error) { Fail(err.Error()) })
+
+		for _, method := range []string{http.MethodPut, http.MethodGet, http.MethodDelete} {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/ns/foo?partitions=3", nil))
+		}
+
+		operations := h.Recent(0)
+		Expect(operations).To(HaveLen(2))
+		Expect(operations[0].Method).To(Equal(http.MethodDelete))
+		Expect(operations[0].Status).To(Equal(http.StatusServiceUnavailable))
+		Expect(operations[0].Succeeded).To(BeFalse())
+		Expect(operations[0].Error).To(Equal(`Error deleting topic "ns_foo": timed out`))
+		Expect(operations[1].Method).To(Equal(http.MethodPut))
+		Expect(operations[1].Path).To(Equal("/ns/foo?partitions=3"))
+		Expect(operations[1].Requester).To(Equal("system:serviceaccount:ns:deployer"))
+		Expect(operations[1].Succeeded).To(BeTrue())
+		Expect(operations[1].Time).NotTo(BeZero())
+	})
+
+	It("records the message of errors with a code", func() {
+		h := history.New(10)
+		handler := h.Instrument(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = fmt.Fprintf(w, `{"code":"CONFLICT","message":"Topic \"ns_bar\" already exists","retryable":false}`+"\n")
+		}), func(err error) { Fail(err.Error()) })
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/ns/foo/migration", nil))
+
+		Expect(h.Recent(1)[0].Error).To(Equal(`Topic "ns_bar" already exists`))
+	})
+
+	It("tells requesters apart by address when they don't authenticate", func() {
+		h := history.New(10)
+		handler := h.Instrument(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}), func(err error) { Fail(err.Error()) })
+
+		request := httptest.NewRequest(http.MethodPut, "/ns/foo", nil)
+		request.RemoteAddr = "10.0.0.1:43210"
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+
+		Expect(h.Recent(1)[0].Requester).To(Equal("10.0.0.1:43210"))
+	})
+})
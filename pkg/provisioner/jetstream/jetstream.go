@@ -0,0 +1,264 @@
+// Package jetstream provisions streams on NATS JetStream, the streams of each namespace being JetStream streams
+// capturing a subject of their own, retained like Kafka topics whether or not they are consumed
+package jetstream
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/handler"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// The error codes of the JetStream API this backend tells apart
+const (
+	streamNameInUse = 10058
+	streamNotFound  = 10059
+)
+
+// Backend provisions streams as JetStream streams with limits retention, and points clients to the NATS server
+type Backend struct {
+	// URL is the address of the NATS server, e.g. nats://nats:4222, tls:// connecting over TLS
+	URL string
+	// Gateway, when set, is the address clients reach the server at, the URL without its credentials otherwise
+	Gateway string
+	// Token, when set, authenticates the connections to the server
+	Token string
+	// TLS, when set, configures the TLS connections to the server
+	TLS *tls.Config
+	// SubjectPrefix, when set, prefixes the subjects of streams
+	SubjectPrefix string
+	// Replicas is the number of replicas of streams
+	Replicas int
+	// MaxAge is how long messages are retained, forever when zero
+	MaxAge time.Duration
+	// MaxBytes is the size streams are capped at, the oldest messages being discarded, unlimited when zero
+	MaxBytes int64
+	// Timeout bounds each request to the JetStream API, 10 seconds when zero
+	Timeout time.Duration
+	// RetryAfter is suggested to callers when the server is unavailable, 5 seconds when zero
+	RetryAfter time.Duration
+	Logger     *slog.Logger
+}
+
+// apiError is the error of a response of the JetStream API
+type apiError struct {
+	Code        int    `json:"code"`
+	ErrCode     int    `json:"err_code"`
+	Description string `json:"description"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("JetStream API returned status %d: %s", e.Code, e.Description)
+}
+
+type apiResponse struct {
+	Error *apiError `json:"error"`
+}
+
+// err returns the error of a response, if any, with an untyped nil otherwise
+func (r apiResponse) err() error {
+	if r.Error == nil {
+		return nil
+	}
+	return r.Error
+}
+
+func hasErrCode(err error, errCode int) bool {
+	apiError, ok := err.(*apiError)
+	return ok && apiError.ErrCode == errCode
+}
+
+type streamConfig struct {
+	Name      string   `json:"name"`
+	Subjects  []string `json:"subjects"`
+	Retention string   `json:"retention"`
+	MaxAge    int64    `json:"max_age"`
+	MaxBytes  int64    `json:"max_bytes"`
+	MaxMsgs   int64    `json:"max_msgs"`
+	Discard   string   `json:"discard"`
+	Storage   string   `json:"storage"`
+	Replicas  int      `json:"num_replicas"`
+}
+
+func (b *Backend) CreateStream(ctx context.Context, request handler.StreamRequest) (*handler.Stream, bool, error) {
+	name, subject, err := b.names(request.Namespace, request.Stream)
+	if err != nil {
+		return nil, false, err
+	}
+	if request.Metadata != nil {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision stream %q: stream metadata is not supported on JetStream", name)}
+	}
+	if request.Replicate {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision stream %q: replication is configured with the mirrors of JetStream streams", name)}
+	}
+	if request.MaxMessageBytes > 0 {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision stream %q: the size of messages is capped by the max_payload of the NATS server", name)}
+	}
+	if request.TieredStorage {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision stream %q: JetStream streams are stored on the disks of the servers", name)}
+	}
+	if request.Group != nil {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision stream %q: the consumers of JetStream streams are created by their clients", name)}
+	}
+	if request.Repartition {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision stream %q: JetStream streams have no partitions to repartition", name)}
+	}
+	err = b.info(ctx, name)
+	if err == nil {
+		b.Logger.Debug("Stream already exists", "stream", name)
+		return b.stream(subject), false, nil
+	}
+	if !hasErrCode(err, streamNotFound) {
+		b.Logger.Error("Error describing stream", "stream", name, "error", err)
+		return nil, false, b.failure(err, "Error describing stream %q: %v", name, err)
+	}
+	config := streamConfig{
+		Name:      name,
+		Subjects:  []string{subject},
+		Retention: "limits",
+		MaxAge:    int64(b.MaxAge),
+		MaxBytes:  -1,
+		MaxMsgs:   -1,
+		Discard:   "old",
+		Storage:   "file",
+		Replicas:  b.Replicas,
+	}
+	if b.MaxBytes > 0 {
+		config.MaxBytes = b.MaxBytes
+	}
+	switch {
+	case request.Retention.Ms > 0:
+		config.MaxAge = int64(time.Duration(request.Retention.Ms) * time.Millisecond)
+	case request.Retention.Ms == -1:
+		config.MaxAge = 0
+	}
+	if request.Retention.Bytes != 0 {
+		config.MaxBytes = request.Retention.Bytes
+	}
+	if config.Replicas <= 0 {
+		config.Replicas = 1
+	}
+	response := apiResponse{}
+	err = b.request(ctx, "$JS.API.STREAM.CREATE."+name, config, &response)
+	if err == nil {
+		err = response.err()
+	}
+	if hasErrCode(err, streamNameInUse) {
+		// another replica created the stream in the meantime
+		b.Logger.Debug("Stream was created concurrently", "stream", name)
+		return b.stream(subject), false, nil
+	}
+	if err != nil {
+		b.Logger.Error("Error creating stream", "stream", name, "error", err)
+		return nil, false, b.failure(err, "Error creating stream %q: %v", name, err)
+	}
+	b.Logger.Debug("Created stream", "stream", name, "subject", subject, "replicas", config.Replicas)
+	created := b.stream(subject)
+	created.Retention = &handler.Retention{Ms: -1, Bytes: config.MaxBytes}
+	if config.MaxAge > 0 {
+		created.Retention.Ms = int64(time.Duration(config.MaxAge) / time.Millisecond)
+	}
+	return created, true, nil
+}
+
+func (b *Backend) DeleteStream(ctx context.Context, namespace, stream string) (*handler.Stream, error) {
+	name, _, err := b.names(namespace, stream)
+	if err != nil {
+		return nil, err
+	}
+	response := apiResponse{}
+	err = b.request(ctx, "$JS.API.STREAM.DELETE."+name, nil, &response)
+	if err == nil {
+		err = response.err()
+	}
+	if hasErrCode(err, streamNotFound) {
+		return nil, &handler.StatusError{Status: http.StatusNotFound, Message: fmt.Sprintf("Stream %q does not exist", name)}
+	}
+	if err != nil {
+		b.Logger.Error("Error deleting stream", "stream", name, "error", err)
+		return nil, b.failure(err, "Error deleting stream %q: %v", name, err)
+	}
+	b.Logger.Info("Deleted stream", "stream", name)
+	return nil, nil
+}
+
+func (b *Backend) DescribeStream(ctx context.Context, namespace, stream string) (*handler.Stream, error) {
+	name, subject, err := b.names(namespace, stream)
+	if err != nil {
+		return nil, err
+	}
+	err = b.info(ctx, name)
+	if hasErrCode(err, streamNotFound) {
+		return nil, &handler.StatusError{Status: http.StatusNotFound, Message: fmt.Sprintf("Stream %q does not exist", name)}
+	}
+	if err != nil {
+		b.Logger.Error("Error describing stream", "stream", name, "error", err)
+		return nil, b.failure(err, "Error describing stream %q: %v", name, err)
+	}
+	return b.stream(subject), nil
+}
+
+// Capabilities of JetStream: the names of streams are those of directories on the servers
+func (b *Backend) Capabilities() handler.Capabilities {
+	return handler.Capabilities{Backend: "jetstream", MaxNameLength: validation.JetStreamNaming{}.MaxLength()}
+}
+
+// info fails when a stream can't be described, e.g. because it doesn't exist
+func (b *Backend) info(ctx context.Context, name string) error {
+	response := apiResponse{}
+	if err := b.request(ctx, "$JS.API.STREAM.INFO."+name, nil, &response); err != nil {
+		return err
+	}
+	return response.err()
+}
+
+// names returns the name of the JetStream stream of a stream, named like Kafka topics, and its subject
+func (b *Backend) names(namespace, stream string) (string, string, error) {
+	naming := validation.JetStreamNaming{SubjectPrefix: b.SubjectPrefix}
+	name, err := handler.StreamName(naming, namespace, stream)
+	if err != nil {
+		return "", "", err
+	}
+	return name, naming.Subject(namespace, stream), nil
+}
+
+func (b *Backend) stream(subject string) *handler.Stream {
+	gateway := b.Gateway
+	if gateway == "" {
+		if server, err := url.Parse(b.URL); err == nil {
+			server.User = nil
+			gateway = server.String()
+		}
+	}
+	return &handler.Stream{Topic: subject, Gateway: gateway}
+}
+
+func (b *Backend) timeout() time.Duration {
+	if b.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return b.Timeout
+}
+
+// failure reports an error of the JetStream API: 503 with a Retry-After header when the server is unavailable, 422
+// when it refuses the request and 500 otherwise
+func (b *Backend) failure(err error, format string, args ...interface{}) *handler.StatusError {
+	statusError := &handler.StatusError{Status: http.StatusInternalServerError, Message: fmt.Sprintf(format, args...)}
+	apiError, ok := err.(*apiError)
+	switch {
+	case !ok || apiError.Code >= 500:
+		statusError.Status, statusError.RetryAfter = http.StatusServiceUnavailable, b.RetryAfter
+		if statusError.RetryAfter <= 0 {
+			statusError.RetryAfter = 5 * time.Second
+		}
+	case apiError.Code == http.StatusBadRequest:
+		statusError.Status = http.StatusUnprocessableEntity
+	}
+	return statusError
+}
@@ -0,0 +1,13 @@
+package jetstream_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestJetStream(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "JetStream Suite")
+}
@@ -0,0 +1,276 @@
+package jetstream_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/handler"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/jetstream"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+var _ = Describe("JetStream backend", func() {
+
+	var (
+		listener net.Listener
+		mu       sync.Mutex
+		streams  map[string]map[string]interface{}
+		disabled bool
+		backend  *jetstream.Backend
+	)
+
+	// reply answers the requests of the JetStream API, from the streams of the test
+	reply := func(subject string, payload []byte) string {
+		mu.Lock()
+		defer mu.Unlock()
+		notFound := `{"error": {"code": 404, "err_code": 10059, "description": "stream not found"}}`
+		parts := strings.Split(subject, ".")
+		name := parts[len(parts)-1]
+		switch verb := strings.Join(parts[:len(parts)-1], "."); verb {
+		case "$JS.API.STREAM.INFO":
+			config, ok := streams[name]
+			if !ok {
+				return notFound
+			}
+			info, _ := json.Marshal(map[string]interface{}{"config": config, "state": map[string]interface{}{"messages": 0}})
+			return string(info)
+		case "$JS.API.STREAM.CREATE":
+			if _, ok := streams[name]; ok {
+				return `{"error": {"code": 400, "err_code": 10058, "description": "stream name already in use with a different configuration"}}`
+			}
+			config := map[string]interface{}{}
+			Expect(json.Unmarshal(payload, &config)).To(Succeed())
+			streams[name] = config
+			return fmt.Sprintf(`{"config": %s}`, payload)
+		case "$JS.API.STREAM.DELETE":
+			if _, ok := streams[name]; !ok {
+				return notFound
+			}
+			delete(streams, name)
+			return `{"success": true}`
+		default:
+			return `{"error": {"code": 400, "description": "unknown request"}}`
+		}
+	}
+
+	serve := func(conn net.Conn) {
+		defer GinkgoRecover()
+		defer conn.Close()
+		_, _ = io.WriteString(conn, `INFO {"server_id": "test", "headers": true, "jetstream": true}`+"\r\n")
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			switch fields[0] {
+			case "CONNECT":
+				options := map[string]interface{}{}
+				Expect(json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(line), "CONNECT ")), &options)).To(Succeed())
+				if options["auth_token"] != "some-token" {
+					_, _ = io.WriteString(conn, "-ERR 'Authorization Violation'\r\n")
+					return
+				}
+			case "PING":
+				_, _ = io.WriteString(conn, "PONG\r\n")
+			case "PUB":
+				size, _ := strconv.Atoi(fields[3])
+				payload := make([]byte, size+2)
+				_, err := io.ReadFull(reader, payload)
+				Expect(err).NotTo(HaveOccurred())
+				mu.Lock()
+				jetStreamDisabled := disabled
+				mu.Unlock()
+				if jetStreamDisabled {
+					_, _ = fmt.Fprintf(conn, "HMSG %s 1 16 16\r\nNATS/1.0 503\r\n\r\n\r\n", fields[2])
+					continue
+				}
+				response := reply(fields[1], payload[:size])
+				_, _ = fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", fields[2], len(response), response)
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		mu.Lock()
+		streams, disabled = map[string]map[string]interface{}{}, false
+		mu.Unlock()
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		go func(listener net.Listener) {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go serve(conn)
+			}
+		}(listener)
+		backend = &jetstream.Backend{
+			URL:           "nats://" + listener.Addr().String(),
+			Gateway:       "nats://nats:4222",
+			Token:         "some-token",
+			SubjectPrefix: "riff",
+			Replicas:      3,
+			MaxAge:        168 * time.Hour,
+			Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		}
+	})
+
+	AfterEach(func() {
+		_ = listener.Close()
+	})
+
+	It("creates the streams of a subject of their own, with limits retention", func() {
+		stream, created, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders"})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(BeTrue())
+		Expect(stream).To(Equal(&handler.Stream{Topic: "riff.my-ns.orders", Gateway: "nats://nats:4222", Retention: &handler.Retention{Ms: 7 * 24 * 60 * 60 * 1000, Bytes: -1}}))
+		config, err := json.Marshal(streams["my-ns_orders"])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(MatchJSON(fmt.Sprintf(`{
+			"name": "my-ns_orders",
+			"subjects": ["riff.my-ns.orders"],
+			"retention": "limits",
+			"max_age": %d,
+			"max_bytes": -1,
+			"max_msgs": -1,
+			"discard": "old",
+			"storage": "file",
+			"num_replicas": 3
+		}`, int64(168*time.Hour))))
+	})
+
+	It("retains the records of streams by age and size, as requested", func() {
+		stream, _, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders", Retention: handler.Retention{Ms: -1, Bytes: 50 << 30}})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stream.Retention).To(Equal(&handler.Retention{Ms: -1, Bytes: 50 << 30}))
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(streams["my-ns_orders"]).To(HaveKeyWithValue("max_age", BeNumerically("==", 0)))
+		Expect(streams["my-ns_orders"]).To(HaveKeyWithValue("max_bytes", BeNumerically("==", 50<<30)))
+	})
+
+	It("leaves existing streams alone", func() {
+		streams["my-ns_orders"] = map[string]interface{}{"name": "my-ns_orders", "num_replicas": 1}
+
+		stream, created, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders"})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(BeFalse())
+		Expect(stream.Topic).To(Equal("riff.my-ns.orders"))
+		Expect(streams["my-ns_orders"]).To(HaveKeyWithValue("num_replicas", BeNumerically("==", 1)))
+	})
+
+	It("refuses to record the metadata of streams", func() {
+		_, _, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders", Metadata: &client.StreamMetadata{ContentType: "application/json"}})
+
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusUnprocessableEntity))
+		Expect(streams).To(BeEmpty())
+	})
+
+	It("refuses stream names that would be wildcards of subjects", func() {
+		_, _, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: ">"})
+
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusBadRequest))
+		Expect(streams).To(BeEmpty())
+	})
+
+	It("describes existing streams", func() {
+		streams["my-ns_orders"] = map[string]interface{}{"name": "my-ns_orders"}
+
+		stream, err := backend.DescribeStream(context.Background(), "my-ns", "orders")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stream).To(Equal(&handler.Stream{Topic: "riff.my-ns.orders", Gateway: "nats://nats:4222"}))
+	})
+
+	It("reports missing streams as not found", func() {
+		_, err := backend.DescribeStream(context.Background(), "my-ns", "orders")
+
+		Expect(err).To(Equal(&handler.StatusError{Status: http.StatusNotFound, Message: `Stream "my-ns_orders" does not exist`}))
+	})
+
+	It("deletes streams", func() {
+		streams["my-ns_orders"] = map[string]interface{}{"name": "my-ns_orders"}
+
+		stream, err := backend.DeleteStream(context.Background(), "my-ns", "orders")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stream).To(BeNil())
+		Expect(streams).To(BeEmpty())
+
+		_, err = backend.DeleteStream(context.Background(), "my-ns", "orders")
+
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusNotFound))
+	})
+
+	It("suggests retrying when JetStream is not enabled", func() {
+		mu.Lock()
+		disabled = true
+		mu.Unlock()
+
+		_, _, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders"})
+
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusServiceUnavailable))
+		Expect(err.(*handler.StatusError).RetryAfter).To(Equal(5 * time.Second))
+	})
+
+	It("suggests retrying when the server is unreachable", func() {
+		Expect(listener.Close()).To(Succeed())
+
+		_, err := backend.DescribeStream(context.Background(), "my-ns", "orders")
+
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("fails to connect without the credentials of the server", func() {
+		backend.Token = "wrong-token"
+
+		_, err := backend.DescribeStream(context.Background(), "my-ns", "orders")
+
+		Expect(err).To(MatchError(ContainSubstring("Authorization Violation")))
+	})
+
+	It("points clients to the server URL without its credentials by default", func() {
+		backend.Gateway, backend.Token = "", ""
+		backend.URL = "nats://some-token@" + listener.Addr().String()
+
+		stream, _, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders"})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stream.Gateway).To(Equal("nats://" + listener.Addr().String()))
+	})
+
+	It("points provisioning responses to the NATS server", func() {
+		requestHandler := &handler.TopicCreationRequestHandler{Backend: backend, Gateway: "unused:6565", Logger: backend.Logger}
+		responseRecorder := httptest.NewRecorder()
+
+		requestHandler.GetHandlerFunc().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPut, "/my-ns/orders", nil))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+		Expect(responseRecorder.Body.String()).To(MatchJSON(`{
+			"gateway": "nats://nats:4222",
+			"gateways": {"grpc": {"address": "nats://nats:4222", "tls": false}},
+			"topic": "riff.my-ns.orders",
+			"retention": {"ms": 604800000, "bytes": -1}
+		}`))
+	})
+})
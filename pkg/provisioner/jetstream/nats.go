@@ -0,0 +1,180 @@
+package jetstream
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errNoResponders is returned when no server answers the requests of the JetStream API, i.e. JetStream is disabled
+var errNoResponders = fmt.Errorf("no responders: JetStream is not enabled on the server")
+
+// request sends a request to a subject over a new connection to the server, and decodes its reply into result. It
+// speaks the few verbs of the NATS protocol that takes, so that no client library is needed for a handful of
+// provisioning requests.
+func (b *Backend) request(ctx context.Context, subject string, body interface{}, result interface{}) error {
+	server, err := url.Parse(b.URL)
+	if err != nil {
+		return err
+	}
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", server.Host)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(b.timeout())
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(conn)
+	line, err := readLine(reader)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected greeting from NATS server: %q", line)
+	}
+	info := struct {
+		TLSRequired bool `json:"tls_required"`
+	}{}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		return err
+	}
+	if server.Scheme == "tls" || info.TLSRequired {
+		config := &tls.Config{ServerName: server.Hostname()}
+		if b.TLS != nil {
+			config = b.TLS.Clone()
+		}
+		conn = tls.Client(conn, config)
+		reader = bufio.NewReader(conn)
+	}
+
+	var payload []byte
+	if body != nil {
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	connect, err := json.Marshal(b.connectOptions(server, info.TLSRequired))
+	if err != nil {
+		return err
+	}
+	inbox, err := newInbox()
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nSUB %s 1\r\nPUB %s %s %d\r\n%s\r\nPING\r\n", connect, inbox, subject, inbox, len(payload), payload); err != nil {
+		return err
+	}
+
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			return err
+		}
+		verb := strings.Fields(line)
+		if len(verb) == 0 {
+			continue
+		}
+		switch strings.ToUpper(verb[0]) {
+		case "PING":
+			if _, err := io.WriteString(conn, "PONG\r\n"); err != nil {
+				return err
+			}
+		case "-ERR":
+			return fmt.Errorf("NATS server returned an error: %s", strings.Trim(strings.TrimSpace(line[4:]), "'"))
+		case "MSG":
+			// MSG <subject> <sid> [reply-to] <size>
+			reply, err := readPayload(reader, verb[len(verb)-1])
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(reply, result)
+		case "HMSG":
+			// HMSG <subject> <sid> [reply-to] <header size> <total size>
+			reply, err := readPayload(reader, verb[len(verb)-1])
+			if err != nil {
+				return err
+			}
+			headerSize, err := strconv.Atoi(verb[len(verb)-2])
+			if err != nil || headerSize > len(reply) {
+				return fmt.Errorf("malformed message from NATS server: %q", line)
+			}
+			if strings.HasPrefix(string(reply[:headerSize]), "NATS/1.0 503") {
+				return errNoResponders
+			}
+			return json.Unmarshal(reply[headerSize:], result)
+		}
+		// +OK, PONG and INFO updates need no answer
+	}
+}
+
+// connectOptions returns the options of the CONNECT verb, authenticating with the credentials of the URL or the
+// token of the backend
+func (b *Backend) connectOptions(server *url.URL, tlsRequired bool) map[string]interface{} {
+	options := map[string]interface{}{
+		"verbose":       false,
+		"pedantic":      false,
+		"tls_required":  tlsRequired,
+		"name":          "kafka-provisioner",
+		"lang":          "go",
+		"version":       "1.0.0",
+		"protocol":      1,
+		"headers":       true,
+		"no_responders": true,
+	}
+	if server.User != nil {
+		if password, ok := server.User.Password(); ok {
+			options["user"], options["pass"] = server.User.Username(), password
+		} else {
+			options["auth_token"] = server.User.Username()
+		}
+	}
+	if b.Token != "" {
+		options["auth_token"] = b.Token
+	}
+	return options
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readPayload reads the payload of a message, and the CRLF following it
+func readPayload(reader *bufio.Reader, size string) ([]byte, error) {
+	n, err := strconv.Atoi(size)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("malformed message size from NATS server: %q", size)
+	}
+	payload := make([]byte, n+2)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, err
+	}
+	return payload[:n], nil
+}
+
+func newInbox() (string, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return "_INBOX." + hex.EncodeToString(id), nil
+}
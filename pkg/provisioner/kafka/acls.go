@@ -0,0 +1,68 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/Shopify/sarama"
+)
+
+func (kfc *kafkaClient) GrantTransactionalIDs(prefix, principal string) error {
+	controller, err := kfc.Admin.Controller()
+	if err != nil {
+		return err
+	}
+	response, err := controller.ApiVersions(&sarama.ApiVersionsRequest{})
+	if err != nil {
+		return err
+	}
+	versions, err := apiVersions(response)
+	if err != nil {
+		return err
+	}
+	if err := versions.Require(FeaturePrefixedACLs); err != nil {
+		return err
+	}
+	request := &sarama.CreateAclsRequest{Version: 1}
+	resource := sarama.Resource{
+		ResourceType:        sarama.AclResourceTransactionalID,
+		ResourceName:        prefix,
+		ResourcePatternType: sarama.AclPatternPrefixed,
+	}
+	for _, operation := range []sarama.AclOperation{sarama.AclOperationWrite, sarama.AclOperationDescribe} {
+		request.AclCreations = append(request.AclCreations, &sarama.AclCreation{
+			Resource: resource,
+			Acl:      sarama.Acl{Principal: principal, Host: "*", Operation: operation, PermissionType: sarama.AclPermissionAllow},
+		})
+	}
+	created, err := createACLs(controller.Addr(), kfc.config, request)
+	if err != nil {
+		return err
+	}
+	if len(created.AclCreationResponses) != len(request.AclCreations) {
+		return sarama.ErrIncompleteResponse
+	}
+	for _, creation := range created.AclCreationResponses {
+		if creation.Err == sarama.ErrNoError {
+			continue
+		}
+		if creation.ErrMsg != nil && *creation.ErrMsg != "" {
+			return fmt.Errorf("%w: %s", creation.Err, *creation.ErrMsg)
+		}
+		return creation.Err
+	}
+	return nil
+}
+
+// createACLs sends a CreateAcls request taking resource patterns on a connection of its own: sarama only sends it
+// to brokers configured as Kafka 2.0 or later, while the admin client is configured for older brokers, and its
+// CreateACLs ignores the errors of each ACL
+func createACLs(address string, config *sarama.Config, request *sarama.CreateAclsRequest) (*sarama.CreateAclsResponse, error) {
+	aclConfig := *config
+	aclConfig.Version = sarama.V2_0_0_0
+	broker := sarama.NewBroker(address)
+	if err := broker.Open(&aclConfig); err != nil {
+		return nil, err
+	}
+	defer broker.Close()
+	return broker.CreateAcls(request)
+}
@@ -1,30 +1,142 @@
 package client
 
 import (
+	"sort"
+	"sync"
+
 	"github.com/Shopify/sarama"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . KafkaClient
 type KafkaClient interface {
 	TopicExists(topicName string) (bool, *KafkaError)
-	CreateTopic(topicName string) error
+	CreateTopic(topicName string, spec TopicSpec) error
+	DeleteTopic(topicName string) error
+	// DeleteTopics deletes topics with a single request to the controller, returning the error of each topic, nil for
+	// those deleted
+	DeleteTopics(topicNames []string) (map[string]error, error)
+	// AlterTopicConfig replaces the config overrides of a topic
+	AlterTopicConfig(topicName string, configEntries map[string]*string) error
+	ListTopics() (map[string]TopicSpec, error)
+	// DescribeTopic returns the layout and config overrides of a topic, as ListTopics does, nil when it doesn't exist
+	DescribeTopic(topicName string) (*TopicSpec, error)
+	// Brokers returns the ids of the brokers of the cluster, sorted, from fresh metadata
+	Brokers() ([]int32, error)
+	// WriteMetadata records the metadata of a topic, replacing any recorded before
+	WriteMetadata(topicName string, metadata StreamMetadata) error
+	// DeleteMetadata deletes the metadata recorded for a topic
+	DeleteMetadata(topicName string) error
+	// ReadMetadata returns the metadata recorded for a topic, nil when none is
+	ReadMetadata(topicName string) (*StreamMetadata, error)
+	// ListMetadata returns the metadata recorded for all topics, by topic name
+	ListMetadata() (map[string]StreamMetadata, error)
+	// RecordDecision records the last provisioning decision about a topic in the journal topic
+	RecordDecision(topicName string, decision Decision) error
+	// ListDecisions returns the last provisioning decision recorded about each topic, by topic name
+	ListDecisions() (map[string]Decision, error)
+	// GroupProgress returns how far a consumer group got through each partition of a topic
+	GroupProgress(groupID, topicName string) ([]PartitionProgress, error)
+	// CommitGroupOffsets commits the offsets a consumer group without members starts consuming a topic from: those
+	// of the first records published at or after startAt, a timestamp in milliseconds, or of the oldest or next
+	// records with sarama.OffsetOldest or sarama.OffsetNewest. The offsets committed are returned, by partition.
+	CommitGroupOffsets(groupID, topicName string, startAt int64) (map[int32]int64, error)
+	// TopicHealth tells whether the partitions of a topic are available and fully replicated
+	TopicHealth(topicName string) (*TopicHealth, error)
+	// ElectPreferredLeaders elects the preferred replicas of the partitions of a topic as their leaders
+	ElectPreferredLeaders(topicName string) ([]LeaderElection, error)
+	// TopicConfigKeys returns the configs topics take on the cluster, nil when unknown
+	TopicConfigKeys() (TopicConfigKeys, error)
+	// BrokerConfig returns the value of a config of the controller broker, nil when it has none
+	BrokerConfig(name string) (*string, error)
+	// GrantTransactionalIDs allows a principal to use the transactional ids starting with a prefix
+	GrantTransactionalIDs(prefix, principal string) error
 	Close() error
 }
 
+// TopicSpec describes the layout and configuration of a topic
+type TopicSpec struct {
+	NumPartitions     int32              `json:"partitions"`
+	ReplicationFactor int16              `json:"replicationFactor"`
+	ConfigEntries     map[string]*string `json:"config,omitempty"`
+}
+
+// BrokerDefault as the partitions or replication factor of a spec lets the broker apply its num.partitions or
+// default.replication.factor, which requires Kafka 2.4
+const BrokerDefault = -1
+
+// PartitionProgress tells how far a consumer group got through a partition
+type PartitionProgress struct {
+	Partition int32 `json:"partition"`
+	// Start is the offset of the oldest record retained
+	Start int64 `json:"start"`
+	// End is the offset the next record will have
+	End int64 `json:"end"`
+	// Committed is the offset the group resumes from, -1 when it committed none
+	Committed int64 `json:"committed"`
+}
+
+// Lag returns the number of records the group has yet to consume
+func (p PartitionProgress) Lag() int64 {
+	next := p.Committed
+	if next < p.Start {
+		next = p.Start
+	}
+	if next >= p.End {
+		return 0
+	}
+	return p.End - next
+}
+
+// DefaultTopicSpec returns the spec of topics created for streams that do not ask for anything specific
+func DefaultTopicSpec() TopicSpec {
+	return TopicSpec{NumPartitions: 1, ReplicationFactor: 1}
+}
+
+// RecordOverhead is an allowance for the keys, headers and batch framing that come with payloads, so that
+// topics accept any payload the gateway accepts
+const RecordOverhead = 16 * 1024
+
+// MaxMessageBytes returns the max.message.bytes config of topics accepting payloads of up to maxPayloadBytes
+func MaxMessageBytes(maxPayloadBytes int) int {
+	return maxPayloadBytes + RecordOverhead
+}
+
 type kafkaClient struct {
 	Admin sarama.ClusterAdmin
+	// brokers and config connect the producers and consumers of the metadata topic
+	brokers []string
+	config  *sarama.Config
+	// listChunkSize and listConcurrency split listing topics into chunks described in parallel, when set
+	listChunkSize   int
+	listConcurrency int
+	// internalReplicationFactor is the replication factor of the metadata and journal topics
+	internalReplicationFactor int16
+
+	mu         sync.Mutex
+	configKeys TopicConfigKeys
 }
 
-func NewKafkaClient(brokerAddress string) (KafkaClient, error) {
+// NewKafkaClient connects to a Kafka cluster, tuning the connections to its brokers
+func NewKafkaClient(brokerAddress string, tuning Tuning) (KafkaClient, error) {
 	config := sarama.NewConfig()
 	config.Version = sarama.V0_11_0_0
 	config.ClientID = "kafka-provisioner"
+	tuning.Apply(config)
 	admin, err := sarama.NewClusterAdmin([]string{brokerAddress}, config)
 	if err != nil {
 		return nil, err
 	}
+	internalReplicationFactor := int16(tuning.InternalReplicationFactor)
+	if internalReplicationFactor == 0 {
+		internalReplicationFactor = BrokerDefault
+	}
 	return &kafkaClient{
-		Admin: admin,
+		Admin:                     admin,
+		brokers:                   []string{brokerAddress},
+		config:                    config,
+		listChunkSize:             tuning.ListChunkSize,
+		listConcurrency:           tuning.ListConcurrency,
+		internalReplicationFactor: internalReplicationFactor,
 	}, nil
 }
 
@@ -33,6 +145,13 @@ type KafkaError struct {
 	KError       sarama.KError
 }
 
+func (ke *KafkaError) Error() string {
+	if ke.GeneralError != nil {
+		return ke.GeneralError.Error()
+	}
+	return ke.KError.Error()
+}
+
 func (kfc *kafkaClient) TopicExists(topicName string) (bool, *KafkaError) {
 	metadata, err := kfc.Admin.DescribeTopics([]string{topicName})
 	if err != nil {
@@ -49,11 +168,192 @@ func (kfc *kafkaClient) TopicExists(topicName string) (bool, *KafkaError) {
 	return false, &KafkaError{KError: topicError}
 }
 
-func (kfc *kafkaClient) CreateTopic(topicName string) error {
-	topicDetail := sarama.TopicDetail{NumPartitions: 1, ReplicationFactor: 1}
+func (kfc *kafkaClient) CreateTopic(topicName string, spec TopicSpec) error {
+	topicDetail := sarama.TopicDetail{
+		NumPartitions:     spec.NumPartitions,
+		ReplicationFactor: spec.ReplicationFactor,
+		ConfigEntries:     spec.ConfigEntries,
+	}
+	if spec.NumPartitions == BrokerDefault || spec.ReplicationFactor == BrokerDefault {
+		return kfc.createTopicWithBrokerDefaults(topicName, &topicDetail)
+	}
 	return kfc.Admin.CreateTopic(topicName, &topicDetail, false)
 }
 
+// createTopicWithBrokerDefaults creates a topic letting the broker apply its defaults. sarama sends CreateTopics
+// requests up to version 2, laid out as those of version 4, the first letting brokers apply defaults.
+func (kfc *kafkaClient) createTopicWithBrokerDefaults(topicName string, topicDetail *sarama.TopicDetail) error {
+	controller, err := kfc.Admin.Controller()
+	if err != nil {
+		return err
+	}
+	response, err := controller.ApiVersions(&sarama.ApiVersionsRequest{})
+	if err != nil {
+		return err
+	}
+	versions, err := apiVersions(response)
+	if err != nil {
+		return err
+	}
+	if err := versions.Require(FeatureBrokerDefaults); err != nil {
+		return err
+	}
+	created, err := controller.CreateTopics(&sarama.CreateTopicsRequest{
+		Version:      4,
+		TopicDetails: map[string]*sarama.TopicDetail{topicName: topicDetail},
+		Timeout:      kfc.config.Admin.Timeout,
+	})
+	if err != nil {
+		return err
+	}
+	topicErr, ok := created.TopicErrors[topicName]
+	if !ok {
+		return sarama.ErrIncompleteResponse
+	}
+	if topicErr.Err != sarama.ErrNoError {
+		return topicErr
+	}
+	return nil
+}
+
+func (kfc *kafkaClient) DeleteTopic(topicName string) error {
+	return kfc.Admin.DeleteTopic(topicName)
+}
+
+func (kfc *kafkaClient) DeleteTopics(topicNames []string) (map[string]error, error) {
+	controller, err := kfc.Admin.Controller()
+	if err != nil {
+		return nil, err
+	}
+	request := &sarama.DeleteTopicsRequest{Topics: topicNames, Timeout: kfc.config.Admin.Timeout}
+	if kfc.config.Version.IsAtLeast(sarama.V0_11_0_0) {
+		request.Version = 1
+	}
+	deleted, err := controller.DeleteTopics(request)
+	if err != nil {
+		return nil, err
+	}
+	topicErrors := make(map[string]error, len(topicNames))
+	for _, topicName := range topicNames {
+		topicErr, ok := deleted.TopicErrorCodes[topicName]
+		switch {
+		case !ok:
+			topicErrors[topicName] = sarama.ErrIncompleteResponse
+		case topicErr != sarama.ErrNoError:
+			topicErrors[topicName] = topicErr
+		default:
+			topicErrors[topicName] = nil
+		}
+	}
+	return topicErrors, nil
+}
+
+func (kfc *kafkaClient) AlterTopicConfig(topicName string, configEntries map[string]*string) error {
+	return kfc.Admin.AlterConfig(sarama.TopicResource, topicName, configEntries, false)
+}
+
+func (kfc *kafkaClient) ListTopics() (map[string]TopicSpec, error) {
+	if kfc.listChunkSize > 0 {
+		return kfc.listTopicsInChunks()
+	}
+	details, err := kfc.Admin.ListTopics()
+	if err != nil {
+		return nil, err
+	}
+	topics := make(map[string]TopicSpec, len(details))
+	for name, detail := range details {
+		topics[name] = TopicSpec{
+			NumPartitions:     detail.NumPartitions,
+			ReplicationFactor: detail.ReplicationFactor,
+			ConfigEntries:     detail.ConfigEntries,
+		}
+	}
+	return topics, nil
+}
+
+func (kfc *kafkaClient) DescribeTopic(topicName string) (*TopicSpec, error) {
+	metadata, err := kfc.Admin.DescribeTopics([]string{topicName})
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata) == 0 || metadata[0].Err == sarama.ErrUnknownTopicOrPartition {
+		return nil, nil
+	}
+	if metadata[0].Err != sarama.ErrNoError {
+		return nil, metadata[0].Err
+	}
+	spec := &TopicSpec{NumPartitions: int32(len(metadata[0].Partitions))}
+	if len(metadata[0].Partitions) > 0 {
+		spec.ReplicationFactor = int16(len(metadata[0].Partitions[0].Replicas))
+	}
+	entries, err := kfc.Admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topicName})
+	if err != nil {
+		return nil, err
+	}
+	spec.ConfigEntries = make(map[string]*string)
+	for _, entry := range entries {
+		// as ListTopics does, only overrides are kept
+		if entry.Default || entry.Sensitive {
+			continue
+		}
+		value := entry.Value
+		spec.ConfigEntries[entry.Name] = &value
+	}
+	return spec, nil
+}
+
+func (kfc *kafkaClient) Brokers() ([]int32, error) {
+	brokers, _, err := kfc.Admin.DescribeCluster()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int32, len(brokers))
+	for i, broker := range brokers {
+		ids[i] = broker.ID()
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (kfc *kafkaClient) GroupProgress(groupID, topicName string) ([]PartitionProgress, error) {
+	kafka, err := sarama.NewClient(kfc.brokers, kfc.config)
+	if err != nil {
+		return nil, err
+	}
+	defer kafka.Close()
+	partitions, err := kafka.Partitions(topicName)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := kfc.Admin.ListConsumerGroupOffsets(groupID, map[string][]int32{topicName: partitions})
+	if err != nil {
+		return nil, err
+	}
+	progress := make([]PartitionProgress, 0, len(partitions))
+	for _, partition := range partitions {
+		start, err := kafka.GetOffset(topicName, partition, sarama.OffsetOldest)
+		if err != nil {
+			return nil, err
+		}
+		end, err := kafka.GetOffset(topicName, partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, err
+		}
+		committed := int64(-1)
+		if block := offsets.GetBlock(topicName, partition); block != nil {
+			if block.Err != sarama.ErrNoError {
+				return nil, block.Err
+			}
+			committed = block.Offset
+		}
+		progress = append(progress, PartitionProgress{Partition: partition, Start: start, End: end, Committed: committed})
+	}
+	sort.Slice(progress, func(i, j int) bool {
+		return progress[i].Partition < progress[j].Partition
+	})
+	return progress, nil
+}
+
 func (kfc *kafkaClient) Close() error {
 	return kfc.Admin.Close()
 }
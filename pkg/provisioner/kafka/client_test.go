@@ -1,6 +1,8 @@
 package client_test
 
 import (
+	"time"
+
 	"github.com/Shopify/sarama"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -37,6 +39,16 @@ var _ = Describe("Kafka Client", func() {
 			Expect(kafkaError).To(BeNil())
 			Expect(topicExists).To(BeTrue(), "Expected topic to exist")
 		})
+
+		It("reports the health of the partitions of the topic", func() {
+			health, err := kafkaClient.TopicHealth("some-topic")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(health).To(Equal(&client.TopicHealth{
+				Status:     client.Healthy,
+				Partitions: []client.PartitionHealth{{Partition: 0, Leader: broker.BrokerID(), Replicas: 1, InSync: 1}},
+			}))
+		})
 	})
 
 	Describe("creating topic", func() {
@@ -52,16 +64,516 @@ var _ = Describe("Kafka Client", func() {
 		})
 
 		It("succeeds when the topic has not been created before", func() {
-			err := kafkaClient.CreateTopic("some-topic")
+			err := kafkaClient.CreateTopic("some-topic", client.DefaultTopicSpec())
+
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("gives the controller the admin timeout to create the topic", func() {
+			Expect(kafkaClient.Close()).To(Succeed())
+			var err error
+			kafkaClient, err = client.NewKafkaClient(broker.Addr(), client.Tuning{AdminTimeout: 42 * time.Second})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(kafkaClient.CreateTopic("some-topic", client.DefaultTopicSpec())).To(Succeed())
+
+			var timeout time.Duration
+			for _, exchange := range broker.History() {
+				if request, ok := exchange.Request.(*sarama.CreateTopicsRequest); ok {
+					timeout = request.Timeout
+				}
+			}
+			Expect(timeout).To(Equal(42 * time.Second))
+		})
+
+		It("lets the broker apply its defaults", func() {
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+					SetController(broker.BrokerID()).
+					SetBroker(broker.Addr(), broker.BrokerID()),
+				"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(GinkgoT()).SetApiKeys([]sarama.ApiVersionsResponseKey{
+					{ApiKey: 18, MinVersion: 0, MaxVersion: 3},
+					{ApiKey: 19, MinVersion: 0, MaxVersion: 5},
+				}),
+				"CreateTopicsRequest": sarama.NewMockCreateTopicsResponse(GinkgoT()),
+			})
+
+			err := kafkaClient.CreateTopic("some-topic", client.TopicSpec{NumPartitions: client.BrokerDefault, ReplicationFactor: client.BrokerDefault})
+
+			Expect(err).NotTo(HaveOccurred())
+			var request *sarama.CreateTopicsRequest
+			for _, exchange := range broker.History() {
+				if r, ok := exchange.Request.(*sarama.CreateTopicsRequest); ok {
+					request = r
+				}
+			}
+			Expect(request).NotTo(BeNil())
+			Expect(request.Version).To(Equal(int16(4)))
+			Expect(request.TopicDetails["some-topic"].NumPartitions).To(Equal(int32(client.BrokerDefault)))
+			Expect(request.TopicDetails["some-topic"].ReplicationFactor).To(Equal(int16(client.BrokerDefault)))
+		})
+
+		It("refuses to let brokers older than Kafka 2.4 apply their defaults", func() {
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+					SetController(broker.BrokerID()).
+					SetBroker(broker.Addr(), broker.BrokerID()),
+				"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(GinkgoT()).SetApiKeys([]sarama.ApiVersionsResponseKey{
+					{ApiKey: 18, MinVersion: 0, MaxVersion: 2},
+					{ApiKey: 19, MinVersion: 0, MaxVersion: 3},
+				}),
+				"CreateTopicsRequest": sarama.NewMockCreateTopicsResponse(GinkgoT()),
+			})
+
+			err := kafkaClient.CreateTopic("some-topic", client.TopicSpec{NumPartitions: 3, ReplicationFactor: client.BrokerDefault})
+
+			Expect(err).To(BeAssignableToTypeOf(&client.UnsupportedFeatureError{}))
+			Expect(client.Classify(err)).To(Equal(client.Terminal))
+		})
+	})
+
+	Describe("deleting topics", func() {
+		BeforeEach(func() {
+			broker = sarama.NewMockBroker(GinkgoT(), int32(1))
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+					SetController(broker.BrokerID()).
+					SetBroker(broker.Addr(), broker.BrokerID()),
+				"DeleteTopicsRequest": sarama.NewMockDeleteTopicsResponse(GinkgoT()),
+			})
+			kafkaClient = newKafkaClient(broker)
+		})
+
+		It("deletes all the topics with a single request to the controller", func() {
+			topicErrors, err := kafkaClient.DeleteTopics([]string{"ns_orders", "ns_payments"})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(topicErrors).To(Equal(map[string]error{"ns_orders": nil, "ns_payments": nil}))
+			var requests []*sarama.DeleteTopicsRequest
+			for _, exchange := range broker.History() {
+				if request, ok := exchange.Request.(*sarama.DeleteTopicsRequest); ok {
+					requests = append(requests, request)
+				}
+			}
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Topics).To(Equal([]string{"ns_orders", "ns_payments"}))
+		})
+	})
+
+	Describe("listing topics", func() {
+		BeforeEach(func() {
+			broker = sarama.NewMockBroker(GinkgoT(), int32(1))
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+					SetController(broker.BrokerID()).
+					SetBroker(broker.Addr(), broker.BrokerID()).
+					SetLeader("some-topic", 0, broker.BrokerID()).
+					SetLeader("some-topic", 1, broker.BrokerID()),
+				"DescribeConfigsRequest": sarama.NewMockDescribeConfigsResponse(GinkgoT()),
+			})
+			kafkaClient = newKafkaClient(broker)
+		})
+
+		It("reports the partitions of each topic", func() {
+			topics, err := kafkaClient.ListTopics()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(topics).To(HaveKey("some-topic"))
+			Expect(topics["some-topic"].NumPartitions).To(Equal(int32(2)))
+		})
+
+		It("describes the configs of topics by chunks, as a single request would", func() {
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+					SetController(broker.BrokerID()).
+					SetBroker(broker.Addr(), broker.BrokerID()).
+					SetLeader("ns_a", 0, broker.BrokerID()).
+					SetLeader("ns_b", 0, broker.BrokerID()).
+					SetLeader("ns_b", 1, broker.BrokerID()).
+					SetLeader("ns_c", 0, broker.BrokerID()),
+				"DescribeConfigsRequest": sarama.NewMockDescribeConfigsResponse(GinkgoT()),
+			})
+			expected, err := kafkaClient.ListTopics()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kafkaClient.Close()).To(Succeed())
+			kafkaClient, err = client.NewKafkaClient(broker.Addr(), client.Tuning{ListChunkSize: 2, ListConcurrency: 2})
+			Expect(err).NotTo(HaveOccurred())
+			before := describeConfigsRequests(broker)
+
+			topics, err := kafkaClient.ListTopics()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(topics).To(Equal(expected))
+			Expect(topics["ns_b"].NumPartitions).To(Equal(int32(2)))
+			Expect(*topics["ns_c"].ConfigEntries["retention.ms"]).To(Equal("5000"))
+			Expect(describeConfigsRequests(broker) - before).To(Equal(2))
+		})
+
+		It("describes a single topic as listing topics would", func() {
+			topics, err := kafkaClient.ListTopics()
+			Expect(err).NotTo(HaveOccurred())
+			expected := topics["some-topic"]
+
+			spec, err := kafkaClient.DescribeTopic("some-topic")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spec).To(Equal(&expected))
+		})
+
+		It("describes nothing for topics that don't exist", func() {
+			spec, err := kafkaClient.DescribeTopic("unknown-topic")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spec).To(BeNil())
+		})
+
+		It("lists the brokers of the cluster", func() {
+			brokers, err := kafkaClient.Brokers()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(brokers).To(Equal([]int32{broker.BrokerID()}))
+		})
+
+		It("describes the configs topics take, and the kind of their values", func() {
+			keys, err := kafkaClient.TopicConfigKeys()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys).To(Equal(client.TopicConfigKeys{
+				"max.message.bytes": {Kind: client.ConfigInteger},
+				"retention.ms":      {Kind: client.ConfigInteger},
+				"password":          {Kind: client.ConfigString},
+			}))
+		})
+
+		It("describes the configs of the controller broker", func() {
+			value, err := kafkaClient.BrokerConfig("min.insync.replicas")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*value).To(Equal("2"))
+
+			value, err = kafkaClient.BrokerConfig("message.max.bytes")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(BeNil())
+		})
+	})
+
+	Describe("granting transactional ids", func() {
+		BeforeEach(func() {
+			broker = sarama.NewMockBroker(GinkgoT(), int32(1))
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+					SetController(broker.BrokerID()).
+					SetBroker(broker.Addr(), broker.BrokerID()),
+				"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(GinkgoT()).SetApiKeys([]sarama.ApiVersionsResponseKey{
+					{ApiKey: 18, MinVersion: 0, MaxVersion: 3},
+					{ApiKey: 30, MinVersion: 0, MaxVersion: 1},
+				}),
+				"CreateAclsRequest": sarama.NewMockCreateAclsResponse(GinkgoT()),
+			})
+			kafkaClient = newKafkaClient(broker)
+		})
+
+		It("allows the principal to write and describe the transactional ids starting with the prefix", func() {
+			Expect(kafkaClient.GrantTransactionalIDs("ns_billing_", "User:billing")).To(Succeed())
+
+			var request *sarama.CreateAclsRequest
+			for _, exchange := range broker.History() {
+				if r, ok := exchange.Request.(*sarama.CreateAclsRequest); ok {
+					request = r
+				}
+			}
+			Expect(request).NotTo(BeNil())
+			Expect(request.Version).To(Equal(int16(1)))
+			var operations []sarama.AclOperation
+			for _, creation := range request.AclCreations {
+				Expect(creation.Resource).To(Equal(sarama.Resource{
+					ResourceType:        sarama.AclResourceTransactionalID,
+					ResourceName:        "ns_billing_",
+					ResourcePatternType: sarama.AclPatternPrefixed,
+				}))
+				Expect(creation.Acl.Principal).To(Equal("User:billing"))
+				Expect(creation.Acl.PermissionType).To(Equal(sarama.AclPermissionAllow))
+				operations = append(operations, creation.Acl.Operation)
+			}
+			Expect(operations).To(Equal([]sarama.AclOperation{sarama.AclOperationWrite, sarama.AclOperationDescribe}))
+		})
+
+		It("reports the ACLs the broker failed to create", func() {
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+					SetController(broker.BrokerID()).
+					SetBroker(broker.Addr(), broker.BrokerID()),
+				"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(GinkgoT()).SetApiKeys([]sarama.ApiVersionsResponseKey{
+					{ApiKey: 18, MinVersion: 0, MaxVersion: 3},
+					{ApiKey: 30, MinVersion: 0, MaxVersion: 1},
+				}),
+				"CreateAclsRequest": sarama.NewMockCreateAclsResponseWithError(GinkgoT()),
+			})
+
+			Expect(kafkaClient.GrantTransactionalIDs("ns_billing_", "User:billing")).To(MatchError(sarama.ErrInvalidRequest))
+		})
+
+		It("refuses to grant prefixes on brokers older than Kafka 2.0", func() {
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+					SetController(broker.BrokerID()).
+					SetBroker(broker.Addr(), broker.BrokerID()),
+				"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(GinkgoT()).SetApiKeys([]sarama.ApiVersionsResponseKey{
+					{ApiKey: 18, MinVersion: 0, MaxVersion: 1},
+					{ApiKey: 30, MinVersion: 0, MaxVersion: 0},
+				}),
+			})
+
+			err := kafkaClient.GrantTransactionalIDs("ns_billing_", "User:billing")
+
+			Expect(err).To(BeAssignableToTypeOf(&client.UnsupportedFeatureError{}))
+		})
+	})
+
+	Describe("committing the offsets of consumer groups", func() {
+		var offsetCommit *sarama.MockOffsetCommitResponse
+
+		BeforeEach(func() {
+			broker = sarama.NewMockBroker(GinkgoT(), int32(1))
+			offsetCommit = sarama.NewMockOffsetCommitResponse(GinkgoT())
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+					SetController(broker.BrokerID()).
+					SetBroker(broker.Addr(), broker.BrokerID()).
+					SetLeader("ns_orders", 0, broker.BrokerID()).
+					SetLeader("ns_orders", 1, broker.BrokerID()),
+				"OffsetRequest": sarama.NewMockOffsetResponse(GinkgoT()).
+					SetOffset("ns_orders", 0, sarama.OffsetOldest, 3).
+					SetOffset("ns_orders", 1, sarama.OffsetOldest, 5).
+					SetOffset("ns_orders", 0, sarama.OffsetNewest, 10).
+					SetOffset("ns_orders", 1, sarama.OffsetNewest, 12).
+					SetOffset("ns_orders", 0, 1767225600000, 8).
+					SetOffset("ns_orders", 1, 1767225600000, -1),
+				"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(GinkgoT()).
+					SetCoordinator(sarama.CoordinatorGroup, "billing", broker),
+				"OffsetCommitRequest": offsetCommit,
+			})
+			kafkaClient = newKafkaClient(broker)
+		})
+
+		committed := func() map[int32]int64 {
+			offsets := map[int32]int64{}
+			for _, exchange := range broker.History() {
+				if request, ok := exchange.Request.(*sarama.OffsetCommitRequest); ok {
+					Expect(request.ConsumerGroup).To(Equal("billing"))
+					for _, partition := range []int32{0, 1} {
+						offset, _, err := request.Offset("ns_orders", partition)
+						Expect(err).NotTo(HaveOccurred())
+						offsets[partition] = offset
+					}
+				}
+			}
+			return offsets
+		}
+
+		It("starts groups from the oldest records retained", func() {
+			offsets, err := kafkaClient.CommitGroupOffsets("billing", "ns_orders", sarama.OffsetOldest)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(offsets).To(Equal(map[int32]int64{0: 3, 1: 5}))
+			Expect(committed()).To(Equal(offsets))
+		})
+
+		It("starts groups from the first records published at a timestamp, or the next ones", func() {
+			offsets, err := kafkaClient.CommitGroupOffsets("billing", "ns_orders", 1767225600000)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(offsets).To(Equal(map[int32]int64{0: 8, 1: 12}))
+		})
+
+		It("reports the offsets the coordinator refused", func() {
+			offsetCommit.SetError("billing", "ns_orders", 1, sarama.ErrUnknownMemberId)
+
+			_, err := kafkaClient.CommitGroupOffsets("billing", "ns_orders", sarama.OffsetNewest)
+
+			Expect(err).To(MatchError(sarama.ErrUnknownMemberId))
+		})
+	})
+
+	Describe("recording stream metadata", func() {
+		BeforeEach(func() {
+			broker = sarama.NewMockBroker(GinkgoT(), int32(1))
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+					SetController(broker.BrokerID()).
+					SetBroker(broker.Addr(), broker.BrokerID()).
+					SetLeader(client.MetadataTopic, 0, broker.BrokerID()),
+				"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(GinkgoT()).SetApiKeys([]sarama.ApiVersionsResponseKey{
+					{ApiKey: 18, MinVersion: 0, MaxVersion: 3},
+					{ApiKey: 19, MinVersion: 0, MaxVersion: 5},
+				}),
+				"CreateTopicsRequest": sarama.NewMockCreateTopicsResponse(GinkgoT()),
+				"ProduceRequest":      sarama.NewMockProduceResponse(GinkgoT()).SetVersion(3),
+				"OffsetRequest": sarama.NewMockOffsetResponse(GinkgoT()).
+					SetOffset(client.MetadataTopic, 0, sarama.OffsetOldest, 0).
+					SetOffset(client.MetadataTopic, 0, sarama.OffsetNewest, 3),
+				"FetchRequest": sarama.NewMockFetchResponse(GinkgoT(), 1).
+					SetMessageWithKey(client.MetadataTopic, 0, 0, sarama.StringEncoder("ns_orders"), sarama.StringEncoder(`{"contentType": "text/plain"}`)).
+					SetMessageWithKey(client.MetadataTopic, 0, 1, sarama.StringEncoder("ns_orders"), sarama.StringEncoder(`{"contentType": "application/json", "labels": {"team": "orders"}}`)).
+					SetMessageWithKey(client.MetadataTopic, 0, 2, sarama.StringEncoder("ns_other"), sarama.StringEncoder(`{"contentType": "text/plain"}`)).
+					SetHighWaterMark(client.MetadataTopic, 0, 3),
+			})
+			kafkaClient = newKafkaClient(broker)
+		})
+
+		It("publishes the metadata to the compacted metadata topic", func() {
+			err := kafkaClient.WriteMetadata("ns_orders", client.StreamMetadata{ContentType: "application/json"})
+
+			Expect(err).NotTo(HaveOccurred())
+			var created, produced bool
+			for _, exchange := range broker.History() {
+				switch request := exchange.Request.(type) {
+				case *sarama.CreateTopicsRequest:
+					detail := request.TopicDetails[client.MetadataTopic]
+					Expect(detail).NotTo(BeNil())
+					Expect(*detail.ConfigEntries["cleanup.policy"]).To(Equal("compact"))
+					Expect(detail.ReplicationFactor).To(Equal(int16(client.BrokerDefault)))
+					created = true
+				case *sarama.ProduceRequest:
+					produced = true
+				}
+			}
+			Expect(created).To(BeTrue())
+			Expect(produced).To(BeTrue())
+		})
+
+		It("reads the last metadata recorded for a topic", func() {
+			metadata, err := kafkaClient.ReadMetadata("ns_orders")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata).To(Equal(&client.StreamMetadata{ContentType: "application/json", Labels: map[string]string{"team": "orders"}}))
+		})
+
+		It("reads nothing for topics without metadata", func() {
+			metadata, err := kafkaClient.ReadMetadata("ns_unknown")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata).To(BeNil())
+		})
+
+		It("reads only the records produced since the last read", func() {
+			_, err := kafkaClient.ReadMetadata("ns_orders")
+			Expect(err).NotTo(HaveOccurred())
+			fetches := fetchRequests(broker)
+			Expect(fetches).To(BeNumerically(">", 0))
+
+			other := newKafkaClient(broker)
+			defer other.Close()
+			metadata, err := other.ListMetadata()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata).To(HaveLen(2))
+			Expect(fetchRequests(broker)).To(Equal(fetches))
+		})
+
+		It("lists the last metadata recorded for all topics", func() {
+			metadata, err := kafkaClient.ListMetadata()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata).To(Equal(map[string]client.StreamMetadata{
+				"ns_orders": {ContentType: "application/json", Labels: map[string]string{"team": "orders"}},
+				"ns_other":  {ContentType: "text/plain"},
+			}))
+		})
+	})
+
+	Describe("journaling provisioning decisions", func() {
+		BeforeEach(func() {
+			broker = sarama.NewMockBroker(GinkgoT(), int32(1))
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+					SetController(broker.BrokerID()).
+					SetBroker(broker.Addr(), broker.BrokerID()).
+					SetLeader(client.JournalTopic, 0, broker.BrokerID()),
+				"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(GinkgoT()).SetApiKeys([]sarama.ApiVersionsResponseKey{
+					{ApiKey: 18, MinVersion: 0, MaxVersion: 3},
+					{ApiKey: 19, MinVersion: 0, MaxVersion: 5},
+				}),
+				"CreateTopicsRequest": sarama.NewMockCreateTopicsResponse(GinkgoT()),
+				"ProduceRequest":      sarama.NewMockProduceResponse(GinkgoT()).SetVersion(3),
+				"OffsetRequest": sarama.NewMockOffsetResponse(GinkgoT()).
+					SetOffset(client.JournalTopic, 0, sarama.OffsetOldest, 0).
+					SetOffset(client.JournalTopic, 0, sarama.OffsetNewest, 2).
+					SetOffset(client.MetadataTopic, 0, sarama.OffsetOldest, 0).
+					SetOffset(client.MetadataTopic, 0, sarama.OffsetNewest, 0),
+				"FetchRequest": sarama.NewMockFetchResponse(GinkgoT(), 1).
+					SetMessageWithKey(client.JournalTopic, 0, 0, sarama.StringEncoder("ns_orders"), sarama.StringEncoder(`{"action": "provisioned", "at": "2026-10-15T09:00:00Z", "spec": {"partitions": 3, "replicationFactor": 1}}`)).
+					SetMessageWithKey(client.JournalTopic, 0, 1, sarama.StringEncoder("ns_orders"), sarama.StringEncoder(`{"action": "deleted", "at": "2026-10-15T10:00:00Z"}`)).
+					SetHighWaterMark(client.JournalTopic, 0, 2),
+			})
+			kafkaClient = newKafkaClient(broker)
+		})
+
+		It("records decisions in the compacted journal topic", func() {
+			err := kafkaClient.RecordDecision("ns_orders", client.Decision{Action: client.DecisionProvisioned, At: time.Now()})
 
 			Expect(err).NotTo(HaveOccurred())
+			var created, produced bool
+			for _, exchange := range broker.History() {
+				switch request := exchange.Request.(type) {
+				case *sarama.CreateTopicsRequest:
+					detail := request.TopicDetails[client.JournalTopic]
+					Expect(detail).NotTo(BeNil())
+					Expect(*detail.ConfigEntries["cleanup.policy"]).To(Equal("compact"))
+					Expect(detail.ReplicationFactor).To(Equal(int16(client.BrokerDefault)))
+					created = true
+				case *sarama.ProduceRequest:
+					produced = true
+				}
+			}
+			Expect(created).To(BeTrue())
+			Expect(produced).To(BeTrue())
+		})
+
+		It("lists the last decision recorded about each topic", func() {
+			decisions, err := kafkaClient.ListDecisions()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decisions).To(Equal(map[string]client.Decision{
+				"ns_orders": {Action: client.DecisionDeleted, At: time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC)},
+			}))
+		})
+
+		It("keeps the metadata of streams apart", func() {
+			metadata, err := kafkaClient.ListMetadata()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata).To(BeEmpty())
 		})
 	})
 
 })
 
+// describeConfigsRequests counts the DescribeConfigs requests a broker received
+func describeConfigsRequests(broker *sarama.MockBroker) int {
+	count := 0
+	for _, exchange := range broker.History() {
+		if _, ok := exchange.Request.(*sarama.DescribeConfigsRequest); ok {
+			count++
+		}
+	}
+	return count
+}
+
+// fetchRequests counts the Fetch requests a broker received
+func fetchRequests(broker *sarama.MockBroker) int {
+	count := 0
+	for _, exchange := range broker.History() {
+		if _, ok := exchange.Request.(*sarama.FetchRequest); ok {
+			count++
+		}
+	}
+	return count
+}
+
 func newKafkaClient(broker *sarama.MockBroker) client.KafkaClient {
-	kClient, err := client.NewKafkaClient(broker.Addr())
+	kClient, err := client.NewKafkaClient(broker.Addr(), client.Tuning{})
 	Expect(err).NotTo(HaveOccurred())
 	return kClient
 }
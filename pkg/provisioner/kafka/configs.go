@@ -0,0 +1,118 @@
+package client
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+// ConfigKind is the kind of values a topic config takes
+type ConfigKind string
+
+const (
+	ConfigString  ConfigKind = "string"
+	ConfigBoolean ConfigKind = "boolean"
+	ConfigInteger ConfigKind = "integer"
+	ConfigNumber  ConfigKind = "number"
+)
+
+// ConfigKey describes a config topics take
+type ConfigKey struct {
+	// ReadOnly configs can't be set on topics
+	ReadOnly bool
+	// Kind is the kind of values the config takes, told by the value the broker gives topics not setting it, as
+	// DescribeConfigs only tells the types of configs from Kafka 2.6
+	Kind ConfigKind
+}
+
+// Accepts tells whether the config takes a value
+func (k ConfigKey) Accepts(value string) bool {
+	switch k.Kind {
+	case ConfigBoolean:
+		return strings.EqualFold(value, "true") || strings.EqualFold(value, "false")
+	case ConfigInteger:
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case ConfigNumber:
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	}
+	return true
+}
+
+// TopicConfigKeys are the configs topics take on a cluster, by name
+type TopicConfigKeys map[string]ConfigKey
+
+func configKind(value string) ConfigKind {
+	if value == "true" || value == "false" {
+		return ConfigBoolean
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ConfigInteger
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return ConfigNumber
+	}
+	return ConfigString
+}
+
+// TopicConfigKeys describes the configs of a topic of the cluster, which tells all those topics take. The keys are
+// cached once known, as they only change when brokers are upgraded. They are unknown, and nil, while the cluster
+// has no topics.
+func (kfc *kafkaClient) TopicConfigKeys() (TopicConfigKeys, error) {
+	kfc.mu.Lock()
+	defer kfc.mu.Unlock()
+	if kfc.configKeys != nil {
+		return kfc.configKeys, nil
+	}
+	kafka, err := sarama.NewClient(kfc.brokers, kfc.config)
+	if err != nil {
+		return nil, err
+	}
+	defer kafka.Close()
+	topics, err := kafka.Topics()
+	if err != nil {
+		return nil, err
+	}
+	if len(topics) == 0 {
+		return nil, nil
+	}
+	sort.Strings(topics)
+	entries, err := kfc.Admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topics[0]})
+	if err != nil {
+		return nil, err
+	}
+	keys := make(TopicConfigKeys, len(entries))
+	for _, entry := range entries {
+		kind := ConfigString
+		if !entry.Sensitive {
+			kind = configKind(entry.Value)
+		}
+		keys[entry.Name] = ConfigKey{ReadOnly: entry.ReadOnly, Kind: kind}
+	}
+	kfc.configKeys = keys
+	return keys, nil
+}
+
+func (kfc *kafkaClient) BrokerConfig(name string) (*string, error) {
+	controller, err := kfc.Admin.Controller()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := kfc.Admin.DescribeConfig(sarama.ConfigResource{
+		Type:        sarama.BrokerResource,
+		Name:        strconv.Itoa(int(controller.ID())),
+		ConfigNames: []string{name},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Name == name {
+			return &entry.Value, nil
+		}
+	}
+	return nil, nil
+}
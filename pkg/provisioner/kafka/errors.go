@@ -0,0 +1,93 @@
+package client
+
+import (
+	"net"
+
+	"github.com/Shopify/sarama"
+)
+
+// ErrorClass tells whether retrying a failed operation may succeed
+type ErrorClass int
+
+const (
+	// Unclassified errors are neither known to be transient nor known to be permanent
+	Unclassified ErrorClass = iota
+	// Retryable errors are transient: the same request may succeed later
+	Retryable
+	// Terminal errors are caused by the request itself, which will keep failing
+	Terminal
+)
+
+var retryableErrors = map[sarama.KError]bool{
+	sarama.ErrLeaderNotAvailable:              true,
+	sarama.ErrNotLeaderForPartition:           true,
+	sarama.ErrRequestTimedOut:                 true,
+	sarama.ErrBrokerNotAvailable:              true,
+	sarama.ErrReplicaNotAvailable:             true,
+	sarama.ErrNetworkException:                true,
+	sarama.ErrOffsetsLoadInProgress:           true,
+	sarama.ErrConsumerCoordinatorNotAvailable: true,
+	sarama.ErrNotCoordinatorForConsumer:       true,
+	sarama.ErrNotEnoughReplicas:               true,
+	sarama.ErrNotEnoughReplicasAfterAppend:    true,
+	sarama.ErrRebalanceInProgress:             true,
+	sarama.ErrNotController:                   true,
+	sarama.ErrConcurrentTransactions:          true,
+	sarama.ErrKafkaStorageError:               true,
+	sarama.ErrReassignmentInProgress:          true,
+	sarama.ErrPreferredLeaderNotAvailable:     true,
+}
+
+var terminalErrors = map[sarama.KError]bool{
+	sarama.ErrInvalidTopic:                       true,
+	sarama.ErrMessageSizeTooLarge:                true,
+	sarama.ErrTopicAuthorizationFailed:           true,
+	sarama.ErrClusterAuthorizationFailed:         true,
+	sarama.ErrUnsupportedVersion:                 true,
+	sarama.ErrInvalidPartitions:                  true,
+	sarama.ErrInvalidReplicationFactor:           true,
+	sarama.ErrInvalidReplicaAssignment:           true,
+	sarama.ErrInvalidConfig:                      true,
+	sarama.ErrInvalidRequest:                     true,
+	sarama.ErrPolicyViolation:                    true,
+	sarama.ErrTopicDeletionDisabled:              true,
+	sarama.ErrUnsupportedCompressionType:         true,
+	sarama.ErrSASLAuthenticationFailed:           true,
+	sarama.ErrUnsupportedForMessageFormat:        true,
+	sarama.ErrGroupAuthorizationFailed:           true,
+	sarama.ErrNonEmptyGroup:                      true,
+	sarama.ErrGroupIDNotFound:                    true,
+	sarama.ErrTransactionalIDAuthorizationFailed: true,
+}
+
+// Classify tells whether the error returned by a KafkaClient operation is worth retrying
+func Classify(err error) ErrorClass {
+	switch e := err.(type) {
+	case nil:
+		return Unclassified
+	case *KafkaError:
+		if e.GeneralError != nil {
+			return Classify(e.GeneralError)
+		}
+		return Classify(e.KError)
+	case *sarama.TopicError:
+		return Classify(e.Err)
+	case sarama.KError:
+		if retryableErrors[e] {
+			return Retryable
+		}
+		if terminalErrors[e] {
+			return Terminal
+		}
+		return Unclassified
+	case net.Error:
+		return Retryable
+	case *UnsupportedFeatureError:
+		return Terminal
+	}
+	switch err {
+	case sarama.ErrOutOfBrokers, sarama.ErrNotConnected, sarama.ErrControllerNotAvailable, sarama.ErrIncompleteResponse:
+		return Retryable
+	}
+	return Unclassified
+}
@@ -0,0 +1,31 @@
+package client_test
+
+import (
+	"errors"
+	"net"
+
+	"github.com/Shopify/sarama"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+var _ = Describe("Kafka error classification", func() {
+
+	DescribeTable("classifies errors",
+		func(err error, class client.ErrorClass) {
+			Expect(client.Classify(err)).To(Equal(class))
+		},
+		Entry("not controller", sarama.ErrNotController, client.Retryable),
+		Entry("request timed out", sarama.ErrRequestTimedOut, client.Retryable),
+		Entry("invalid partitions", sarama.ErrInvalidPartitions, client.Terminal),
+		Entry("policy violation", sarama.ErrPolicyViolation, client.Terminal),
+		Entry("unknown server error", sarama.ErrUnknown, client.Unclassified),
+		Entry("topic errors", &sarama.TopicError{Err: sarama.ErrInvalidReplicationFactor}, client.Terminal),
+		Entry("kafka error codes", &client.KafkaError{KError: sarama.ErrNotController}, client.Retryable),
+		Entry("kafka general errors", &client.KafkaError{GeneralError: sarama.ErrOutOfBrokers}, client.Retryable),
+		Entry("network errors", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, client.Retryable),
+		Entry("other errors", errors.New("oopsie"), client.Unclassified),
+	)
+})
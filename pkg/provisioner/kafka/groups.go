@@ -0,0 +1,55 @@
+package client
+
+import (
+	"github.com/Shopify/sarama"
+)
+
+func (kfc *kafkaClient) CommitGroupOffsets(groupID, topicName string, startAt int64) (map[int32]int64, error) {
+	kafka, err := sarama.NewClient(kfc.brokers, kfc.config)
+	if err != nil {
+		return nil, err
+	}
+	defer kafka.Close()
+	partitions, err := kafka.Partitions(topicName)
+	if err != nil {
+		return nil, err
+	}
+	offsets := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		offset, err := kafka.GetOffset(topicName, partition, startAt)
+		if err == nil && offset < 0 {
+			// no record was published since the timestamp, consume the next ones
+			offset, err = kafka.GetOffset(topicName, partition, sarama.OffsetNewest)
+		}
+		if err != nil {
+			return nil, err
+		}
+		offsets[partition] = offset
+	}
+	coordinator, err := kafka.Coordinator(groupID)
+	if err != nil {
+		return nil, err
+	}
+	// the coordinator accepts offsets committed outside of any generation for groups without members
+	request := &sarama.OffsetCommitRequest{
+		Version:                 2,
+		ConsumerGroup:           groupID,
+		ConsumerGroupGeneration: -1,
+		RetentionTime:           -1,
+	}
+	for partition, offset := range offsets {
+		request.AddBlock(topicName, partition, offset, -1, 0, "")
+	}
+	response, err := coordinator.CommitOffset(request)
+	if err != nil {
+		return nil, err
+	}
+	for partition := range offsets {
+		if err, ok := response.Errors[topicName][partition]; !ok {
+			return nil, sarama.ErrIncompleteResponse
+		} else if err != sarama.ErrNoError {
+			return nil, err
+		}
+	}
+	return offsets, nil
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"sort"
+
+	"github.com/Shopify/sarama"
+)
+
+// Health statuses of topics, from the healthiest
+const (
+	Healthy         = "healthy"
+	UnderReplicated = "underReplicated"
+	Offline         = "offline"
+)
+
+// PartitionHealth tells how many of the replicas of a partition are in sync with its leader
+type PartitionHealth struct {
+	Partition int32 `json:"partition"`
+	// Leader is the broker leading the partition, -1 when the partition is offline
+	Leader   int32 `json:"leader"`
+	Replicas int   `json:"replicas"`
+	InSync   int   `json:"inSync"`
+}
+
+// Offline tells whether the partition has no leader, records being neither produced to nor consumed from it
+func (p PartitionHealth) Offline() bool {
+	return p.Leader < 0
+}
+
+// UnderReplicated tells whether some replicas of the partition lag behind its leader
+func (p PartitionHealth) UnderReplicated() bool {
+	return p.InSync < p.Replicas
+}
+
+// TopicHealth tells whether the partitions of a topic are available and fully replicated
+type TopicHealth struct {
+	// Status is the worst status of the partitions of the topic
+	Status                    string            `json:"status"`
+	UnderReplicatedPartitions []int32           `json:"underReplicatedPartitions,omitempty"`
+	OfflinePartitions         []int32           `json:"offlinePartitions,omitempty"`
+	Partitions                []PartitionHealth `json:"partitions"`
+}
+
+// NewTopicHealth sums up the health of the partitions of a topic
+func NewTopicHealth(partitions []PartitionHealth) *TopicHealth {
+	health := &TopicHealth{Status: Healthy, Partitions: partitions}
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].Partition < partitions[j].Partition
+	})
+	for _, partition := range partitions {
+		if partition.UnderReplicated() {
+			health.UnderReplicatedPartitions = append(health.UnderReplicatedPartitions, partition.Partition)
+			if health.Status == Healthy {
+				health.Status = UnderReplicated
+			}
+		}
+		if partition.Offline() {
+			health.OfflinePartitions = append(health.OfflinePartitions, partition.Partition)
+			health.Status = Offline
+		}
+	}
+	return health
+}
+
+func (kfc *kafkaClient) TopicHealth(topicName string) (*TopicHealth, error) {
+	metadata, err := kfc.Admin.DescribeTopics([]string{topicName})
+	if err != nil {
+		return nil, err
+	}
+	if metadata[0].Err != sarama.ErrNoError {
+		return nil, metadata[0].Err
+	}
+	partitions := make([]PartitionHealth, 0, len(metadata[0].Partitions))
+	for _, partition := range metadata[0].Partitions {
+		// offline partitions have no leader to be in sync with, Kafka reporting the replicas last in sync
+		inSync := len(partition.Isr)
+		if partition.Leader < 0 {
+			inSync = 0
+		}
+		partitions = append(partitions, PartitionHealth{
+			Partition: partition.ID,
+			Leader:    partition.Leader,
+			Replicas:  len(partition.Replicas),
+			InSync:    inSync,
+		})
+	}
+	return NewTopicHealth(partitions), nil
+}
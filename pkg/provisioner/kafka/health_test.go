@@ -0,0 +1,42 @@
+package client_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+var _ = Describe("Topic health", func() {
+
+	It("is healthy when all replicas are in sync", func() {
+		health := client.NewTopicHealth([]client.PartitionHealth{
+			{Partition: 0, Leader: 1, Replicas: 3, InSync: 3},
+		})
+
+		Expect(health.Status).To(Equal(client.Healthy))
+		Expect(health.UnderReplicatedPartitions).To(BeEmpty())
+		Expect(health.OfflinePartitions).To(BeEmpty())
+	})
+
+	It("flags the partitions whose replicas lag behind", func() {
+		health := client.NewTopicHealth([]client.PartitionHealth{
+			{Partition: 1, Leader: 2, Replicas: 3, InSync: 2},
+			{Partition: 0, Leader: 1, Replicas: 3, InSync: 3},
+		})
+
+		Expect(health.Status).To(Equal(client.UnderReplicated))
+		Expect(health.UnderReplicatedPartitions).To(Equal([]int32{1}))
+		Expect(health.Partitions[0].Partition).To(Equal(int32(0)))
+	})
+
+	It("is offline when any partition has no leader", func() {
+		health := client.NewTopicHealth([]client.PartitionHealth{
+			{Partition: 0, Leader: -1, Replicas: 3, InSync: 0},
+			{Partition: 1, Leader: 2, Replicas: 3, InSync: 2},
+		})
+
+		Expect(health.Status).To(Equal(client.Offline))
+		Expect(health.OfflinePartitions).To(Equal([]int32{0}))
+		Expect(health.UnderReplicatedPartitions).To(Equal([]int32{0, 1}))
+	})
+})
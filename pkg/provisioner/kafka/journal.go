@@ -0,0 +1,68 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// JournalTopic records the last provisioning decision about each topic the provisioner owns, keyed by the name of
+// the topic. Being compacted, it keeps what the provisioner knows of the topics it provisioned and deleted across
+// restarts. Like the metadata topic, its name can't collide with the topics of streams, and it isn't prefixed with
+// the __ Kafka reserves for its internal topics.
+const JournalTopic = "riff-provisioner-state"
+
+// The actions of provisioning decisions
+const (
+	DecisionProvisioned = "provisioned"
+	DecisionAltered     = "altered"
+	DecisionDeleted     = "deleted"
+	// DecisionDisappeared tells a topic the provisioner owned found deleted behind its back, e.g. while it was down
+	DecisionDisappeared = "disappeared"
+)
+
+// Decision is what the provisioner did to a topic, last
+type Decision struct {
+	Action string    `json:"action"`
+	At     time.Time `json:"at"`
+	// Spec is the layout and config overrides the topic was provisioned or altered with, when known
+	Spec *TopicSpec `json:"spec,omitempty"`
+	// Archived tells topics of deleted streams kept until their grace period is over
+	Archived bool `json:"archived,omitempty"`
+}
+
+// Owns tells whether the provisioner owns the topic of a decision, having provisioned it without deleting it since
+func (d Decision) Owns() bool {
+	return d.Action == DecisionProvisioned || d.Action == DecisionAltered
+}
+
+func (kfc *kafkaClient) RecordDecision(topicName string, decision Decision) error {
+	view := kfc.keyedTopic(JournalTopic)
+	if err := view.create(kfc.createCompactedTopic); err != nil {
+		return err
+	}
+	value, err := json.Marshal(decision)
+	if err != nil {
+		return err
+	}
+	return view.produce(topicName, value)
+}
+
+func (kfc *kafkaClient) ListDecisions() (map[string]Decision, error) {
+	var decisions map[string]Decision
+	err := kfc.keyedTopic(JournalTopic).read(func(values map[string][]byte) error {
+		decisions = make(map[string]Decision, len(values))
+		for topicName, value := range values {
+			decision := Decision{}
+			if err := json.Unmarshal(value, &decision); err != nil {
+				return fmt.Errorf("invalid decision recorded for topic %q: %v", topicName, err)
+			}
+			decisions[topicName] = decision
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
@@ -8,6 +8,43 @@ import (
 )
 
 type FakeKafkaClient struct {
+	AlterTopicConfigStub        func(string, map[string]*string) error
+	alterTopicConfigMutex       sync.RWMutex
+	alterTopicConfigArgsForCall []struct {
+		arg1 string
+		arg2 map[string]*string
+	}
+	alterTopicConfigReturns struct {
+		result1 error
+	}
+	alterTopicConfigReturnsOnCall map[int]struct {
+		result1 error
+	}
+	BrokerConfigStub        func(string) (*string, error)
+	brokerConfigMutex       sync.RWMutex
+	brokerConfigArgsForCall []struct {
+		arg1 string
+	}
+	brokerConfigReturns struct {
+		result1 *string
+		result2 error
+	}
+	brokerConfigReturnsOnCall map[int]struct {
+		result1 *string
+		result2 error
+	}
+	BrokersStub        func() ([]int32, error)
+	brokersMutex       sync.RWMutex
+	brokersArgsForCall []struct {
+	}
+	brokersReturns struct {
+		result1 []int32
+		result2 error
+	}
+	brokersReturnsOnCall map[int]struct {
+		result1 []int32
+		result2 error
+	}
 	CloseStub        func() error
 	closeMutex       sync.RWMutex
 	closeArgsForCall []struct {
@@ -18,10 +55,26 @@ type FakeKafkaClient struct {
 	closeReturnsOnCall map[int]struct {
 		result1 error
 	}
-	CreateTopicStub        func(string) error
+	CommitGroupOffsetsStub        func(string, string, int64) (map[int32]int64, error)
+	commitGroupOffsetsMutex       sync.RWMutex
+	commitGroupOffsetsArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 int64
+	}
+	commitGroupOffsetsReturns struct {
+		result1 map[int32]int64
+		result2 error
+	}
+	commitGroupOffsetsReturnsOnCall map[int]struct {
+		result1 map[int32]int64
+		result2 error
+	}
+	CreateTopicStub        func(string, client.TopicSpec) error
 	createTopicMutex       sync.RWMutex
 	createTopicArgsForCall []struct {
 		arg1 string
+		arg2 client.TopicSpec
 	}
 	createTopicReturns struct {
 		result1 error
@@ -29,6 +82,166 @@ type FakeKafkaClient struct {
 	createTopicReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DeleteMetadataStub        func(string) error
+	deleteMetadataMutex       sync.RWMutex
+	deleteMetadataArgsForCall []struct {
+		arg1 string
+	}
+	deleteMetadataReturns struct {
+		result1 error
+	}
+	deleteMetadataReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteTopicStub        func(string) error
+	deleteTopicMutex       sync.RWMutex
+	deleteTopicArgsForCall []struct {
+		arg1 string
+	}
+	deleteTopicReturns struct {
+		result1 error
+	}
+	deleteTopicReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteTopicsStub        func([]string) (map[string]error, error)
+	deleteTopicsMutex       sync.RWMutex
+	deleteTopicsArgsForCall []struct {
+		arg1 []string
+	}
+	deleteTopicsReturns struct {
+		result1 map[string]error
+		result2 error
+	}
+	deleteTopicsReturnsOnCall map[int]struct {
+		result1 map[string]error
+		result2 error
+	}
+	DescribeTopicStub        func(string) (*client.TopicSpec, error)
+	describeTopicMutex       sync.RWMutex
+	describeTopicArgsForCall []struct {
+		arg1 string
+	}
+	describeTopicReturns struct {
+		result1 *client.TopicSpec
+		result2 error
+	}
+	describeTopicReturnsOnCall map[int]struct {
+		result1 *client.TopicSpec
+		result2 error
+	}
+	ElectPreferredLeadersStub        func(string) ([]client.LeaderElection, error)
+	electPreferredLeadersMutex       sync.RWMutex
+	electPreferredLeadersArgsForCall []struct {
+		arg1 string
+	}
+	electPreferredLeadersReturns struct {
+		result1 []client.LeaderElection
+		result2 error
+	}
+	electPreferredLeadersReturnsOnCall map[int]struct {
+		result1 []client.LeaderElection
+		result2 error
+	}
+	GrantTransactionalIDsStub        func(string, string) error
+	grantTransactionalIDsMutex       sync.RWMutex
+	grantTransactionalIDsArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	grantTransactionalIDsReturns struct {
+		result1 error
+	}
+	grantTransactionalIDsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GroupProgressStub        func(string, string) ([]client.PartitionProgress, error)
+	groupProgressMutex       sync.RWMutex
+	groupProgressArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	groupProgressReturns struct {
+		result1 []client.PartitionProgress
+		result2 error
+	}
+	groupProgressReturnsOnCall map[int]struct {
+		result1 []client.PartitionProgress
+		result2 error
+	}
+	ListDecisionsStub        func() (map[string]client.Decision, error)
+	listDecisionsMutex       sync.RWMutex
+	listDecisionsArgsForCall []struct {
+	}
+	listDecisionsReturns struct {
+		result1 map[string]client.Decision
+		result2 error
+	}
+	listDecisionsReturnsOnCall map[int]struct {
+		result1 map[string]client.Decision
+		result2 error
+	}
+	ListMetadataStub        func() (map[string]client.StreamMetadata, error)
+	listMetadataMutex       sync.RWMutex
+	listMetadataArgsForCall []struct {
+	}
+	listMetadataReturns struct {
+		result1 map[string]client.StreamMetadata
+		result2 error
+	}
+	listMetadataReturnsOnCall map[int]struct {
+		result1 map[string]client.StreamMetadata
+		result2 error
+	}
+	ListTopicsStub        func() (map[string]client.TopicSpec, error)
+	listTopicsMutex       sync.RWMutex
+	listTopicsArgsForCall []struct {
+	}
+	listTopicsReturns struct {
+		result1 map[string]client.TopicSpec
+		result2 error
+	}
+	listTopicsReturnsOnCall map[int]struct {
+		result1 map[string]client.TopicSpec
+		result2 error
+	}
+	ReadMetadataStub        func(string) (*client.StreamMetadata, error)
+	readMetadataMutex       sync.RWMutex
+	readMetadataArgsForCall []struct {
+		arg1 string
+	}
+	readMetadataReturns struct {
+		result1 *client.StreamMetadata
+		result2 error
+	}
+	readMetadataReturnsOnCall map[int]struct {
+		result1 *client.StreamMetadata
+		result2 error
+	}
+	RecordDecisionStub        func(string, client.Decision) error
+	recordDecisionMutex       sync.RWMutex
+	recordDecisionArgsForCall []struct {
+		arg1 string
+		arg2 client.Decision
+	}
+	recordDecisionReturns struct {
+		result1 error
+	}
+	recordDecisionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	TopicConfigKeysStub        func() (client.TopicConfigKeys, error)
+	topicConfigKeysMutex       sync.RWMutex
+	topicConfigKeysArgsForCall []struct {
+	}
+	topicConfigKeysReturns struct {
+		result1 client.TopicConfigKeys
+		result2 error
+	}
+	topicConfigKeysReturnsOnCall map[int]struct {
+		result1 client.TopicConfigKeys
+		result2 error
+	}
 	TopicExistsStub        func(string) (bool, *client.KafkaError)
 	topicExistsMutex       sync.RWMutex
 	topicExistsArgsForCall []struct {
@@ -42,24 +255,232 @@ type FakeKafkaClient struct {
 		result1 bool
 		result2 *client.KafkaError
 	}
+	TopicHealthStub        func(string) (*client.TopicHealth, error)
+	topicHealthMutex       sync.RWMutex
+	topicHealthArgsForCall []struct {
+		arg1 string
+	}
+	topicHealthReturns struct {
+		result1 *client.TopicHealth
+		result2 error
+	}
+	topicHealthReturnsOnCall map[int]struct {
+		result1 *client.TopicHealth
+		result2 error
+	}
+	WriteMetadataStub        func(string, client.StreamMetadata) error
+	writeMetadataMutex       sync.RWMutex
+	writeMetadataArgsForCall []struct {
+		arg1 string
+		arg2 client.StreamMetadata
+	}
+	writeMetadataReturns struct {
+		result1 error
+	}
+	writeMetadataReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeKafkaClient) AlterTopicConfig(arg1 string, arg2 map[string]*string) error {
+	fake.alterTopicConfigMutex.Lock()
+	ret, specificReturn := fake.alterTopicConfigReturnsOnCall[len(fake.alterTopicConfigArgsForCall)]
+	fake.alterTopicConfigArgsForCall = append(fake.alterTopicConfigArgsForCall, struct {
+		arg1 string
+		arg2 map[string]*string
+	}{arg1, arg2})
+	stub := fake.AlterTopicConfigStub
+	fakeReturns := fake.alterTopicConfigReturns
+	fake.recordInvocation("AlterTopicConfig", []interface{}{arg1, arg2})
+	fake.alterTopicConfigMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeKafkaClient) AlterTopicConfigCallCount() int {
+	fake.alterTopicConfigMutex.RLock()
+	defer fake.alterTopicConfigMutex.RUnlock()
+	return len(fake.alterTopicConfigArgsForCall)
+}
+
+func (fake *FakeKafkaClient) AlterTopicConfigCalls(stub func(string, map[string]*string) error) {
+	fake.alterTopicConfigMutex.Lock()
+	defer fake.alterTopicConfigMutex.Unlock()
+	fake.AlterTopicConfigStub = stub
+}
+
+func (fake *FakeKafkaClient) AlterTopicConfigArgsForCall(i int) (string, map[string]*string) {
+	fake.alterTopicConfigMutex.RLock()
+	defer fake.alterTopicConfigMutex.RUnlock()
+	argsForCall := fake.alterTopicConfigArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeKafkaClient) AlterTopicConfigReturns(result1 error) {
+	fake.alterTopicConfigMutex.Lock()
+	defer fake.alterTopicConfigMutex.Unlock()
+	fake.AlterTopicConfigStub = nil
+	fake.alterTopicConfigReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeKafkaClient) AlterTopicConfigReturnsOnCall(i int, result1 error) {
+	fake.alterTopicConfigMutex.Lock()
+	defer fake.alterTopicConfigMutex.Unlock()
+	fake.AlterTopicConfigStub = nil
+	if fake.alterTopicConfigReturnsOnCall == nil {
+		fake.alterTopicConfigReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.alterTopicConfigReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeKafkaClient) BrokerConfig(arg1 string) (*string, error) {
+	fake.brokerConfigMutex.Lock()
+	ret, specificReturn := fake.brokerConfigReturnsOnCall[len(fake.brokerConfigArgsForCall)]
+	fake.brokerConfigArgsForCall = append(fake.brokerConfigArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.BrokerConfigStub
+	fakeReturns := fake.brokerConfigReturns
+	fake.recordInvocation("BrokerConfig", []interface{}{arg1})
+	fake.brokerConfigMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKafkaClient) BrokerConfigCallCount() int {
+	fake.brokerConfigMutex.RLock()
+	defer fake.brokerConfigMutex.RUnlock()
+	return len(fake.brokerConfigArgsForCall)
+}
+
+func (fake *FakeKafkaClient) BrokerConfigCalls(stub func(string) (*string, error)) {
+	fake.brokerConfigMutex.Lock()
+	defer fake.brokerConfigMutex.Unlock()
+	fake.BrokerConfigStub = stub
+}
+
+func (fake *FakeKafkaClient) BrokerConfigArgsForCall(i int) string {
+	fake.brokerConfigMutex.RLock()
+	defer fake.brokerConfigMutex.RUnlock()
+	argsForCall := fake.brokerConfigArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeKafkaClient) BrokerConfigReturns(result1 *string, result2 error) {
+	fake.brokerConfigMutex.Lock()
+	defer fake.brokerConfigMutex.Unlock()
+	fake.BrokerConfigStub = nil
+	fake.brokerConfigReturns = struct {
+		result1 *string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) BrokerConfigReturnsOnCall(i int, result1 *string, result2 error) {
+	fake.brokerConfigMutex.Lock()
+	defer fake.brokerConfigMutex.Unlock()
+	fake.BrokerConfigStub = nil
+	if fake.brokerConfigReturnsOnCall == nil {
+		fake.brokerConfigReturnsOnCall = make(map[int]struct {
+			result1 *string
+			result2 error
+		})
+	}
+	fake.brokerConfigReturnsOnCall[i] = struct {
+		result1 *string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) Brokers() ([]int32, error) {
+	fake.brokersMutex.Lock()
+	ret, specificReturn := fake.brokersReturnsOnCall[len(fake.brokersArgsForCall)]
+	fake.brokersArgsForCall = append(fake.brokersArgsForCall, struct {
+	}{})
+	stub := fake.BrokersStub
+	fakeReturns := fake.brokersReturns
+	fake.recordInvocation("Brokers", []interface{}{})
+	fake.brokersMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKafkaClient) BrokersCallCount() int {
+	fake.brokersMutex.RLock()
+	defer fake.brokersMutex.RUnlock()
+	return len(fake.brokersArgsForCall)
+}
+
+func (fake *FakeKafkaClient) BrokersCalls(stub func() ([]int32, error)) {
+	fake.brokersMutex.Lock()
+	defer fake.brokersMutex.Unlock()
+	fake.BrokersStub = stub
+}
+
+func (fake *FakeKafkaClient) BrokersReturns(result1 []int32, result2 error) {
+	fake.brokersMutex.Lock()
+	defer fake.brokersMutex.Unlock()
+	fake.BrokersStub = nil
+	fake.brokersReturns = struct {
+		result1 []int32
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) BrokersReturnsOnCall(i int, result1 []int32, result2 error) {
+	fake.brokersMutex.Lock()
+	defer fake.brokersMutex.Unlock()
+	fake.BrokersStub = nil
+	if fake.brokersReturnsOnCall == nil {
+		fake.brokersReturnsOnCall = make(map[int]struct {
+			result1 []int32
+			result2 error
+		})
+	}
+	fake.brokersReturnsOnCall[i] = struct {
+		result1 []int32
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeKafkaClient) Close() error {
 	fake.closeMutex.Lock()
 	ret, specificReturn := fake.closeReturnsOnCall[len(fake.closeArgsForCall)]
 	fake.closeArgsForCall = append(fake.closeArgsForCall, struct {
 	}{})
+	stub := fake.CloseStub
+	fakeReturns := fake.closeReturns
 	fake.recordInvocation("Close", []interface{}{})
 	fake.closeMutex.Unlock()
-	if fake.CloseStub != nil {
-		return fake.CloseStub()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.closeReturns
 	return fakeReturns.result1
 }
 
@@ -98,21 +519,89 @@ func (fake *FakeKafkaClient) CloseReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeKafkaClient) CreateTopic(arg1 string) error {
+func (fake *FakeKafkaClient) CommitGroupOffsets(arg1 string, arg2 string, arg3 int64) (map[int32]int64, error) {
+	fake.commitGroupOffsetsMutex.Lock()
+	ret, specificReturn := fake.commitGroupOffsetsReturnsOnCall[len(fake.commitGroupOffsetsArgsForCall)]
+	fake.commitGroupOffsetsArgsForCall = append(fake.commitGroupOffsetsArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 int64
+	}{arg1, arg2, arg3})
+	stub := fake.CommitGroupOffsetsStub
+	fakeReturns := fake.commitGroupOffsetsReturns
+	fake.recordInvocation("CommitGroupOffsets", []interface{}{arg1, arg2, arg3})
+	fake.commitGroupOffsetsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKafkaClient) CommitGroupOffsetsCallCount() int {
+	fake.commitGroupOffsetsMutex.RLock()
+	defer fake.commitGroupOffsetsMutex.RUnlock()
+	return len(fake.commitGroupOffsetsArgsForCall)
+}
+
+func (fake *FakeKafkaClient) CommitGroupOffsetsCalls(stub func(string, string, int64) (map[int32]int64, error)) {
+	fake.commitGroupOffsetsMutex.Lock()
+	defer fake.commitGroupOffsetsMutex.Unlock()
+	fake.CommitGroupOffsetsStub = stub
+}
+
+func (fake *FakeKafkaClient) CommitGroupOffsetsArgsForCall(i int) (string, string, int64) {
+	fake.commitGroupOffsetsMutex.RLock()
+	defer fake.commitGroupOffsetsMutex.RUnlock()
+	argsForCall := fake.commitGroupOffsetsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeKafkaClient) CommitGroupOffsetsReturns(result1 map[int32]int64, result2 error) {
+	fake.commitGroupOffsetsMutex.Lock()
+	defer fake.commitGroupOffsetsMutex.Unlock()
+	fake.CommitGroupOffsetsStub = nil
+	fake.commitGroupOffsetsReturns = struct {
+		result1 map[int32]int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) CommitGroupOffsetsReturnsOnCall(i int, result1 map[int32]int64, result2 error) {
+	fake.commitGroupOffsetsMutex.Lock()
+	defer fake.commitGroupOffsetsMutex.Unlock()
+	fake.CommitGroupOffsetsStub = nil
+	if fake.commitGroupOffsetsReturnsOnCall == nil {
+		fake.commitGroupOffsetsReturnsOnCall = make(map[int]struct {
+			result1 map[int32]int64
+			result2 error
+		})
+	}
+	fake.commitGroupOffsetsReturnsOnCall[i] = struct {
+		result1 map[int32]int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) CreateTopic(arg1 string, arg2 client.TopicSpec) error {
 	fake.createTopicMutex.Lock()
 	ret, specificReturn := fake.createTopicReturnsOnCall[len(fake.createTopicArgsForCall)]
 	fake.createTopicArgsForCall = append(fake.createTopicArgsForCall, struct {
 		arg1 string
-	}{arg1})
-	fake.recordInvocation("CreateTopic", []interface{}{arg1})
+		arg2 client.TopicSpec
+	}{arg1, arg2})
+	stub := fake.CreateTopicStub
+	fakeReturns := fake.createTopicReturns
+	fake.recordInvocation("CreateTopic", []interface{}{arg1, arg2})
 	fake.createTopicMutex.Unlock()
-	if fake.CreateTopicStub != nil {
-		return fake.CreateTopicStub(arg1)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.createTopicReturns
 	return fakeReturns.result1
 }
 
@@ -122,17 +611,17 @@ func (fake *FakeKafkaClient) CreateTopicCallCount() int {
 	return len(fake.createTopicArgsForCall)
 }
 
-func (fake *FakeKafkaClient) CreateTopicCalls(stub func(string) error) {
+func (fake *FakeKafkaClient) CreateTopicCalls(stub func(string, client.TopicSpec) error) {
 	fake.createTopicMutex.Lock()
 	defer fake.createTopicMutex.Unlock()
 	fake.CreateTopicStub = stub
 }
 
-func (fake *FakeKafkaClient) CreateTopicArgsForCall(i int) string {
+func (fake *FakeKafkaClient) CreateTopicArgsForCall(i int) (string, client.TopicSpec) {
 	fake.createTopicMutex.RLock()
 	defer fake.createTopicMutex.RUnlock()
 	argsForCall := fake.createTopicArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeKafkaClient) CreateTopicReturns(result1 error) {
@@ -158,78 +647,995 @@ func (fake *FakeKafkaClient) CreateTopicReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeKafkaClient) TopicExists(arg1 string) (bool, *client.KafkaError) {
-	fake.topicExistsMutex.Lock()
-	ret, specificReturn := fake.topicExistsReturnsOnCall[len(fake.topicExistsArgsForCall)]
-	fake.topicExistsArgsForCall = append(fake.topicExistsArgsForCall, struct {
+func (fake *FakeKafkaClient) DeleteMetadata(arg1 string) error {
+	fake.deleteMetadataMutex.Lock()
+	ret, specificReturn := fake.deleteMetadataReturnsOnCall[len(fake.deleteMetadataArgsForCall)]
+	fake.deleteMetadataArgsForCall = append(fake.deleteMetadataArgsForCall, struct {
 		arg1 string
 	}{arg1})
-	fake.recordInvocation("TopicExists", []interface{}{arg1})
-	fake.topicExistsMutex.Unlock()
-	if fake.TopicExistsStub != nil {
-		return fake.TopicExistsStub(arg1)
+	stub := fake.DeleteMetadataStub
+	fakeReturns := fake.deleteMetadataReturns
+	fake.recordInvocation("DeleteMetadata", []interface{}{arg1})
+	fake.deleteMetadataMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2
+		return ret.result1
 	}
-	fakeReturns := fake.topicExistsReturns
-	return fakeReturns.result1, fakeReturns.result2
+	return fakeReturns.result1
 }
 
-func (fake *FakeKafkaClient) TopicExistsCallCount() int {
-	fake.topicExistsMutex.RLock()
-	defer fake.topicExistsMutex.RUnlock()
-	return len(fake.topicExistsArgsForCall)
+func (fake *FakeKafkaClient) DeleteMetadataCallCount() int {
+	fake.deleteMetadataMutex.RLock()
+	defer fake.deleteMetadataMutex.RUnlock()
+	return len(fake.deleteMetadataArgsForCall)
 }
 
-func (fake *FakeKafkaClient) TopicExistsCalls(stub func(string) (bool, *client.KafkaError)) {
-	fake.topicExistsMutex.Lock()
-	defer fake.topicExistsMutex.Unlock()
-	fake.TopicExistsStub = stub
+func (fake *FakeKafkaClient) DeleteMetadataCalls(stub func(string) error) {
+	fake.deleteMetadataMutex.Lock()
+	defer fake.deleteMetadataMutex.Unlock()
+	fake.DeleteMetadataStub = stub
 }
 
-func (fake *FakeKafkaClient) TopicExistsArgsForCall(i int) string {
-	fake.topicExistsMutex.RLock()
-	defer fake.topicExistsMutex.RUnlock()
-	argsForCall := fake.topicExistsArgsForCall[i]
+func (fake *FakeKafkaClient) DeleteMetadataArgsForCall(i int) string {
+	fake.deleteMetadataMutex.RLock()
+	defer fake.deleteMetadataMutex.RUnlock()
+	argsForCall := fake.deleteMetadataArgsForCall[i]
 	return argsForCall.arg1
 }
 
-func (fake *FakeKafkaClient) TopicExistsReturns(result1 bool, result2 *client.KafkaError) {
-	fake.topicExistsMutex.Lock()
-	defer fake.topicExistsMutex.Unlock()
-	fake.TopicExistsStub = nil
-	fake.topicExistsReturns = struct {
-		result1 bool
-		result2 *client.KafkaError
-	}{result1, result2}
+func (fake *FakeKafkaClient) DeleteMetadataReturns(result1 error) {
+	fake.deleteMetadataMutex.Lock()
+	defer fake.deleteMetadataMutex.Unlock()
+	fake.DeleteMetadataStub = nil
+	fake.deleteMetadataReturns = struct {
+		result1 error
+	}{result1}
 }
 
-func (fake *FakeKafkaClient) TopicExistsReturnsOnCall(i int, result1 bool, result2 *client.KafkaError) {
-	fake.topicExistsMutex.Lock()
-	defer fake.topicExistsMutex.Unlock()
-	fake.TopicExistsStub = nil
-	if fake.topicExistsReturnsOnCall == nil {
-		fake.topicExistsReturnsOnCall = make(map[int]struct {
-			result1 bool
-			result2 *client.KafkaError
+func (fake *FakeKafkaClient) DeleteMetadataReturnsOnCall(i int, result1 error) {
+	fake.deleteMetadataMutex.Lock()
+	defer fake.deleteMetadataMutex.Unlock()
+	fake.DeleteMetadataStub = nil
+	if fake.deleteMetadataReturnsOnCall == nil {
+		fake.deleteMetadataReturnsOnCall = make(map[int]struct {
+			result1 error
 		})
 	}
-	fake.topicExistsReturnsOnCall[i] = struct {
-		result1 bool
-		result2 *client.KafkaError
-	}{result1, result2}
+	fake.deleteMetadataReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
 }
 
-func (fake *FakeKafkaClient) Invocations() map[string][][]interface{} {
-	fake.invocationsMutex.RLock()
-	defer fake.invocationsMutex.RUnlock()
-	fake.closeMutex.RLock()
-	defer fake.closeMutex.RUnlock()
-	fake.createTopicMutex.RLock()
-	defer fake.createTopicMutex.RUnlock()
-	fake.topicExistsMutex.RLock()
-	defer fake.topicExistsMutex.RUnlock()
+func (fake *FakeKafkaClient) DeleteTopic(arg1 string) error {
+	fake.deleteTopicMutex.Lock()
+	ret, specificReturn := fake.deleteTopicReturnsOnCall[len(fake.deleteTopicArgsForCall)]
+	fake.deleteTopicArgsForCall = append(fake.deleteTopicArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DeleteTopicStub
+	fakeReturns := fake.deleteTopicReturns
+	fake.recordInvocation("DeleteTopic", []interface{}{arg1})
+	fake.deleteTopicMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeKafkaClient) DeleteTopicCallCount() int {
+	fake.deleteTopicMutex.RLock()
+	defer fake.deleteTopicMutex.RUnlock()
+	return len(fake.deleteTopicArgsForCall)
+}
+
+func (fake *FakeKafkaClient) DeleteTopicCalls(stub func(string) error) {
+	fake.deleteTopicMutex.Lock()
+	defer fake.deleteTopicMutex.Unlock()
+	fake.DeleteTopicStub = stub
+}
+
+func (fake *FakeKafkaClient) DeleteTopicArgsForCall(i int) string {
+	fake.deleteTopicMutex.RLock()
+	defer fake.deleteTopicMutex.RUnlock()
+	argsForCall := fake.deleteTopicArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeKafkaClient) DeleteTopicReturns(result1 error) {
+	fake.deleteTopicMutex.Lock()
+	defer fake.deleteTopicMutex.Unlock()
+	fake.DeleteTopicStub = nil
+	fake.deleteTopicReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeKafkaClient) DeleteTopicReturnsOnCall(i int, result1 error) {
+	fake.deleteTopicMutex.Lock()
+	defer fake.deleteTopicMutex.Unlock()
+	fake.DeleteTopicStub = nil
+	if fake.deleteTopicReturnsOnCall == nil {
+		fake.deleteTopicReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteTopicReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeKafkaClient) DeleteTopics(arg1 []string) (map[string]error, error) {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.deleteTopicsMutex.Lock()
+	ret, specificReturn := fake.deleteTopicsReturnsOnCall[len(fake.deleteTopicsArgsForCall)]
+	fake.deleteTopicsArgsForCall = append(fake.deleteTopicsArgsForCall, struct {
+		arg1 []string
+	}{arg1Copy})
+	stub := fake.DeleteTopicsStub
+	fakeReturns := fake.deleteTopicsReturns
+	fake.recordInvocation("DeleteTopics", []interface{}{arg1Copy})
+	fake.deleteTopicsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKafkaClient) DeleteTopicsCallCount() int {
+	fake.deleteTopicsMutex.RLock()
+	defer fake.deleteTopicsMutex.RUnlock()
+	return len(fake.deleteTopicsArgsForCall)
+}
+
+func (fake *FakeKafkaClient) DeleteTopicsCalls(stub func([]string) (map[string]error, error)) {
+	fake.deleteTopicsMutex.Lock()
+	defer fake.deleteTopicsMutex.Unlock()
+	fake.DeleteTopicsStub = stub
+}
+
+func (fake *FakeKafkaClient) DeleteTopicsArgsForCall(i int) []string {
+	fake.deleteTopicsMutex.RLock()
+	defer fake.deleteTopicsMutex.RUnlock()
+	argsForCall := fake.deleteTopicsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeKafkaClient) DeleteTopicsReturns(result1 map[string]error, result2 error) {
+	fake.deleteTopicsMutex.Lock()
+	defer fake.deleteTopicsMutex.Unlock()
+	fake.DeleteTopicsStub = nil
+	fake.deleteTopicsReturns = struct {
+		result1 map[string]error
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) DeleteTopicsReturnsOnCall(i int, result1 map[string]error, result2 error) {
+	fake.deleteTopicsMutex.Lock()
+	defer fake.deleteTopicsMutex.Unlock()
+	fake.DeleteTopicsStub = nil
+	if fake.deleteTopicsReturnsOnCall == nil {
+		fake.deleteTopicsReturnsOnCall = make(map[int]struct {
+			result1 map[string]error
+			result2 error
+		})
+	}
+	fake.deleteTopicsReturnsOnCall[i] = struct {
+		result1 map[string]error
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) DescribeTopic(arg1 string) (*client.TopicSpec, error) {
+	fake.describeTopicMutex.Lock()
+	ret, specificReturn := fake.describeTopicReturnsOnCall[len(fake.describeTopicArgsForCall)]
+	fake.describeTopicArgsForCall = append(fake.describeTopicArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DescribeTopicStub
+	fakeReturns := fake.describeTopicReturns
+	fake.recordInvocation("DescribeTopic", []interface{}{arg1})
+	fake.describeTopicMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKafkaClient) DescribeTopicCallCount() int {
+	fake.describeTopicMutex.RLock()
+	defer fake.describeTopicMutex.RUnlock()
+	return len(fake.describeTopicArgsForCall)
+}
+
+func (fake *FakeKafkaClient) DescribeTopicCalls(stub func(string) (*client.TopicSpec, error)) {
+	fake.describeTopicMutex.Lock()
+	defer fake.describeTopicMutex.Unlock()
+	fake.DescribeTopicStub = stub
+}
+
+func (fake *FakeKafkaClient) DescribeTopicArgsForCall(i int) string {
+	fake.describeTopicMutex.RLock()
+	defer fake.describeTopicMutex.RUnlock()
+	argsForCall := fake.describeTopicArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeKafkaClient) DescribeTopicReturns(result1 *client.TopicSpec, result2 error) {
+	fake.describeTopicMutex.Lock()
+	defer fake.describeTopicMutex.Unlock()
+	fake.DescribeTopicStub = nil
+	fake.describeTopicReturns = struct {
+		result1 *client.TopicSpec
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) DescribeTopicReturnsOnCall(i int, result1 *client.TopicSpec, result2 error) {
+	fake.describeTopicMutex.Lock()
+	defer fake.describeTopicMutex.Unlock()
+	fake.DescribeTopicStub = nil
+	if fake.describeTopicReturnsOnCall == nil {
+		fake.describeTopicReturnsOnCall = make(map[int]struct {
+			result1 *client.TopicSpec
+			result2 error
+		})
+	}
+	fake.describeTopicReturnsOnCall[i] = struct {
+		result1 *client.TopicSpec
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) ElectPreferredLeaders(arg1 string) ([]client.LeaderElection, error) {
+	fake.electPreferredLeadersMutex.Lock()
+	ret, specificReturn := fake.electPreferredLeadersReturnsOnCall[len(fake.electPreferredLeadersArgsForCall)]
+	fake.electPreferredLeadersArgsForCall = append(fake.electPreferredLeadersArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ElectPreferredLeadersStub
+	fakeReturns := fake.electPreferredLeadersReturns
+	fake.recordInvocation("ElectPreferredLeaders", []interface{}{arg1})
+	fake.electPreferredLeadersMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKafkaClient) ElectPreferredLeadersCallCount() int {
+	fake.electPreferredLeadersMutex.RLock()
+	defer fake.electPreferredLeadersMutex.RUnlock()
+	return len(fake.electPreferredLeadersArgsForCall)
+}
+
+func (fake *FakeKafkaClient) ElectPreferredLeadersCalls(stub func(string) ([]client.LeaderElection, error)) {
+	fake.electPreferredLeadersMutex.Lock()
+	defer fake.electPreferredLeadersMutex.Unlock()
+	fake.ElectPreferredLeadersStub = stub
+}
+
+func (fake *FakeKafkaClient) ElectPreferredLeadersArgsForCall(i int) string {
+	fake.electPreferredLeadersMutex.RLock()
+	defer fake.electPreferredLeadersMutex.RUnlock()
+	argsForCall := fake.electPreferredLeadersArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeKafkaClient) ElectPreferredLeadersReturns(result1 []client.LeaderElection, result2 error) {
+	fake.electPreferredLeadersMutex.Lock()
+	defer fake.electPreferredLeadersMutex.Unlock()
+	fake.ElectPreferredLeadersStub = nil
+	fake.electPreferredLeadersReturns = struct {
+		result1 []client.LeaderElection
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) ElectPreferredLeadersReturnsOnCall(i int, result1 []client.LeaderElection, result2 error) {
+	fake.electPreferredLeadersMutex.Lock()
+	defer fake.electPreferredLeadersMutex.Unlock()
+	fake.ElectPreferredLeadersStub = nil
+	if fake.electPreferredLeadersReturnsOnCall == nil {
+		fake.electPreferredLeadersReturnsOnCall = make(map[int]struct {
+			result1 []client.LeaderElection
+			result2 error
+		})
+	}
+	fake.electPreferredLeadersReturnsOnCall[i] = struct {
+		result1 []client.LeaderElection
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) GrantTransactionalIDs(arg1 string, arg2 string) error {
+	fake.grantTransactionalIDsMutex.Lock()
+	ret, specificReturn := fake.grantTransactionalIDsReturnsOnCall[len(fake.grantTransactionalIDsArgsForCall)]
+	fake.grantTransactionalIDsArgsForCall = append(fake.grantTransactionalIDsArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GrantTransactionalIDsStub
+	fakeReturns := fake.grantTransactionalIDsReturns
+	fake.recordInvocation("GrantTransactionalIDs", []interface{}{arg1, arg2})
+	fake.grantTransactionalIDsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeKafkaClient) GrantTransactionalIDsCallCount() int {
+	fake.grantTransactionalIDsMutex.RLock()
+	defer fake.grantTransactionalIDsMutex.RUnlock()
+	return len(fake.grantTransactionalIDsArgsForCall)
+}
+
+func (fake *FakeKafkaClient) GrantTransactionalIDsCalls(stub func(string, string) error) {
+	fake.grantTransactionalIDsMutex.Lock()
+	defer fake.grantTransactionalIDsMutex.Unlock()
+	fake.GrantTransactionalIDsStub = stub
+}
+
+func (fake *FakeKafkaClient) GrantTransactionalIDsArgsForCall(i int) (string, string) {
+	fake.grantTransactionalIDsMutex.RLock()
+	defer fake.grantTransactionalIDsMutex.RUnlock()
+	argsForCall := fake.grantTransactionalIDsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeKafkaClient) GrantTransactionalIDsReturns(result1 error) {
+	fake.grantTransactionalIDsMutex.Lock()
+	defer fake.grantTransactionalIDsMutex.Unlock()
+	fake.GrantTransactionalIDsStub = nil
+	fake.grantTransactionalIDsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeKafkaClient) GrantTransactionalIDsReturnsOnCall(i int, result1 error) {
+	fake.grantTransactionalIDsMutex.Lock()
+	defer fake.grantTransactionalIDsMutex.Unlock()
+	fake.GrantTransactionalIDsStub = nil
+	if fake.grantTransactionalIDsReturnsOnCall == nil {
+		fake.grantTransactionalIDsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.grantTransactionalIDsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeKafkaClient) GroupProgress(arg1 string, arg2 string) ([]client.PartitionProgress, error) {
+	fake.groupProgressMutex.Lock()
+	ret, specificReturn := fake.groupProgressReturnsOnCall[len(fake.groupProgressArgsForCall)]
+	fake.groupProgressArgsForCall = append(fake.groupProgressArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GroupProgressStub
+	fakeReturns := fake.groupProgressReturns
+	fake.recordInvocation("GroupProgress", []interface{}{arg1, arg2})
+	fake.groupProgressMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKafkaClient) GroupProgressCallCount() int {
+	fake.groupProgressMutex.RLock()
+	defer fake.groupProgressMutex.RUnlock()
+	return len(fake.groupProgressArgsForCall)
+}
+
+func (fake *FakeKafkaClient) GroupProgressCalls(stub func(string, string) ([]client.PartitionProgress, error)) {
+	fake.groupProgressMutex.Lock()
+	defer fake.groupProgressMutex.Unlock()
+	fake.GroupProgressStub = stub
+}
+
+func (fake *FakeKafkaClient) GroupProgressArgsForCall(i int) (string, string) {
+	fake.groupProgressMutex.RLock()
+	defer fake.groupProgressMutex.RUnlock()
+	argsForCall := fake.groupProgressArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeKafkaClient) GroupProgressReturns(result1 []client.PartitionProgress, result2 error) {
+	fake.groupProgressMutex.Lock()
+	defer fake.groupProgressMutex.Unlock()
+	fake.GroupProgressStub = nil
+	fake.groupProgressReturns = struct {
+		result1 []client.PartitionProgress
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) GroupProgressReturnsOnCall(i int, result1 []client.PartitionProgress, result2 error) {
+	fake.groupProgressMutex.Lock()
+	defer fake.groupProgressMutex.Unlock()
+	fake.GroupProgressStub = nil
+	if fake.groupProgressReturnsOnCall == nil {
+		fake.groupProgressReturnsOnCall = make(map[int]struct {
+			result1 []client.PartitionProgress
+			result2 error
+		})
+	}
+	fake.groupProgressReturnsOnCall[i] = struct {
+		result1 []client.PartitionProgress
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) ListDecisions() (map[string]client.Decision, error) {
+	fake.listDecisionsMutex.Lock()
+	ret, specificReturn := fake.listDecisionsReturnsOnCall[len(fake.listDecisionsArgsForCall)]
+	fake.listDecisionsArgsForCall = append(fake.listDecisionsArgsForCall, struct {
+	}{})
+	stub := fake.ListDecisionsStub
+	fakeReturns := fake.listDecisionsReturns
+	fake.recordInvocation("ListDecisions", []interface{}{})
+	fake.listDecisionsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKafkaClient) ListDecisionsCallCount() int {
+	fake.listDecisionsMutex.RLock()
+	defer fake.listDecisionsMutex.RUnlock()
+	return len(fake.listDecisionsArgsForCall)
+}
+
+func (fake *FakeKafkaClient) ListDecisionsCalls(stub func() (map[string]client.Decision, error)) {
+	fake.listDecisionsMutex.Lock()
+	defer fake.listDecisionsMutex.Unlock()
+	fake.ListDecisionsStub = stub
+}
+
+func (fake *FakeKafkaClient) ListDecisionsReturns(result1 map[string]client.Decision, result2 error) {
+	fake.listDecisionsMutex.Lock()
+	defer fake.listDecisionsMutex.Unlock()
+	fake.ListDecisionsStub = nil
+	fake.listDecisionsReturns = struct {
+		result1 map[string]client.Decision
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) ListDecisionsReturnsOnCall(i int, result1 map[string]client.Decision, result2 error) {
+	fake.listDecisionsMutex.Lock()
+	defer fake.listDecisionsMutex.Unlock()
+	fake.ListDecisionsStub = nil
+	if fake.listDecisionsReturnsOnCall == nil {
+		fake.listDecisionsReturnsOnCall = make(map[int]struct {
+			result1 map[string]client.Decision
+			result2 error
+		})
+	}
+	fake.listDecisionsReturnsOnCall[i] = struct {
+		result1 map[string]client.Decision
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) ListMetadata() (map[string]client.StreamMetadata, error) {
+	fake.listMetadataMutex.Lock()
+	ret, specificReturn := fake.listMetadataReturnsOnCall[len(fake.listMetadataArgsForCall)]
+	fake.listMetadataArgsForCall = append(fake.listMetadataArgsForCall, struct {
+	}{})
+	stub := fake.ListMetadataStub
+	fakeReturns := fake.listMetadataReturns
+	fake.recordInvocation("ListMetadata", []interface{}{})
+	fake.listMetadataMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKafkaClient) ListMetadataCallCount() int {
+	fake.listMetadataMutex.RLock()
+	defer fake.listMetadataMutex.RUnlock()
+	return len(fake.listMetadataArgsForCall)
+}
+
+func (fake *FakeKafkaClient) ListMetadataCalls(stub func() (map[string]client.StreamMetadata, error)) {
+	fake.listMetadataMutex.Lock()
+	defer fake.listMetadataMutex.Unlock()
+	fake.ListMetadataStub = stub
+}
+
+func (fake *FakeKafkaClient) ListMetadataReturns(result1 map[string]client.StreamMetadata, result2 error) {
+	fake.listMetadataMutex.Lock()
+	defer fake.listMetadataMutex.Unlock()
+	fake.ListMetadataStub = nil
+	fake.listMetadataReturns = struct {
+		result1 map[string]client.StreamMetadata
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) ListMetadataReturnsOnCall(i int, result1 map[string]client.StreamMetadata, result2 error) {
+	fake.listMetadataMutex.Lock()
+	defer fake.listMetadataMutex.Unlock()
+	fake.ListMetadataStub = nil
+	if fake.listMetadataReturnsOnCall == nil {
+		fake.listMetadataReturnsOnCall = make(map[int]struct {
+			result1 map[string]client.StreamMetadata
+			result2 error
+		})
+	}
+	fake.listMetadataReturnsOnCall[i] = struct {
+		result1 map[string]client.StreamMetadata
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) ListTopics() (map[string]client.TopicSpec, error) {
+	fake.listTopicsMutex.Lock()
+	ret, specificReturn := fake.listTopicsReturnsOnCall[len(fake.listTopicsArgsForCall)]
+	fake.listTopicsArgsForCall = append(fake.listTopicsArgsForCall, struct {
+	}{})
+	stub := fake.ListTopicsStub
+	fakeReturns := fake.listTopicsReturns
+	fake.recordInvocation("ListTopics", []interface{}{})
+	fake.listTopicsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKafkaClient) ListTopicsCallCount() int {
+	fake.listTopicsMutex.RLock()
+	defer fake.listTopicsMutex.RUnlock()
+	return len(fake.listTopicsArgsForCall)
+}
+
+func (fake *FakeKafkaClient) ListTopicsCalls(stub func() (map[string]client.TopicSpec, error)) {
+	fake.listTopicsMutex.Lock()
+	defer fake.listTopicsMutex.Unlock()
+	fake.ListTopicsStub = stub
+}
+
+func (fake *FakeKafkaClient) ListTopicsReturns(result1 map[string]client.TopicSpec, result2 error) {
+	fake.listTopicsMutex.Lock()
+	defer fake.listTopicsMutex.Unlock()
+	fake.ListTopicsStub = nil
+	fake.listTopicsReturns = struct {
+		result1 map[string]client.TopicSpec
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) ListTopicsReturnsOnCall(i int, result1 map[string]client.TopicSpec, result2 error) {
+	fake.listTopicsMutex.Lock()
+	defer fake.listTopicsMutex.Unlock()
+	fake.ListTopicsStub = nil
+	if fake.listTopicsReturnsOnCall == nil {
+		fake.listTopicsReturnsOnCall = make(map[int]struct {
+			result1 map[string]client.TopicSpec
+			result2 error
+		})
+	}
+	fake.listTopicsReturnsOnCall[i] = struct {
+		result1 map[string]client.TopicSpec
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) ReadMetadata(arg1 string) (*client.StreamMetadata, error) {
+	fake.readMetadataMutex.Lock()
+	ret, specificReturn := fake.readMetadataReturnsOnCall[len(fake.readMetadataArgsForCall)]
+	fake.readMetadataArgsForCall = append(fake.readMetadataArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ReadMetadataStub
+	fakeReturns := fake.readMetadataReturns
+	fake.recordInvocation("ReadMetadata", []interface{}{arg1})
+	fake.readMetadataMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKafkaClient) ReadMetadataCallCount() int {
+	fake.readMetadataMutex.RLock()
+	defer fake.readMetadataMutex.RUnlock()
+	return len(fake.readMetadataArgsForCall)
+}
+
+func (fake *FakeKafkaClient) ReadMetadataCalls(stub func(string) (*client.StreamMetadata, error)) {
+	fake.readMetadataMutex.Lock()
+	defer fake.readMetadataMutex.Unlock()
+	fake.ReadMetadataStub = stub
+}
+
+func (fake *FakeKafkaClient) ReadMetadataArgsForCall(i int) string {
+	fake.readMetadataMutex.RLock()
+	defer fake.readMetadataMutex.RUnlock()
+	argsForCall := fake.readMetadataArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeKafkaClient) ReadMetadataReturns(result1 *client.StreamMetadata, result2 error) {
+	fake.readMetadataMutex.Lock()
+	defer fake.readMetadataMutex.Unlock()
+	fake.ReadMetadataStub = nil
+	fake.readMetadataReturns = struct {
+		result1 *client.StreamMetadata
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) ReadMetadataReturnsOnCall(i int, result1 *client.StreamMetadata, result2 error) {
+	fake.readMetadataMutex.Lock()
+	defer fake.readMetadataMutex.Unlock()
+	fake.ReadMetadataStub = nil
+	if fake.readMetadataReturnsOnCall == nil {
+		fake.readMetadataReturnsOnCall = make(map[int]struct {
+			result1 *client.StreamMetadata
+			result2 error
+		})
+	}
+	fake.readMetadataReturnsOnCall[i] = struct {
+		result1 *client.StreamMetadata
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) RecordDecision(arg1 string, arg2 client.Decision) error {
+	fake.recordDecisionMutex.Lock()
+	ret, specificReturn := fake.recordDecisionReturnsOnCall[len(fake.recordDecisionArgsForCall)]
+	fake.recordDecisionArgsForCall = append(fake.recordDecisionArgsForCall, struct {
+		arg1 string
+		arg2 client.Decision
+	}{arg1, arg2})
+	stub := fake.RecordDecisionStub
+	fakeReturns := fake.recordDecisionReturns
+	fake.recordInvocation("RecordDecision", []interface{}{arg1, arg2})
+	fake.recordDecisionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeKafkaClient) RecordDecisionCallCount() int {
+	fake.recordDecisionMutex.RLock()
+	defer fake.recordDecisionMutex.RUnlock()
+	return len(fake.recordDecisionArgsForCall)
+}
+
+func (fake *FakeKafkaClient) RecordDecisionCalls(stub func(string, client.Decision) error) {
+	fake.recordDecisionMutex.Lock()
+	defer fake.recordDecisionMutex.Unlock()
+	fake.RecordDecisionStub = stub
+}
+
+func (fake *FakeKafkaClient) RecordDecisionArgsForCall(i int) (string, client.Decision) {
+	fake.recordDecisionMutex.RLock()
+	defer fake.recordDecisionMutex.RUnlock()
+	argsForCall := fake.recordDecisionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeKafkaClient) RecordDecisionReturns(result1 error) {
+	fake.recordDecisionMutex.Lock()
+	defer fake.recordDecisionMutex.Unlock()
+	fake.RecordDecisionStub = nil
+	fake.recordDecisionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeKafkaClient) RecordDecisionReturnsOnCall(i int, result1 error) {
+	fake.recordDecisionMutex.Lock()
+	defer fake.recordDecisionMutex.Unlock()
+	fake.RecordDecisionStub = nil
+	if fake.recordDecisionReturnsOnCall == nil {
+		fake.recordDecisionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.recordDecisionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeKafkaClient) TopicConfigKeys() (client.TopicConfigKeys, error) {
+	fake.topicConfigKeysMutex.Lock()
+	ret, specificReturn := fake.topicConfigKeysReturnsOnCall[len(fake.topicConfigKeysArgsForCall)]
+	fake.topicConfigKeysArgsForCall = append(fake.topicConfigKeysArgsForCall, struct {
+	}{})
+	stub := fake.TopicConfigKeysStub
+	fakeReturns := fake.topicConfigKeysReturns
+	fake.recordInvocation("TopicConfigKeys", []interface{}{})
+	fake.topicConfigKeysMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKafkaClient) TopicConfigKeysCallCount() int {
+	fake.topicConfigKeysMutex.RLock()
+	defer fake.topicConfigKeysMutex.RUnlock()
+	return len(fake.topicConfigKeysArgsForCall)
+}
+
+func (fake *FakeKafkaClient) TopicConfigKeysCalls(stub func() (client.TopicConfigKeys, error)) {
+	fake.topicConfigKeysMutex.Lock()
+	defer fake.topicConfigKeysMutex.Unlock()
+	fake.TopicConfigKeysStub = stub
+}
+
+func (fake *FakeKafkaClient) TopicConfigKeysReturns(result1 client.TopicConfigKeys, result2 error) {
+	fake.topicConfigKeysMutex.Lock()
+	defer fake.topicConfigKeysMutex.Unlock()
+	fake.TopicConfigKeysStub = nil
+	fake.topicConfigKeysReturns = struct {
+		result1 client.TopicConfigKeys
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) TopicConfigKeysReturnsOnCall(i int, result1 client.TopicConfigKeys, result2 error) {
+	fake.topicConfigKeysMutex.Lock()
+	defer fake.topicConfigKeysMutex.Unlock()
+	fake.TopicConfigKeysStub = nil
+	if fake.topicConfigKeysReturnsOnCall == nil {
+		fake.topicConfigKeysReturnsOnCall = make(map[int]struct {
+			result1 client.TopicConfigKeys
+			result2 error
+		})
+	}
+	fake.topicConfigKeysReturnsOnCall[i] = struct {
+		result1 client.TopicConfigKeys
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) TopicExists(arg1 string) (bool, *client.KafkaError) {
+	fake.topicExistsMutex.Lock()
+	ret, specificReturn := fake.topicExistsReturnsOnCall[len(fake.topicExistsArgsForCall)]
+	fake.topicExistsArgsForCall = append(fake.topicExistsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.TopicExistsStub
+	fakeReturns := fake.topicExistsReturns
+	fake.recordInvocation("TopicExists", []interface{}{arg1})
+	fake.topicExistsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKafkaClient) TopicExistsCallCount() int {
+	fake.topicExistsMutex.RLock()
+	defer fake.topicExistsMutex.RUnlock()
+	return len(fake.topicExistsArgsForCall)
+}
+
+func (fake *FakeKafkaClient) TopicExistsCalls(stub func(string) (bool, *client.KafkaError)) {
+	fake.topicExistsMutex.Lock()
+	defer fake.topicExistsMutex.Unlock()
+	fake.TopicExistsStub = stub
+}
+
+func (fake *FakeKafkaClient) TopicExistsArgsForCall(i int) string {
+	fake.topicExistsMutex.RLock()
+	defer fake.topicExistsMutex.RUnlock()
+	argsForCall := fake.topicExistsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeKafkaClient) TopicExistsReturns(result1 bool, result2 *client.KafkaError) {
+	fake.topicExistsMutex.Lock()
+	defer fake.topicExistsMutex.Unlock()
+	fake.TopicExistsStub = nil
+	fake.topicExistsReturns = struct {
+		result1 bool
+		result2 *client.KafkaError
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) TopicExistsReturnsOnCall(i int, result1 bool, result2 *client.KafkaError) {
+	fake.topicExistsMutex.Lock()
+	defer fake.topicExistsMutex.Unlock()
+	fake.TopicExistsStub = nil
+	if fake.topicExistsReturnsOnCall == nil {
+		fake.topicExistsReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 *client.KafkaError
+		})
+	}
+	fake.topicExistsReturnsOnCall[i] = struct {
+		result1 bool
+		result2 *client.KafkaError
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) TopicHealth(arg1 string) (*client.TopicHealth, error) {
+	fake.topicHealthMutex.Lock()
+	ret, specificReturn := fake.topicHealthReturnsOnCall[len(fake.topicHealthArgsForCall)]
+	fake.topicHealthArgsForCall = append(fake.topicHealthArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.TopicHealthStub
+	fakeReturns := fake.topicHealthReturns
+	fake.recordInvocation("TopicHealth", []interface{}{arg1})
+	fake.topicHealthMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKafkaClient) TopicHealthCallCount() int {
+	fake.topicHealthMutex.RLock()
+	defer fake.topicHealthMutex.RUnlock()
+	return len(fake.topicHealthArgsForCall)
+}
+
+func (fake *FakeKafkaClient) TopicHealthCalls(stub func(string) (*client.TopicHealth, error)) {
+	fake.topicHealthMutex.Lock()
+	defer fake.topicHealthMutex.Unlock()
+	fake.TopicHealthStub = stub
+}
+
+func (fake *FakeKafkaClient) TopicHealthArgsForCall(i int) string {
+	fake.topicHealthMutex.RLock()
+	defer fake.topicHealthMutex.RUnlock()
+	argsForCall := fake.topicHealthArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeKafkaClient) TopicHealthReturns(result1 *client.TopicHealth, result2 error) {
+	fake.topicHealthMutex.Lock()
+	defer fake.topicHealthMutex.Unlock()
+	fake.TopicHealthStub = nil
+	fake.topicHealthReturns = struct {
+		result1 *client.TopicHealth
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) TopicHealthReturnsOnCall(i int, result1 *client.TopicHealth, result2 error) {
+	fake.topicHealthMutex.Lock()
+	defer fake.topicHealthMutex.Unlock()
+	fake.TopicHealthStub = nil
+	if fake.topicHealthReturnsOnCall == nil {
+		fake.topicHealthReturnsOnCall = make(map[int]struct {
+			result1 *client.TopicHealth
+			result2 error
+		})
+	}
+	fake.topicHealthReturnsOnCall[i] = struct {
+		result1 *client.TopicHealth
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKafkaClient) WriteMetadata(arg1 string, arg2 client.StreamMetadata) error {
+	fake.writeMetadataMutex.Lock()
+	ret, specificReturn := fake.writeMetadataReturnsOnCall[len(fake.writeMetadataArgsForCall)]
+	fake.writeMetadataArgsForCall = append(fake.writeMetadataArgsForCall, struct {
+		arg1 string
+		arg2 client.StreamMetadata
+	}{arg1, arg2})
+	stub := fake.WriteMetadataStub
+	fakeReturns := fake.writeMetadataReturns
+	fake.recordInvocation("WriteMetadata", []interface{}{arg1, arg2})
+	fake.writeMetadataMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeKafkaClient) WriteMetadataCallCount() int {
+	fake.writeMetadataMutex.RLock()
+	defer fake.writeMetadataMutex.RUnlock()
+	return len(fake.writeMetadataArgsForCall)
+}
+
+func (fake *FakeKafkaClient) WriteMetadataCalls(stub func(string, client.StreamMetadata) error) {
+	fake.writeMetadataMutex.Lock()
+	defer fake.writeMetadataMutex.Unlock()
+	fake.WriteMetadataStub = stub
+}
+
+func (fake *FakeKafkaClient) WriteMetadataArgsForCall(i int) (string, client.StreamMetadata) {
+	fake.writeMetadataMutex.RLock()
+	defer fake.writeMetadataMutex.RUnlock()
+	argsForCall := fake.writeMetadataArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeKafkaClient) WriteMetadataReturns(result1 error) {
+	fake.writeMetadataMutex.Lock()
+	defer fake.writeMetadataMutex.Unlock()
+	fake.WriteMetadataStub = nil
+	fake.writeMetadataReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeKafkaClient) WriteMetadataReturnsOnCall(i int, result1 error) {
+	fake.writeMetadataMutex.Lock()
+	defer fake.writeMetadataMutex.Unlock()
+	fake.WriteMetadataStub = nil
+	if fake.writeMetadataReturnsOnCall == nil {
+		fake.writeMetadataReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.writeMetadataReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeKafkaClient) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
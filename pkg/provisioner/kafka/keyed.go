@@ -0,0 +1,187 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// keyedTopic is a view of a compacted topic of a single partition keyed by the names of topics, such as the metadata
+// topic. Views live as long as the process, shared by the clients connected to the same brokers, which are created
+// for each request: records are produced and consumed over a single connection, and reads catch up with the records
+// produced since the last read rather than scanning the topic from its start, as the gateway follows the metadata
+// topic.
+type keyedTopic struct {
+	topic   string
+	brokers []string
+	config  *sarama.Config
+
+	mu       sync.Mutex
+	kafka    sarama.Client
+	producer sarama.SyncProducer
+	consumer sarama.Consumer
+	// created tells the topic was created, or found to exist, by the view
+	created bool
+	// offset is the offset of the next record to read, values the last value read for each key
+	offset int64
+	values map[string][]byte
+}
+
+// keyedReadTimeout bounds how long catching up with a compacted topic may take
+const keyedReadTimeout = 10 * time.Second
+
+var (
+	keyedTopicsMu sync.Mutex
+	keyedTopics   = make(map[string]*keyedTopic)
+)
+
+// keyedTopic returns the view of a compacted topic on the brokers of the client
+func (kfc *kafkaClient) keyedTopic(topic string) *keyedTopic {
+	key := strings.Join(kfc.brokers, ",") + "/" + topic
+	keyedTopicsMu.Lock()
+	defer keyedTopicsMu.Unlock()
+	kt, ok := keyedTopics[key]
+	if !ok {
+		config := *kfc.config
+		config.Producer.Return.Successes = true
+		config.Producer.RequiredAcks = sarama.WaitForAll
+		kt = &keyedTopic{topic: topic, brokers: kfc.brokers, config: &config, values: make(map[string][]byte)}
+		keyedTopics[key] = kt
+	}
+	return kt
+}
+
+// create creates the topic once for the life of the view
+func (kt *keyedTopic) create(create func(topic string) error) error {
+	kt.mu.Lock()
+	defer kt.mu.Unlock()
+	if kt.created {
+		return nil
+	}
+	if err := create(kt.topic); err != nil {
+		return err
+	}
+	kt.created = true
+	return nil
+}
+
+// produce records a value for a key, nil values being tombstones
+func (kt *keyedTopic) produce(key string, value []byte) error {
+	kt.mu.Lock()
+	defer kt.mu.Unlock()
+	if err := kt.connect(); err != nil {
+		return err
+	}
+	message := &sarama.ProducerMessage{Topic: kt.topic, Key: sarama.StringEncoder(key)}
+	if value != nil {
+		message.Value = sarama.ByteEncoder(value)
+	}
+	_, _, err := kt.producer.SendMessage(message)
+	return err
+}
+
+// read catches up with the topic and hands f the last value recorded for each key, which f must not keep or modify
+func (kt *keyedTopic) read(f func(values map[string][]byte) error) error {
+	kt.mu.Lock()
+	defer kt.mu.Unlock()
+	if err := kt.catchUp(); err != nil {
+		return err
+	}
+	return f(kt.values)
+}
+
+// catchUp reads the records produced since the last read, topics not created yet having none. Callers hold kt.mu.
+func (kt *keyedTopic) catchUp() error {
+	if err := kt.connect(); err != nil {
+		return err
+	}
+	end, err := kt.kafka.GetOffset(kt.topic, 0, sarama.OffsetNewest)
+	if err == sarama.ErrUnknownTopicOrPartition {
+		kt.offset, kt.values = 0, make(map[string][]byte)
+		return nil
+	}
+	if err != nil {
+		kt.disconnect()
+		return err
+	}
+	start, err := kt.kafka.GetOffset(kt.topic, 0, sarama.OffsetOldest)
+	if err != nil {
+		kt.disconnect()
+		return err
+	}
+	if kt.offset < start || kt.offset > end {
+		// the records read were deleted, or the topic recreated, since the last read
+		kt.offset, kt.values = start, make(map[string][]byte)
+	}
+	if kt.offset >= end {
+		return nil
+	}
+
+	partitionConsumer, err := kt.consumer.ConsumePartition(kt.topic, 0, kt.offset)
+	if err != nil {
+		kt.disconnect()
+		return err
+	}
+	// closing rather than closing asynchronously, so that the next read may consume the partition again
+	defer func() { _ = partitionConsumer.Close() }()
+
+	timeout := time.NewTimer(keyedReadTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case message := <-partitionConsumer.Messages():
+			// empty values are tombstones
+			if len(message.Value) > 0 {
+				kt.values[string(message.Key)] = message.Value
+			} else {
+				delete(kt.values, string(message.Key))
+			}
+			kt.offset = message.Offset + 1
+			if kt.offset >= end {
+				return nil
+			}
+		case err := <-partitionConsumer.Errors():
+			return err.Err
+		case <-timeout.C:
+			return fmt.Errorf("timed out reading the topic %q", kt.topic)
+		}
+	}
+}
+
+// connect opens the connection of the view unless open. Callers hold kt.mu.
+func (kt *keyedTopic) connect() error {
+	if kt.kafka != nil && !kt.kafka.Closed() {
+		return nil
+	}
+	kafka, err := sarama.NewClient(kt.brokers, kt.config)
+	if err != nil {
+		return err
+	}
+	producer, err := sarama.NewSyncProducerFromClient(kafka)
+	if err != nil {
+		_ = kafka.Close()
+		return err
+	}
+	consumer, err := sarama.NewConsumerFromClient(kafka)
+	if err != nil {
+		_ = producer.Close()
+		_ = kafka.Close()
+		return err
+	}
+	kt.kafka, kt.producer, kt.consumer = kafka, producer, consumer
+	return nil
+}
+
+// disconnect closes the connection of the view after an error, the next call opening it again. Callers hold kt.mu.
+func (kt *keyedTopic) disconnect() {
+	if kt.kafka == nil {
+		return
+	}
+	_ = kt.consumer.Close()
+	_ = kt.producer.Close()
+	_ = kt.kafka.Close()
+	kt.kafka, kt.producer, kt.consumer = nil, nil, nil
+}
@@ -0,0 +1,224 @@
+package client
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// Outcomes of electing the preferred leader of a partition
+const (
+	LeaderElected = "elected"
+	// LeaderElectionNotNeeded tells that the preferred replica of the partition already leads it
+	LeaderElectionNotNeeded = "notNeeded"
+	LeaderElectionFailed    = "failed"
+)
+
+// LeaderElection tells the outcome of electing the preferred leader of a partition, the first of its replicas
+type LeaderElection struct {
+	Partition int32  `json:"partition"`
+	Outcome   string `json:"outcome"`
+	Error     string `json:"error,omitempty"`
+}
+
+// electLeadersKey is the key of the ElectLeaders API, versions 0 and 1 of which are laid out alike but for the type
+// of election, preferred being 0
+const electLeadersKey = 43
+
+func (kfc *kafkaClient) ElectPreferredLeaders(topicName string) ([]LeaderElection, error) {
+	kafka, err := sarama.NewClient(kfc.brokers, kfc.config)
+	if err != nil {
+		return nil, err
+	}
+	defer kafka.Close()
+	partitions, err := kafka.Partitions(topicName)
+	if err != nil {
+		return nil, err
+	}
+	controller, err := kfc.Admin.Controller()
+	if err != nil {
+		return nil, err
+	}
+	response, err := controller.ApiVersions(&sarama.ApiVersionsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	versions, err := apiVersions(response)
+	if err != nil {
+		return nil, err
+	}
+	if err := versions.Require(FeatureLeaderElection); err != nil {
+		return nil, err
+	}
+	version := int16(0)
+	if versions[electLeadersKey] >= 1 {
+		version = 1
+	}
+	return electPreferredLeaders(controller.Addr(), kfc.config, version, topicName, partitions)
+}
+
+// electPreferredLeaders asks the controller to elect the preferred leaders of partitions of a topic. sarama doesn't
+// implement ElectLeaders, which is sent on a connection of its own.
+func electPreferredLeaders(address string, config *sarama.Config, version int16, topicName string, partitions []int32) ([]LeaderElection, error) {
+	dialer := &net.Dialer{Timeout: config.Net.DialTimeout, KeepAlive: config.Net.KeepAlive}
+	var conn net.Conn
+	var err error
+	if config.Net.TLS.Enable {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, config.Net.TLS.Config)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(config.Net.ReadTimeout)); err != nil {
+		return nil, err
+	}
+
+	body := &encoder{}
+	if version >= 1 {
+		body.int8(0)
+	}
+	body.int32(1)
+	body.string(topicName)
+	body.int32(int32(len(partitions)))
+	for _, partition := range partitions {
+		body.int32(partition)
+	}
+	body.int32(int32(config.Admin.Timeout / time.Millisecond))
+	request := &encoder{}
+	request.int16(electLeadersKey)
+	request.int16(version)
+	request.int32(1)
+	request.string(config.ClientID)
+	request.bytes(body.Bytes())
+	frame := &encoder{}
+	frame.int32(int32(request.Len()))
+	frame.bytes(request.Bytes())
+	if _, err := conn.Write(frame.Bytes()); err != nil {
+		return nil, err
+	}
+
+	var size int32
+	if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return nil, err
+	}
+	response := &decoder{buf: payload}
+	response.int32() // correlation id
+	response.int32() // throttle time
+	if version >= 1 {
+		if code := sarama.KError(response.int16()); code != sarama.ErrNoError {
+			return nil, code
+		}
+	}
+	var elections []LeaderElection
+	for topics := response.int32(); topics > 0; topics-- {
+		response.string()
+		for results := response.int32(); results > 0; results-- {
+			election := LeaderElection{Partition: response.int32(), Outcome: LeaderElected}
+			code := sarama.KError(response.int16())
+			message := response.nullableString()
+			switch code {
+			case sarama.ErrNoError:
+			case sarama.ErrElectionNotNeeded:
+				election.Outcome = LeaderElectionNotNeeded
+			default:
+				election.Outcome, election.Error = LeaderElectionFailed, code.Error()
+				if message != "" {
+					election.Error = message
+				}
+			}
+			elections = append(elections, election)
+		}
+	}
+	if response.err != nil {
+		return nil, fmt.Errorf("error decoding the response of the controller to a leader election: %v", response.err)
+	}
+	sort.Slice(elections, func(i, j int) bool {
+		return elections[i].Partition < elections[j].Partition
+	})
+	return elections, nil
+}
+
+// encoder lays out the fields of Kafka requests
+type encoder struct {
+	bytes.Buffer
+}
+
+func (e *encoder) int8(v int8) {
+	_ = binary.Write(e, binary.BigEndian, v)
+}
+
+func (e *encoder) int16(v int16) {
+	_ = binary.Write(e, binary.BigEndian, v)
+}
+
+func (e *encoder) int32(v int32) {
+	_ = binary.Write(e, binary.BigEndian, v)
+}
+
+func (e *encoder) string(v string) {
+	e.int16(int16(len(v)))
+	e.WriteString(v)
+}
+
+func (e *encoder) bytes(v []byte) {
+	e.Write(v)
+}
+
+// decoder reads the fields of Kafka responses, remembering the first error so that it is checked once
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.buf) < n {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	next := d.buf[:n]
+	d.buf = d.buf[n:]
+	return next
+}
+
+func (d *decoder) int16() int16 {
+	if b := d.next(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *decoder) int32() int32 {
+	if b := d.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *decoder) string() string {
+	return string(d.next(int(d.int16())))
+}
+
+func (d *decoder) nullableString() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(int(n)))
+}
@@ -0,0 +1,126 @@
+package client_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+
+	"github.com/Shopify/sarama"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+var _ = Describe("Electing preferred leaders", func() {
+	var (
+		broker       *sarama.MockBroker
+		controller   net.Listener
+		electLeaders int16
+		elected      chan []byte
+		kafkaClient  client.KafkaClient
+	)
+
+	BeforeEach(func() {
+		var err error
+		controller, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		electLeaders = 1
+		elected = make(chan []byte, 1)
+		go serveController(controller, &electLeaders, elected)
+
+		broker = sarama.NewMockBroker(GinkgoT(), int32(1))
+		broker.SetHandlerByMap(map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+				SetController(2).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetBroker(controller.Addr().String(), 2).
+				SetLeader("some-topic", 0, broker.BrokerID()).
+				SetLeader("some-topic", 1, broker.BrokerID()),
+		})
+		kafkaClient = newKafkaClient(broker)
+	})
+
+	AfterEach(func() {
+		Expect(kafkaClient.Close()).To(Succeed())
+		broker.Close()
+		Expect(controller.Close()).To(Succeed())
+	})
+
+	It("asks the controller to elect the preferred leaders of all the partitions of the topic", func() {
+		elections, err := kafkaClient.ElectPreferredLeaders("some-topic")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(elections).To(Equal([]client.LeaderElection{
+			{Partition: 0, Outcome: client.LeaderElected},
+			{Partition: 1, Outcome: client.LeaderElectionFailed, Error: "preferred replica out of sync"},
+		}))
+		body := <-elected
+		expected := &bytes.Buffer{}
+		for _, field := range []interface{}{int8(0), int32(1), int16(len("some-topic")), []byte("some-topic"), int32(2), int32(0), int32(1), int32(3000)} {
+			Expect(binary.Write(expected, binary.BigEndian, field)).To(Succeed())
+		}
+		Expect(body).To(Equal(expected.Bytes()))
+	})
+
+	It("refuses to elect leaders on clusters older than Kafka 2.2", func() {
+		electLeaders = -1
+
+		_, err := kafkaClient.ElectPreferredLeaders("some-topic")
+
+		Expect(err).To(BeAssignableToTypeOf(&client.UnsupportedFeatureError{}))
+	})
+})
+
+// serveController answers ApiVersions requests, telling the ElectLeaders version supported when not negative, and
+// ElectLeaders ones, sending their body on elected and failing the election of partitions other than 0
+func serveController(listener net.Listener, electLeaders *int16, elected chan<- []byte) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				var size int32
+				if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+					return
+				}
+				request := make([]byte, size)
+				if _, err := io.ReadFull(conn, request); err != nil {
+					return
+				}
+				key := int16(binary.BigEndian.Uint16(request))
+				correlationID := request[4:8]
+				clientID := int(binary.BigEndian.Uint16(request[8:]))
+				body := request[10+clientID:]
+				response := &bytes.Buffer{}
+				response.Write(correlationID)
+				switch key {
+				case 18:
+					keys := [][3]int16{{0, 0, 3}, {3, 0, 5}}
+					if *electLeaders >= 0 {
+						keys = append(keys, [3]int16{43, 0, *electLeaders})
+					}
+					_ = binary.Write(response, binary.BigEndian, int16(0))
+					_ = binary.Write(response, binary.BigEndian, int32(len(keys)))
+					_ = binary.Write(response, binary.BigEndian, keys)
+				case 43:
+					elected <- body
+					for _, field := range []interface{}{
+						int32(0), int16(0), int32(1), int16(len("some-topic")), []byte("some-topic"), int32(2),
+						int32(0), int16(0), int16(-1),
+						int32(1), int16(80), int16(len("preferred replica out of sync")), []byte("preferred replica out of sync"),
+					} {
+						_ = binary.Write(response, binary.BigEndian, field)
+					}
+				default:
+					return
+				}
+				_ = binary.Write(conn, binary.BigEndian, int32(response.Len()))
+				_, _ = conn.Write(response.Bytes())
+			}
+		}()
+	}
+}
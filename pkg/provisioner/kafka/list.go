@@ -0,0 +1,111 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Shopify/sarama"
+)
+
+// listTopicsInChunks lists topics as ListTopics does, but describes their configs by chunks of listChunkSize topics,
+// spread across the brokers and listConcurrency chunks at a time, rather than by a single request to one broker that
+// takes seconds to answer on clusters with thousands of topics
+func (kfc *kafkaClient) listTopicsInChunks() (map[string]TopicSpec, error) {
+	kafka, err := sarama.NewClient(kfc.brokers, kfc.config)
+	if err != nil {
+		return nil, err
+	}
+	defer kafka.Close()
+	names, err := kafka.Topics()
+	if err != nil {
+		return nil, err
+	}
+	brokers := kafka.Brokers()
+	if len(brokers) == 0 {
+		return nil, sarama.ErrOutOfBrokers
+	}
+	topics := make(map[string]TopicSpec, len(names))
+	for _, name := range names {
+		partitions, err := kafka.Partitions(name)
+		if err != nil {
+			return nil, err
+		}
+		spec := TopicSpec{NumPartitions: int32(len(partitions))}
+		if len(partitions) > 0 {
+			replicas, err := kafka.Replicas(name, partitions[0])
+			if err != nil {
+				return nil, err
+			}
+			spec.ReplicationFactor = int16(len(replicas))
+		}
+		topics[name] = spec
+	}
+
+	var chunks [][]string
+	for start := 0; start < len(names); start += kfc.listChunkSize {
+		chunks = append(chunks, names[start:min(start+kfc.listChunkSize, len(names))])
+	}
+	concurrency := kfc.listConcurrency
+	if concurrency <= 0 {
+		concurrency = len(brokers)
+	}
+	configs := make([]map[string]map[string]*string, len(chunks))
+	errs := make([]error, len(chunks))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, chunk []string, broker *sarama.Broker) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			configs[i], errs[i] = kfc.describeTopicConfigs(broker, chunk)
+		}(i, chunk, brokers[i%len(brokers)])
+	}
+	wg.Wait()
+	for i := range chunks {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		for name, entries := range configs[i] {
+			spec := topics[name]
+			spec.ConfigEntries = entries
+			topics[name] = spec
+		}
+	}
+	return topics, nil
+}
+
+// describeTopicConfigs returns the config overrides of topics, by topic name, described by a broker. Topics deleted
+// since they were listed have none.
+func (kfc *kafkaClient) describeTopicConfigs(broker *sarama.Broker, topicNames []string) (map[string]map[string]*string, error) {
+	// the broker may be connected already
+	_ = broker.Open(kfc.config)
+	request := &sarama.DescribeConfigsRequest{}
+	if kfc.config.Version.IsAtLeast(sarama.V1_1_0_0) {
+		request.Version = 1
+	}
+	if kfc.config.Version.IsAtLeast(sarama.V2_0_0_0) {
+		request.Version = 2
+	}
+	for _, name := range topicNames {
+		request.Resources = append(request.Resources, &sarama.ConfigResource{Type: sarama.TopicResource, Name: name})
+	}
+	response, err := broker.DescribeConfigs(request)
+	if err != nil {
+		return nil, fmt.Errorf("error describing the configs of %d topics on broker %d: %v", len(topicNames), broker.ID(), err)
+	}
+	configs := make(map[string]map[string]*string, len(response.Resources))
+	for _, resource := range response.Resources {
+		entries := make(map[string]*string)
+		for _, entry := range resource.Configs {
+			// as ListTopics does, only overrides are kept
+			if entry.Default || entry.Sensitive {
+				continue
+			}
+			entries[entry.Name] = &entry.Value
+		}
+		configs[resource.Name] = entries
+	}
+	return configs, nil
+}
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// MetadataTopic records the metadata of streams, keyed by the name of their topic. Its name can't collide with
+// the topics of streams, which all have an underscore between their namespace and name.
+const MetadataTopic = "riff-stream-metadata"
+
+// StreamMetadata is what riff records about a stream beyond its topic, so that the broker holds the authoritative
+// record of it
+type StreamMetadata struct {
+	ContentType string            `json:"contentType,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	// Envelope, when set, is how the records of the stream are framed, so that producers and the gateway agree on it
+	Envelope string `json:"envelope,omitempty"`
+	// Schema, when set, tells the subject of the schema of the stream's values in a Schema Registry
+	Schema *SchemaSubject `json:"schema,omitempty"`
+	// Archived, when set, tells that the stream was deleted and that its topic is kept until its grace period
+	// is over
+	Archived *Archive `json:"archived,omitempty"`
+	// Migration, when set, tells that the stream was renamed and that its records are being copied to the topic of
+	// its new name
+	Migration *Migration `json:"migration,omitempty"`
+	// Spec, when set, is the layout and config overrides the topic was provisioned with, which its live config is
+	// reconciled against
+	Spec *TopicSpec `json:"spec,omitempty"`
+}
+
+// Migration records the new topic of a renamed stream, its records being copied there until the stream is deleted
+type Migration struct {
+	To    string    `json:"to"`
+	Since time.Time `json:"since"`
+}
+
+// Archive records when a stream was archived rather than deleted, and how to restore its topic
+type Archive struct {
+	Since time.Time `json:"since"`
+	// DeleteAfter is when the grace period is over, the topic then being deleted
+	DeleteAfter time.Time `json:"deleteAfter"`
+	// ConfigEntries are the config overrides of the topic before it was archived, restored should the stream be
+	// provisioned again
+	ConfigEntries map[string]*string `json:"config,omitempty"`
+}
+
+// Subject name strategies, as named by Confluent's serializers
+const (
+	// TopicNameStrategy is the <topic>-value subject, the default
+	TopicNameStrategy = "topic-name"
+	// RecordNameStrategy is the fully qualified name of the record, sharing the schema across topics
+	RecordNameStrategy = "record-name"
+	// TopicRecordNameStrategy is the <topic>-<record> subject, topics holding records of several types
+	TopicRecordNameStrategy = "topic-record-name"
+)
+
+// Envelopes framing the records of streams
+const (
+	// RawEnvelope records values as is, the gateway leaving them alone
+	RawEnvelope = "raw"
+	// CloudEventsEnvelope records CloudEvents, in binary mode with their attributes as headers, or in structured mode
+	CloudEventsEnvelope = "cloudevents"
+	// RiffEnvelope records values in riff's message encoding, framing payloads with their headers, which the gateway
+	// leaves to the processors of streams
+	RiffEnvelope = "riff"
+)
+
+// ValidateEnvelope checks that an envelope is known, empty leaving the framing of records unspecified
+func ValidateEnvelope(envelope string) error {
+	switch envelope {
+	case "", RawEnvelope, CloudEventsEnvelope, RiffEnvelope:
+		return nil
+	default:
+		return fmt.Errorf("unknown envelope %q, should be one of %s, %s or %s", envelope, RawEnvelope, CloudEventsEnvelope, RiffEnvelope)
+	}
+}
+
+// SchemaSubject names the subject of the schema of a stream, following the naming strategy its consumers expect
+type SchemaSubject struct {
+	SubjectNameStrategy string `json:"subjectNameStrategy"`
+	// RecordName is the fully qualified name of the record of the stream's values, e.g. com.example.Order, required
+	// by the strategies naming subjects after it
+	RecordName string `json:"recordName,omitempty"`
+}
+
+var recordName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// Validate checks that the strategy is known and has the record name it needs
+func (s *SchemaSubject) Validate() error {
+	switch s.SubjectNameStrategy {
+	case TopicNameStrategy:
+		if s.RecordName != "" {
+			return fmt.Errorf("the %s subject name strategy doesn't use a record name", TopicNameStrategy)
+		}
+		return nil
+	case RecordNameStrategy, TopicRecordNameStrategy:
+		if !recordName.MatchString(s.RecordName) {
+			return fmt.Errorf("the %s subject name strategy requires the fully qualified name of the record, e.g. com.example.Order, got %q", s.SubjectNameStrategy, s.RecordName)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown subject name strategy %q, should be one of %s, %s or %s", s.SubjectNameStrategy, TopicNameStrategy, RecordNameStrategy, TopicRecordNameStrategy)
+	}
+}
+
+// Subject returns the subject of the schema of the values of a topic, <topic>-value when s is nil
+func (s *SchemaSubject) Subject(topic string) string {
+	if s == nil {
+		return topic + "-value"
+	}
+	switch s.SubjectNameStrategy {
+	case RecordNameStrategy:
+		return s.RecordName
+	case TopicRecordNameStrategy:
+		return topic + "-" + s.RecordName
+	default:
+		return topic + "-value"
+	}
+}
+
+func (kfc *kafkaClient) WriteMetadata(topicName string, metadata StreamMetadata) error {
+	view := kfc.keyedTopic(MetadataTopic)
+	if err := view.create(kfc.createCompactedTopic); err != nil {
+		return err
+	}
+	value, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	return view.produce(topicName, value)
+}
+
+// DeleteMetadata records a tombstone, compaction then removing the metadata of the topic
+func (kfc *kafkaClient) DeleteMetadata(topicName string) error {
+	return kfc.keyedTopic(MetadataTopic).produce(topicName, nil)
+}
+
+// createCompactedTopic creates a compacted topic of a single partition, such as the metadata topic, unless it exists.
+// Being the authoritative record of streams, it is replicated as the brokers replicate topics by default, unless
+// configured otherwise.
+func (kfc *kafkaClient) createCompactedTopic(topic string) error {
+	compact := "compact"
+	spec := TopicSpec{
+		NumPartitions:     1,
+		ReplicationFactor: kfc.internalReplicationFactor,
+		ConfigEntries:     map[string]*string{"cleanup.policy": &compact},
+	}
+	err := kfc.CreateTopic(topic, spec)
+	if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+		return nil
+	}
+	return err
+}
+
+// ReadMetadata returns the last metadata recorded for a topic, compaction keeping the metadata topic about as large
+// as the number of streams
+func (kfc *kafkaClient) ReadMetadata(topicName string) (*StreamMetadata, error) {
+	var metadata *StreamMetadata
+	err := kfc.keyedTopic(MetadataTopic).read(func(values map[string][]byte) error {
+		value, ok := values[topicName]
+		if !ok {
+			return nil
+		}
+		metadata = &StreamMetadata{}
+		return unmarshalMetadata(topicName, value, metadata)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func (kfc *kafkaClient) ListMetadata() (map[string]StreamMetadata, error) {
+	var metadata map[string]StreamMetadata
+	err := kfc.keyedTopic(MetadataTopic).read(func(values map[string][]byte) error {
+		metadata = make(map[string]StreamMetadata, len(values))
+		for topicName, value := range values {
+			var m StreamMetadata
+			if err := unmarshalMetadata(topicName, value, &m); err != nil {
+				return err
+			}
+			metadata[topicName] = m
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func unmarshalMetadata(topicName string, value []byte, metadata *StreamMetadata) error {
+	if err := json.Unmarshal(value, metadata); err != nil {
+		return fmt.Errorf("invalid metadata recorded for topic %q: %v", topicName, err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/projectriff/kafka-provisioner/pkg/env"
+)
+
+// Tuning adapts the sarama clients of the provisioner and the gateway to the cluster and the links to it, e.g. the
+// high-latency links to managed clusters that sarama's defaults don't suit. Zero fields keep sarama's defaults.
+type Tuning struct {
+	// AdminTimeout is how long the controller may take to carry out admin operations, such as creating topics
+	AdminTimeout time.Duration
+	// DialTimeout is how long connecting to a broker may take
+	DialTimeout time.Duration
+	// KeepAlive is the period of the keep-alive probes of connections to brokers
+	KeepAlive time.Duration
+	// MaxOpenRequests bounds the requests awaiting a response on a connection
+	MaxOpenRequests int
+	// MetadataRetryMax and MetadataRetryBackoff tell how many times, and how often, fetching the metadata of the
+	// cluster is retried, e.g. while a leader is being elected
+	MetadataRetryMax     int
+	MetadataRetryBackoff time.Duration
+	// ListChunkSize, when set, is the number of topics whose configs are described by each request when listing
+	// topics, rather than all topics by a single request
+	ListChunkSize int
+	// ListConcurrency bounds the chunks of topics described at once, the number of brokers when zero
+	ListConcurrency int
+	// InternalReplicationFactor is the replication factor of the metadata and journal topics, the default
+	// replication factor of the brokers when zero
+	InternalReplicationFactor int
+}
+
+// adminResponseMargin is how long the responses of admin operations may take to arrive once the controller is done
+const adminResponseMargin = 5 * time.Second
+
+// TuningFromEnv reads the tuning of sarama clients from KAFKA_ADMIN_TIMEOUT, KAFKA_DIAL_TIMEOUT, KAFKA_KEEP_ALIVE,
+// KAFKA_MAX_OPEN_REQUESTS, KAFKA_METADATA_RETRY_MAX, KAFKA_METADATA_RETRY_BACKOFF, KAFKA_LIST_CHUNK_SIZE,
+// KAFKA_LIST_CONCURRENCY and KAFKA_INTERNAL_REPLICATION_FACTOR
+func TuningFromEnv() (Tuning, error) {
+	var t Tuning
+	var err error
+	for name, d := range map[string]*time.Duration{
+		"KAFKA_ADMIN_TIMEOUT":          &t.AdminTimeout,
+		"KAFKA_DIAL_TIMEOUT":           &t.DialTimeout,
+		"KAFKA_KEEP_ALIVE":             &t.KeepAlive,
+		"KAFKA_METADATA_RETRY_BACKOFF": &t.MetadataRetryBackoff,
+	} {
+		if *d, err = env.Duration(name, 0); err != nil {
+			return Tuning{}, err
+		}
+		if *d < 0 {
+			return Tuning{}, fmt.Errorf("environment variable %s should be a positive duration, got %v", name, *d)
+		}
+	}
+	for name, i := range map[string]*int{
+		"KAFKA_MAX_OPEN_REQUESTS":           &t.MaxOpenRequests,
+		"KAFKA_METADATA_RETRY_MAX":          &t.MetadataRetryMax,
+		"KAFKA_LIST_CHUNK_SIZE":             &t.ListChunkSize,
+		"KAFKA_LIST_CONCURRENCY":            &t.ListConcurrency,
+		"KAFKA_INTERNAL_REPLICATION_FACTOR": &t.InternalReplicationFactor,
+	} {
+		if *i, err = env.Int(name); err != nil {
+			return Tuning{}, err
+		}
+		if *i < 0 {
+			return Tuning{}, fmt.Errorf("environment variable %s should be positive, got %d", name, *i)
+		}
+	}
+	if t.InternalReplicationFactor > math.MaxInt16 {
+		return Tuning{}, fmt.Errorf("environment variable KAFKA_INTERNAL_REPLICATION_FACTOR should be at most %d, got %d", math.MaxInt16, t.InternalReplicationFactor)
+	}
+	return t, nil
+}
+
+// Apply sets the fields of config t tunes
+func (t Tuning) Apply(config *sarama.Config) {
+	if t.AdminTimeout > 0 {
+		config.Admin.Timeout = t.AdminTimeout
+		// the controller answers once done or timed out, responses taking as long
+		if config.Net.ReadTimeout < t.AdminTimeout+adminResponseMargin {
+			config.Net.ReadTimeout = t.AdminTimeout + adminResponseMargin
+		}
+	}
+	if t.DialTimeout > 0 {
+		config.Net.DialTimeout = t.DialTimeout
+	}
+	if t.KeepAlive > 0 {
+		config.Net.KeepAlive = t.KeepAlive
+	}
+	if t.MaxOpenRequests > 0 {
+		config.Net.MaxOpenRequests = t.MaxOpenRequests
+	}
+	if t.MetadataRetryMax > 0 {
+		config.Metadata.Retry.Max = t.MetadataRetryMax
+	}
+	if t.MetadataRetryBackoff > 0 {
+		config.Metadata.Retry.Backoff = t.MetadataRetryBackoff
+	}
+}
@@ -0,0 +1,94 @@
+package client_test
+
+import (
+	"os"
+	"time"
+
+	"github.com/Shopify/sarama"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+var _ = Describe("Tuning", func() {
+
+	variables := []string{"KAFKA_ADMIN_TIMEOUT", "KAFKA_DIAL_TIMEOUT", "KAFKA_KEEP_ALIVE", "KAFKA_MAX_OPEN_REQUESTS", "KAFKA_METADATA_RETRY_MAX", "KAFKA_METADATA_RETRY_BACKOFF", "KAFKA_LIST_CHUNK_SIZE", "KAFKA_LIST_CONCURRENCY", "KAFKA_INTERNAL_REPLICATION_FACTOR"}
+
+	AfterEach(func() {
+		for _, name := range variables {
+			Expect(os.Unsetenv(name)).To(Succeed())
+		}
+	})
+
+	It("keeps sarama's defaults when unset", func() {
+		tuning, err := client.TuningFromEnv()
+		Expect(err).NotTo(HaveOccurred())
+		config := sarama.NewConfig()
+
+		tuning.Apply(config)
+
+		defaults := sarama.NewConfig()
+		Expect(config.Admin).To(Equal(defaults.Admin))
+		Expect(config.Net.DialTimeout).To(Equal(defaults.Net.DialTimeout))
+		Expect(config.Net.ReadTimeout).To(Equal(defaults.Net.ReadTimeout))
+		Expect(config.Net.KeepAlive).To(Equal(defaults.Net.KeepAlive))
+		Expect(config.Net.MaxOpenRequests).To(Equal(defaults.Net.MaxOpenRequests))
+		Expect(config.Metadata.Retry).To(Equal(defaults.Metadata.Retry))
+	})
+
+	It("reads the tuning from the environment", func() {
+		for name, value := range map[string]string{
+			"KAFKA_ADMIN_TIMEOUT":               "60s",
+			"KAFKA_DIAL_TIMEOUT":                "5s",
+			"KAFKA_KEEP_ALIVE":                  "30s",
+			"KAFKA_MAX_OPEN_REQUESTS":           "1",
+			"KAFKA_METADATA_RETRY_MAX":          "10",
+			"KAFKA_METADATA_RETRY_BACKOFF":      "1s",
+			"KAFKA_LIST_CHUNK_SIZE":             "500",
+			"KAFKA_LIST_CONCURRENCY":            "4",
+			"KAFKA_INTERNAL_REPLICATION_FACTOR": "3",
+		} {
+			Expect(os.Setenv(name, value)).To(Succeed())
+		}
+
+		tuning, err := client.TuningFromEnv()
+		Expect(err).NotTo(HaveOccurred())
+		config := sarama.NewConfig()
+		tuning.Apply(config)
+
+		Expect(config.Admin.Timeout).To(Equal(time.Minute))
+		Expect(config.Net.DialTimeout).To(Equal(5 * time.Second))
+		Expect(config.Net.KeepAlive).To(Equal(30 * time.Second))
+		Expect(config.Net.MaxOpenRequests).To(Equal(1))
+		Expect(config.Metadata.Retry.Max).To(Equal(10))
+		Expect(config.Metadata.Retry.Backoff).To(Equal(time.Second))
+		// responses to admin operations are awaited for as long as the controller may take
+		Expect(config.Net.ReadTimeout).To(BeNumerically(">", time.Minute))
+		Expect(config.Validate()).To(Succeed())
+		Expect(tuning.ListChunkSize).To(Equal(500))
+		Expect(tuning.ListConcurrency).To(Equal(4))
+		Expect(tuning.InternalReplicationFactor).To(Equal(3))
+	})
+
+	It("rejects negative values", func() {
+		for _, name := range variables {
+			Expect(os.Setenv(name, "-1")).To(Succeed())
+			if name != "KAFKA_MAX_OPEN_REQUESTS" && name != "KAFKA_METADATA_RETRY_MAX" && name != "KAFKA_LIST_CHUNK_SIZE" && name != "KAFKA_LIST_CONCURRENCY" && name != "KAFKA_INTERNAL_REPLICATION_FACTOR" {
+				Expect(os.Setenv(name, "-1s")).To(Succeed())
+			}
+
+			_, err := client.TuningFromEnv()
+
+			Expect(err).To(MatchError(ContainSubstring(name)), name)
+			Expect(os.Unsetenv(name)).To(Succeed())
+		}
+	})
+
+	It("rejects internal replication factors topics can't have", func() {
+		Expect(os.Setenv("KAFKA_INTERNAL_REPLICATION_FACTOR", "40000")).To(Succeed())
+
+		_, err := client.TuningFromEnv()
+
+		Expect(err).To(MatchError(ContainSubstring("KAFKA_INTERNAL_REPLICATION_FACTOR")))
+	})
+})
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/Shopify/sarama"
+)
+
+// Feature is a feature of Kafka the provisioner or the gateway relies on, supported by brokers implementing an API
+// at some version
+type Feature struct {
+	Name       string
+	APIKey     int16
+	MinVersion int16
+	// Since is the first version of Kafka supporting the feature
+	Since string
+}
+
+// Features relied on, by the API they take
+var (
+	// FeatureTopicConfigs describes and alters the configs of topics, which provisioning relies on
+	FeatureTopicConfigs = Feature{Name: "describing and altering topic configs", APIKey: 33, Since: "0.11"}
+	// FeatureIdempotence initializes the ids of idempotent producers
+	FeatureIdempotence = Feature{Name: "idempotent producers", APIKey: 22, Since: "0.11"}
+	// FeatureTransactions adds the partitions of records to transactions
+	FeatureTransactions = Feature{Name: "transactions", APIKey: 24, Since: "0.11"}
+	// FeatureGroupDeletion deletes consumer groups
+	FeatureGroupDeletion = Feature{Name: "deleting consumer groups", APIKey: 42, Since: "1.1"}
+	// FeatureZstd produces batches compressed with zstd
+	FeatureZstd = Feature{Name: "zstd compression", APIKey: 0, MinVersion: 7, Since: "2.1"}
+	// FeatureLeaderElection elects the preferred leaders of partitions
+	FeatureLeaderElection = Feature{Name: "electing preferred leaders", APIKey: 43, Since: "2.2"}
+	// FeatureBrokerDefaults creates topics with the default partitions and replication factor of the broker
+	FeatureBrokerDefaults = Feature{Name: "creating topics with the broker's default partitions and replication factor", APIKey: 19, MinVersion: 4, Since: "2.4"}
+	// FeaturePrefixedACLs creates ACLs on all the resources whose name starts with a prefix
+	FeaturePrefixedACLs = Feature{Name: "prefixed ACLs", APIKey: 30, MinVersion: 1, Since: "2.0"}
+)
+
+// UnsupportedFeatureError tells that the cluster doesn't support a feature
+type UnsupportedFeatureError struct {
+	Feature Feature
+}
+
+func (e *UnsupportedFeatureError) Error() string {
+	return fmt.Sprintf("the Kafka cluster doesn't support %s, which requires Kafka %s or later", e.Feature.Name, e.Feature.Since)
+}
+
+// APIVersions are the latest versions of the APIs a broker supports, by API key
+type APIVersions map[int16]int16
+
+// Supports tells whether the broker supports a feature
+func (v APIVersions) Supports(feature Feature) bool {
+	version, ok := v[feature.APIKey]
+	return ok && version >= feature.MinVersion
+}
+
+// Require returns an UnsupportedFeatureError for the first feature the broker doesn't support, if any
+func (v APIVersions) Require(features ...Feature) error {
+	for _, feature := range features {
+		if !v.Supports(feature) {
+			return &UnsupportedFeatureError{Feature: feature}
+		}
+	}
+	return nil
+}
+
+// ProbeAPIVersions asks the first broker reachable the versions of the APIs it supports, which requires Kafka 0.10
+func ProbeAPIVersions(brokerAddresses []string, tuning Tuning) (APIVersions, error) {
+	config := sarama.NewConfig()
+	config.Version = sarama.V0_10_0_0
+	config.ClientID = "kafka-provisioner"
+	tuning.Apply(config)
+	var err error
+	for _, address := range brokerAddresses {
+		var versions APIVersions
+		if versions, err = probeAPIVersions(address, config); err == nil {
+			return versions, nil
+		}
+	}
+	return nil, err
+}
+
+func probeAPIVersions(address string, config *sarama.Config) (APIVersions, error) {
+	broker := sarama.NewBroker(address)
+	if err := broker.Open(config); err != nil {
+		return nil, err
+	}
+	defer broker.Close()
+	response, err := broker.ApiVersions(&sarama.ApiVersionsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("error asking broker %q the versions of the APIs it supports, which requires Kafka 0.10 or later: %v", address, err)
+	}
+	return apiVersions(response)
+}
+
+func apiVersions(response *sarama.ApiVersionsResponse) (APIVersions, error) {
+	if response.ErrorCode != int16(sarama.ErrNoError) {
+		return nil, sarama.KError(response.ErrorCode)
+	}
+	versions := make(APIVersions, len(response.ApiKeys))
+	for _, key := range response.ApiKeys {
+		versions[key.ApiKey] = key.MaxVersion
+	}
+	return versions, nil
+}
@@ -0,0 +1,43 @@
+package client_test
+
+import (
+	"github.com/Shopify/sarama"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+var _ = Describe("API versions", func() {
+
+	It("probes the versions of the APIs brokers support", func() {
+		broker := sarama.NewMockBroker(GinkgoT(), int32(1))
+		defer broker.Close()
+		broker.SetHandlerByMap(map[string]sarama.MockResponse{
+			"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(GinkgoT()).SetApiKeys([]sarama.ApiVersionsResponseKey{
+				{ApiKey: 0, MinVersion: 0, MaxVersion: 5},
+				{ApiKey: 33, MinVersion: 0, MaxVersion: 1},
+			}),
+		})
+
+		versions, err := client.ProbeAPIVersions([]string{"127.0.0.1:1", broker.Addr()}, client.Tuning{})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(versions).To(Equal(client.APIVersions{0: 5, 33: 1}))
+	})
+
+	It("fails when no broker is reachable", func() {
+		_, err := client.ProbeAPIVersions([]string{"127.0.0.1:1"}, client.Tuning{})
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("tells the features the cluster doesn't support and the version of Kafka they require", func() {
+		versions := client.APIVersions{0: 5, 22: 1, 24: 1, 33: 1}
+
+		Expect(versions.Require(client.FeatureTopicConfigs, client.FeatureIdempotence, client.FeatureTransactions)).To(Succeed())
+		Expect(versions.Supports(client.FeatureGroupDeletion)).To(BeFalse())
+		err := versions.Require(client.FeatureTopicConfigs, client.FeatureZstd)
+		Expect(err).To(BeAssignableToTypeOf(&client.UnsupportedFeatureError{}))
+		Expect(err).To(MatchError("the Kafka cluster doesn't support zstd compression, which requires Kafka 2.1 or later"))
+	})
+})
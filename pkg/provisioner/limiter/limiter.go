@@ -0,0 +1,210 @@
+// Package limiter bounds the number of Kafka admin operations run at once, and the rate of those mutating the
+// metadata of the cluster, so that a burst of provisioning requests queues in the provisioner rather than on the
+// Kafka controller
+package limiter
+
+import (
+	"sync"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// Limiter lets up to a fixed number of admin operations run at once, the others waiting for their turn. Waiting
+// operations are queued by namespace and their namespaces take turns, so that a namespace provisioning many streams
+// doesn't hold back the others.
+type Limiter struct {
+	max int
+	// Mutations throttles the operations creating, deleting and altering topics, before they wait for their turn,
+	// when set
+	Mutations *Throttle
+
+	mu      sync.Mutex
+	running int
+	// queues are the operations waiting, by namespace, and turns the namespaces with operations waiting, in the
+	// order they take their turn
+	queues map[string][]chan struct{}
+	turns  []string
+}
+
+// New creates a limiter letting max operations run at once, not limiting them when max is not positive
+func New(max int) *Limiter {
+	return &Limiter{max: max, queues: make(map[string][]chan struct{})}
+}
+
+// Disabled tells whether operations run without limit
+func (l *Limiter) Disabled() bool {
+	return l.max <= 0
+}
+
+// Acquire waits until an operation of a namespace may run
+func (l *Limiter) Acquire(namespace string) {
+	if l.Disabled() {
+		return
+	}
+	l.mu.Lock()
+	if l.running < l.max {
+		l.running++
+		l.mu.Unlock()
+		return
+	}
+	turn := make(chan struct{})
+	if len(l.queues[namespace]) == 0 {
+		l.turns = append(l.turns, namespace)
+	}
+	l.queues[namespace] = append(l.queues[namespace], turn)
+	l.mu.Unlock()
+	<-turn
+}
+
+// Release lets another operation run once one is done, that of the next namespace whose turn it is
+func (l *Limiter) Release() {
+	if l.Disabled() {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.turns) == 0 {
+		l.running--
+		return
+	}
+	namespace := l.turns[0]
+	l.turns = l.turns[1:]
+	queue := l.queues[namespace]
+	if len(queue) > 1 {
+		l.queues[namespace] = queue[1:]
+		l.turns = append(l.turns, namespace)
+	} else {
+		delete(l.queues, namespace)
+	}
+	// the slot is handed over, the count of operations running staying the same
+	close(queue[0])
+}
+
+// Waiting returns the number of operations waiting for their turn
+func (l *Limiter) Waiting() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	waiting := 0
+	for _, queue := range l.queues {
+		waiting += len(queue)
+	}
+	return waiting
+}
+
+// WrapKafkaClient bounds the admin operations of kafkaClient, across all the clients it wraps, queueing them by the
+// namespace of their topic. Operations on all topics share a queue of their own. The metadata of streams is
+// produced and consumed rather than administered, and isn't bounded.
+func (l *Limiter) WrapKafkaClient(kafkaClient client.KafkaClient) client.KafkaClient {
+	if l.Disabled() && l.Mutations.Disabled() {
+		return kafkaClient
+	}
+	return &limitedClient{KafkaClient: kafkaClient, limiter: l}
+}
+
+type limitedClient struct {
+	client.KafkaClient
+	limiter *Limiter
+}
+
+// acquire waits for the turn of the namespace of a topic, that of topics outside namespaces being ""
+func (c *limitedClient) acquire(topicName string) {
+	namespace, _, _ := validation.ParseTopicName(topicName)
+	c.limiter.Acquire(namespace)
+}
+
+func (c *limitedClient) TopicExists(topicName string) (bool, *client.KafkaError) {
+	c.acquire(topicName)
+	defer c.limiter.Release()
+	return c.KafkaClient.TopicExists(topicName)
+}
+
+func (c *limitedClient) CreateTopic(topicName string, spec client.TopicSpec) error {
+	c.limiter.Mutations.Wait()
+	c.acquire(topicName)
+	defer c.limiter.Release()
+	return c.KafkaClient.CreateTopic(topicName, spec)
+}
+
+func (c *limitedClient) DeleteTopic(topicName string) error {
+	c.limiter.Mutations.Wait()
+	c.acquire(topicName)
+	defer c.limiter.Release()
+	return c.KafkaClient.DeleteTopic(topicName)
+}
+
+// DeleteTopics throttles each topic deleted, and waits for the turn of the namespace of the first topic, those of a
+// batch usually being of a single namespace
+func (c *limitedClient) DeleteTopics(topicNames []string) (map[string]error, error) {
+	for range topicNames {
+		c.limiter.Mutations.Wait()
+	}
+	if len(topicNames) > 0 {
+		c.acquire(topicNames[0])
+	} else {
+		c.limiter.Acquire("")
+	}
+	defer c.limiter.Release()
+	return c.KafkaClient.DeleteTopics(topicNames)
+}
+
+func (c *limitedClient) AlterTopicConfig(topicName string, configEntries map[string]*string) error {
+	c.limiter.Mutations.Wait()
+	c.acquire(topicName)
+	defer c.limiter.Release()
+	return c.KafkaClient.AlterTopicConfig(topicName, configEntries)
+}
+
+func (c *limitedClient) ListTopics() (map[string]client.TopicSpec, error) {
+	c.limiter.Acquire("")
+	defer c.limiter.Release()
+	return c.KafkaClient.ListTopics()
+}
+
+func (c *limitedClient) DescribeTopic(topicName string) (*client.TopicSpec, error) {
+	c.acquire(topicName)
+	defer c.limiter.Release()
+	return c.KafkaClient.DescribeTopic(topicName)
+}
+
+func (c *limitedClient) GroupProgress(groupID, topicName string) ([]client.PartitionProgress, error) {
+	c.acquire(topicName)
+	defer c.limiter.Release()
+	return c.KafkaClient.GroupProgress(groupID, topicName)
+}
+
+func (c *limitedClient) CommitGroupOffsets(groupID, topicName string, startAt int64) (map[int32]int64, error) {
+	c.acquire(topicName)
+	defer c.limiter.Release()
+	return c.KafkaClient.CommitGroupOffsets(groupID, topicName, startAt)
+}
+
+func (c *limitedClient) TopicHealth(topicName string) (*client.TopicHealth, error) {
+	c.acquire(topicName)
+	defer c.limiter.Release()
+	return c.KafkaClient.TopicHealth(topicName)
+}
+
+func (c *limitedClient) ElectPreferredLeaders(topicName string) ([]client.LeaderElection, error) {
+	c.acquire(topicName)
+	defer c.limiter.Release()
+	return c.KafkaClient.ElectPreferredLeaders(topicName)
+}
+
+func (c *limitedClient) TopicConfigKeys() (client.TopicConfigKeys, error) {
+	c.limiter.Acquire("")
+	defer c.limiter.Release()
+	return c.KafkaClient.TopicConfigKeys()
+}
+
+func (c *limitedClient) BrokerConfig(name string) (*string, error) {
+	c.limiter.Acquire("")
+	defer c.limiter.Release()
+	return c.KafkaClient.BrokerConfig(name)
+}
+
+func (c *limitedClient) GrantTransactionalIDs(prefix, principal string) error {
+	c.limiter.Acquire("")
+	defer c.limiter.Release()
+	return c.KafkaClient.GrantTransactionalIDs(prefix, principal)
+}
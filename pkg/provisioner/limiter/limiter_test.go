@@ -0,0 +1,161 @@
+package limiter_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka/kafkafakes"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/limiter"
+)
+
+var _ = Describe("Limiter", func() {
+
+	var (
+		fakeKafkaClient *kafkafakes.FakeKafkaClient
+		running, most   int32
+		unblock         chan struct{}
+	)
+
+	BeforeEach(func() {
+		fakeKafkaClient = &kafkafakes.FakeKafkaClient{}
+		running, most = 0, 0
+		unblock = make(chan struct{})
+		fakeKafkaClient.CreateTopicStub = func(string, client.TopicSpec) error {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				m := atomic.LoadInt32(&most)
+				if n <= m || atomic.CompareAndSwapInt32(&most, m, n) {
+					break
+				}
+			}
+			<-unblock
+			return nil
+		}
+	})
+
+	createTopics := func(kafkaClient client.KafkaClient, count int) *sync.WaitGroup {
+		wg := &sync.WaitGroup{}
+		for i := 0; i < count; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = kafkaClient.CreateTopic("ns_foo", client.DefaultTopicSpec())
+			}()
+		}
+		return wg
+	}
+
+	It("queues the operations beyond its limit, across the clients it wraps", func() {
+		l := limiter.New(2)
+
+		wg := createTopics(l.WrapKafkaClient(fakeKafkaClient), 3)
+		other := createTopics(l.WrapKafkaClient(fakeKafkaClient), 2)
+
+		Eventually(func() int32 { return atomic.LoadInt32(&running) }).Should(Equal(int32(2)))
+		Consistently(func() int32 { return atomic.LoadInt32(&running) }, 50*time.Millisecond).Should(Equal(int32(2)))
+		close(unblock)
+		wg.Wait()
+		other.Wait()
+		Expect(fakeKafkaClient.CreateTopicCallCount()).To(Equal(5))
+		Expect(atomic.LoadInt32(&most)).To(Equal(int32(2)))
+	})
+
+	It("lets namespaces take turns", func() {
+		l := limiter.New(1)
+		l.Acquire("")
+		var started []string
+		var m sync.Mutex
+		fakeKafkaClient.CreateTopicStub = func(topicName string, _ client.TopicSpec) error {
+			m.Lock()
+			defer m.Unlock()
+			started = append(started, topicName)
+			return nil
+		}
+		kafkaClient := l.WrapKafkaClient(fakeKafkaClient)
+		wg := &sync.WaitGroup{}
+		for i, topicName := range []string{"busy_a", "busy_b", "busy_c", "quiet_a"} {
+			wg.Add(1)
+			go func(topicName string) {
+				defer wg.Done()
+				_ = kafkaClient.CreateTopic(topicName, client.DefaultTopicSpec())
+			}(topicName)
+			// queued one after the other
+			Eventually(l.Waiting).Should(Equal(i + 1))
+		}
+
+		l.Release()
+		wg.Wait()
+
+		Expect(started).To(Equal([]string{"busy_a", "quiet_a", "busy_b", "busy_c"}))
+		Expect(l.Waiting()).To(BeZero())
+	})
+
+	It("doesn't bound the metadata of streams", func() {
+		l := limiter.New(1)
+		l.Acquire("ns")
+		defer l.Release()
+
+		_, err := l.WrapKafkaClient(fakeKafkaClient).ListMetadata()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeKafkaClient.ListMetadataCallCount()).To(Equal(1))
+	})
+
+	It("returns clients as they are when disabled", func() {
+		l := limiter.New(0)
+
+		Expect(l.Disabled()).To(BeTrue())
+		Expect(l.WrapKafkaClient(fakeKafkaClient)).To(BeIdenticalTo(fakeKafkaClient))
+	})
+	It("throttles the operations mutating topics, whichever the number running at once", func() {
+		l := limiter.New(0)
+		l.Mutations = limiter.NewThrottle(20)
+		kafkaClient := l.WrapKafkaClient(fakeKafkaClient)
+		fakeKafkaClient.CreateTopicStub = nil
+
+		start := time.Now()
+		// a second's worth of operations go through at once, the others waiting for their token
+		for i := 0; i < 22; i++ {
+			Expect(kafkaClient.CreateTopic("ns_foo", client.DefaultTopicSpec())).To(Succeed())
+		}
+		Expect(kafkaClient.DeleteTopic("ns_foo")).To(Succeed())
+
+		Expect(time.Since(start)).To(BeNumerically(">=", 140*time.Millisecond))
+		Expect(fakeKafkaClient.CreateTopicCallCount()).To(Equal(22))
+		Expect(fakeKafkaClient.DeleteTopicCallCount()).To(Equal(1))
+	})
+
+	It("tells how long until the operations throttled are let through", func() {
+		throttle := limiter.NewThrottle(10)
+		Expect(throttle.Backlog()).To(BeZero())
+
+		for i := 0; i < 15; i++ {
+			go throttle.Wait()
+		}
+
+		// the 5 operations beyond a second's worth wait for a token each
+		Eventually(throttle.Backlog).Should(BeNumerically(">", 400*time.Millisecond))
+		Expect(throttle.Backlog()).To(BeNumerically("<=", 500*time.Millisecond))
+		Expect(limiter.NewThrottle(0).Backlog()).To(BeZero())
+	})
+
+	It("doesn't throttle the operations describing topics", func() {
+		l := limiter.New(0)
+		l.Mutations = limiter.NewThrottle(1)
+		kafkaClient := l.WrapKafkaClient(fakeKafkaClient)
+
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			_, err := kafkaClient.ListTopics()
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+		Expect(limiter.NewThrottle(0).Disabled()).To(BeTrue())
+	})
+})
@@ -0,0 +1,76 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle limits the rate of the admin operations mutating the metadata of the cluster, such as creating, deleting
+// and altering topics, whichever the number of operations running at once: the Kafka controller degrades badly when
+// hammered with metadata mutations. Operations share a bucket refilled with rate tokens per second and holding up to
+// a second's worth, those finding it empty waiting for their token.
+type Throttle struct {
+	rate float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewThrottle creates a throttle letting rate operations per second through, not throttling them when rate is not
+// positive
+func NewThrottle(rate int) *Throttle {
+	return &Throttle{rate: float64(rate), tokens: float64(rate)}
+}
+
+// Disabled tells whether operations run without throttling
+func (t *Throttle) Disabled() bool {
+	return t == nil || t.rate <= 0
+}
+
+// Wait waits until an operation may run
+func (t *Throttle) Wait() {
+	if t.Disabled() {
+		return
+	}
+	if delay := t.reserve(); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// Backlog returns how long until the operations waiting for their token are let through, zero when an operation
+// may run right away
+func (t *Throttle) Backlog() time.Duration {
+	if t.Disabled() {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tokens := t.tokens
+	if !t.last.IsZero() {
+		tokens += time.Since(t.last).Seconds() * t.rate
+	}
+	if tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-tokens / t.rate * float64(time.Second))
+}
+
+// reserve takes a token of the bucket, returning how long until it is available
+func (t *Throttle) reserve() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if !t.last.IsZero() {
+		t.tokens += now.Sub(t.last).Seconds() * t.rate
+		if t.tokens > t.rate {
+			t.tokens = t.rate
+		}
+	}
+	t.last = now
+	t.tokens--
+	if t.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-t.tokens / t.rate * float64(time.Second))
+}
@@ -0,0 +1,222 @@
+// Package memory holds streams and their records in process, with an HTTP data plane following the HTTP API of the
+// gateway, so that demos and tests run without any broker. Streams and records are lost when the process exits.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/handler"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// Backend provisions streams of a single partition held in memory, and serves their records
+type Backend struct {
+	// MaxRecords caps the records retained by each stream, the oldest being dropped, unlimited when zero
+	MaxRecords int
+	// FetchTimeout is how long fetches wait for a record to be published at the offset following the last one
+	FetchTimeout time.Duration
+	Logger       *slog.Logger
+
+	mu      sync.Mutex
+	streams map[string]*stream
+}
+
+type stream struct {
+	metadata *client.StreamMetadata
+	// first is the offset of the oldest record retained
+	first   int64
+	records []record
+	// published is closed, and replaced, when a record is published, waking up the fetches waiting for it
+	published chan struct{}
+}
+
+type record struct {
+	value       []byte
+	contentType string
+}
+
+// NewBackend creates a backend without streams, fetches waiting 5 seconds for records like those of the gateway
+func NewBackend(maxRecords int, logger *slog.Logger) *Backend {
+	return &Backend{MaxRecords: maxRecords, FetchTimeout: 5 * time.Second, Logger: logger, streams: map[string]*stream{}}
+}
+
+func (b *Backend) CreateStream(_ context.Context, request handler.StreamRequest) (*handler.Stream, bool, error) {
+	topic, err := topicName(request.Namespace, request.Stream)
+	if err != nil {
+		return nil, false, err
+	}
+	if request.Replicate {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: streams held in memory aren't replicated", topic)}
+	}
+	if request.TieredStorage || request.Retention != (handler.Retention{}) {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: the retention of streams held in memory is capped by their number of records", topic)}
+	}
+	if request.Group != nil {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: streams held in memory have no consumer groups", topic)}
+	}
+	if request.Repartition {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: streams held in memory have a single partition", topic)}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.streams[topic]
+	if !ok {
+		s = &stream{published: make(chan struct{})}
+		b.streams[topic] = s
+		b.Logger.Debug("Created topic", "topic", topic)
+	}
+	if request.Metadata != nil {
+		s.metadata = request.Metadata
+	}
+	return &handler.Stream{Topic: topic, Metadata: s.metadata}, !ok, nil
+}
+
+func (b *Backend) DeleteStream(_ context.Context, namespace, name string) (*handler.Stream, error) {
+	topic, err := topicName(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.streams[topic]
+	if !ok {
+		return nil, &handler.StatusError{Status: http.StatusNotFound, Message: fmt.Sprintf("Topic %q does not exist", topic)}
+	}
+	delete(b.streams, topic)
+	// fetches waiting for records find the stream gone
+	close(s.published)
+	b.Logger.Info("Deleted topic", "topic", topic)
+	return nil, nil
+}
+
+func (b *Backend) DescribeStream(_ context.Context, namespace, name string) (*handler.Stream, error) {
+	topic, err := topicName(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.streams[topic]
+	if !ok {
+		return nil, &handler.StatusError{Status: http.StatusNotFound, Message: fmt.Sprintf("Topic %q does not exist", topic)}
+	}
+	return &handler.Stream{Topic: topic, Metadata: s.metadata}, nil
+}
+
+func (b *Backend) Capabilities() handler.Capabilities {
+	return handler.Capabilities{Backend: "memory", Metadata: true, MaxNameLength: validation.KafkaNaming{}.MaxLength()}
+}
+
+// ServeHTTP publishes the body of POST /<namespace>/<stream-name> requests as the value of a record, and returns
+// the record at an offset of partition 0 to GET /<namespace>/<stream-name>/0/<offset> requests, like the gateway
+func (b *Backend) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	parts := strings.Split(strings.Trim(request.URL.Path, "/"), "/")
+	switch {
+	case request.Method == http.MethodPost && len(parts) == 2:
+		b.publish(writer, request, validation.TopicName(parts[0], parts[1]))
+	case request.Method == http.MethodGet && len(parts) == 4:
+		partition, err := strconv.ParseInt(parts[2], 10, 32)
+		if err != nil || partition < 0 {
+			writer.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(writer, "Invalid partition %q\n", parts[2])
+			return
+		}
+		offset, err := strconv.ParseInt(parts[3], 10, 64)
+		if err != nil || offset < 0 {
+			writer.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(writer, "Invalid offset %q\n", parts[3])
+			return
+		}
+		b.fetch(writer, request, validation.TopicName(parts[0], parts[1]), partition, offset)
+	case request.Method != http.MethodPost && request.Method != http.MethodGet:
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		writer.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(writer, "URLs should be of the form /<namespace>/<stream-name> to publish, or /<namespace>/<stream-name>/<partition>/<offset> to fetch a record\n")
+	}
+}
+
+func (b *Backend) publish(writer http.ResponseWriter, request *http.Request, topic string) {
+	value, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(writer, "Error reading the record: %v\n", err)
+		return
+	}
+	b.mu.Lock()
+	s, ok := b.streams[topic]
+	if !ok {
+		b.mu.Unlock()
+		writer.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprintf(writer, "Topic %q does not exist\n", topic)
+		return
+	}
+	s.records = append(s.records, record{value: value, contentType: request.Header.Get("Content-Type")})
+	if b.MaxRecords > 0 && len(s.records) > b.MaxRecords {
+		dropped := len(s.records) - b.MaxRecords
+		s.records, s.first = append([]record(nil), s.records[dropped:]...), s.first+int64(dropped)
+	}
+	offset := s.first + int64(len(s.records)) - 1
+	close(s.published)
+	s.published = make(chan struct{})
+	b.mu.Unlock()
+
+	writer.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(writer).Encode(map[string]interface{}{"topic": topic, "partition": 0, "offset": offset})
+}
+
+// fetch returns a record as its content type, waiting up to FetchTimeout for the record following the last one
+func (b *Backend) fetch(writer http.ResponseWriter, request *http.Request, topic string, partition, offset int64) {
+	ctx, cancel := context.WithTimeout(request.Context(), b.FetchTimeout)
+	defer cancel()
+	for {
+		b.mu.Lock()
+		s, ok := b.streams[topic]
+		if !ok {
+			b.mu.Unlock()
+			writer.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprintf(writer, "Topic %q does not exist\n", topic)
+			return
+		}
+		next := s.first + int64(len(s.records))
+		if partition != 0 || offset < s.first || offset > next {
+			b.mu.Unlock()
+			writer.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprintf(writer, "there is no record at offset %d of partition %d\n", offset, partition)
+			return
+		}
+		if offset < next {
+			r := s.records[offset-s.first]
+			b.mu.Unlock()
+			if r.contentType != "" {
+				writer.Header().Set("Content-Type", r.contentType)
+			}
+			_, _ = writer.Write(r.value)
+			return
+		}
+		published := s.published
+		b.mu.Unlock()
+		select {
+		case <-published:
+		case <-ctx.Done():
+			writer.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprintf(writer, "there is no record at offset %d of partition %d yet\n", offset, partition)
+			return
+		}
+	}
+}
+
+// topicName returns the name of the topic of a stream, named like Kafka topics
+func topicName(namespace, name string) (string, error) {
+	return handler.StreamName(validation.KafkaNaming{}, namespace, name)
+}
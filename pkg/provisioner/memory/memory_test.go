@@ -0,0 +1,152 @@
+package memory_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/handler"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/memory"
+)
+
+var _ = Describe("In-memory backend", func() {
+
+	var (
+		backend          *memory.Backend
+		responseRecorder *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		backend = memory.NewBackend(0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		backend.FetchTimeout = 50 * time.Millisecond
+		responseRecorder = httptest.NewRecorder()
+	})
+
+	provision := func(namespace, stream string) {
+		_, _, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: namespace, Stream: stream})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	publish := func(path, contentType, value string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(value))
+		request.Header.Set("Content-Type", contentType)
+		backend.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	It("creates streams once, with their metadata", func() {
+		metadata := &client.StreamMetadata{ContentType: "application/json"}
+
+		stream, created, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders", Metadata: metadata})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(BeTrue())
+		Expect(stream).To(Equal(&handler.Stream{Topic: "my-ns_orders", Metadata: metadata}))
+
+		stream, created, err = backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders"})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(BeFalse())
+		Expect(stream.Metadata).To(Equal(metadata))
+	})
+
+	It("describes and deletes streams", func() {
+		provision("my-ns", "orders")
+
+		stream, err := backend.DescribeStream(context.Background(), "my-ns", "orders")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stream.Topic).To(Equal("my-ns_orders"))
+
+		_, err = backend.DeleteStream(context.Background(), "my-ns", "orders")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = backend.DescribeStream(context.Background(), "my-ns", "orders")
+		Expect(err).To(Equal(&handler.StatusError{Status: http.StatusNotFound, Message: `Topic "my-ns_orders" does not exist`}))
+		_, err = backend.DeleteStream(context.Background(), "my-ns", "orders")
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusNotFound))
+	})
+
+	It("refuses to replicate streams", func() {
+		_, _, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders", Replicate: true})
+
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusUnprocessableEntity))
+	})
+
+	It("publishes and fetches records", func() {
+		provision("my-ns", "orders")
+
+		Expect(publish("/my-ns/orders", "text/plain", "first").Body.String()).To(MatchJSON(`{"topic": "my-ns_orders", "partition": 0, "offset": 0}`))
+		Expect(publish("/my-ns/orders", "application/json", `{"total": 42}`).Body.String()).To(MatchJSON(`{"topic": "my-ns_orders", "partition": 0, "offset": 1}`))
+
+		backend.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/my-ns/orders/0/1", nil))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		Expect(responseRecorder.Header().Get("Content-Type")).To(Equal("application/json"))
+		Expect(responseRecorder.Body.String()).To(Equal(`{"total": 42}`))
+	})
+
+	It("waits for the record following the last one", func() {
+		provision("my-ns", "orders")
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(10 * time.Millisecond)
+			publish("/my-ns/orders", "text/plain", "late")
+		}()
+		backend.FetchTimeout = 5 * time.Second
+
+		backend.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/my-ns/orders/0/0", nil))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		Expect(responseRecorder.Body.String()).To(Equal("late"))
+	})
+
+	It("answers fetches with a 404 status when there is no record", func() {
+		provision("my-ns", "orders")
+
+		backend.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/my-ns/orders/0/0", nil))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+		Expect(responseRecorder.Body.String()).To(Equal("there is no record at offset 0 of partition 0 yet\n"))
+	})
+
+	It("drops the oldest records beyond MaxRecords", func() {
+		backend.MaxRecords = 2
+		provision("my-ns", "orders")
+		for _, value := range []string{"a", "b", "c"} {
+			publish("/my-ns/orders", "text/plain", value)
+		}
+
+		backend.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/my-ns/orders/0/0", nil))
+		Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+
+		responseRecorder = httptest.NewRecorder()
+		backend.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/my-ns/orders/0/2", nil))
+		Expect(responseRecorder.Body.String()).To(Equal("c"))
+	})
+
+	It("refuses records of streams that aren't provisioned", func() {
+		Expect(publish("/my-ns/orders", "text/plain", "lost").Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("provisions streams through the handler", func() {
+		requestHandler := &handler.TopicCreationRequestHandler{Backend: backend, Gateway: "localhost:8081", GatewayHTTP: "http://localhost:8081", Logger: backend.Logger}
+
+		requestHandler.GetHandlerFunc().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPut, "/my-ns/orders", nil))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+		Expect(responseRecorder.Body.String()).To(MatchJSON(`{
+			"gateway": "localhost:8081",
+			"gateways": {"grpc": {"address": "localhost:8081", "tls": false}, "http": {"url": "http://localhost:8081/my-ns/orders", "tls": false}},
+			"topic": "my-ns_orders"
+		}`))
+		Expect(publish("/my-ns/orders", "text/plain", "first").Code).To(Equal(http.StatusOK))
+	})
+})
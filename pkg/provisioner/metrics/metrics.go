@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/quota"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "riff"
+	subsystem = "kafka_provisioner"
+)
+
+// Metrics holds the collectors exported by the provisioner
+type Metrics struct {
+	Registry             *prometheus.Registry
+	namespaceTopics      *prometheus.GaugeVec
+	namespacePartitions  *prometheus.GaugeVec
+	provisioningDuration *prometheus.HistogramVec
+	httpRequests         *prometheus.CounterVec
+	httpRequestDuration  *prometheus.HistogramVec
+	brokerUp             prometheus.Gauge
+	driftedTopics        prometheus.Gauge
+	ownedTopics          prometheus.Gauge
+	disappearedTopics    prometheus.Gauge
+	controllerQueueDepth prometheus.Gauge
+	controllerRetries    prometheus.Counter
+	gatewayFailovers     prometheus.Counter
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		namespaceTopics: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "namespace_topics",
+			Help:      "Number of topics provisioned for streams of a namespace.",
+		}, []string{"namespace"}),
+		namespacePartitions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "namespace_partitions",
+			Help:      "Total number of partitions of the topics provisioned for streams of a namespace.",
+		}, []string{"namespace"}),
+		provisioningDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "provisioning_duration_seconds",
+			Help:      "Latency of provisioning requests, by response status code.",
+			Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"code"}),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests served, by route, method and response status code.",
+		}, []string{"route", "method", "code"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests, by route, method and response status code.",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"route", "method", "code"}),
+		brokerUp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "broker_up",
+			Help:      "Whether the Kafka brokers were reachable at the last background probe, 1 if they were, 0 otherwise.",
+		}),
+		driftedTopics: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "drifted_topics",
+			Help:      "Number of topics whose live config differed from the spec they were provisioned with at the last reconciliation.",
+		}),
+		ownedTopics: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "owned_topics",
+			Help:      "Number of topics the provisioner owned according to its journal when it started.",
+		}),
+		disappearedTopics: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "disappeared_topics",
+			Help:      "Number of topics owned according to the journal found deleted behind the provisioner's back when it started.",
+		}),
+		controllerQueueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "controller_queue_depth",
+			Help:      "Number of streams and processors waiting to be reconciled by the stream controller.",
+		}),
+		controllerRetries: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "controller_retries_total",
+			Help:      "Number of failed reconciliations of streams and processors requeued by the stream controller.",
+		}),
+		gatewayFailovers: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "gateway_failovers_total",
+			Help:      "Number of times provisioning responses switched to another gateway endpoint, the one selected being unhealthy.",
+		}),
+	}
+	m.Registry.MustRegister(m.namespaceTopics, m.namespacePartitions, m.provisioningDuration, m.httpRequests,
+		m.httpRequestDuration, m.brokerUp, m.driftedTopics, m.ownedTopics, m.disappearedTopics, m.controllerQueueDepth, m.controllerRetries, m.gatewayFailovers)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format, or the OpenMetrics one (which
+// carries exemplars) when the scraper asks for it
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// SetNamespaceUsage replaces the per-namespace gauges with the usage of the given topics
+func (m *Metrics) SetNamespaceUsage(topics map[string]client.TopicSpec) {
+	m.namespaceTopics.Reset()
+	m.namespacePartitions.Reset()
+	for ns, usage := range quota.UsageByNamespace(topics) {
+		m.namespaceTopics.WithLabelValues(ns).Set(float64(usage.Topics))
+		m.namespacePartitions.WithLabelValues(ns).Set(float64(usage.Partitions))
+	}
+}
+
+// SetBrokerUp records whether the brokers were reachable at the last probe
+func (m *Metrics) SetBrokerUp(up bool) {
+	if up {
+		m.brokerUp.Set(1)
+	} else {
+		m.brokerUp.Set(0)
+	}
+}
+
+// SetDriftedTopics records the number of topics found drifted by the last reconciliation
+func (m *Metrics) SetDriftedTopics(count int) {
+	m.driftedTopics.Set(float64(count))
+}
+
+// SetJournalRecovery records the number of topics owned, and of those that disappeared, found replaying the journal
+func (m *Metrics) SetJournalRecovery(owned, disappeared int) {
+	m.ownedTopics.Set(float64(owned))
+	m.disappearedTopics.Set(float64(disappeared))
+}
+
+// SetControllerQueueDepth records the number of streams and processors waiting to be reconciled
+func (m *Metrics) SetControllerQueueDepth(depth int) {
+	m.controllerQueueDepth.Set(float64(depth))
+}
+
+// IncControllerRetries counts a failed reconciliation requeued
+func (m *Metrics) IncControllerRetries() {
+	m.controllerRetries.Inc()
+}
+
+// IncGatewayFailovers counts a switch to another gateway endpoint
+func (m *Metrics) IncGatewayFailovers() {
+	m.gatewayFailovers.Inc()
+}
+
+// NamespaceUsageRefresher periodically updates the per-namespace gauges from the cluster metadata
+type NamespaceUsageRefresher struct {
+	Metrics    *Metrics
+	Interval   time.Duration
+	ListTopics func() (map[string]client.TopicSpec, error)
+	Logger     *slog.Logger
+}
+
+// Run refreshes the gauges right away, then every Interval until ctx is done
+func (r *NamespaceUsageRefresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		if topics, err := r.ListTopics(); err != nil {
+			r.Logger.Error("Error listing topics to refresh namespace metrics", "error", err)
+		} else {
+			r.Metrics.SetNamespaceUsage(topics)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"log/slog"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestMetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Metrics Suite")
+}
+
+var logger = slog.New(slog.NewTextHandler(ioutil.Discard, nil))
@@ -0,0 +1,169 @@
+package metrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/metrics"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+var _ = Describe("Metrics", func() {
+
+	var m *metrics.Metrics
+
+	BeforeEach(func() {
+		m = metrics.NewMetrics()
+	})
+
+	scrape := func() string {
+		recorder := httptest.NewRecorder()
+		m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+		return recorder.Body.String()
+	}
+
+	scrapeOpenMetrics := func() string {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/metrics", nil)
+		request.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+		m.Handler().ServeHTTP(recorder, request)
+		return recorder.Body.String()
+	}
+
+	It("exports topic and partition gauges per namespace", func() {
+		m.SetNamespaceUsage(map[string]client.TopicSpec{
+			"ns_foo":       {NumPartitions: 3},
+			"ns_bar":       {NumPartitions: 1},
+			"other-ns_foo": {NumPartitions: 2},
+		})
+
+		body := scrape()
+		Expect(body).To(ContainSubstring(`riff_kafka_provisioner_namespace_topics{namespace="ns"} 2`))
+		Expect(body).To(ContainSubstring(`riff_kafka_provisioner_namespace_partitions{namespace="ns"} 4`))
+		Expect(body).To(ContainSubstring(`riff_kafka_provisioner_namespace_topics{namespace="other-ns"} 1`))
+	})
+
+	It("labels gauges with unescaped namespaces", func() {
+		m.SetNamespaceUsage(map[string]client.TopicSpec{validation.TopicName("a_b", "c"): {NumPartitions: 1}})
+
+		Expect(scrape()).To(ContainSubstring(`riff_kafka_provisioner_namespace_topics{namespace="a_b"} 1`))
+	})
+
+	It("drops namespaces whose topics are gone", func() {
+		m.SetNamespaceUsage(map[string]client.TopicSpec{"ns_foo": {NumPartitions: 1}})
+		m.SetNamespaceUsage(map[string]client.TopicSpec{"other-ns_foo": {NumPartitions: 1}})
+
+		Expect(scrape()).NotTo(ContainSubstring(`namespace="ns"`))
+	})
+
+	It("exports the number of drifted topics", func() {
+		m.SetDriftedTopics(2)
+
+		Expect(scrape()).To(ContainSubstring(`riff_kafka_provisioner_drifted_topics 2`))
+	})
+
+	It("exports the topics owned and disappeared found recovering the journal", func() {
+		m.SetJournalRecovery(5, 1)
+
+		Expect(scrape()).To(ContainSubstring(`riff_kafka_provisioner_owned_topics 5`))
+		Expect(scrape()).To(ContainSubstring(`riff_kafka_provisioner_disappeared_topics 1`))
+	})
+
+	It("exports the depth and retries of the stream controller queue", func() {
+		m.SetControllerQueueDepth(3)
+		m.IncControllerRetries()
+
+		Expect(scrape()).To(ContainSubstring(`riff_kafka_provisioner_controller_queue_depth 3`))
+		Expect(scrape()).To(ContainSubstring(`riff_kafka_provisioner_controller_retries_total 1`))
+	})
+
+	It("periodically refreshes the gauges", func() {
+		calls := make(chan struct{}, 10)
+		refresher := &metrics.NamespaceUsageRefresher{
+			Metrics:  m,
+			Interval: 10 * time.Millisecond,
+			ListTopics: func() (map[string]client.TopicSpec, error) {
+				calls <- struct{}{}
+				return map[string]client.TopicSpec{"ns_foo": {NumPartitions: 1}}, nil
+			},
+			Logger: logger,
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go refresher.Run(ctx)
+
+		Eventually(calls).Should(HaveLen(2))
+		Expect(scrape()).To(ContainSubstring(`riff_kafka_provisioner_namespace_topics{namespace="ns"} 1`))
+	})
+
+	Describe("provisioning latency", func() {
+		var instrumented http.Handler
+
+		BeforeEach(func() {
+			instrumented = m.InstrumentProvisioning(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+			}))
+		})
+
+		It("records the latency by status code", func() {
+			instrumented.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/ns/stream", nil))
+
+			Expect(scrape()).To(ContainSubstring(`riff_kafka_provisioner_provisioning_duration_seconds_count{code="201"} 1`))
+		})
+
+		It("links requests of sampled traces as exemplars", func() {
+			request := httptest.NewRequest("PUT", "/ns/stream", nil)
+			request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+			instrumented.ServeHTTP(httptest.NewRecorder(), request)
+
+			Expect(scrapeOpenMetrics()).To(ContainSubstring(`# {trace_id="4bf92f3577b34da6a3ce929d0e0e4736"}`))
+		})
+
+		It("ignores traces that were not sampled", func() {
+			request := httptest.NewRequest("PUT", "/ns/stream", nil)
+			request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+			instrumented.ServeHTTP(httptest.NewRecorder(), request)
+
+			Expect(scrapeOpenMetrics()).NotTo(ContainSubstring("trace_id"))
+		})
+	})
+
+	Context("instrumenting routes", func() {
+		var instrumented http.Handler
+
+		BeforeEach(func() {
+			instrumented = m.InstrumentRoutes(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodDelete {
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}), func(r *http.Request) string {
+				return "/{namespace}/{stream}"
+			})
+		})
+
+		It("counts requests and records their latency by route, method and status code", func() {
+			instrumented.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ns/orders", nil))
+			instrumented.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ns/payments", nil))
+			instrumented.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/ns/orders", nil))
+
+			metrics := scrape()
+			Expect(metrics).To(ContainSubstring(`riff_kafka_provisioner_http_requests_total{code="200",method="GET",route="/{namespace}/{stream}"} 2`))
+			Expect(metrics).To(ContainSubstring(`riff_kafka_provisioner_http_requests_total{code="404",method="DELETE",route="/{namespace}/{stream}"} 1`))
+			Expect(metrics).To(ContainSubstring(`riff_kafka_provisioner_http_request_duration_seconds_count{code="200",method="GET",route="/{namespace}/{stream}"} 2`))
+		})
+
+		It("labels unknown methods as other", func() {
+			instrumented.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("BREW", "/ns/orders", nil))
+
+			Expect(scrape()).To(ContainSubstring(`riff_kafka_provisioner_http_requests_total{code="200",method="other",route="/{namespace}/{stream}"} 1`))
+		})
+	})
+})
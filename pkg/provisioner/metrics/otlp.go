@@ -0,0 +1,215 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// aggregationTemporalityCumulative is the OTLP value for cumulative sums and histograms, which is what Prometheus collects
+const aggregationTemporalityCumulative = 2
+
+// OTLPExporter periodically pushes the gathered metrics to an OpenTelemetry collector,
+// using the OTLP/HTTP protocol with JSON encoding
+type OTLPExporter struct {
+	// Endpoint is the base URL of the collector, e.g. http://collector:4318
+	Endpoint    string
+	Interval    time.Duration
+	ServiceName string
+	Gatherer    prometheus.Gatherer
+	HTTPClient  *http.Client
+	Logger      *slog.Logger
+
+	start time.Time
+}
+
+// Run exports the metrics every Interval until ctx is done
+func (e *OTLPExporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Export(); err != nil {
+				e.Logger.Error("Error exporting metrics", "endpoint", e.Endpoint, "error", err)
+			}
+		}
+	}
+}
+
+// Export pushes the current value of the metrics once
+func (e *OTLPExporter) Export() error {
+	if e.start.IsZero() {
+		e.start = time.Now()
+	}
+	families, err := e.Gatherer.Gather()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(e.convert(families, time.Now()))
+	if err != nil {
+		return err
+	}
+	response, err := e.HTTPClient.Post(strings.TrimSuffix(e.Endpoint, "/")+"/v1/metrics", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("collector returned status %d", response.StatusCode)
+	}
+	return nil
+}
+
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Gauge       *otlpData `json:"gauge,omitempty"`
+	Sum         *otlpData `json:"sum,omitempty"`
+	Histogram   *otlpData `json:"histogram,omitempty"`
+	Summary     *otlpData `json:"summary,omitempty"`
+}
+
+type otlpData struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality,omitempty"`
+	IsMonotonic            bool            `json:"isMonotonic,omitempty"`
+}
+
+// otlpDataPoint covers number, histogram and summary data points. 64 bits integers are strings in OTLP JSON.
+type otlpDataPoint struct {
+	Attributes        []otlpAttribute     `json:"attributes,omitempty"`
+	StartTimeUnixNano string              `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string              `json:"timeUnixNano"`
+	AsDouble          *float64            `json:"asDouble,omitempty"`
+	Count             string              `json:"count,omitempty"`
+	Sum               *float64            `json:"sum,omitempty"`
+	BucketCounts      []string            `json:"bucketCounts,omitempty"`
+	ExplicitBounds    []float64           `json:"explicitBounds,omitempty"`
+	QuantileValues    []otlpQuantileValue `json:"quantileValues,omitempty"`
+}
+
+type otlpQuantileValue struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+func attribute(key, value string) otlpAttribute {
+	a := otlpAttribute{Key: key}
+	a.Value.StringValue = value
+	return a
+}
+
+func nanos(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func (e *OTLPExporter) convert(families []*dto.MetricFamily, now time.Time) otlpRequest {
+	scope := otlpScopeMetrics{}
+	scope.Scope.Name = "github.com/projectriff/kafka-provisioner"
+	for _, family := range families {
+		metric := otlpMetric{Name: family.GetName(), Description: family.GetHelp()}
+		data := &otlpData{}
+		for _, m := range family.GetMetric() {
+			point := otlpDataPoint{StartTimeUnixNano: nanos(e.start), TimeUnixNano: nanos(now)}
+			for _, label := range m.GetLabel() {
+				point.Attributes = append(point.Attributes, attribute(label.GetName(), label.GetValue()))
+			}
+			switch family.GetType() {
+			case dto.MetricType_GAUGE:
+				point.StartTimeUnixNano = ""
+				value := m.GetGauge().GetValue()
+				point.AsDouble = &value
+			case dto.MetricType_COUNTER:
+				value := m.GetCounter().GetValue()
+				point.AsDouble = &value
+			case dto.MetricType_HISTOGRAM:
+				histogram := m.GetHistogram()
+				sum := histogram.GetSampleSum()
+				point.Sum = &sum
+				point.Count = strconv.FormatUint(histogram.GetSampleCount(), 10)
+				// Prometheus buckets are cumulative, OTLP ones are not and have an implicit +Inf bucket
+				previous := uint64(0)
+				for _, bucket := range histogram.GetBucket() {
+					if math.IsInf(bucket.GetUpperBound(), +1) {
+						continue
+					}
+					point.ExplicitBounds = append(point.ExplicitBounds, bucket.GetUpperBound())
+					point.BucketCounts = append(point.BucketCounts, strconv.FormatUint(bucket.GetCumulativeCount()-previous, 10))
+					previous = bucket.GetCumulativeCount()
+				}
+				point.BucketCounts = append(point.BucketCounts, strconv.FormatUint(histogram.GetSampleCount()-previous, 10))
+			case dto.MetricType_SUMMARY:
+				summary := m.GetSummary()
+				sum := summary.GetSampleSum()
+				point.Sum = &sum
+				point.Count = strconv.FormatUint(summary.GetSampleCount(), 10)
+				for _, quantile := range summary.GetQuantile() {
+					point.QuantileValues = append(point.QuantileValues, otlpQuantileValue{Quantile: quantile.GetQuantile(), Value: quantile.GetValue()})
+				}
+			default:
+				value := m.GetUntyped().GetValue()
+				point.AsDouble = &value
+			}
+			data.DataPoints = append(data.DataPoints, point)
+		}
+		if len(data.DataPoints) == 0 {
+			continue
+		}
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			data.AggregationTemporality = aggregationTemporalityCumulative
+			data.IsMonotonic = true
+			metric.Sum = data
+		case dto.MetricType_HISTOGRAM:
+			data.AggregationTemporality = aggregationTemporalityCumulative
+			metric.Histogram = data
+		case dto.MetricType_SUMMARY:
+			metric.Summary = data
+		default:
+			metric.Gauge = data
+		}
+		scope.Metrics = append(scope.Metrics, metric)
+	}
+
+	resource := otlpResourceMetrics{ScopeMetrics: []otlpScopeMetrics{scope}}
+	resource.Resource.Attributes = []otlpAttribute{attribute("service.name", e.ServiceName)}
+	return otlpRequest{ResourceMetrics: []otlpResourceMetrics{resource}}
+}
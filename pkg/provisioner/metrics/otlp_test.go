@@ -0,0 +1,110 @@
+package metrics_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ = Describe("OTLP exporter", func() {
+
+	var (
+		server   *httptest.Server
+		received map[string]interface{}
+		status   int
+		m        *metrics.Metrics
+		exporter *metrics.OTLPExporter
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/v1/metrics"))
+			Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+			received = map[string]interface{}{}
+			Expect(json.NewDecoder(r.Body).Decode(&received)).To(Succeed())
+			w.WriteHeader(status)
+		}))
+		m = metrics.NewMetrics()
+		exporter = &metrics.OTLPExporter{
+			Endpoint:    server.URL,
+			ServiceName: "kafka-provisioner",
+			Gatherer:    m.Registry,
+			HTTPClient:  server.Client(),
+			Logger:      logger,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	exported := func() map[string]map[string]interface{} {
+		resourceMetrics := received["resourceMetrics"].([]interface{})[0].(map[string]interface{})
+		Expect(resourceMetrics["resource"]).To(Equal(map[string]interface{}{
+			"attributes": []interface{}{
+				map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "kafka-provisioner"}},
+			},
+		}))
+		byName := map[string]map[string]interface{}{}
+		for _, metric := range resourceMetrics["scopeMetrics"].([]interface{})[0].(map[string]interface{})["metrics"].([]interface{}) {
+			byName[metric.(map[string]interface{})["name"].(string)] = metric.(map[string]interface{})
+		}
+		return byName
+	}
+
+	It("exports gauges", func() {
+		m.SetNamespaceUsage(map[string]client.TopicSpec{"ns_foo": {NumPartitions: 3}})
+
+		Expect(exporter.Export()).To(Succeed())
+
+		gauge := exported()["riff_kafka_provisioner_namespace_partitions"]["gauge"].(map[string]interface{})
+		point := gauge["dataPoints"].([]interface{})[0].(map[string]interface{})
+		Expect(point["asDouble"]).To(Equal(3.0))
+		Expect(point["attributes"]).To(Equal([]interface{}{
+			map[string]interface{}{"key": "namespace", "value": map[string]interface{}{"stringValue": "ns"}},
+		}))
+	})
+
+	It("exports counters as cumulative monotonic sums", func() {
+		counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "some_total", Help: "Some counter."})
+		m.Registry.MustRegister(counter)
+		counter.Add(2)
+
+		Expect(exporter.Export()).To(Succeed())
+
+		sum := exported()["some_total"]["sum"].(map[string]interface{})
+		Expect(sum["isMonotonic"]).To(BeTrue())
+		Expect(sum["aggregationTemporality"]).To(Equal(2.0))
+		Expect(sum["dataPoints"].([]interface{})[0].(map[string]interface{})["asDouble"]).To(Equal(2.0))
+	})
+
+	It("exports histograms with non-cumulative buckets", func() {
+		histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "some_seconds", Help: "Some histogram.", Buckets: []float64{1, 2}})
+		m.Registry.MustRegister(histogram)
+		histogram.Observe(0.5)
+		histogram.Observe(1.5)
+		histogram.Observe(1.7)
+		histogram.Observe(3)
+
+		Expect(exporter.Export()).To(Succeed())
+
+		point := exported()["some_seconds"]["histogram"].(map[string]interface{})["dataPoints"].([]interface{})[0].(map[string]interface{})
+		Expect(point["count"]).To(Equal("4"))
+		Expect(point["sum"]).To(Equal(6.7))
+		Expect(point["explicitBounds"]).To(Equal([]interface{}{1.0, 2.0}))
+		Expect(point["bucketCounts"]).To(Equal([]interface{}{"1", "2", "1"}))
+	})
+
+	It("fails when the collector rejects the metrics", func() {
+		status = http.StatusBadRequest
+
+		Expect(exporter.Export()).To(MatchError("collector returned status 400"))
+	})
+})
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// traceParent matches a W3C trace context header: version-traceid-parentid-flags
+var traceParent = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-([0-9a-f]{2})$`)
+
+// sampledTraceID returns the id of the trace the request belongs to, if the caller sampled it
+func sampledTraceID(request *http.Request) (string, bool) {
+	match := traceParent.FindStringSubmatch(request.Header.Get("traceparent"))
+	if match == nil || match[1] == "00000000000000000000000000000000" {
+		return "", false
+	}
+	flags, _ := strconv.ParseUint(match[2], 16, 8)
+	return match[1], flags&0x01 == 0x01
+}
+
+// InstrumentProvisioning records the latency of provisioning requests served by next. Requests
+// belonging to a sampled trace are recorded with the trace id as an exemplar.
+func (m *Metrics) InstrumentProvisioning(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: responseWriter, status: http.StatusOK}
+		next.ServeHTTP(recorder, request)
+
+		observer := m.provisioningDuration.WithLabelValues(strconv.Itoa(recorder.status))
+		elapsed := time.Since(start).Seconds()
+		if traceID, sampled := sampledTraceID(request); sampled {
+			observer.(prometheus.ExemplarObserver).ObserveWithExemplar(elapsed, prometheus.Labels{"trace_id": traceID})
+		} else {
+			observer.Observe(elapsed)
+		}
+	})
+}
+
+// methods are the HTTP methods requests are labeled with, others being labeled "other" so that callers can't grow
+// the number of series at will
+var methods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPut:     true,
+	http.MethodPost:    true,
+	http.MethodDelete:  true,
+	http.MethodPatch:   true,
+	http.MethodOptions: true,
+}
+
+// InstrumentRoutes counts the requests served by next and records their latency, by the route that route returns
+// for them, method and response status code. Routes should be templates, such as "/{namespace}/{stream}", rather than
+// paths, each label value making a series.
+func (m *Metrics) InstrumentRoutes(next http.Handler, route func(*http.Request) string) http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: responseWriter, status: http.StatusOK}
+		next.ServeHTTP(recorder, request)
+
+		method := request.Method
+		if !methods[method] {
+			method = "other"
+		}
+		labels := []string{route(request), method, strconv.Itoa(recorder.status)}
+		m.httpRequests.WithLabelValues(labels...).Inc()
+		m.httpRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
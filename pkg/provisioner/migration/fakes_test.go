@@ -0,0 +1,151 @@
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/Shopify/sarama"
+)
+
+type fakeSession struct {
+	ctx context.Context
+
+	m      sync.Mutex
+	marked map[int32]int64
+}
+
+func (s *fakeSession) Claims() map[string][]int32 {
+	return nil
+}
+
+func (s *fakeSession) MemberID() string {
+	return "member"
+}
+
+func (s *fakeSession) GenerationID() int32 {
+	return 1
+}
+
+func (s *fakeSession) MarkOffset(_ string, partition int32, offset int64, _ string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.marked == nil {
+		s.marked = make(map[int32]int64)
+	}
+	s.marked[partition] = offset
+}
+
+func (s *fakeSession) Marked(partition int32) int64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.marked[partition]
+}
+
+func (s *fakeSession) Commit() {}
+
+func (s *fakeSession) ResetOffset(string, int32, int64, string) {}
+
+func (s *fakeSession) MarkMessage(message *sarama.ConsumerMessage, metadata string) {
+	s.MarkOffset(message.Topic, message.Partition, message.Offset+1, metadata)
+}
+
+func (s *fakeSession) Context() context.Context {
+	return s.ctx
+}
+
+type fakeClaim struct {
+	topic     string
+	partition int32
+	messages  chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Topic() string {
+	return c.topic
+}
+
+func (c *fakeClaim) Partition() int32 {
+	return c.partition
+}
+
+func (c *fakeClaim) InitialOffset() int64 {
+	return 0
+}
+
+func (c *fakeClaim) HighWaterMarkOffset() int64 {
+	return 0
+}
+
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return c.messages
+}
+
+// recordingProducer records the messages sent, failing the first sends when asked to
+type recordingProducer struct {
+	sarama.SyncProducer
+
+	m        sync.Mutex
+	failures int
+	sent     []*sarama.ProducerMessage
+	closed   bool
+}
+
+func (p *recordingProducer) SendMessages(messages []*sarama.ProducerMessage) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("not enough in-sync replicas")
+	}
+	p.sent = append(p.sent, messages...)
+	return nil
+}
+
+func (p *recordingProducer) Sent() []*sarama.ProducerMessage {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return append([]*sarama.ProducerMessage(nil), p.sent...)
+}
+
+func (p *recordingProducer) Close() error {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.closed = true
+	return nil
+}
+
+// fakeConsumerGroup records the topics it consumes until the context of Consume is done
+type fakeConsumerGroup struct {
+	sarama.ConsumerGroup
+
+	m      sync.Mutex
+	topics []string
+	closed bool
+}
+
+func (g *fakeConsumerGroup) Consume(ctx context.Context, topics []string, _ sarama.ConsumerGroupHandler) error {
+	g.m.Lock()
+	g.topics = topics
+	g.m.Unlock()
+	<-ctx.Done()
+	return nil
+}
+
+func (g *fakeConsumerGroup) Topics() []string {
+	g.m.Lock()
+	defer g.m.Unlock()
+	return g.topics
+}
+
+func (g *fakeConsumerGroup) Close() error {
+	g.m.Lock()
+	defer g.m.Unlock()
+	g.closed = true
+	return nil
+}
+
+func (g *fakeConsumerGroup) Closed() bool {
+	g.m.Lock()
+	defer g.m.Unlock()
+	return g.closed
+}
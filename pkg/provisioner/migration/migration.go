@@ -0,0 +1,235 @@
+// Package migration copies the records of renamed streams to the topic of their new name, so that their publishers
+// and subscribers can cut over without losing the history of the stream
+package migration
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// GroupID returns the consumer group copying the records of a topic, whose committed offsets tell how far the copy
+// got
+func GroupID(source string) string {
+	return "riff-migration." + source
+}
+
+// maxBatch bounds the number of records copied at once
+const maxBatch = 500
+
+// Copier is the consumer group handler copying the records of its claims to the same partition of a topic, keys,
+// headers and timestamps included. Records are copied at least once: those copied again after a failure or a
+// rebalance are duplicated.
+type Copier struct {
+	Target string
+	// Producer produces the records copied, with a manual partitioner
+	Producer sarama.SyncProducer
+	// RetryInterval is how long to wait before copying records again after the producer failed
+	RetryInterval time.Duration
+	Logger        *slog.Logger
+}
+
+func (c *Copier) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (c *Copier) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim copies the records of a partition by batches, marking each batch once produced
+func (c *Copier) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case <-session.Context().Done():
+			return nil
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			batch := []*sarama.ConsumerMessage{message}
+		drain:
+			for len(batch) < maxBatch {
+				select {
+				case message, ok := <-claim.Messages():
+					if !ok {
+						break drain
+					}
+					batch = append(batch, message)
+				default:
+					break drain
+				}
+			}
+			if !c.copy(session.Context(), batch) {
+				return nil
+			}
+			session.MarkMessage(batch[len(batch)-1], "")
+		}
+	}
+}
+
+// copy produces a batch until it succeeds, returning false when ctx is done first
+func (c *Copier) copy(ctx context.Context, batch []*sarama.ConsumerMessage) bool {
+	messages := make([]*sarama.ProducerMessage, len(batch))
+	for i, message := range batch {
+		headers := make([]sarama.RecordHeader, len(message.Headers))
+		for j, header := range message.Headers {
+			headers[j] = *header
+		}
+		messages[i] = &sarama.ProducerMessage{
+			Topic:     c.Target,
+			Partition: message.Partition,
+			Headers:   headers,
+			Timestamp: message.Timestamp,
+		}
+		// nil keys and values stay nil, tombstones remaining tombstones
+		if message.Key != nil {
+			messages[i].Key = sarama.ByteEncoder(message.Key)
+		}
+		if message.Value != nil {
+			messages[i].Value = sarama.ByteEncoder(message.Value)
+		}
+	}
+	for {
+		err := c.Producer.SendMessages(messages)
+		if err == nil {
+			return true
+		}
+		c.Logger.Error("Error copying records", "topic", batch[0].Topic, "partition", batch[0].Partition, "target", c.Target, "error", err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(c.RetryInterval):
+		}
+	}
+}
+
+// Migrator runs the copies of the migrations of streams, as a member of the consumer group of each, so that
+// provisioner replicas share the partitions to copy
+type Migrator struct {
+	// NewConsumerGroup joins the consumer group copying a topic, consuming from the oldest record
+	NewConsumerGroup func(groupID string) (sarama.ConsumerGroup, error)
+	// NewProducer creates the producer of the records copied, with a manual partitioner
+	NewProducer func() (sarama.SyncProducer, error)
+	// RetryInterval is how long to wait before joining a group or copying records again after an error
+	RetryInterval time.Duration
+	Logger        *slog.Logger
+
+	m      sync.Mutex
+	copies map[string]*copying
+}
+
+// copying is a copy being run, by the topic it copies
+type copying struct {
+	target string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Start copies the records of a topic to another, unless already copying them
+func (mg *Migrator) Start(source, target string) {
+	mg.m.Lock()
+	defer mg.m.Unlock()
+	mg.start(source, target)
+}
+
+func (mg *Migrator) start(source, target string) {
+	if mg.copies == nil {
+		mg.copies = make(map[string]*copying)
+	}
+	if c, ok := mg.copies[source]; ok {
+		if c.target == target {
+			return
+		}
+		mg.stop(source)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &copying{target: target, cancel: cancel, done: make(chan struct{})}
+	mg.copies[source] = c
+	go func() {
+		defer close(c.done)
+		mg.run(ctx, source, target)
+	}()
+	mg.Logger.Info("Copying records of migrated stream", "topic", source, "target", target)
+}
+
+// Sync runs the copies of migrations, by source topic, stopping the others, e.g. those of the streams deleted
+// since
+func (mg *Migrator) Sync(migrations map[string]string) {
+	mg.m.Lock()
+	defer mg.m.Unlock()
+	for source := range mg.copies {
+		if _, ok := migrations[source]; !ok {
+			mg.stop(source)
+		}
+	}
+	for source, target := range migrations {
+		mg.start(source, target)
+	}
+}
+
+// Copying returns the topics being copied
+func (mg *Migrator) Copying() []string {
+	mg.m.Lock()
+	defer mg.m.Unlock()
+	sources := make([]string, 0, len(mg.copies))
+	for source := range mg.copies {
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// Stop stops all copies, waiting for them to leave their group
+func (mg *Migrator) Stop() {
+	mg.m.Lock()
+	defer mg.m.Unlock()
+	for source := range mg.copies {
+		mg.stop(source)
+	}
+}
+
+func (mg *Migrator) stop(source string) {
+	c := mg.copies[source]
+	c.cancel()
+	<-c.done
+	delete(mg.copies, source)
+	mg.Logger.Info("Stopped copying records of migrated stream", "topic", source, "target", c.target)
+}
+
+// run copies a topic until ctx is done, joining its group again after errors
+func (mg *Migrator) run(ctx context.Context, source, target string) {
+	for {
+		if err := mg.copy(ctx, source, target); err != nil {
+			mg.Logger.Error("Error copying records of migrated stream", "topic", source, "target", target, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(mg.RetryInterval):
+		}
+	}
+}
+
+func (mg *Migrator) copy(ctx context.Context, source, target string) error {
+	producer, err := mg.NewProducer()
+	if err != nil {
+		return err
+	}
+	defer producer.Close()
+	group, err := mg.NewConsumerGroup(GroupID(source))
+	if err != nil {
+		return err
+	}
+	defer group.Close()
+	copier := &Copier{Target: target, Producer: producer, RetryInterval: mg.RetryInterval, Logger: mg.Logger}
+	// Consume returns at each rebalance
+	for ctx.Err() == nil {
+		if err := group.Consume(ctx, []string{source}, copier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package migration_test
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Shopify/sarama"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/migration"
+)
+
+var _ = Describe("Copier", func() {
+
+	var (
+		producer *recordingProducer
+		copier   *migration.Copier
+		session  *fakeSession
+		claim    *fakeClaim
+		cancel   context.CancelFunc
+		done     chan struct{}
+	)
+
+	BeforeEach(func() {
+		producer = &recordingProducer{}
+		copier = &migration.Copier{Target: "ns_new", Producer: producer, RetryInterval: time.Millisecond, Logger: slog.Default()}
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		session = &fakeSession{ctx: ctx}
+		claim = &fakeClaim{topic: "ns_old", partition: 2, messages: make(chan *sarama.ConsumerMessage, 10)}
+		done = make(chan struct{})
+	})
+
+	consume := func() {
+		go func() {
+			defer close(done)
+			_ = copier.ConsumeClaim(session, claim)
+		}()
+	}
+
+	AfterEach(func() {
+		cancel()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("copies records to the same partition of the target, with their keys, headers and timestamps", func() {
+		timestamp := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		claim.messages <- &sarama.ConsumerMessage{
+			Topic: "ns_old", Partition: 2, Offset: 41, Key: []byte("k"), Value: []byte("v"), Timestamp: timestamp,
+			Headers: []*sarama.RecordHeader{{Key: []byte("Content-Type"), Value: []byte("text/plain")}},
+		}
+		claim.messages <- &sarama.ConsumerMessage{Topic: "ns_old", Partition: 2, Offset: 42, Key: []byte("k")}
+		consume()
+
+		Eventually(func() int64 { return session.Marked(2) }).Should(Equal(int64(43)))
+		sent := producer.Sent()
+		Expect(sent).To(HaveLen(2))
+		Expect(sent[0].Topic).To(Equal("ns_new"))
+		Expect(sent[0].Partition).To(Equal(int32(2)))
+		Expect(sent[0].Key).To(Equal(sarama.ByteEncoder("k")))
+		Expect(sent[0].Value).To(Equal(sarama.ByteEncoder("v")))
+		Expect(sent[0].Timestamp).To(Equal(timestamp))
+		Expect(sent[0].Headers).To(Equal([]sarama.RecordHeader{{Key: []byte("Content-Type"), Value: []byte("text/plain")}}))
+		Expect(sent[1].Value).To(BeNil())
+	})
+
+	It("copies records again until the producer succeeds, marking them only then", func() {
+		producer.failures = 2
+		claim.messages <- &sarama.ConsumerMessage{Topic: "ns_old", Partition: 2, Offset: 7, Value: []byte("v")}
+		consume()
+
+		Eventually(func() int64 { return session.Marked(2) }).Should(Equal(int64(8)))
+		Expect(producer.Sent()).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("Migrator", func() {
+
+	var (
+		groups   map[string]*fakeConsumerGroup
+		migrator *migration.Migrator
+	)
+
+	BeforeEach(func() {
+		groups = map[string]*fakeConsumerGroup{
+			migration.GroupID("ns_a"): {},
+			migration.GroupID("ns_b"): {},
+		}
+		migrator = &migration.Migrator{
+			NewConsumerGroup: func(groupID string) (sarama.ConsumerGroup, error) {
+				return groups[groupID], nil
+			},
+			NewProducer: func() (sarama.SyncProducer, error) {
+				return &recordingProducer{}, nil
+			},
+			RetryInterval: time.Millisecond,
+			Logger:        slog.Default(),
+		}
+	})
+
+	AfterEach(func() {
+		migrator.Stop()
+	})
+
+	It("copies the topics of the migrations synced, in their consumer group", func() {
+		migrator.Sync(map[string]string{"ns_a": "ns_a2", "ns_b": "ns_b2"})
+
+		Expect(migrator.Copying()).To(ConsistOf("ns_a", "ns_b"))
+		Eventually(groups[migration.GroupID("ns_a")].Topics).Should(Equal([]string{"ns_a"}))
+		Eventually(groups[migration.GroupID("ns_b")].Topics).Should(Equal([]string{"ns_b"}))
+	})
+
+	It("stops copying the topics no longer migrated", func() {
+		migrator.Start("ns_a", "ns_a2")
+		migrator.Start("ns_b", "ns_b2")
+		Eventually(groups[migration.GroupID("ns_a")].Topics).ShouldNot(BeEmpty())
+
+		migrator.Sync(map[string]string{"ns_b": "ns_b2"})
+
+		Expect(migrator.Copying()).To(ConsistOf("ns_b"))
+		Expect(groups[migration.GroupID("ns_a")].Closed()).To(BeTrue())
+		Expect(groups[migration.GroupID("ns_b")].Closed()).To(BeFalse())
+	})
+})
@@ -0,0 +1,68 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+// Input is the document a policy is evaluated against
+type Input struct {
+	Namespace string           `json:"namespace"`
+	Stream    string           `json:"stream"`
+	Topic     string           `json:"topic"`
+	Spec      client.TopicSpec `json:"spec"`
+}
+
+// Decision is the outcome of a policy evaluation. When set, Spec replaces the requested spec.
+type Decision struct {
+	Allow  bool              `json:"allow"`
+	Reason string            `json:"reason,omitempty"`
+	Spec   *client.TopicSpec `json:"spec,omitempty"`
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Evaluator
+type Evaluator interface {
+	Evaluate(input Input) (Decision, error)
+}
+
+type opaEvaluator struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewOPAEvaluator returns an Evaluator querying the OPA data API document at url,
+// for instance http://opa:8181/v1/data/riff/provisioning. The document is expected to evaluate to a Decision.
+func NewOPAEvaluator(url string, httpClient *http.Client) Evaluator {
+	return &opaEvaluator{url: url, httpClient: httpClient}
+}
+
+func (oe *opaEvaluator) Evaluate(input Input) (Decision, error) {
+	body, err := json.Marshal(struct {
+		Input Input `json:"input"`
+	}{Input: input})
+	if err != nil {
+		return Decision{}, err
+	}
+	response, err := oe.httpClient.Post(oe.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return Decision{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return Decision{}, fmt.Errorf("policy endpoint %s returned status %d", oe.url, response.StatusCode)
+	}
+	var result struct {
+		Result *Decision `json:"result"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return Decision{}, fmt.Errorf("invalid response from policy endpoint %s: %v", oe.url, err)
+	}
+	if result.Result == nil {
+		return Decision{}, fmt.Errorf("policy endpoint %s returned no decision", oe.url)
+	}
+	return *result.Result, nil
+}
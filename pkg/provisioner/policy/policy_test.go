@@ -0,0 +1,90 @@
+package policy_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/policy"
+)
+
+var _ = Describe("OPA policy evaluator", func() {
+
+	var (
+		server       *httptest.Server
+		responseBody string
+		receivedBody map[string]interface{}
+		evaluator    policy.Evaluator
+		input        policy.Input
+	)
+
+	BeforeEach(func() {
+		receivedBody = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPost))
+			Expect(r.URL.Path).To(Equal("/v1/data/riff/provisioning"))
+			Expect(json.NewDecoder(r.Body).Decode(&receivedBody)).To(Succeed())
+			_, _ = w.Write([]byte(responseBody))
+		}))
+		evaluator = policy.NewOPAEvaluator(server.URL+"/v1/data/riff/provisioning", server.Client())
+		input = policy.Input{
+			Namespace: "prod",
+			Stream:    "orders",
+			Topic:     "prod_orders",
+			Spec:      client.DefaultTopicSpec(),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("sends the request as the policy input", func() {
+		responseBody = `{"result": {"allow": true}}`
+
+		_, err := evaluator.Evaluate(input)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(receivedBody).To(Equal(map[string]interface{}{
+			"input": map[string]interface{}{
+				"namespace": "prod",
+				"stream":    "orders",
+				"topic":     "prod_orders",
+				"spec": map[string]interface{}{
+					"partitions":        1.0,
+					"replicationFactor": 1.0,
+				},
+			},
+		}))
+	})
+
+	It("returns denials with their reason", func() {
+		responseBody = `{"result": {"allow": false, "reason": "no streams on fridays"}}`
+
+		decision, err := evaluator.Evaluate(input)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision).To(Equal(policy.Decision{Allow: false, Reason: "no streams on fridays"}))
+	})
+
+	It("returns mutated specs", func() {
+		responseBody = `{"result": {"allow": true, "spec": {"partitions": 1, "replicationFactor": 3}}}`
+
+		decision, err := evaluator.Evaluate(input)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision.Allow).To(BeTrue())
+		Expect(decision.Spec).To(Equal(&client.TopicSpec{NumPartitions: 1, ReplicationFactor: 3}))
+	})
+
+	It("fails when the policy is undefined", func() {
+		responseBody = `{}`
+
+		_, err := evaluator.Evaluate(input)
+
+		Expect(err).To(MatchError(ContainSubstring("returned no decision")))
+	})
+})
@@ -0,0 +1,114 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package policyfakes
+
+import (
+	"sync"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/policy"
+)
+
+type FakeEvaluator struct {
+	EvaluateStub        func(policy.Input) (policy.Decision, error)
+	evaluateMutex       sync.RWMutex
+	evaluateArgsForCall []struct {
+		arg1 policy.Input
+	}
+	evaluateReturns struct {
+		result1 policy.Decision
+		result2 error
+	}
+	evaluateReturnsOnCall map[int]struct {
+		result1 policy.Decision
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeEvaluator) Evaluate(arg1 policy.Input) (policy.Decision, error) {
+	fake.evaluateMutex.Lock()
+	ret, specificReturn := fake.evaluateReturnsOnCall[len(fake.evaluateArgsForCall)]
+	fake.evaluateArgsForCall = append(fake.evaluateArgsForCall, struct {
+		arg1 policy.Input
+	}{arg1})
+	stub := fake.EvaluateStub
+	fakeReturns := fake.evaluateReturns
+	fake.recordInvocation("Evaluate", []interface{}{arg1})
+	fake.evaluateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEvaluator) EvaluateCallCount() int {
+	fake.evaluateMutex.RLock()
+	defer fake.evaluateMutex.RUnlock()
+	return len(fake.evaluateArgsForCall)
+}
+
+func (fake *FakeEvaluator) EvaluateCalls(stub func(policy.Input) (policy.Decision, error)) {
+	fake.evaluateMutex.Lock()
+	defer fake.evaluateMutex.Unlock()
+	fake.EvaluateStub = stub
+}
+
+func (fake *FakeEvaluator) EvaluateArgsForCall(i int) policy.Input {
+	fake.evaluateMutex.RLock()
+	defer fake.evaluateMutex.RUnlock()
+	argsForCall := fake.evaluateArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEvaluator) EvaluateReturns(result1 policy.Decision, result2 error) {
+	fake.evaluateMutex.Lock()
+	defer fake.evaluateMutex.Unlock()
+	fake.EvaluateStub = nil
+	fake.evaluateReturns = struct {
+		result1 policy.Decision
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEvaluator) EvaluateReturnsOnCall(i int, result1 policy.Decision, result2 error) {
+	fake.evaluateMutex.Lock()
+	defer fake.evaluateMutex.Unlock()
+	fake.EvaluateStub = nil
+	if fake.evaluateReturnsOnCall == nil {
+		fake.evaluateReturnsOnCall = make(map[int]struct {
+			result1 policy.Decision
+			result2 error
+		})
+	}
+	fake.evaluateReturnsOnCall[i] = struct {
+		result1 policy.Decision
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEvaluator) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeEvaluator) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ policy.Evaluator = new(FakeEvaluator)
@@ -0,0 +1,236 @@
+// Package pubsub provisions streams on Google Cloud Pub/Sub, each stream being a topic and a subscription of the same
+// name, derived from its namespace and name
+package pubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/handler"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// DefaultEndpoint is the base URL of the Pub/Sub API
+const DefaultEndpoint = "https://pubsub.googleapis.com"
+
+// Backend provisions streams as a topic, and a pull subscription retaining acknowledged messages so that they can be
+// replayed, and points clients to the Pub/Sub API
+type Backend struct {
+	// Project is the Google Cloud project of topics and subscriptions
+	Project string
+	// Endpoint is the base URL of the Pub/Sub API, e.g. that of an emulator, DefaultEndpoint when empty
+	Endpoint string
+	// Gateway is the address clients reach Pub/Sub at, e.g. pubsub.googleapis.com:443
+	Gateway string
+	// TopicPrefix prefixes the names of topics and subscriptions, which must start with a letter
+	TopicPrefix string
+	// Retention, when positive, is how long topics retain messages
+	Retention time.Duration
+	// AckDeadline, when positive, is how long subscribers have to acknowledge messages, 10 seconds otherwise
+	AckDeadline time.Duration
+	// RetryAfter is suggested to callers when Pub/Sub is unavailable, 5 seconds when zero
+	RetryAfter time.Duration
+	// Client authenticates requests, e.g. with the application default credentials
+	Client *http.Client
+	Logger *slog.Logger
+}
+
+// apiError is the error of a response of the Pub/Sub API
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("Pub/Sub API returned status %d: %s", e.Code, e.Message)
+}
+
+func hasStatus(err error, status int) bool {
+	apiError, ok := err.(*apiError)
+	return ok && apiError.Code == status
+}
+
+func (b *Backend) CreateStream(ctx context.Context, request handler.StreamRequest) (*handler.Stream, bool, error) {
+	topic, subscription, err := b.names(request.Namespace, request.Stream)
+	if err != nil {
+		return nil, false, err
+	}
+	if request.Metadata != nil {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: stream metadata is not supported on Pub/Sub", topic)}
+	}
+	if request.Replicate {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: Pub/Sub topics are global", topic)}
+	}
+	if request.MaxMessageBytes > 0 {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: Pub/Sub messages are up to 10MB", topic)}
+	}
+	if request.TieredStorage {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: Pub/Sub manages the storage of messages", topic)}
+	}
+	if request.Group != nil {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: streams are consumed from the subscription provisioned with them", topic)}
+	}
+	if request.Repartition {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: Pub/Sub topics have no partitions to repartition", topic)}
+	}
+	if request.Retention.Bytes != 0 || request.Retention.Ms == -1 {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: Pub/Sub topics retain messages for a limited time, regardless of their size", topic)}
+	}
+	retention := b.Retention
+	if request.Retention.Ms > 0 {
+		retention = time.Duration(request.Retention.Ms) * time.Millisecond
+	}
+	labels := map[string]string{"riff-namespace": request.Namespace, "riff-stream": request.Stream}
+	topicResource := map[string]interface{}{"labels": labels}
+	if retention > 0 {
+		topicResource["messageRetentionDuration"] = duration(retention)
+	}
+	created := true
+	err = b.do(ctx, http.MethodPut, "/v1/"+topic, topicResource)
+	if hasStatus(err, http.StatusConflict) {
+		b.Logger.Debug("Topic already exists", "topic", topic)
+		created = false
+	} else if err != nil {
+		b.Logger.Error("Error creating topic", "topic", topic, "error", err)
+		return nil, false, b.failure(err, "Error creating topic %q: %v", topic, err)
+	}
+	subscriptionResource := map[string]interface{}{"topic": topic, "retainAckedMessages": true, "labels": labels}
+	if b.AckDeadline > 0 {
+		subscriptionResource["ackDeadlineSeconds"] = int(b.AckDeadline / time.Second)
+	}
+	// the subscription is created even when the topic exists, in case creating it failed before
+	err = b.do(ctx, http.MethodPut, "/v1/"+subscription, subscriptionResource)
+	if err != nil && !hasStatus(err, http.StatusConflict) {
+		b.Logger.Error("Error creating subscription", "subscription", subscription, "error", err)
+		return nil, false, b.failure(err, "Error creating subscription %q: %v", subscription, err)
+	}
+	stream := &handler.Stream{Topic: topic, Gateway: b.Gateway}
+	if created {
+		b.Logger.Debug("Created topic", "topic", topic, "subscription", subscription)
+		if retention > 0 {
+			stream.Retention = &handler.Retention{Ms: int64(retention / time.Millisecond)}
+		}
+	}
+	return stream, created, nil
+}
+
+func (b *Backend) DeleteStream(ctx context.Context, namespace, stream string) (*handler.Stream, error) {
+	topic, subscription, err := b.names(namespace, stream)
+	if err != nil {
+		return nil, err
+	}
+	// deleting the topic first would leave the subscription detached, rather than deleted
+	err = b.do(ctx, http.MethodDelete, "/v1/"+subscription, nil)
+	if err != nil && !hasStatus(err, http.StatusNotFound) {
+		b.Logger.Error("Error deleting subscription", "subscription", subscription, "error", err)
+		return nil, b.failure(err, "Error deleting subscription %q: %v", subscription, err)
+	}
+	err = b.do(ctx, http.MethodDelete, "/v1/"+topic, nil)
+	if hasStatus(err, http.StatusNotFound) {
+		return nil, &handler.StatusError{Status: http.StatusNotFound, Message: fmt.Sprintf("Topic %q does not exist", topic)}
+	}
+	if err != nil {
+		b.Logger.Error("Error deleting topic", "topic", topic, "error", err)
+		return nil, b.failure(err, "Error deleting topic %q: %v", topic, err)
+	}
+	b.Logger.Info("Deleted topic", "topic", topic, "subscription", subscription)
+	return nil, nil
+}
+
+func (b *Backend) DescribeStream(ctx context.Context, namespace, stream string) (*handler.Stream, error) {
+	topic, _, err := b.names(namespace, stream)
+	if err != nil {
+		return nil, err
+	}
+	err = b.do(ctx, http.MethodGet, "/v1/"+topic, nil)
+	if hasStatus(err, http.StatusNotFound) {
+		return nil, &handler.StatusError{Status: http.StatusNotFound, Message: fmt.Sprintf("Topic %q does not exist", topic)}
+	}
+	if err != nil {
+		b.Logger.Error("Error describing topic", "topic", topic, "error", err)
+		return nil, b.failure(err, "Error describing topic %q: %v", topic, err)
+	}
+	return &handler.Stream{Topic: topic, Gateway: b.Gateway}, nil
+}
+
+// Capabilities of Pub/Sub: the IDs of topics are up to 255 characters long, prefix included
+func (b *Backend) Capabilities() handler.Capabilities {
+	return handler.Capabilities{Backend: "pubsub", MaxNameLength: validation.PubSubNaming{Prefix: b.TopicPrefix}.MaxLength()}
+}
+
+// names returns the resource names of the topic and subscription of a stream, which share their ID
+func (b *Backend) names(namespace, stream string) (string, string, error) {
+	id, err := handler.StreamName(validation.PubSubNaming{Prefix: b.TopicPrefix}, namespace, stream)
+	if err != nil {
+		return "", "", err
+	}
+	return fmt.Sprintf("projects/%s/topics/%s", b.Project, id), fmt.Sprintf("projects/%s/subscriptions/%s", b.Project, id), nil
+}
+
+// duration formats a duration as the API does, e.g. 604800s
+func duration(d time.Duration) string {
+	return fmt.Sprintf("%ds", int64(d/time.Second))
+}
+
+// failure reports an error of the Pub/Sub API: 503 with a Retry-After header when it is unavailable or throttles
+// requests, 422 when it refuses the request and 500 otherwise
+func (b *Backend) failure(err error, format string, args ...interface{}) *handler.StatusError {
+	statusError := &handler.StatusError{Status: http.StatusInternalServerError, Message: fmt.Sprintf(format, args...)}
+	apiError, ok := err.(*apiError)
+	switch {
+	case !ok || apiError.Code >= 500 || apiError.Code == http.StatusTooManyRequests:
+		statusError.Status, statusError.RetryAfter = http.StatusServiceUnavailable, b.RetryAfter
+		if statusError.RetryAfter <= 0 {
+			statusError.RetryAfter = 5 * time.Second
+		}
+	case apiError.Code == http.StatusBadRequest:
+		statusError.Status = http.StatusUnprocessableEntity
+	}
+	return statusError
+}
+
+// do sends body, if any, as JSON to the Pub/Sub API
+func (b *Backend) do(ctx context.Context, method, path string, body interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	endpoint := b.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	request, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(endpoint, "/")+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	response, err := b.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		status := struct {
+			Error apiError `json:"error"`
+		}{}
+		_ = json.NewDecoder(response.Body).Decode(&status)
+		status.Error.Code = response.StatusCode
+		return &status.Error
+	}
+	_, _ = io.Copy(io.Discard, response.Body)
+	return nil
+}
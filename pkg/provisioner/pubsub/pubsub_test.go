@@ -0,0 +1,189 @@
+package pubsub_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/handler"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/pubsub"
+)
+
+var _ = Describe("Pub/Sub backend", func() {
+
+	var (
+		server    *httptest.Server
+		mu        sync.Mutex
+		resources map[string]string
+		failing   bool
+		backend   *pubsub.Backend
+	)
+
+	BeforeEach(func() {
+		resources, failing = map[string]string{}, false
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			if failing {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`{"error": {"code": 503, "message": "unavailable", "status": "UNAVAILABLE"}}`))
+				return
+			}
+			name := strings.TrimPrefix(r.URL.Path, "/v1/")
+			body, err := io.ReadAll(r.Body)
+			Expect(err).NotTo(HaveOccurred())
+			_, ok := resources[name]
+			switch {
+			case r.Method == http.MethodPut && ok:
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte(`{"error": {"code": 409, "message": "Resource already exists in the project", "status": "ALREADY_EXISTS"}}`))
+			case r.Method == http.MethodPut:
+				resources[name] = string(body)
+				_, _ = w.Write(body)
+			case !ok:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error": {"code": 404, "message": "Resource not found", "status": "NOT_FOUND"}}`))
+			case r.Method == http.MethodDelete:
+				delete(resources, name)
+				_, _ = w.Write([]byte(`{}`))
+			default:
+				_, _ = w.Write([]byte(resources[name]))
+			}
+		}))
+		backend = &pubsub.Backend{
+			Project:     "my-project",
+			Endpoint:    server.URL,
+			Gateway:     "pubsub.googleapis.com:443",
+			TopicPrefix: "riff_",
+			Retention:   7 * 24 * time.Hour,
+			AckDeadline: time.Minute,
+			Client:      server.Client(),
+			Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("creates the topic and subscription of streams", func() {
+		stream, created, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders"})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(BeTrue())
+		Expect(stream).To(Equal(&handler.Stream{Topic: "projects/my-project/topics/riff_my-ns_orders", Gateway: "pubsub.googleapis.com:443", Retention: &handler.Retention{Ms: 7 * 24 * 60 * 60 * 1000}}))
+		Expect(resources).To(HaveKeyWithValue("projects/my-project/topics/riff_my-ns_orders", MatchJSON(`{
+			"labels": {"riff-namespace": "my-ns", "riff-stream": "orders"},
+			"messageRetentionDuration": "604800s"
+		}`)))
+		Expect(resources).To(HaveKeyWithValue("projects/my-project/subscriptions/riff_my-ns_orders", MatchJSON(`{
+			"topic": "projects/my-project/topics/riff_my-ns_orders",
+			"ackDeadlineSeconds": 60,
+			"retainAckedMessages": true,
+			"labels": {"riff-namespace": "my-ns", "riff-stream": "orders"}
+		}`)))
+	})
+
+	It("retains the messages of topics as long as requested", func() {
+		stream, _, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders", Retention: handler.Retention{Ms: 24 * 60 * 60 * 1000}})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stream.Retention).To(Equal(&handler.Retention{Ms: 24 * 60 * 60 * 1000}))
+		Expect(resources).To(HaveKeyWithValue("projects/my-project/topics/riff_my-ns_orders", ContainSubstring(`"messageRetentionDuration":"86400s"`)))
+
+		_, _, err = backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "other", Retention: handler.Retention{Bytes: 50 << 30}})
+
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusUnprocessableEntity))
+	})
+
+	It("creates the missing subscription of existing topics", func() {
+		resources["projects/my-project/topics/riff_my-ns_orders"] = "{}"
+
+		_, created, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders"})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(BeFalse())
+		Expect(resources).To(HaveKeyWithValue("projects/my-project/topics/riff_my-ns_orders", "{}"))
+		Expect(resources).To(HaveKey("projects/my-project/subscriptions/riff_my-ns_orders"))
+	})
+
+	It("refuses to record the metadata of streams", func() {
+		_, _, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders", Metadata: &client.StreamMetadata{ContentType: "application/json"}})
+
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusUnprocessableEntity))
+		Expect(resources).To(BeEmpty())
+	})
+
+	It("refuses streams Pub/Sub can't name", func() {
+		backend.TopicPrefix = ""
+
+		_, _, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "0-ns", Stream: "orders"})
+
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusBadRequest))
+		Expect(err.(*handler.StatusError).Message).To(HavePrefix(`Invalid stream: ID "0-ns_orders" should start with a letter`))
+		Expect(resources).To(BeEmpty())
+	})
+
+	It("describes existing streams", func() {
+		resources["projects/my-project/topics/riff_my-ns_orders"] = "{}"
+
+		stream, err := backend.DescribeStream(context.Background(), "my-ns", "orders")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stream.Topic).To(Equal("projects/my-project/topics/riff_my-ns_orders"))
+	})
+
+	It("reports missing streams as not found", func() {
+		_, err := backend.DescribeStream(context.Background(), "my-ns", "orders")
+
+		Expect(err).To(Equal(&handler.StatusError{Status: http.StatusNotFound, Message: `Topic "projects/my-project/topics/riff_my-ns_orders" does not exist`}))
+	})
+
+	It("deletes the subscription and topic of streams", func() {
+		_, _, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders"})
+		Expect(err).NotTo(HaveOccurred())
+
+		stream, err := backend.DeleteStream(context.Background(), "my-ns", "orders")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stream).To(BeNil())
+		Expect(resources).To(BeEmpty())
+
+		_, err = backend.DeleteStream(context.Background(), "my-ns", "orders")
+
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusNotFound))
+	})
+
+	It("suggests retrying when Pub/Sub is unavailable", func() {
+		mu.Lock()
+		failing = true
+		mu.Unlock()
+
+		_, _, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders"})
+
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusServiceUnavailable))
+		Expect(err.(*handler.StatusError).RetryAfter).To(Equal(5 * time.Second))
+	})
+
+	It("points provisioning responses to Pub/Sub", func() {
+		requestHandler := &handler.TopicCreationRequestHandler{Backend: backend, Gateway: "unused:6565", Logger: backend.Logger}
+		responseRecorder := httptest.NewRecorder()
+
+		requestHandler.GetHandlerFunc().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPut, "/my-ns/orders", nil))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+		response := map[string]interface{}{}
+		Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &response)).To(Succeed())
+		Expect(response).To(HaveKeyWithValue("gateway", "pubsub.googleapis.com:443"))
+		Expect(response).To(HaveKeyWithValue("topic", "projects/my-project/topics/riff_my-ns_orders"))
+	})
+})
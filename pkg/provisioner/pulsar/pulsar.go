@@ -0,0 +1,237 @@
+// Package pulsar provisions streams on Apache Pulsar through its admin API, the streams of each namespace being
+// partitioned topics of a Pulsar namespace of the same name under a tenant
+package pulsar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/handler"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// Backend provisions streams as persistent partitioned topics, creating their tenant and namespace when missing,
+// and points clients to the service URL of the cluster
+type Backend struct {
+	// AdminURL is the base URL of the admin API, e.g. http://pulsar:8080
+	AdminURL string
+	// ServiceURL is the address clients reach the cluster at, e.g. pulsar://pulsar:6650
+	ServiceURL string
+	// Token, when set, authenticates requests to the admin API
+	Token string
+	// Tenant is the tenant of the namespaces of streams
+	Tenant string
+	// Clusters are the clusters tenants are created for
+	Clusters []string
+	// Partitions is the number of partitions of the topics of streams
+	Partitions int
+	// RetryAfter is suggested to callers when the admin API is unavailable, 5 seconds when zero
+	RetryAfter time.Duration
+	Client     *http.Client
+	Logger     *slog.Logger
+}
+
+// adminError reports a failed request to the admin API
+type adminError struct {
+	status int
+	reason string
+}
+
+func (e *adminError) Error() string {
+	return fmt.Sprintf("pulsar admin API returned status %d: %s", e.status, e.reason)
+}
+
+func hasStatus(err error, status int) bool {
+	adminError, ok := err.(*adminError)
+	return ok && adminError.status == status
+}
+
+func (b *Backend) CreateStream(ctx context.Context, request handler.StreamRequest) (*handler.Stream, bool, error) {
+	topic, err := handler.StreamName(b.naming(), request.Namespace, request.Stream)
+	if err != nil {
+		return nil, false, err
+	}
+	if request.Metadata != nil {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: stream metadata is not supported on Pulsar", topic)}
+	}
+	if request.Replicate {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: replication is configured with the geo-replication of Pulsar namespaces", topic)}
+	}
+	if request.MaxMessageBytes > 0 {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: the size of messages is capped by the maxMessageSize of Pulsar brokers", topic)}
+	}
+	if request.TieredStorage {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: tiered storage is configured with the offload policies of Pulsar namespaces", topic)}
+	}
+	if request.Group != nil {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: the subscriptions of Pulsar topics are created by their consumers", topic)}
+	}
+	if request.Repartition {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: Pulsar topics are repartitioned by key with Key_Shared subscriptions", topic)}
+	}
+	if request.Retention != (handler.Retention{}) {
+		return nil, false, &handler.StatusError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Refusing to provision topic %q: retention is configured with the retention policies of Pulsar namespaces", topic)}
+	}
+	if err := b.ensureTenant(ctx); err != nil {
+		b.Logger.Error("Error creating tenant", "tenant", b.Tenant, "error", err)
+		return nil, false, b.failure(err, "Error creating tenant %q: %v", b.Tenant, err)
+	}
+	err = b.do(ctx, http.MethodPut, b.namespacePath(request.Namespace), nil, nil)
+	if err != nil && !hasStatus(err, http.StatusConflict) {
+		b.Logger.Error("Error creating namespace", "tenant", b.Tenant, "namespace", request.Namespace, "error", err)
+		return nil, false, b.failure(err, "Error creating namespace %q of tenant %q: %v", request.Namespace, b.Tenant, err)
+	}
+	partitions := b.Partitions
+	if partitions <= 0 {
+		partitions = 1
+	}
+	err = b.do(ctx, http.MethodPut, b.partitionsPath(request.Namespace, request.Stream), partitions, nil)
+	if hasStatus(err, http.StatusConflict) {
+		b.Logger.Debug("Topic already exists", "topic", topic)
+		return &handler.Stream{Topic: topic, Gateway: b.ServiceURL}, false, nil
+	}
+	if err != nil {
+		b.Logger.Error("Error creating topic", "topic", topic, "error", err)
+		return nil, false, b.failure(err, "Error creating topic %q: %v", topic, err)
+	}
+	b.Logger.Debug("Created topic", "topic", topic, "partitions", partitions)
+	return &handler.Stream{Topic: topic, Gateway: b.ServiceURL}, true, nil
+}
+
+func (b *Backend) DeleteStream(ctx context.Context, namespace, stream string) (*handler.Stream, error) {
+	topic, err := handler.StreamName(b.naming(), namespace, stream)
+	if err != nil {
+		return nil, err
+	}
+	// producers and subscriptions of deleted streams are disconnected
+	err = b.do(ctx, http.MethodDelete, b.partitionsPath(namespace, stream)+"?force=true", nil, nil)
+	if hasStatus(err, http.StatusNotFound) {
+		return nil, &handler.StatusError{Status: http.StatusNotFound, Message: fmt.Sprintf("Topic %q does not exist", topic)}
+	}
+	if err != nil {
+		b.Logger.Error("Error deleting topic", "topic", topic, "error", err)
+		return nil, b.failure(err, "Error deleting topic %q: %v", topic, err)
+	}
+	b.Logger.Info("Deleted topic", "topic", topic)
+	return nil, nil
+}
+
+func (b *Backend) DescribeStream(ctx context.Context, namespace, stream string) (*handler.Stream, error) {
+	topic, err := handler.StreamName(b.naming(), namespace, stream)
+	if err != nil {
+		return nil, err
+	}
+	metadata := struct {
+		Partitions int `json:"partitions"`
+	}{}
+	err = b.do(ctx, http.MethodGet, b.partitionsPath(namespace, stream), nil, &metadata)
+	// older brokers describe missing topics as having no partitions
+	if hasStatus(err, http.StatusNotFound) || err == nil && metadata.Partitions == 0 {
+		return nil, &handler.StatusError{Status: http.StatusNotFound, Message: fmt.Sprintf("Topic %q does not exist", topic)}
+	}
+	if err != nil {
+		b.Logger.Error("Error describing topic", "topic", topic, "error", err)
+		return nil, b.failure(err, "Error describing topic %q: %v", topic, err)
+	}
+	return &handler.Stream{Topic: topic, Gateway: b.ServiceURL}, nil
+}
+
+// Capabilities of Pulsar: topics may be compacted and written in transactions, though this backend configures neither
+func (b *Backend) Capabilities() handler.Capabilities {
+	return handler.Capabilities{Backend: "pulsar", Partitions: true, Compaction: true, Transactions: true, MaxNameLength: b.naming().MaxLength()}
+}
+
+// ensureTenant creates the tenant of streams, unless it exists
+func (b *Backend) ensureTenant(ctx context.Context) error {
+	path := "/admin/v2/tenants/" + url.PathEscape(b.Tenant)
+	err := b.do(ctx, http.MethodGet, path, nil, nil)
+	if !hasStatus(err, http.StatusNotFound) {
+		return err
+	}
+	tenant := struct {
+		AdminRoles      []string `json:"adminRoles"`
+		AllowedClusters []string `json:"allowedClusters"`
+	}{AdminRoles: []string{}, AllowedClusters: b.Clusters}
+	err = b.do(ctx, http.MethodPut, path, tenant, nil)
+	if hasStatus(err, http.StatusConflict) {
+		return nil
+	}
+	return err
+}
+
+// naming names the streams of a namespace after the topics of the Pulsar namespace of the tenant
+func (b *Backend) naming() validation.Naming {
+	return validation.PulsarNaming{Tenant: b.Tenant}
+}
+
+func (b *Backend) namespacePath(namespace string) string {
+	return fmt.Sprintf("/admin/v2/namespaces/%s/%s", url.PathEscape(b.Tenant), url.PathEscape(namespace))
+}
+
+func (b *Backend) partitionsPath(namespace, stream string) string {
+	return fmt.Sprintf("/admin/v2/persistent/%s/%s/%s/partitions", url.PathEscape(b.Tenant), url.PathEscape(namespace), url.PathEscape(stream))
+}
+
+// failure reports an error of the admin API: 503 with a Retry-After header when it is unavailable, 422 when it
+// refuses the request and 500 otherwise
+func (b *Backend) failure(err error, format string, args ...interface{}) *handler.StatusError {
+	statusError := &handler.StatusError{Status: http.StatusInternalServerError, Message: fmt.Sprintf(format, args...)}
+	adminError, ok := err.(*adminError)
+	switch {
+	case !ok || adminError.status >= 500:
+		statusError.Status, statusError.RetryAfter = http.StatusServiceUnavailable, b.RetryAfter
+		if statusError.RetryAfter <= 0 {
+			statusError.RetryAfter = 5 * time.Second
+		}
+	case adminError.status == http.StatusBadRequest || adminError.status == http.StatusPreconditionFailed:
+		statusError.Status = http.StatusUnprocessableEntity
+	}
+	return statusError
+}
+
+// do sends body, if any, as JSON to the admin API and decodes the response into result, if any
+func (b *Backend) do(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(b.AdminURL, "/")+path, reader)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if b.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+b.Token)
+	}
+	response, err := b.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		status := struct {
+			Reason string `json:"reason"`
+		}{}
+		_ = json.NewDecoder(response.Body).Decode(&status)
+		return &adminError{status: response.StatusCode, reason: status.Reason}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
@@ -0,0 +1,202 @@
+package pulsar_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/handler"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/pulsar"
+)
+
+var _ = Describe("Pulsar backend", func() {
+
+	var (
+		server     *httptest.Server
+		mu         sync.Mutex
+		tenants    map[string]string
+		namespaces map[string]bool
+		topics     map[string]int
+		failing    bool
+		backend    *pulsar.Backend
+	)
+
+	BeforeEach(func() {
+		tenants, namespaces, topics, failing = map[string]string{}, map[string]bool{}, map[string]int{}, false
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer some-token"))
+			if failing {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			Expect(err).NotTo(HaveOccurred())
+			conflict := func() {
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte(`{"reason": "already exists"}`))
+			}
+			notFound := func() {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"reason": "not found"}`))
+			}
+			switch path := r.URL.Path; {
+			case strings.HasPrefix(path, "/admin/v2/tenants/"):
+				name := strings.TrimPrefix(path, "/admin/v2/tenants/")
+				_, ok := tenants[name]
+				switch {
+				case r.Method == http.MethodGet && !ok:
+					notFound()
+				case r.Method == http.MethodPut && ok:
+					conflict()
+				case r.Method == http.MethodPut:
+					tenants[name] = string(body)
+					w.WriteHeader(http.StatusNoContent)
+				}
+			case strings.HasPrefix(path, "/admin/v2/namespaces/") && r.Method == http.MethodPut:
+				name := strings.TrimPrefix(path, "/admin/v2/namespaces/")
+				if namespaces[name] {
+					conflict()
+					return
+				}
+				namespaces[name] = true
+				w.WriteHeader(http.StatusNoContent)
+			case strings.HasPrefix(path, "/admin/v2/persistent/") && strings.HasSuffix(path, "/partitions"):
+				name := strings.TrimSuffix(strings.TrimPrefix(path, "/admin/v2/persistent/"), "/partitions")
+				partitions, ok := topics[name]
+				switch r.Method {
+				case http.MethodGet:
+					_, _ = fmt.Fprintf(w, `{"partitions": %d}`, partitions)
+				case http.MethodPut:
+					if ok {
+						conflict()
+						return
+					}
+					Expect(json.Unmarshal(body, &partitions)).To(Succeed())
+					topics[name] = partitions
+					w.WriteHeader(http.StatusNoContent)
+				case http.MethodDelete:
+					Expect(r.URL.Query().Get("force")).To(Equal("true"))
+					if !ok {
+						notFound()
+						return
+					}
+					delete(topics, name)
+					w.WriteHeader(http.StatusNoContent)
+				}
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		}))
+		backend = &pulsar.Backend{
+			AdminURL:   server.URL,
+			ServiceURL: "pulsar://pulsar:6650",
+			Token:      "some-token",
+			Tenant:     "riff",
+			Clusters:   []string{"standalone"},
+			Partitions: 3,
+			Client:     server.Client(),
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("creates the tenant, namespace and partitioned topic of streams", func() {
+		stream, created, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders"})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(BeTrue())
+		Expect(stream).To(Equal(&handler.Stream{Topic: "persistent://riff/my-ns/orders", Gateway: "pulsar://pulsar:6650"}))
+		Expect(tenants).To(HaveKeyWithValue("riff", MatchJSON(`{"adminRoles": [], "allowedClusters": ["standalone"]}`)))
+		Expect(namespaces).To(HaveKey("riff/my-ns"))
+		Expect(topics).To(HaveKeyWithValue("riff/my-ns/orders", 3))
+	})
+
+	It("leaves existing tenants, namespaces and topics alone", func() {
+		tenants["riff"] = "{}"
+		namespaces["riff/my-ns"] = true
+		topics["riff/my-ns/orders"] = 1
+
+		stream, created, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders"})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(BeFalse())
+		Expect(stream.Topic).To(Equal("persistent://riff/my-ns/orders"))
+		Expect(tenants).To(HaveKeyWithValue("riff", "{}"))
+		Expect(topics).To(HaveKeyWithValue("riff/my-ns/orders", 1))
+	})
+
+	It("refuses to record the metadata of streams", func() {
+		_, _, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders", Metadata: &client.StreamMetadata{ContentType: "application/json"}})
+
+		Expect(err).To(BeAssignableToTypeOf(&handler.StatusError{}))
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusUnprocessableEntity))
+		Expect(topics).To(BeEmpty())
+	})
+
+	It("describes the streams whose topic exists", func() {
+		topics["riff/my-ns/orders"] = 3
+
+		stream, err := backend.DescribeStream(context.Background(), "my-ns", "orders")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stream).To(Equal(&handler.Stream{Topic: "persistent://riff/my-ns/orders", Gateway: "pulsar://pulsar:6650"}))
+	})
+
+	It("reports the streams whose topic doesn't exist as not found", func() {
+		_, err := backend.DescribeStream(context.Background(), "my-ns", "orders")
+
+		Expect(err).To(Equal(&handler.StatusError{Status: http.StatusNotFound, Message: `Topic "persistent://riff/my-ns/orders" does not exist`}))
+	})
+
+	It("deletes the topic of streams", func() {
+		topics["riff/my-ns/orders"] = 3
+
+		stream, err := backend.DeleteStream(context.Background(), "my-ns", "orders")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stream).To(BeNil())
+		Expect(topics).To(BeEmpty())
+
+		_, err = backend.DeleteStream(context.Background(), "my-ns", "orders")
+
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusNotFound))
+	})
+
+	It("suggests retrying when the admin API is unavailable", func() {
+		failing = true
+
+		_, _, err := backend.CreateStream(context.Background(), handler.StreamRequest{Namespace: "my-ns", Stream: "orders"})
+
+		Expect(err.(*handler.StatusError).Status).To(Equal(http.StatusServiceUnavailable))
+		Expect(err.(*handler.StatusError).RetryAfter).To(Equal(5 * time.Second))
+	})
+
+	It("points provisioning responses to the service URL", func() {
+		requestHandler := &handler.TopicCreationRequestHandler{Backend: backend, Gateway: "unused:6565", Logger: backend.Logger}
+		responseRecorder := httptest.NewRecorder()
+
+		requestHandler.GetHandlerFunc().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPut, "/my-ns/orders", nil))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+		Expect(responseRecorder.Body.String()).To(MatchJSON(`{
+			"gateway": "pulsar://pulsar:6650",
+			"gateways": {"grpc": {"address": "pulsar://pulsar:6650", "tls": false}},
+			"topic": "persistent://riff/my-ns/orders"
+		}`))
+	})
+})
@@ -0,0 +1,112 @@
+package quota
+
+import (
+	"errors"
+	"fmt"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// Limits caps what a single namespace may provision. Zero values mean unlimited.
+type Limits struct {
+	MaxTopics     int
+	MaxPartitions int
+}
+
+// Usage is what a namespace has provisioned so far
+type Usage struct {
+	Topics     int
+	Partitions int
+}
+
+// Unlimited tells whether no limit is enforced at all
+func (l Limits) Unlimited() bool {
+	return l.MaxTopics <= 0 && l.MaxPartitions <= 0
+}
+
+// NamespaceUsage counts the topics of the given namespace, relying on topic names being
+// of the form <namespace>_<stream-name>, namespaces being escaped as validation.TopicName does
+func NamespaceUsage(topics map[string]client.TopicSpec, namespace string) Usage {
+	usage := Usage{}
+	for name, spec := range topics {
+		if topicNamespace, _, ok := validation.ParseTopicName(name); ok && topicNamespace == namespace {
+			usage.Topics++
+			usage.Partitions += int(spec.NumPartitions)
+		}
+	}
+	return usage
+}
+
+// UsageByNamespace groups the usage of all topics following the <namespace>_<stream-name> naming convention, by
+// unescaped namespace
+func UsageByNamespace(topics map[string]client.TopicSpec) map[string]Usage {
+	usages := make(map[string]Usage)
+	for name, spec := range topics {
+		namespace, _, ok := validation.ParseTopicName(name)
+		if !ok {
+			continue
+		}
+		usage := usages[namespace]
+		usage.Topics++
+		usage.Partitions += int(spec.NumPartitions)
+		usages[namespace] = usage
+	}
+	return usages
+}
+
+// Check returns an error when adding a topic with the given number of partitions to usage would exceed the limits
+func (l Limits) Check(usage Usage, partitions int) error {
+	if l.MaxTopics > 0 && usage.Topics+1 > l.MaxTopics {
+		return fmt.Errorf("topic quota exceeded: %d of %d topics already provisioned", usage.Topics, l.MaxTopics)
+	}
+	if l.MaxPartitions > 0 && usage.Partitions+partitions > l.MaxPartitions {
+		return fmt.Errorf("partition quota exceeded: %d of %d partitions already provisioned, %d requested",
+			usage.Partitions, l.MaxPartitions, partitions)
+	}
+	return nil
+}
+
+// ClusterBudget caps the total number of partitions in the cluster. A zero MaxPartitions means unlimited.
+type ClusterBudget struct {
+	MaxPartitions int
+	// WarnRatio is the fraction of MaxPartitions above which new topics trigger a warning
+	WarnRatio float64
+	// WarnOnly turns an exhausted budget into a warning instead of a refusal
+	WarnOnly bool
+}
+
+// Unlimited tells whether the budget is not enforced at all
+func (b ClusterBudget) Unlimited() bool {
+	return b.MaxPartitions <= 0
+}
+
+// ClusterPartitions counts the partitions of all topics
+func ClusterPartitions(topics map[string]client.TopicSpec) int {
+	partitions := 0
+	for _, spec := range topics {
+		partitions += int(spec.NumPartitions)
+	}
+	return partitions
+}
+
+// Check returns an error when adding the given number of partitions to a cluster that already has total
+// partitions would exceed the budget, or a warning when it would get close to it
+func (b ClusterBudget) Check(total, partitions int) (warning string, err error) {
+	if b.Unlimited() {
+		return "", nil
+	}
+	after := total + partitions
+	if after > b.MaxPartitions {
+		message := fmt.Sprintf("cluster partition budget exceeded: %d of %d partitions already in use, %d requested",
+			total, b.MaxPartitions, partitions)
+		if b.WarnOnly {
+			return message, nil
+		}
+		return "", errors.New(message)
+	}
+	if b.WarnRatio > 0 && float64(after) > b.WarnRatio*float64(b.MaxPartitions) {
+		return fmt.Sprintf("cluster is close to its partition budget: %d of %d partitions in use", after, b.MaxPartitions), nil
+	}
+	return "", nil
+}
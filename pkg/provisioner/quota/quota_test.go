@@ -0,0 +1,123 @@
+package quota_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/quota"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+var _ = Describe("Namespace quotas", func() {
+
+	It("only accounts for the topics of the namespace", func() {
+		topics := map[string]client.TopicSpec{
+			"ns_foo":       {NumPartitions: 3},
+			"ns_bar":       {NumPartitions: 1},
+			"other-ns_foo": {NumPartitions: 12},
+			"ns-2_foo":     {NumPartitions: 5},
+		}
+
+		Expect(quota.NamespaceUsage(topics, "ns")).To(Equal(quota.Usage{Topics: 2, Partitions: 4}))
+	})
+
+	It("accounts for the topics of escaped namespaces", func() {
+		topics := map[string]client.TopicSpec{
+			validation.TopicName("a_b", "c"): {NumPartitions: 3},
+			"a_b_c":                          {NumPartitions: 12},
+		}
+
+		Expect(quota.NamespaceUsage(topics, "a_b")).To(Equal(quota.Usage{Topics: 1, Partitions: 3}))
+		Expect(quota.NamespaceUsage(topics, "a")).To(Equal(quota.Usage{Topics: 1, Partitions: 12}))
+	})
+
+	It("groups usage by namespace", func() {
+		topics := map[string]client.TopicSpec{
+			"ns_foo":             {NumPartitions: 3},
+			"ns_bar":             {NumPartitions: 1},
+			"other-ns_foo":       {NumPartitions: 12},
+			"__consumer_offsets": {NumPartitions: 50},
+		}
+
+		Expect(quota.UsageByNamespace(topics)).To(Equal(map[string]quota.Usage{
+			"ns":       {Topics: 2, Partitions: 4},
+			"other-ns": {Topics: 1, Partitions: 12},
+		}))
+	})
+
+	It("groups usage by unescaped namespace", func() {
+		topics := map[string]client.TopicSpec{
+			validation.TopicName("a_b", "c"): {NumPartitions: 3},
+			"a_b_c":                          {NumPartitions: 12},
+		}
+
+		Expect(quota.UsageByNamespace(topics)).To(Equal(map[string]quota.Usage{
+			"a_b": {Topics: 1, Partitions: 3},
+			"a":   {Topics: 1, Partitions: 12},
+		}))
+	})
+
+	It("allows anything when no limit is set", func() {
+		limits := quota.Limits{}
+
+		Expect(limits.Unlimited()).To(BeTrue())
+		Expect(limits.Check(quota.Usage{Topics: 1000, Partitions: 1000}, 100)).To(Succeed())
+	})
+
+	It("rejects topics beyond the topic count limit", func() {
+		limits := quota.Limits{MaxTopics: 2}
+
+		Expect(limits.Check(quota.Usage{Topics: 1}, 1)).To(Succeed())
+		Expect(limits.Check(quota.Usage{Topics: 2}, 1)).
+			To(MatchError("topic quota exceeded: 2 of 2 topics already provisioned"))
+	})
+
+	It("rejects topics beyond the partition limit", func() {
+		limits := quota.Limits{MaxPartitions: 4}
+
+		Expect(limits.Check(quota.Usage{Topics: 1, Partitions: 3}, 1)).To(Succeed())
+		Expect(limits.Check(quota.Usage{Topics: 1, Partitions: 3}, 2)).
+			To(MatchError("partition quota exceeded: 3 of 4 partitions already provisioned, 2 requested"))
+	})
+})
+
+var _ = Describe("Cluster partition budget", func() {
+
+	It("sums the partitions of all topics", func() {
+		topics := map[string]client.TopicSpec{
+			"ns_foo":       {NumPartitions: 3},
+			"other-ns_foo": {NumPartitions: 12},
+		}
+
+		Expect(quota.ClusterPartitions(topics)).To(Equal(15))
+	})
+
+	It("refuses partitions beyond the budget", func() {
+		budget := quota.ClusterBudget{MaxPartitions: 100}
+
+		_, err := budget.Check(99, 2)
+
+		Expect(err).To(MatchError("cluster partition budget exceeded: 99 of 100 partitions already in use, 2 requested"))
+	})
+
+	It("only warns about partitions beyond the budget in warn-only mode", func() {
+		budget := quota.ClusterBudget{MaxPartitions: 100, WarnOnly: true}
+
+		warning, err := budget.Check(99, 2)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warning).To(Equal("cluster partition budget exceeded: 99 of 100 partitions already in use, 2 requested"))
+	})
+
+	It("warns when getting close to the budget", func() {
+		budget := quota.ClusterBudget{MaxPartitions: 100, WarnRatio: 0.9}
+
+		warning, err := budget.Check(85, 1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warning).To(BeEmpty())
+
+		warning, err = budget.Check(90, 1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warning).To(Equal("cluster is close to its partition budget: 91 of 100 partitions in use"))
+	})
+})
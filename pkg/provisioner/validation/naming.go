@@ -0,0 +1,114 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Naming maps the namespace and name of streams to the names of a backend, following its naming rules, so that the
+// API of the provisioner stays the same whichever backend streams are provisioned on
+type Naming interface {
+	// Name returns the name of the stream on the backend, failing when its naming rules refuse it
+	Name(namespace, stream string) (string, error)
+	// MaxLength is the longest the namespace and name of a stream may be, joined by an underscore, zero when the
+	// backend sets no limit
+	MaxLength() int
+}
+
+// KafkaNaming names streams after the topics backing them, e.g. my-ns_foo
+type KafkaNaming struct{}
+
+func (KafkaNaming) Name(namespace, stream string) (string, error) {
+	topicName := TopicName(namespace, stream)
+	if err := ValidateTopicName(topicName); err != nil {
+		return "", err
+	}
+	return topicName, nil
+}
+
+func (KafkaNaming) MaxLength() int {
+	return MaxTopicNameLength
+}
+
+var pulsarName = regexp.MustCompile(`^[-=:.\w]+$`)
+
+// PulsarNaming names streams after persistent topics, the namespace of a stream being a Pulsar namespace of the
+// tenant, e.g. persistent://riff/my-ns/foo
+type PulsarNaming struct {
+	Tenant string
+}
+
+func (n PulsarNaming) Name(namespace, stream string) (string, error) {
+	for _, name := range []string{namespace, stream} {
+		if !pulsarName.MatchString(name) {
+			return "", fmt.Errorf("name %q contains characters other than alphanumerics, '_', '-', '=', ':' and '.'", name)
+		}
+	}
+	return fmt.Sprintf("persistent://%s/%s/%s", n.Tenant, namespace, stream), nil
+}
+
+func (PulsarNaming) MaxLength() int {
+	return 0
+}
+
+// MaxJetStreamNameLength is the longest JetStream stream name, streams being stored in directories of their name
+const MaxJetStreamNameLength = 255
+
+// JetStreamNaming names streams like Kafka topics, each capturing the subject of its namespace and name, e.g.
+// riff.my-ns.foo
+type JetStreamNaming struct {
+	// SubjectPrefix, when set, prefixes the subjects of streams
+	SubjectPrefix string
+}
+
+func (JetStreamNaming) Name(namespace, stream string) (string, error) {
+	// dots and wildcards would make the subject of a stream capture those of others
+	if strings.ContainsAny(namespace+stream, ".*>/\\ \t") {
+		return "", fmt.Errorf("%q of namespace %q can't name a JetStream subject", stream, namespace)
+	}
+	name := TopicName(namespace, stream)
+	if len(name) > MaxJetStreamNameLength {
+		return "", fmt.Errorf("stream name %q is %d characters long, the maximum is %d", name, len(name), MaxJetStreamNameLength)
+	}
+	return name, nil
+}
+
+// Subject returns the subject captured by the stream of a namespace and name, that Name accepts
+func (n JetStreamNaming) Subject(namespace, stream string) string {
+	subject := namespace + "." + stream
+	if n.SubjectPrefix != "" {
+		subject = n.SubjectPrefix + "." + subject
+	}
+	return subject
+}
+
+func (JetStreamNaming) MaxLength() int {
+	return MaxJetStreamNameLength
+}
+
+// MaxPubSubIDLength is the longest ID of Pub/Sub topics and subscriptions
+const MaxPubSubIDLength = 255
+
+var pubSubID = regexp.MustCompile(`^[a-zA-Z][-a-zA-Z0-9._~+%]{2,}$`)
+
+// PubSubNaming names streams after the IDs of topics and subscriptions, prefixed like riff_my-ns_foo
+type PubSubNaming struct {
+	// Prefix prefixes the IDs, which must start with a letter
+	Prefix string
+}
+
+func (n PubSubNaming) Name(namespace, stream string) (string, error) {
+	id := n.Prefix + TopicName(namespace, stream)
+	if len(id) > MaxPubSubIDLength {
+		return "", fmt.Errorf("ID %q is %d characters long, the maximum is %d", id, len(id), MaxPubSubIDLength)
+	}
+	if !pubSubID.MatchString(id) || strings.HasPrefix(id, "goog") {
+		return "", fmt.Errorf("ID %q should start with a letter, but not \"goog\", and contain only ASCII alphanumerics, '-', '_', '.', '~', '+' and '%%'", id)
+	}
+	return id, nil
+}
+
+func (n PubSubNaming) MaxLength() int {
+	return MaxPubSubIDLength - len(n.Prefix)
+}
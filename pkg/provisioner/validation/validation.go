@@ -0,0 +1,202 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+)
+
+// MaxTopicNameLength is the longest topic name Kafka accepts
+const MaxTopicNameLength = 249
+
+var legalTopicName = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// TopicName returns the name of the topic backing the given stream. Namespaces that aren't kubernetes namespace
+// names are escaped, so that two different streams never map to the same topic.
+func TopicName(namespace, stream string) string {
+	// NOTE: choice of underscore as separator is important as it is not allowed in k8s names
+	return fmt.Sprintf("%s_%s", EscapeNamespace(namespace), stream)
+}
+
+// EscapeNamespace returns the prefix of the topics of a namespace: kubernetes namespace names as they are, other
+// namespaces with each byte but lowercase alphanumerics written as '-' and two hex digits, and a trailing '-' that no
+// kubernetes namespace name has. The namespace "a_b" is escaped as "a-5fb-", never as a prefix containing the
+// separator, whose stream "c" would otherwise collide with the stream "b_c" of the namespace "a".
+func EscapeNamespace(namespace string) string {
+	if namespaceName.MatchString(namespace) {
+		return namespace
+	}
+	var escaped strings.Builder
+	for i := 0; i < len(namespace); i++ {
+		if c := namespace[i]; c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+			escaped.WriteByte(c)
+		} else {
+			fmt.Fprintf(&escaped, "-%02x", c)
+		}
+	}
+	escaped.WriteByte('-')
+	return escaped.String()
+}
+
+// unescapeNamespace reverses EscapeNamespace for prefixes that aren't kubernetes namespace names, false when the
+// prefix isn't one it returns
+func unescapeNamespace(prefix string) (string, bool) {
+	escaped, ok := strings.CutSuffix(prefix, "-")
+	if !ok {
+		return "", false
+	}
+	var namespace strings.Builder
+	for i := 0; i < len(escaped); i++ {
+		c := escaped[i]
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+			namespace.WriteByte(c)
+			continue
+		}
+		if c != '-' || i+2 >= len(escaped) {
+			return "", false
+		}
+		decoded, err := strconv.ParseUint(escaped[i+1:i+3], 16, 8)
+		if err != nil || escaped[i+1:i+3] != fmt.Sprintf("%02x", decoded) {
+			return "", false
+		}
+		namespace.WriteByte(byte(decoded))
+		i += 2
+	}
+	// escaping is injective only as long as kubernetes namespace names are left as they are
+	if namespaceName.MatchString(namespace.String()) {
+		return "", false
+	}
+	return namespace.String(), true
+}
+
+// TransactionalIDPrefix returns the prefix of the transactional ids reserved for a processor. Underscores not being
+// allowed in k8s names, the prefix of a processor never starts those of another.
+func TransactionalIDPrefix(namespace, processor string) string {
+	return fmt.Sprintf("%s_%s_", EscapeNamespace(namespace), processor)
+}
+
+var namespaceName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// ParseTopicName returns the stream a topic backs, or false for topics that don't back a stream, such as Kafka's
+// internal topics or those mirrored from another cluster, whose prefix is neither a kubernetes namespace nor an
+// escaped namespace
+func ParseTopicName(topicName string) (namespace, stream string, ok bool) {
+	prefix, stream, ok := strings.Cut(topicName, "_")
+	if !ok || stream == "" {
+		return "", "", false
+	}
+	if namespaceName.MatchString(prefix) {
+		return prefix, stream, true
+	}
+	if namespace, ok = unescapeNamespace(prefix); !ok {
+		return "", "", false
+	}
+	return namespace, stream, true
+}
+
+// ValidateNamespace checks that a namespace is a kubernetes namespace name, that topics of streams are prefixed with
+func ValidateNamespace(namespace string) error {
+	if !namespaceName.MatchString(namespace) {
+		return fmt.Errorf("namespace %q should be a kubernetes namespace name", namespace)
+	}
+	return nil
+}
+
+// ValidateTopicName checks that Kafka accepts the given topic name
+func ValidateTopicName(topicName string) error {
+	if len(topicName) > MaxTopicNameLength {
+		return fmt.Errorf("topic name %q is %d characters long, the maximum is %d", topicName, len(topicName), MaxTopicNameLength)
+	}
+	if !legalTopicName.MatchString(topicName) {
+		return fmt.Errorf("topic name %q contains characters other than ASCII alphanumerics, '.', '_' and '-'", topicName)
+	}
+	return nil
+}
+
+// Rules restrict the specs of the topics that may be provisioned
+type Rules struct {
+	// MaxPartitions is the maximum number of partitions of a topic, unlimited when 0
+	MaxPartitions int32
+	// AllowedConfigs lists the topic configs that may be set, any config is allowed when empty
+	AllowedConfigs []string
+}
+
+// ValidateSpec checks a topic spec against the rules, reporting all violations at once
+func (r Rules) ValidateSpec(spec client.TopicSpec) error {
+	var violations []string
+	if spec.NumPartitions < 1 && spec.NumPartitions != client.BrokerDefault {
+		violations = append(violations, fmt.Sprintf("partitions should be at least 1, or -1 for the broker default, got %d", spec.NumPartitions))
+	}
+	if r.MaxPartitions > 0 && spec.NumPartitions > r.MaxPartitions {
+		violations = append(violations, fmt.Sprintf("partitions should be at most %d, got %d", r.MaxPartitions, spec.NumPartitions))
+	}
+	if spec.ReplicationFactor < 1 && spec.ReplicationFactor != client.BrokerDefault {
+		violations = append(violations, fmt.Sprintf("replication factor should be at least 1, or -1 for the broker default, got %d", spec.ReplicationFactor))
+	}
+	if len(r.AllowedConfigs) > 0 {
+		var disallowed []string
+		for key := range spec.ConfigEntries {
+			if !r.allows(key) {
+				disallowed = append(disallowed, key)
+			}
+		}
+		if len(disallowed) > 0 {
+			sort.Strings(disallowed)
+			violations = append(violations, fmt.Sprintf("configs %s are not allowed", strings.Join(disallowed, ", ")))
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("invalid topic spec: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+func (r Rules) allows(key string) bool {
+	for _, allowed := range r.AllowedConfigs {
+		if key == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateConfigs checks config entries against the configs topics take on the cluster, reporting all invalid
+// entries at once. Any entry is valid when the configs are unknown.
+func ValidateConfigs(keys client.TopicConfigKeys, configEntries map[string]*string) error {
+	if keys == nil {
+		return nil
+	}
+	names := make([]string, 0, len(configEntries))
+	for name := range configEntries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var invalid []string
+	for _, name := range names {
+		key, ok := keys[name]
+		value := configEntries[name]
+		switch {
+		case !ok:
+			invalid = append(invalid, fmt.Sprintf("%s is not a topic config", name))
+		case key.ReadOnly:
+			invalid = append(invalid, fmt.Sprintf("%s is read-only", name))
+		case value != nil && !key.Accepts(*value):
+			invalid = append(invalid, fmt.Sprintf("%s should be %s %s, got %q", name, article(key.Kind), key.Kind, *value))
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid topic configs: %s", strings.Join(invalid, "; "))
+	}
+	return nil
+}
+
+func article(kind client.ConfigKind) string {
+	if kind == client.ConfigInteger {
+		return "an"
+	}
+	return "a"
+}
@@ -0,0 +1,173 @@
+package validation_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+var _ = Describe("Validation", func() {
+
+	Describe("topic names", func() {
+		It("accepts names built from kubernetes names", func() {
+			Expect(validation.ValidateTopicName(validation.TopicName("my-ns", "my.stream"))).To(Succeed())
+		})
+
+		It("rejects names that are too long", func() {
+			name := validation.TopicName("ns", strings.Repeat("a", 247))
+
+			Expect(validation.ValidateTopicName(name)).To(MatchError(ContainSubstring("is 250 characters long, the maximum is 249")))
+		})
+
+		It("rejects names with illegal characters", func() {
+			Expect(validation.ValidateTopicName("ns_stream?")).To(MatchError(ContainSubstring("contains characters other than")))
+		})
+
+		It("tells the stream of topics", func() {
+			namespace, stream, ok := validation.ParseTopicName(validation.TopicName("my-ns", "my_stream"))
+
+			Expect(ok).To(BeTrue())
+			Expect([]string{namespace, stream}).To(Equal([]string{"my-ns", "my_stream"}))
+		})
+
+		It("escapes namespaces that aren't kubernetes namespace names", func() {
+			Expect(validation.TopicName("a_b", "c")).To(Equal("a-5fb-_c"))
+			Expect(validation.TopicName("a", "b_c")).To(Equal("a_b_c"))
+			Expect(validation.TopicName("My.ns-", "c")).To(Equal("-4dy-2ens-2d-_c"))
+
+			for _, namespace := range []string{"a_b", "My.ns-", "a-5fb-", ""} {
+				parsed, stream, ok := validation.ParseTopicName(validation.TopicName(namespace, "c"))
+				Expect(ok).To(BeTrue(), namespace)
+				Expect([]string{parsed, stream}).To(Equal([]string{namespace, "c"}))
+			}
+		})
+
+		It("tells apart topics not backing streams", func() {
+			for _, name := range []string{"__consumer_offsets", "riff-stream-metadata", "dr.my-ns_stream", "my-ns_", "a-5-_c", "a-5Fb-_c", "a-_c", "ab-61-_c"} {
+				_, _, ok := validation.ParseTopicName(name)
+				Expect(ok).To(BeFalse(), name)
+			}
+		})
+	})
+
+	Describe("naming", func() {
+		It("names streams after Kafka topics", func() {
+			Expect(validation.KafkaNaming{}.Name("my-ns", "foo")).To(Equal("my-ns_foo"))
+			_, err := validation.KafkaNaming{}.Name("ns", strings.Repeat("a", 247))
+			Expect(err).To(MatchError(ContainSubstring("the maximum is 249")))
+		})
+
+		It("names streams after Pulsar topics of the tenant", func() {
+			naming := validation.PulsarNaming{Tenant: "riff"}
+
+			Expect(naming.Name("my-ns", "foo")).To(Equal("persistent://riff/my-ns/foo"))
+			_, err := naming.Name("my-ns", "foo bar")
+			Expect(err).To(MatchError(ContainSubstring(`name "foo bar" contains characters other than`)))
+			Expect(naming.MaxLength()).To(BeZero())
+		})
+
+		It("names streams after JetStream streams capturing a subject", func() {
+			naming := validation.JetStreamNaming{SubjectPrefix: "riff"}
+
+			Expect(naming.Name("my-ns", "foo")).To(Equal("my-ns_foo"))
+			Expect(naming.Subject("my-ns", "foo")).To(Equal("riff.my-ns.foo"))
+			Expect(validation.JetStreamNaming{}.Subject("my-ns", "foo")).To(Equal("my-ns.foo"))
+		})
+
+		It("refuses JetStream names capturing other subjects", func() {
+			for _, stream := range []string{"foo.bar", "foo*", "foo>", "foo bar"} {
+				_, err := validation.JetStreamNaming{}.Name("my-ns", stream)
+				Expect(err).To(MatchError(ContainSubstring("can't name a JetStream subject")), stream)
+			}
+		})
+
+		It("names streams after Pub/Sub IDs", func() {
+			naming := validation.PubSubNaming{Prefix: "riff_"}
+
+			Expect(naming.Name("my-ns", "foo")).To(Equal("riff_my-ns_foo"))
+			Expect(naming.MaxLength()).To(Equal(250))
+			_, err := naming.Name("ns", strings.Repeat("a", 248))
+			Expect(err).To(MatchError(ContainSubstring("is 256 characters long, the maximum is 255")))
+		})
+
+		It("refuses Pub/Sub IDs not starting with a letter, or starting with goog", func() {
+			for _, namespace := range []string{"0-ns", "google"} {
+				_, err := validation.PubSubNaming{}.Name(namespace, "foo")
+				Expect(err).To(MatchError(ContainSubstring("should start with a letter")), namespace)
+			}
+		})
+	})
+
+	Describe("topic specs", func() {
+		var value = "compact"
+
+		It("accepts the default spec without rules", func() {
+			Expect(validation.Rules{}.ValidateSpec(client.DefaultTopicSpec())).To(Succeed())
+		})
+
+		It("reports all violations", func() {
+			rules := validation.Rules{MaxPartitions: 4, AllowedConfigs: []string{"retention.ms"}}
+			spec := client.TopicSpec{
+				NumPartitions:     8,
+				ReplicationFactor: 0,
+				ConfigEntries:     map[string]*string{"cleanup.policy": &value, "retention.ms": &value},
+			}
+
+			Expect(rules.ValidateSpec(spec)).To(MatchError("invalid topic spec: partitions should be at most 4, got 8; " +
+				"replication factor should be at least 1, or -1 for the broker default, got 0; configs cleanup.policy are not allowed"))
+		})
+
+		It("accepts the defaults of the broker", func() {
+			spec := client.TopicSpec{NumPartitions: client.BrokerDefault, ReplicationFactor: client.BrokerDefault}
+
+			Expect(validation.Rules{MaxPartitions: 4}.ValidateSpec(spec)).To(Succeed())
+		})
+	})
+
+	Describe("topic configs", func() {
+		keys := client.TopicConfigKeys{
+			"cleanup.policy":            {Kind: client.ConfigString},
+			"retention.ms":              {Kind: client.ConfigInteger},
+			"min.cleanable.dirty.ratio": {Kind: client.ConfigNumber},
+			"preallocate":               {Kind: client.ConfigBoolean},
+			"message.format.version":    {ReadOnly: true, Kind: client.ConfigString},
+		}
+		entries := func(entries map[string]string) map[string]*string {
+			configEntries := make(map[string]*string, len(entries))
+			for name, value := range entries {
+				value := value
+				configEntries[name] = &value
+			}
+			return configEntries
+		}
+
+		It("accepts the configs topics take", func() {
+			Expect(validation.ValidateConfigs(keys, entries(map[string]string{
+				"cleanup.policy":            "compact",
+				"retention.ms":              "-1",
+				"min.cleanable.dirty.ratio": "1",
+				"preallocate":               "TRUE",
+			}))).To(Succeed())
+		})
+
+		It("reports all invalid entries", func() {
+			err := validation.ValidateConfigs(keys, entries(map[string]string{
+				"retention.ms":           "a week",
+				"retention.mss":          "604800000",
+				"preallocate":            "yes",
+				"message.format.version": "2.8",
+			}))
+
+			Expect(err).To(MatchError("invalid topic configs: message.format.version is read-only; " +
+				"preallocate should be a boolean, got \"yes\"; retention.ms should be an integer, got \"a week\"; " +
+				"retention.mss is not a topic config"))
+		})
+
+		It("accepts any entry while the configs are unknown", func() {
+			Expect(validation.ValidateConfigs(nil, entries(map[string]string{"retention.mss": "1"}))).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,234 @@
+// Package workqueue queues the keys of the objects the stream controller reconciles, retrying those that fail with
+// an exponential backoff and a global rate limit, so that a broker going down doesn't get hammered by retries
+package workqueue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter delays the retries of keys, each key backing off exponentially from BaseDelay up to MaxDelay as its
+// retries keep failing, and all retries sharing a bucket refilled with QPS tokens per second and holding up to Burst
+type RateLimiter struct {
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	QPS       float64
+	Burst     int
+
+	mu       sync.Mutex
+	failures map[string]int
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+}
+
+// NewRateLimiter creates a rate limiter backing keys off from base up to max, and retrying qps keys per second
+// after a burst of burst keys
+func NewRateLimiter(base, max time.Duration, qps float64, burst int) *RateLimiter {
+	return &RateLimiter{BaseDelay: base, MaxDelay: max, QPS: qps, Burst: burst, failures: map[string]int{}, tokens: float64(burst), now: time.Now}
+}
+
+// DefaultRateLimiter backs keys off from a second up to five minutes, retrying ten keys per second after a burst
+// of a hundred
+func DefaultRateLimiter() *RateLimiter {
+	return NewRateLimiter(time.Second, 5*time.Minute, 10, 100)
+}
+
+// When returns how long to wait before retrying a key, counting a failure of the key
+func (r *RateLimiter) When(key string) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failures := r.failures[key]
+	r.failures[key] = failures + 1
+	backoff := r.MaxDelay
+	if failures < 32 {
+		if exp := r.BaseDelay << failures; exp > 0 && exp < r.MaxDelay {
+			backoff = exp
+		}
+	}
+	if limit := r.reserve(); limit > backoff {
+		return limit
+	}
+	return backoff
+}
+
+// reserve takes a token of the bucket, returning how long until it is available
+func (r *RateLimiter) reserve() time.Duration {
+	if r.QPS <= 0 {
+		return 0
+	}
+	now := r.now()
+	if !r.last.IsZero() {
+		r.tokens += now.Sub(r.last).Seconds() * r.QPS
+		if r.tokens > float64(r.Burst) {
+			r.tokens = float64(r.Burst)
+		}
+	}
+	r.last = now
+	r.tokens--
+	if r.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-r.tokens / r.QPS * float64(time.Second))
+}
+
+// Forget resets the backoff of a key once it succeeds
+func (r *RateLimiter) Forget(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.failures, key)
+}
+
+// NumRequeues returns how many times a key failed in a row
+func (r *RateLimiter) NumRequeues(key string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failures[key]
+}
+
+// Queue hands out keys to workers, each key being queued once however many times it is added, and never handed to
+// two workers at once: keys added while processed are queued again once done
+type Queue struct {
+	limiter *RateLimiter
+
+	mu         sync.Mutex
+	queue      []string
+	dirty      map[string]bool
+	processing map[string]bool
+	// waiting are the keys to retry once their backoff is over
+	waiting  map[string]*time.Timer
+	shutdown bool
+	ready    chan struct{}
+}
+
+// New creates a queue retrying the keys that fail as limiter tells
+func New(limiter *RateLimiter) *Queue {
+	return &Queue{
+		limiter:    limiter,
+		dirty:      map[string]bool{},
+		processing: map[string]bool{},
+		waiting:    map[string]*time.Timer{},
+		ready:      make(chan struct{}, 1),
+	}
+}
+
+// Add queues a key, unless already queued or waiting to be retried, the backoff of keys failing being honored
+func (q *Queue) Add(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.waiting[key]; ok {
+		return
+	}
+	q.add(key)
+}
+
+func (q *Queue) add(key string) {
+	if q.shutdown || q.dirty[key] {
+		return
+	}
+	q.dirty[key] = true
+	if q.processing[key] {
+		return
+	}
+	q.queue = append(q.queue, key)
+	q.signal()
+}
+
+// signal wakes a worker up
+func (q *Queue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// AddRateLimited queues a key that failed once its backoff is over
+func (q *Queue) AddRateLimited(key string) {
+	delay := q.limiter.When(key)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.shutdown {
+		return
+	}
+	if _, ok := q.waiting[key]; ok {
+		return
+	}
+	q.waiting[key] = time.AfterFunc(delay, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		delete(q.waiting, key)
+		q.add(key)
+	})
+}
+
+// Forget resets the backoff of a key once it succeeds
+func (q *Queue) Forget(key string) {
+	q.limiter.Forget(key)
+}
+
+// NumRequeues returns how many times a key failed in a row
+func (q *Queue) NumRequeues(key string) int {
+	return q.limiter.NumRequeues(key)
+}
+
+// Get waits for a key to process, returning false once the queue is shut down or ctx is done. Workers call Done
+// once they processed the key.
+func (q *Queue) Get(ctx context.Context) (string, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.queue) > 0 {
+			key := q.queue[0]
+			q.queue = q.queue[1:]
+			q.processing[key] = true
+			delete(q.dirty, key)
+			if len(q.queue) > 0 {
+				q.signal()
+			}
+			q.mu.Unlock()
+			return key, true
+		}
+		if q.shutdown {
+			// wakes the next worker up in turn
+			q.signal()
+			q.mu.Unlock()
+			return "", false
+		}
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return "", false
+		case <-q.ready:
+		}
+	}
+}
+
+// Done tells that a key was processed, queuing it again when added in the meantime
+func (q *Queue) Done(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.processing, key)
+	if q.dirty[key] {
+		q.queue = append(q.queue, key)
+		q.signal()
+	}
+}
+
+// Len returns the number of keys queued, those being processed or waiting to be retried left out
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.queue)
+}
+
+// ShutDown stops handing out keys once those queued are, and drops the retries pending
+func (q *Queue) ShutDown() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.shutdown = true
+	for key, timer := range q.waiting {
+		timer.Stop()
+		delete(q.waiting, key)
+	}
+	q.signal()
+}
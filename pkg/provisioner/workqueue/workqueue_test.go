@@ -0,0 +1,120 @@
+package workqueue_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/workqueue"
+)
+
+var _ = Describe("Rate limiter", func() {
+
+	It("backs keys off exponentially up to the max delay", func() {
+		limiter := workqueue.NewRateLimiter(time.Millisecond, 8*time.Millisecond, 0, 0)
+
+		var delays []time.Duration
+		for i := 0; i < 5; i++ {
+			delays = append(delays, limiter.When("some-key"))
+		}
+
+		Expect(delays).To(Equal([]time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 8 * time.Millisecond, 8 * time.Millisecond}))
+		Expect(limiter.NumRequeues("some-key")).To(Equal(5))
+		Expect(limiter.When("other-key")).To(Equal(time.Millisecond))
+	})
+
+	It("starts over once keys are forgotten", func() {
+		limiter := workqueue.NewRateLimiter(time.Millisecond, time.Second, 0, 0)
+		limiter.When("some-key")
+		limiter.When("some-key")
+
+		limiter.Forget("some-key")
+
+		Expect(limiter.NumRequeues("some-key")).To(BeZero())
+		Expect(limiter.When("some-key")).To(Equal(time.Millisecond))
+	})
+
+	It("delays retries beyond the burst of the global rate limit", func() {
+		limiter := workqueue.NewRateLimiter(time.Nanosecond, time.Nanosecond, 1, 2)
+
+		Expect(limiter.When("a")).To(Equal(time.Nanosecond))
+		Expect(limiter.When("b")).To(Equal(time.Nanosecond))
+		Expect(limiter.When("c")).To(BeNumerically("~", time.Second, 50*time.Millisecond))
+	})
+})
+
+var _ = Describe("Queue", func() {
+
+	var (
+		queue *workqueue.Queue
+		ctx   context.Context
+	)
+
+	BeforeEach(func() {
+		queue = workqueue.New(workqueue.NewRateLimiter(10*time.Millisecond, time.Second, 0, 0))
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		queue.ShutDown()
+	})
+
+	It("queues keys once however many times they are added", func() {
+		queue.Add("a")
+		queue.Add("b")
+		queue.Add("a")
+
+		Expect(queue.Len()).To(Equal(2))
+		key, ok := queue.Get(ctx)
+		Expect(ok).To(BeTrue())
+		Expect(key).To(Equal("a"))
+	})
+
+	It("queues keys added while processed again once done", func() {
+		queue.Add("a")
+		key, _ := queue.Get(ctx)
+
+		queue.Add(key)
+		Expect(queue.Len()).To(BeZero())
+
+		queue.Done(key)
+		Expect(queue.Len()).To(Equal(1))
+	})
+
+	It("retries keys once their backoff is over", func() {
+		queue.Add("a")
+		key, _ := queue.Get(ctx)
+
+		queue.AddRateLimited(key)
+		queue.Done(key)
+		start := time.Now()
+
+		key, ok := queue.Get(ctx)
+		Expect(ok).To(BeTrue())
+		Expect(key).To(Equal("a"))
+		Expect(time.Since(start)).To(BeNumerically(">=", 5*time.Millisecond))
+		Expect(queue.NumRequeues("a")).To(Equal(1))
+	})
+
+	It("leaves keys waiting to be retried to their backoff", func() {
+		queue = workqueue.New(workqueue.NewRateLimiter(time.Hour, time.Hour, 0, 0))
+		queue.AddRateLimited("a")
+
+		queue.Add("a")
+
+		Expect(queue.Len()).To(BeZero())
+	})
+
+	It("stops handing out keys once shut down", func() {
+		done := make(chan bool)
+		go func() {
+			_, ok := queue.Get(ctx)
+			done <- ok
+		}()
+
+		queue.ShutDown()
+
+		Eventually(done).Should(Receive(BeFalse()))
+	})
+})
@@ -0,0 +1,117 @@
+// Package webhook implements a Kubernetes validating admission webhook rejecting riff Streams
+// that the provisioner would fail to provision
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	client "github.com/projectriff/kafka-provisioner/pkg/provisioner/kafka"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+)
+
+// AdmissionReview mirrors the admission.k8s.io/v1 AdmissionReview resource, restricted to the fields used here
+type AdmissionReview struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Request    *AdmissionRequest  `json:"request,omitempty"`
+	Response   *AdmissionResponse `json:"response,omitempty"`
+}
+
+type AdmissionRequest struct {
+	UID       string          `json:"uid"`
+	Namespace string          `json:"namespace"`
+	Operation string          `json:"operation"`
+	Object    json.RawMessage `json:"object"`
+}
+
+type AdmissionResponse struct {
+	UID     string  `json:"uid"`
+	Allowed bool    `json:"allowed"`
+	Result  *Status `json:"status,omitempty"`
+}
+
+type Status struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+}
+
+// stream holds the parts of a riff Stream relevant to provisioning. Unset fields fall back to the provisioner defaults.
+type stream struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Partitions        int32             `json:"partitions"`
+		ReplicationFactor int16             `json:"replicationFactor"`
+		Config            map[string]string `json:"config"`
+	} `json:"spec"`
+}
+
+type StreamValidator struct {
+	Rules  validation.Rules
+	Logger *slog.Logger
+}
+
+func (sv *StreamValidator) GetHandlerFunc() http.HandlerFunc {
+	return func(responseWriter http.ResponseWriter, request *http.Request) {
+		review := AdmissionReview{}
+		if err := json.NewDecoder(request.Body).Decode(&review); err != nil || review.Request == nil {
+			responseWriter.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(responseWriter, "Request body should be an AdmissionReview\n")
+			return
+		}
+
+		response := &AdmissionResponse{UID: review.Request.UID, Allowed: true}
+		if err := sv.validate(review.Request); err != nil {
+			sv.Logger.Info("Rejecting stream", "namespace", review.Request.Namespace, "error", err)
+			response.Allowed = false
+			response.Result = &Status{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		}
+
+		responseWriter.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(responseWriter).Encode(AdmissionReview{
+			APIVersion: review.APIVersion,
+			Kind:       review.Kind,
+			Response:   response,
+		}); err != nil {
+			sv.Logger.Error("Failed to write json response", "error", err)
+		}
+	}
+}
+
+func (sv *StreamValidator) validate(request *AdmissionRequest) error {
+	if request.Operation == "DELETE" {
+		return nil
+	}
+	s := stream{}
+	if err := json.Unmarshal(request.Object, &s); err != nil {
+		return fmt.Errorf("invalid stream: %v", err)
+	}
+	namespace := s.Metadata.Namespace
+	if namespace == "" {
+		namespace = request.Namespace
+	}
+	if _, err := (validation.KafkaNaming{}).Name(namespace, s.Metadata.Name); err != nil {
+		return err
+	}
+
+	spec := client.DefaultTopicSpec()
+	if s.Spec.Partitions != 0 {
+		spec.NumPartitions = s.Spec.Partitions
+	}
+	if s.Spec.ReplicationFactor != 0 {
+		spec.ReplicationFactor = s.Spec.ReplicationFactor
+	}
+	if len(s.Spec.Config) > 0 {
+		spec.ConfigEntries = make(map[string]*string, len(s.Spec.Config))
+		for key, value := range s.Spec.Config {
+			value := value
+			spec.ConfigEntries[key] = &value
+		}
+	}
+	return sv.Rules.ValidateSpec(spec)
+}
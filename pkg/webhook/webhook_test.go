@@ -0,0 +1,87 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/projectriff/kafka-provisioner/pkg/provisioner/validation"
+	"github.com/projectriff/kafka-provisioner/pkg/webhook"
+)
+
+var _ = Describe("Stream validating webhook", func() {
+
+	var (
+		responseRecorder *httptest.ResponseRecorder
+		handlerFunc      http.HandlerFunc
+	)
+
+	BeforeEach(func() {
+		responseRecorder = httptest.NewRecorder()
+		validator := &webhook.StreamValidator{
+			Rules:  validation.Rules{MaxPartitions: 4, AllowedConfigs: []string{"retention.ms"}},
+			Logger: slog.New(slog.NewTextHandler(ioutil.Discard, nil)),
+		}
+		handlerFunc = validator.GetHandlerFunc()
+	})
+
+	review := func(stream string) *http.Request {
+		body := fmt.Sprintf(`{
+			"apiVersion": "admission.k8s.io/v1",
+			"kind": "AdmissionReview",
+			"request": {"uid": "some-uid", "namespace": "my-ns", "operation": "CREATE", "object": %s}
+		}`, stream)
+		return httptest.NewRequest(http.MethodPost, "/validate-streams", strings.NewReader(body))
+	}
+
+	decode := func() webhook.AdmissionReview {
+		Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		result := webhook.AdmissionReview{}
+		Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &result)).To(Succeed())
+		Expect(result.APIVersion).To(Equal("admission.k8s.io/v1"))
+		Expect(result.Response.UID).To(Equal("some-uid"))
+		return result
+	}
+
+	It("admits valid streams", func() {
+		handlerFunc.ServeHTTP(responseRecorder, review(`{
+			"metadata": {"name": "my-stream"},
+			"spec": {"partitions": 2, "config": {"retention.ms": "1000"}}
+		}`))
+
+		Expect(decode().Response.Allowed).To(BeTrue())
+	})
+
+	It("rejects streams whose topic name would be too long", func() {
+		handlerFunc.ServeHTTP(responseRecorder, review(fmt.Sprintf(`{"metadata": {"name": "%s"}}`, strings.Repeat("a", 250))))
+
+		result := decode()
+		Expect(result.Response.Allowed).To(BeFalse())
+		Expect(result.Response.Result.Code).To(Equal(int32(http.StatusUnprocessableEntity)))
+		Expect(result.Response.Result.Message).To(ContainSubstring("the maximum is 249"))
+	})
+
+	It("rejects streams violating the topic spec rules", func() {
+		handlerFunc.ServeHTTP(responseRecorder, review(`{
+			"metadata": {"name": "my-stream"},
+			"spec": {"partitions": 8, "config": {"cleanup.policy": "compact"}}
+		}`))
+
+		result := decode()
+		Expect(result.Response.Allowed).To(BeFalse())
+		Expect(result.Response.Result.Message).
+			To(Equal("invalid topic spec: partitions should be at most 4, got 8; configs cleanup.policy are not allowed"))
+	})
+
+	It("returns 400 for anything but an AdmissionReview", func() {
+		handlerFunc.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, "/validate-streams", strings.NewReader("{}")))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+	})
+})